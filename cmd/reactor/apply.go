@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/overlay"
+	"github.com/dyluth/reactor/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply read-only workspace changes to the host project",
+		Long: `Apply the changes captured by 'reactor up --read-only-workspace' to the host project.
+
+Each added, changed or deleted file in the workspace overlay is copied onto the
+project directory and then cleared from the overlay. Files matched by the
+project's .gitignore are skipped unless --include-ignored is set. The
+container must be stopped first, e.g. with 'reactor down', as the overlay
+cannot change while it is mounted. A file that cannot be applied is reported
+and left in the overlay, and the others are still applied.
+
+With --interactive, the diff for each file is shown and you are asked to
+confirm it: y applies the file, n skips it, a applies all remaining files and
+q stops. Skipped files stay in the overlay and can be applied later.
+
+Examples:
+  reactor diff --workspace                 # Review what the agent changed
+  reactor apply --interactive              # Confirm changes file-by-file
+  reactor apply                            # Apply all changes
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: applyCmdHandler,
+	}
+
+	cmd.Flags().BoolP("interactive", "i", false, "Confirm each file before applying it")
+	cmd.Flags().Bool("include-ignored", false, "Also apply files matched by .gitignore")
+	cmd.Flags().String("name", "", "Session name of the container (see 'reactor up --name')")
+
+	return cmd
+}
+
+func applyCmdHandler(cmd *cobra.Command, args []string) error {
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	includeIgnored, _ := cmd.Flags().GetBool("include-ignored")
+	sessionName, _ := cmd.Flags().GetString("name")
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+	}
+
+	configService := config.NewService()
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if err := checkOverlayUnmounted(resolved, sessionName); err != nil {
+		return err
+	}
+
+	upperDir, _ := overlay.Dirs(core.SessionConfigDir(resolved.ProjectConfigDir, sessionName))
+	changes, err := overlay.Changes(resolved.ProjectRoot, upperDir)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No changes to apply from read-only workspace.")
+		return nil
+	}
+
+	ignored := map[string]bool{}
+	if !includeIgnored {
+		ignored, err = overlay.IgnoredPaths(resolved.ProjectRoot, changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	reader := bufio.NewReader(cmd.InOrStdin())
+	applyAll := !interactive
+	applied, skipped, failed := 0, 0, 0
+
+	for _, change := range changes {
+		if ignored[change.Path] {
+			fmt.Printf("Skipping ignored file: %s\n", change.Path)
+			skipped++
+			continue
+		}
+
+		if !applyAll {
+			if err := overlay.WritePatch(os.Stdout, resolved.ProjectRoot, upperDir, []overlay.Change{change}); err != nil {
+				return err
+			}
+
+			answer, err := promptApply(reader, change)
+			if err != nil {
+				return err
+			}
+			switch answer {
+			case "q":
+				fmt.Printf("Stopped. Applied %d file(s), skipped %d, %d not yet decided.\n", applied, skipped, len(changes)-applied-skipped-failed)
+				return applyFailures(failed)
+			case "a":
+				applyAll = true
+			case "y":
+			default:
+				skipped++
+				continue
+			}
+		}
+
+		// A file that fails, e.g. as the container's root user owns its copy in the
+		// overlay, does not keep the others from being applied
+		if err := overlay.Apply(resolved.ProjectRoot, upperDir, change); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s %s\n", change.Kind, change.Path)
+		applied++
+	}
+
+	fmt.Printf("%s Applied %d file(s) to %s", ui.Stdout().Success(), applied, resolved.ProjectRoot)
+	if skipped > 0 {
+		fmt.Printf(" (%d skipped)", skipped)
+	}
+	fmt.Println()
+	return applyFailures(failed)
+}
+
+// applyFailures returns the error apply ends with when files could not be applied
+func applyFailures(failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to apply %d file(s); they stay in the overlay", failed)
+}
+
+// checkOverlayUnmounted refuses to change the layers of the session's overlay while its
+// container runs with the overlay mounted, as overlayfs does not allow that
+func checkOverlayUnmounted(resolved *config.ResolvedConfig, sessionName string) error {
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer closeDockerService(dockerService)
+
+	info, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if info.Status == docker.StatusRunning {
+		stop := "reactor down"
+		if sessionName != "" {
+			stop += " --name " + sessionName
+		}
+		return fmt.Errorf("container %s is running with the workspace overlay mounted; stop it with '%s' first, which keeps the changes", containerName, stop)
+	}
+	return nil
+}
+
+// promptApply asks whether to apply a change and returns one of y, n, a or q
+func promptApply(reader *bufio.Reader, change overlay.Change) (string, error) {
+	for {
+		fmt.Printf("Apply %s %s? [y,n,a,q] ", change.Kind, change.Path)
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return "q", nil
+			}
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+
+		switch answer := strings.ToLower(strings.TrimSpace(line)); answer {
+		case "y", "n", "a", "q":
+			return answer, nil
+		case "":
+			return "n", nil
+		}
+		fmt.Println("Please answer y (apply), n (skip), a (apply all remaining) or q (quit).")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/state"
+)
+
+// provisioningPollInterval is how often attach checks whether provisioning has finished
+var provisioningPollInterval = time.Second
+
+// waitForProvisioning waits while 'reactor up' is still installing tools or running
+// lifecycle commands in the container, so attaching does not land in a half-initialized
+// environment. With noWait, or when the up process stopped before finishing, it warns
+// instead.
+func waitForProvisioning(ctx context.Context, containerName, containerID string, noWait bool, out io.Writer) error {
+	waiting := ""
+	for {
+		p, err := state.ProvisioningOf(containerName, containerID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot check whether %s is still being provisioned: %v\n", containerName, err)
+			return nil
+		}
+		if p == nil {
+			if waiting != "" {
+				fmt.Fprintln(out, "Provisioning finished.")
+			}
+			return nil
+		}
+		if !p.Running() {
+			fmt.Fprintf(os.Stderr, "Warning: 'reactor up' stopped while running %s in %s, so the container may be incompletely set up; recreate it with 'reactor down' and 'reactor up' if anything is missing\n", p.Phase, containerName)
+			return nil
+		}
+		if noWait {
+			fmt.Fprintf(os.Stderr, "Warning: %s is still running in %s; attaching before it has finished\n", p.Phase, containerName)
+			return nil
+		}
+		if p.Phase != waiting {
+			fmt.Fprintf(out, "Waiting for %s to finish in %s (provisioning for %s; Ctrl-C to stop, or attach with --no-wait)...\n", p.Phase, containerName, shortDuration(time.Since(p.StartedAt)))
+			waiting = p.Phase
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(provisioningPollInterval):
+		}
+	}
+}
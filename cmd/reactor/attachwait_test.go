@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForProvisioning(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+	provisioningPollInterval = 10 * time.Millisecond
+	defer func() { provisioningPollInterval = time.Second }()
+	ctx := context.Background()
+	const name = "reactor-alice-api-abc123"
+
+	var out bytes.Buffer
+	require.NoError(t, waitForProvisioning(ctx, name, "c1", false, &out))
+	assert.Empty(t, out.String(), "nothing is being provisioned")
+
+	// This process stands in for the 'reactor up' still running postCreateCommand
+	require.NoError(t, state.SetProvisioning(name, "c1", "postCreateCommand"))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = state.FinishProvisioning(name)
+	}()
+	require.NoError(t, waitForProvisioning(ctx, name, "c1", false, &out))
+	assert.Contains(t, out.String(), "Waiting for postCreateCommand to finish in "+name)
+	assert.Contains(t, out.String(), "Provisioning finished.")
+
+	// --no-wait and an interrupted 'reactor up' warn without waiting
+	require.NoError(t, state.SetProvisioning(name, "c1", "tools"))
+	out.Reset()
+	require.NoError(t, waitForProvisioning(ctx, name, "c1", true, &out))
+	assert.Empty(t, out.String())
+
+	s, err := state.Load()
+	require.NoError(t, err)
+	s.Provisioning[name] = state.Provisioning{ID: "c1", Phase: "tools", PID: 0}
+	require.NoError(t, state.Save(s))
+	require.NoError(t, waitForProvisioning(ctx, name, "c1", false, &out))
+	assert.Empty(t, out.String())
+
+	// A cancelled wait stops attaching
+	require.NoError(t, state.SetProvisioning(name, "c1", "postCreateCommand"))
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, waitForProvisioning(cancelled, name, "c1", false, &out), context.Canceled)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+func newCheckpointCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checkpoint",
+		Short: "Save and restore the state of the dev container",
+		Long: `Save the state of the running dev container and roll back to it later.
+
+A checkpoint always saves the container's filesystem by committing it to a local
+image. When the Docker daemon has experimental features enabled and CRIU installed,
+the running processes are checkpointed too and resumed on restore; otherwise the
+container is started fresh from the saved filesystem. Restoring recreates the
+container with the same name, configuration and mounts.
+
+Mounted directories, including the project workspace, are not part of a checkpoint.
+Use version control or 'reactor up --read-only-workspace' to protect project files.
+
+The last 3 checkpoints of each container are kept. 'reactor exec
+--checkpoint-before-exec' takes one automatically before a risky command.
+
+Examples:
+  reactor checkpoint create                # Save the current state
+  reactor checkpoint list                  # Show saved checkpoints
+  reactor checkpoint restore               # Roll back to the latest checkpoint
+  reactor checkpoint restore 20261016-101500
+  reactor checkpoint rm 20261016-101500
+
+For more details, see the full documentation.`,
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Save the state of the running container",
+		Long:  "Save the filesystem (and, where supported, the processes) of the running dev container",
+		Args:  cobra.NoArgs,
+		RunE:  checkpointCreateHandler,
+	}
+	cmd.AddCommand(createCmd)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the container's checkpoints",
+		Long:  "List the checkpoints saved for the dev container, most recent first",
+		Args:  cobra.NoArgs,
+		RunE:  checkpointListHandler,
+	}
+	cmd.AddCommand(listCmd)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [id]",
+		Short: "Roll the container back to a checkpoint",
+		Long:  "Recreate the dev container from a checkpoint, the most recent one by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  checkpointRestoreHandler,
+	}
+	cmd.AddCommand(restoreCmd)
+
+	rmCmd := &cobra.Command{
+		Use:   "rm <id>",
+		Short: "Remove a checkpoint",
+		Long:  "Remove a checkpoint's image and saved process state",
+		Args:  cobra.ExactArgs(1),
+		RunE:  checkpointRmHandler,
+	}
+	cmd.AddCommand(rmCmd)
+
+	for _, sub := range []*cobra.Command{createCmd, listCmd, restoreCmd, rmCmd} {
+		sub.Flags().String("name", "", "Session name of the container")
+	}
+
+	return cmd
+}
+
+// checkpointTarget is the container checkpoint commands operate on
+type checkpointTarget struct {
+	containerName string
+	processDir    string
+	info          docker.ContainerInfo
+	docker        *docker.Service
+}
+
+func resolveCheckpointTarget(ctx context.Context, cmd *cobra.Command) (*checkpointTarget, error) {
+	sessionName, _ := cmd.Flags().GetString("name")
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return nil, err
+		}
+	}
+
+	resolved, err := config.NewService().ResolveConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+
+	dockerService, err := newJobsDockerService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		closeDockerService(dockerService)
+		return nil, fmt.Errorf("failed to check container existence: %w", err)
+	}
+
+	return &checkpointTarget{
+		containerName: containerName,
+		processDir:    config.CheckpointDir(resolved.ProjectConfigDir, containerName),
+		info:          info,
+		docker:        dockerService,
+	}, nil
+}
+
+func checkpointCreateHandler(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	target, err := resolveCheckpointTarget(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(target.docker)
+
+	if target.info.Status != docker.StatusRunning {
+		return fmt.Errorf("container %s is not running. Run 'reactor up' first", target.containerName)
+	}
+	cp, err := target.docker.CreateCheckpoint(ctx, target.info.ID, target.containerName, target.processDir, "")
+	if err != nil {
+		return err
+	}
+	printCheckpointCreated(os.Stdout, cp)
+	return nil
+}
+
+func checkpointListHandler(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	target, err := resolveCheckpointTarget(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(target.docker)
+
+	checkpoints, err := target.docker.ListCheckpoints(ctx, target.containerName)
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		fmt.Printf("No checkpoints for %s. Create one with 'reactor checkpoint create'.\n", target.containerName)
+		return nil
+	}
+
+	fmt.Printf("%-16s %-20s %-10s %-10s %s\n", "ID", "CREATED", "STATE", "SIZE", "BEFORE")
+	for _, cp := range checkpoints {
+		kind := "fs"
+		if cp.Process {
+			kind = "fs+procs"
+		}
+		before := cp.Command
+		if before == "" {
+			before = "-"
+		}
+		fmt.Printf("%-16s %-20s %-10s %-10s %s\n", cp.ID, cp.Created.Local().Format("2006-01-02 15:04:05"), kind, units.HumanSize(float64(cp.Size)), before)
+	}
+	return nil
+}
+
+func checkpointRestoreHandler(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	target, err := resolveCheckpointTarget(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(target.docker)
+
+	if target.info.Status == docker.StatusNotFound {
+		return fmt.Errorf("no container found for project: %s", target.containerName)
+	}
+	id := ""
+	if len(args) == 1 {
+		id = args[0]
+	}
+	cp, err := findCheckpoint(ctx, target, id)
+	if err != nil {
+		return err
+	}
+	return restoreCheckpoint(ctx, os.Stdout, target.docker, target.info.ID, cp, target.processDir)
+}
+
+func checkpointRmHandler(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	target, err := resolveCheckpointTarget(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(target.docker)
+
+	cp, err := findCheckpoint(ctx, target, args[0])
+	if err != nil {
+		return err
+	}
+	if err := target.docker.RemoveCheckpoint(ctx, cp, target.processDir); err != nil {
+		return err
+	}
+	fmt.Printf("Removed checkpoint %s\n", cp.ID)
+	return nil
+}
+
+// findCheckpoint returns the checkpoint with the given ID, or the most recent one for ""
+func findCheckpoint(ctx context.Context, target *checkpointTarget, id string) (docker.Checkpoint, error) {
+	checkpoints, err := target.docker.ListCheckpoints(ctx, target.containerName)
+	if err != nil {
+		return docker.Checkpoint{}, err
+	}
+	if len(checkpoints) == 0 {
+		return docker.Checkpoint{}, fmt.Errorf("no checkpoints for %s", target.containerName)
+	}
+	if id == "" {
+		return checkpoints[0], nil
+	}
+	for _, cp := range checkpoints {
+		if cp.ID == id {
+			return cp, nil
+		}
+	}
+	return docker.Checkpoint{}, fmt.Errorf("checkpoint %s not found for %s. Use 'reactor checkpoint list' to see the available checkpoints", id, target.containerName)
+}
+
+// restoreCheckpoint rolls a container back, reporting progress to out
+func restoreCheckpoint(ctx context.Context, out io.Writer, dockerService *docker.Service, containerID string, cp docker.Checkpoint, processDir string) error {
+	cfg, err := dockerService.InspectContainerConfig(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	// Checkpoints taken before the source image was recorded are judged by their own image
+	image := cp.SourceImage
+	if image == "" {
+		image = cp.ImageID
+	}
+	if err := orchestrator.CheckContainerPolicy(cfg, image); err != nil {
+		return fmt.Errorf("cannot restore checkpoint %s: %w", cp.ID, err)
+	}
+	// The provider directories live in tmpfs mounts the checkpoint does not hold, so
+	// they are saved from the container and decrypted into its replacement
+	info, err := dockerService.ContainerExists(ctx, cp.ContainerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if err := orchestrator.SaveCredentials(ctx, dockerService, info); err != nil {
+		return fmt.Errorf("cannot restore checkpoint %s, as the encrypted credentials cannot be saved: %w", cp.ID, err)
+	}
+	fmt.Fprintf(out, "Restoring %s to checkpoint %s...\n", cp.ContainerName, cp.ID)
+	newID, err := dockerService.RestoreCheckpoint(ctx, containerID, cp, processDir)
+	if err != nil {
+		return fmt.Errorf("failed to restore checkpoint %s: %w", cp.ID, err)
+	}
+	restored, err := dockerService.ContainerExists(ctx, cp.ContainerName)
+	if err == nil && restored.ID != newID {
+		err = fmt.Errorf("container %s is not the restored one", cp.ContainerName)
+	}
+	if err == nil {
+		err = orchestrator.RestoreContainerCredentials(ctx, dockerService, restored)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to restore encrypted credentials into %s: %v\n", cp.ContainerName, err)
+	}
+	fmt.Fprintf(out, "Restored checkpoint %s\n", cp.ID)
+	return nil
+}
+
+func printCheckpointCreated(out io.Writer, cp docker.Checkpoint) {
+	saved := "filesystem"
+	if cp.Process {
+		saved = "filesystem and processes"
+	}
+	fmt.Fprintf(out, "Checkpoint %s saved (%s). Restore it with 'reactor checkpoint restore %s'.\n", cp.ID, saved, cp.ID)
+	if cp.Command != "" {
+		fmt.Fprintf(out, "Taken before: %s\n", strings.TrimSpace(cp.Command))
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// activeSession returns the running container of the project that 'reactor diff' uses
+// by default: the default session when it runs, otherwise the only running session.
+// It is empty when none of the account's sessions of the project is running, and an
+// error when several are and none is the default.
+func activeSession(containers []docker.ContainerInfo, account, defaultName, discoveryName string) (string, error) {
+	var running []string
+	for _, c := range containers {
+		if c.Name == discoveryName || c.Status != docker.StatusRunning {
+			continue
+		}
+		if owner, _ := sessionOwner(c); owner != account {
+			continue
+		}
+		if c.Name == defaultName {
+			return c.Name, nil
+		}
+		running = append(running, c.Name)
+	}
+	switch len(running) {
+	case 0:
+		return "", nil
+	case 1:
+		return running[0], nil
+	}
+	return "", fmt.Errorf("%d sessions of this project are running (%s); choose one with --container", len(running), strings.Join(running, ", "))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveSession(t *testing.T) {
+	session := func(name, account string, status docker.ContainerStatus) docker.ContainerInfo {
+		return docker.ContainerInfo{Name: name, Status: status, Labels: map[string]string{orchestrator.AccountLabel: account, orchestrator.ProjectLabel: "/src/api"}}
+	}
+	discovery := session("reactor-discovery-cam-api", "cam", docker.StatusRunning)
+	defaultSession := session("reactor-cam-api", "cam", docker.StatusRunning)
+	feature := session("reactor-cam-api-feature", "cam", docker.StatusRunning)
+	stopped := session("reactor-cam-api-old", "cam", docker.StatusStopped)
+	otherAccount := session("reactor-work-api", "work", docker.StatusRunning)
+
+	pick := func(containers ...docker.ContainerInfo) (string, error) {
+		return activeSession(containers, "cam", defaultSession.Name, discovery.Name)
+	}
+
+	name, err := pick(discovery, stopped, otherAccount)
+	require.NoError(t, err)
+	assert.Empty(t, name, "only the discovery container, stopped sessions and other accounts' sessions")
+
+	name, err = pick(discovery, feature, stopped)
+	require.NoError(t, err)
+	assert.Equal(t, feature.Name, name)
+
+	name, err = pick(feature, defaultSession)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSession.Name, name, "the default session is preferred")
+
+	_, err = pick(feature, session("reactor-cam-api-bugfix", "cam", docker.StatusRunning))
+	assert.EqualError(t, err, "2 sessions of this project are running (reactor-cam-api-feature, reactor-cam-api-bugfix); choose one with --container")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/spf13/cobra"
+)
+
+// doctorReport is the output of 'reactor doctor --json'
+type doctorReport struct {
+	Endpoint         docker.Endpoint `json:"endpoint"`
+	EndpointError    string          `json:"endpointError,omitempty"`
+	DockerAPIVersion string          `json:"dockerApiVersion,omitempty"`
+	DaemonError      string          `json:"daemonError,omitempty"`
+	DockerCLI        string          `json:"dockerCli,omitempty"`
+	Git              string          `json:"git,omitempty"`
+}
+
+func newDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that reactor can reach Docker and find its tools",
+		Long: `Report the Docker endpoint reactor uses and how it was chosen, whether the
+daemon answers, and where the docker and git commands are.
+
+Without DOCKER_HOST or a docker CLI context, reactor probes the sockets of
+Colima, Rancher Desktop, OrbStack, Docker Desktop and /var/run/docker.sock in
+that order and uses the first one a daemon answers on. Change the order, or add
+socket paths, with dockerSockets in ~/.reactor/config.yaml or
+REACTOR_DOCKER_SOCKETS (comma-separated).
+
+Examples:
+  reactor doctor                                      # Show the chosen endpoint and checks
+  reactor doctor --json                               # The same as JSON
+  REACTOR_DOCKER_SOCKETS=orbstack,colima reactor doctor   # Prefer OrbStack over Colima
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: doctorHandler,
+	}
+	cmd.Flags().Bool("json", false, "Print the report as JSON")
+	return cmd
+}
+
+func doctorHandler(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if !cmd.Flags().Changed("json") {
+		asJSON = userPreferences().JSONOutput()
+	}
+
+	report := runDoctor(context.Background())
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(os.Stdout, report)
+	}
+
+	if report.EndpointError != "" || report.DaemonError != "" || report.DockerCLI == "" {
+		return fmt.Errorf("reactor doctor found problems")
+	}
+	return nil
+}
+
+func runDoctor(ctx context.Context) doctorReport {
+	var report doctorReport
+	endpoint, err := docker.DiscoverEndpoint()
+	if err != nil {
+		report.EndpointError = err.Error()
+	}
+	report.Endpoint = endpoint
+
+	if dockerService, err := docker.NewService(); err != nil {
+		report.DaemonError = err.Error()
+	} else {
+		defer func() { _ = dockerService.Close() }()
+		if version, err := dockerService.APIVersion(ctx); err != nil {
+			report.DaemonError = err.Error()
+		} else {
+			report.DockerAPIVersion = version
+		}
+	}
+
+	report.DockerCLI, _ = exec.LookPath("docker")
+	report.Git, _ = exec.LookPath("git")
+	return report
+}
+
+func printDoctorReport(w io.Writer, report doctorReport) {
+	if report.EndpointError != "" {
+		fmt.Fprintf(w, "Docker endpoint: %s\n", report.EndpointError)
+	} else {
+		fmt.Fprintf(w, "Docker endpoint: %s (from %s)\n", report.Endpoint.Host, report.Endpoint.Source)
+	}
+	for _, probe := range report.Endpoint.Probes {
+		status := "not running"
+		if probe.Live {
+			status = "answering"
+		}
+		fmt.Fprintf(w, "  %-16s %-50s %s\n", probe.Runtime, probe.Path, status)
+	}
+
+	if report.DaemonError != "" {
+		fmt.Fprintf(w, "Docker daemon:   not reachable: %s\n", report.DaemonError)
+	} else {
+		fmt.Fprintf(w, "Docker daemon:   reachable (API %s)\n", report.DockerAPIVersion)
+	}
+	fmt.Fprintf(w, "docker command:  %s\n", foundOrMissing(report.DockerCLI, "not found; install Docker"))
+	fmt.Fprintf(w, "git command:     %s\n", foundOrMissing(report.Git, "not found; project hashes and some features need it"))
+}
+
+func foundOrMissing(path, missing string) string {
+	if path == "" {
+		return missing
+	}
+	return path
+}
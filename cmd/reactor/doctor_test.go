@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintDoctorReport(t *testing.T) {
+	var out bytes.Buffer
+	printDoctorReport(&out, doctorReport{
+		Endpoint: docker.Endpoint{
+			Host:   "unix:///Users/me/.orbstack/run/docker.sock",
+			Source: "orbstack",
+			Probes: []docker.SocketProbe{
+				{Runtime: "colima", Path: "/Users/me/.colima/default/docker.sock"},
+				{Runtime: "orbstack", Path: "/Users/me/.orbstack/run/docker.sock", Live: true},
+			},
+		},
+		DockerAPIVersion: "1.47",
+		DockerCLI:        "/usr/local/bin/docker",
+	})
+
+	assert.Contains(t, out.String(), "Docker endpoint: unix:///Users/me/.orbstack/run/docker.sock (from orbstack)\n")
+	assert.Regexp(t, `colima +/Users/me/.colima/default/docker.sock +not running`, out.String())
+	assert.Regexp(t, `orbstack +/Users/me/.orbstack/run/docker.sock +answering`, out.String())
+	assert.Contains(t, out.String(), "Docker daemon:   reachable (API 1.47)")
+	assert.Contains(t, out.String(), "git command:     not found")
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newEnvCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env [service]",
+		Short: "Show the environment a dev container will receive",
+		Long: `Print the fully resolved environment variables for the dev container.
+
+Variables are merged in order of precedence (later wins):
+  1. containerEnv from devcontainer.json (${localEnv:VAR} is expanded)
+  2. the account env file (~/.reactor/<account>/account.env)
+  3. files given with --env-file, in order (and env_file of a workspace service first)
+  4. -e/--env overrides given on the command line
+
+Values of variables that look like credentials (names containing TOKEN, SECRET,
+PASSWORD, API_KEY, ...) are masked unless --show-secrets is given.
+
+With a service name, the service is looked up in the workspace file and its
+directory and account are used instead of the current project.
+
+Examples:
+  reactor env                              # Show environment as dotenv
+  reactor env --format json                # Show environment as JSON with sources
+  reactor env -e LOG_LEVEL=debug           # Preview the effect of an override
+  reactor env --env-file .env              # Preview the effect of a dotenv file
+  reactor env api                          # Show environment for a workspace service
+
+For more details, see the full documentation.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: envCmdHandler,
+	}
+
+	cmd.Flags().String("format", "dotenv", "Output format: dotenv or json")
+	cmd.Flags().Bool("show-secrets", false, "Show secret values instead of masking them")
+	cmd.Flags().StringArrayP("env", "e", []string{}, "Set an environment variable (KEY=VALUE), can be used multiple times")
+	cmd.Flags().StringArray("env-file", []string{}, "Load environment variables from a dotenv file, can be used multiple times")
+	cmd.Flags().StringP("file", "f", "", "Path to workspace file when a service is given (default: reactor-workspace.yml)")
+
+	return cmd
+}
+
+func envCmdHandler(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	accountOverride, _ := cmd.Flags().GetString("account")
+	envOverrides, _ := cmd.Flags().GetStringArray("env")
+	envFiles, _ := cmd.Flags().GetStringArray("env-file")
+	workspaceFile, _ := cmd.Flags().GetString("file")
+
+	if format != "dotenv" && format != "json" {
+		return fmt.Errorf("invalid format '%s': must be 'dotenv' or 'json'", format)
+	}
+
+	projectDirectory, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	if len(args) == 1 {
+		service, err := resolveWorkspaceService(workspaceFile, args[0])
+		if err != nil {
+			return err
+		}
+		projectDirectory = service.Path
+		if accountOverride == "" {
+			accountOverride = service.Account
+		}
+		if service.EnvFile != "" {
+			envFiles = append([]string{service.EnvFile}, envFiles...)
+		}
+	}
+
+	configService := config.NewServiceWithRoot(projectDirectory)
+	if len(args) == 0 {
+		configName, _ := cmd.Flags().GetString("config")
+		configService.SetConfigName(configName)
+	}
+	if accountOverride != "" {
+		configService.SetOverrides(map[string]string{config.SettingAccount: accountOverride})
+	}
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+
+	vars, err := config.ResolveEnvironment(resolved, envFiles, envOverrides)
+	if err != nil {
+		return err
+	}
+	if !showSecrets {
+		vars = config.MaskSecrets(vars)
+	}
+
+	if format == "json" {
+		if vars == nil {
+			vars = []config.EnvVar{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(vars)
+	}
+
+	for _, v := range vars {
+		fmt.Printf("# from %s\n", v.Source)
+		fmt.Printf("%s=%s\n", v.Name, dotenvValue(v.Value))
+	}
+	return nil
+}
+
+// resolveWorkspaceService returns a service from the workspace file with its path and
+// env file made absolute
+func resolveWorkspaceService(workspaceFile, serviceName string) (workspace.Service, error) {
+	workspacePath := workspaceFile
+	if workspacePath == "" || filepath.Ext(workspacePath) == "" {
+		var found bool
+		var err error
+		workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
+		if err != nil {
+			return workspace.Service{}, fmt.Errorf("error finding workspace file: %w", err)
+		}
+		if !found {
+			return workspace.Service{}, fmt.Errorf("no reactor-workspace.yml found; a workspace is required to resolve service '%s'", serviceName)
+		}
+	}
+
+	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	if err != nil {
+		return workspace.Service{}, fmt.Errorf("failed to parse workspace file: %w", err)
+	}
+
+	service, exists := ws.Services[serviceName]
+	if !exists {
+		return workspace.Service{}, fmt.Errorf("service '%s' not found in workspace", serviceName)
+	}
+
+	workspaceDir := filepath.Dir(workspacePath)
+	servicePath := service.Path
+	if !filepath.IsAbs(servicePath) {
+		servicePath = filepath.Join(workspaceDir, servicePath)
+	}
+	service.Path, err = filepath.Abs(servicePath)
+	if err != nil {
+		return workspace.Service{}, fmt.Errorf("failed to resolve path for service '%s': %w", serviceName, err)
+	}
+	if service.EnvFile != "" && !filepath.IsAbs(service.EnvFile) {
+		service.EnvFile = filepath.Join(workspaceDir, service.EnvFile)
+	}
+	return service, nil
+}
+
+// dotenvValue quotes a value when it contains characters a dotenv parser would mangle
+func dotenvValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"'#$\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
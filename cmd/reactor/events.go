@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show lifecycle events of reactor containers",
+		Long: `Show what happened to reactor containers: starts, exits, restarts, OOM kills
+and health changes, one line per event, e.g.
+
+  14:02:11  service api restarted
+  14:05:37  container for project billing was OOM-killed
+
+Events of the last --since (default 1h) are shown. With --follow the command
+keeps listening for new events until interrupted, which is useful while
+supervising long agent runs. Containers of other tools are not shown.
+
+Examples:
+  reactor events                   # Events of the last hour
+  reactor events --since 24h       # Events of the last day
+  reactor events -f                # Keep printing events as they happen
+  reactor events -f --since 0      # Only new events
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: eventsCmdHandler,
+	}
+
+	cmd.Flags().BoolP("follow", "f", false, "Keep printing events until interrupted")
+	cmd.Flags().Duration("since", time.Hour, "Show events from this long ago (0 for none)")
+
+	return cmd
+}
+
+func eventsCmdHandler(cmd *cobra.Command, args []string) error {
+	follow, _ := cmd.Flags().GetBool("follow")
+	since, _ := cmd.Flags().GetDuration("since")
+	if since < 0 {
+		return fmt.Errorf("--since must not be negative")
+	}
+	if since == 0 && !follow {
+		return fmt.Errorf("--since 0 shows no past events; use it with --follow")
+	}
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer closeDockerService(dockerService)
+
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+
+	now := time.Now()
+	var from, until time.Time
+	if since > 0 {
+		from = now.Add(-since)
+	}
+	if !follow {
+		until = now
+	}
+
+	events, errs := dockerService.ContainerEvents(ctx, from, until)
+	shown := 0
+	for event := range events {
+		fmt.Printf("%s  %s\n", event.Time.Format("15:04:05"), describeContainerEvent(event))
+		shown++
+	}
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+	if shown == 0 && !follow {
+		fmt.Printf("No reactor container events in the last %s\n", since)
+	}
+	return nil
+}
+
+// describeContainerEvent renders an event as a sentence such as "service api restarted"
+func describeContainerEvent(event docker.ContainerEvent) string {
+	return eventSubject(event) + " " + eventVerb(event)
+}
+
+// eventSubject names the container of an event by workspace service, then by project
+func eventSubject(event docker.ContainerEvent) string {
+	subject := "container " + event.Name
+	if service := event.Attributes["com.reactor.workspace.service"]; service != "" {
+		subject = "service " + service
+	} else if project := event.Attributes[orchestrator.ProjectLabel]; project != "" {
+		subject = "container for project " + filepath.Base(project)
+	}
+	if session := event.Attributes[core.SessionLabel]; session != "" {
+		subject += " (session " + session + ")"
+	}
+	return subject
+}
+
+// eventVerb describes what happened to the container
+func eventVerb(event docker.ContainerEvent) string {
+	switch event.Action {
+	case "create":
+		return "was created"
+	case "start":
+		return "started"
+	case "restart":
+		return "restarted"
+	case "die":
+		switch code := event.Attributes["exitCode"]; code {
+		case "", "0":
+			return "exited"
+		case "137":
+			return "was killed (exit code 137)"
+		default:
+			return "exited with code " + code
+		}
+	case "oom":
+		return "was OOM-killed"
+	case "stop":
+		return "stopped"
+	case "pause":
+		return "was paused"
+	case "unpause":
+		return "was unpaused"
+	case "destroy":
+		return "was removed"
+	}
+	if status, ok := strings.CutPrefix(event.Action, "health_status:"); ok {
+		return "is " + strings.TrimSpace(status)
+	}
+	return event.Action
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeContainerEvent(t *testing.T) {
+	project := map[string]string{"com.reactor.project": "/home/u/src/billing"}
+	service := map[string]string{"com.reactor.workspace.service": "api", "com.reactor.project": "/home/u/src/ws/api"}
+	tests := []struct {
+		event    docker.ContainerEvent
+		expected string
+	}{
+		{docker.ContainerEvent{Action: "restart", Attributes: service}, "service api restarted"},
+		{docker.ContainerEvent{Action: "oom", Attributes: project}, "container for project billing was OOM-killed"},
+		{docker.ContainerEvent{Action: "die", Attributes: map[string]string{"exitCode": "2", "com.reactor.project": "/src/app"}}, "container for project app exited with code 2"},
+		{docker.ContainerEvent{Action: "die", Attributes: map[string]string{"exitCode": "137"}, Name: "reactor-u-app-1234"}, "container reactor-u-app-1234 was killed (exit code 137)"},
+		{docker.ContainerEvent{Action: "die", Attributes: map[string]string{"exitCode": "0"}, Name: "reactor-u-app-1234"}, "container reactor-u-app-1234 exited"},
+		{docker.ContainerEvent{Action: "health_status: unhealthy", Attributes: service}, "service api is unhealthy"},
+		{docker.ContainerEvent{Action: "start", Attributes: map[string]string{"com.reactor.project": "/src/app", "com.reactor.session": "review"}}, "container for project app (session review) started"},
+		{docker.ContainerEvent{Action: "destroy", Name: "reactor-u-app-1234"}, "container reactor-u-app-1234 was removed"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, describeContainerEvent(tt.event))
+	}
+}
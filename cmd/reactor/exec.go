@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/hooks"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+func newExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec [flags] [--] <command...>",
+		Short: "Execute command in running dev container",
+		Long: `Execute a command inside the running development container.
+
+The container must already be running (started with 'reactor up'). This is
+useful for running tests, builds, or other commands inside the container.
+
+A TTY is allocated when both stdin and stdout are terminals. Otherwise stdin is
+piped to the command until EOF and stdout and stderr are kept separate, so the
+command can be used in pipelines and redirections. Use --tty or --no-tty to
+override the detection. The command's exit status is passed through.
+
+With --detach the command runs in the background without holding the terminal.
+It is recorded as a job; manage it with 'reactor jobs list', 'reactor jobs logs <id>'
+and 'reactor jobs stop <id>'.
+
+With --checkpoint-before-exec, the container's state is saved before the command
+runs (see 'reactor checkpoint'). If the command fails, the checkpoint can be restored
+with 'reactor checkpoint restore', or automatically with --restore-on-failure.
+Progress messages go to stderr so the command's output is unchanged.
+
+With --env-file, the variables in a local dotenv file are added to the command's
+environment, overriding those the container was started with.
+
+Commands are recorded per project. List them with --history, and run the most recent
+one again with --last, in the session it ran in unless --name is given. The other
+flags apply as given, so 'reactor exec --last -d' re-runs it as a job.
+
+Examples:
+  reactor exec npm test                           # Run npm test inside container
+  reactor exec -- ls -la                          # Run ls command (use -- for flags)
+  cat prompt.txt | reactor exec -- claude -p      # Pipe a prompt to an agent
+  reactor exec -- tar czf - src > src.tgz         # Binary-safe output redirection
+  reactor exec --name feature-x -- git status     # Run in a named session
+  reactor exec -d -- npm run watch                # Run a watcher in the background
+  reactor exec --env-file .env.test -- npm test   # Run with extra variables
+  reactor exec --checkpoint-before-exec --restore-on-failure -- ./migrate.sh
+  reactor exec --last                             # Run the previous command again
+  reactor exec --history                          # List the project's recent commands
+
+For more details, see the full documentation.`,
+		Args: execArgs,
+		RunE: execCmdHandler,
+	}
+
+	// Stop flag parsing at the first argument so command flags reach the container
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().Bool("tty", false, "Force TTY allocation")
+	cmd.Flags().Bool("no-tty", false, "Disable TTY allocation")
+	cmd.Flags().String("name", "", "Session name of the container to run in")
+	cmd.Flags().BoolP("detach", "d", false, "Run the command in the background as a job")
+	cmd.Flags().StringArray("env-file", []string{}, "Load environment variables from a dotenv file, can be used multiple times")
+	cmd.Flags().Bool("checkpoint-before-exec", false, "Save the container's state before running the command")
+	cmd.Flags().Bool("restore-on-failure", false, "Restore the checkpoint if the command fails (requires --checkpoint-before-exec)")
+	cmd.Flags().Bool("last", false, "Run the most recent command in this project again")
+	cmd.Flags().Bool("history", false, "List the commands recently run in this project")
+	cmd.MarkFlagsMutuallyExclusive("tty", "no-tty")
+	cmd.MarkFlagsMutuallyExclusive("last", "history")
+	cmd.MarkFlagsMutuallyExclusive("detach", "tty")
+	cmd.MarkFlagsMutuallyExclusive("detach", "restore-on-failure")
+
+	return cmd
+}
+
+func execCmdHandler(cmd *cobra.Command, args []string) error {
+	forceTTY, _ := cmd.Flags().GetBool("tty")
+	disableTTY, _ := cmd.Flags().GetBool("no-tty")
+	sessionName, _ := cmd.Flags().GetString("name")
+	detach, _ := cmd.Flags().GetBool("detach")
+	envFiles, _ := cmd.Flags().GetStringArray("env-file")
+	checkpointBefore, _ := cmd.Flags().GetBool("checkpoint-before-exec")
+	restoreOnFailure, _ := cmd.Flags().GetBool("restore-on-failure")
+	last, _ := cmd.Flags().GetBool("last")
+	showHistory, _ := cmd.Flags().GetBool("history")
+	if showHistory {
+		resolved, err := config.NewService().ResolveConfiguration()
+		if err != nil {
+			return err
+		}
+		runs, err := state.ExecHistory(resolved.ProjectRoot)
+		if err != nil {
+			return err
+		}
+		printExecHistory(os.Stdout, runs, time.Now())
+		return nil
+	}
+	if restoreOnFailure && !checkpointBefore {
+		return fmt.Errorf("--restore-on-failure requires --checkpoint-before-exec")
+	}
+
+	fileEnv, err := config.LoadEnvFiles(envFiles)
+	if err != nil {
+		return err
+	}
+	env := make([]string, 0, len(fileEnv))
+	for name, value := range fileEnv {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+
+	// Check dependencies first
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+
+	configService := config.NewService()
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if last {
+		run, err := lastExecRun(resolved.ProjectRoot)
+		if err != nil {
+			return err
+		}
+		args = run.Command
+		if sessionName == "" {
+			sessionName = run.Session
+		}
+		fmt.Fprintf(os.Stderr, "Re-running: %s\n", strings.Join(args, " "))
+	}
+
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+	}
+
+	// Initialize Docker service
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+
+	// Check Docker daemon health
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	containerInfo, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if containerInfo.Status != docker.StatusRunning {
+		return fmt.Errorf("container %s is not running. Run 'reactor up' first", containerName)
+	}
+	recordLastSession(containerName, resolved.ProjectRoot)
+
+	var cp docker.Checkpoint
+	processDir := config.CheckpointDir(resolved.ProjectConfigDir, containerName)
+	if checkpointBefore {
+		cp, err = dockerService.CreateCheckpoint(ctx, containerInfo.ID, containerName, processDir, strings.Join(args, " "))
+		if err != nil {
+			return fmt.Errorf("failed to checkpoint before running the command: %w", err)
+		}
+		printCheckpointCreated(os.Stderr, cp)
+	}
+
+	if detach {
+		if err := startJob(ctx, dockerService, containerInfo.ID, containerName, resolved.ProjectRoot, args, env); err != nil {
+			return err
+		}
+		recordExecRun(resolved.ProjectRoot, state.ExecRun{Command: args, Session: sessionName, Detached: true})
+		return nil
+	}
+
+	tty := docker.IsInteractiveTerminal()
+	if forceTTY {
+		tty = true
+	}
+	if disableTTY {
+		tty = false
+	}
+
+	// Only attach stdin when there is input to forward; an interactive terminal
+	// without a TTY would otherwise keep the command waiting for input
+	opts := docker.ExecOptions{
+		Command: args,
+		Env:     env,
+		Tty:     tty,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+	if tty || !term.IsTerminal(os.Stdin.Fd()) {
+		opts.Stdin = os.Stdin
+	}
+
+	execStart := time.Now()
+	err = dockerService.ExecCommand(ctx, containerInfo.ID, opts)
+	var exitErr *docker.ExitError
+	firePostExecHook(ctx, resolved, containerName, containerInfo.ID, sessionName, args, time.Since(execStart), err)
+	recordExecRun(resolved.ProjectRoot, state.ExecRun{Command: args, Session: sessionName, ExitCode: execExitCode(err)})
+	if checkpointBefore && errors.As(err, &exitErr) {
+		if !restoreOnFailure {
+			fmt.Fprintf(os.Stderr, "Command failed. Roll back with 'reactor checkpoint restore %s'.\n", cp.ID)
+			return err
+		}
+		if restoreErr := restoreCheckpoint(ctx, os.Stderr, dockerService, containerInfo.ID, cp, processDir); restoreErr != nil {
+			return fmt.Errorf("command failed (%v) and %w", err, restoreErr)
+		}
+	}
+	return err
+}
+
+// firePostExecHook tells post-exec hooks about a finished command; the exit code is
+// only reported when the command ran to completion
+func firePostExecHook(ctx context.Context, resolved *config.ResolvedConfig, containerName, containerID, sessionName string, command []string, duration time.Duration, execErr error) {
+	payload := hooks.Payload{
+		Event:           hooks.PostExec,
+		ProjectRoot:     resolved.ProjectRoot,
+		Account:         resolved.Account,
+		ContainerName:   containerName,
+		ContainerID:     containerID,
+		Session:         sessionName,
+		Command:         command,
+		DurationSeconds: int64(duration / time.Second),
+		ExitCode:        execExitCode(execErr),
+	}
+	_ = hooks.Fire(ctx, payload)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+// execArgs requires a command unless --last or --history supplies one
+func execArgs(cmd *cobra.Command, args []string) error {
+	last, _ := cmd.Flags().GetBool("last")
+	history, _ := cmd.Flags().GetBool("history")
+	if last || history {
+		if len(args) > 0 {
+			return fmt.Errorf("--last and --history do not take a command")
+		}
+		return nil
+	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
+// execExitCode returns the exit code of a finished command, nil when it did not run to completion
+func execExitCode(execErr error) *int {
+	var exitErr *docker.ExitError
+	switch {
+	case execErr == nil:
+		code := 0
+		return &code
+	case errors.As(execErr, &exitErr):
+		return &exitErr.Code
+	}
+	return nil
+}
+
+// lastExecRun returns the most recent command run in a project
+func lastExecRun(projectRoot string) (*state.ExecRun, error) {
+	runs, err := state.ExecHistory(projectRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no commands have been run in %s yet; run one with 'reactor exec -- <command>'", projectRoot)
+	}
+	return &runs[len(runs)-1], nil
+}
+
+// recordExecRun adds a command to the project's history for 'reactor exec --last'
+func recordExecRun(projectRoot string, run state.ExecRun) {
+	run.RanAt = time.Now().UTC()
+	if err := state.RecordExecRun(projectRoot, run); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the command in the exec history: %v\n", err)
+	}
+}
+
+// printExecHistory lists the commands run in a project, most recent last
+func printExecHistory(out io.Writer, runs []state.ExecRun, now time.Time) {
+	if len(runs) == 0 {
+		fmt.Fprintln(out, "No commands recorded for this project.")
+		fmt.Fprintln(out, "Run one with 'reactor exec -- <command>'.")
+		return
+	}
+
+	fmt.Fprintf(out, "%-4s %-12s %-15s %-9s %s\n", "#", "RAN", "SESSION", "EXIT", "COMMAND")
+	fmt.Fprintf(out, "%-4s %-12s %-15s %-9s %s\n",
+		strings.Repeat("-", 4),
+		strings.Repeat("-", 12),
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 9),
+		strings.Repeat("-", 7))
+	for i, run := range runs {
+		session := run.Session
+		if session == "" {
+			session = "-"
+		}
+		exit := "-"
+		switch {
+		case run.Detached:
+			exit = "detached"
+		case run.ExitCode != nil:
+			exit = fmt.Sprintf("%d", *run.ExitCode)
+		}
+		ran := now.Sub(run.RanAt).Round(time.Second).String() + " ago"
+		fmt.Fprintf(out, "%-4d %-12s %-15s %-9s %s\n", i+1, ran, session, exit, strings.Join(run.Command, " "))
+	}
+	fmt.Fprintln(out, "\nRe-run the most recent command with 'reactor exec --last'.")
+}
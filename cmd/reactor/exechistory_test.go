@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecArgs(t *testing.T) {
+	cmd := newExecCmd()
+	assert.Error(t, execArgs(cmd, nil), "a command is required")
+	assert.NoError(t, execArgs(cmd, []string{"npm", "test"}))
+
+	require.NoError(t, cmd.Flags().Set("last", "true"))
+	assert.NoError(t, execArgs(cmd, nil))
+	assert.EqualError(t, execArgs(cmd, []string{"npm", "test"}), "--last and --history do not take a command")
+}
+
+func TestExecExitCode(t *testing.T) {
+	assert.Equal(t, 0, *execExitCode(nil))
+	assert.Equal(t, 3, *execExitCode(&docker.ExitError{Code: 3}))
+	assert.Nil(t, execExitCode(assert.AnError))
+}
+
+func TestLastExecRun(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	_, err := lastExecRun("/src/api")
+	assert.ErrorContains(t, err, "no commands have been run in /src/api yet")
+
+	recordExecRun("/src/api", state.ExecRun{Command: []string{"npm", "test"}})
+	recordExecRun("/src/api", state.ExecRun{Command: []string{"go", "test", "./..."}, Session: "feature-x"})
+	run, err := lastExecRun("/src/api")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "test", "./..."}, run.Command)
+	assert.Equal(t, "feature-x", run.Session)
+	assert.False(t, run.RanAt.IsZero())
+}
+
+func TestPrintExecHistory(t *testing.T) {
+	var out bytes.Buffer
+	printExecHistory(&out, nil, time.Now())
+	assert.Contains(t, out.String(), "No commands recorded for this project.")
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	failed := 1
+	out.Reset()
+	printExecHistory(&out, []state.ExecRun{
+		{Command: []string{"npm", "test"}, RanAt: now.Add(-2 * time.Minute), ExitCode: &failed},
+		{Command: []string{"npm", "run", "watch"}, Session: "feature-x", RanAt: now.Add(-time.Minute), Detached: true},
+	}, now)
+	assert.Contains(t, out.String(), "1    2m0s ago     -               1         npm test\n")
+	assert.Contains(t, out.String(), "2    1m0s ago     feature-x       detached  npm run watch\n")
+	assert.Contains(t, out.String(), "reactor exec --last")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/spf13/cobra"
+)
+
+func newExplainErrorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain-error [message]",
+		Short: "Explain a Docker error and how to fix it",
+		Long: `Look up a Docker error message in reactor's knowledge base of common failures
+and print how to fix it. reactor already appends these hints to its own errors;
+use this command for errors from docker itself, CI logs or a container's output.
+
+Known failures include permission denied on the Docker socket, the daemon not
+running, no space left on device, images that do not exist, and exec format
+errors from images built for a different CPU architecture.
+
+The message is read from stdin when no argument is given or it is '-'.
+
+Examples:
+  reactor explain-error "exec /bin/sh: exec format error"
+  docker pull myimage:latest 2>&1 | reactor explain-error`,
+		Args: cobra.ArbitraryArgs,
+		RunE: explainErrorHandler,
+	}
+	return cmd
+}
+
+func explainErrorHandler(cmd *cobra.Command, args []string) error {
+	message := strings.Join(args, " ")
+	if message == "" || message == "-" {
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, 1<<20))
+		if err != nil {
+			return fmt.Errorf("failed to read error message from stdin: %w", err)
+		}
+		message = string(data)
+	}
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("no error message given. Pass it as an argument or on stdin")
+	}
+
+	printExplanation(os.Stdout, message)
+	return nil
+}
+
+// printExplanation writes the diagnosis for an error message, if it is a known failure
+func printExplanation(out io.Writer, message string) {
+	d, ok := docker.Diagnose(message)
+	if !ok {
+		fmt.Fprintf(out, "No known remediation for this error. See https://github.com/dyluth/reactor#troubleshooting for common problems.\n")
+		return
+	}
+	fmt.Fprintf(out, "%s\n\n%s\n\nSee: %s\n", d.Problem, d.Hint, d.DocURL)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintExplanation(t *testing.T) {
+	var out bytes.Buffer
+	printExplanation(&out, "standard_init_linux.go:228: exec user process caused: exec format error")
+	assert.Contains(t, out.String(), "Image built for a different CPU architecture")
+	assert.Contains(t, out.String(), "See: https://github.com/dyluth/reactor#exec-format-error")
+
+	out.Reset()
+	printExplanation(&out, "something unexpected")
+	assert.Contains(t, out.String(), "No known remediation")
+}
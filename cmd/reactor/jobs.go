@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+func newJobsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Manage background jobs started with 'reactor exec --detach'",
+		Long: `Manage commands running in the background inside dev containers.
+
+Jobs are started with 'reactor exec -d -- <command>' and are identified by a
+small number. Their output is kept inside the container in /tmp/reactor-jobs.
+Jobs of containers that have been removed are forgotten when listing.
+
+Examples:
+  reactor exec -d -- npm run watch    # Start a job
+  reactor jobs list                   # Show jobs and whether they are still running
+  reactor jobs logs 1 -f              # Follow a job's output
+  reactor jobs stop 1                 # Send SIGTERM to a job
+
+For more details, see the full documentation.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List background jobs",
+		Long:  "List background jobs with their container, status and command",
+		Args:  cobra.NoArgs,
+		RunE:  jobsListHandler,
+	})
+
+	logsCmd := &cobra.Command{
+		Use:   "logs <id>",
+		Short: "Show a job's output",
+		Long:  "Print the combined stdout and stderr of a background job",
+		Args:  cobra.ExactArgs(1),
+		RunE:  jobsLogsHandler,
+	}
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep printing output as the job writes it, until the job exits")
+	cmd.AddCommand(logsCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stop <id>",
+		Short: "Stop a background job",
+		Long:  "Send SIGTERM to a running background job",
+		Args:  cobra.ExactArgs(1),
+		RunE:  jobsStopHandler,
+	})
+
+	return cmd
+}
+
+// startJob runs command detached in the container and records it as a job
+func startJob(ctx context.Context, dockerService *docker.Service, containerID, containerName, projectRoot string, command, env []string) error {
+	jobID, err := state.NextJobID()
+	if err != nil {
+		return fmt.Errorf("failed to allocate job ID: %w", err)
+	}
+
+	execID, err := dockerService.StartDetachedExec(ctx, containerID, jobID, command, env)
+	if err != nil {
+		return err
+	}
+
+	if err := state.RecordJob(state.Job{
+		ID:            jobID,
+		ContainerName: containerName,
+		ExecID:        execID,
+		Command:       command,
+		ProjectRoot:   projectRoot,
+		StartedAt:     time.Now().UTC(),
+	}); err != nil {
+		return fmt.Errorf("job started but could not be recorded: %w", err)
+	}
+
+	fmt.Printf("Started job %s: %s\n", jobID, strings.Join(command, " "))
+	fmt.Printf("Use 'reactor jobs logs %s' to see its output and 'reactor jobs stop %s' to stop it.\n", jobID, jobID)
+	return nil
+}
+
+func jobsListHandler(cmd *cobra.Command, args []string) error {
+	jobs, err := state.Jobs()
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No jobs found.")
+		fmt.Println("Start one with 'reactor exec -d -- <command>'.")
+		return nil
+	}
+
+	ctx := context.Background()
+	dockerService, err := newJobsDockerService(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(dockerService)
+
+	fmt.Printf("%-5s %-12s %-35s %-10s %s\n", "ID", "STATUS", "CONTAINER", "STARTED", "COMMAND")
+	fmt.Printf("%-5s %-12s %-35s %-10s %s\n",
+		strings.Repeat("-", 5),
+		strings.Repeat("-", 12),
+		strings.Repeat("-", 35),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 7))
+
+	var gone []string
+	for _, job := range jobs {
+		status := "unknown"
+		running, exitCode, err := dockerService.ExecStatus(ctx, job.ExecID)
+		switch {
+		case err == nil && running:
+			status = "running"
+		case err == nil:
+			status = fmt.Sprintf("exited (%d)", exitCode)
+		default:
+			if info, existsErr := dockerService.ContainerExists(ctx, job.ContainerName); existsErr == nil && info.Status == docker.StatusNotFound {
+				gone = append(gone, job.ID)
+				continue
+			}
+		}
+
+		started := time.Since(job.StartedAt).Round(time.Second).String() + " ago"
+		fmt.Printf("%-5s %-12s %-35s %-10s %s\n", job.ID, status, job.ContainerName, started, strings.Join(job.Command, " "))
+	}
+
+	if err := state.ForgetJobs(gone...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to forget jobs of removed containers: %v\n", err)
+	}
+	return nil
+}
+
+func jobsLogsHandler(cmd *cobra.Command, args []string) error {
+	follow, _ := cmd.Flags().GetBool("follow")
+
+	ctx := context.Background()
+	job, containerID, dockerService, err := lookupJobContainer(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(dockerService)
+
+	// Stop following once the job has finished
+	command := docker.JobLogsCommand(job.ID, follow)
+	if follow {
+		if running, _, err := dockerService.ExecStatus(ctx, job.ExecID); err != nil || !running {
+			command = docker.JobLogsCommand(job.ID, false)
+		}
+	}
+
+	return dockerService.ExecCommand(ctx, containerID, docker.ExecOptions{
+		Command: command,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	})
+}
+
+func jobsStopHandler(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	job, containerID, dockerService, err := lookupJobContainer(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(dockerService)
+
+	running, exitCode, err := dockerService.ExecStatus(ctx, job.ExecID)
+	if err != nil {
+		return err
+	}
+	if !running {
+		return fmt.Errorf("job %s has already exited with code %d", job.ID, exitCode)
+	}
+
+	if err := dockerService.ExecCommand(ctx, containerID, docker.ExecOptions{
+		Command: docker.JobStopCommand(job.ID),
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to stop job %s: %w", job.ID, err)
+	}
+
+	fmt.Printf("Stopped job %s: %s\n", job.ID, strings.Join(job.Command, " "))
+	return nil
+}
+
+// lookupJobContainer finds a job and the ID of its running container
+func lookupJobContainer(ctx context.Context, jobID string) (*state.Job, string, *docker.Service, error) {
+	job, err := state.FindJob(jobID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	dockerService, err := newJobsDockerService(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	info, err := dockerService.ContainerExists(ctx, job.ContainerName)
+	if err != nil {
+		closeDockerService(dockerService)
+		return nil, "", nil, fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if info.Status != docker.StatusRunning {
+		closeDockerService(dockerService)
+		return nil, "", nil, fmt.Errorf("container %s of job %s is not running", job.ContainerName, job.ID)
+	}
+	return job, info.ID, dockerService, nil
+}
+
+func newJobsDockerService(ctx context.Context) (*docker.Service, error) {
+	if err := config.CheckDependencies(); err != nil {
+		return nil, err
+	}
+
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		closeDockerService(dockerService)
+		return nil, fmt.Errorf("docker daemon not available: %w", err)
+	}
+	return dockerService, nil
+}
+
+func closeDockerService(dockerService *docker.Service) {
+	if err := dockerService.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/spf13/cobra"
+)
+
+func newLogsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show output of the dev container's main process",
+		Long: `Show the stdout and stderr of the dev container's default command or agent process.
+
+When a container is removed (by 'reactor down', a discovery run or recovery from a
+broken container), its output is archived to
+~/.reactor/<account>/<project-hash>/logs/<container-name>/. The output of the last
+5 runs is kept; older runs are rotated out. Use --session to read an earlier run
+even after the container has been recreated.
+
+Examples:
+  reactor logs                        # Output of the current container
+  reactor logs -f                     # Follow the current container's output
+  reactor logs --session previous     # Output of the run before the current container
+  reactor logs --session 2            # Output of the run two containers ago
+  reactor logs --list                 # List the archived runs
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: logsCmdHandler,
+	}
+
+	cmd.Flags().String("session", "current", "Run to show: current, previous, or how many runs back (1, 2, ...)")
+	cmd.Flags().BoolP("follow", "f", false, "Keep printing output of the current container")
+	cmd.Flags().String("tail", "all", "Number of lines to show from the end of the output")
+	cmd.Flags().Bool("list", false, "List archived runs instead of showing output")
+	cmd.Flags().String("name", "", "Session name of the container (see 'reactor up --name')")
+
+	return cmd
+}
+
+func logsCmdHandler(cmd *cobra.Command, args []string) error {
+	session, _ := cmd.Flags().GetString("session")
+	follow, _ := cmd.Flags().GetBool("follow")
+	tail, _ := cmd.Flags().GetString("tail")
+	list, _ := cmd.Flags().GetBool("list")
+	sessionName, _ := cmd.Flags().GetString("name")
+
+	runsBack, err := parseLogSession(session)
+	if err != nil {
+		return err
+	}
+	if tail != "all" {
+		if n, err := strconv.Atoi(tail); err != nil || n < 0 {
+			return fmt.Errorf("invalid --tail '%s': must be a number of lines or 'all'", tail)
+		}
+	}
+	if follow && runsBack > 0 {
+		return fmt.Errorf("--follow can only be used with the current container")
+	}
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+	}
+
+	resolved, err := config.NewService().ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	logDir := config.ContainerLogDir(resolved.ProjectConfigDir, containerName)
+
+	archived, err := docker.ArchivedLogs(logDir)
+	if err != nil {
+		return err
+	}
+
+	if list {
+		return printArchivedLogs(archived)
+	}
+
+	if runsBack > 0 {
+		if runsBack > len(archived) {
+			return fmt.Errorf("no output archived for run %d of %s (%d run(s) kept in %s)", runsBack, containerName, len(archived), logDir)
+		}
+		data, err := os.ReadFile(archived[runsBack-1])
+		if err != nil {
+			return fmt.Errorf("failed to read archived output: %w", err)
+		}
+		_, err = os.Stdout.WriteString(tailLines(string(data), tail))
+		return err
+	}
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+
+	info, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if info.Status == docker.StatusNotFound {
+		if len(archived) > 0 {
+			return fmt.Errorf("no container found for project: %s. Use --session previous to see the output of the last run", containerName)
+		}
+		return fmt.Errorf("no container found for project: %s", containerName)
+	}
+
+	return dockerService.ContainerOutput(ctx, info.ID, docker.LogOptions{Follow: follow, Tail: tail}, os.Stdout, os.Stderr)
+}
+
+// parseLogSession converts --session to the number of runs before the current container
+func parseLogSession(session string) (int, error) {
+	switch session {
+	case "", "current":
+		return 0, nil
+	case "previous":
+		return 1, nil
+	}
+	n, err := strconv.Atoi(session)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid --session '%s': must be current, previous or a number of runs back", session)
+	}
+	return n, nil
+}
+
+func printArchivedLogs(archived []string) error {
+	if len(archived) == 0 {
+		fmt.Println("No archived runs. Output is archived when the container is removed.")
+		return nil
+	}
+
+	fmt.Printf("%-8s %-20s %-10s %s\n", "SESSION", "ENDED", "SIZE", "FILE")
+	for i, path := range archived {
+		ended := "-"
+		if t, err := docker.ArchivedLogTime(path); err == nil {
+			ended = t.Local().Format("2006-01-02 15:04:05")
+		}
+		size := "-"
+		if info, err := os.Stat(path); err == nil {
+			size = units.HumanSize(float64(info.Size()))
+		}
+		fmt.Printf("%-8d %-20s %-10s %s\n", i+1, ended, size, path)
+	}
+	return nil
+}
+
+// tailLines returns the last n lines of output, or all of it for "all"
+func tailLines(output, tail string) string {
+	n, err := strconv.Atoi(tail)
+	if err != nil {
+		return output
+	}
+	lines := strings.SplitAfter(output, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "")
+}
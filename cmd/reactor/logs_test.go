@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogSession(t *testing.T) {
+	for session, want := range map[string]int{"current": 0, "": 0, "previous": 1, "3": 3} {
+		got, err := parseLogSession(session)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, session)
+	}
+
+	_, err := parseLogSession("last-week")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --session 'last-week'")
+}
+
+func TestTailLines(t *testing.T) {
+	output := "one\ntwo\nthree\n"
+	assert.Equal(t, output, tailLines(output, "all"))
+	assert.Equal(t, "two\nthree\n", tailLines(output, "2"))
+	assert.Equal(t, output, tailLines(output, "10"))
+	assert.Equal(t, "", tailLines(output, "0"))
+	assert.Equal(t, "partial", tailLines("one\npartial", "1"))
+}
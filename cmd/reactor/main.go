@@ -2,20 +2,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
+	units "github.com/docker/go-units"
 	"github.com/dyluth/reactor/pkg/config"
 	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/devcontainer"
 	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/metrics"
+	"github.com/dyluth/reactor/pkg/notify"
+	"github.com/dyluth/reactor/pkg/ondemand"
 	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/overlay"
+	"github.com/dyluth/reactor/pkg/portforward"
+	"github.com/dyluth/reactor/pkg/settings"
+	"github.com/dyluth/reactor/pkg/state"
 	"github.com/dyluth/reactor/pkg/templates"
+	"github.com/dyluth/reactor/pkg/tunnel"
+	"github.com/dyluth/reactor/pkg/ui"
 	"github.com/dyluth/reactor/pkg/workspace"
 	"github.com/spf13/cobra"
 )
@@ -28,8 +44,18 @@ var (
 )
 
 func main() {
-	if err := newRootCmd().Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	docker.AdoptedLabels = state.AdoptedLabels
+	orchestrator.ReactorVersion = Version
+	start := time.Now()
+	cmd, err := newRootCmd().ExecuteC()
+	recordTelemetry(cmd, err, time.Since(start))
+	if err != nil {
+		// Pass through the exit status of commands run inside the container
+		var exitErr *docker.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+		fmt.Fprintf(os.Stderr, "%s %v\n", ui.Stderr().Red("Error:"), docker.WithDiagnosis(err))
 		os.Exit(1)
 	}
 }
@@ -42,25 +68,77 @@ func newRootCmd() *cobra.Command {
 for AI CLI tools like Claude, Gemini, and others.
 
 It manages account-isolated configuration, persistent sessions, and container
-lifecycle while keeping your host machine clean.`,
+lifecycle while keeping your host machine clean.
+
+The commands, the user preferences in ~/.reactor/config.yaml and the REACTOR_*
+environment variables are described in the Usage section of the README.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		// Every command honours --verbose and REACTOR_DEBUG, including workspace up's own -v
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if err := debug.Configure(verbose); err != nil {
+				return err
+			}
+			if noColor, _ := cmd.Flags().GetBool("no-color"); noColor {
+				settings.DisableColor()
+			}
+			account, _ := cmd.Flags().GetString("account")
+			if err := config.SetGlobalOverride(config.SettingAccount, account); err != nil {
+				return err
+			}
+			// The isolation prefix decides where every piece of state lives, so it is
+			// settled before anything reads it
+			configName, _ := cmd.Flags().GetString("config")
+			config.SetGlobalConfigName(configName)
+			if _, err := config.ApplyIsolationPrefix(".", configName); err != nil {
+				return err
+			}
+			_, err := settings.Load()
+			return err
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			// The update check caches its result under ~/.reactor, which an ephemeral run leaves alone
+			ephemeral, _ := cmd.Flags().GetBool("ephemeral")
+			if cmd.Name() != "version" && cmd.Name() != "completion" && !ephemeral {
+				notifyUpdate(os.Stderr, userPreferences())
+			}
+		},
 	}
 
 	// Add global flags
-	cmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging")
+	cmd.PersistentFlags().String("account", "", "Use this account instead of the project's for this command (also REACTOR_ACCOUNT)")
+	cmd.PersistentFlags().String("config", "", "Use the configuration in .devcontainer/<name>/devcontainer.json for this command")
+	cmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging (all debug categories; pick some with REACTOR_DEBUG=config,docker,orchestrator,workspace)")
+	cmd.PersistentFlags().Bool("no-color", false, "Disable colored output and status symbols (also NO_COLOR or color: never in ~/.reactor/config.yaml)")
 
 	// Add subcommands
 	cmd.AddCommand(newUpCmd())
 	cmd.AddCommand(newDownCmd())
 	cmd.AddCommand(newExecCmd())
+	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newLogsCmd())
+	cmd.AddCommand(newPortsCmd())
+	cmd.AddCommand(newEventsCmd())
+	cmd.AddCommand(newJobsCmd())
+	cmd.AddCommand(newCheckpointCmd())
+	cmd.AddCommand(newScheduleCmd())
 	cmd.AddCommand(newBuildCmd())
+	cmd.AddCommand(newPrefetchCmd())
+	cmd.AddCommand(newPoolCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newSelftestCmd())
 	cmd.AddCommand(newSessionsCmd())
 	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newApplyCmd())
 	cmd.AddCommand(newAccountsCmd())
 	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newEnvCmd())
 	cmd.AddCommand(newWorkspaceCmd())
+	cmd.AddCommand(newPresetCmd())
 	cmd.AddCommand(newCompletionCmd())
+	cmd.AddCommand(newExplainErrorCmd())
+	cmd.AddCommand(newTelemetryCmd())
 	cmd.AddCommand(newVersionCmd())
 
 	return cmd
@@ -76,27 +154,147 @@ The up command provisions a Docker container based on the devcontainer.json
 specification found in your project, then attaches you to an interactive 
 session. Containers are automatically reused when possible for fast startup.
 
+When stdin or stdout is not a terminal (for example in CI), the session runs
+without a TTY: input is piped to the container shell until EOF and the command
+exits non-zero if the shell does.
+
+Containers run with an init process as PID 1 so that zombie processes left by
+agent-spawned subprocesses are reaped. Set "init": false in devcontainer.json
+or pass --no-init to disable it. A script set in customizations.reactor.entrypoint
+is mounted into the container and run under init before the container command;
+it must finish with 'exec "$@"'.
+
+After the container starts, up checks that the container user can write to the
+account's provider directories (such as ~/.claude) and the project. Pass
+--fix-permissions to chown provider directories it cannot write to; the project
+directory is only reported, never chowned.
+
+With --dry-run, up resolves the configuration and prints the container it would
+create (image, name, mounts, environment, ports, labels, user and command)
+without calling Docker. Secret-looking environment values are masked.
+
+Before a container is created or restarted, every forwarded host port is checked
+on this machine; if one is taken, up fails and names the process holding it.
+
+An existing container is reused as it was created. When its image, bind mounts
+or environment no longer match the configuration (devcontainer.json was edited,
+the image was rebuilt, -e differs), up warns and lists the differences; with
+--recreate-on-drift it removes the container and creates it afresh instead,
+also when the forwarded ports changed.
+
+With --use-devcontainer-cli, building, creating the container and running
+lifecycle commands are delegated to the official devcontainer CLI (install it
+with 'npm install -g @devcontainers/cli'), giving full Dev Container spec coverage
+(features, docker compose, ...). Reactor still names the container, mounts the
+account's provider directories and attaches the session, so 'reactor down',
+'reactor exec' and sessions work as usual. The account env file, --env-file files
+and -e overrides are passed to lifecycle commands as remote environment variables. Forwarded ports,
+--read-only-workspace, --clone-repo and --no-init are not available in this mode. Set
+engine: devcontainer-cli in ~/.reactor/config.yaml (or REACTOR_ENGINE) to make
+it the default; runs using those options then keep reactor's own engine, and
+--use-devcontainer-cli=false opts out for a single run.
+
+Repos that publish several configurations in .devcontainer/<name>/devcontainer.json
+select one with --config <name>; 'reactor config list' shows them. Without
+--config the default devcontainer.json is used, or the only configuration if
+there is just one. A container keeps the configuration it was created from;
+use --name to run another configuration of the same project alongside it.
+
+With --watch-config, up watches devcontainer.json and the Dockerfile while the
+session runs and says so in the terminal when one of them changes. When the
+session ends it asks for a single key: r rebuilds the image, recreates the
+container and attaches again; any other key leaves the container running.
+
+With --ephemeral, up creates a throwaway container for trying out untrusted code:
+only the project is mounted, at /workspace. No provider credentials, shell
+history or account env file reach it, nothing is written under ~/.reactor, and
+the container is removed when the session ends.
+
+With --on-demand, up does not start the container or attach. It listens on the
+forwarded ports itself, starts the container on the first connection and proxies
+traffic to it, so a rarely used service costs nothing until it is accessed. With
+--idle-timeout the container is stopped again after that long without
+connections. up keeps running in the foreground until interrupted; the container
+keeps running after that.
+
+With --clone-repo <url>, the workspace is a Docker volume instead of the project
+directory: the repository is cloned into it inside the container, as the
+container user, so untrusted code never lands on the host. The image needs git
+(add "git" to customizations.reactor.tools otherwise); the environment still
+comes from the current directory's devcontainer.json. The volume survives
+'reactor down', and a later 'reactor up --clone-repo' with the same URL carries
+on with the clone. Use --name to work on several repositories side by side.
+
+If a container reactor did not create already has the project's container name,
+up asks whether to remove it rather than reusing it. --replace removes it without
+asking; --name starts this project's container under another name instead.
+
+A policy in ~/.reactor/policy.yaml (or the file REACTOR_POLICY names) is
+checked before any image is pulled or built: it can forbid Docker host
+integration, elevated privileges and host networking, restrict the registries
+and images used, and require hardened containers. A container that breaks it is
+not created, and every violation is listed.
+
 Examples:
   reactor up                               # Start container from devcontainer.json
+  reactor up <<'EOF'                       # Drive the session from a script
+  claude -p "fix the failing tests"
+  EOF
   reactor up --account work-account       # Override account for isolation
+  reactor up --config backend              # Use .devcontainer/backend/devcontainer.json
   reactor up --rebuild                     # Force rebuild before starting
+  reactor up --recreate-on-drift           # Recreate the container after config edits
+  reactor up --read-only-workspace         # Capture agent edits in an overlay
+  reactor up --clone-repo https://github.com/org/repo.git  # Work on a clone kept in a volume
+  reactor up --fix-permissions             # Chown root-owned provider directories
+  reactor up --allow-privileged            # Allow devcontainer.json's privileged and capAdd
+  reactor up --rerun-hooks                 # Run edited postCreate/postStart commands again
+  reactor up --replace                     # Remove another container holding the name
+  reactor up --dry-run                     # Show the container that would be created
+  reactor up --profile                     # Show how long each startup phase took
+  reactor up --name feature-x              # Run an extra named session for this project
+  reactor up -e LOG_LEVEL=debug            # Override an environment variable
+  reactor up --env-file .env               # Load variables from a local dotenv file
+  reactor up --use-devcontainer-cli        # Provision with the official devcontainer CLI
+  reactor up --on-demand --idle-timeout 30m  # Start on first connection, stop when idle
+  reactor up --watch-config                # Offer a rebuild when devcontainer.json changes
+  reactor up --ephemeral                   # Throwaway container without credentials
+  reactor up --propagate-exit              # Exit with the session's exit code
 
 For more details, see the full documentation.`,
 		RunE: upCmdHandler,
 	}
 
-	// Add flags (removed --provider and --image, kept account for override)
-	cmd.Flags().String("account", "", "Override account from devcontainer.json customizations")
+	// Add flags (removed --provider and --image; --account and --config are global flags)
 	cmd.Flags().Bool("rebuild", false, "Force rebuild of container image before starting")
+	cmd.Flags().Bool("recreate-on-drift", false, "Recreate an existing container that no longer matches the configuration")
 	cmd.Flags().Bool("discovery-mode", false, "Run with no mounts for configuration discovery")
 	cmd.Flags().Bool("docker-host-integration", false, "Mount host Docker socket (DANGEROUS - use only with trusted images)")
+	cmd.Flags().Bool("allow-privileged", false, "Create the container with devcontainer.json's privileged, capAdd and securityOpt without asking")
+	cmd.Flags().Bool("rerun-hooks", false, "Run postCreateCommand and postStartCommand again in an existing container when they changed, without asking")
+	cmd.Flags().Bool("replace", false, "Remove a container reactor did not create that holds the container's name, without asking")
+	cmd.Flags().Bool("no-init", false, "Do not run an init process as PID 1 (overrides devcontainer.json)")
+	cmd.Flags().Bool("read-only-workspace", false, "Mount the project read-only and capture changes in a writable overlay (Linux with a local Docker daemon only)")
+	cmd.Flags().String("clone-repo", "", "Clone this repository inside the container into a volume used as the workspace")
+	cmd.Flags().Bool("dry-run", false, "Print the container that would be created without calling Docker")
+	cmd.Flags().Bool("fix-permissions", false, "Chown provider config directories the container user cannot write to")
+	cmd.Flags().Bool("profile", false, "Print timing for each startup phase")
+	cmd.Flags().Bool("use-devcontainer-cli", false, "Provision the container with the official devcontainer CLI")
+	cmd.Flags().Bool("on-demand", false, "Listen on the forwarded ports and start the container on the first connection")
+	cmd.Flags().Duration("idle-timeout", 0, "With --on-demand, stop the container after this long without connections")
+	cmd.Flags().Bool("watch-config", false, "Watch devcontainer.json and the Dockerfile during the session and offer to rebuild when they change")
+	cmd.Flags().Bool("ephemeral", false, "Create a throwaway container with only the workspace mounted, removed when the session ends")
+	cmd.Flags().Bool("propagate-exit", false, "Exit with the exit code of the session's shell or agent")
+	cmd.Flags().String("name", "", "Session name for running several containers for the same project")
 	cmd.Flags().StringSliceP("port", "p", []string{}, "Port forwarding (host:container), can be used multiple times")
+	cmd.Flags().StringArrayP("env", "e", []string{}, "Set an environment variable (KEY=VALUE), can be used multiple times")
+	cmd.Flags().StringArray("env-file", []string{}, "Load environment variables from a dotenv file, can be used multiple times")
 
 	return cmd
 }
 
 func newDownCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "down",
 		Short: "Stop and remove dev container for current project",
 		Long: `Stop and remove the development container for the current project.
@@ -106,34 +304,19 @@ resources. The container can be recreated with 'reactor up'.
 
 Examples:
   reactor down                             # Stop and remove current project container
+  reactor down --name feature-x            # Stop and remove a named session
 
 For more details, see the full documentation.`,
 		RunE: downCmdHandler,
 	}
-}
-
-func newExecCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "exec <command...>",
-		Short: "Execute command in running dev container",
-		Long: `Execute a command inside the running development container.
 
-The container must already be running (started with 'reactor up'). This is
-useful for running tests, builds, or other commands inside the container.
-
-Examples:
-  reactor exec npm test                    # Run npm test inside container
-  reactor exec -- ls -la                  # Run ls command (use -- for flags)
+	cmd.Flags().String("name", "", "Session name given to 'reactor up --name'")
 
-For more details, see the full documentation.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("exec command not yet implemented - this will be added in Milestone 2")
-		},
-	}
+	return cmd
 }
 
 func newBuildCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "build",
 		Short: "Build dev container image from devcontainer.json",
 		Long: `Build the development container image based on devcontainer.json.
@@ -141,13 +324,61 @@ func newBuildCmd() *cobra.Command {
 This command only builds the container image without starting it. Use this
 when you want to pre-build images or verify the build process.
 
+With --scan, or when 'customizations.reactor.scan' is set in devcontainer.json,
+the built image is scanned with trivy or grype (whichever is installed). The
+build fails if vulnerabilities at or above the severity threshold are found,
+unless the scan is configured with "warnOnly": true.
+
+The build context is filtered by .dockerignore (or <Dockerfile>.dockerignore);
+.git and node_modules are always left out unless re-included with "!" patterns.
+Use --context-filter to list the files that would be sent without building.
+
+The full build output is always written to ~/.reactor/<account>/<project-hash>/build.log.
+With --progress tty (the default on a terminal) only the current build step is
+shown and a one-line summary is printed on success; if the build fails, the last
+lines of the log are printed. --progress plain streams the full output.
+
 Examples:
   reactor build                            # Build container image
+  reactor build --progress plain           # Stream the full build output
+  reactor build --config backend           # Build .devcontainer/backend/devcontainer.json
+  reactor build --context-filter           # Show what the build context contains
   reactor build --no-cache                # Build without using cache
+  reactor build --scan                     # Build and fail on high/critical vulnerabilities
+  reactor build --scan --scan-severity critical
+  reactor build --sbom                     # Also write an SPDX SBOM of the image
+
+Private package registries can be reached during the build without baking
+credentials into a layer: list BuildKit secrets and SSH agents in devcontainer.json,
+written as for 'docker build --secret' and '--ssh', and mount them in the Dockerfile
+with RUN --mount=type=secret,id=<id> or RUN --mount=type=ssh:
+
+  "customizations": {"reactor": {"build": {
+    "secrets": ["id=npmrc,src=~/.npmrc", "id=npm_token,env=NPM_TOKEN"],
+    "ssh": ["default"]
+  }}}
+
+Relative src paths start at the devcontainer.json directory. Builds with secrets
+or ssh run through the docker CLI, which must be installed, and pull base images
+with its own registry credentials.
+
+Built images are labelled with the build time (org.opencontainers.image.created),
+the reactor version (com.reactor.version) and a sha256 of the devcontainer.json
+they were built from (com.reactor.devcontainer-hash). --sbom writes a software
+bill of materials of the image, generated with syft or trivy, to
+~/.reactor/<account>/<project-hash>/sbom.spdx.json.
 
 For more details, see the full documentation.`,
 		RunE: buildCmdHandler,
 	}
+
+	cmd.Flags().String("progress", docker.ProgressAuto, "Build output: auto, plain (full output) or tty (current step only)")
+	cmd.Flags().Bool("context-filter", false, "List the files that would be sent as the build context, then exit without building")
+	cmd.Flags().Bool("scan", false, "Scan the built image for vulnerabilities")
+	cmd.Flags().String("scan-severity", "", "Minimum severity that fails the scan: low, medium, high, critical (default: high)")
+	cmd.Flags().Bool("sbom", false, "Write an SPDX SBOM of the built image to the project config directory (needs syft or trivy)")
+
+	return cmd
 }
 
 func newDiffCmd() *cobra.Command {
@@ -158,17 +389,31 @@ func newDiffCmd() *cobra.Command {
 
 This command is particularly useful for discovery mode to understand what
 configuration files and directories an AI agent creates. Without arguments,
-it operates on the discovery container for the current project.
+it operates on the current project's running container, or on its discovery
+container when none is running. When several sessions of the project are
+running, the default one is used; pick another with --container, or the
+discovery container with --discovery.
+
+With --workspace, it instead lists the changes captured in the overlay of a
+container started with 'reactor up --read-only-workspace'. Add --patch to
+render them as a unified diff that can be reviewed or applied with 'git apply'.
 
 Examples:
-  reactor diff                                    # Diff current project's discovery container
-  reactor diff reactor-discovery-cam-myproject   # Diff specific container by name
+  reactor diff                                    # Diff current project's running container
+  reactor diff --discovery                        # Diff current project's discovery container
+  reactor diff --container reactor-cam-myproject-abc123  # Diff specific container by name
+  reactor diff --workspace                        # List read-only workspace changes
+  reactor diff --patch > agent.patch              # Export workspace changes as a patch
 
 For more details, see the full documentation.`,
 		RunE: diffCmdHandler,
 	}
 
-	cmd.Flags().Bool("discovery", false, "Run in discovery mode (no file mounts)")
+	cmd.Flags().String("container", "", "Diff this container instead of the project's running one")
+	cmd.Flags().Bool("discovery", false, "Diff the project's discovery container")
+	cmd.Flags().String("name", "", "Session name of the container (see 'reactor up --name')")
+	cmd.Flags().Bool("workspace", false, "Show changes captured by a read-only workspace overlay")
+	cmd.Flags().Bool("patch", false, "Print read-only workspace changes as a unified diff (implies --workspace)")
 
 	return cmd
 }
@@ -187,6 +432,9 @@ Examples:
   reactor accounts list           # List all configured accounts
   reactor accounts show          # Show current account
   reactor accounts set work      # Switch to work account
+  reactor accounts export work work.tar.gz   # Package an account to move it
+  reactor accounts import work.tar.gz        # Restore it on another machine
+  reactor accounts login ghcr.io             # Store registry credentials for the account
 
 For more details, see the full documentation.`,
 	}
@@ -213,6 +461,52 @@ For more details, see the full documentation.`,
 		RunE:  accountsSetHandler,
 	})
 
+	exportCmd := &cobra.Command{
+		Use:   "export <account-name> <file.tar.gz>",
+		Short: "Export an account to an archive",
+		Long: `Package an account's defaults and provider config directories into a
+gzipped tar archive so agent state can be moved to another machine.
+
+The archive contains ~/.reactor/<account>/defaults.json and the provider
+directories (e.g. claude, gemini) of every project. Overlays, build logs and
+other per-machine state are not included. account.env, registries.json and files that look like
+credentials (tokens, OAuth and API keys) are left out unless --include-secrets
+is given; treat such an archive like a password.
+
+Project state is keyed by a hash of the project's absolute path, so it is picked
+up on the new machine when the project is checked out at the same path.
+
+Examples:
+  reactor accounts export work work.tar.gz
+  reactor accounts export work work.tar.gz --include-secrets`,
+		Args: cobra.ExactArgs(2),
+		RunE: accountsExportHandler,
+	}
+	exportCmd.Flags().Bool("include-secrets", false, "Include account.env and credential files in the archive")
+	cmd.AddCommand(exportCmd)
+
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import an account from an archive",
+		Long: `Restore an account exported with 'reactor accounts export'.
+
+The account keeps its original name unless --as is given. Importing into an
+account that already exists fails unless --force is given, in which case the
+archived files overwrite existing ones and other files are kept.
+
+Examples:
+  reactor accounts import work.tar.gz
+  reactor accounts import work.tar.gz --as work-laptop`,
+		Args: cobra.ExactArgs(1),
+		RunE: accountsImportHandler,
+	}
+	importCmd.Flags().String("as", "", "Import under a different account name")
+	importCmd.Flags().Bool("force", false, "Overwrite files of an existing account")
+	cmd.AddCommand(importCmd)
+
+	cmd.AddCommand(newAccountsLoginCmd())
+	cmd.AddCommand(newAccountsLogoutCmd())
+
 	return cmd
 }
 
@@ -229,19 +523,118 @@ container images configured independently.
 Examples:
   reactor config init                # Initialize project configuration
   reactor config show               # Display current configuration
+  reactor config list               # List the devcontainer configurations of the project
+  reactor config validate --strict  # Check for unsupported devcontainer.json properties
+  reactor config explain            # Show where each setting's value comes from
   reactor config set provider claude # Set AI provider to claude
   reactor config get account        # Get current account setting
 
 For more details, see the full documentation.`,
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	showCmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show resolved configuration",
 		Long:  "Display current configuration hierarchy and account directory locations",
 		RunE:  configShowHandler,
+	}
+	cmd.AddCommand(showCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the project's devcontainer configurations",
+		Long: `List the devcontainer.json files of the current project.
+
+Besides the default .devcontainer/devcontainer.json (or .devcontainer.json), a
+repo can publish several configurations as .devcontainer/<name>/devcontainer.json,
+for example one per service of a monorepo. Select one with 'reactor up --config <name>'.`,
+		Args: cobra.NoArgs,
+		RunE: configListHandler,
 	})
 
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the project's devcontainer.json",
+		Long: `Validate the devcontainer.json for the current project.
+
+The configuration is parsed and resolved exactly as 'reactor up' would. With
+--strict, every property is also checked against the Dev Container
+specification and the command fails if the file uses properties that reactor
+does not implement or that are not part of the specification (such as typos),
+so you find out before they are silently ignored at runtime.
+
+Examples:
+  reactor config validate             # Check the configuration resolves
+  reactor config validate --strict    # Also report unsupported properties
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: configValidateHandler,
+	}
+	validateCmd.Flags().Bool("strict", false, "Fail on devcontainer.json properties that reactor does not support")
+	cmd.AddCommand(validateCmd)
+
+	explainCmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Show where each resolved setting comes from",
+		Long: `Show every reactor setting with its resolved value and the layer that supplied it.
+
+Settings are resolved with the same precedence everywhere, highest first:
+  1. command-line flag (e.g. --account)
+  2. REACTOR_* environment variable (e.g. REACTOR_ACCOUNT, REACTOR_DEFAULT_COMMAND)
+  3. project devcontainer.json (including customizations.reactor)
+  4. account defaults (~/.reactor/<account>/defaults.json); for the account
+     itself, the account in the user preferences (~/.reactor/config.yaml)
+  5. builtin default
+
+With output: json in ~/.reactor/config.yaml (or REACTOR_OUTPUT=json) the
+settings are printed as JSON unless --format is given.
+
+Environment variables passed to the container are merged separately, later wins:
+  1. containerEnv from devcontainer.json (${localEnv:VAR} is expanded)
+  2. the account env file (~/.reactor/<account>/account.env)
+  3. files given with --env-file (or env_file of a workspace service), in order
+  4. -e/--env overrides
+Use 'reactor env' to see the resulting environment and where each value came from.
+
+"credentialScope" selects where provider config directories such as ~/.claude
+come from: "project" (default) keeps them per project in
+~/.reactor/<account>/<project-hash>/, "account" shares
+~/.reactor/<account>/shared/ between every project of the account, so agents only
+need to log in once. Existing containers keep their mounts until 'reactor down'.
+
+"credentialEncryption" (account defaults or the environment only) keeps those
+directories encrypted on disk: "age" uses the age CLI with an identity in
+~/.reactor/<account>/age-identity.txt, "keychain" a key in the macOS keychain or
+the Secret Service on Linux. They are decrypted into memory-only mounts when the
+container starts and re-encrypted when an 'up' session ends and on 'reactor down'.
+Changes are lost if the container is stopped any other way. Default "off".
+
+"shellHistory" (default true) mounts ~/.reactor/<account>/<project-hash>/history
+at /reactor-history and points HISTFILE, NODE_REPL_HISTORY and PYTHON_HISTORY
+there, so shell and REPL history survives 'reactor down' and rebuilds.
+
+"hostRegistries" (account defaults or the environment only) lists registries
+whose images are pulled with the host's Docker credentials when the account has
+none stored with 'reactor accounts login'. Default: none.
+
+Desktop notifications are set in account defaults or the environment only:
+"notify": true shows one when an image build or workspace up that took at least
+"notifyAfter" seconds (default 30) finishes or fails. They use osascript on
+macOS and notify-send on Linux.
+
+Examples:
+  reactor config explain                  # Explain the current project's settings
+  reactor config explain --account work   # Explain with an account override
+  reactor config explain --format json    # Machine-readable output
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: configExplainHandler,
+	}
+	explainCmd.Flags().String("format", "table", "Output format: table or json")
+	cmd.AddCommand(explainCmd)
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "get <key>",
 		Short: "Get configuration value",
@@ -307,14 +700,17 @@ development contexts without losing your work.
 
 Examples:
   reactor sessions list          # Show all reactor containers  
+  reactor sessions list --stats  # Include CPU, memory and PIDs
   reactor sessions attach        # Auto-attach to current project
   reactor sessions attach name   # Attach to specific container
+  reactor sessions rename name fix-login-bug --note "OAuth redirect"  # Label a session
+  reactor sessions adopt name    # Backfill labels of a container made by an older reactor
 
 For more details, see the full documentation.`,
 	}
 
 	// Add subcommands
-	cmd.AddCommand(&cobra.Command{
+	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all reactor containers",
 		Long: `List all reactor containers with their status and project information.
@@ -322,27 +718,66 @@ For more details, see the full documentation.`,
 Shows containers across all accounts and projects, including both running and
 stopped containers. Use this to see what development environments are available.
 
+The ACCOUNT and PROJECT columns show who each container was created for. Narrow
+the list with --account, and with --project given a project directory or its
+name.
+
+UPTIME shows how long a running container has been up, or how long ago a
+stopped one stopped. The most recently used containers come first: running ones
+by when they started, then the others by when they stopped. Use --sort name to
+order them by name.
+
+With --stats, the CPU usage, memory usage and limit, and number of processes of
+each running container are sampled once and shown, which helps spot runaway
+agent processes. Sampling takes about a second.
+
 For more details, see the full documentation.`,
 		RunE: sessionsListHandler,
-	})
+	}
+	listCmd.Flags().Bool("stats", false, "Show CPU, memory and process count of running containers")
+	listCmd.Flags().String("sort", "recent", "Order of the list: recent (running first, then most recently stopped) or name")
+	listCmd.Flags().String("project", "", "Only list containers of this project directory, or of projects with this name")
+	cmd.AddCommand(listCmd)
 
-	cmd.AddCommand(&cobra.Command{
-		Use:   "attach [container-name]",
+	attachCmd := &cobra.Command{
+		Use:   "attach [container-name | alias | -]",
 		Short: "Attach to a container session",
 		Long: `Attach to a specific container session by name, or auto-attach to the current project's container.
 
 Without arguments, automatically finds and attaches to the container for the current
-project. With a container name, attaches to that specific container. Stopped
+project. With a container name, or an alias given with 'reactor sessions rename',
+attaches to that specific container. With '-',
+re-attaches to the container you most recently used from any project. Stopped
 containers are automatically started before attachment.
 
+By default an interactive bash shell is started; --command runs a different
+command (through /bin/sh -c) instead. With --propagate-exit reactor exits with the
+shell's or command's exit code, so scripts can tell whether the run succeeded;
+without it reactor exits 0 once the session ends.
+
+When 'reactor up' is still installing tools or running postCreateCommand in the
+container, attach waits until it has finished, showing which step is running.
+--no-wait attaches straight away with a warning.
+
 Examples:
   reactor sessions attach                           # Auto-attach to current project
+  reactor sessions attach --name feature-x          # Auto-attach to a named session
   reactor sessions attach reactor-cam-myproject-abc123  # Attach to specific container
+  reactor sessions attach -                         # Re-attach to the last used container
+  reactor sessions attach - --command claude        # Run claude in the last used container
+  reactor sessions attach --command claude --propagate-exit  # Exit with claude's exit code
 
 For more details, see the full documentation.`,
 		RunE: sessionsAttachHandler,
 		Args: cobra.MaximumNArgs(1),
-	})
+	}
+	attachCmd.Flags().String("name", "", "Session name of the current project's container to attach to")
+	attachCmd.Flags().String("command", "", "Command to run on attach instead of the default shell")
+	attachCmd.Flags().Bool("propagate-exit", false, "Exit with the exit code of the session's shell or command")
+	attachCmd.Flags().Bool("no-wait", false, "Attach while 'reactor up' is still provisioning the container")
+	cmd.AddCommand(attachCmd)
+	cmd.AddCommand(newSessionsRenameCmd())
+	cmd.AddCommand(newSessionsAdoptCmd())
 
 	cmd.AddCommand(&cobra.Command{
 		Use:   "clean",
@@ -395,24 +830,40 @@ To install completions:
 	return cmd
 }
 
-func newVersionCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "version",
-		Short: "Show version information",
-		Long:  "Display version, build date, and git commit information",
-		Run:   versionHandler,
-	}
-}
-
 // Command handlers
 func upCmdHandler(cmd *cobra.Command, args []string) error {
 	// Get CLI flags
 	accountOverride, _ := cmd.Flags().GetString("account")
+	configName, _ := cmd.Flags().GetString("config")
 	rebuild, _ := cmd.Flags().GetBool("rebuild")
+	recreateOnDrift, _ := cmd.Flags().GetBool("recreate-on-drift")
 	discoveryMode, _ := cmd.Flags().GetBool("discovery-mode")
 	dockerHostIntegration, _ := cmd.Flags().GetBool("docker-host-integration")
+	allowPrivileged, _ := cmd.Flags().GetBool("allow-privileged")
+	rerunHooks, _ := cmd.Flags().GetBool("rerun-hooks")
+	replace, _ := cmd.Flags().GetBool("replace")
+	noInit, _ := cmd.Flags().GetBool("no-init")
+	readOnlyWorkspace, _ := cmd.Flags().GetBool("read-only-workspace")
+	cloneRepo, _ := cmd.Flags().GetString("clone-repo")
+	fixPermissions, _ := cmd.Flags().GetBool("fix-permissions")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	showProfile, _ := cmd.Flags().GetBool("profile")
+	useDevcontainerCLI, _ := cmd.Flags().GetBool("use-devcontainer-cli")
+	onDemand, _ := cmd.Flags().GetBool("on-demand")
+	idleTimeout, _ := cmd.Flags().GetDuration("idle-timeout")
+	watchConfig, _ := cmd.Flags().GetBool("watch-config")
+	ephemeral, _ := cmd.Flags().GetBool("ephemeral")
+	propagateExit, _ := cmd.Flags().GetBool("propagate-exit")
+	sessionName, _ := cmd.Flags().GetString("name")
 	portMappings, _ := cmd.Flags().GetStringSlice("port")
+	// The engine preference gives way to options only reactor's own engine supports
+	if !cmd.Flags().Changed("use-devcontainer-cli") && userPreferences().UseDevcontainerCLI() {
+		useDevcontainerCLI = !dryRun && !discoveryMode && !ephemeral && !onDemand && !noInit && !readOnlyWorkspace && cloneRepo == "" && len(portMappings) == 0
+	}
+	envOverrides, _ := cmd.Flags().GetStringArray("env")
+	envFiles, _ := cmd.Flags().GetStringArray("env-file")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	profile := metrics.NewProfile()
 
 	// Get current working directory as project directory
 	projectDirectory, err := os.Getwd()
@@ -424,24 +875,101 @@ func upCmdHandler(cmd *cobra.Command, args []string) error {
 	upConfig := orchestrator.UpConfig{
 		ProjectDirectory:      projectDirectory,
 		AccountOverride:       accountOverride,
+		ConfigName:            configName,
 		ForceRebuild:          rebuild,
+		RecreateOnDrift:       recreateOnDrift,
 		CLIPortMappings:       portMappings,
+		EnvFiles:              envFiles,
+		EnvOverrides:          envOverrides,
 		DiscoveryMode:         discoveryMode,
 		DockerHostIntegration: dockerHostIntegration,
+		Ephemeral:             ephemeral,
+		AllowPrivileged:       allowPrivileged,
+		RerunHooks:            rerunHooks,
+		ReplaceConflicting:    replace,
+		DisableInit:           noInit,
+		ReadOnlyWorkspace:     readOnlyWorkspace,
+		CloneRepo:             cloneRepo,
+		FixPermissions:        fixPermissions,
+		DryRun:                dryRun,
+		UseDevcontainerCLI:    useDevcontainerCLI,
+		SessionName:           sessionName,
 		Verbose:               verbose,
+		Profile:               profile,
+		Events:                printProgress(os.Stdout, os.Stderr),
+	}
+
+	if idleTimeout != 0 && !onDemand {
+		return fmt.Errorf("--idle-timeout requires --on-demand")
+	}
+	if watchConfig && (dryRun || onDemand) {
+		return fmt.Errorf("--watch-config cannot be used with --dry-run or --on-demand")
+	}
+	if ephemeral && (onDemand || watchConfig) {
+		return fmt.Errorf("--ephemeral cannot be used with --on-demand or --watch-config")
+	}
+	if onDemand {
+		if dryRun || discoveryMode || useDevcontainerCLI {
+			return fmt.Errorf("--on-demand cannot be used with --dry-run, --discovery-mode or --use-devcontainer-cli")
+		}
+		// The container is created on the first connection, when nobody can be asked
+		proxy, ports, err := newOnDemandProxy(upConfig, idleTimeout, "")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Listening on %s; the container starts on the first connection. Press Ctrl+C to stop.\n", describeListenPorts(ports))
+		return serveOnDemand(map[string]*ondemand.Proxy{"": proxy})
+	}
+
+	upConfig.ConfirmPrivileges = terminalPrivilegesPrompt()
+	upConfig.ConfirmRerunHooks = terminalRerunHooksPrompt()
+	upConfig.ConfirmReplace = terminalReplacePrompt()
+
+	for {
+		rebuild, err := upAndAttach(upConfig, showProfile, watchConfig, propagateExit)
+		if err != nil || !rebuild {
+			return err
+		}
+		// The configuration changed during the session: rebuild and recreate
+		upConfig.ForceRebuild = true
+		upConfig.RecreateOnDrift = true
+		upConfig.Profile = metrics.NewProfile()
+	}
+}
+
+// upAndAttach starts the container and attaches the session. With watchConfig it reports
+// whether the user asked to rebuild after the configuration changed during the session.
+// With propagateExit a session that exits non-zero returns its *docker.ExitError.
+func upAndAttach(upConfig orchestrator.UpConfig, showProfile, watchConfig, propagateExit bool) (bool, error) {
+	dryRun, discoveryMode, ephemeral, verbose := upConfig.DryRun, upConfig.DiscoveryMode, upConfig.Ephemeral, upConfig.Verbose
+	sessionName, profile := upConfig.SessionName, upConfig.Profile
+
+	// A warm pool the container was claimed from is refilled while the session runs,
+	// and the refill is waited for so no half-created container is left behind
+	var poolRefill sync.WaitGroup
+	defer poolRefill.Wait()
+	upConfig.DeferPoolRefill = func(refill func()) {
+		poolRefill.Add(1)
+		go func() {
+			defer poolRefill.Done()
+			refill()
+		}()
 	}
 
 	// Call orchestrator Up function
 	ctx := context.Background()
-	_, containerID, err := orchestrator.Up(ctx, upConfig)
+	resolved, containerID, err := orchestrator.Up(ctx, upConfig)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if dryRun {
+		return false, nil
 	}
 
 	// Initialize Docker service for session attachment
 	dockerService, err := docker.NewService()
 	if err != nil {
-		return fmt.Errorf("failed to initialize Docker service: %w", err)
+		return false, fmt.Errorf("failed to initialize Docker service: %w", err)
 	}
 	defer func() {
 		if err := dockerService.Close(); err != nil {
@@ -456,15 +984,82 @@ func upCmdHandler(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Attaching to container session...\n")
 	}
 
-	if err := dockerService.AttachInteractiveSession(ctx, containerID); err != nil {
-		return fmt.Errorf("failed to attach to container session: %w", err)
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	if !discoveryMode && !ephemeral {
+		recordLastSession(containerName, resolved.ProjectRoot)
+	}
+
+	var watcher *configWatcher
+	if watchConfig {
+		watcher = newConfigWatcher(configWatchFiles(resolved), func(path string) { printConfigChanged(os.Stderr, path) })
+		watcher.Start(ctx, configWatchInterval)
+	}
+
+	dockerService.SetProfile(profile)
+	exitErr, sessionErr := sessionExit(dockerService.AttachInteractiveSession(ctx, containerID), propagateExit)
+	if watcher != nil {
+		watcher.Stop()
+	}
+
+	// A throwaway container leaves nothing behind
+	if ephemeral {
+		if err := dockerService.RemoveContainer(ctx, containerID); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove ephemeral container: %v\n", err)
+		} else {
+			fmt.Printf("\nSession ended. Ephemeral container removed.\n")
+		}
+		if showProfile {
+			fmt.Println()
+			profile.Print(os.Stdout)
+		}
+		if sessionErr != nil {
+			return false, fmt.Errorf("failed to attach to container session: %w", sessionErr)
+		}
+		if exitErr != nil {
+			return false, exitErr
+		}
+		return false, nil
+	}
+
+	// Keep startup timings so slow startups can be compared across runs
+	if err := metrics.Save(resolved.ProjectConfigDir, profile); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if showProfile {
+		fmt.Println()
+		profile.Print(os.Stdout)
+	}
+
+	if sessionErr != nil {
+		return false, fmt.Errorf("failed to attach to container session: %w", sessionErr)
+	}
+
+	// Save credentials the agent changed now, in case the container is stopped without 'reactor down'
+	if !discoveryMode {
+		if info, err := dockerService.ContainerExists(ctx, containerName); err == nil {
+			if err := orchestrator.SaveCredentials(ctx, dockerService, info); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save encrypted credentials: %v\n", err)
+			}
+		}
+	}
+
+	if watcher != nil && len(watcher.Changed()) > 0 {
+		if confirm := terminalRebuildPrompt(); confirm != nil {
+			rebuild, err := confirm(watcher.Changed())
+			if err != nil || rebuild {
+				return rebuild, err
+			}
+		}
 	}
 
 	// Inform user about container state after session ends
 	fmt.Printf("\nSession ended. Container is still running.\n")
 	fmt.Printf("Use 'docker stop %s' to stop it.\n", containerID)
 
-	return nil
+	if exitErr != nil {
+		return false, exitErr
+	}
+	return false, nil
 }
 
 func downCmdHandler(cmd *cobra.Command, args []string) error {
@@ -474,12 +1069,35 @@ func downCmdHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get current directory: %w", err)
 	}
 
+	sessionName, _ := cmd.Flags().GetString("name")
+
 	// Call orchestrator Down function
 	ctx := context.Background()
-	return orchestrator.Down(ctx, projectDirectory)
+	return orchestrator.Down(ctx, projectDirectory, sessionName, printProgress(os.Stdout, os.Stderr))
 }
 
 func diffCmdHandler(cmd *cobra.Command, args []string) error {
+	explicitName, _ := cmd.Flags().GetString("container")
+	discovery, _ := cmd.Flags().GetBool("discovery")
+	if len(args) > 0 {
+		if explicitName != "" {
+			return fmt.Errorf("give the container either as an argument or with --container, not both")
+		}
+		explicitName = args[0]
+	}
+	if discovery && explicitName != "" {
+		return fmt.Errorf("--discovery cannot be combined with a container name")
+	}
+	sessionName, _ := cmd.Flags().GetString("name")
+	if sessionName != "" {
+		if discovery || explicitName != "" {
+			return fmt.Errorf("--name cannot be combined with --discovery or a container name")
+		}
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+	}
+
 	// Check dependencies first
 	if err := config.CheckDependencies(); err != nil {
 		return err
@@ -492,6 +1110,13 @@ func diffCmdHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Read-only workspace changes live on the host, so no Docker access is needed
+	workspaceMode, _ := cmd.Flags().GetBool("workspace")
+	patchMode, _ := cmd.Flags().GetBool("patch")
+	if workspaceMode || patchMode {
+		return workspaceDiff(resolved, sessionName, patchMode)
+	}
+
 	// Initialize Docker service
 	ctx := context.Background()
 	dockerService, err := docker.NewService()
@@ -509,14 +1134,29 @@ func diffCmdHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("docker daemon not available: %w", err)
 	}
 
-	// Determine container name to diff
-	var containerName string
-	if len(args) > 0 {
-		// User provided specific container name
-		containerName = args[0]
-	} else {
-		// Default to discovery container for current project
-		containerName = core.GenerateDiscoveryContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash)
+	// Determine container name to diff: as given, else the project's running
+	// container, else its discovery container
+	discoveryName := core.GenerateDiscoveryContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash)
+	containerName := explicitName
+	if discovery {
+		containerName = discoveryName
+	}
+	if sessionName != "" {
+		containerName = core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	}
+	if containerName == "" {
+		containers, err := dockerService.ListContainersByLabel(ctx, orchestrator.ProjectLabel, resolved.ProjectRoot)
+		if err != nil {
+			return fmt.Errorf("failed to list project containers: %w", err)
+		}
+		defaultName := core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash)
+		if containerName, err = activeSession(containers, resolved.Account, defaultName, discoveryName); err != nil {
+			return err
+		}
+	}
+	fellBack := containerName == ""
+	if fellBack {
+		containerName = discoveryName
 	}
 
 	// Check if container exists
@@ -526,7 +1166,13 @@ func diffCmdHandler(cmd *cobra.Command, args []string) error {
 	}
 
 	if containerInfo.Status == docker.StatusNotFound {
-		return fmt.Errorf("container %s not found. Run discovery mode first: reactor run --discovery-mode", containerName)
+		switch {
+		case fellBack:
+			return fmt.Errorf("no running container or discovery container found for this project. Start one with 'reactor up', or run discovery mode first: reactor run --discovery-mode")
+		case discovery:
+			return fmt.Errorf("container %s not found. Run discovery mode first: reactor run --discovery-mode", containerName)
+		}
+		return fmt.Errorf("container %s not found", containerName)
 	}
 
 	// Get container diff
@@ -549,7 +1195,37 @@ func diffCmdHandler(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// workspaceDiff reports the changes captured in the read-only workspace overlay of a session
+func workspaceDiff(resolved *config.ResolvedConfig, sessionName string, asPatch bool) error {
+	upperDir, _ := overlay.Dirs(core.SessionConfigDir(resolved.ProjectConfigDir, sessionName))
+	changes, err := overlay.Changes(resolved.ProjectRoot, upperDir)
+	if err != nil {
+		return err
+	}
+
+	if asPatch {
+		return overlay.WritePatch(os.Stdout, resolved.ProjectRoot, upperDir, changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No changes detected in read-only workspace.")
+		return nil
+	}
+
+	fmt.Printf("Read-only workspace changes for %s:\n", resolved.ProjectRoot)
+	for _, change := range changes {
+		fmt.Printf("%s %s\n", change.Kind, change.Path)
+	}
+
+	return nil
+}
+
 func buildCmdHandler(cmd *cobra.Command, args []string) error {
+	progress, _ := cmd.Flags().GetString("progress")
+	if _, err := docker.ParseProgressMode(progress); err != nil {
+		return err
+	}
+
 	// Check dependencies first
 	if err := config.CheckDependencies(); err != nil {
 		return err
@@ -567,23 +1243,6 @@ func buildCmdHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no build configuration found in devcontainer.json. Add a 'build' property to enable building")
 	}
 
-	// Initialize Docker service
-	ctx := context.Background()
-	dockerService, err := docker.NewService()
-	if err != nil {
-		return fmt.Errorf("failed to initialize Docker service: %w", err)
-	}
-	defer func() {
-		if err := dockerService.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
-		}
-	}()
-
-	// Check Docker daemon health
-	if err := dockerService.CheckHealth(ctx); err != nil {
-		return fmt.Errorf("docker daemon not available: %w", err)
-	}
-
 	// Create a minimal up config to build the image
 	// Get current working directory as project directory
 	projectDirectory, err := os.Getwd()
@@ -603,60 +1262,96 @@ func buildCmdHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to change to project directory %s: %w", projectDirectory, err)
 	}
 
-	// Create BuildSpec from resolved configuration using the same logic as orchestrator
-	if resolved.Build == nil {
-		return fmt.Errorf("build configuration is nil")
-	}
-
-	// Find the devcontainer.json file to determine context base directory
-	configPath, found, err := config.FindDevContainerFile(resolved.ProjectRoot)
+	// Build the image exactly as 'reactor up' would, including its provenance labels
+	buildSpec, err := orchestrator.BuildSpecFor(resolved)
 	if err != nil {
-		return fmt.Errorf("failed to find devcontainer.json: %w", err)
-	}
-	if !found {
-		return fmt.Errorf("devcontainer.json not found")
+		return err
 	}
+	buildSpec.Progress = progress
+	imageName := buildSpec.ImageName
 
-	// Get directory containing devcontainer.json
-	configDir := filepath.Dir(configPath)
+	// List the build context instead of building when debugging .dockerignore
+	if contextFilter, _ := cmd.Flags().GetBool("context-filter"); contextFilter {
+		return printBuildContext(buildSpec.Context, buildSpec.Dockerfile)
+	}
 
-	// Resolve build context relative to devcontainer.json directory
-	var contextPath string
-	if resolved.Build.Context != "" {
-		if filepath.IsAbs(resolved.Build.Context) {
-			contextPath = resolved.Build.Context
-		} else {
-			contextPath = filepath.Join(configDir, resolved.Build.Context)
+	// Initialize Docker service
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
 		}
-	} else {
-		// Default context to same directory as devcontainer.json
-		contextPath = configDir
+	}()
+
+	// Check Docker daemon health
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+	registryAuth, err := orchestrator.RegistryResolver(resolved)
+	if err != nil {
+		return err
 	}
+	dockerService.SetRegistryAuth(registryAuth)
 
-	// Clean the path
-	contextPath = filepath.Clean(contextPath)
+	// Force rebuild for explicit build command
+	previousID, _ := dockerService.ImageID(ctx, imageName)
+	buildStart := time.Now()
+	err = dockerService.BuildImage(ctx, buildSpec, true)
+	notify.Finished(notify.FromSettings(resolved.Settings), "Image build", buildStart, err)
+	if err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
 
-	// Dockerfile defaults to "Dockerfile" if not specified
-	dockerfile := resolved.Build.Dockerfile
-	if dockerfile == "" {
-		dockerfile = "Dockerfile"
+	fmt.Printf("Build completed successfully.\n")
+	// The previous build is kept while the project's container still runs it; 'reactor up
+	// --recreate-on-drift' removes it along with the container
+	if removed, err := dockerService.RemoveReplacedImage(ctx, imageName, previousID); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if removed {
+		fmt.Printf("Removed the previous build of %s.\n", imageName)
 	}
 
-	// Create image name using project hash
-	imageName := fmt.Sprintf("reactor-build:%s", resolved.ProjectHash)
+	if sbomFlag, _ := cmd.Flags().GetBool("sbom"); sbomFlag {
+		if err := writeImageSBOM(ctx, imageName, resolved.ProjectConfigDir); err != nil {
+			return err
+		}
+	}
 
-	buildSpec := docker.BuildSpec{
-		Dockerfile: dockerfile,
-		Context:    contextPath,
-		ImageName:  imageName,
+	// Scan the image when requested on the command line or in devcontainer.json
+	scanFlag, _ := cmd.Flags().GetBool("scan")
+	scanSeverity, _ := cmd.Flags().GetString("scan-severity")
+	scanConfig := resolved.Scan
+	if scanConfig == nil && (scanFlag || scanSeverity != "") {
+		scanConfig = &config.ScanConfig{}
+	}
+	if scanConfig != nil {
+		if scanSeverity != "" {
+			scanConfig.Severity = scanSeverity
+		}
+		if err := scanImage(ctx, imageName, scanConfig); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Force rebuild for explicit build command
-	if err := dockerService.BuildImage(ctx, buildSpec, true); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+// printBuildContext lists the files a build would send to the daemon
+func printBuildContext(contextPath, dockerfile string) error {
+	files, err := docker.ListBuildContext(contextPath, dockerfile)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Build completed successfully.\n")
+	var total int64
+	for _, file := range files {
+		fmt.Printf("%10s  %s\n", units.HumanSize(float64(file.Size)), file.Path)
+		total += file.Size
+	}
+	fmt.Printf("\n%d files, %s would be sent from %s\n", len(files), units.HumanSize(float64(total)), contextPath)
 	return nil
 }
 
@@ -676,6 +1371,53 @@ func accountsShowHandler(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func accountsExportHandler(cmd *cobra.Command, args []string) error {
+	includeSecrets, _ := cmd.Flags().GetBool("include-secrets")
+	account, archivePath := args[0], args[1]
+
+	f, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	summary, err := config.ExportAccount(account, f, includeSecrets)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write archive: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(archivePath)
+		return err
+	}
+
+	fmt.Printf("Exported account '%s' (%d files) to %s\n", summary.Account, len(summary.Files), archivePath)
+	if len(summary.SkippedSecrets) > 0 {
+		fmt.Printf("Left out %d secret file(s); use --include-secrets to export them:\n", len(summary.SkippedSecrets))
+		for _, file := range summary.SkippedSecrets {
+			fmt.Printf("  %s\n", file)
+		}
+	}
+	return nil
+}
+
+func accountsImportHandler(cmd *cobra.Command, args []string) error {
+	as, _ := cmd.Flags().GetString("as")
+	force, _ := cmd.Flags().GetBool("force")
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	summary, err := config.ImportAccount(f, as, force)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported account '%s' (%d files)\n", summary.Account, len(summary.Files))
+	fmt.Printf("Use it with: reactor config set account %s\n", summary.Account)
+	return nil
+}
+
 func accountsSetHandler(cmd *cobra.Command, args []string) error {
 	// Find the devcontainer.json file to show where to edit
 	configPath, found, err := config.FindDevContainerFile(".")
@@ -695,7 +1437,8 @@ func accountsSetHandler(cmd *cobra.Command, args []string) error {
 	fmt.Printf("      \"account\": \"%s\"\n", args[0])
 	fmt.Printf("    }\n")
 	fmt.Printf("  }\n")
-	fmt.Printf("}\n")
+	fmt.Printf("}\n\n")
+	fmt.Printf("To use it for a single command instead, pass --account %s or set REACTOR_ACCOUNT=%s.\n", args[0], args[0])
 	return nil
 }
 
@@ -704,36 +1447,147 @@ func configShowHandler(cmd *cobra.Command, args []string) error {
 	return configService.ShowConfiguration()
 }
 
-func configGetHandler(cmd *cobra.Command, args []string) error {
-	key := args[0]
-	configService := config.NewService()
-
-	// Try to resolve configuration to show current values
-	resolved, err := configService.ResolveConfiguration()
+func configListHandler(cmd *cobra.Command, args []string) error {
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	files, err := config.ListDevContainerFiles(projectRoot)
 	if err != nil {
 		return err
 	}
+	if len(files) == 0 {
+		return fmt.Errorf("no devcontainer.json found. Run 'reactor init' to create one")
+	}
 
-	switch key {
-	case "account":
-		fmt.Printf("%s\n", resolved.Account)
-	case "image":
-		fmt.Printf("%s\n", resolved.Image)
-	default:
-		// Find the devcontainer.json file to show where to check
-		configPath, found, findErr := config.FindDevContainerFile(".")
-		if findErr != nil {
-			return fmt.Errorf("error finding devcontainer.json: %w", findErr)
+	fmt.Printf("%-20s %-25s %s\n", "CONFIG", "NAME", "PATH")
+	fmt.Printf("%-20s %-25s %s\n", strings.Repeat("-", 20), strings.Repeat("-", 25), strings.Repeat("-", 40))
+	for _, file := range files {
+		configName := file.Name
+		if configName == "" {
+			configName = "(default)"
 		}
-		if !found {
-			return fmt.Errorf("no devcontainer.json found")
+		name := ""
+		if devConfig, err := config.LoadDevContainerConfig(file.Path); err != nil {
+			name = "(invalid)"
+		} else {
+			name = devConfig.Name
+		}
+		path, err := filepath.Rel(projectRoot, file.Path)
+		if err != nil {
+			path = file.Path
 		}
+		fmt.Printf("%-20s %-25s %s\n", configName, name, path)
+	}
+	return nil
+}
 
-		fmt.Printf("For configuration key '%s', check your devcontainer.json file:\n", key)
-		fmt.Printf("  %s\n", configPath)
-		fmt.Printf("See https://containers.dev/implementors/json_reference/ for available options.\n")
+func configExplainHandler(cmd *cobra.Command, args []string) error {
+	accountOverride, _ := cmd.Flags().GetString("account")
+	format, _ := cmd.Flags().GetString("format")
+	if !cmd.Flags().Changed("format") && userPreferences().JSONOutput() {
+		format = "json"
+	}
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid format '%s': must be 'table' or 'json'", format)
+	}
+
+	configService := config.NewService()
+	if accountOverride != "" {
+		configService.SetOverrides(map[string]string{config.SettingAccount: accountOverride})
+	}
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+
+	settings := resolved.Settings.Explain()
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(settings)
+	}
+
+	fmt.Printf("%-16s %-40s %-8s %s\n", "SETTING", "VALUE", "SOURCE", "ORIGIN")
+	fmt.Printf("%-16s %-40s %-8s %s\n", "-------", "-----", "------", "------")
+	for _, setting := range settings {
+		value := setting.Value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Printf("%-16s %-40s %-8s %s\n", setting.Key, value, setting.Source, setting.Origin)
+	}
+	return nil
+}
+
+func configValidateHandler(cmd *cobra.Command, args []string) error {
+	style := ui.Stdout()
+	strict, _ := cmd.Flags().GetBool("strict")
+
+	configPath, found, err := config.FindDevContainerFile(".")
+	if err != nil {
+		return fmt.Errorf("error finding devcontainer.json: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("no devcontainer.json found in .devcontainer/devcontainer.json or .devcontainer.json")
+	}
+
+	configService := config.NewService()
+	if _, err := configService.ResolveConfiguration(); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s is valid\n", style.Success(), configPath)
+
+	if !strict {
+		return nil
+	}
+
+	findings, err := devcontainer.CheckFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	problems := devcontainer.Problems(findings)
+	if len(problems) == 0 {
+		fmt.Printf("%s All %d properties are supported by reactor\n", style.Success(), len(findings))
+		return nil
+	}
+
+	fmt.Printf("\n%s The following properties will not take effect:\n", style.Warning())
+	for _, problem := range problems {
+		fmt.Printf("   %s\n", problem)
+	}
+	return fmt.Errorf("%d of %d properties are not supported by reactor", len(problems), len(findings))
+}
+
+func configGetHandler(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	configService := config.NewService()
+
+	// Try to resolve configuration to show current values
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if setting, ok := resolved.Settings.Lookup(key); ok {
+		fmt.Printf("%s\n", setting.Value)
+		return nil
+	}
+
+	// Find the devcontainer.json file to show where to check
+	configPath, found, findErr := config.FindDevContainerFile(".")
+	if findErr != nil {
+		return fmt.Errorf("error finding devcontainer.json: %w", findErr)
+	}
+	if !found {
+		return fmt.Errorf("no devcontainer.json found")
 	}
 
+	fmt.Printf("For configuration key '%s', check your devcontainer.json file:\n", key)
+	fmt.Printf("  %s\n", configPath)
+	fmt.Printf("See https://containers.dev/implementors/json_reference/ for available options.\n")
+
 	return nil
 }
 
@@ -802,12 +1656,6 @@ func configInitHandler(cmd *cobra.Command, args []string) error {
 	}
 }
 
-func versionHandler(cmd *cobra.Command, args []string) {
-	fmt.Printf("reactor version %s\n", Version)
-	fmt.Printf("Git commit: %s\n", GitCommit)
-	fmt.Printf("Build date: %s\n", BuildDate)
-}
-
 func completionHandler(cmd *cobra.Command, args []string) error {
 	shell := args[0]
 
@@ -825,6 +1673,15 @@ func completionHandler(cmd *cobra.Command, args []string) error {
 
 // Session command handlers
 func sessionsListHandler(cmd *cobra.Command, args []string) error {
+	showStats, _ := cmd.Flags().GetBool("stats")
+	sortOrder, _ := cmd.Flags().GetString("sort")
+	if !slices.Contains(sessionSortOrders, sortOrder) {
+		return fmt.Errorf("invalid --sort '%s': use one of %s", sortOrder, strings.Join(sessionSortOrders, ", "))
+	}
+	var filter sessionFilter
+	filter.account, _ = cmd.Flags().GetString("account")
+	filter.project, _ = cmd.Flags().GetString("project")
+
 	// Check dependencies first
 	if err := config.CheckDependencies(); err != nil {
 		return err
@@ -858,14 +1715,54 @@ func sessionsListHandler(cmd *cobra.Command, args []string) error {
 		fmt.Println("Run 'reactor run' to create a new container session.")
 		return nil
 	}
+	if filter != (sessionFilter{}) {
+		containers = filterSessions(containers, filter)
+		if len(containers) == 0 {
+			fmt.Println("No reactor containers match the --account and --project filters.")
+			return nil
+		}
+	}
+
+	ids := make([]string, len(containers))
+	for i, container := range containers {
+		ids[i] = container.ID
+	}
+	times := dockerService.InspectContainerTimes(ctx, ids)
+	sortSessions(containers, times, sortOrder)
+
+	var stats map[string]docker.ContainerStats
+	if showStats {
+		var running []string
+		for _, container := range containers {
+			if container.Status == docker.StatusRunning {
+				running = append(running, container.ID)
+			}
+		}
+		stats = dockerService.CollectStats(ctx, running)
+	}
 
 	// Display containers in a table format
-	fmt.Printf("%-35s %-8s %-25s %-10s\n", "CONTAINER NAME", "STATUS", "IMAGE", "UPTIME")
-	fmt.Printf("%-35s %-8s %-25s %-10s\n",
+	style := ui.Stdout()
+	notes := loadSessionNotes()
+	now := time.Now()
+	fmt.Printf("%-35s %-12s %-20s %-15s %-20s %-8s %-25s %-16s", "CONTAINER NAME", "ACCOUNT", "PROJECT", "SESSION", "ALIAS", "STATUS", "IMAGE", "UPTIME")
+	if showStats {
+		fmt.Printf(" "+statsHeader, "CPU %", "MEM USAGE / LIMIT", "PIDS")
+	}
+	fmt.Printf(" NOTE")
+	fmt.Printf("\n%-35s %-12s %-20s %-15s %-20s %-8s %-25s %-16s",
 		strings.Repeat("-", 35),
+		strings.Repeat("-", 12),
+		strings.Repeat("-", 20),
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 20),
 		strings.Repeat("-", 8),
 		strings.Repeat("-", 25),
-		strings.Repeat("-", 10))
+		strings.Repeat("-", 16))
+	if showStats {
+		fmt.Printf(" "+statsHeader, strings.Repeat("-", 8), strings.Repeat("-", 21), strings.Repeat("-", 6))
+	}
+	fmt.Printf(" %s\n", strings.Repeat("-", 4))
 
 	for _, container := range containers {
 		status := "unknown"
@@ -884,15 +1781,43 @@ func sessionsListHandler(cmd *cobra.Command, args []string) error {
 			image = image[:22] + "..."
 		}
 
-		// For now, show "-" for uptime since we don't have that info easily available
-		// Could be enhanced to calculate from container inspection
-		uptime := "-"
+		uptime := sessionUptime(container, times[container.ID], now)
+
+		// Containers started without --name are the project's default session
+		session := container.Labels[core.SessionLabel]
+		if session == "" {
+			session = "default"
+		}
+
+		alias := notes[container.Name].Alias
+		if alias == "" {
+			alias = "-"
+		}
+
+		// The project's directory name; the full path is in the container's labels
+		account, project := sessionOwner(container)
+		if account == "" {
+			account = "-"
+		}
+		if project == "" {
+			project = "-"
+		} else {
+			project = filepath.Base(project)
+		}
 
-		fmt.Printf("%-35s %-8s %-25s %-10s\n", container.Name, status, image, uptime)
+		fmt.Printf("%-35s %-12s %-20s %-15s %-20s %s %-25s %-16s", container.Name, account, project, session, alias, style.State(fmt.Sprintf("%-8s", status)), image, uptime)
+		if showStats {
+			sample, ok := stats[container.ID]
+			fmt.Printf(" %s", statsColumns(sample, ok))
+		}
+		fmt.Printf(" %s\n", notes[container.Name].Note)
 	}
 
 	fmt.Printf("\nFound %d reactor container(s).\n", len(containers))
-	fmt.Println("Use 'reactor sessions attach <container-name>' to connect to a container.")
+	fmt.Println("Use 'reactor sessions attach <container-name>' to connect to a container, or 'reactor sessions rename' to label it.")
+	if unlabelled := countUnadopted(containers); unlabelled > 0 {
+		fmt.Printf("%d container(s) have no reactor labels, so some commands cannot manage them; run 'reactor sessions adopt <container-name>' to fix them.\n", unlabelled)
+	}
 
 	return nil
 }
@@ -922,8 +1847,21 @@ func sessionsAttachHandler(cmd *cobra.Command, args []string) error {
 	}
 
 	var containerName string
+	projectRoot := ""
 
-	if len(args) == 0 {
+	if len(args) == 1 && args[0] == "-" {
+		// Re-attach to the most recently used container, whichever project it belongs to
+		last, err := state.LastSession()
+		if err != nil {
+			return err
+		}
+		if last == nil {
+			return fmt.Errorf("no recently used container recorded. Run 'reactor up' or 'reactor sessions attach' first")
+		}
+		containerName = last.ContainerName
+		projectRoot = last.ProjectRoot
+		fmt.Printf("Last used container: %s\n", containerName)
+	} else if len(args) == 0 {
 		// Auto-attach to current project container
 		// Load configuration to get project info
 		configService := config.NewService()
@@ -932,10 +1870,23 @@ func sessionsAttachHandler(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to load project configuration: %w", err)
 		}
 
-		// Find container for current project
-		containerInfo, err := dockerService.FindProjectContainer(ctx, resolved.Account, resolved.ProjectRoot, resolved.ProjectHash)
-		if err != nil {
-			return fmt.Errorf("failed to find project container: %w", err)
+		// Find container for current project, optionally for a named session
+		var containerInfo *docker.ContainerInfo
+		if sessionName, _ := cmd.Flags().GetString("name"); sessionName != "" {
+			name := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+			info, err := dockerService.ContainerExists(ctx, name)
+			if err != nil {
+				return fmt.Errorf("failed to find project container: %w", err)
+			}
+			if info.Status == docker.StatusNotFound {
+				return fmt.Errorf("no session %q found for current project. Run 'reactor up --name %s' to create one", sessionName, sessionName)
+			}
+			containerInfo = &info
+		} else {
+			containerInfo, err = dockerService.FindProjectContainer(ctx, resolved.Account, resolved.ProjectRoot, resolved.ProjectHash)
+			if err != nil {
+				return fmt.Errorf("failed to find project container: %w", err)
+			}
 		}
 
 		if containerInfo == nil {
@@ -943,21 +1894,24 @@ func sessionsAttachHandler(cmd *cobra.Command, args []string) error {
 		}
 
 		containerName = containerInfo.Name
+		projectRoot = resolved.ProjectRoot
 		fmt.Printf("Found container for current project: %s\n", containerName)
 	} else {
-		// Use specified container name
+		// Use specified container name, or the container with that alias
 		containerName = args[0]
 	}
 
 	// Check if container exists and get its info
-	containerInfo, err := dockerService.ContainerExists(ctx, containerName)
+	containerInfo, err := findSessionContainer(ctx, dockerService, containerName)
 	if err != nil {
-		return fmt.Errorf("failed to check container status: %w", err)
+		return err
 	}
 
 	if containerInfo.Status == docker.StatusNotFound {
 		return fmt.Errorf("container '%s' not found", containerName)
 	}
+	containerName = containerInfo.Name
+	recordLastSession(containerName, projectRoot)
 
 	// Start container if it's stopped
 	if containerInfo.Status == docker.StatusStopped {
@@ -968,19 +1922,43 @@ func sessionsAttachHandler(cmd *cobra.Command, args []string) error {
 		fmt.Println("Container started successfully.")
 	}
 
+	// A container reused by a 'reactor up' still running its postCreateCommand is not ready
+	noWait, _ := cmd.Flags().GetBool("no-wait")
+	if err := waitForProvisioning(ctx, containerName, containerInfo.ID, noWait, os.Stdout); err != nil {
+		return err
+	}
+
 	// Attach to the container
 	fmt.Printf("Attaching to container: %s\n", containerName)
-	if err := dockerService.AttachInteractiveSession(ctx, containerInfo.ID); err != nil {
-		return fmt.Errorf("failed to attach to container: %w", err)
+	var attachErr error
+	if command, _ := cmd.Flags().GetString("command"); command != "" {
+		attachErr = dockerService.AttachSessionCommand(ctx, containerInfo.ID, []string{"/bin/sh", "-c", command})
+	} else {
+		attachErr = dockerService.AttachInteractiveSession(ctx, containerInfo.ID)
+	}
+	propagateExit, _ := cmd.Flags().GetBool("propagate-exit")
+	exitErr, attachErr := sessionExit(attachErr, propagateExit)
+	if attachErr != nil {
+		return fmt.Errorf("failed to attach to container: %w", attachErr)
 	}
 
 	// Show exit message
 	fmt.Printf("\nSession ended. Container '%s' is still running.\n", containerName)
 	fmt.Printf("Use 'docker stop %s' to stop it.\n", containerName)
 
+	if exitErr != nil {
+		return exitErr
+	}
 	return nil
 }
 
+// recordLastSession remembers the container for 'reactor sessions attach -'
+func recordLastSession(containerName, projectRoot string) {
+	if err := state.RecordSession(containerName, projectRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record last used container: %v\n", err)
+	}
+}
+
 func sessionsCleanHandler(cmd *cobra.Command, args []string) error {
 	// Check dependencies first
 	if err := config.CheckDependencies(); err != nil {
@@ -1022,10 +2000,16 @@ func sessionsCleanHandler(cmd *cobra.Command, args []string) error {
 	}
 
 	// Clean up all containers using standard removal
-	removedCount := 0
+	var removed []string
 	for _, container := range containers {
 		fmt.Printf("Removing container: %s ... ", container.Name)
 
+		// Keep the container if its encrypted credentials cannot be saved
+		if err := orchestrator.SaveCredentials(ctx, dockerService, container); err != nil {
+			fmt.Printf("failed to save encrypted credentials, container kept: %v\n", err)
+			continue
+		}
+
 		// Use standard container removal
 		err := dockerService.RemoveContainer(ctx, container.ID)
 		if err != nil {
@@ -1033,9 +2017,13 @@ func sessionsCleanHandler(cmd *cobra.Command, args []string) error {
 			// Continue with other containers
 		} else {
 			fmt.Println("done")
-			removedCount++
+			removed = append(removed, container.Name)
 		}
 	}
+	removedCount := len(removed)
+	if err := state.ForgetContainers(removed...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove session notes: %v\n", err)
+	}
 
 	fmt.Printf("\nSuccessfully cleaned up %d of %d reactor containers.\n", removedCount, len(containers))
 	return nil
@@ -1051,51 +2039,93 @@ The workspace commands allow you to orchestrate multiple dev containers defined
 in a reactor-workspace.yml file. This is ideal for microservice development
 where you need to run multiple services simultaneously.
 
+A workspace file can layer personal tweaks on top of a shared team file with
+'extends:'. Services are merged by name and fields set in the extending file win:
+
+  # reactor-workspace.local.yml
+  extends: reactor-workspace.yml
+  services:
+    api:
+      account: personal
+
+The workspace can also be generated by another tool and piped in with '-f -',
+or fetched with '-f https://...'; pin the expected content with --checksum.
+Service paths and 'extends' are then resolved from the current directory, and
+the same -f source must be passed to later commands such as 'workspace down'.
+
+A service can run on another Docker daemon, such as a remote build machine,
+with 'docker_host: tcp://build-box:2376' or 'context: build-box' (a docker CLI
+context). The other services keep using DOCKER_HOST; 'workspace list' shows
+the daemon of each service, and the other commands find each service on it.
+
 Examples:
+  reactor workspace init --from-compose docker-compose.yml
   reactor workspace validate           # Validate workspace configuration
+  reactor workspace up -f reactor-workspace.local.yml
+  gen-workspace | reactor workspace up -f -
+  reactor workspace up -f https://example.com/ws.yml --checksum sha256:<hex>
   reactor workspace list             # List services and their status
   reactor workspace up               # Start all services
   reactor workspace down             # Stop all services
+  reactor workspace ps               # List started workspaces in any directory
 
 For more details, see the full documentation.`,
 	}
 
 	// Add --file / -f flag to all workspace commands
-	cmd.PersistentFlags().StringP("file", "f", "", "Path to workspace file (default: reactor-workspace.yml)")
+	cmd.PersistentFlags().StringP("file", "f", "", "Workspace file, directory, '-' for stdin or an https:// URL (default: reactor-workspace.yml)")
+	cmd.PersistentFlags().String("checksum", "", "Expected sha256:<hex> digest of a workspace read from stdin or a URL")
 
 	// Add subcommands for PR 1 and PR 2
+	cmd.AddCommand(newWorkspaceInitCmd())
 	cmd.AddCommand(newWorkspaceValidateCmd())
 	cmd.AddCommand(newWorkspaceListCmd())
 	cmd.AddCommand(newWorkspaceUpCmd())
 	cmd.AddCommand(newWorkspaceDownCmd())
 	cmd.AddCommand(newWorkspaceExecCmd())
+	cmd.AddCommand(newWorkspacePsCmd())
 
 	return cmd
 }
 
 func newWorkspaceValidateCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate workspace configuration",
 		Long: `Validate the reactor-workspace.yml file and all service configurations.
 
 This command parses the workspace file and validates:
-- Workspace file syntax and version
+- Workspace file syntax and schema version
 - Service path existence and accessibility  
+- Service names that differ only by case
 - Each service's devcontainer.json file validity
 - Path traversal security checks
 
+Workspace files declare the schema version they are written for. This reactor
+understands version "1"; a file for a newer schema is reported with a hint to
+upgrade reactor.
+
+With --fix, common mistakes are corrected in the file before it is validated:
+a missing version (or one written as "1.0"), service paths such as "./api/" or
+absolute paths inside the workspace, and identical services whose names differ
+only by case. Comments are kept, but the file is re-indented.
+
 Examples:
   reactor workspace validate                    # Validate default workspace file
   reactor workspace validate -f my-workspace.yml  # Validate specific file
+  reactor workspace validate --fix              # Correct common mistakes first
 
 For more details, see the full documentation.`,
 		RunE: workspaceValidateHandler,
 	}
+
+	cmd.Flags().Bool("fix", false, "Correct common mistakes in the workspace file before validating it")
+
+	return cmd
 }
 
 func newWorkspaceListCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List workspace services and their status",
 		Long: `List all services defined in the workspace with their container status.
@@ -1104,69 +2134,135 @@ Shows each service name, path, account, and current container status (running,
 stopped, or not found). This gives you a complete overview of your workspace
 state at a glance.
 
+With --watch, the table is refreshed until interrupted and status transitions
+(for example starting → running → unhealthy) are logged beneath it, which is
+useful while a large workspace comes up.
+
+With --stats, the CPU usage, memory usage and limit, and number of processes of
+each running service container are sampled once per refresh and shown.
+
 Examples:
   reactor workspace list                       # List services in default workspace
   reactor workspace list -f my-workspace.yml  # List services in specific workspace
+  reactor workspace list --watch               # Refresh status live
+  reactor workspace list --stats               # Include CPU, memory and PIDs
 
 For more details, see the full documentation.`,
 		RunE: workspaceListHandler,
 	}
+
+	cmd.Flags().BoolP("watch", "w", false, "Refresh the status table until interrupted")
+	cmd.Flags().Duration("interval", 2*time.Second, "Refresh interval for --watch")
+	cmd.Flags().Bool("stats", false, "Show CPU, memory and process count of running services")
+
+	return cmd
 }
 
-// workspaceValidateHandler validates a workspace file and all its services
-func workspaceValidateHandler(cmd *cobra.Command, args []string) error {
-	// Get workspace file path from flag or use default
+// resolveWorkspaceSource locates the workspace named by --file: a file, a directory
+// containing reactor-workspace.yml, "-" for stdin or an https:// URL. Definitions read
+// from stdin or a URL are returned as data together with a stable path in the current
+// directory; data is nil for workspace files on disk.
+func resolveWorkspaceSource(cmd *cobra.Command) (string, []byte, error) {
 	workspaceFile, _ := cmd.Flags().GetString("file")
+	checksum, _ := cmd.Flags().GetString("checksum")
 
-	// Handle workspace file path
-	var workspacePath string
-	if workspaceFile != "" {
-		// User specified a specific file path
-		if filepath.Ext(workspaceFile) != "" {
-			// It's a file path, use it directly
-			workspacePath = workspaceFile
-		} else {
-			// It's a directory, find workspace file in it
-			var found bool
-			var err error
-			workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
-			if err != nil {
-				return fmt.Errorf("error finding workspace file: %w", err)
-			}
-			if !found {
-				return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
-			}
+	if workspace.IsStreamSource(workspaceFile) {
+		data, err := workspace.ReadSource(workspaceFile, cmd.InOrStdin(), checksum)
+		if err != nil {
+			return "", nil, err
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get current directory: %w", err)
 		}
+		return workspace.SourcePath(workspaceFile, cwd), data, nil
+	}
 
-		// Check if the specified file exists
-		if _, err := os.Stat(workspacePath); err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("workspace file not found: %s", workspacePath)
-			}
-			return fmt.Errorf("error accessing workspace file %s: %w", workspacePath, err)
+	if checksum != "" {
+		return "", nil, fmt.Errorf("--checksum can only be used when the workspace is read from stdin (-f -) or a URL")
+	}
+
+	if workspaceFile == "" {
+		workspacePath, found, err := workspace.FindWorkspaceFile("")
+		if err != nil {
+			return "", nil, fmt.Errorf("error finding workspace file: %w", err)
 		}
-	} else {
-		// No file specified, find default workspace file in current directory
+		if !found {
+			return "", nil, fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in current directory")
+		}
+		return workspacePath, nil, nil
+	}
+
+	workspacePath := workspaceFile
+	if filepath.Ext(workspaceFile) == "" {
+		// It's a directory, find workspace file in it
 		var found bool
 		var err error
-		workspacePath, found, err = workspace.FindWorkspaceFile("")
+		workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
 		if err != nil {
-			return fmt.Errorf("error finding workspace file: %w", err)
+			return "", nil, fmt.Errorf("error finding workspace file: %w", err)
 		}
 		if !found {
-			return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in current directory")
+			return "", nil, fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
+		}
+	}
+
+	if _, err := os.Stat(workspacePath); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, fmt.Errorf("workspace file not found: %s", workspacePath)
+		}
+		return "", nil, fmt.Errorf("error accessing workspace file %s: %w", workspacePath, err)
+	}
+	return workspacePath, nil, nil
+}
+
+// parseWorkspace parses a workspace located by resolveWorkspaceSource
+func parseWorkspace(workspacePath string, data []byte) (*workspace.Workspace, error) {
+	if data != nil {
+		return workspace.ParseWorkspaceData(data, workspacePath)
+	}
+	return workspace.ParseWorkspaceFile(workspacePath)
+}
+
+// workspaceValidateHandler validates a workspace file and all its services
+func workspaceValidateHandler(cmd *cobra.Command, args []string) error {
+	style := ui.Stdout()
+
+	// Get workspace file path from flag or use default
+	workspacePath, workspaceData, err := resolveWorkspaceSource(cmd)
+	if err != nil {
+		return err
+	}
+
+	fix, _ := cmd.Flags().GetBool("fix")
+	if fix {
+		if workspaceData != nil {
+			return fmt.Errorf("--fix needs a workspace file to write to, not stdin or a URL")
+		}
+		if err := fixWorkspaceFile(workspacePath); err != nil {
+			return err
 		}
 	}
 
-	// Parse and validate workspace file
-	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	ws, err := parseWorkspace(workspacePath, workspaceData)
 	if err != nil {
 		return fmt.Errorf("workspace validation failed: %w", err)
 	}
 
-	fmt.Printf("✓ Workspace file valid: %s\n", workspacePath)
+	fmt.Printf("%s Workspace file valid: %s\n", style.Success(), workspacePath)
 	fmt.Printf("  Version: %s\n", ws.Version)
-	fmt.Printf("  Services: %d\n\n", len(ws.Services))
+	fmt.Printf("  Services: %d\n", len(ws.Services))
+	if !fix && workspaceData == nil {
+		if data, err := os.ReadFile(workspacePath); err == nil {
+			if _, fixes, err := workspace.FixWorkspaceData(data, workspacePath); err == nil && len(fixes) > 0 {
+				fmt.Println("  Fixable with --fix:")
+				for _, description := range fixes {
+					fmt.Printf("    - %s\n", description)
+				}
+			}
+		}
+	}
+	fmt.Println()
 
 	// Validate each service's devcontainer.json
 	validServices := 0
@@ -1176,6 +2272,17 @@ func workspaceValidateHandler(cmd *cobra.Command, args []string) error {
 		if service.Account != "" {
 			fmt.Printf("  Account: %s\n", service.Account)
 		}
+		if service.DockerHost != "" || service.Context != "" {
+			host, err := serviceDockerHost(service)
+			if err != nil {
+				fmt.Printf("  %s %v\n\n", style.Failure(), err)
+				continue
+			}
+			fmt.Printf("  Docker host: %s\n", describeDockerHost(host))
+			if _, isRemote, _ := tunnel.DetectRemote(host); isRemote && !service.SharedPaths {
+				fmt.Printf("  %s The project and ~/.reactor are bind mounted from this machine, which a remote daemon cannot do; set shared_paths: true when they exist at the same paths there\n", style.Warning())
+			}
+		}
 
 		// Resolve service path relative to workspace file
 		workspaceDir := filepath.Dir(workspacePath)
@@ -1187,11 +2294,11 @@ func workspaceValidateHandler(cmd *cobra.Command, args []string) error {
 		// Check for devcontainer.json in service directory
 		devcontainerPath, found, err := config.FindDevContainerFile(servicePath)
 		if err != nil {
-			fmt.Printf("  ✗ Error checking devcontainer.json: %v\n\n", err)
+			fmt.Printf("  %s Error checking devcontainer.json: %v\n\n", style.Failure(), err)
 			continue
 		}
 		if !found {
-			fmt.Printf("  ✗ No devcontainer.json found\n\n")
+			fmt.Printf("  %s No devcontainer.json found\n\n", style.Failure())
 			continue
 		}
 
@@ -1199,92 +2306,86 @@ func workspaceValidateHandler(cmd *cobra.Command, args []string) error {
 		configService := config.NewServiceWithRoot(servicePath)
 		_, err = configService.ResolveConfiguration()
 		if err != nil {
-			fmt.Printf("  ✗ Invalid devcontainer.json: %v\n\n", err)
+			fmt.Printf("  %s Invalid devcontainer.json: %v\n\n", style.Failure(), err)
 			continue
 		}
 
-		fmt.Printf("  ✓ devcontainer.json: %s\n\n", devcontainerPath)
+		fmt.Printf("  %s devcontainer.json: %s\n\n", style.Success(), devcontainerPath)
 		validServices++
 	}
 
 	// Summary
 	totalServices := len(ws.Services)
 	if validServices == totalServices {
-		fmt.Printf("✓ All %d services validated successfully\n", totalServices)
+		fmt.Printf("%s All %d services validated successfully\n", style.Success(), totalServices)
 	} else {
-		fmt.Printf("✗ %d of %d services validated successfully\n", validServices, totalServices)
+		fmt.Printf("%s %d of %d services validated successfully\n", style.Failure(), validServices, totalServices)
 		return fmt.Errorf("workspace validation failed: %d service(s) have configuration errors", totalServices-validServices)
 	}
 
 	return nil
 }
 
+// fixWorkspaceFile applies workspace.FixWorkspaceData to a workspace file in place
+func fixWorkspaceFile(workspacePath string) error {
+	info, err := os.Stat(workspacePath)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace file: %w", err)
+	}
+	data, err := os.ReadFile(workspacePath)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace file: %w", err)
+	}
+	fixed, fixes, err := workspace.FixWorkspaceData(data, workspacePath)
+	if err != nil {
+		return fmt.Errorf("cannot fix %s: %w", workspacePath, err)
+	}
+	if len(fixes) == 0 {
+		fmt.Printf("Nothing to fix in %s\n\n", workspacePath)
+		return nil
+	}
+	if err := os.WriteFile(workspacePath, fixed, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write workspace file: %w", err)
+	}
+	fmt.Printf("Fixed %s:\n", workspacePath)
+	for _, description := range fixes {
+		fmt.Printf("  - %s\n", description)
+	}
+	fmt.Println()
+	return nil
+}
+
 // workspaceListHandler lists services and their container status
 func workspaceListHandler(cmd *cobra.Command, args []string) error {
 	// Get workspace file path from flag or use default
-	workspaceFile, _ := cmd.Flags().GetString("file")
-
-	// Handle workspace file path
-	var workspacePath string
-	if workspaceFile != "" {
-		// User specified a specific file path
-		if filepath.Ext(workspaceFile) != "" {
-			// It's a file path, use it directly
-			workspacePath = workspaceFile
-		} else {
-			// It's a directory, find workspace file in it
-			var found bool
-			var err error
-			workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
-			if err != nil {
-				return fmt.Errorf("error finding workspace file: %w", err)
-			}
-			if !found {
-				return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
-			}
-		}
-
-		// Check if the specified file exists
-		if _, err := os.Stat(workspacePath); err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("workspace file not found: %s", workspacePath)
-			}
-			return fmt.Errorf("error accessing workspace file %s: %w", workspacePath, err)
-		}
-	} else {
-		// No file specified, find default workspace file in current directory
-		var found bool
-		var err error
-		workspacePath, found, err = workspace.FindWorkspaceFile("")
-		if err != nil {
-			return fmt.Errorf("error finding workspace file: %w", err)
-		}
-		if !found {
-			return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in current directory")
-		}
+	workspacePath, workspaceData, err := resolveWorkspaceSource(cmd)
+	if err != nil {
+		return err
 	}
 
-	// Parse workspace file
-	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	ws, err := parseWorkspace(workspacePath, workspaceData)
 	if err != nil {
 		return fmt.Errorf("failed to parse workspace file: %w", err)
 	}
 
-	// Initialize Docker service to check container status
+	// Connect to each Docker daemon the services run on to check container status
 	ctx := context.Background()
-	dockerService, err := docker.NewService()
+	endpoints, err := newWorkspaceEndpoints(ws)
 	if err != nil {
-		return fmt.Errorf("failed to initialize Docker service: %w", err)
+		return err
 	}
-	defer func() {
-		if err := dockerService.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
-		}
-	}()
+	defer endpoints.Close()
 
-	// Check Docker daemon health
-	if err := dockerService.CheckHealth(ctx); err != nil {
-		return fmt.Errorf("docker daemon not available: %w", err)
+	// Check Docker daemon health; services on an unreachable daemon are shown as such
+	hosts := endpoints.allHosts()
+	unreachable := endpoints.checkHealth(ctx, hosts)
+	for _, host := range hosts {
+		if err, ok := unreachable[host]; ok {
+			if len(unreachable) == len(hosts) {
+				return fmt.Errorf("docker daemon not available: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: docker daemon %s not available: %v\n", describeDockerHost(host), err)
+		}
 	}
 
 	// Generate workspace hash for container labeling
@@ -1293,19 +2394,50 @@ func workspaceListHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate workspace hash: %w", err)
 	}
 
+	showStats, _ := cmd.Flags().GetBool("stats")
+	watch, _ := cmd.Flags().GetBool("watch")
+	if watch {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval <= 0 {
+			return fmt.Errorf("--interval must be greater than zero")
+		}
+		return watchWorkspaceStatus(ctx, endpoints, ws, workspacePath, workspaceHash, interval, showStats)
+	}
+
+	printWorkspaceStatus(ctx, endpoints, ws, workspacePath, workspaceHash, showStats)
+	return nil
+}
+
+// printWorkspaceStatus renders the service status table and returns each service's status.
+// With showStats the resource usage of running service containers is sampled and shown.
+// Services on several Docker daemons are shown in one table with a HOST column.
+func printWorkspaceStatus(ctx context.Context, endpoints *workspaceEndpoints, ws *workspace.Workspace, workspacePath, workspaceHash string, showStats bool) map[string]string {
+	statuses := make(map[string]string, len(ws.Services))
+
 	fmt.Printf("Workspace: %s\n", workspacePath)
 	fmt.Printf("Services: %d\n\n", len(ws.Services))
 
-	// Display header
-	fmt.Printf("%-15s %-30s %-15s %-10s\n", "SERVICE", "PATH", "ACCOUNT", "STATUS")
-	fmt.Printf("%-15s %-30s %-15s %-10s\n",
-		strings.Repeat("-", 15),
-		strings.Repeat("-", 30),
-		strings.Repeat("-", 15),
-		strings.Repeat("-", 10))
+	// Rows are printed once every service is inspected, so stats can be sampled in parallel
+	type statusRow struct {
+		line        string
+		containerID string
+	}
+	style := ui.Stdout()
+	var rows []statusRow
+	running := map[string][]string{} // running container IDs by Docker host
+	showHosts := endpoints.multiHost()
+
+	// Sort service names so the table keeps a stable order between refreshes
+	serviceNames := make([]string, 0, len(ws.Services))
+	for serviceName := range ws.Services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
 
 	// Check status for each service
-	for serviceName, service := range ws.Services {
+	for _, serviceName := range serviceNames {
+		service := ws.Services[serviceName]
+
 		// Resolve service path for project hash calculation
 		workspaceDir := filepath.Dir(workspacePath)
 		servicePath := service.Path
@@ -1317,18 +2449,25 @@ func workspaceListHandler(cmd *cobra.Command, args []string) error {
 		projectHash := config.GenerateProjectHash(servicePath)
 		expectedContainerName := fmt.Sprintf("reactor-ws-%s-%s", serviceName, projectHash)
 
-		// Check container status
-		containerInfo, err := dockerService.ContainerExists(ctx, expectedContainerName)
+		// Check container status on the daemon the service runs on
+		host := endpoints.host(serviceName)
 		status := "not found"
+		var containerInfo docker.ContainerInfo
+		dockerService, err := endpoints.forHost(host)
 		if err == nil {
-			switch containerInfo.Status {
-			case docker.StatusRunning:
-				status = "running"
-			case docker.StatusStopped:
-				status = "stopped"
-			case docker.StatusNotFound:
-				status = "not found"
-			}
+			containerInfo, err = dockerService.ContainerExists(ctx, expectedContainerName)
+		}
+		switch {
+		case err != nil && showHosts:
+			status = "unreachable"
+		case err == nil:
+			status = serviceStatus(containerInfo)
+		}
+		statuses[serviceName] = status
+		row := statusRow{}
+		if containerInfo.Status == docker.StatusRunning {
+			row.containerID = containerInfo.ID
+			running[host] = append(running[host], containerInfo.ID)
 		}
 
 		// Truncate path if too long for display
@@ -1352,12 +2491,79 @@ func workspaceListHandler(cmd *cobra.Command, args []string) error {
 			account = account[:12] + "..."
 		}
 
-		fmt.Printf("%-15s %-30s %-15s %-10s\n", serviceName, displayPath, account, status)
+		row.line = fmt.Sprintf("%-15s %-30s %-15s %s", serviceName, displayPath, account, style.State(fmt.Sprintf("%-11s", status)))
+		if showHosts {
+			displayHost := describeDockerHost(host)
+			if len(displayHost) > 25 {
+				displayHost = displayHost[:22] + "..."
+			}
+			row.line += fmt.Sprintf(" %-25s", displayHost)
+		}
+		rows = append(rows, row)
 	}
 
-	fmt.Printf("\nWorkspace Hash: %s\n", workspaceHash[:16]+"...") // Show first 16 chars of hash
+	// Container IDs are unique across daemons, so each daemon's samples merge into one map
+	stats := map[string]docker.ContainerStats{}
+	if showStats {
+		for host, ids := range running {
+			if dockerService, err := endpoints.forHost(host); err == nil {
+				for id, sample := range dockerService.CollectStats(ctx, ids) {
+					stats[id] = sample
+				}
+			}
+		}
+	}
 
-	return nil
+	// Display header
+	fmt.Printf("%-15s %-30s %-15s %-11s", "SERVICE", "PATH", "ACCOUNT", "STATUS")
+	if showHosts {
+		fmt.Printf(" %-25s", "HOST")
+	}
+	if showStats {
+		fmt.Printf(" "+statsHeader, "CPU %", "MEM USAGE / LIMIT", "PIDS")
+	}
+	fmt.Printf("\n%-15s %-30s %-15s %-11s",
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 30),
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 11))
+	if showHosts {
+		fmt.Printf(" %-25s", strings.Repeat("-", 25))
+	}
+	if showStats {
+		fmt.Printf(" "+statsHeader, strings.Repeat("-", 8), strings.Repeat("-", 21), strings.Repeat("-", 6))
+	}
+	fmt.Println()
+
+	for _, row := range rows {
+		fmt.Print(row.line)
+		if showStats {
+			sample, ok := stats[row.containerID]
+			fmt.Printf(" %s", statsColumns(sample, ok && row.containerID != ""))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\nWorkspace Hash: %s\n", workspaceHash[:16]+"...") // Show first 16 chars of hash
+
+	return statuses
+}
+
+// serviceStatus describes a workspace container's lifecycle and health state
+func serviceStatus(info docker.ContainerInfo) string {
+	switch {
+	case info.State == "created" || info.State == "restarting":
+		return "starting"
+	case info.Status == docker.StatusRunning && info.Health == "starting":
+		return "starting"
+	case info.Status == docker.StatusRunning && info.Health == "unhealthy":
+		return "unhealthy"
+	case info.Status == docker.StatusRunning:
+		return "running"
+	case info.Status == docker.StatusStopped:
+		return "stopped"
+	}
+	return "not found"
 }
 
 func newWorkspaceUpCmd() *cobra.Command {
@@ -1371,15 +2577,84 @@ port conflict checking and colored output streaming. Each service is started
 using its devcontainer.json configuration with workspace-specific labeling
 and naming conventions.
 
+Before any service starts, the images of all services are pulled in parallel.
+Services that share an image (e.g. the same base image) trigger a single pull.
+
+A service's env_file (a dotenv file relative to the workspace file) is loaded
+into its container, followed by any files given with --env-file.
+
+A service that fails to start is retried when it has a restart_policy:
+
+  services:
+    db:
+      path: ./db
+      restart_policy:
+        max_attempts: 3   # retries after the first failure
+        delay: 5s         # wait before the first retry, doubled after each
+
+With --keep-going, services that still fail are reported but the command
+succeeds, so one flaky service does not fail the whole workspace.
+
+A service with "on_demand: true" is not started. Once the other services are
+up, workspace up listens on its forwarded ports in the foreground and starts it
+on the first connection (see 'reactor up --on-demand'); --idle-timeout stops it
+again after that long without connections.
+
+With --attach <service>, an interactive session is attached to that service
+once all services are up, like 'reactor workspace exec <service> -- bash' but
+with the container's own shell and command. --attach - lists the services and
+asks which one to attach to before starting them.
+
+With --dry-run, the container each service would get is printed instead and
+Docker is not called.
+
+Several developers sharing a dev box, or several checkouts of the workspace, can
+run it side by side by shifting every host port the services publish:
+
+  port_offset: 100   # in reactor-workspace.yml or a local override that extends it
+
+REACTOR_PORT_OFFSET overrides port_offset, and --port-offset overrides both.
+
+Services share files without host paths through volumes declared once in the
+workspace file. A service mounts them with volumes, or mounts every volume of
+another service at the same paths with mounts_from (":ro" for read-only):
+
+  volumes: [artifacts]
+  services:
+    api:
+      path: ./api
+      volumes: ["artifacts:/artifacts"]
+    worker:
+      path: ./worker
+      mounts_from: api:ro
+
+The volumes belong to this workspace and outlive its containers; 'reactor
+workspace down --volumes' removes them.
+
+Before pulling, each service's image is checked against the platform of its Docker
+daemon, so an image published only for another CPU architecture is reported up
+front rather than failing with "exec format error" once started. To run such an
+image under emulation, give the service its platform:
+
+  services:
+    db:
+      path: ./db
+      platform: linux/amd64
+
 Examples:
   reactor workspace up                    # Start all services
+  reactor workspace up --attach api       # Start all services, then attach to api
   reactor workspace up api frontend      # Start specific services  
   reactor workspace up -f my-workspace.yml api  # Use specific workspace file
+  reactor workspace up --dry-run          # Show the containers that would be created
+  reactor workspace up --keep-going       # Succeed even if some services fail to start
+  reactor workspace up --port-offset 100  # Publish port 8080 on 8180, and so on
 
 The command will:
 - Validate all service configurations before starting any containers
 - Check for host port conflicts across services
-- Start services in parallel with goroutines
+- Check that each service's image is published for the platform it runs as
+- Start services in parallel with goroutines, retrying per restart_policy
 - Stream output with service-specific color prefixes
 - Apply workspace labels for container tracking
 - Report final success/failure status
@@ -1390,9 +2665,19 @@ For more details, see the full documentation.`,
 
 	// Add flags specific to the up command
 	cmd.Flags().Bool("rebuild", false, "Force rebuild of container images")
+	cmd.Flags().Bool("recreate-on-drift", false, "Recreate service containers that no longer match their configuration")
 	cmd.Flags().StringArrayP("port", "p", nil, "Port forwarding (host:container)")
+	cmd.Flags().Int("port-offset", 0, "Add this to every host port the services publish (also REACTOR_PORT_OFFSET)")
+	cmd.Flags().StringArray("env-file", nil, "Load environment variables from a dotenv file into every service, can be used multiple times")
 	cmd.Flags().Bool("discovery", false, "Enable discovery mode (no mounts)")
 	cmd.Flags().Bool("docker-host", false, "Enable Docker host integration (dangerous)")
+	cmd.Flags().Bool("allow-privileged", false, "Create service containers with their privileged, capAdd and securityOpt settings")
+	cmd.Flags().Bool("rerun-hooks", false, "Run changed postCreateCommand and postStartCommand again in existing service containers")
+	cmd.Flags().Bool("replace", false, "Remove containers reactor did not create that hold a service container's name")
+	cmd.Flags().Bool("dry-run", false, "Print the containers that would be created without calling Docker")
+	cmd.Flags().Bool("keep-going", false, "Report services that fail to start without failing the workspace")
+	cmd.Flags().Duration("idle-timeout", 0, "Stop on-demand services after this long without connections")
+	cmd.Flags().String("attach", "", "Attach to this service once the workspace is up (- to choose)")
 	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
 
 	return cmd
@@ -1408,10 +2693,17 @@ This command finds containers associated with the workspace using workspace
 labels and stops them in parallel. If no services are specified, all services
 in the workspace will be stopped.
 
+With --orphans, only containers carrying the workspace's instance label whose
+service is no longer in the file are removed, such as those left behind after a
+service was renamed or deleted. The services in the file keep running.
+
 Examples:
   reactor workspace down                    # Stop all services
   reactor workspace down api frontend      # Stop specific services  
   reactor workspace down -f my-workspace.yml # Use specific workspace file
+  reactor workspace down --instance 3f9a1c2b7d4e # Stop a workspace listed by 'workspace ps'
+  reactor workspace down --volumes          # Also remove the shared volumes
+  reactor workspace down --orphans          # Remove containers of renamed or deleted services
 
 Key features:
 - Parallel execution for faster shutdown
@@ -1422,6 +2714,9 @@ Key features:
 For more details, see the full documentation.`,
 		RunE: workspaceDownHandler,
 	}
+	cmd.Flags().String("instance", "", "Stop the workspace with this instance from 'reactor workspace ps', from any directory")
+	cmd.Flags().Bool("volumes", false, "Also remove the volumes the services share")
+	cmd.Flags().Bool("orphans", false, "Only remove containers of the workspace whose service is no longer in the file")
 
 	return cmd
 }
@@ -1456,56 +2751,37 @@ For more details, see the full documentation.`,
 
 // workspaceUpHandler starts all or specific services in a workspace
 func workspaceUpHandler(cmd *cobra.Command, args []string) error {
-	// Get workspace file path from flag or use default
-	workspaceFile, _ := cmd.Flags().GetString("file")
-
 	// Get command-specific flags
 	forceRebuild, _ := cmd.Flags().GetBool("rebuild")
+	recreateOnDrift, _ := cmd.Flags().GetBool("recreate-on-drift")
 	portMappings, _ := cmd.Flags().GetStringArray("port")
+	portOffsetFlag, _ := cmd.Flags().GetInt("port-offset")
+	envFiles, _ := cmd.Flags().GetStringArray("env-file")
 	discoveryMode, _ := cmd.Flags().GetBool("discovery")
 	dockerHostIntegration, _ := cmd.Flags().GetBool("docker-host")
+	allowPrivileged, _ := cmd.Flags().GetBool("allow-privileged")
+	rerunHooks, _ := cmd.Flags().GetBool("rerun-hooks")
+	replace, _ := cmd.Flags().GetBool("replace")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	keepGoing, _ := cmd.Flags().GetBool("keep-going")
+	idleTimeout, _ := cmd.Flags().GetDuration("idle-timeout")
+	attach, _ := cmd.Flags().GetString("attach")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 
-	// Handle workspace file path (reusing existing logic pattern)
-	var workspacePath string
-	if workspaceFile != "" {
-		if filepath.Ext(workspaceFile) != "" {
-			workspacePath = workspaceFile
-		} else {
-			var found bool
-			var err error
-			workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
-			if err != nil {
-				return fmt.Errorf("error finding workspace file: %w", err)
-			}
-			if !found {
-				return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
-			}
-		}
-
-		if _, err := os.Stat(workspacePath); err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("workspace file not found: %s", workspacePath)
-			}
-			return fmt.Errorf("error accessing workspace file %s: %w", workspacePath, err)
-		}
-	} else {
-		var found bool
-		var err error
-		workspacePath, found, err = workspace.FindWorkspaceFile("")
-		if err != nil {
-			return fmt.Errorf("error finding workspace file: %w", err)
-		}
-		if !found {
-			return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in current directory")
-		}
+	// Get workspace file path from flag or use default
+	workspacePath, workspaceData, err := resolveWorkspaceSource(cmd)
+	if err != nil {
+		return err
 	}
 
-	// Parse workspace file
-	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	ws, err := parseWorkspace(workspacePath, workspaceData)
 	if err != nil {
 		return fmt.Errorf("failed to parse workspace file: %w", err)
 	}
+	portOffset, err := workspacePortOffset(ws, portOffsetFlag, cmd.Flags().Changed("port-offset"))
+	if err != nil {
+		return err
+	}
 
 	// Determine which services to start
 	var servicesToStart []string
@@ -1530,11 +2806,70 @@ func workspaceUpHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate workspace hash: %w", err)
 	}
 
+	// Resolve the Docker daemon of each service; connections are made on first use
+	endpoints, err := newWorkspaceEndpoints(ws)
+	if err != nil {
+		return err
+	}
+	defer endpoints.Close()
+	if err := checkSharedVolumeHosts(ws, endpoints); err != nil {
+		return err
+	}
+
+	baseConfig := orchestrator.UpConfig{
+		ForceRebuild:          forceRebuild,
+		RecreateOnDrift:       recreateOnDrift,
+		CLIPortMappings:       portMappings,
+		PortOffset:            portOffset,
+		EnvFiles:              envFiles,
+		DiscoveryMode:         discoveryMode,
+		DockerHostIntegration: dockerHostIntegration,
+		AllowPrivileged:       allowPrivileged,
+		RerunHooks:            rerunHooks,
+		ReplaceConflicting:    replace,
+		DryRun:                dryRun,
+		Verbose:               verbose,
+		Events:                printProgress(os.Stdout, os.Stderr),
+	}
+
+	if dryRun {
+		if attach != "" {
+			return fmt.Errorf("--attach cannot be used with --dry-run")
+		}
+		if err := validateServicesAndPorts(ws, servicesToStart, workspacePath, portMappings); err != nil {
+			return fmt.Errorf("pre-flight validation failed: %w", err)
+		}
+		return dryRunWorkspaceServices(ws, endpoints, servicesToStart, workspacePath, workspaceHash, baseConfig)
+	}
+
+	// On-demand services are proxied instead of started
+	var startNow, onDemand []string
+	for _, name := range servicesToStart {
+		if ws.Services[name].OnDemand && !discoveryMode {
+			onDemand = append(onDemand, name)
+		} else {
+			startNow = append(startNow, name)
+		}
+	}
+
+	// Choose the service to attach to up front, so a prompt is not lost in the start output
+	if attach != "" {
+		if len(onDemand) > 0 {
+			return fmt.Errorf("--attach cannot be used while on-demand services are served in the foreground")
+		}
+		if attach, err = selectAttachService(attach, servicesToStart); err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf("Starting workspace services: %v\n", servicesToStart)
 	fmt.Printf("Workspace: %s\n", workspacePath)
+	if portOffset != 0 {
+		fmt.Printf("Port offset: %d\n", portOffset)
+	}
 
 	// Check if workspace is already running
-	if err := checkWorkspaceNotRunning(workspaceHash, servicesToStart); err != nil {
+	if err := checkWorkspaceNotRunning(endpoints, workspaceHash, servicesToStart); err != nil {
 		return err
 	}
 
@@ -1545,14 +2880,60 @@ func workspaceUpHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pre-flight validation failed: %w", err)
 	}
 
-	// Start services in parallel
-	return startServicesInParallel(ws, servicesToStart, workspacePath, workspaceHash, orchestrator.UpConfig{
-		ForceRebuild:          forceRebuild,
-		CLIPortMappings:       portMappings,
-		DiscoveryMode:         discoveryMode,
-		DockerHostIntegration: dockerHostIntegration,
-		Verbose:               verbose,
-	})
+	// Pull each image shared by the services once, before the services start
+	if err := prePullWorkspaceImages(ws, endpoints, servicesToStart, workspacePath); err != nil {
+		return err
+	}
+
+	proxies, err := newWorkspaceProxies(ws, endpoints, onDemand, workspacePath, workspaceHash, baseConfig, idleTimeout)
+	if err != nil {
+		return err
+	}
+	// On-demand services have no container until their first connection
+	onDemandServices := make(map[string]state.WorkspaceService, len(onDemand))
+	for _, name := range onDemand {
+		onDemandServices[name] = state.WorkspaceService{DockerHost: endpoints.host(name)}
+	}
+	recordStartedWorkspace(workspacePath, workspaceHash, onDemandServices)
+
+	// Start services in parallel; notifications follow the default account's settings
+	if len(startNow) > 0 {
+		start := time.Now()
+		var started map[string]state.WorkspaceService
+		started, err = startServicesInParallel(ws, endpoints, startNow, workspacePath, workspaceHash, baseConfig, keepGoing)
+		recordStartedWorkspace(workspacePath, workspaceHash, started)
+		if settings, settingsErr := config.ResolveSettings(nil, "", nil); settingsErr == nil {
+			notify.Finished(notify.FromSettings(settings), "Workspace up", start, err)
+		}
+		if err != nil {
+			return err
+		}
+		if attach != "" {
+			return attachWorkspaceService(endpoints, workspaceHash, attach)
+		}
+		if len(proxies) == 0 {
+			return nil
+		}
+	}
+
+	fmt.Printf("\nOn-demand services start on their first connection. Press Ctrl+C to stop listening.\n")
+	return serveOnDemand(proxies)
+}
+
+// newWorkspaceProxies builds the on-demand proxy of each service, reporting the ports it holds
+func newWorkspaceProxies(ws *workspace.Workspace, endpoints *workspaceEndpoints, services []string, workspacePath, workspaceHash string, baseConfig orchestrator.UpConfig, idleTimeout time.Duration) (map[string]*ondemand.Proxy, error) {
+	workspaceDir := filepath.Dir(workspacePath)
+	proxies := make(map[string]*ondemand.Proxy, len(services))
+	for _, name := range services {
+		serviceConfig := workspaceServiceUpConfig(ws, endpoints, name, workspaceDir, workspaceHash, baseConfig)
+		proxy, ports, err := newOnDemandProxy(serviceConfig, idleTimeout, fmt.Sprintf("[%s] ", name))
+		if err != nil {
+			return nil, fmt.Errorf("service '%s': %w", name, err)
+		}
+		fmt.Printf("%s On demand: listening on %s\n", ui.Stdout().Service(name), describeListenPorts(ports))
+		proxies[name] = proxy
+	}
+	return proxies, nil
 }
 
 // workspaceExecHandler executes a command in a workspace service container
@@ -1565,45 +2946,12 @@ func workspaceExecHandler(cmd *cobra.Command, args []string) error {
 	command := args[1:]
 
 	// Get workspace file path from flag or use default
-	workspaceFile, _ := cmd.Flags().GetString("file")
-
-	// Handle workspace file path (reusing existing logic pattern)
-	var workspacePath string
-	if workspaceFile != "" {
-		if filepath.Ext(workspaceFile) != "" {
-			workspacePath = workspaceFile
-		} else {
-			var found bool
-			var err error
-			workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
-			if err != nil {
-				return fmt.Errorf("error finding workspace file: %w", err)
-			}
-			if !found {
-				return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
-			}
-		}
-
-		if _, err := os.Stat(workspacePath); err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("workspace file not found: %s", workspacePath)
-			}
-			return fmt.Errorf("error accessing workspace file %s: %w", workspacePath, err)
-		}
-	} else {
-		var found bool
-		var err error
-		workspacePath, found, err = workspace.FindWorkspaceFile("")
-		if err != nil {
-			return fmt.Errorf("error finding workspace file: %w", err)
-		}
-		if !found {
-			return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in current directory")
-		}
+	workspacePath, workspaceData, err := resolveWorkspaceSource(cmd)
+	if err != nil {
+		return err
 	}
 
-	// Parse workspace file
-	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	ws, err := parseWorkspace(workspacePath, workspaceData)
 	if err != nil {
 		return fmt.Errorf("failed to parse workspace file: %w", err)
 	}
@@ -1619,9 +2967,13 @@ func workspaceExecHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate workspace hash: %w", err)
 	}
 
-	// Initialize Docker service
+	// Initialize Docker service for the daemon the service runs on
 	ctx := context.Background()
-	dockerService, err := docker.NewService()
+	host, err := serviceDockerHost(ws.Services[serviceName])
+	if err != nil {
+		return fmt.Errorf("service '%s': %w", serviceName, err)
+	}
+	dockerService, err := docker.NewServiceForHost(host)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Docker service: %w", err)
 	}
@@ -1631,30 +2983,9 @@ func workspaceExecHandler(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	// Find container using workspace labels instead of reconstructing name
-	client := dockerService.GetClient()
-	filterArgs := filters.NewArgs()
-	filterArgs.Add("label", fmt.Sprintf("com.reactor.workspace.instance=%s", workspaceHash))
-	filterArgs.Add("label", fmt.Sprintf("com.reactor.workspace.service=%s", serviceName))
-
-	containers, err := client.ContainerList(ctx, container.ListOptions{
-		Filters: filterArgs,
-	})
+	container, err := findWorkspaceServiceContainer(ctx, dockerService, workspaceHash, serviceName)
 	if err != nil {
-		return fmt.Errorf("failed to list containers: %w", err)
-	}
-
-	if len(containers) == 0 {
-		return fmt.Errorf("container for service '%s' not found - start it first with 'reactor workspace up %s'", serviceName, serviceName)
-	}
-
-	if len(containers) > 1 {
-		return fmt.Errorf("multiple containers found for service '%s' - this shouldn't happen", serviceName)
-	}
-
-	container := containers[0]
-	if container.State != "running" {
-		return fmt.Errorf("container for service '%s' is not running (status: %s) - start it first with 'reactor workspace up %s'", serviceName, container.State, serviceName)
+		return err
 	}
 
 	// Execute the command in the container
@@ -1664,48 +2995,34 @@ func workspaceExecHandler(cmd *cobra.Command, args []string) error {
 
 // workspaceDownHandler stops and removes all or specific services in a workspace
 func workspaceDownHandler(cmd *cobra.Command, args []string) error {
-	// Get workspace file path from flag or use default
-	workspaceFile, _ := cmd.Flags().GetString("file")
-
-	// Handle workspace file path (reusing existing logic pattern)
-	var workspacePath string
-	if workspaceFile != "" {
-		if filepath.Ext(workspaceFile) != "" {
-			workspacePath = workspaceFile
-		} else {
-			// It's a directory, find workspace file in it
-			var found bool
-			var err error
-			workspacePath, found, err = workspace.FindWorkspaceFile(workspaceFile)
-			if err != nil {
-				return fmt.Errorf("error finding workspace file: %w", err)
-			}
-			if !found {
-				return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
-			}
+	removeVolumes, _ := cmd.Flags().GetBool("volumes")
+	orphans, _ := cmd.Flags().GetBool("orphans")
+	if orphans && (len(args) > 0 || removeVolumes) {
+		return fmt.Errorf("--orphans only removes containers of services no longer in the workspace and cannot be used with a list of services or --volumes")
+	}
+	if instance, _ := cmd.Flags().GetString("instance"); instance != "" {
+		if cmd.Flags().Changed("file") {
+			return fmt.Errorf("--instance cannot be used with --file")
 		}
-		// Verify file exists
-		if _, err := os.Stat(workspacePath); err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("workspace file not found: %s", workspacePath)
-			}
-			return fmt.Errorf("error accessing workspace file %s: %w", workspacePath, err)
+		if removeVolumes {
+			return fmt.Errorf("--instance cannot be used with --volumes")
 		}
-	} else {
-		// No file specified, find default workspace file in current directory
-		var found bool
-		var err error
-		workspacePath, found, err = workspace.FindWorkspaceFile("")
-		if err != nil {
-			return fmt.Errorf("error finding workspace file: %w", err)
-		}
-		if !found {
-			return fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in current directory")
+		if orphans {
+			return fmt.Errorf("--instance cannot be used with --orphans; orphans are found by comparing with the workspace file")
 		}
+		return workspaceDownInstance(instance, args)
+	}
+	if removeVolumes && len(args) > 0 {
+		return fmt.Errorf("--volumes removes the volumes shared by all services and cannot be used with a list of services")
+	}
+
+	// Get workspace file path from flag or use default
+	workspacePath, workspaceData, err := resolveWorkspaceSource(cmd)
+	if err != nil {
+		return err
 	}
 
-	// Parse workspace file
-	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	ws, err := parseWorkspace(workspacePath, workspaceData)
 	if err != nil {
 		return fmt.Errorf("failed to parse workspace file: %w", err)
 	}
@@ -1733,15 +3050,50 @@ func workspaceDownHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate workspace hash: %w", err)
 	}
 
+	endpoints, err := newWorkspaceEndpoints(ws)
+	if err != nil {
+		return err
+	}
+	defer endpoints.Close()
+
+	if orphans {
+		return workspaceDownOrphans(ws, endpoints, workspacePath, workspaceHash)
+	}
+
 	fmt.Printf("Stopping workspace services: %v\n", servicesToStop)
 	fmt.Printf("Workspace: %s\n", workspacePath)
 
+	// Stop any SSH port tunnels started for the services on a remote daemon, and the
+	// ports added with 'reactor ports add'
+	for _, serviceName := range servicesToStop {
+		servicePath := ws.Services[serviceName].Path
+		if !filepath.IsAbs(servicePath) {
+			servicePath = filepath.Join(filepath.Dir(workspacePath), servicePath)
+		}
+		if resolved, err := config.NewServiceWithRoot(servicePath).ResolveConfiguration(); err == nil {
+			if err := tunnel.Stop(resolved.ProjectConfigDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			if err := portforward.StopAll(resolved.ProjectConfigDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
 	// Stop services in parallel
-	return stopServicesInParallel(servicesToStop, workspaceHash)
+	if err := stopServicesInParallel(endpoints, servicesToStop, workspaceHash); err != nil {
+		return err
+	}
+	forgetStoppedWorkspaceServices(workspaceHash, servicesToStop)
+	if removeVolumes {
+		return removeWorkspaceVolumes(context.Background(), ws, endpoints, workspaceHash)
+	}
+	return nil
 }
 
 // validateServicesAndPorts performs pre-flight validation for workspace services
 func validateServicesAndPorts(ws *workspace.Workspace, servicesToStart []string, workspacePath string, cliPorts []string) error {
+	style := ui.Stdout()
 	workspaceDir := filepath.Dir(workspacePath)
 	allHostPorts := make(map[int][]string) // Map of host port to services using it
 
@@ -1789,7 +3141,7 @@ func validateServicesAndPorts(ws *workspace.Workspace, servicesToStart []string,
 
 		// CLI ports can override devcontainer ports, but we still track them
 		if existing, exists := allHostPorts[hostPort]; exists {
-			fmt.Printf("  ⚠️  CLI port %d overrides devcontainer.json port for services: %v\n", hostPort, existing)
+			fmt.Printf("  %s CLI port %d overrides devcontainer.json port for services: %v\n", style.Warning(), hostPort, existing)
 		}
 		allHostPorts[hostPort] = []string{"CLI"}
 	}
@@ -1806,20 +3158,104 @@ func validateServicesAndPorts(ws *workspace.Workspace, servicesToStart []string,
 		return fmt.Errorf("port conflicts detected:\n  - %s", strings.Join(conflicts, "\n  - "))
 	}
 
-	fmt.Printf("  ✓ All service configurations valid\n")
-	fmt.Printf("  ✓ No port conflicts detected\n\n")
+	fmt.Printf("  %s All service configurations valid\n", style.Success())
+	fmt.Printf("  %s No port conflicts detected\n\n", style.Success())
 	return nil
 }
 
-// startServicesInParallel starts multiple services using goroutines
-func startServicesInParallel(ws *workspace.Workspace, servicesToStart []string, workspacePath, workspaceHash string, baseConfig orchestrator.UpConfig) error {
+// prePullWorkspaceImages pulls the images of the services to start, each unique image
+// once per Docker daemon, so that services sharing a base image do not pull it
+// simultaneously. Images are first checked against the platform they will run as.
+func prePullWorkspaceImages(ws *workspace.Workspace, endpoints *workspaceEndpoints, servicesToStart []string, workspacePath string) error {
+	workspaceDir := filepath.Dir(workspacePath)
+
+	images := map[string][]string{}      // images to pull by Docker host
+	serviceImages := map[string]string{} // image of each service that does not build one
+	auth := imageRegistryAuth{}
+	for _, serviceName := range servicesToStart {
+		service := ws.Services[serviceName]
+		servicePath := service.Path
+		if !filepath.IsAbs(servicePath) {
+			servicePath = filepath.Join(workspaceDir, service.Path)
+		}
+
+		configService := config.NewServiceWithRoot(servicePath)
+		if service.Account != "" {
+			configService.SetOverrides(map[string]string{config.SettingAccount: service.Account})
+		}
+		resolved, err := configService.ResolveConfiguration()
+		if err != nil {
+			return fmt.Errorf("service '%s' configuration invalid: %w", serviceName, err)
+		}
+		if resolved.Build != nil && service.Platform != "" {
+			return fmt.Errorf("service '%s' sets platform %s but builds its image; platform can only be set for services that use an image", serviceName, service.Platform)
+		}
+		// Services with a build configuration produce their own image
+		if resolved.Build == nil {
+			host := endpoints.host(serviceName)
+			images[host] = append(images[host], resolved.Image)
+			serviceImages[serviceName] = resolved.Image
+			// A shared image is pulled once, with the credentials of the first service's account
+			if _, ok := auth[docker.NormalizeImageName(resolved.Image)]; !ok {
+				resolver, err := orchestrator.RegistryResolver(resolved)
+				if err != nil {
+					return fmt.Errorf("service '%s': %w", serviceName, err)
+				}
+				auth[docker.NormalizeImageName(resolved.Image)] = resolver
+			}
+		}
+	}
+
+	platforms, err := workspaceImagePlatforms(ws, endpoints, serviceImages)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	hosts := endpoints.distinctHosts(servicesToStart)
+	for _, host := range hosts {
+		if len(images[host]) == 0 {
+			continue
+		}
+		dockerService, err := endpoints.forHost(host)
+		if err != nil {
+			return err
+		}
+		dockerService.SetRegistryAuth(auth)
+		dockerService.SetImagePlatforms(platforms[host])
+	}
+	if err := checkWorkspacePlatforms(ctx, ws, endpoints, serviceImages); err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		if len(images[host]) == 0 {
+			continue
+		}
+		dockerService, err := endpoints.forHost(host)
+		if err != nil {
+			return err
+		}
+		if err := dockerService.PullImages(ctx, images[host], os.Stdout); err != nil {
+			if host != "" {
+				return fmt.Errorf("%s: %w", host, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// startServicesInParallel starts multiple services using goroutines, returning the
+// services that started even when others failed
+func startServicesInParallel(ws *workspace.Workspace, endpoints *workspaceEndpoints, servicesToStart []string, workspacePath, workspaceHash string, baseConfig orchestrator.UpConfig, keepGoing bool) (map[string]state.WorkspaceService, error) {
+	style := ui.Stdout()
 	workspaceDir := filepath.Dir(workspacePath)
 
 	// Channel for collecting results
 	type serviceResult struct {
 		serviceName string
 		err         error
-		containerID string
+		started     state.WorkspaceService
 	}
 
 	resultChan := make(chan serviceResult, len(servicesToStart))
@@ -1827,41 +3263,24 @@ func startServicesInParallel(ws *workspace.Workspace, servicesToStart []string,
 	// Start services in parallel
 	for _, serviceName := range servicesToStart {
 		go func(name string) {
-			service := ws.Services[name]
-
-			// Resolve service path
-			servicePath := service.Path
-			if !filepath.IsAbs(servicePath) {
-				servicePath = filepath.Join(workspaceDir, service.Path)
-			}
-
-			// Create service-specific orchestrator config
-			serviceConfig := baseConfig
-			serviceConfig.ProjectDirectory = servicePath
-			serviceConfig.AccountOverride = service.Account
-			serviceConfig.NamePrefix = fmt.Sprintf("reactor-ws-%s-", name)
-
-			// Add workspace labels
-			if serviceConfig.Labels == nil {
-				serviceConfig.Labels = make(map[string]string)
-			}
-			serviceConfig.Labels["com.reactor.workspace.instance"] = workspaceHash
-			serviceConfig.Labels["com.reactor.workspace.service"] = name
+			serviceConfig := workspaceServiceUpConfig(ws, endpoints, name, workspaceDir, workspaceHash, baseConfig)
 
 			// Start the service
 			ctx := context.Background()
-			fmt.Printf("[%s] Starting service...\n", name)
+			fmt.Printf("%s Starting service...\n", style.Service(name))
 
-			resolved, containerID, err := orchestrator.Up(ctx, serviceConfig)
+			resolved, containerID, err := startServiceWithRetries(name, ws.Services[name], time.Sleep, func() (*config.ResolvedConfig, string, error) {
+				return orchestrator.Up(ctx, serviceConfig)
+			})
 			if err != nil {
-				fmt.Printf("[%s] ❌ Failed: %v\n", name, err)
-				resultChan <- serviceResult{name, err, ""}
+				fmt.Printf("%s %s Failed: %v\n", style.Service(name), style.Failure(), docker.WithDiagnosis(err))
+				resultChan <- serviceResult{name, err, state.WorkspaceService{}}
 				return
 			}
 
-			fmt.Printf("[%s] ✅ Started successfully (container: %s)\n", name, containerID)
+			fmt.Printf("%s %s Started successfully (container: %s)\n", style.Service(name), style.Success(), containerID)
 			if resolved != nil && len(resolved.ForwardPorts) > 0 {
-				fmt.Printf("[%s] Port mappings: ", name)
+				fmt.Printf("%s Port mappings: ", style.Service(name))
 				for i, port := range resolved.ForwardPorts {
 					if i > 0 {
 						fmt.Printf(", ")
@@ -1871,13 +3290,18 @@ func startServicesInParallel(ws *workspace.Workspace, servicesToStart []string,
 				fmt.Printf("\n")
 			}
 
-			resultChan <- serviceResult{name, nil, containerID}
+			started := state.WorkspaceService{ContainerID: containerID, DockerHost: endpoints.host(name)}
+			if resolved != nil {
+				started.ConfigDir = resolved.ProjectConfigDir
+			}
+			resultChan <- serviceResult{name, nil, started}
 		}(serviceName)
 	}
 
 	// Collect results
 	var successCount, failCount int
 	var errors []string
+	started := make(map[string]state.WorkspaceService, len(servicesToStart))
 
 	for i := 0; i < len(servicesToStart); i++ {
 		result := <-resultChan
@@ -1886,38 +3310,110 @@ func startServicesInParallel(ws *workspace.Workspace, servicesToStart []string,
 			errors = append(errors, fmt.Sprintf("%s: %v", result.serviceName, result.err))
 		} else {
 			successCount++
+			started[result.serviceName] = result.started
 		}
 	}
 
 	// Print final summary
 	fmt.Printf("\n=== Workspace Start Summary ===\n")
-	fmt.Printf("✅ Started successfully: %d/%d services\n", successCount, len(servicesToStart))
+	fmt.Printf("%s Started successfully: %d/%d services\n", style.Success(), successCount, len(servicesToStart))
 	if failCount > 0 {
-		fmt.Printf("❌ Failed to start: %d/%d services\n", failCount, len(servicesToStart))
+		fmt.Printf("%s Failed to start: %d/%d services\n", style.Failure(), failCount, len(servicesToStart))
 		for _, errMsg := range errors {
 			fmt.Printf("  - %s\n", errMsg)
 		}
-		return fmt.Errorf("%d service(s) failed to start", failCount)
+		if keepGoing {
+			fmt.Printf("\nWorkspace is up without %d service(s) (--keep-going)\n", failCount)
+			return started, nil
+		}
+		return started, fmt.Errorf("%d service(s) failed to start", failCount)
 	}
 
-	fmt.Printf("\nWorkspace is ready! 🚀\n")
-	return nil
+	fmt.Printf("\n%s\n", style.Green("Workspace is ready!"))
+	return started, nil
 }
 
-// stopServicesInParallel stops workspace services in parallel using their workspace labels
-func stopServicesInParallel(servicesToStop []string, workspaceHash string) error {
-	ctx := context.Background()
-	dockerService, err := docker.NewService()
-	if err != nil {
-		return fmt.Errorf("failed to initialize Docker service: %w", err)
+// startServiceWithRetries calls up until it succeeds or the service's restart policy
+// runs out of retries, sleeping between attempts
+func startServiceWithRetries(name string, service workspace.Service, sleep func(time.Duration), up func() (*config.ResolvedConfig, string, error)) (*config.ResolvedConfig, string, error) {
+	retries := service.Retries()
+	for attempt := 1; ; attempt++ {
+		resolved, containerID, err := up()
+		if err == nil || attempt > retries {
+			return resolved, containerID, err
+		}
+		delay := service.RetryDelay(attempt)
+		fmt.Printf("%s %s Start failed, retrying in %s (%d/%d): %v\n", ui.Stdout().Service(name), ui.Stdout().Warning(), delay, attempt, retries, err)
+		sleep(delay)
 	}
-	defer func() {
-		if err := dockerService.Close(); err != nil {
-			log.Printf("Warning: failed to close Docker service: %v", err)
+}
+
+// workspaceServiceUpConfig returns the orchestrator config that starts a workspace service
+// on the Docker daemon it runs on
+func workspaceServiceUpConfig(ws *workspace.Workspace, endpoints *workspaceEndpoints, name, workspaceDir, workspaceHash string, baseConfig orchestrator.UpConfig) orchestrator.UpConfig {
+	service := ws.Services[name]
+
+	// Resolve service path
+	servicePath := service.Path
+	if !filepath.IsAbs(servicePath) {
+		servicePath = filepath.Join(workspaceDir, service.Path)
+	}
+
+	// Create service-specific orchestrator config
+	serviceConfig := baseConfig
+	serviceConfig.ProjectDirectory = servicePath
+	serviceConfig.AccountOverride = service.Account
+	serviceConfig.NamePrefix = fmt.Sprintf("reactor-ws-%s-", name)
+	serviceConfig.DockerHost = endpoints.host(name)
+	serviceConfig.SharedHostPaths = service.SharedPaths
+	serviceConfig.Service = name
+	serviceConfig.WorkDir = service.WorkDir
+	serviceConfig.Command = service.Command
+	serviceConfig.Mounts = workspaceServiceMounts(ws, name, workspaceHash)
+	serviceConfig.Platform = service.Platform
+
+	// The service's env_file applies before files given on the command line
+	if service.EnvFile != "" {
+		envFile := service.EnvFile
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(workspaceDir, envFile)
 		}
-	}()
+		serviceConfig.EnvFiles = append([]string{envFile}, baseConfig.EnvFiles...)
+	}
 
-	client := dockerService.GetClient()
+	// Add workspace labels; each service gets its own map
+	serviceConfig.Labels = make(map[string]string, len(baseConfig.Labels)+2)
+	for k, v := range baseConfig.Labels {
+		serviceConfig.Labels[k] = v
+	}
+	serviceConfig.Labels["com.reactor.workspace.instance"] = workspaceHash
+	serviceConfig.Labels["com.reactor.workspace.service"] = name
+
+	return serviceConfig
+}
+
+// dryRunWorkspaceServices prints the container each service would get, one service at a time
+func dryRunWorkspaceServices(ws *workspace.Workspace, endpoints *workspaceEndpoints, servicesToStart []string, workspacePath, workspaceHash string, baseConfig orchestrator.UpConfig) error {
+	workspaceDir := filepath.Dir(workspacePath)
+	names := append([]string(nil), servicesToStart...)
+	sort.Strings(names)
+
+	for i, name := range names {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("[%s]\n", name)
+		if _, _, err := orchestrator.Up(context.Background(), workspaceServiceUpConfig(ws, endpoints, name, workspaceDir, workspaceHash, baseConfig)); err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// stopServicesInParallel stops workspace services in parallel using their workspace labels
+func stopServicesInParallel(endpoints *workspaceEndpoints, servicesToStop []string, workspaceHash string) error {
+	style := ui.Stdout()
+	ctx := context.Background()
 
 	// Channel for collecting results
 	type serviceResult struct {
@@ -1931,55 +3427,65 @@ func stopServicesInParallel(servicesToStop []string, workspaceHash string) error
 	// Stop services in parallel
 	for _, serviceName := range servicesToStop {
 		go func(name string) {
-			fmt.Printf("[%s] Looking for container...\n", name)
+			fmt.Printf("%s Looking for container...\n", style.Service(name))
 
-			// Find container using workspace labels
-			filterArgs := filters.NewArgs()
-			filterArgs.Add("label", fmt.Sprintf("com.reactor.workspace.instance=%s", workspaceHash))
-			filterArgs.Add("label", fmt.Sprintf("com.reactor.workspace.service=%s", name))
+			// Each service is stopped on the daemon it runs on
+			dockerService, err := endpoints.forService(name)
+			if err != nil {
+				fmt.Printf("%s %s %v\n", style.Service(name), style.Failure(), err)
+				resultChan <- serviceResult{name, err, ""}
+				return
+			}
+			client := dockerService.GetClient()
 
-			containers, err := client.ContainerList(ctx, container.ListOptions{
-				Filters: filterArgs,
-				All:     true, // Include stopped containers
+			// Find containers using workspace labels; the services share one container listing
+			containers, err := dockerService.ListContainersByLabels(ctx, map[string]string{
+				"com.reactor.workspace.instance": workspaceHash,
+				"com.reactor.workspace.service":  name,
 			})
 			if err != nil {
-				fmt.Printf("[%s] ❌ Failed to list containers: %v\n", name, err)
+				fmt.Printf("%s %s Failed to list containers: %v\n", style.Service(name), style.Failure(), err)
 				resultChan <- serviceResult{name, err, ""}
 				return
 			}
 
 			if len(containers) == 0 {
-				fmt.Printf("[%s] ⚠️  No container found (already removed or never created)\n", name)
+				fmt.Printf("%s %s No container found (already removed or never created)\n", style.Service(name), style.Warning())
 				resultChan <- serviceResult{name, nil, ""}
 				return
 			}
 
 			if len(containers) > 1 {
-				fmt.Printf("[%s] ⚠️  Multiple containers found, stopping all\n", name)
+				fmt.Printf("%s %s Multiple containers found, stopping all\n", style.Service(name), style.Warning())
 			}
 
 			// Stop and remove each container found
 			for _, cont := range containers {
-				fmt.Printf("[%s] Stopping container %s...\n", name, cont.ID[:12])
+				fmt.Printf("%s Stopping container %s...\n", style.Service(name), cont.ID[:12])
+
+				// Keep the container if its encrypted credentials cannot be saved
+				if err := orchestrator.SaveCredentials(ctx, dockerService, cont); err != nil {
+					fmt.Printf("%s %s Failed to save encrypted credentials, container left running: %v\n", style.Service(name), style.Failure(), err)
+					resultChan <- serviceResult{name, err, cont.ID}
+					return
+				}
 
 				// Stop the container first if it's running
 				if cont.State == "running" {
 					timeout := 10
 					if err := client.ContainerStop(ctx, cont.ID, container.StopOptions{Timeout: &timeout}); err != nil {
-						fmt.Printf("[%s] ⚠️  Failed to stop container: %v\n", name, err)
+						fmt.Printf("%s %s Failed to stop container: %v\n", style.Service(name), style.Warning(), err)
 					}
 				}
 
-				// Remove the container
-				if err := client.ContainerRemove(ctx, cont.ID, container.RemoveOptions{
-					Force: true, // Force removal even if running
-				}); err != nil {
-					fmt.Printf("[%s] ❌ Failed to remove container: %v\n", name, err)
+				// Remove the container, archiving its output as 'reactor down' does
+				if err := dockerService.RemoveContainer(ctx, cont.ID); err != nil {
+					fmt.Printf("%s %s Failed to remove container: %v\n", style.Service(name), style.Failure(), err)
 					resultChan <- serviceResult{name, err, cont.ID}
 					return
 				}
 
-				fmt.Printf("[%s] ✅ Stopped and removed container %s\n", name, cont.ID[:12])
+				fmt.Printf("%s %s Stopped and removed container %s\n", style.Service(name), style.Success(), cont.ID[:12])
 			}
 
 			resultChan <- serviceResult{name, nil, containers[0].ID}
@@ -2002,9 +3508,9 @@ func stopServicesInParallel(servicesToStop []string, workspaceHash string) error
 
 	// Print final summary
 	fmt.Printf("\n=== Workspace Stop Summary ===\n")
-	fmt.Printf("✅ Stopped successfully: %d/%d services\n", successCount, len(servicesToStop))
+	fmt.Printf("%s Stopped successfully: %d/%d services\n", style.Success(), successCount, len(servicesToStop))
 	if failCount > 0 {
-		fmt.Printf("❌ Failed to stop: %d/%d services\n", failCount, len(servicesToStop))
+		fmt.Printf("%s Failed to stop: %d/%d services\n", style.Failure(), failCount, len(servicesToStop))
 		for _, errMsg := range errors {
 			fmt.Printf("  - %s\n", errMsg)
 		}
@@ -2025,33 +3531,41 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-// checkWorkspaceNotRunning checks if any of the services are already running
-func checkWorkspaceNotRunning(workspaceHash string, servicesToStart []string) error {
+// checkWorkspaceNotRunning checks if any of the services are already running, on every
+// Docker daemon the workspace uses
+func checkWorkspaceNotRunning(endpoints *workspaceEndpoints, workspaceHash string, servicesToStart []string) error {
 	ctx := context.Background()
-	dockerService, err := docker.NewService()
-	if err != nil {
-		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	starting := map[string]bool{}
+	for _, host := range endpoints.distinctHosts(servicesToStart) {
+		starting[host] = true
 	}
-	defer func() {
-		if err := dockerService.Close(); err != nil {
-			log.Printf("Warning: failed to close Docker service: %v", err)
-		}
-	}()
-
-	client := dockerService.GetClient()
 
 	// Find any running containers for this workspace
-	filterArgs := filters.NewArgs()
-	filterArgs.Add("label", fmt.Sprintf("com.reactor.workspace.instance=%s", workspaceHash))
-	filterArgs.Add("status", "running")
-
-	runningContainers, err := client.ContainerList(ctx, container.ListOptions{
-		Filters: filterArgs,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to check existing containers: %w", err)
+	var workspaceContainers []docker.ContainerInfo
+	for _, host := range endpoints.allHosts() {
+		dockerService, err := endpoints.forHost(host)
+		var containers []docker.ContainerInfo
+		if err == nil {
+			containers, err = dockerService.ListContainersByLabels(ctx, map[string]string{
+				"com.reactor.workspace.instance": workspaceHash,
+			})
+		}
+		if err != nil {
+			// Only the daemons services are started on must be reachable
+			if starting[host] {
+				return fmt.Errorf("failed to check existing containers%s: %w", describeDockerHostSuffix(host), err)
+			}
+			continue
+		}
+		workspaceContainers = append(workspaceContainers, containers...)
 	}
 
+	var runningContainers []docker.ContainerInfo
+	for _, c := range workspaceContainers {
+		if c.Status == docker.StatusRunning {
+			runningContainers = append(runningContainers, c)
+		}
+	}
 	if len(runningContainers) == 0 {
 		return nil // No running containers, safe to start
 	}
@@ -2073,7 +3587,7 @@ func checkWorkspaceNotRunning(workspaceHash string, servicesToStart []string) er
 	}
 
 	if len(conflictingServices) > 0 {
-		fmt.Printf("⚠️  Some services are already running: %v\n", conflictingServices)
+		fmt.Printf("%s Some services are already running: %v\n", ui.Stdout().Warning(), conflictingServices)
 		fmt.Printf("   All running services in this workspace: %v\n", runningServices)
 		fmt.Printf("   Use 'reactor workspace exec <service> -- <command>' to run commands in existing containers\n")
 		fmt.Printf("   Or stop the workspace first with: docker stop %s\n",
@@ -2090,15 +3604,10 @@ func checkWorkspaceNotRunning(workspaceHash string, servicesToStart []string) er
 }
 
 // getContainerNames extracts container names from a list of containers
-func getContainerNames(containers []container.Summary) []string {
+func getContainerNames(containers []docker.ContainerInfo) []string {
 	var names []string
 	for _, container := range containers {
-		// Container names have a leading slash, remove it
-		name := container.Names[0]
-		if len(name) > 0 && name[0] == '/' {
-			name = name[1:]
-		}
-		names = append(names, name)
+		names = append(names, container.Name)
 	}
 	return names
 }
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dyluth/reactor/pkg/config"
 	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/spf13/cobra"
 )
 
 func TestMergePortMappings(t *testing.T) {
@@ -130,3 +139,192 @@ func TestMergePortMappings(t *testing.T) {
 func TestCreateBuildSpecFromConfig(t *testing.T) {
 	t.Skip("createBuildSpecFromConfig function has been moved to orchestrator package as private function")
 }
+
+func TestStartServiceWithRetries(t *testing.T) {
+	service := workspace.Service{RestartPolicy: &workspace.RestartPolicy{MaxAttempts: 3, Delay: "1s"}}
+
+	t.Run("SucceedsAfterRetries", func(t *testing.T) {
+		var slept []time.Duration
+		calls := 0
+		_, containerID, err := startServiceWithRetries("db", service, func(d time.Duration) { slept = append(slept, d) }, func() (*config.ResolvedConfig, string, error) {
+			calls++
+			if calls < 3 {
+				return nil, "", errors.New("port not ready")
+			}
+			return &config.ResolvedConfig{}, "abc123", nil
+		})
+		if err != nil || containerID != "abc123" {
+			t.Fatalf("expected success on the third attempt, got %q, %v", containerID, err)
+		}
+		if want := []time.Duration{time.Second, 2 * time.Second}; len(slept) != 2 || slept[0] != want[0] || slept[1] != want[1] {
+			t.Errorf("expected delays %v, got %v", want, slept)
+		}
+	})
+
+	t.Run("GivesUpAfterMaxAttempts", func(t *testing.T) {
+		calls := 0
+		_, _, err := startServiceWithRetries("db", service, func(time.Duration) {}, func() (*config.ResolvedConfig, string, error) {
+			calls++
+			return nil, "", errors.New("always fails")
+		})
+		if err == nil || calls != 4 {
+			t.Errorf("expected 4 attempts and an error, got %d attempts, %v", calls, err)
+		}
+	})
+
+	t.Run("NoPolicyMeansNoRetry", func(t *testing.T) {
+		calls := 0
+		_, _, err := startServiceWithRetries("db", workspace.Service{}, func(time.Duration) { t.Error("unexpected sleep") }, func() (*config.ResolvedConfig, string, error) {
+			calls++
+			return nil, "", errors.New("fails")
+		})
+		if err == nil || calls != 1 {
+			t.Errorf("expected a single attempt, got %d", calls)
+		}
+	})
+}
+
+func TestPromptAttachService(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		services []string
+		expected string
+	}{
+		{"by number", "2\n", []string{"web", "api", "db"}, "db"},
+		{"by name", "web\n", []string{"web", "api", "db"}, "web"},
+		{"retries invalid answers", "7\nnope\n1\n", []string{"web", "api"}, "api"},
+		{"single service needs no answer", "", []string{"api"}, "api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := promptAttachService(bufio.NewReader(strings.NewReader(tt.input)), &out, tt.services)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := promptAttachService(bufio.NewReader(strings.NewReader("")), &bytes.Buffer{}, []string{"web", "api"}); err == nil {
+		t.Error("expected an error when input ends without a choice")
+	}
+}
+
+func TestSelectAttachService(t *testing.T) {
+	if got, err := selectAttachService("api", []string{"web", "api"}); err != nil || got != "api" {
+		t.Errorf("expected api, got %q (%v)", got, err)
+	}
+	if _, err := selectAttachService("db", []string{"web", "api"}); err == nil || !strings.Contains(err.Error(), "not being started") {
+		t.Errorf("expected an error for a service that is not started, got %v", err)
+	}
+}
+
+func TestGlobalAccountFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv("REACTOR_ACCOUNT", "")
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".devcontainer.json"), []byte(`{"image": "alpine", "customizations": {"reactor": {"account": "team"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// resolveAccount runs a command under the root command, as 'reactor [flags] probe'
+	resolveAccount := func(args ...string) string {
+		t.Helper()
+		var account string
+		root := newRootCmd()
+		root.PersistentPostRun = nil
+		root.AddCommand(&cobra.Command{
+			Use: "probe",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				resolved, err := config.NewServiceWithRoot(projectDir).ResolveConfiguration()
+				if err == nil {
+					account = resolved.Account
+				}
+				return err
+			},
+		})
+		root.SetArgs(append(args, "probe"))
+		if err := root.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return account
+	}
+
+	if got := resolveAccount("--account", "personal"); got != "personal" {
+		t.Errorf("account with --account = %q, want personal", got)
+	}
+	// The flag only lasts for the command it is given to
+	if got := resolveAccount(); got != "team" {
+		t.Errorf("account without --account = %q, want team", got)
+	}
+}
+
+func TestGlobalConfigFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	projectDir := t.TempDir()
+	for _, name := range []string{"api", "web"} {
+		dir := filepath.Join(projectDir, ".devcontainer", name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "devcontainer.json"), []byte(`{"image": "alpine"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// resolveConfig runs a command that resolves the project, as 'reactor [flags] probe'
+	resolveConfig := func(args ...string) (string, error) {
+		t.Helper()
+		var configName string
+		root := newRootCmd()
+		root.PersistentPostRun = nil
+		root.AddCommand(&cobra.Command{
+			Use: "probe",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				resolved, err := config.NewService().ResolveConfiguration()
+				if err == nil {
+					configName = resolved.ConfigName
+				}
+				return err
+			},
+		})
+		root.SetArgs(append(args, "probe"))
+		err := root.Execute()
+		return configName, err
+	}
+
+	if got, err := resolveConfig("--config", "web"); err != nil || got != "web" {
+		t.Errorf("configuration with --config = %q (%v), want web", got, err)
+	}
+	// Without --config the project's configurations are ambiguous
+	if _, err := resolveConfig(); err == nil || !strings.Contains(err.Error(), "choose one with --config") {
+		t.Errorf("expected an error naming --config, got %v", err)
+	}
+}
+
+func TestGlobalFlagsNotShadowed(t *testing.T) {
+	// --account and --config are the root's persistent flags; a local copy would hide them
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, name := range []string{"account", "config"} {
+			if cmd.HasParent() && cmd.LocalNonPersistentFlags().Lookup(name) != nil {
+				t.Errorf("'%s' defines its own --%s", cmd.CommandPath(), name)
+			}
+		}
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(newRootCmd())
+}
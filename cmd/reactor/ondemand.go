@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/ondemand"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+)
+
+// newOnDemandProxy returns a proxy that holds the project's forwarded ports and starts
+// its container on the first connection, with the container's ports published on free
+// host ports instead. Log lines are prefixed with prefix.
+func newOnDemandProxy(upConfig orchestrator.UpConfig, idleTimeout time.Duration, prefix string) (*ondemand.Proxy, []orchestrator.PortMapping, error) {
+	ports, err := orchestrator.ForwardedPorts(upConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ports) == 0 {
+		return nil, nil, fmt.Errorf("on-demand mode needs ports to listen on; add forwardPorts to devcontainer.json or use --port")
+	}
+
+	backendPorts, err := ondemand.FreePorts(len(ports))
+	if err != nil {
+		return nil, nil, err
+	}
+	upConfig.ProxiedHostPorts = make(map[int]int, len(ports))
+	routes := make([]ondemand.Route, len(ports))
+	for i, pm := range ports {
+		upConfig.ProxiedHostPorts[pm.HostPort] = backendPorts[i]
+		routes[i] = ondemand.Route{ListenPort: pm.HostPort, ContainerPort: pm.ContainerPort}
+	}
+
+	var containerName string
+	proxy := &ondemand.Proxy{
+		Routes:      routes,
+		IdleTimeout: idleTimeout,
+		Start: func(ctx context.Context) (ondemand.Backend, error) {
+			resolved, _, err := orchestrator.Up(ctx, upConfig)
+			if err != nil {
+				return nil, docker.WithDiagnosis(err)
+			}
+			containerName = upConfig.NamePrefix + core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), upConfig.SessionName)
+			backend := make(ondemand.Backend, len(resolved.ForwardPorts))
+			for _, pm := range resolved.ForwardPorts {
+				backend[pm.ContainerPort] = pm.HostPort
+			}
+			return backend, nil
+		},
+		Stop: func(ctx context.Context) error {
+			return stopOnDemandContainer(ctx, upConfig.DockerHost, containerName)
+		},
+		Logf: func(format string, args ...any) {
+			fmt.Printf(prefix+format+"\n", args...)
+		},
+	}
+	return proxy, ports, nil
+}
+
+// stopOnDemandContainer stops an idle container on dockerHost (empty for DOCKER_HOST),
+// saving its encrypted credentials first
+func stopOnDemandContainer(ctx context.Context, dockerHost, containerName string) error {
+	dockerService, err := docker.NewServiceForHost(dockerHost)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer closeDockerService(dockerService)
+
+	info, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if info.Status != docker.StatusRunning {
+		return nil
+	}
+	if err := orchestrator.SaveCredentials(ctx, dockerService, info); err != nil {
+		return fmt.Errorf("failed to save encrypted credentials: %w", err)
+	}
+	return dockerService.StopContainer(ctx, info.ID)
+}
+
+// serveOnDemand runs proxies until interrupted. Containers they started keep running.
+func serveOnDemand(proxies map[string]*ondemand.Proxy) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(proxies))
+	for name, proxy := range proxies {
+		wg.Add(1)
+		go func(name string, proxy *ondemand.Proxy) {
+			defer wg.Done()
+			if err := proxy.Serve(ctx); err != nil {
+				if name != "" {
+					err = fmt.Errorf("service '%s': %w", name, err)
+				}
+				errs <- err
+				stop()
+			}
+		}(name, proxy)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// describeListenPorts lists the host ports a proxy listens on, e.g. "localhost:3000, localhost:8080"
+func describeListenPorts(ports []orchestrator.PortMapping) string {
+	addresses := make([]string, len(ports))
+	for i, pm := range ports {
+		addresses[i] = fmt.Sprintf("localhost:%d", pm.HostPort)
+	}
+	return strings.Join(addresses, ", ")
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/pool"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+func newPoolCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pool",
+		Short: "Keep idle copies of the project's container warm for faster startup",
+		Long: `Keep a warm pool of idle copies of the project's container, so 'reactor up'
+takes one over instead of waiting for its container to be created and started.
+
+'reactor pool start' is run in the project directory with the options
+'reactor up' will be run with. Its containers are created exactly as 'reactor
+up' would create the project's container, with the same mounts, environment
+and policy check, and 'reactor up' claims one by renaming it; options that
+change the container, or an edited devcontainer.json, leave the pool unused.
+The pool is topped up again while the session runs. A container that publishes
+ports cannot have a pool, as only one container can hold them.
+
+Pool containers are not shown by 'reactor sessions list' until they are claimed.
+
+Examples:
+  reactor pool start --size 2             # Keep 2 idle copies of this project's container
+  reactor pool start --name review        # Of the container of the session 'review'
+  reactor pool status
+  reactor pool stop --container reactor-me-app-0123abcd
+  reactor pool stop                       # Stop every pool
+
+For more details, see the full documentation.`,
+	}
+
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Keep idle copies of the project's container",
+		Args:  cobra.NoArgs,
+		RunE:  poolStartHandler,
+	}
+	startCmd.Flags().Int("size", 2, "Number of idle containers to keep")
+	startCmd.Flags().String("name", "", "Session name of the container, as for 'reactor up'")
+	startCmd.Flags().Bool("docker-host-integration", false, "Mount the host Docker socket, as for 'reactor up'")
+	startCmd.Flags().Bool("allow-privileged", false, "Create containers with the privileges devcontainer.json requests without asking")
+	cmd.AddCommand(startCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the warm pools and their idle containers",
+		Args:  cobra.NoArgs,
+		RunE:  poolStatusHandler,
+	})
+
+	stopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Remove the idle containers of a pool, or of every pool",
+		Args:  cobra.NoArgs,
+		RunE:  poolStopHandler,
+	}
+	stopCmd.Flags().String("container", "", "Container whose pools to stop, as 'reactor pool status' shows (default: every pool)")
+	cmd.AddCommand(stopCmd)
+
+	return cmd
+}
+
+func poolStartHandler(cmd *cobra.Command, args []string) error {
+	size, _ := cmd.Flags().GetInt("size")
+	if size < 1 {
+		return fmt.Errorf("--size must be at least 1")
+	}
+	accountOverride, _ := cmd.Flags().GetString("account")
+	configName, _ := cmd.Flags().GetString("config")
+	sessionName, _ := cmd.Flags().GetString("name")
+	dockerHostIntegration, _ := cmd.Flags().GetBool("docker-host-integration")
+	allowPrivileged, _ := cmd.Flags().GetBool("allow-privileged")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+
+	projectDirectory, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	_, _, err = orchestrator.Up(context.Background(), orchestrator.UpConfig{
+		ProjectDirectory:      projectDirectory,
+		AccountOverride:       accountOverride,
+		ConfigName:            configName,
+		SessionName:           sessionName,
+		DockerHostIntegration: dockerHostIntegration,
+		AllowPrivileged:       allowPrivileged,
+		ConfirmPrivileges:     terminalPrivilegesPrompt(),
+		PoolSize:              size,
+		Verbose:               verbose,
+		Events:                printProgress(os.Stdout, os.Stderr),
+	})
+	return err
+}
+
+func poolStatusHandler(cmd *cobra.Command, args []string) error {
+	pools, err := state.Pools()
+	if err != nil {
+		return err
+	}
+	if len(pools) == 0 {
+		fmt.Println("No warm pools. Start one in a project directory with 'reactor pool start'.")
+		return nil
+	}
+
+	ctx := context.Background()
+	dockerService, err := newJobsDockerService(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(dockerService)
+
+	fmt.Printf("%-40s %-40s %-6s %s\n", "CONTAINER", "IMAGE", "SIZE", "IDLE")
+	for _, key := range sortedPoolKeys(pools) {
+		idle, err := pool.Idle(ctx, dockerService, key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-40s %-40s %-6d %d\n", pools[key].Container, pools[key].Image, pools[key].Size, len(idle))
+	}
+	return nil
+}
+
+func poolStopHandler(cmd *cobra.Command, args []string) error {
+	containerName, _ := cmd.Flags().GetString("container")
+	pools, err := state.Pools()
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for _, key := range sortedPoolKeys(pools) {
+		if containerName == "" || pools[key].Container == containerName {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		if containerName != "" {
+			return fmt.Errorf("no warm pool of container %s; see 'reactor pool status'", containerName)
+		}
+		fmt.Println("No warm pools to stop.")
+		return nil
+	}
+
+	ctx := context.Background()
+	dockerService, err := newJobsDockerService(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDockerService(dockerService)
+
+	for _, key := range keys {
+		removed, err := pool.Stop(ctx, dockerService, key)
+		if err != nil {
+			return fmt.Errorf("failed to stop the pool of %s: %w", pools[key].Container, err)
+		}
+		fmt.Printf("Stopped the pool of %s: removed %d idle container(s).\n", pools[key].Container, removed)
+	}
+	return nil
+}
+
+// sortedPoolKeys orders pools by the container they are for
+func sortedPoolKeys(pools map[string]state.Pool) []string {
+	keys := make([]string, 0, len(pools))
+	for key := range pools {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := pools[keys[i]], pools[keys[j]]
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"syscall"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/portforward"
+	"github.com/dyluth/reactor/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+func newPortsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ports",
+		Short: "Forward ports to the running container without recreating it",
+		Long: `Forward more host ports to the running dev container.
+
+Docker cannot publish ports on a container after it is created, so changing
+forwardPorts normally means recreating the container. 'reactor ports add'
+instead starts a background reactor process that listens on the host port on
+127.0.0.1 and forwards connections to the container's IP address. The forward
+lasts until it is removed or 'reactor down' removes the container, and follows
+the container to its new IP address when it is restarted.
+
+The host reaches container IP addresses directly only on Linux with a local
+Docker daemon; add the port to forwardPorts and recreate the container elsewhere.
+
+Examples:
+  reactor ports add 8080:80       # Forward host port 8080 to container port 80
+  reactor ports add 3000          # Forward port 3000 under the same number
+  reactor ports list              # Show the forwards of the current container
+  reactor ports remove 8080       # Stop forwarding host port 8080
+
+For more details, see the full documentation.`,
+	}
+	cmd.PersistentFlags().String("name", "", "Session name of the container (see 'reactor up --name')")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add <host:container | port>",
+		Short: "Forward a host port to the running container",
+		Args:  cobra.ExactArgs(1),
+		RunE:  portsAddHandler,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <host-port>",
+		Short: "Stop forwarding a host port",
+		Args:  cobra.ExactArgs(1),
+		RunE:  portsRemoveHandler,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the ports forwarded with 'reactor ports add'",
+		Args:  cobra.NoArgs,
+		RunE:  portsListHandler,
+	})
+	// serve is the background process started by 'ports add'
+	cmd.AddCommand(&cobra.Command{
+		Use:    "serve <host-port> <container-id> <container-port>",
+		Hidden: true,
+		Args:   cobra.ExactArgs(3),
+		RunE:   portsServeHandler,
+	})
+
+	return cmd
+}
+
+// portsProject resolves the current project and returns the config directory of the
+// session, which holds its forwards, and the name of its container
+func portsProject(cmd *cobra.Command) (string, string, error) {
+	sessionName, _ := cmd.Flags().GetString("name")
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return "", "", err
+		}
+	}
+	resolved, err := config.NewService().ResolveConfiguration()
+	if err != nil {
+		return "", "", err
+	}
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	return core.SessionConfigDir(resolved.ProjectConfigDir, sessionName), containerName, nil
+}
+
+func portsAddHandler(cmd *cobra.Command, args []string) error {
+	hostPort, containerPort, err := portforward.ParseSpec(args[0])
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("reactor ports needs a host that can reach container IP addresses, which is only the case on Linux; add %d to forwardPorts and recreate the container instead", containerPort)
+	}
+	if _, isRemote, err := tunnel.DetectRemote(os.Getenv("DOCKER_HOST")); err != nil {
+		return err
+	} else if isRemote {
+		return fmt.Errorf("reactor ports cannot reach containers on a remote Docker daemon; add %d to forwardPorts and recreate the container instead", containerPort)
+	}
+
+	sessionDir, containerName, err := portsProject(cmd)
+	if err != nil {
+		return err
+	}
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+
+	info, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if info.Status != docker.StatusRunning {
+		return fmt.Errorf("container %s is not running; start it with 'reactor up'", containerName)
+	}
+	ip, err := dockerService.ContainerIP(ctx, info.ID)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the reactor binary: %w", err)
+	}
+	target := net.JoinHostPort(ip, strconv.Itoa(containerPort))
+	f := portforward.Forward{HostPort: hostPort, ContainerPort: containerPort, ContainerID: info.ID, Target: target}
+	if _, err := portforward.Start(sessionDir, f, executable); err != nil {
+		return err
+	}
+	fmt.Printf("Forwarding 127.0.0.1:%d to port %d of %s\n", hostPort, containerPort, containerName)
+	return nil
+}
+
+func portsRemoveHandler(cmd *cobra.Command, args []string) error {
+	hostPort, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid host port '%s'", args[0])
+	}
+	sessionDir, _, err := portsProject(cmd)
+	if err != nil {
+		return err
+	}
+	removed, err := portforward.Remove(sessionDir, hostPort)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("port %d is not forwarded; see 'reactor ports list'", hostPort)
+	}
+	fmt.Printf("Stopped forwarding port %d\n", hostPort)
+	return nil
+}
+
+func portsListHandler(cmd *cobra.Command, args []string) error {
+	sessionDir, _, err := portsProject(cmd)
+	if err != nil {
+		return err
+	}
+	forwards, err := portforward.List(sessionDir)
+	if err != nil {
+		return err
+	}
+	if len(forwards) == 0 {
+		fmt.Println("No ports forwarded. Add one with 'reactor ports add <host:container>'.")
+		return nil
+	}
+	fmt.Printf("%-10s %-10s %s\n", "HOST", "CONTAINER", "TARGET")
+	for _, f := range forwards {
+		fmt.Printf("%-10d %-10d %s\n", f.HostPort, f.ContainerPort, f.Target)
+	}
+	return nil
+}
+
+func portsServeHandler(cmd *cobra.Command, args []string) error {
+	hostPort, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid host port '%s'", args[0])
+	}
+	containerID, containerPort := args[1], args[2]
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() { _ = dockerService.Close() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Look the IP address up for every connection, as a restarted container may get another
+	target := func(ctx context.Context) (string, error) {
+		ip, err := dockerService.ContainerIP(ctx, containerID)
+		if err != nil {
+			return "", err
+		}
+		return net.JoinHostPort(ip, containerPort), nil
+	}
+	return portforward.Serve(ctx, hostPort, target, func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	})
+}
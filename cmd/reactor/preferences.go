@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/settings"
+	"github.com/moby/term"
+)
+
+// updateCheckInterval is how often reactor looks for a newer release on its own
+const updateCheckInterval = 24 * time.Hour
+
+// updateCheckFileName caches the result of the last update check next to the preferences
+const updateCheckFileName = "update-check.json"
+
+// userPreferences returns the preferences from ~/.reactor/config.yaml and the
+// environment. The root command has already reported an invalid file, so a failure
+// here falls back to the defaults.
+func userPreferences() *settings.Preferences {
+	prefs, err := settings.Load()
+	if err != nil {
+		return &settings.Preferences{}
+	}
+	return prefs
+}
+
+// updateCheck is the cached result of the last update check
+type updateCheck struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	LatestVersion string    `json:"latestVersion,omitempty"`
+	ReleaseURL    string    `json:"releaseUrl,omitempty"`
+}
+
+// notifyUpdate tells the user on out when a newer release than this build exists. The
+// latest release is looked up at most once per updateCheckInterval; development
+// builds, non-interactive runs and users with updateCheck: false are never checked.
+func notifyUpdate(out *os.File, prefs *settings.Preferences) {
+	if !prefs.UpdateCheckEnabled() || parseVersion(Version) == nil || !term.IsTerminal(out.Fd()) {
+		return
+	}
+	check := latestReleaseCheck(filepath.Join(filepath.Dir(prefs.Path), updateCheckFileName), time.Now(), func(ctx context.Context) (release, error) {
+		return fetchLatestRelease(ctx, &http.Client{Timeout: 2 * time.Second}, latestReleaseURL)
+	})
+	printUpdateNotice(out, check, Version)
+}
+
+// latestReleaseCheck returns the cached update check, refreshing it with fetch when it
+// is older than updateCheckInterval. A failed fetch is cached too, so an offline
+// machine is not slowed down on every command.
+func latestReleaseCheck(cachePath string, now time.Time, fetch func(context.Context) (release, error)) updateCheck {
+	var check updateCheck
+	if data, err := os.ReadFile(cachePath); err == nil {
+		_ = json.Unmarshal(data, &check)
+	}
+	if now.Sub(check.CheckedAt) < updateCheckInterval {
+		return check
+	}
+
+	check = updateCheck{CheckedAt: now.UTC()}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if latest, err := fetch(ctx); err == nil {
+		check.LatestVersion, check.ReleaseURL = latest.TagName, latest.HTMLURL
+	}
+	if data, err := json.Marshal(check); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			_ = os.WriteFile(cachePath, data, 0644)
+		}
+	}
+	return check
+}
+
+// printUpdateNotice prints a notice when the checked release is newer than current
+func printUpdateNotice(w io.Writer, check updateCheck, current string) {
+	if !isNewerVersion(check.LatestVersion, current) {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nA newer reactor release is available: %s (this is %s)\n", check.LatestVersion, current)
+	if check.ReleaseURL != "" {
+		_, _ = fmt.Fprintf(w, "%s\n", check.ReleaseURL)
+	}
+	_, _ = fmt.Fprintf(w, "Set updateCheck: false in ~/.reactor/%s or %s=false to stop these notices.\n", settings.FileName, settings.EnvUpdateCheck)
+}
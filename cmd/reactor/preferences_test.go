@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestReleaseCheck(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), updateCheckFileName)
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	fetches := 0
+	fetch := func(context.Context) (release, error) {
+		fetches++
+		return release{TagName: "v1.5.0", HTMLURL: "https://github.com/dyluth/reactor/releases/tag/v1.5.0"}, nil
+	}
+
+	check := latestReleaseCheck(cachePath, now, fetch)
+	assert.Equal(t, "v1.5.0", check.LatestVersion)
+	assert.Equal(t, 1, fetches)
+
+	// Within a day the cached result is used
+	check = latestReleaseCheck(cachePath, now.Add(23*time.Hour), fetch)
+	assert.Equal(t, "v1.5.0", check.LatestVersion)
+	assert.Equal(t, 1, fetches)
+
+	// A failed check is cached as well, so offline machines are not slowed down
+	failing := func(context.Context) (release, error) {
+		fetches++
+		return release{}, errors.New("offline")
+	}
+	check = latestReleaseCheck(cachePath, now.Add(25*time.Hour), failing)
+	assert.Empty(t, check.LatestVersion)
+	latestReleaseCheck(cachePath, now.Add(26*time.Hour), failing)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestPrintUpdateNotice(t *testing.T) {
+	var out bytes.Buffer
+	check := updateCheck{LatestVersion: "v1.5.0", ReleaseURL: "https://github.com/dyluth/reactor/releases/tag/v1.5.0"}
+
+	printUpdateNotice(&out, check, "v1.5.0")
+	assert.Empty(t, out.String())
+
+	printUpdateNotice(&out, check, "v1.4.2")
+	assert.Contains(t, out.String(), "A newer reactor release is available: v1.5.0 (this is v1.4.2)")
+	assert.Contains(t, out.String(), "https://github.com/dyluth/reactor/releases/tag/v1.5.0")
+	assert.Contains(t, out.String(), "updateCheck: false")
+}
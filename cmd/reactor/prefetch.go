@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/prefetch"
+	"github.com/spf13/cobra"
+)
+
+func newPrefetchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prefetch [directory]",
+		Short: "Pull and build the images of every dev container in a directory tree",
+		Long: `Find every devcontainer.json under a directory (default: the current one) and
+pull or build the images 'reactor up' would use, so the first 'up' of the day
+does not wait for them. Configurations in .devcontainer/<name>/ are included;
+hidden directories, node_modules and vendor are not searched.
+
+With --workspace the services of a workspace are prefetched instead, each with
+its own account's registry credentials.
+
+Images that are already present are left alone, like 'reactor up' does; an
+image shared by several configurations is pulled once. Builds write their full
+output to the project's build.log instead of the terminal. At most --jobs
+images are fetched at a time (default 2), so the command can run alongside
+other work or from cron or a login script.
+
+Examples:
+  reactor prefetch ~/src/monorepo            # Prefetch every configuration in a tree
+  reactor prefetch --jobs 4                  # Fetch up to 4 images at a time
+  reactor prefetch --workspace               # Prefetch the workspace in this directory
+  reactor prefetch --dry-run                 # Show what would be fetched
+  0 8 * * 1-5  reactor prefetch ~/src >> ~/.reactor/prefetch.log 2>&1   # crontab entry
+
+For more details, see the full documentation.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: prefetchHandler,
+	}
+
+	cmd.Flags().IntP("jobs", "j", 2, "Maximum number of images pulled or built at a time")
+	cmd.Flags().Bool("workspace", false, "Prefetch the services of a workspace instead of a directory tree")
+	cmd.Flags().StringP("file", "f", "", "Workspace file or directory (implies --workspace)")
+	cmd.Flags().String("checksum", "", "Expected sha256 of a workspace read from stdin or a URL")
+	cmd.Flags().Bool("dry-run", false, "List the images that would be pulled or built without fetching them")
+
+	return cmd
+}
+
+func prefetchHandler(cmd *cobra.Command, args []string) error {
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	useWorkspace, _ := cmd.Flags().GetBool("workspace")
+	workspaceFile, _ := cmd.Flags().GetString("file")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	if workspaceFile != "" {
+		useWorkspace = true
+	}
+
+	var targets []prefetch.Target
+	var err error
+	root := "."
+	if useWorkspace {
+		if len(args) > 0 {
+			return fmt.Errorf("a directory cannot be given with --workspace; use --file to name the workspace")
+		}
+		targets, err = workspacePrefetchTargets(cmd)
+	} else {
+		if len(args) > 0 {
+			root = args[0]
+		}
+		targets, err = prefetch.Discover(root)
+	}
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		fmt.Printf("No dev container configurations found under %s\n", root)
+		return nil
+	}
+
+	fetchJobs := prefetchJobs(targets)
+	if len(fetchJobs) == 0 {
+		return fmt.Errorf("no images to prefetch: every configuration failed to resolve")
+	}
+	if dryRun {
+		for _, job := range fetchJobs {
+			fmt.Printf("Would fetch %s\n", job.Name)
+		}
+		return nil
+	}
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := checkDockerHealth(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Prefetching %d images for %d configurations (%d at a time)...\n", len(fetchJobs), len(targets), jobs)
+	started := time.Now()
+	results := prefetch.Run(ctx, fetchJobs, jobs, func(result prefetch.Result) {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "  failed    %s: %v\n", result.Job.Name, result.Err)
+			return
+		}
+		fmt.Printf("  %-9s %s (%s)\n", result.Outcome, result.Job.Name, result.Elapsed.Round(100*time.Millisecond))
+	})
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images failed to prefetch", failed, len(results))
+	}
+	fmt.Printf("Prefetched %d images in %s\n", len(results), time.Since(started).Round(100*time.Millisecond))
+	return nil
+}
+
+// workspacePrefetchTargets returns a target for every service of the workspace named by --file
+func workspacePrefetchTargets(cmd *cobra.Command) ([]prefetch.Target, error) {
+	workspacePath, workspaceData, err := resolveWorkspaceSource(cmd)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := parseWorkspace(workspacePath, workspaceData)
+	if err != nil {
+		return nil, err
+	}
+	workspaceDir, err := filepath.Abs(filepath.Dir(workspacePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace directory: %w", err)
+	}
+
+	names := make([]string, 0, len(ws.Services))
+	for name := range ws.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	targets := make([]prefetch.Target, 0, len(names))
+	for _, name := range names {
+		service := ws.Services[name]
+		servicePath := service.Path
+		if !filepath.IsAbs(servicePath) {
+			servicePath = filepath.Join(workspaceDir, service.Path)
+		}
+		targets = append(targets, prefetch.Target{Dir: servicePath, Account: service.Account, Label: name})
+	}
+	return targets, nil
+}
+
+// prefetchJobs resolves each target and returns a job per image to fetch. Configurations
+// that fail to resolve are reported and skipped so one broken project does not stop the
+// others; an image used by several configurations is pulled once.
+func prefetchJobs(targets []prefetch.Target) []prefetch.Job {
+	var jobs []prefetch.Job
+	pulled := map[string]bool{}
+	for _, target := range targets {
+		configService := config.NewServiceWithRoot(target.Dir)
+		configService.SetConfigName(target.ConfigName)
+		if target.Account != "" {
+			configService.SetOverrides(map[string]string{config.SettingAccount: target.Account})
+		}
+		resolved, err := configService.ResolveConfiguration()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", target.Label, err)
+			continue
+		}
+		registryAuth, err := orchestrator.RegistryResolver(resolved)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", target.Label, err)
+			continue
+		}
+
+		if resolved.Build == nil {
+			imageName := docker.NormalizeImageName(resolved.Image)
+			if pulled[imageName] {
+				continue
+			}
+			pulled[imageName] = true
+			jobs = append(jobs, prefetch.Job{
+				Name:  fmt.Sprintf("%s (%s)", imageName, target.Label),
+				Fetch: pullJob(imageName, registryAuth),
+			})
+			continue
+		}
+
+		spec, err := orchestrator.BuildSpecFor(resolved)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", target.Label, err)
+			continue
+		}
+		jobs = append(jobs, prefetch.Job{
+			Name:  fmt.Sprintf("%s (%s)", spec.ImageName, target.Label),
+			Fetch: buildJob(spec, registryAuth),
+		})
+	}
+	return jobs
+}
+
+// pullJob pulls an image unless it is already present
+func pullJob(imageName string, registryAuth docker.RegistryAuth) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		dockerService, err := docker.NewService()
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize Docker service: %w", err)
+		}
+		defer closeDockerService(dockerService)
+
+		exists, err := dockerService.ImageExists(ctx, imageName)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if image exists: %w", err)
+		}
+		if exists {
+			return "present", nil
+		}
+		dockerService.SetRegistryAuth(registryAuth)
+		if err := dockerService.PullImages(ctx, []string{imageName}, io.Discard); err != nil {
+			return "", err
+		}
+		return "pulled", nil
+	}
+}
+
+// buildJob builds a configuration's image unless it is already present, recording the
+// build output only in the project's build log
+func buildJob(spec docker.BuildSpec, registryAuth docker.RegistryAuth) func(context.Context) (string, error) {
+	spec.Progress = docker.ProgressPlain
+	spec.Output = io.Discard
+	return func(ctx context.Context) (string, error) {
+		dockerService, err := docker.NewService()
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize Docker service: %w", err)
+		}
+		defer closeDockerService(dockerService)
+
+		exists, err := dockerService.ImageExists(ctx, spec.ImageName)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if image exists: %w", err)
+		}
+		if exists {
+			return "present", nil
+		}
+		dockerService.SetRegistryAuth(registryAuth)
+		if err := dockerService.BuildImage(ctx, spec, false); err != nil {
+			return "", fmt.Errorf("build failed, see %s: %w", spec.LogFile, err)
+		}
+		return "built", nil
+	}
+}
+
+// checkDockerHealth fails early when the Docker daemon cannot be reached
+func checkDockerHealth(ctx context.Context) error {
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer closeDockerService(dockerService)
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+	return nil
+}
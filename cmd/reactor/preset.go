@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/preset"
+	"github.com/dyluth/reactor/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newPresetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preset",
+		Short: "Share and fetch devcontainer presets via OCI registries",
+		Long: `Share and fetch dev container presets through any OCI-compliant registry.
+
+A preset packages a project's devcontainer.json together with its Dockerfile,
+feature configuration and helper scripts as an OCI artifact. Teams can publish
+blessed AI-agent environments to their registry and pull them into new projects.
+
+Registry credentials are read from your Docker CLI configuration, so run
+'docker login <registry>' before publishing to a private registry.
+
+Examples:
+  reactor preset publish ghcr.io/my-org/presets/python-agent:v1
+  reactor preset fetch ghcr.io/my-org/presets/python-agent:v1
+
+For more details, see the full documentation.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "publish <oci-ref>",
+		Short: "Publish the current project's dev container configuration",
+		Long: `Package the current project's dev container configuration and push it to an OCI registry.
+
+When the configuration lives in .devcontainer/, the whole directory is published.
+For a root .devcontainer.json, the file and its build Dockerfile are published.
+
+Examples:
+  reactor preset publish ghcr.io/my-org/presets/python-agent:v1
+  reactor preset publish localhost:5000/presets/go-agent`,
+		Args: cobra.ExactArgs(1),
+		RunE: presetPublishHandler,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "fetch <oci-ref>",
+		Short: "Fetch a preset into the current directory",
+		Long: `Pull a preset from an OCI registry and unpack it into the current directory.
+
+Existing files are never overwritten; the command fails if any preset file
+already exists in the target directory.
+
+Examples:
+  reactor preset fetch ghcr.io/my-org/presets/python-agent:v1
+  reactor preset fetch ghcr.io/my-org/presets/python-agent@sha256:<digest>`,
+		Args: cobra.ExactArgs(1),
+		RunE: presetFetchHandler,
+	})
+
+	return cmd
+}
+
+func presetPublishHandler(cmd *cobra.Command, args []string) error {
+	ref, err := preset.ParseReference(args[0])
+	if err != nil {
+		return err
+	}
+
+	projectDirectory, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	archive, files, err := preset.Package(projectDirectory)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Packaging preset from %s:\n", projectDirectory)
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+
+	annotations := map[string]string{
+		"org.opencontainers.image.title":   filepath.Base(projectDirectory),
+		"org.opencontainers.image.created": time.Now().UTC().Format(time.RFC3339),
+		"com.reactor.version":              Version,
+	}
+
+	digest, err := preset.NewClient().Push(ref, archive, annotations)
+	if err != nil {
+		return fmt.Errorf("failed to publish preset: %w", err)
+	}
+
+	fmt.Printf("\nPublished preset: %s\n", ref)
+	fmt.Printf("Digest: %s\n", digest)
+	return nil
+}
+
+func presetFetchHandler(cmd *cobra.Command, args []string) error {
+	ref, err := preset.ParseReference(args[0])
+	if err != nil {
+		return err
+	}
+
+	targetDirectory, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	fmt.Printf("Fetching preset: %s\n", ref)
+	archive, err := preset.NewClient().Pull(ref)
+	if err != nil {
+		return fmt.Errorf("failed to fetch preset: %w", err)
+	}
+
+	files, err := preset.Extract(archive, targetDirectory)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Fetched %d preset file(s):\n", ui.Stdout().Success(), len(files))
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+	fmt.Printf("Next steps:\n")
+	fmt.Printf("  reactor up\n")
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/moby/term"
+)
+
+// terminalPrivilegesPrompt asks on the terminal before a container is created with
+// elevated privileges. It returns nil when stdin is not a terminal, e.g. when a script
+// is piped to 'reactor up', so Up refuses without --allow-privileged.
+func terminalPrivilegesPrompt() func(requested []string) (bool, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+	return promptPrivileges(os.Stdin, os.Stdout)
+}
+
+// promptPrivileges returns an orchestrator.UpConfig.ConfirmPrivileges that asks on out
+// and reads the answer from in. Anything but y or yes declines.
+func promptPrivileges(in io.Reader, out io.Writer) func(requested []string) (bool, error) {
+	return func(requested []string) (bool, error) {
+		fmt.Fprintln(out, "devcontainer.json asks for elevated privileges:")
+		for _, privilege := range requested {
+			fmt.Fprintf(out, "  - %s\n", privilege)
+		}
+		fmt.Fprintln(out, "They let processes in the container reach the host kernel and devices; only allow them for images and agents you trust.")
+		fmt.Fprint(out, "Create the container with these privileges? [y/N] ")
+		return readYesNo(in)
+	}
+}
+
+// terminalRerunHooksPrompt asks on the terminal whether lifecycle commands edited since
+// the container was created run again. It returns nil when stdin is not a terminal, so
+// Up only warns about them without --rerun-hooks.
+func terminalRerunHooksPrompt() func(changed []string) (bool, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+	return promptRerunHooks(os.Stdin, os.Stdout)
+}
+
+// promptRerunHooks returns an orchestrator.UpConfig.ConfirmRerunHooks that asks on out
+// and reads the answer from in
+func promptRerunHooks(in io.Reader, out io.Writer) func(changed []string) (bool, error) {
+	return func(changed []string) (bool, error) {
+		names := strings.Join(changed, " and ")
+		fmt.Fprintf(out, "%s changed in devcontainer.json since the container was created.\n", names)
+		fmt.Fprintf(out, "Run %s again in the existing container? [y/N] ", names)
+		return readYesNo(in)
+	}
+}
+
+// terminalReplacePrompt asks on the terminal whether to remove a container reactor did
+// not create that holds the container's name. It returns nil when stdin is not a
+// terminal, so Up refuses without --replace.
+func terminalReplacePrompt() func(holder docker.ContainerInfo) (bool, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+	return promptReplace(os.Stdin, os.Stdout)
+}
+
+// promptReplace returns an orchestrator.UpConfig.ConfirmReplace that asks on out and
+// reads the answer from in
+func promptReplace(in io.Reader, out io.Writer) func(holder docker.ContainerInfo) (bool, error) {
+	return func(holder docker.ContainerInfo) (bool, error) {
+		id := holder.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Fprintf(out, "Container %s (image %s, %s) already uses the name %s, but reactor did not create it.\n", id, holder.Image, holder.State, holder.Name)
+		fmt.Fprint(out, "Remove it and create this project's container? [y/N] ")
+		return readYesNo(in)
+	}
+}
+
+// readYesNo reads an answer to a [y/N] question; anything but y or yes, including no
+// answer at all, declines
+func readYesNo(in io.Reader) (bool, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
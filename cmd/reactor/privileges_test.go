@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptPrivileges(t *testing.T) {
+	answers := map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false}
+	for input, expected := range answers {
+		var out bytes.Buffer
+		confirmed, err := promptPrivileges(strings.NewReader(input), &out)([]string{"privileged mode"})
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, confirmed, input)
+		assert.Contains(t, out.String(), "  - privileged mode\n")
+		assert.Contains(t, out.String(), "[y/N]")
+	}
+}
+
+func TestPromptRerunHooks(t *testing.T) {
+	var out bytes.Buffer
+	confirmed, err := promptRerunHooks(strings.NewReader("y\n"), &out)([]string{"postCreateCommand"})
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+	assert.Contains(t, out.String(), "Run postCreateCommand again in the existing container? [y/N]")
+
+	confirmed, err = promptRerunHooks(strings.NewReader(""), &out)([]string{"postCreateCommand"})
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+}
+
+func TestPromptReplace(t *testing.T) {
+	holder := docker.ContainerInfo{ID: "0123456789abcdef", Name: "reactor-demo", Image: "postgres:16", State: "exited"}
+	var out bytes.Buffer
+	confirmed, err := promptReplace(strings.NewReader("y\n"), &out)(holder)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+	assert.Contains(t, out.String(), "Container 0123456789ab (image postgres:16, exited) already uses the name reactor-demo, but reactor did not create it.\n")
+
+	confirmed, err = promptReplace(strings.NewReader("\n"), &out)(holder)
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+}
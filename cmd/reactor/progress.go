@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/ui"
+)
+
+// printProgress renders the progress events of orchestrator.Up and Down as text:
+// messages on stdout, warnings on stderr and verbose details marked [INFO]. Lines of
+// a workspace service start with its prefix. Services start in parallel, so lines are
+// written one event at a time.
+func printProgress(stdout, stderr io.Writer) orchestrator.EventFunc {
+	var mu sync.Mutex
+	outStyle, errStyle := ui.New(stdout), ui.New(stderr)
+	return func(event orchestrator.Event) {
+		w, style, marker := stdout, outStyle, ""
+		switch event.Level {
+		case orchestrator.LevelProgress:
+			return
+		case orchestrator.LevelDetail:
+			marker = "[INFO] "
+		case orchestrator.LevelWarning:
+			w, style, marker = stderr, errStyle, "Warning: "
+		}
+		prefix := ""
+		if event.Service != "" {
+			prefix = style.Service(event.Service) + " "
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = fmt.Fprintf(w, "%s%s%s\n", prefix, marker, event.Message)
+		for _, detail := range event.Details {
+			_, _ = fmt.Fprintf(w, "%s  - %s\n", prefix, detail)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintProgress(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	events := printProgress(&stdout, &stderr)
+
+	events(orchestrator.Event{Phase: orchestrator.PhaseContainer, Level: orchestrator.LevelProgress})
+	events(orchestrator.Event{Phase: orchestrator.PhaseContainer, Level: orchestrator.LevelInfo, Message: "Container provisioned: reactor-x"})
+	events(orchestrator.Event{Phase: orchestrator.PhaseContainer, Level: orchestrator.LevelDetail, Message: "Status: running"})
+	events(orchestrator.Event{Phase: orchestrator.PhaseContainer, Service: "api", Level: orchestrator.LevelWarning, Message: "configuration changed:", Details: []string{"image changed"}})
+
+	assert.Equal(t, "Container provisioned: reactor-x\n[INFO] Status: running\n", stdout.String())
+	assert.Equal(t, "[api] Warning: configuration changed:\n[api]   - image changed\n", stderr.String())
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/registryauth"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+// imageRegistryAuth pulls each image with the credentials of the account that needs it,
+// keyed by normalized image name, for pulls on behalf of several workspace services
+type imageRegistryAuth map[string]docker.RegistryAuth
+
+func (a imageRegistryAuth) AuthFor(imageName string) (registry.AuthConfig, bool, error) {
+	if auth, ok := a[docker.NormalizeImageName(imageName)]; ok {
+		return auth.AuthFor(imageName)
+	}
+	return registry.AuthConfig{}, false, nil
+}
+
+func (a imageRegistryAuth) All() (map[string]registry.AuthConfig, error) {
+	configs := map[string]registry.AuthConfig{}
+	for _, auth := range a {
+		all, err := auth.All()
+		if err != nil {
+			return nil, err
+		}
+		for server, config := range all {
+			if _, ok := configs[server]; !ok {
+				configs[server] = config
+			}
+		}
+	}
+	return configs, nil
+}
+
+func newAccountsLoginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login <registry>",
+		Short: "Store container registry credentials for an account",
+		Long: `Store credentials for a container registry in the account directory, so
+'reactor up', 'reactor build' and 'reactor workspace up' pull private images with
+the account's own identity instead of the host's Docker login.
+
+The credentials are kept in ~/.reactor/<account>/registries.json, readable by
+the owner only. With credential encryption on ("credentialEncryption" in account
+defaults), they are encrypted into registries.enc with age or the OS keychain
+like the provider directories.
+
+The credentials are checked with the registry through the Docker daemon before
+they are stored; --no-verify skips the check.
+
+To use the host's own Docker credentials (~/.docker/config.json, including
+credential helpers) for some registries instead, list them in the account
+defaults: {"hostRegistries": ["ghcr.io"]}. The account's stored credentials
+take precedence.
+
+Examples:
+  reactor accounts login ghcr.io --username bot --password-stdin < token.txt
+  reactor accounts login registry.example.com --account work`,
+		Args: cobra.ExactArgs(1),
+		RunE: accountsLoginHandler,
+	}
+	cmd.Flags().StringP("username", "u", "", "Registry username (prompted for when not given)")
+	cmd.Flags().Bool("password-stdin", false, "Read the password or token from stdin")
+	cmd.Flags().Bool("no-verify", false, "Store the credentials without checking them with the registry")
+	return cmd
+}
+
+func newAccountsLogoutCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logout <registry>",
+		Short: "Remove stored container registry credentials from an account",
+		Args:  cobra.ExactArgs(1),
+		RunE:  accountsLogoutHandler,
+	}
+	return cmd
+}
+
+func accountsLoginHandler(cmd *cobra.Command, args []string) error {
+	username, _ := cmd.Flags().GetString("username")
+	passwordStdin, _ := cmd.Flags().GetBool("password-stdin")
+	noVerify, _ := cmd.Flags().GetBool("no-verify")
+	host := registryauth.NormalizeHost(args[0])
+	if host == "" {
+		return fmt.Errorf("registry must be a host name such as ghcr.io")
+	}
+
+	store, account, err := accountRegistryStore(cmd)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(cmd.InOrStdin())
+	if username == "" {
+		if passwordStdin {
+			return fmt.Errorf("--password-stdin requires --username")
+		}
+		fmt.Printf("Username for %s: ", host)
+		if username, err = readLine(reader); err != nil {
+			return err
+		}
+	}
+	password, err := readRegistryPassword(reader, host, passwordStdin)
+	if err != nil {
+		return err
+	}
+	if username == "" || password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+
+	cred := registryauth.Credential{Username: username, Password: password}
+	if !noVerify {
+		if err := verifyRegistryCredential(host, cred); err != nil {
+			return err
+		}
+	}
+	if err := store.Set(host, cred); err != nil {
+		return err
+	}
+	fmt.Printf("Stored credentials for %s in account '%s' (%s)\n", host, account, store.Path())
+	return nil
+}
+
+func accountsLogoutHandler(cmd *cobra.Command, args []string) error {
+	host := registryauth.NormalizeHost(args[0])
+	store, account, err := accountRegistryStore(cmd)
+	if err != nil {
+		return err
+	}
+	removed, err := store.Remove(host)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("account '%s' has no stored credentials for %s", account, host)
+	}
+	fmt.Printf("Removed credentials for %s from account '%s'\n", host, account)
+	return nil
+}
+
+// accountRegistryStore returns the registry credential store of the --account flag or
+// the current project's account, encrypted per the account's settings
+func accountRegistryStore(cmd *cobra.Command) (*registryauth.Store, string, error) {
+	accountOverride, _ := cmd.Flags().GetString("account")
+	overrides := map[string]string{}
+	if accountOverride != "" {
+		overrides[config.SettingAccount] = accountOverride
+	}
+
+	// Outside a project the account comes from the environment or the system user
+	var devConfig *config.DevContainerConfig
+	configPath, found, err := config.FindDevContainerFile(".")
+	if err == nil && found {
+		if devConfig, err = config.LoadDevContainerConfig(configPath); err != nil {
+			return nil, "", err
+		}
+	}
+	settings, err := config.ResolveSettings(devConfig, configPath, overrides)
+	if err != nil {
+		return nil, "", err
+	}
+
+	account := settings.Get(config.SettingAccount)
+	store, err := orchestrator.AccountRegistryStore(account, settings.Get(config.SettingCredentialEncryption))
+	if err != nil {
+		return nil, "", err
+	}
+	return store, account, nil
+}
+
+// readRegistryPassword reads the password from stdin or prompts for it without echo
+func readRegistryPassword(reader *bufio.Reader, host string, fromStdin bool) (string, error) {
+	if fromStdin {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	fmt.Printf("Password for %s: ", host)
+	fd := os.Stdin.Fd()
+	if term.IsTerminal(fd) {
+		state, err := term.SaveState(fd)
+		if err == nil && term.DisableEcho(fd, state) == nil {
+			defer func() {
+				_ = term.RestoreTerminal(fd, state)
+				fmt.Println()
+			}()
+		}
+	}
+	return readLine(reader)
+}
+
+// readLine reads one line of input without its line ending
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// verifyRegistryCredential checks a credential with the registry through the Docker daemon
+func verifyRegistryCredential(host string, cred registryauth.Credential) error {
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer closeDockerService(dockerService)
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available to verify the credentials (use --no-verify to skip): %w", err)
+	}
+
+	return dockerService.RegistryLogin(ctx, registry.AuthConfig{
+		Username:      cred.Username,
+		Password:      cred.Password,
+		ServerAddress: registryauth.ServerAddress(host),
+	})
+}
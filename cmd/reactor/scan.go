@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/scan"
+	"github.com/dyluth/reactor/pkg/ui"
+)
+
+// maxReportedVulnerabilities limits how many findings are listed individually
+const maxReportedVulnerabilities = 20
+
+// writeImageSBOM generates a software bill of materials of a built image and writes it
+// to the project config directory
+func writeImageSBOM(ctx context.Context, imageName, projectConfigDir string) error {
+	tool, err := scan.DetectSBOMTool()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generating an SBOM of %s with %s...\n", imageName, tool)
+	sbom, err := scan.GenerateSBOM(ctx, tool, imageName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(projectConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create project config directory: %w", err)
+	}
+	path := filepath.Join(projectConfigDir, config.SBOMFileName)
+	if err := os.WriteFile(path, sbom, 0644); err != nil {
+		return fmt.Errorf("failed to write SBOM: %w", err)
+	}
+	fmt.Printf("SBOM written to %s\n", path)
+	return nil
+}
+
+// scanImage runs a vulnerability scan on a built image and applies the severity policy
+func scanImage(ctx context.Context, imageName string, scanConfig *config.ScanConfig) error {
+	threshold := scan.DefaultSeverity
+	if scanConfig.Severity != "" {
+		var err error
+		if threshold, err = scan.ParseSeverity(scanConfig.Severity); err != nil {
+			return err
+		}
+	}
+
+	scanner, err := scan.DetectScanner(scanConfig.Scanner)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Scanning image %s with %s...\n", imageName, scanner)
+	result, err := scan.Run(ctx, scanner, imageName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Vulnerabilities: %s\n", result.Summary())
+
+	findings := result.AtOrAbove(threshold)
+	if len(findings) == 0 {
+		fmt.Printf("%s No vulnerabilities at or above %s severity.\n", ui.Stdout().Success(), threshold)
+		return nil
+	}
+
+	for i, v := range findings {
+		if i == maxReportedVulnerabilities {
+			fmt.Printf("  ... and %d more\n", len(findings)-i)
+			break
+		}
+		fmt.Printf("  %-9s %-20s %s %s\n", v.Severity, v.ID, v.Package, v.InstalledVersion)
+	}
+
+	if scanConfig.WarnOnly {
+		fmt.Fprintf(os.Stderr, "Warning: found %d vulnerabilities at or above %s severity\n", len(findings), threshold)
+		return nil
+	}
+	return fmt.Errorf("image scan failed: found %d vulnerabilities at or above %s severity", len(findings), threshold)
+}
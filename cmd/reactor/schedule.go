@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/schedule"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run recurring commands inside the dev container",
+		Long: `Run commands inside the dev container on a cron schedule.
+
+Schedules are declared in devcontainer.json:
+
+  "customizations": {
+    "reactor": {
+      "schedules": [
+        {"cron": "0 * * * *", "command": "make test"},
+        {"cron": "@daily", "command": "git fetch --all"}
+      ]
+    }
+  }
+
+Cron expressions have five fields (minute hour day-of-month month day-of-week)
+and are evaluated in local time; @hourly, @daily, @weekly, @monthly and @yearly
+are also accepted. 'reactor schedule run' stays in the foreground and executes
+each command with sh -c in the running container when it is due. A command that
+is still running when it comes due again is skipped. The outcome of every run is
+recorded in the reactor state file and shown by 'reactor schedule list'.
+
+Examples:
+  reactor schedule list                # Show schedules, next run and last result
+  reactor schedule run                 # Execute schedules until interrupted
+  reactor schedule run --name feature  # Execute them in a named session
+
+For more details, see the full documentation.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the project's schedules",
+		Long:  "List the schedules from devcontainer.json with their next run time and last result",
+		Args:  cobra.NoArgs,
+		RunE:  scheduleListHandler,
+	}
+	listCmd.Flags().String("name", "", "Session name of the container")
+	cmd.AddCommand(listCmd)
+
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Execute schedules until interrupted",
+		Long:  "Execute the project's schedules in the running dev container until interrupted with Ctrl+C",
+		Args:  cobra.NoArgs,
+		RunE:  scheduleRunHandler,
+	}
+	runCmd.Flags().String("name", "", "Session name of the container to run in")
+	cmd.AddCommand(runCmd)
+
+	return cmd
+}
+
+// resolveSchedules loads the project's schedules and the name of the container they run in
+func resolveSchedules(cmd *cobra.Command) ([]schedule.Task, string, error) {
+	sessionName, _ := cmd.Flags().GetString("name")
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return nil, "", err
+		}
+	}
+
+	resolved, err := config.NewService().ResolveConfiguration()
+	if err != nil {
+		return nil, "", err
+	}
+
+	tasks := make([]schedule.Task, len(resolved.Schedules))
+	for i, s := range resolved.Schedules {
+		tasks[i] = schedule.Task{Cron: s.Cron, Command: s.Command}
+	}
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	return tasks, containerName, nil
+}
+
+func scheduleListHandler(cmd *cobra.Command, args []string) error {
+	tasks, containerName, err := resolveSchedules(cmd)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No schedules configured.")
+		fmt.Println("Add them to customizations.reactor.schedules in devcontainer.json.")
+		return nil
+	}
+
+	runner, err := schedule.NewRunner(tasks, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-16s %-17s %-22s %s\n", "CRON", "NEXT RUN", "LAST RESULT", "COMMAND")
+	now := time.Now()
+	for i, task := range tasks {
+		next := "never"
+		if t := runner.Next(i, now); !t.IsZero() {
+			next = t.Format("2006-01-02 15:04")
+		}
+
+		last := "-"
+		run, err := state.LastScheduleRun(containerName, task.Cron, task.Command)
+		if err != nil {
+			return err
+		}
+		if run != nil {
+			status := "ok"
+			if run.Error != "" {
+				status = fmt.Sprintf("failed (%d)", run.ExitCode)
+			}
+			last = fmt.Sprintf("%s %s", status, run.StartedAt.Local().Format("01-02 15:04"))
+		}
+
+		fmt.Printf("%-16s %-17s %-22s %s\n", task.Cron, next, last, task.Command)
+	}
+	return nil
+}
+
+func scheduleRunHandler(cmd *cobra.Command, args []string) error {
+	style := ui.Stdout()
+	tasks, containerName, err := resolveSchedules(cmd)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("no schedules configured; add them to customizations.reactor.schedules in devcontainer.json")
+	}
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+
+	info, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+	if info.Status != docker.StatusRunning {
+		return fmt.Errorf("container %s is not running. Run 'reactor up' first", containerName)
+	}
+
+	// The container is looked up for every run so a restarted container is picked up
+	exec := func(ctx context.Context, command string, out io.Writer) (int, error) {
+		info, err := dockerService.ContainerExists(ctx, containerName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check container existence: %w", err)
+		}
+		if info.Status != docker.StatusRunning {
+			return 0, fmt.Errorf("container %s is not running", containerName)
+		}
+		err = dockerService.ExecCommand(ctx, info.ID, docker.ExecOptions{
+			Command: []string{"sh", "-c", command},
+			Stdout:  out,
+			Stderr:  out,
+		})
+		var exitErr *docker.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.Code, nil
+		}
+		return 0, err
+	}
+
+	onResult := func(result schedule.Result) {
+		run := state.ScheduleRun{
+			ContainerName: containerName,
+			Cron:          result.Task.Cron,
+			Command:       result.Task.Command,
+			StartedAt:     result.StartedAt.UTC(),
+			Duration:      result.Duration,
+			ExitCode:      result.ExitCode,
+			Output:        result.Output,
+		}
+		timestamp := result.StartedAt.Format("15:04:05")
+		if result.Err != nil {
+			run.Error = result.Err.Error()
+			fmt.Printf("[%s] %s %s: %v\n", timestamp, style.Failure(), result.Task.Command, result.Err)
+		} else {
+			fmt.Printf("[%s] %s %s (%s)\n", timestamp, style.Success(), result.Task.Command, result.Duration.Round(time.Millisecond))
+		}
+		if err := state.RecordScheduleRun(run); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record scheduled run: %v\n", err)
+		}
+	}
+
+	runner, err := schedule.NewRunner(tasks, exec, onResult)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Running %d schedule(s) in %s. Press Ctrl+C to stop.\n", len(tasks), containerName)
+	return runner.Run(ctx)
+}
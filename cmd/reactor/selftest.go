@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/spf13/cobra"
+)
+
+// selftestMarker is echoed by the exec check and written by the diff check
+const selftestMarker = "reactor-selftest"
+
+// Results of a self-test check
+const (
+	selftestPass = "PASS"
+	selftestFail = "FAIL"
+	selftestSkip = "SKIP"
+)
+
+func newSelftestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Check that reactor works end to end on this machine",
+		Long: `Create a throwaway project whose dev container is built from a small image, then
+build, start, exec into, diff and remove it, reporting whether each step works.
+Use it to validate a new machine or CI runner.
+
+The self-test runs under its own isolation prefix, so it has its own
+~/.reactor-<prefix> directory and container names and does not touch your
+accounts, projects or containers. Everything it creates is removed afterwards,
+and a check is skipped when a step it builds on failed.
+
+Examples:
+  reactor selftest                          # Run the self-test with alpine
+  reactor selftest --image debian:bookworm-slim
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: selftestHandler,
+	}
+	cmd.Flags().String("image", "alpine:3.20", "Base image of the throwaway dev container; it must provide /bin/sh")
+	return cmd
+}
+
+// selftestCheck is one capability tested by 'reactor selftest'
+type selftestCheck struct {
+	Name     string
+	Requires string // a check that must pass first, empty for none
+	Run      func(ctx context.Context) error
+}
+
+// selftestResult is the outcome of a selftestCheck
+type selftestResult struct {
+	Name     string
+	Status   string // selftestPass, selftestFail or selftestSkip
+	Duration time.Duration
+	Detail   string // the error of a failed check or why it was skipped
+}
+
+func selftestHandler(cmd *cobra.Command, args []string) error {
+	baseImage, _ := cmd.Flags().GetString("image")
+
+	// Keep reactor's state and containers apart from the user's; a prefix set by the
+	// caller, e.g. a CI job, is kept
+	prefix := os.Getenv("REACTOR_ISOLATION_PREFIX")
+	if prefix == "" {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return fmt.Errorf("failed to generate an isolation prefix: %w", err)
+		}
+		prefix = "selftest-" + hex.EncodeToString(suffix)
+		if err := os.Setenv("REACTOR_ISOLATION_PREFIX", prefix); err != nil {
+			return err
+		}
+		defer func() { _ = os.Unsetenv("REACTOR_ISOLATION_PREFIX") }()
+		if reactorHome, err := config.GetReactorHomeDir(); err == nil {
+			defer func() { _ = os.RemoveAll(reactorHome) }()
+		}
+	}
+
+	projectDir, err := newSelftestProject(baseImage)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(projectDir) }()
+
+	fmt.Printf("Running the reactor self-test with %s (isolation prefix %s)\n", baseImage, prefix)
+	ctx := context.Background()
+	st := &selftest{projectDir: projectDir}
+	defer st.cleanup(ctx)
+
+	results := runSelftestChecks(ctx, st.checks(), os.Stdout)
+	failed := 0
+	for _, result := range results {
+		if result.Status == selftestFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("self-test failed: %d of %d checks failed", failed, len(results))
+	}
+	fmt.Println("Self-test passed.")
+	return nil
+}
+
+// newSelftestProject writes a throwaway project whose dev container is built from baseImage
+func newSelftestProject(baseImage string) (string, error) {
+	projectDir, err := os.MkdirTemp("", "reactor-selftest-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create the self-test project: %w", err)
+	}
+	devcontainerDir := filepath.Join(projectDir, ".devcontainer")
+	files := map[string]string{
+		"Dockerfile": fmt.Sprintf("FROM %s\n", baseImage),
+		"devcontainer.json": `{
+  "name": "reactor self-test",
+  "build": {"dockerfile": "Dockerfile"},
+  "remoteUser": "root",
+  "customizations": {"reactor": {"account": "selftest", "defaultCommand": "sleep infinity"}}
+}
+`,
+	}
+	err = os.MkdirAll(devcontainerDir, 0755)
+	for name, content := range files {
+		if err == nil {
+			err = os.WriteFile(filepath.Join(devcontainerDir, name), []byte(content), 0644)
+		}
+	}
+	if err != nil {
+		_ = os.RemoveAll(projectDir)
+		return "", fmt.Errorf("failed to create the self-test project: %w", err)
+	}
+	return projectDir, nil
+}
+
+// runSelftestChecks runs the checks in order, printing each result as it completes
+func runSelftestChecks(ctx context.Context, checks []selftestCheck, out io.Writer) []selftestResult {
+	passed := map[string]bool{}
+	results := make([]selftestResult, 0, len(checks))
+	for _, check := range checks {
+		result := selftestResult{Name: check.Name}
+		if check.Requires != "" && !passed[check.Requires] {
+			result.Status = selftestSkip
+			result.Detail = check.Requires + " did not pass"
+		} else {
+			start := time.Now()
+			err := check.Run(ctx)
+			result.Duration = time.Since(start).Round(time.Millisecond)
+			result.Status = selftestPass
+			if err != nil {
+				result.Status = selftestFail
+				result.Detail = err.Error()
+			}
+		}
+		passed[check.Name] = result.Status == selftestPass
+
+		switch result.Status {
+		case selftestPass:
+			fmt.Fprintf(out, "  %s  %-8s %s\n", result.Status, result.Name, result.Duration)
+		default:
+			fmt.Fprintf(out, "  %s  %-8s %s\n", result.Status, result.Name, result.Detail)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// selftest holds what the checks of one self-test run share
+type selftest struct {
+	projectDir    string
+	dockerService *docker.Service
+	resolved      *config.ResolvedConfig
+	imageName     string
+	containerID   string
+}
+
+func (s *selftest) checks() []selftestCheck {
+	return []selftestCheck{
+		{Name: "docker", Run: s.checkDocker},
+		{Name: "build", Requires: "docker", Run: s.checkBuild},
+		{Name: "up", Requires: "build", Run: s.checkUp},
+		{Name: "exec", Requires: "up", Run: s.checkExec},
+		{Name: "diff", Requires: "up", Run: s.checkDiff},
+		{Name: "down", Requires: "up", Run: s.checkDown},
+	}
+}
+
+func (s *selftest) checkDocker(ctx context.Context) error {
+	dockerService, err := newJobsDockerService(ctx)
+	if err != nil {
+		return err
+	}
+	s.dockerService = dockerService
+	return nil
+}
+
+func (s *selftest) checkBuild(ctx context.Context) error {
+	resolved, err := config.NewServiceWithRoot(s.projectDir).ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+	s.resolved = resolved
+	spec, err := orchestrator.BuildSpecFor(resolved)
+	if err != nil {
+		return err
+	}
+	var output bytes.Buffer
+	spec.Output = &output
+	s.imageName = spec.ImageName
+	if err := s.dockerService.BuildImage(ctx, spec, true); err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+func (s *selftest) checkUp(ctx context.Context) error {
+	_, containerID, err := orchestrator.Up(ctx, orchestrator.UpConfig{ProjectDirectory: s.projectDir})
+	if err != nil {
+		return err
+	}
+	s.containerID = containerID
+	return nil
+}
+
+func (s *selftest) checkExec(ctx context.Context) error {
+	var stdout, stderr bytes.Buffer
+	err := s.dockerService.ExecCommand(ctx, s.containerID, docker.ExecOptions{
+		Command: []string{"echo", selftestMarker},
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if strings.TrimSpace(stdout.String()) != selftestMarker {
+		return fmt.Errorf("expected output %q, got %q", selftestMarker, stdout.String())
+	}
+	return nil
+}
+
+func (s *selftest) checkDiff(ctx context.Context) error {
+	path := "/tmp/" + selftestMarker
+	if err := s.dockerService.ExecCommand(ctx, s.containerID, docker.ExecOptions{
+		Command: []string{"touch", path},
+		Stdout:  io.Discard,
+		Stderr:  io.Discard,
+	}); err != nil {
+		return err
+	}
+	changes, err := s.dockerService.ContainerDiff(ctx, s.containerID)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if change.Path == path {
+			return nil
+		}
+	}
+	return fmt.Errorf("the diff of %d change(s) does not include %s", len(changes), path)
+}
+
+func (s *selftest) checkDown(ctx context.Context) error {
+	if err := orchestrator.Down(ctx, s.projectDir, "", nil); err != nil {
+		return err
+	}
+	name := core.GenerateContainerName(s.resolved.Account, s.resolved.ProjectRoot, s.resolved.ProjectHash)
+	info, err := s.dockerService.ContainerExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if info.Status != docker.StatusNotFound {
+		return fmt.Errorf("container %s still exists", name)
+	}
+	s.containerID = ""
+	return nil
+}
+
+// cleanup removes the container and image a failed run may have left behind
+func (s *selftest) cleanup(ctx context.Context) {
+	if s.dockerService == nil {
+		return
+	}
+	defer closeDockerService(s.dockerService)
+	if s.containerID != "" {
+		if err := s.dockerService.RemoveContainer(ctx, s.containerID); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove the self-test container: %v\n", err)
+		}
+	}
+	if s.imageName != "" {
+		if _, err := s.dockerService.GetClient().ImageRemove(ctx, s.imageName, image.RemoveOptions{Force: true, PruneChildren: true}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove the self-test image %s: %v\n", s.imageName, err)
+		}
+	}
+}
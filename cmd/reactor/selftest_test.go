@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSelftestChecks(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	var ran []string
+	record := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+	results := runSelftestChecks(context.Background(), []selftestCheck{
+		{Name: "docker", Run: ok},
+		{Name: "up", Requires: "docker", Run: record("up", errors.New("no space left on device"))},
+		{Name: "exec", Requires: "up", Run: record("exec", nil)},
+		{Name: "lint", Run: record("lint", nil)},
+	}, &out)
+
+	require.Len(t, results, 4)
+	assert.Equal(t, []string{selftestPass, selftestFail, selftestSkip, selftestPass},
+		[]string{results[0].Status, results[1].Status, results[2].Status, results[3].Status})
+	assert.Equal(t, []string{"up", "lint"}, ran)
+	assert.Contains(t, out.String(), "FAIL  up       no space left on device")
+	assert.Contains(t, out.String(), "SKIP  exec     up did not pass")
+}
+
+func TestNewSelftestProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	projectDir, err := newSelftestProject("alpine:3.20")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(projectDir) }()
+
+	dockerfile, err := os.ReadFile(filepath.Join(projectDir, ".devcontainer", "Dockerfile"))
+	require.NoError(t, err)
+	assert.Equal(t, "FROM alpine:3.20\n", string(dockerfile))
+
+	resolved, err := config.NewServiceWithRoot(projectDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Equal(t, "selftest", resolved.Account)
+	assert.Equal(t, "root", resolved.RemoteUser)
+	assert.NotNil(t, resolved.Build)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// Labels that identify the workspace and service of a workspace container
+const (
+	workspaceInstanceLabel = "com.reactor.workspace.instance"
+	workspaceServiceLabel  = "com.reactor.workspace.service"
+)
+
+func newSessionsAdoptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adopt <container-name>",
+		Short: "Backfill the labels of a container created without them",
+		Long: `Adopt a container that has a reactor name but not the labels reactor records
+on the containers it creates, because an older version or a manual 'docker run'
+created it. Without them workspace commands cannot find the container, and
+session and project information is missing from list output.
+
+The project is taken from the container's /workspace mount, or from --project.
+The name must be the one reactor would generate for the project, so the account
+and session are read from it. For a workspace service, pass the workspace file
+with --workspace. Docker cannot change the labels of an existing container, so
+the missing ones are kept in the reactor state file and merged in whenever
+reactor lists containers; recreating the container adds them for real.
+
+Examples:
+  reactor sessions adopt reactor-cam-myproject-abc123
+  reactor sessions adopt reactor-cam-myproject-abc123 --project ~/src/myproject
+  reactor sessions adopt reactor-ws-api-reactor-cam-api-def456 --workspace reactor-workspace.yml
+
+For more details, see the full documentation.`,
+		Args: cobra.ExactArgs(1),
+		RunE: sessionsAdoptHandler,
+	}
+	cmd.Flags().String("project", "", "Project directory the container was created for")
+	cmd.Flags().String("workspace", "", "Workspace file (or its directory) of a workspace service container")
+	return cmd
+}
+
+func sessionsAdoptHandler(cmd *cobra.Command, args []string) error {
+	containerName := args[0]
+	projectDir, _ := cmd.Flags().GetString("project")
+	workspaceFile, _ := cmd.Flags().GetString("workspace")
+	if projectDir != "" && workspaceFile != "" {
+		return fmt.Errorf("--project cannot be used with --workspace; the workspace file names each service's project")
+	}
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+
+	reactorContainers, err := dockerService.ListReactorContainers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list reactor containers: %w", err)
+	}
+	var info *docker.ContainerInfo
+	for i := range reactorContainers {
+		if reactorContainers[i].Name == containerName {
+			info = &reactorContainers[i]
+		}
+	}
+	if info == nil {
+		return fmt.Errorf("no container named '%s' with a reactor name; run 'reactor sessions list' to see reactor containers", containerName)
+	}
+	created, err := dockerService.InspectContainerConfig(ctx, info.ID)
+	if err != nil {
+		return err
+	}
+
+	var labels map[string]string
+	if workspaceFile != "" {
+		labels, err = workspaceAdoptionLabels(containerName, workspaceFile)
+	} else {
+		if projectDir == "" {
+			projectDir = workspaceMountSource(created.Mounts)
+		}
+		if projectDir == "" {
+			return fmt.Errorf("cannot tell which project %s belongs to; pass its directory with --project", containerName)
+		}
+		labels, err = projectAdoptionLabels(containerName, projectDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	missing := make(map[string]string)
+	for key, value := range labels {
+		if _, ok := created.Labels[key]; !ok {
+			missing[key] = value
+		}
+	}
+	if len(missing) == 0 {
+		fmt.Printf("%s already has its reactor labels; nothing to adopt.\n", containerName)
+		return nil
+	}
+
+	if err := state.AdoptContainer(containerName, state.AdoptedContainer{ID: info.ID, Labels: missing}); err != nil {
+		return err
+	}
+	fmt.Printf("Adopted %s with the labels:\n", containerName)
+	keys := make([]string, 0, len(missing))
+	for key := range missing {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  %s=%s\n", key, missing[key])
+	}
+	return nil
+}
+
+// projectAdoptionLabels returns the labels reactor gives a container of the project,
+// reading the session from the container name
+func projectAdoptionLabels(containerName, projectDir string) (map[string]string, error) {
+	projectRoot, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	account, session, ok := core.ParseContainerName(containerName, projectRoot, config.GenerateProjectHash(projectRoot))
+	if !ok {
+		return nil, fmt.Errorf("container %s was not created for project %s; pass the project it was created for with --project", containerName, projectRoot)
+	}
+
+	labels := map[string]string{orchestrator.ProjectLabel: projectRoot, orchestrator.AccountLabel: account}
+	if session != "" {
+		labels[core.SessionLabel] = session
+	}
+	return labels, nil
+}
+
+// workspaceAdoptionLabels finds the workspace service a container was created for and
+// returns its project and workspace labels
+func workspaceAdoptionLabels(containerName, workspaceFile string) (map[string]string, error) {
+	workspacePath := workspaceFile
+	if fileInfo, err := os.Stat(workspaceFile); err == nil && fileInfo.IsDir() {
+		path, found, err := workspace.FindWorkspaceFile(workspaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("error finding workspace file: %w", err)
+		}
+		if !found {
+			return nil, fmt.Errorf("no reactor-workspace.yml or reactor-workspace.yaml found in directory: %s", workspaceFile)
+		}
+		workspacePath = path
+	}
+	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	workspaceHash, err := workspace.GenerateWorkspaceHash(workspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate workspace hash: %w", err)
+	}
+
+	names := make([]string, 0, len(ws.Services))
+	for name := range ws.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		projectName, ok := strings.CutPrefix(containerName, fmt.Sprintf("reactor-ws-%s-", name))
+		if !ok {
+			continue
+		}
+		servicePath := ws.Services[name].Path
+		if !filepath.IsAbs(servicePath) {
+			servicePath = filepath.Join(filepath.Dir(workspacePath), servicePath)
+		}
+		labels, err := projectAdoptionLabels(projectName, servicePath)
+		if err != nil {
+			continue
+		}
+		labels[workspaceInstanceLabel] = workspaceHash
+		labels[workspaceServiceLabel] = name
+		return labels, nil
+	}
+	return nil, fmt.Errorf("container %s does not belong to a service of %s", containerName, workspacePath)
+}
+
+// countUnadopted counts the listed containers that have a reactor name but not the
+// project label reactor gives the containers it creates. The devcontainer CLI
+// identifies its containers by their labels, so those are left alone.
+func countUnadopted(containers []docker.ContainerInfo) int {
+	count := 0
+	for _, c := range containers {
+		if c.Labels[orchestrator.ProjectLabel] == "" && c.Labels[orchestrator.DevcontainerCLILabel] == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// workspaceMountSource returns the host directory bind-mounted at /workspace, or "" if
+// the container has no such mount. Binds of paths with spaces are quoted.
+func workspaceMountSource(mounts []string) string {
+	for _, mount := range mounts {
+		mount = strings.Trim(mount, `"`)
+		source, ok := strings.CutSuffix(mount, ":/workspace")
+		if !ok {
+			source, _, ok = strings.Cut(mount, ":/workspace:")
+		}
+		if ok && filepath.IsAbs(source) {
+			return source
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectAdoptionLabels(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	projectRoot := filepath.Join(t.TempDir(), "api")
+	name := core.GenerateContainerName("cam", projectRoot, config.GenerateProjectHash(projectRoot))
+
+	labels, err := projectAdoptionLabels(name, projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{orchestrator.ProjectLabel: projectRoot, orchestrator.AccountLabel: "cam"}, labels)
+
+	labels, err = projectAdoptionLabels(core.SessionContainerName(name, "feature-x"), projectRoot)
+	require.NoError(t, err)
+	assert.Equal(t, "feature-x", labels[core.SessionLabel])
+
+	_, err = projectAdoptionLabels(name, filepath.Join(t.TempDir(), "web"))
+	assert.ErrorContains(t, err, "was not created for project")
+}
+
+func TestWorkspaceAdoptionLabels(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	dir := t.TempDir()
+	apiPath := filepath.Join(dir, "api")
+	require.NoError(t, os.MkdirAll(apiPath, 0755))
+	workspacePath := filepath.Join(dir, "reactor-workspace.yml")
+	require.NoError(t, os.WriteFile(workspacePath, []byte("version: \"1\"\nservices:\n  api:\n    path: ./api\n"), 0644))
+	workspaceHash, err := workspace.GenerateWorkspaceHash(workspacePath)
+	require.NoError(t, err)
+
+	name := "reactor-ws-api-" + core.GenerateContainerName("cam", apiPath, config.GenerateProjectHash(apiPath))
+	labels, err := workspaceAdoptionLabels(name, dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		orchestrator.ProjectLabel: apiPath,
+		orchestrator.AccountLabel: "cam",
+		workspaceInstanceLabel:    workspaceHash,
+		workspaceServiceLabel:     "api",
+	}, labels)
+
+	_, err = workspaceAdoptionLabels("reactor-ws-web-reactor-cam-web-abc123", workspacePath)
+	assert.ErrorContains(t, err, "does not belong to a service")
+}
+
+func TestWorkspaceMountSource(t *testing.T) {
+	assert.Equal(t, "/src/api", workspaceMountSource([]string{"/home/u/.reactor/cam:/home/claude/.claude", "/src/api:/workspace"}))
+	assert.Equal(t, "/src/my api", workspaceMountSource([]string{`"/src/my api:/workspace"`}))
+	assert.Equal(t, "/src/api", workspaceMountSource([]string{"/src/api:/workspace:ro"}))
+	assert.Empty(t, workspaceMountSource([]string{"reactor-overlay:/workspace-lower", "type=volume,source=x,target=/workspace"}))
+}
+
+func TestCountUnadopted(t *testing.T) {
+	containers := []docker.ContainerInfo{
+		{Name: "reactor-cam-api-abc123", Labels: map[string]string{orchestrator.ProjectLabel: "/src/api"}},
+		{Name: "reactor-cam-web-def456"},
+		{Name: "reactor-cam-cli-fed321", Labels: map[string]string{orchestrator.DevcontainerCLILabel: "reactor-cam-cli-fed321"}},
+	}
+	assert.Equal(t, 1, countUnadopted(containers))
+}
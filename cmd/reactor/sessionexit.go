@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// sessionExit separates how the session's process exited from failures to run the
+// session. It returns the exit status reactor passes on as its own, which it only does
+// with propagate, nil when the process succeeded or its status is not passed on, and
+// any other error.
+func sessionExit(sessionErr error, propagate bool) (*docker.ExitError, error) {
+	var exitErr *docker.ExitError
+	if !errors.As(sessionErr, &exitErr) {
+		return nil, sessionErr
+	}
+	if propagate {
+		return exitErr, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionExit(t *testing.T) {
+	exitErr, err := sessionExit(nil, true)
+	assert.Nil(t, exitErr)
+	assert.NoError(t, err)
+
+	failure := errors.New("failed to create exec instance")
+	exitErr, err = sessionExit(failure, true)
+	assert.Nil(t, exitErr)
+	assert.Equal(t, failure, err)
+
+	exitErr, err = sessionExit(fmt.Errorf("session: %w", &docker.ExitError{Code: 3}), true)
+	assert.NoError(t, err)
+	assert.Equal(t, &docker.ExitError{Code: 3}, exitErr)
+
+	// Without --propagate-exit the status is not passed on, with or without a terminal
+	exitErr, err = sessionExit(&docker.ExitError{Code: 3}, false)
+	assert.NoError(t, err)
+	assert.Nil(t, exitErr)
+}
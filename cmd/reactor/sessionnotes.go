@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+// sessionAliasPattern matches aliases such as "fix-login-bug"; they are typed in place
+// of container names, so they contain no spaces
+var sessionAliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$`)
+
+func newSessionsRenameCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <container-name | alias> [alias]",
+		Short: "Give a container a memorable alias and a note",
+		Long: `Give a container an alias and a free-text note, to keep track of what each
+agent session is working on. Both are shown by 'reactor sessions list', and the
+alias can be used instead of the container name with 'reactor sessions attach'.
+
+Aliases and notes are kept in the reactor state file, so they survive restarts of
+the container; 'reactor sessions clean' removes them with the containers. Without
+an alias argument the current alias is kept, so a note can be changed on its own.
+
+Examples:
+  reactor sessions rename reactor-cam-myproject-abc123 fix-login-bug
+  reactor sessions rename fix-login-bug --note "OAuth redirect loops on Safari"
+  reactor sessions rename fix-login-bug --clear     # Remove the alias and note
+
+For more details, see the full documentation.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: sessionsRenameHandler,
+	}
+	cmd.Flags().String("note", "", "Free-text note about what the session is working on; empty removes it")
+	cmd.Flags().Bool("clear", false, "Remove the container's alias and note")
+	return cmd
+}
+
+func sessionsRenameHandler(cmd *cobra.Command, args []string) error {
+	clearAll, _ := cmd.Flags().GetBool("clear")
+	noteChanged := cmd.Flags().Changed("note")
+	if clearAll && (len(args) == 2 || noteChanged) {
+		return fmt.Errorf("--clear cannot be used with an alias or --note")
+	}
+	if !clearAll && len(args) == 1 && !noteChanged {
+		return fmt.Errorf("give an alias, --note or --clear")
+	}
+	if len(args) == 2 && !sessionAliasPattern.MatchString(args[1]) {
+		return fmt.Errorf("invalid alias %q: use letters, digits, '.', '_' or '-', starting with a letter or digit", args[1])
+	}
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+
+	info, err := findSessionContainer(ctx, dockerService, args[0])
+	if err != nil {
+		return err
+	}
+	if info.Status == docker.StatusNotFound {
+		return fmt.Errorf("container '%s' not found; run 'reactor sessions list' to see containers", args[0])
+	}
+	containerName := info.Name
+
+	// An alias that is another container's name would hide that container
+	if len(args) == 2 && args[1] != containerName {
+		other, err := dockerService.ContainerExists(ctx, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to check container status: %w", err)
+		}
+		if other.Status != docker.StatusNotFound {
+			return fmt.Errorf("invalid alias %q: it is the name of another container", args[1])
+		}
+	}
+
+	if clearAll {
+		if err := state.SetSessionNote(containerName, "", ""); err != nil {
+			return err
+		}
+		fmt.Printf("Removed the alias and note of %s\n", containerName)
+		return nil
+	}
+
+	notes, err := state.SessionNotes()
+	if err != nil {
+		return err
+	}
+	current := notes[containerName]
+	if len(args) == 2 {
+		current.Alias = args[1]
+	}
+	if noteChanged {
+		current.Note, _ = cmd.Flags().GetString("note")
+	}
+	if err := state.SetSessionNote(containerName, current.Alias, current.Note); err != nil {
+		return err
+	}
+
+	if current.Alias != "" {
+		fmt.Printf("%s is now %s\n", containerName, current.Alias)
+	}
+	if current.Note != "" {
+		fmt.Printf("Note: %s\n", current.Note)
+	}
+	return nil
+}
+
+// findSessionContainer returns the container a name or alias refers to. A container's
+// own name wins over an alias, so an alias cannot hide a container. The status is
+// StatusNotFound when neither matches.
+func findSessionContainer(ctx context.Context, dockerService *docker.Service, nameOrAlias string) (docker.ContainerInfo, error) {
+	info, err := dockerService.ContainerExists(ctx, nameOrAlias)
+	if err != nil {
+		return docker.ContainerInfo{}, fmt.Errorf("failed to check container status: %w", err)
+	}
+	if info.Status != docker.StatusNotFound {
+		return info, nil
+	}
+	aliased, err := state.ResolveSessionAlias(nameOrAlias)
+	if err != nil || aliased == "" {
+		return info, err
+	}
+	info, err = dockerService.ContainerExists(ctx, aliased)
+	if err != nil {
+		return docker.ContainerInfo{}, fmt.Errorf("failed to check container status: %w", err)
+	}
+	return info, nil
+}
+
+// loadSessionNotes returns the aliases and notes for display, warning when the state
+// file cannot be read
+func loadSessionNotes() map[string]state.SessionNote {
+	notes, err := state.SessionNotes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read session notes: %v\n", err)
+	}
+	return notes
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionsRename_Arguments(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"reactor-a"}, "give an alias, --note or --clear"},
+		{[]string{"reactor-a", "fix-login-bug", "--clear"}, "--clear cannot be used with an alias or --note"},
+		{[]string{"reactor-a", "--note", "x", "--clear"}, "--clear cannot be used with an alias or --note"},
+		{[]string{"reactor-a", "fix login"}, `invalid alias "fix login"`},
+	}
+
+	for _, tt := range tests {
+		cmd := newSessionsRenameCmd()
+		cmd.SetArgs(tt.args)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		assert.ErrorContains(t, cmd.Execute(), tt.expected, tt.args)
+	}
+}
+
+// namedContainers is a Docker client that lists containers with the given names
+type namedContainers struct {
+	docker.DockerClient
+	names []string
+}
+
+func (c namedContainers) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	var summaries []container.Summary
+	for _, name := range c.names {
+		summaries = append(summaries, container.Summary{ID: name + "-id", Names: []string{"/" + name}, State: "running"})
+	}
+	return summaries, nil
+}
+
+func TestFindSessionContainer(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+	ctx := context.Background()
+	dockerService := docker.NewServiceWithClient(namedContainers{names: []string{"reactor-a", "reactor-b"}})
+	require.NoError(t, state.SetSessionNote("reactor-a", "fix-login-bug", ""))
+	// Recorded before aliases naming containers were refused
+	require.NoError(t, state.SetSessionNote("reactor-a-old", "reactor-b", ""))
+
+	info, err := findSessionContainer(ctx, dockerService, "fix-login-bug")
+	require.NoError(t, err)
+	assert.Equal(t, "reactor-a", info.Name)
+
+	info, err = findSessionContainer(ctx, dockerService, "reactor-b")
+	require.NoError(t, err)
+	assert.Equal(t, "reactor-b", info.Name, "a container's own name wins over an alias")
+
+	info, err = findSessionContainer(ctx, dockerService, "missing")
+	require.NoError(t, err)
+	assert.Equal(t, docker.StatusNotFound, info.Status)
+}
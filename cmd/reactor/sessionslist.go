@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+)
+
+// devcontainerLocalFolderLabel is the project directory the devcontainer CLI labels its
+// containers with
+const devcontainerLocalFolderLabel = "devcontainer.local_folder"
+
+// sessionOwner returns the account and project directory a container was created for,
+// read from its labels. Containers created before reactor recorded the account have it
+// read from their name; either is empty when unknown.
+func sessionOwner(c docker.ContainerInfo) (account, project string) {
+	project = c.Labels[orchestrator.ProjectLabel]
+	if project == "" {
+		project = c.Labels[devcontainerLocalFolderLabel]
+	}
+	account = c.Labels[orchestrator.AccountLabel]
+	if account == "" && project != "" {
+		name := c.Name
+		if cliName := c.Labels[orchestrator.DevcontainerCLILabel]; cliName != "" {
+			name = cliName
+		}
+		account, _, _ = core.ParseContainerName(name, project, config.GenerateProjectHash(project))
+	}
+	return account, project
+}
+
+// sessionFilter selects the containers 'reactor sessions list' shows
+type sessionFilter struct {
+	account string
+	// project is a project directory, or the name of one
+	project string
+}
+
+// matches reports whether a container of account and project passes the filter
+func (f sessionFilter) matches(account, project string) bool {
+	if f.account != "" && account != f.account {
+		return false
+	}
+	if f.project == "" {
+		return true
+	}
+	if project == "" {
+		return false
+	}
+	if filepath.Base(project) == f.project {
+		return true
+	}
+	dir, err := filepath.Abs(f.project)
+	return err == nil && dir == project
+}
+
+// filterSessions returns the containers that pass the filter
+func filterSessions(containers []docker.ContainerInfo, filter sessionFilter) []docker.ContainerInfo {
+	var matched []docker.ContainerInfo
+	for _, c := range containers {
+		if filter.matches(sessionOwner(c)) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// sessionSortOrders are the orders 'reactor sessions list --sort' accepts
+var sessionSortOrders = []string{"recent", "name"}
+
+// sortSessions orders containers by name, or by "recent" use: running containers first,
+// most recently started first, then the others by when they stopped or were created
+func sortSessions(containers []docker.ContainerInfo, times map[string]docker.ContainerTimes, order string) {
+	switch order {
+	case "name":
+		sort.SliceStable(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+	case "recent":
+		sort.SliceStable(containers, func(i, j int) bool {
+			a, b := containers[i], containers[j]
+			if running := a.Status == docker.StatusRunning; running != (b.Status == docker.StatusRunning) {
+				return running
+			}
+			if at, bt := lastUsed(a, times[a.ID]), lastUsed(b, times[b.ID]); !at.Equal(bt) {
+				return at.After(bt)
+			}
+			return a.Name < b.Name
+		})
+	}
+}
+
+// lastUsed returns when a running container was started, or when another stopped or,
+// never started, was created
+func lastUsed(c docker.ContainerInfo, t docker.ContainerTimes) time.Time {
+	if c.Status == docker.StatusRunning {
+		return t.StartedAt
+	}
+	if !t.FinishedAt.IsZero() {
+		return t.FinishedAt
+	}
+	return c.Created
+}
+
+// sessionUptime describes how long a container has been running, or how long ago it
+// stopped or was created, e.g. "up 3h" or "stopped 2d ago"
+func sessionUptime(c docker.ContainerInfo, t docker.ContainerTimes, now time.Time) string {
+	switch {
+	case c.Status == docker.StatusRunning && !t.StartedAt.IsZero():
+		return "up " + shortDuration(now.Sub(t.StartedAt))
+	case c.Status == docker.StatusRunning:
+		return "-"
+	case !t.FinishedAt.IsZero():
+		return "stopped " + shortDuration(now.Sub(t.FinishedAt)) + " ago"
+	case !c.Created.IsZero():
+		return "created " + shortDuration(now.Sub(c.Created)) + " ago"
+	}
+	return "-"
+}
+
+// shortDuration formats a duration in its largest whole unit, e.g. 45s, 12m, 3h or 2d
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
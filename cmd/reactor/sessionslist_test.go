@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionOwner(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	apiRoot := "/src/api"
+	legacyName := core.GenerateContainerName("cam", apiRoot, config.GenerateProjectHash(apiRoot))
+
+	account, project := sessionOwner(docker.ContainerInfo{Name: "x", Labels: map[string]string{orchestrator.AccountLabel: "work", orchestrator.ProjectLabel: apiRoot}})
+	assert.Equal(t, "work", account)
+	assert.Equal(t, apiRoot, project)
+
+	// Containers from before the account label have it read from their name
+	account, project = sessionOwner(docker.ContainerInfo{Name: core.SessionContainerName(legacyName, "feature-x"), Labels: map[string]string{orchestrator.ProjectLabel: apiRoot}})
+	assert.Equal(t, "cam", account)
+	assert.Equal(t, apiRoot, project)
+
+	account, project = sessionOwner(docker.ContainerInfo{Name: "eager_turing", Labels: map[string]string{orchestrator.DevcontainerCLILabel: legacyName, devcontainerLocalFolderLabel: apiRoot}})
+	assert.Equal(t, "cam", account)
+	assert.Equal(t, apiRoot, project)
+
+	account, project = sessionOwner(docker.ContainerInfo{Name: legacyName})
+	assert.Empty(t, account)
+	assert.Empty(t, project)
+}
+
+func TestFilterSessions(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	webRoot := filepath.Join(dir, "web")
+	container := func(name, account, project string) docker.ContainerInfo {
+		return docker.ContainerInfo{Name: name, Labels: map[string]string{orchestrator.AccountLabel: account, orchestrator.ProjectLabel: project}}
+	}
+	containers := []docker.ContainerInfo{
+		container("a", "work", "/src/api"),
+		container("b", "personal", "/src/api"),
+		container("c", "work", webRoot),
+		{Name: "d"},
+	}
+	names := func(filter sessionFilter) []string {
+		var names []string
+		for _, c := range filterSessions(containers, filter) {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"a", "c"}, names(sessionFilter{account: "work"}))
+	assert.Equal(t, []string{"a", "b"}, names(sessionFilter{project: "api"}))
+	assert.Equal(t, []string{"b"}, names(sessionFilter{account: "personal", project: "/src/api"}))
+
+	// A relative directory is resolved from the working directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	assert.Equal(t, []string{"c"}, names(sessionFilter{project: "./web"}))
+	assert.Empty(t, names(sessionFilter{project: "mobile"}))
+}
+
+func TestSortSessions(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	containers := []docker.ContainerInfo{
+		{ID: "1", Name: "old-stopped", Status: docker.StatusStopped},
+		{ID: "2", Name: "long-running", Status: docker.StatusRunning},
+		{ID: "3", Name: "never-started", Status: docker.StatusNotFound, Created: now.Add(-time.Hour)},
+		{ID: "4", Name: "just-started", Status: docker.StatusRunning},
+		{ID: "5", Name: "recently-stopped", Status: docker.StatusStopped},
+	}
+	times := map[string]docker.ContainerTimes{
+		"1": {StartedAt: now.Add(-72 * time.Hour), FinishedAt: now.Add(-48 * time.Hour)},
+		"2": {StartedAt: now.Add(-24 * time.Hour)},
+		"4": {StartedAt: now.Add(-time.Minute)},
+		"5": {StartedAt: now.Add(-3 * time.Hour), FinishedAt: now.Add(-2 * time.Minute)},
+	}
+
+	names := func() []string {
+		var names []string
+		for _, c := range containers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	sortSessions(containers, times, "recent")
+	assert.Equal(t, []string{"just-started", "long-running", "recently-stopped", "never-started", "old-stopped"}, names())
+
+	sortSessions(containers, times, "name")
+	assert.Equal(t, []string{"just-started", "long-running", "never-started", "old-stopped", "recently-stopped"}, names())
+}
+
+func TestSessionUptime(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	running := docker.ContainerInfo{Status: docker.StatusRunning}
+	stopped := docker.ContainerInfo{Status: docker.StatusStopped, Created: now.Add(-5 * 24 * time.Hour)}
+
+	assert.Equal(t, "up 3h", sessionUptime(running, docker.ContainerTimes{StartedAt: now.Add(-3*time.Hour - 20*time.Minute)}, now))
+	assert.Equal(t, "-", sessionUptime(running, docker.ContainerTimes{}, now))
+	assert.Equal(t, "stopped 12m ago", sessionUptime(stopped, docker.ContainerTimes{FinishedAt: now.Add(-12 * time.Minute)}, now))
+	assert.Equal(t, "created 5d ago", sessionUptime(stopped, docker.ContainerTimes{}, now))
+	assert.Equal(t, "-", sessionUptime(docker.ContainerInfo{Status: docker.StatusStopped}, docker.ContainerTimes{}, now))
+}
+
+func TestShortDuration(t *testing.T) {
+	assert.Equal(t, "0s", shortDuration(-time.Second))
+	assert.Equal(t, "45s", shortDuration(45*time.Second))
+	assert.Equal(t, "59m", shortDuration(59*time.Minute+59*time.Second))
+	assert.Equal(t, "47h", shortDuration(47*time.Hour))
+	assert.Equal(t, "2d", shortDuration(50*time.Hour))
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	units "github.com/docker/go-units"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// statsHeader is the column layout --stats appends to list tables
+const statsHeader = "%-8s %-21s %-6s"
+
+// statsColumns formats a container's resource usage for a list table, or dashes when
+// no sample was taken (for example because the container is not running)
+func statsColumns(stats docker.ContainerStats, ok bool) string {
+	if !ok {
+		return fmt.Sprintf(statsHeader, "-", "-", "-")
+	}
+	memory := units.BytesSize(float64(stats.MemoryUsage))
+	if stats.MemoryLimit > 0 {
+		memory += " / " + units.BytesSize(float64(stats.MemoryLimit))
+	}
+	return fmt.Sprintf(statsHeader, fmt.Sprintf("%.1f%%", stats.CPUPercent), memory, fmt.Sprint(stats.PIDs))
+}
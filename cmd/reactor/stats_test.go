@@ -0,0 +1,16 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsColumns(t *testing.T) {
+	columns := strings.Fields(statsColumns(docker.ContainerStats{CPUPercent: 153.25, MemoryUsage: 512 << 20, MemoryLimit: 2 << 30, PIDs: 37}, true))
+	assert.Equal(t, []string{"153.2%", "512MiB", "/", "2GiB", "37"}, columns)
+
+	assert.Equal(t, []string{"-", "-", "-"}, strings.Fields(statsColumns(docker.ContainerStats{}, false)))
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the project's dev container",
+		Long: `Show the state of the dev container for the current project, including the
+disk space used by its writable layer.
+
+A warning is printed when the writable layer grows past 80% of the project's
+diskLimit (customizations.reactor.diskLimit), or past 10GB when no limit is set.
+The limit is only enforced when the Docker storage driver supports it
+(e.g. overlay2 on xfs with pquota); otherwise it is used for the warning alone.
+
+When the container exits with an error or is OOM-killed, reactor records a
+post-mortem the next time it sees the stopped container (status, up, down):
+the exit code, the reason, the last lines of output and the memory limit.
+--last-exit shows it, answering "why did my session die?".
+
+Examples:
+  reactor status                    # Status of the default container
+  reactor status --name feature-x   # Status of a named session
+  reactor status --last-exit        # Why the container last crashed
+
+For more details, see the full documentation.`,
+		Args: cobra.NoArgs,
+		RunE: statusCmdHandler,
+	}
+
+	cmd.Flags().String("name", "", "Session name of the container to inspect")
+	cmd.Flags().Bool("last-exit", false, "Show the post-mortem of the last crash or OOM kill")
+
+	return cmd
+}
+
+func statusCmdHandler(cmd *cobra.Command, args []string) error {
+	sessionName, _ := cmd.Flags().GetString("name")
+	lastExit, _ := cmd.Flags().GetBool("last-exit")
+
+	if err := config.CheckDependencies(); err != nil {
+		return err
+	}
+
+	configService := config.NewService()
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker service: %w", err)
+	}
+	defer func() {
+		if err := dockerService.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+		}
+	}()
+
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		return fmt.Errorf("docker daemon not available: %w", err)
+	}
+
+	containerName := core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), sessionName)
+	containerInfo, err := dockerService.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+
+	// Record a crash that happened since reactor last touched the container
+	logDir := config.ContainerLogDir(resolved.ProjectConfigDir, containerName)
+	if containerInfo.Status == docker.StatusStopped {
+		if _, err := dockerService.CapturePostMortem(ctx, containerInfo.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record why the container stopped: %v\n", err)
+		}
+	}
+	if lastExit {
+		return printPostMortem(os.Stdout, containerName, logDir)
+	}
+
+	fmt.Printf("Container: %s\n", containerName)
+	if containerInfo.Status == docker.StatusNotFound {
+		fmt.Println("State:     not created (run 'reactor up' to start it)")
+		return nil
+	}
+
+	state := containerInfo.State
+	if containerInfo.Health != "" {
+		state = fmt.Sprintf("%s (%s)", state, containerInfo.Health)
+	}
+	fmt.Printf("State:     %s\n", ui.Stdout().State(state))
+	fmt.Printf("Image:     %s\n", containerInfo.Image)
+	if pm, err := docker.ReadPostMortem(logDir); err == nil && pm != nil && pm.ContainerID == containerInfo.ID && containerInfo.Status == docker.StatusStopped {
+		fmt.Printf("Exit:      %s (run 'reactor status --last-exit' for details)\n", pm.Cause())
+	}
+
+	usage, err := dockerService.ContainerDiskUsage(ctx, containerName)
+	if err != nil {
+		return err
+	}
+
+	limit := "none"
+	if resolved.DiskLimit != "" {
+		limit = resolved.DiskLimit
+		if containerInfo.Labels[docker.DiskLimitLabel] == "" {
+			limit += " (not enforced by the storage driver)"
+		}
+	}
+	fmt.Printf("Disk:      %s used by the writable layer, limit %s\n", units.BytesSize(float64(usage)), limit)
+
+	if threshold := config.DiskWarnThreshold(resolved.DiskLimit); usage > threshold {
+		fmt.Fprintf(os.Stderr, "Warning: container writable layer uses %s, above the %s warning threshold. Consider cleaning caches or recreating the container with 'reactor down' and 'reactor up'.\n",
+			units.BytesSize(float64(usage)), units.BytesSize(float64(threshold)))
+	}
+
+	return nil
+}
+
+// printPostMortem prints the post-mortem recorded in logDir
+func printPostMortem(w io.Writer, containerName, logDir string) error {
+	pm, err := docker.ReadPostMortem(logDir)
+	if err != nil {
+		return err
+	}
+	if pm == nil {
+		_, _ = fmt.Fprintf(w, "No crash recorded for %s: it has not exited with an error or been OOM-killed.\n", containerName)
+		return nil
+	}
+
+	id := pm.ContainerID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	_, _ = fmt.Fprintf(w, "Container: %s (%s)\n", pm.ContainerName, id)
+	_, _ = fmt.Fprintf(w, "Image:     %s\n", pm.Image)
+	_, _ = fmt.Fprintf(w, "Cause:     %s\n", pm.Cause())
+	_, _ = fmt.Fprintf(w, "Exit code: %d\n", pm.ExitCode)
+	if !pm.FinishedAt.IsZero() {
+		finished := pm.FinishedAt.Local().Format(time.DateTime)
+		if !pm.StartedAt.IsZero() && pm.FinishedAt.After(pm.StartedAt) {
+			finished += fmt.Sprintf(" after running %s", pm.FinishedAt.Sub(pm.StartedAt).Round(time.Second))
+		}
+		_, _ = fmt.Fprintf(w, "Stopped:   %s\n", finished)
+	}
+	if pm.MemoryLimit > 0 {
+		_, _ = fmt.Fprintf(w, "Memory:    limit %s\n", units.BytesSize(float64(pm.MemoryLimit)))
+	} else if pm.OOMKilled {
+		_, _ = fmt.Fprintln(w, "Memory:    no container limit, the host ran out of memory")
+	}
+	if pm.Health != "" {
+		_, _ = fmt.Fprintf(w, "Health:    %s\n", pm.Health)
+	}
+	if pm.Error != "" {
+		_, _ = fmt.Fprintf(w, "Error:     %s\n", pm.Error)
+	}
+	if pm.OOMKilled {
+		_, _ = fmt.Fprintln(w, "\nThe container used all the memory it was allowed. Run fewer processes in it at once,\nor give Docker more memory (Docker Desktop: Settings > Resources).")
+	}
+
+	if len(pm.LastLogLines) > 0 {
+		_, _ = fmt.Fprintf(w, "\nLast %d lines of output:\n", len(pm.LastLogLines))
+		for _, line := range pm.LastLogLines {
+			_, _ = fmt.Fprintf(w, "  %s\n", line)
+		}
+	} else {
+		_, _ = fmt.Fprintln(w, "\nThe container printed no output.")
+	}
+	_, _ = fmt.Fprintf(w, "\nRecorded in %s\n", filepath.Join(logDir, docker.PostMortemFileName))
+	return nil
+}
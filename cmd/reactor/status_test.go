@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintPostMortem(t *testing.T) {
+	logDir := t.TempDir()
+
+	var out bytes.Buffer
+	require.NoError(t, printPostMortem(&out, "reactor-alice-proj", logDir))
+	assert.Contains(t, out.String(), "No crash recorded for reactor-alice-proj")
+
+	started := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	data, err := json.Marshal(docker.PostMortem{
+		ContainerName: "reactor-alice-proj",
+		ContainerID:   "0123456789abcdef",
+		Image:         "node:20",
+		ExitCode:      137,
+		OOMKilled:     true,
+		StartedAt:     started,
+		FinishedAt:    started.Add(90 * time.Minute),
+		MemoryLimit:   2 << 30,
+		LastLogLines:  []string{"building index", "allocating 4GB"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(logDir, docker.PostMortemFileName), data, 0644))
+
+	out.Reset()
+	require.NoError(t, printPostMortem(&out, "reactor-alice-proj", logDir))
+	report := out.String()
+	assert.Contains(t, report, "Container: reactor-alice-proj (0123456789ab)")
+	assert.Contains(t, report, "Cause:     killed by the out-of-memory killer")
+	assert.Contains(t, report, "Exit code: 137")
+	assert.Contains(t, report, "after running 1h30m0s")
+	assert.Contains(t, report, "Memory:    limit 2GiB")
+	assert.Contains(t, report, "Last 2 lines of output:\n  building index\n  allocating 4GB\n")
+	assert.Contains(t, report, filepath.Join(logDir, docker.PostMortemFileName))
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/policy"
+	"github.com/dyluth/reactor/pkg/telemetry"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newTelemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Manage opt-in usage statistics",
+		Long: `Manage reactor's usage statistics, which are off until enabled.
+
+When enabled, every command run adds to counters kept in
+~/.reactor/telemetry.json: how often each command and flag is used, how long
+commands take and the class of error they fail with (such as
+docker-daemon-not-running). Arguments, flag values, paths and project or
+account names are never recorded.
+
+The statistics never leave this machine on their own: 'reactor telemetry
+export' prints them, or posts them to a URL such as a platform team's collector,
+so you can see exactly what is shared. 'reactor telemetry disable' stops
+recording and deletes them. REACTOR_TELEMETRY=off or DO_NOT_TRACK=1 pause
+recording, e.g. in CI, without deleting anything.
+
+Examples:
+  reactor telemetry status                         # Show whether it is on and what was recorded
+  reactor telemetry enable                         # Start recording locally
+  reactor telemetry export                         # Print the statistics as JSON
+  reactor telemetry export --url https://example.com/reactor  # Post them to a collector
+  reactor telemetry disable                        # Stop and delete the statistics
+
+For more details, see the full documentation.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show whether usage statistics are recorded, and what they hold",
+		Args:  cobra.NoArgs,
+		RunE:  telemetryStatusHandler,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "enable",
+		Short: "Start recording usage statistics on this machine",
+		Args:  cobra.NoArgs,
+		RunE:  telemetryEnableHandler,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "disable",
+		Short: "Stop recording usage statistics and delete them",
+		Args:  cobra.NoArgs,
+		RunE:  telemetryDisableHandler,
+	})
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the usage statistics as JSON, or post them to a URL",
+		Args:  cobra.NoArgs,
+		RunE:  telemetryExportHandler,
+	}
+	exportCmd.Flags().String("url", "", "Post the statistics to this http(s) URL instead of printing them")
+	cmd.AddCommand(exportCmd)
+
+	return cmd
+}
+
+func telemetryStatusHandler(cmd *cobra.Command, args []string) error {
+	stats, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	path, err := telemetry.Path()
+	if err != nil {
+		return err
+	}
+	printTelemetryStatus(os.Stdout, stats, path)
+	return nil
+}
+
+// printTelemetryStatus describes whether statistics are recorded and sums them up
+func printTelemetryStatus(out io.Writer, stats *telemetry.Stats, path string) {
+	if !stats.Enabled {
+		fmt.Fprintln(out, "Telemetry: disabled. Nothing is recorded; enable it with 'reactor telemetry enable'.")
+		return
+	}
+	fmt.Fprintf(out, "Telemetry: enabled since %s, recorded in %s\n", stats.Since.Local().Format("2006-01-02"), path)
+	if variable, paused := telemetry.Paused(); paused {
+		fmt.Fprintf(out, "Recording is paused by %s.\n", variable)
+	}
+	fmt.Fprintln(out, "Nothing leaves this machine unless you run 'reactor telemetry export'.")
+	if len(stats.Commands) == 0 {
+		fmt.Fprintln(out, "\nNo commands recorded yet.")
+		return
+	}
+	fmt.Fprintf(out, "\n%-24s %-6s %-9s %s\n", "COMMAND", "RUNS", "FAILURES", "AVERAGE")
+	for _, name := range stats.CommandNames() {
+		c := stats.Commands[name]
+		fmt.Fprintf(out, "%-24s %-6d %-9d %s\n", name, c.Runs, c.Failures, c.Average().Round(100*time.Millisecond))
+	}
+}
+
+func telemetryEnableHandler(cmd *cobra.Command, args []string) error {
+	if _, err := telemetry.Enable(time.Now()); err != nil {
+		return err
+	}
+	fmt.Println("Telemetry enabled: command and flag usage, durations and error classes are recorded on this machine.")
+	fmt.Println("See them with 'reactor telemetry status'; they are only shared when you run 'reactor telemetry export'.")
+	if variable, paused := telemetry.Paused(); paused {
+		fmt.Fprintf(os.Stderr, "Warning: recording stays paused while %s is set\n", variable)
+	}
+	return nil
+}
+
+func telemetryDisableHandler(cmd *cobra.Command, args []string) error {
+	if err := telemetry.Disable(); err != nil {
+		return err
+	}
+	fmt.Println("Telemetry disabled, and the statistics recorded so far were deleted.")
+	return nil
+}
+
+func telemetryExportHandler(cmd *cobra.Command, args []string) error {
+	stats, err := telemetry.Load()
+	if err != nil {
+		return err
+	}
+	if !stats.Enabled {
+		return fmt.Errorf("telemetry is not enabled, so there is nothing to export; enable it with 'reactor telemetry enable'")
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry: %w", err)
+	}
+
+	url, _ := cmd.Flags().GetString("url")
+	if url == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("--url '%s' must start with http:// or https://", url)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := postTelemetry(ctx, &http.Client{}, url, data); err != nil {
+		return err
+	}
+	fmt.Printf("Exported usage statistics of %d commands to %s\n", len(stats.Commands), url)
+	return nil
+}
+
+// postTelemetry sends the exported statistics as a JSON POST
+func postTelemetry(ctx context.Context, client *http.Client, url string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to export telemetry: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export telemetry: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export telemetry: %s answered %s", url, resp.Status)
+	}
+	return nil
+}
+
+// recordTelemetry adds the run of cmd to the usage statistics when they are enabled.
+// Hidden commands, such as the background process of 'ports add', are not counted.
+func recordTelemetry(cmd *cobra.Command, runErr error, duration time.Duration) {
+	if cmd == nil || !cmd.HasParent() {
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Hidden {
+			return
+		}
+	}
+	run := telemetry.Run{
+		Command:    strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		Flags:      changedFlags(cmd),
+		Duration:   duration,
+		ErrorClass: errorClass(runErr),
+		Version:    Version,
+	}
+	if err := telemetry.Record(run); err != nil {
+		debug.Logf(debug.Config, "failed to record telemetry: %v", err)
+	}
+}
+
+// changedFlags returns the names of the flags given on the command line
+func changedFlags(cmd *cobra.Command) []string {
+	var names []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		names = append(names, flag.Name)
+	})
+	return names
+}
+
+// errorClass sorts an error into a class that says what went wrong without any of the
+// details of the message, empty for success
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var exitErr *docker.ExitError
+	if errors.As(err, &exitErr) {
+		return "exit-status"
+	}
+	var violation *policy.ViolationError
+	if errors.As(err, &violation) {
+		return "policy-violation"
+	}
+	if errors.Is(err, context.Canceled) {
+		return "interrupted"
+	}
+	if d, ok := docker.Diagnose(err.Error()); ok {
+		// The anchor of the troubleshooting section names the failure
+		if _, anchor, found := strings.Cut(d.DocURL, "#"); found {
+			return anchor
+		}
+	}
+	message := err.Error()
+	for _, usage := range []string{"unknown flag", "unknown shorthand flag", "unknown command", "accepts ", "requires at least", "invalid argument"} {
+		if strings.HasPrefix(message, usage) {
+			return "usage"
+		}
+	}
+	return telemetry.ErrorOther
+}
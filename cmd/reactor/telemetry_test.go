@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/policy"
+	"github.com/dyluth/reactor/pkg/telemetry"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "", errorClass(nil))
+	assert.Equal(t, "exit-status", errorClass(&docker.ExitError{Code: 2}))
+	assert.Equal(t, "policy-violation", errorClass(fmt.Errorf("up: %w", &policy.ViolationError{Path: "policy.yaml"})))
+	assert.Equal(t, "interrupted", errorClass(fmt.Errorf("exec: %w", context.Canceled)))
+	assert.Equal(t, "docker-daemon-not-running", errorClass(errors.New("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?")))
+	assert.Equal(t, "usage", errorClass(errors.New("unknown flag: --rebuld")))
+	// The message itself, which may hold paths or names, is never used
+	assert.Equal(t, telemetry.ErrorOther, errorClass(errors.New("failed to read /home/me/project/.devcontainer/devcontainer.json")))
+}
+
+func TestRecordTelemetry(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv(telemetry.EnvTelemetry, "")
+	t.Setenv("DO_NOT_TRACK", "")
+	_, err := telemetry.Enable(time.Now())
+	require.NoError(t, err)
+
+	root := &cobra.Command{Use: "reactor"}
+	sessions := &cobra.Command{Use: "sessions"}
+	attach := &cobra.Command{Use: "attach"}
+	attach.Flags().Bool("no-wait", false, "")
+	attach.Flags().String("name", "", "")
+	serve := &cobra.Command{Use: "serve", Hidden: true}
+	sessions.AddCommand(attach)
+	root.AddCommand(sessions, serve)
+	require.NoError(t, attach.Flags().Parse([]string{"--name", "secret-project"}))
+
+	recordTelemetry(attach, nil, 1500*time.Millisecond)
+	recordTelemetry(serve, nil, time.Hour)
+	recordTelemetry(root, errors.New("unknown command"), time.Millisecond)
+
+	stats, err := telemetry.Load()
+	require.NoError(t, err)
+	require.Len(t, stats.Commands, 1)
+	assert.Equal(t, &telemetry.Command{Runs: 1, TotalMs: 1500, MaxMs: 1500, Flags: map[string]int{"name": 1}}, stats.Commands["sessions attach"])
+
+	var out bytes.Buffer
+	printTelemetryStatus(&out, stats, "/home/me/.reactor/telemetry.json")
+	assert.Contains(t, out.String(), "Telemetry: enabled since")
+	assert.Contains(t, out.String(), "sessions attach")
+	assert.NotContains(t, out.String(), "secret-project")
+
+	out.Reset()
+	printTelemetryStatus(&out, &telemetry.Stats{}, "")
+	assert.Contains(t, out.String(), "Telemetry: disabled")
+}
+
+func TestPostTelemetry(t *testing.T) {
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	require.NoError(t, postTelemetry(context.Background(), server.Client(), server.URL, []byte(`{"enabled":true}`)))
+	assert.Equal(t, `{"enabled":true}`, string(received))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	assert.ErrorContains(t, postTelemetry(context.Background(), failing.Client(), failing.URL, nil), "answered 403 Forbidden")
+}
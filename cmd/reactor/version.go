@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/spf13/cobra"
+)
+
+// latestReleaseURL is the GitHub API endpoint describing the latest reactor release
+var latestReleaseURL = "https://api.github.com/repos/dyluth/reactor/releases/latest"
+
+// buildInfo is the output of 'reactor version --json'
+type buildInfo struct {
+	Version          string `json:"version"`
+	GitCommit        string `json:"commit"`
+	BuildDate        string `json:"buildDate"`
+	GoVersion        string `json:"goVersion"`
+	Platform         string `json:"platform"`
+	DockerAPIVersion string `json:"dockerApiVersion,omitempty"`
+	LatestVersion    string `json:"latestVersion,omitempty"`
+	UpdateAvailable  *bool  `json:"updateAvailable,omitempty"`
+	ReleaseURL       string `json:"releaseUrl,omitempty"`
+}
+
+// release is the part of a GitHub release the update check uses
+type release struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+}
+
+func newVersionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show version information",
+		Long: `Display version, build date, git commit, Go version and the Docker API
+version negotiated with the daemon. The Docker API version is left out when the
+daemon is not reachable.
+
+Use --json for bug reports and tooling, and --check to compare this build with
+the latest release on GitHub. --json is the default with output: json in
+~/.reactor/config.yaml.
+
+Release builds also check for a newer release on their own, at most once a day,
+and mention it after other commands in a terminal. Set updateCheck: false in
+~/.reactor/config.yaml or REACTOR_UPDATE_CHECK=false to turn this off.
+
+Examples:
+  reactor version                  # Human-readable build information
+  reactor version --json           # Build information as JSON
+  reactor version --check          # Check whether a newer release is available`,
+		Args: cobra.NoArgs,
+		RunE: versionHandler,
+	}
+
+	cmd.Flags().Bool("json", false, "Print build information as JSON")
+	cmd.Flags().Bool("check", false, "Compare this version with the latest release")
+
+	return cmd
+}
+
+func versionHandler(cmd *cobra.Command, args []string) error {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if !cmd.Flags().Changed("json") {
+		asJSON = userPreferences().JSONOutput()
+	}
+	check, _ := cmd.Flags().GetBool("check")
+	ctx := context.Background()
+
+	info := buildInfo{
+		Version:          Version,
+		GitCommit:        GitCommit,
+		BuildDate:        BuildDate,
+		GoVersion:        runtime.Version(),
+		Platform:         runtime.GOOS + "/" + runtime.GOARCH,
+		DockerAPIVersion: dockerAPIVersion(ctx),
+	}
+
+	if check {
+		latest, err := fetchLatestRelease(ctx, &http.Client{Timeout: 10 * time.Second}, latestReleaseURL)
+		if err != nil {
+			return err
+		}
+		newer := isNewerVersion(latest.TagName, Version)
+		info.LatestVersion = latest.TagName
+		info.UpdateAvailable = &newer
+		info.ReleaseURL = latest.HTMLURL
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	fmt.Printf("reactor version %s\n", info.Version)
+	fmt.Printf("Git commit: %s\n", info.GitCommit)
+	fmt.Printf("Build date: %s\n", info.BuildDate)
+	fmt.Printf("Go version: %s (%s)\n", info.GoVersion, info.Platform)
+	if info.DockerAPIVersion != "" {
+		fmt.Printf("Docker API version: %s\n", info.DockerAPIVersion)
+	} else {
+		fmt.Printf("Docker API version: unavailable (daemon not reachable)\n")
+	}
+
+	if check {
+		switch {
+		case *info.UpdateAvailable:
+			fmt.Printf("\nA newer version is available: %s\n%s\n", info.LatestVersion, info.ReleaseURL)
+		case parseVersion(Version) == nil:
+			fmt.Printf("\nThis is a development build; the latest release is %s\n", info.LatestVersion)
+		default:
+			fmt.Printf("\nreactor is up to date (latest release: %s)\n", info.LatestVersion)
+		}
+	}
+	return nil
+}
+
+// dockerAPIVersion returns the API version negotiated with the daemon, or "" if it is unreachable
+func dockerAPIVersion(ctx context.Context) string {
+	dockerService, err := docker.NewService()
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = dockerService.Close() }()
+
+	version, err := dockerService.APIVersion(ctx)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// fetchLatestRelease reads the latest release from the GitHub releases API
+func fetchLatestRelease(ctx context.Context, client *http.Client, url string) (release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return release{}, fmt.Errorf("failed to check for the latest release: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("failed to check for the latest release: %s", resp.Status)
+	}
+
+	var latest release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&latest); err != nil {
+		return release{}, fmt.Errorf("failed to decode the latest release: %w", err)
+	}
+	if latest.TagName == "" {
+		return release{}, fmt.Errorf("failed to check for the latest release: response has no tag name")
+	}
+	return latest, nil
+}
+
+// isNewerVersion reports whether latest is a higher release than current. Development
+// builds and versions that are not numeric never compare as older.
+func isNewerVersion(latest, current string) bool {
+	l, c := parseVersion(latest), parseVersion(current)
+	if l == nil || c == nil {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses "v1.2.3" (with an optional pre-release or build suffix, or the
+// "-N-gSHA" suffix of git describe) into its major, minor and patch numbers
+func parseVersion(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil
+		}
+		numbers[i] = n
+	}
+	return numbers
+}
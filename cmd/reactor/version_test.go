@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	assert.True(t, isNewerVersion("v1.3.0", "v1.2.9"))
+	assert.True(t, isNewerVersion("v2.0.0", "1.10.0"))
+	assert.True(t, isNewerVersion("v1.2.4", "v1.2.3-5-gabc1234-dirty"))
+	assert.False(t, isNewerVersion("v1.2.3", "v1.2.3"))
+	assert.False(t, isNewerVersion("v1.2.3", "v1.10.0"))
+	assert.False(t, isNewerVersion("v1.2.3", "dev"))
+	assert.False(t, isNewerVersion("nightly", "v1.0.0"))
+}
+
+func TestFetchLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/vnd.github+json", r.Header.Get("Accept"))
+		_, _ = w.Write([]byte(`{"tag_name": "v1.4.0", "html_url": "https://github.com/dyluth/reactor/releases/tag/v1.4.0"}`))
+	}))
+	defer server.Close()
+
+	latest, err := fetchLatestRelease(context.Background(), server.Client(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, release{TagName: "v1.4.0", HTMLURL: "https://github.com/dyluth/reactor/releases/tag/v1.4.0"}, latest)
+}
+
+func TestFetchLatestRelease_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := fetchLatestRelease(context.Background(), server.Client(), server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to check for the latest release: 403 Forbidden")
+}
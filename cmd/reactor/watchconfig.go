@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/moby/term"
+)
+
+// configWatchInterval is how often 'reactor up --watch-config' checks the watched files
+const configWatchInterval = time.Second
+
+// configWatchFiles returns the files whose changes call for a rebuild: devcontainer.json
+// and, when the image is built, its Dockerfile
+func configWatchFiles(resolved *config.ResolvedConfig) []string {
+	files := []string{resolved.ConfigPath}
+	if resolved.Build != nil {
+		if spec, err := orchestrator.BuildSpecFor(resolved); err == nil {
+			files = append(files, filepath.Join(spec.Context, spec.Dockerfile))
+		}
+	}
+	return files
+}
+
+// configWatcher polls files for changes to their content, so editors that rewrite a
+// file without changing it do not count
+type configWatcher struct {
+	files  []string
+	notify func(path string) // called once per file, the first time it changes
+
+	mu      sync.Mutex
+	hashes  map[string]string
+	changed map[string]bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newConfigWatcher records the current content of files; call Start to begin polling
+func newConfigWatcher(files []string, notify func(path string)) *configWatcher {
+	w := &configWatcher{
+		files:   files,
+		notify:  notify,
+		hashes:  make(map[string]string, len(files)),
+		changed: make(map[string]bool),
+	}
+	for _, file := range files {
+		w.hashes[file] = fileHash(file)
+	}
+	return w
+}
+
+// Start polls the files every interval until Stop is called
+func (w *configWatcher) Start(ctx context.Context, interval time.Duration) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.Check()
+			}
+		}
+	}()
+}
+
+// Stop ends polling started with Start
+func (w *configWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// Check compares the files with their recorded content
+func (w *configWatcher) Check() {
+	for _, file := range w.files {
+		hash := fileHash(file)
+		w.mu.Lock()
+		first := hash != w.hashes[file] && !w.changed[file]
+		if first {
+			w.changed[file] = true
+		}
+		w.mu.Unlock()
+		if first && w.notify != nil {
+			w.notify(file)
+		}
+	}
+}
+
+// Changed returns the files that changed since the watcher was created
+func (w *configWatcher) Changed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := make([]string, 0, len(w.changed))
+	for file := range w.changed {
+		changed = append(changed, file)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// fileHash returns a hash of a file's content, empty when it cannot be read
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// printConfigChanged tells the user in the session that a watched file changed. The
+// session's terminal is in raw mode, so lines end with \r\n.
+func printConfigChanged(out io.Writer, path string) {
+	fmt.Fprintf(out, "\r\n[reactor] %s changed. Exit the session to rebuild and recreate the container.\r\n", filepath.Base(path))
+}
+
+// terminalRebuildPrompt asks on the terminal whether to rebuild after the watched files
+// changed. It returns nil when stdin is not a terminal, so nothing is rebuilt.
+func terminalRebuildPrompt() func(changed []string) (bool, error) {
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		return nil
+	}
+	return func(changed []string) (bool, error) {
+		// Read a single key without waiting for Enter
+		oldState, err := term.SetRawTerminal(fd)
+		if err != nil {
+			return false, fmt.Errorf("failed to read answer: %w", err)
+		}
+		defer func() { _ = term.RestoreTerminal(fd, oldState) }()
+		return promptRebuild(os.Stdin, os.Stdout, changed)
+	}
+}
+
+// promptRebuild asks on out whether to rebuild and recreate the container after files
+// changed, reading a single key from in: r rebuilds, anything else leaves it running
+func promptRebuild(in io.Reader, out io.Writer, changed []string) (bool, error) {
+	names := make([]string, len(changed))
+	for i, file := range changed {
+		names[i] = filepath.Base(file)
+	}
+	fmt.Fprintf(out, "\r\n%s changed during the session.\r\n", strings.Join(names, " and "))
+	fmt.Fprint(out, "Press r to rebuild and recreate the container, any other key to leave it running: ")
+	key := make([]byte, 1)
+	n, err := in.Read(key)
+	fmt.Fprint(out, "\r\n")
+	if n == 0 {
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("failed to read answer: %w", err)
+		}
+		return false, nil
+	}
+	return key[0] == 'r' || key[0] == 'R', nil
+}
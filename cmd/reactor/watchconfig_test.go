@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".devcontainer", "devcontainer.json")
+
+	assert.Equal(t, []string{configPath}, configWatchFiles(&config.ResolvedConfig{ConfigPath: configPath}))
+	assert.Equal(t, []string{configPath, filepath.Join(dir, ".devcontainer", "Dockerfile.dev")}, configWatchFiles(&config.ResolvedConfig{
+		ConfigPath: configPath,
+		Build:      &config.Build{Dockerfile: "Dockerfile.dev"},
+	}))
+}
+
+func TestConfigWatcher(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "devcontainer.json")
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine"}`), 0644))
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM alpine\n"), 0644))
+
+	var notified []string
+	w := newConfigWatcher([]string{configPath, dockerfile}, func(path string) { notified = append(notified, path) })
+
+	// Rewriting a file with the same content is not a change
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine"}`), 0644))
+	w.Check()
+	assert.Empty(t, w.Changed())
+
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM debian\n"), 0644))
+	w.Check()
+	require.NoError(t, os.WriteFile(dockerfile, []byte("FROM ubuntu\n"), 0644))
+	w.Check()
+	assert.Equal(t, []string{dockerfile}, w.Changed())
+	assert.Equal(t, []string{dockerfile}, notified, "each file is only reported once")
+}
+
+func TestPromptRebuild(t *testing.T) {
+	changed := []string{"/p/.devcontainer/devcontainer.json", "/p/.devcontainer/Dockerfile"}
+	for _, tc := range []struct {
+		input string
+		want  bool
+	}{
+		{"r", true},
+		{"R", true},
+		{"q", false},
+		{"", false},
+	} {
+		var out bytes.Buffer
+		rebuild, err := promptRebuild(strings.NewReader(tc.input), &out, changed)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, rebuild, "input %q", tc.input)
+		assert.Contains(t, out.String(), "devcontainer.json and Dockerfile changed during the session.")
+	}
+}
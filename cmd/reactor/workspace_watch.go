@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/settings"
+	"github.com/dyluth/reactor/pkg/workspace"
+)
+
+// maxWatchTransitions bounds the transition log shown beneath the live table
+const maxWatchTransitions = 10
+
+// watchWorkspaceStatus redraws the workspace status table every interval until
+// interrupted, logging each service status transition as it is observed.
+func watchWorkspaceStatus(ctx context.Context, endpoints *workspaceEndpoints, ws *workspace.Workspace, workspacePath, workspaceHash string, interval time.Duration, showStats bool) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous map[string]string
+	var transitions []string
+
+	clearScreen := settings.ColorEnabled(os.Stdout)
+	for {
+		// Clear the screen and move the cursor home before redrawing
+		if clearScreen {
+			fmt.Print("\033[H\033[2J")
+		} else {
+			fmt.Printf("\n--- %s ---\n", time.Now().Format("15:04:05"))
+		}
+		current := printWorkspaceStatus(ctx, endpoints, ws, workspacePath, workspaceHash, showStats)
+
+		transitions = append(transitions, statusTransitions(previous, current, time.Now())...)
+		if len(transitions) > maxWatchTransitions {
+			transitions = transitions[len(transitions)-maxWatchTransitions:]
+		}
+		previous = current
+
+		if len(transitions) > 0 {
+			fmt.Printf("\nRecent transitions:\n")
+			for _, transition := range transitions {
+				fmt.Printf("  %s\n", transition)
+			}
+		}
+		fmt.Printf("\nRefreshing every %s. Press Ctrl+C to stop.\n", interval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// statusTransitions describes the services whose status changed between two refreshes
+func statusTransitions(previous, current map[string]string, at time.Time) []string {
+	if previous == nil {
+		return nil
+	}
+
+	serviceNames := make([]string, 0, len(current))
+	for serviceName := range current {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	var transitions []string
+	for _, serviceName := range serviceNames {
+		if before, after := previous[serviceName], current[serviceName]; before != after {
+			transitions = append(transitions, fmt.Sprintf("%s %s: %s → %s", at.Format("15:04:05"), serviceName, before, after))
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceStatus(t *testing.T) {
+	tests := []struct {
+		info     docker.ContainerInfo
+		expected string
+	}{
+		{docker.ContainerInfo{Status: docker.StatusNotFound}, "not found"},
+		{docker.ContainerInfo{Status: docker.StatusNotFound, State: "created"}, "starting"},
+		{docker.ContainerInfo{Status: docker.StatusRunning, State: "running", Health: "starting"}, "starting"},
+		{docker.ContainerInfo{Status: docker.StatusRunning, State: "running"}, "running"},
+		{docker.ContainerInfo{Status: docker.StatusRunning, State: "running", Health: "healthy"}, "running"},
+		{docker.ContainerInfo{Status: docker.StatusRunning, State: "running", Health: "unhealthy"}, "unhealthy"},
+		{docker.ContainerInfo{Status: docker.StatusStopped, State: "exited"}, "stopped"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, serviceStatus(tt.info), "state=%s health=%s", tt.info.State, tt.info.Health)
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC)
+
+	assert.Empty(t, statusTransitions(nil, map[string]string{"api": "running"}, at), "first refresh has no transitions")
+
+	transitions := statusTransitions(
+		map[string]string{"api": "starting", "db": "running", "web": "running"},
+		map[string]string{"api": "running", "db": "running", "web": "unhealthy"},
+		at,
+	)
+	assert.Equal(t, []string{
+		"12:30:00 api: starting → running",
+		"12:30:00 web: running → unhealthy",
+	}, transitions)
+}
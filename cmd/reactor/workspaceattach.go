@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+)
+
+// attachPromptValue as the --attach value asks which service to attach to
+const attachPromptValue = "-"
+
+// selectAttachService returns the service 'workspace up --attach' attaches to, asking
+// on the terminal when attach is attachPromptValue
+func selectAttachService(attach string, services []string) (string, error) {
+	if attach == attachPromptValue {
+		if !docker.IsInteractiveTerminal() {
+			return "", fmt.Errorf("--attach - needs a terminal to choose a service; name the service instead")
+		}
+		return promptAttachService(bufio.NewReader(os.Stdin), os.Stdout, services)
+	}
+
+	for _, name := range services {
+		if name == attach {
+			return attach, nil
+		}
+	}
+	return "", fmt.Errorf("cannot attach to service '%s': it is not being started", attach)
+}
+
+// promptAttachService lists services by number and reads a number or a name
+func promptAttachService(reader *bufio.Reader, out io.Writer, services []string) (string, error) {
+	sorted := append([]string(nil), services...)
+	sort.Strings(sorted)
+	if len(sorted) == 1 {
+		return sorted[0], nil
+	}
+
+	for {
+		_, _ = fmt.Fprintln(out, "Attach to which service once the workspace is up?")
+		for i, name := range sorted {
+			_, _ = fmt.Fprintf(out, "  %d) %s\n", i+1, name)
+		}
+		_, _ = fmt.Fprintf(out, "Service [1-%d]: ", len(sorted))
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read service choice: %w", err)
+		}
+
+		answer := strings.TrimSpace(line)
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(sorted) {
+			return sorted[n-1], nil
+		}
+		for _, name := range sorted {
+			if name == answer {
+				return name, nil
+			}
+		}
+		_, _ = fmt.Fprintf(out, "Please answer with a number from 1 to %d or a service name.\n", len(sorted))
+	}
+}
+
+// findWorkspaceServiceContainer returns the running container of a workspace service
+func findWorkspaceServiceContainer(ctx context.Context, dockerService *docker.Service, workspaceHash, serviceName string) (docker.ContainerInfo, error) {
+	// Find container using workspace labels instead of reconstructing name
+	containers, err := dockerService.ListContainersByLabels(ctx, map[string]string{
+		"com.reactor.workspace.instance": workspaceHash,
+		"com.reactor.workspace.service":  serviceName,
+	})
+	if err != nil {
+		return docker.ContainerInfo{}, err
+	}
+
+	if len(containers) == 0 {
+		return docker.ContainerInfo{}, fmt.Errorf("container for service '%s' not found - start it first with 'reactor workspace up %s'", serviceName, serviceName)
+	}
+
+	if len(containers) > 1 {
+		return docker.ContainerInfo{}, fmt.Errorf("multiple containers found for service '%s' - this shouldn't happen", serviceName)
+	}
+
+	container := containers[0]
+	if container.State != "running" {
+		return docker.ContainerInfo{}, fmt.Errorf("container for service '%s' is not running (status: %s) - start it first with 'reactor workspace up %s'", serviceName, container.State, serviceName)
+	}
+	return container, nil
+}
+
+// attachWorkspaceService attaches an interactive session to a started workspace service
+func attachWorkspaceService(endpoints *workspaceEndpoints, workspaceHash, serviceName string) error {
+	ctx := context.Background()
+	dockerService, err := endpoints.forService(serviceName)
+	if err != nil {
+		return err
+	}
+
+	container, err := findWorkspaceServiceContainer(ctx, dockerService, workspaceHash, serviceName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nAttaching to service '%s'...\n", serviceName)
+	exitErr, err := sessionExit(dockerService.AttachInteractiveSession(ctx, container.ID), false)
+	if err != nil {
+		return fmt.Errorf("failed to attach to service '%s': %w", serviceName, err)
+	}
+
+	// Save credentials the agent changed now, in case the container is stopped without 'workspace down'
+	if info, err := dockerService.ContainerExists(ctx, container.Name); err == nil {
+		if err := orchestrator.SaveCredentials(ctx, dockerService, info); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save encrypted credentials: %v\n", err)
+		}
+	}
+
+	fmt.Printf("\nSession ended. The workspace is still running.\n")
+	fmt.Printf("Use 'reactor workspace down' to stop it.\n")
+	if exitErr != nil {
+		return exitErr
+	}
+	return nil
+}
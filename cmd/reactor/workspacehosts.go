@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/workspace"
+)
+
+// workspaceEndpoints keeps one Docker service per daemon a workspace's services run on.
+// Services without docker_host or context use the default daemon, the empty host.
+type workspaceEndpoints struct {
+	hosts map[string]string // service name to Docker host
+
+	mu       sync.Mutex
+	services map[string]*docker.Service // by Docker host, created on first use
+}
+
+// newWorkspaceEndpoints resolves the Docker host of every service in the workspace
+func newWorkspaceEndpoints(ws *workspace.Workspace) (*workspaceEndpoints, error) {
+	endpoints := &workspaceEndpoints{
+		hosts:    make(map[string]string, len(ws.Services)),
+		services: map[string]*docker.Service{},
+	}
+	for name, service := range ws.Services {
+		host, err := serviceDockerHost(service)
+		if err != nil {
+			return nil, fmt.Errorf("service '%s': %w", name, err)
+		}
+		endpoints.hosts[name] = host
+	}
+	return endpoints, nil
+}
+
+// serviceDockerHost returns the daemon a workspace service runs on, empty for the default
+func serviceDockerHost(service workspace.Service) (string, error) {
+	if service.Context != "" {
+		return docker.ContextHost(service.Context)
+	}
+	return service.DockerHost, nil
+}
+
+// host returns the Docker host of a service
+func (e *workspaceEndpoints) host(serviceName string) string {
+	return e.hosts[serviceName]
+}
+
+// distinctHosts returns the Docker hosts the given services run on, each once, sorted
+func (e *workspaceEndpoints) distinctHosts(serviceNames []string) []string {
+	seen := map[string]bool{}
+	var hosts []string
+	for _, name := range serviceNames {
+		host := e.hosts[name]
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// allHosts returns every Docker host the workspace uses
+func (e *workspaceEndpoints) allHosts() []string {
+	names := make([]string, 0, len(e.hosts))
+	for name := range e.hosts {
+		names = append(names, name)
+	}
+	return e.distinctHosts(names)
+}
+
+// multiHost reports whether any service runs somewhere other than the default daemon
+func (e *workspaceEndpoints) multiHost() bool {
+	for _, host := range e.hosts {
+		if host != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// forHost returns the Docker service of a host, connecting on first use
+func (e *workspaceEndpoints) forHost(host string) (*docker.Service, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if dockerService, ok := e.services[host]; ok {
+		return dockerService, nil
+	}
+	dockerService, err := docker.NewServiceForHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Docker service%s: %w", describeDockerHostSuffix(host), err)
+	}
+	e.services[host] = dockerService
+	return dockerService, nil
+}
+
+// forService returns the Docker service of the daemon a workspace service runs on
+func (e *workspaceEndpoints) forService(serviceName string) (*docker.Service, error) {
+	return e.forHost(e.hosts[serviceName])
+}
+
+// checkHealth pings the given daemons, returning the error of each one that cannot be reached
+func (e *workspaceEndpoints) checkHealth(ctx context.Context, hosts []string) map[string]error {
+	unreachable := map[string]error{}
+	for _, host := range hosts {
+		dockerService, err := e.forHost(host)
+		if err == nil {
+			err = dockerService.CheckHealth(ctx)
+		}
+		if err != nil {
+			unreachable[host] = err
+		}
+	}
+	return unreachable
+}
+
+// Close closes the connection to every daemon used
+func (e *workspaceEndpoints) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for host, dockerService := range e.services {
+		closeDockerService(dockerService)
+		delete(e.services, host)
+	}
+}
+
+// describeDockerHost names a Docker host for display; DOCKER_HOST's daemon is "default"
+func describeDockerHost(host string) string {
+	if host == "" {
+		return "default"
+	}
+	return host
+}
+
+// describeDockerHostSuffix is " for <host>" for a non-default host, for error messages
+func describeDockerHostSuffix(host string) string {
+	if host == "" {
+		return ""
+	}
+	return " for " + host
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceEndpoints(t *testing.T) {
+	ws := &workspace.Workspace{Services: map[string]workspace.Service{
+		"api":    {Path: "./api"},
+		"web":    {Path: "./web"},
+		"ml":     {Path: "./ml", DockerHost: "tcp://gpu-box:2376"},
+		"search": {Path: "./search", DockerHost: "tcp://gpu-box:2376"},
+	}}
+
+	endpoints, err := newWorkspaceEndpoints(ws)
+	require.NoError(t, err)
+	defer endpoints.Close()
+
+	assert.Equal(t, "", endpoints.host("api"))
+	assert.Equal(t, "tcp://gpu-box:2376", endpoints.host("ml"))
+	assert.Equal(t, []string{"", "tcp://gpu-box:2376"}, endpoints.allHosts())
+	assert.Equal(t, []string{"tcp://gpu-box:2376"}, endpoints.distinctHosts([]string{"ml", "search"}))
+	assert.True(t, endpoints.multiHost())
+
+	// Services on the same daemon share one connection
+	ml, err := endpoints.forService("ml")
+	require.NoError(t, err)
+	search, err := endpoints.forService("search")
+	require.NoError(t, err)
+	assert.Same(t, ml, search)
+
+	local, err := newWorkspaceEndpoints(&workspace.Workspace{Services: map[string]workspace.Service{"api": {Path: "./api"}}})
+	require.NoError(t, err)
+	assert.False(t, local.multiHost())
+}
+
+func TestWorkspaceEndpoints_UnknownContext(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	ws := &workspace.Workspace{Services: map[string]workspace.Service{
+		"ml": {Path: "./ml", Context: "gpu-box"},
+	}}
+
+	_, err := newWorkspaceEndpoints(ws)
+	assert.ErrorContains(t, err, "service 'ml': docker context 'gpu-box' not found")
+}
+
+func TestDescribeDockerHost(t *testing.T) {
+	assert.Equal(t, "default", describeDockerHost(""))
+	assert.Equal(t, "ssh://dev@gpu-box", describeDockerHost("ssh://dev@gpu-box"))
+}
@@ -0,0 +1,704 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultWorkspaceFile is the workspace file 'workspace init' writes without -f
+const defaultWorkspaceFile = "reactor-workspace.yml"
+
+func newWorkspaceInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init --from-compose <docker-compose.yml>",
+		Short: "Create a workspace from a docker-compose file",
+		Long: `Create a reactor-workspace.yml and a stub devcontainer.json for each service
+of a docker-compose file, to ease moving a compose setup to reactor.
+
+Each service gets a directory: its build context when that is a subdirectory
+of the workspace, otherwise one named after the service. Its devcontainer.json
+takes the service's image or build, ports and environment. The workspace file
+takes env_file, working_dir, command, platform and named volumes. Anything else,
+such as bind mounts or depends_on, is listed so it can be moved by hand.
+
+Variables such as ${TAG} are substituted from the environment and the .env file
+next to the compose file, as compose does. Those in a service's environment
+become ${localEnv:...} instead, so their values are read from the host when the
+container is created rather than written into devcontainer.json.
+
+Existing files are never overwritten.
+
+Examples:
+  reactor workspace init --from-compose docker-compose.yml
+  reactor workspace init --from-compose compose.yaml -f reactor-workspace.yml`,
+		Args: cobra.NoArgs,
+		RunE: workspaceInitHandler,
+	}
+
+	cmd.Flags().String("from-compose", "", "docker-compose file to convert")
+	_ = cmd.MarkFlagRequired("from-compose")
+
+	return cmd
+}
+
+func workspaceInitHandler(cmd *cobra.Command, args []string) error {
+	composePath, _ := cmd.Flags().GetString("from-compose")
+	workspacePath, _ := cmd.Flags().GetString("file")
+	if workspacePath == "" {
+		workspacePath = defaultWorkspaceFile
+	}
+	if workspace.IsStreamSource(workspacePath) {
+		return fmt.Errorf("workspace init writes a file; -f cannot be stdin or a URL")
+	}
+
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return fmt.Errorf("failed to read compose file: %w", err)
+	}
+	composeDir, err := filepath.Abs(filepath.Dir(composePath))
+	if err != nil {
+		return fmt.Errorf("failed to resolve compose file directory: %w", err)
+	}
+	workspaceDir, err := filepath.Abs(filepath.Dir(workspacePath))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace directory: %w", err)
+	}
+
+	conversion, err := convertCompose(data, composeDir, workspaceDir)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", composePath, err)
+	}
+	written, err := writeComposeConversion(conversion, workspacePath, filepath.Base(composePath))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range written {
+		fmt.Printf("Created %s\n", file)
+	}
+	if len(conversion.Warnings) > 0 {
+		fmt.Println()
+		fmt.Println("Not converted, move these by hand:")
+		for _, warning := range conversion.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+	fmt.Println()
+	fmt.Println("Review the generated files, then run 'reactor workspace validate'.")
+	return nil
+}
+
+// devContainerStub is the devcontainer.json written for a compose service
+type devContainerStub struct {
+	Name         string             `json:"name"`
+	Image        string             `json:"image,omitempty"`
+	Build        *devContainerBuild `json:"build,omitempty"`
+	ForwardPorts []interface{}      `json:"forwardPorts,omitempty"`
+	ContainerEnv map[string]string  `json:"containerEnv,omitempty"`
+}
+
+// devContainerBuild is the build section of a devContainerStub. As in compose, the
+// Dockerfile is relative to the context.
+type devContainerBuild struct {
+	Dockerfile string `json:"dockerfile,omitempty"`
+	Context    string `json:"context"`
+}
+
+// composeConversion is a compose file converted to a workspace
+type composeConversion struct {
+	Workspace workspace.Workspace
+	// DevContainers holds a stub for each service, keyed by its service path
+	DevContainers map[string]devContainerStub
+	// Warnings lists the compose settings that were not converted
+	Warnings []string
+}
+
+// convertedComposeKeys are the compose service keys convertCompose understands
+var convertedComposeKeys = map[string]bool{
+	"image": true, "build": true, "ports": true, "environment": true, "env_file": true,
+	"command": true, "working_dir": true, "platform": true, "volumes": true,
+}
+
+// convertCompose converts the services of a compose file in composeDir into a
+// workspace whose file lives in workspaceDir
+func convertCompose(data []byte, composeDir, workspaceDir string) (*composeConversion, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid compose YAML: %w", err)
+	}
+	unset, err := interpolateCompose(&root, composeDir)
+	if err != nil {
+		return nil, err
+	}
+	var compose struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+		Volumes  map[string]interface{}            `yaml:"volumes"`
+	}
+	if err := root.Decode(&compose); err != nil {
+		return nil, fmt.Errorf("invalid compose YAML: %w", err)
+	}
+	if len(compose.Services) == 0 {
+		return nil, fmt.Errorf("no services defined")
+	}
+
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		// The name becomes a directory of the workspace
+		if !composeServiceNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("service name '%s' must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c := &composeConversion{
+		Workspace:     workspace.Workspace{Version: "1", Services: make(map[string]workspace.Service)},
+		DevContainers: make(map[string]devContainerStub),
+	}
+	for _, name := range unset {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("variable '%s' is not set in the environment or .env, so it was converted to an empty string", name))
+	}
+	usedVolumes := make(map[string]bool)
+	for _, name := range names {
+		raw := compose.Services[name]
+		warn := func(format string, args ...interface{}) {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("service '%s': ", name)+fmt.Sprintf(format, args...))
+		}
+
+		image, _ := raw["image"].(string)
+		buildContext, dockerfile, err := composeBuild(raw["build"], warn)
+		if err != nil {
+			return nil, fmt.Errorf("service '%s' %w", name, err)
+		}
+		if image == "" && buildContext == "" {
+			return nil, fmt.Errorf("service '%s' has neither an image nor a build", name)
+		}
+
+		var contextDir string
+		if buildContext != "" {
+			contextDir = filepath.Join(composeDir, buildContext)
+		}
+		servicePath, err := composeServicePath(name, contextDir, workspaceDir, c.DevContainers)
+		if err != nil {
+			return nil, err
+		}
+
+		stub := devContainerStub{Name: name}
+		if contextDir != "" {
+			configDir := filepath.Join(workspaceDir, servicePath, ".devcontainer")
+			rel, err := filepath.Rel(configDir, contextDir)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s' build context: %w", name, err)
+			}
+			stub.Build = &devContainerBuild{Dockerfile: dockerfile, Context: filepath.ToSlash(rel)}
+		} else {
+			stub.Image = image
+		}
+		if stub.ForwardPorts, err = composePorts(raw["ports"], warn); err != nil {
+			return nil, fmt.Errorf("service '%s' %w", name, err)
+		}
+		if stub.ContainerEnv, err = composeEnvironment(raw["environment"]); err != nil {
+			return nil, fmt.Errorf("service '%s' %w", name, err)
+		}
+		c.DevContainers[servicePath] = stub
+
+		service := workspace.Service{Path: servicePath}
+		service.Platform, _ = raw["platform"].(string)
+		service.WorkDir, _ = raw["working_dir"].(string)
+		if service.Command, err = composeCommand(raw["command"]); err != nil {
+			return nil, fmt.Errorf("service '%s' %w", name, err)
+		}
+		if envFile := composeEnvFile(raw["env_file"], warn); envFile != "" {
+			rel, err := filepath.Rel(workspaceDir, filepath.Join(composeDir, envFile))
+			if err != nil {
+				return nil, fmt.Errorf("service '%s' env_file: %w", name, err)
+			}
+			service.EnvFile = filepath.ToSlash(rel)
+		}
+		for _, m := range composeVolumes(raw["volumes"], compose.Volumes, warn) {
+			usedVolumes[strings.SplitN(m, ":", 2)[0]] = true
+			service.Volumes = append(service.Volumes, m)
+		}
+		c.Workspace.Services[name] = service
+
+		var ignored []string
+		for key := range raw {
+			if !convertedComposeKeys[key] {
+				ignored = append(ignored, key)
+			}
+		}
+		sort.Strings(ignored)
+		for _, key := range ignored {
+			warn("'%s' is not converted", key)
+		}
+	}
+
+	for volume := range usedVolumes {
+		c.Workspace.Volumes = append(c.Workspace.Volumes, volume)
+	}
+	sort.Strings(c.Workspace.Volumes)
+	return c, nil
+}
+
+// composeServiceNamePattern matches the service names compose accepts
+var composeServiceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// composeVariablePattern matches what compose interpolates: $$, $VAR, ${VAR} and
+// ${VAR} with a default (:- or -) or an error for when it is missing (:? or ?). A
+// '${' that starts none of these is invalid.
+var composeVariablePattern = regexp.MustCompile(`\$\$|\$([A-Za-z_][A-Za-z0-9_]*)|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?])([^}]*))?\}|\$\{`)
+
+// interpolateCompose substitutes variables in the values of a compose file from the
+// environment and the .env file next to it, as compose does, and returns the names
+// of the variables that were not set. Service environment values instead take their
+// variables from the host when the container is created, as ${localEnv:VAR}, so
+// secrets are not written into devcontainer.json.
+func interpolateCompose(root *yaml.Node, composeDir string) ([]string, error) {
+	dotenv, err := config.LoadEnvFile(filepath.Join(composeDir, ".env"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+	lookup := func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := dotenv[name]
+		return value, ok
+	}
+
+	unset := make(map[string]bool)
+	interpolate := func(name, op, arg string) (string, error) {
+		value, ok := lookup(name)
+		switch {
+		case (op == ":-" && (!ok || value == "")) || (op == "-" && !ok):
+			return arg, nil
+		case (op == ":?" && (!ok || value == "")) || (op == "?" && !ok):
+			return "", fmt.Errorf("variable '%s' is required: %s", name, arg)
+		case !ok:
+			unset[name] = true
+		}
+		return value, nil
+	}
+	fromHost := func(name, op, arg string) (string, error) {
+		if op == ":-" || op == "-" {
+			return "${localEnv:" + name + ":" + arg + "}", nil
+		}
+		return "${localEnv:" + name + "}", nil
+	}
+
+	// Environment values are mapping values or list entries of services.<name>.environment
+	environments := make(map[*yaml.Node]bool)
+	if services := composeMappingValue(composeMappingValue(root, "services")); services != nil {
+		for i := 1; i < len(services.Content); i += 2 {
+			if environment := composeMappingValue(services.Content[i], "environment"); environment != nil {
+				environments[environment] = true
+				if err := substituteComposeNode(environment, fromHost, nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := substituteComposeNode(root, interpolate, environments); err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(unset)), nil
+}
+
+// composeMappingValue returns the value of key in a mapping node, or the mapping a
+// document node holds when no key is given; nil when there is none
+func composeMappingValue(node *yaml.Node, key ...string) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	if len(key) == 0 {
+		return node
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key[0] {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// substituteComposeNode replaces the variables in the scalar values under node, but
+// not in mapping keys or the nodes in skip
+func substituteComposeNode(node *yaml.Node, replace func(name, op, arg string) (string, error), skip map[*yaml.Node]bool) error {
+	if skip[node] {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		var out strings.Builder
+		last := 0
+		for _, m := range composeVariablePattern.FindAllStringSubmatchIndex(node.Value, -1) {
+			out.WriteString(node.Value[last:m[0]])
+			last = m[1]
+			group := func(i int) string {
+				if m[2*i] < 0 {
+					return ""
+				}
+				return node.Value[m[2*i]:m[2*i+1]]
+			}
+			switch match := node.Value[m[0]:m[1]]; {
+			case match == "$$":
+				out.WriteString("$")
+			case match == "${":
+				return fmt.Errorf("invalid interpolation format in '%s'", node.Value)
+			default:
+				name := group(1) + group(2)
+				value, err := replace(name, group(3), group(4))
+				if err != nil {
+					return err
+				}
+				out.WriteString(value)
+			}
+		}
+		out.WriteString(node.Value[last:])
+		node.Value = out.String()
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := substituteComposeNode(node.Content[i], replace, skip); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := substituteComposeNode(child, replace, skip); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// composeServicePath picks the workspace service path of a compose service: its
+// build context when that is a subdirectory of the workspace not taken by another
+// service, otherwise a directory named after the service
+func composeServicePath(name, contextDir, workspaceDir string, taken map[string]devContainerStub) (string, error) {
+	if contextDir != "" {
+		rel, err := filepath.Rel(workspaceDir, contextDir)
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			if _, ok := taken[filepath.ToSlash(rel)]; !ok {
+				return filepath.ToSlash(rel), nil
+			}
+		}
+	}
+	if _, ok := taken[name]; ok {
+		return "", fmt.Errorf("service '%s' needs the directory '%s', which another service builds from", name, name)
+	}
+	return name, nil
+}
+
+// composeBuild returns the context and Dockerfile of a compose build, written as a
+// context path or as a mapping
+func composeBuild(value interface{}, warn func(string, ...interface{})) (string, string, error) {
+	switch build := value.(type) {
+	case nil:
+		return "", "", nil
+	case string:
+		return build, "", nil
+	case map[string]interface{}:
+		buildContext, _ := build["context"].(string)
+		if buildContext == "" {
+			buildContext = "."
+		}
+		dockerfile, _ := build["dockerfile"].(string)
+		var ignored []string
+		for key := range build {
+			if key != "context" && key != "dockerfile" {
+				ignored = append(ignored, key)
+			}
+		}
+		sort.Strings(ignored)
+		for _, key := range ignored {
+			warn("build '%s' is not converted", key)
+		}
+		return buildContext, dockerfile, nil
+	default:
+		return "", "", fmt.Errorf("build must be a path or a mapping")
+	}
+}
+
+// composePorts converts compose ports to forwardPorts: a port the host publishes
+// under the same number becomes a number, any other a "host:container" string.
+// UDP ports, ranges and host IPs are not supported and are reported.
+func composePorts(value interface{}, warn func(string, ...interface{})) ([]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ports must be a list")
+	}
+	var ports []interface{}
+	for _, entry := range list {
+		var hostIP, host, target, protocol string
+		switch port := entry.(type) {
+		case int:
+			target = strconv.Itoa(port)
+		case string:
+			// [ip:][host:]container[/protocol]
+			port, protocol, _ = strings.Cut(port, "/")
+			rest, after, found := cutLast(port, ":")
+			if !found {
+				target = port
+			} else {
+				target = after
+				if hostIP, host, found = cutLast(rest, ":"); !found {
+					hostIP, host = "", rest
+				}
+			}
+		case map[string]interface{}:
+			target = fmt.Sprint(port["target"])
+			if published, ok := port["published"]; ok {
+				host = fmt.Sprint(published)
+			}
+			hostIP, _ = port["host_ip"].(string)
+			protocol, _ = port["protocol"].(string)
+		default:
+			return nil, fmt.Errorf("port %v must be a number, string or mapping", entry)
+		}
+
+		if protocol == "udp" {
+			warn("port '%v' is not converted: only TCP ports are forwarded", entry)
+			continue
+		}
+		if strings.Contains(target, "-") || strings.Contains(host, "-") {
+			warn("port '%v' is not converted: ranges are not supported", entry)
+			continue
+		}
+		targetPort, err := strconv.Atoi(target)
+		if err != nil {
+			return nil, fmt.Errorf("port %v has an invalid container port", entry)
+		}
+		if host != "" {
+			if _, err := strconv.Atoi(host); err != nil {
+				return nil, fmt.Errorf("port %v has an invalid host port", entry)
+			}
+		}
+		if hostIP != "" {
+			warn("port '%v' is published on all interfaces instead of %s", entry, hostIP)
+		}
+		if host == "" || host == target {
+			ports = append(ports, targetPort)
+			continue
+		}
+		ports = append(ports, host+":"+target)
+	}
+	return ports, nil
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// composeEnvironment converts compose environment, a mapping or a list of
+// KEY=value, to containerEnv. A variable without a value is taken from the host,
+// as compose does.
+func composeEnvironment(value interface{}) (map[string]string, error) {
+	env := make(map[string]string)
+	switch environment := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		for key, v := range environment {
+			if v == nil {
+				env[key] = "${localEnv:" + key + "}"
+			} else {
+				env[key] = fmt.Sprint(v)
+			}
+		}
+	case []interface{}:
+		for _, entry := range environment {
+			key, v, found := strings.Cut(fmt.Sprint(entry), "=")
+			if !found {
+				v = "${localEnv:" + key + "}"
+			}
+			env[key] = v
+		}
+	default:
+		return nil, fmt.Errorf("environment must be a mapping or a list")
+	}
+	return env, nil
+}
+
+// composeCommand converts a compose command to a workspace command, which is run
+// with /bin/sh -c; the list form is quoted for the shell
+func composeCommand(value interface{}) (string, error) {
+	switch command := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return command, nil
+	case []interface{}:
+		args := make([]string, len(command))
+		for i, arg := range command {
+			args[i] = shellQuote(fmt.Sprint(arg))
+		}
+		return strings.Join(args, " "), nil
+	default:
+		return "", fmt.Errorf("command must be a string or a list")
+	}
+}
+
+// shellSafePattern matches arguments that need no quoting for /bin/sh
+var shellSafePattern = regexp.MustCompile(`^[a-zA-Z0-9_./:=@%+,-]+$`)
+
+// shellQuote quotes an argument for /bin/sh unless it needs no quoting
+func shellQuote(arg string) string {
+	if shellSafePattern.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// composeEnvFile returns the first env_file of a compose service; a workspace
+// service takes one, so the others are reported
+func composeEnvFile(value interface{}, warn func(string, ...interface{})) string {
+	var files []string
+	switch envFile := value.(type) {
+	case string:
+		files = []string{envFile}
+	case []interface{}:
+		for _, entry := range envFile {
+			switch file := entry.(type) {
+			case string:
+				files = append(files, file)
+			case map[string]interface{}:
+				if path, ok := file["path"].(string); ok {
+					files = append(files, path)
+				}
+			}
+		}
+	}
+	if len(files) == 0 {
+		return ""
+	}
+	for _, file := range files[1:] {
+		warn("env_file '%s' is not converted: a workspace service takes one env_file", file)
+	}
+	return files[0]
+}
+
+// composeVolumes converts a compose service's named volumes to workspace volume
+// mounts. Bind mounts and anonymous volumes are reported.
+func composeVolumes(value interface{}, declared map[string]interface{}, warn func(string, ...interface{})) []string {
+	list, _ := value.([]interface{})
+	var mounts []string
+	for _, entry := range list {
+		var source, target string
+		named, readOnly := true, false
+		switch volume := entry.(type) {
+		case string:
+			parts := strings.Split(volume, ":")
+			if len(parts) < 2 {
+				warn("anonymous volume '%s' is not converted", volume)
+				continue
+			}
+			source, target = parts[0], parts[1]
+			readOnly = len(parts) > 2 && slices.Contains(strings.Split(parts[2], ","), "ro")
+		case map[string]interface{}:
+			source, _ = volume["source"].(string)
+			target, _ = volume["target"].(string)
+			readOnly, _ = volume["read_only"].(bool)
+			volumeType, _ := volume["type"].(string)
+			named = volumeType == "" || volumeType == "volume"
+		}
+		if _, declared := declared[source]; !declared || !named {
+			warn("volume '%v' is not converted: only named volumes are, use the service directory or a workspace volume instead", entry)
+			continue
+		}
+		mount := source + ":" + target
+		if readOnly {
+			mount += ":ro"
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+// writeComposeConversion creates the service directories, their devcontainer.json
+// stubs and the workspace file, which records composeName. Nothing is written when
+// any of the files already exists. It returns the files written.
+func writeComposeConversion(c *composeConversion, workspacePath, composeName string) ([]string, error) {
+	if _, err := os.Stat(workspacePath); err == nil {
+		return nil, fmt.Errorf("%s already exists", workspacePath)
+	}
+	workspaceDir := filepath.Dir(workspacePath)
+
+	paths := make([]string, 0, len(c.DevContainers))
+	for servicePath := range c.DevContainers {
+		paths = append(paths, servicePath)
+	}
+	sort.Strings(paths)
+	for _, servicePath := range paths {
+		existing, found, err := config.FindDevContainerFile(filepath.Join(workspaceDir, servicePath))
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return nil, fmt.Errorf("%s already exists; remove it or move the service to another directory", existing)
+		}
+	}
+
+	var written []string
+	for _, servicePath := range paths {
+		configDir := filepath.Join(workspaceDir, servicePath, ".devcontainer")
+		if err := os.MkdirAll(configDir, 0755); err != nil {
+			return written, fmt.Errorf("failed to create %s: %w", configDir, err)
+		}
+		data, err := json.MarshalIndent(c.DevContainers[servicePath], "", "  ")
+		if err != nil {
+			return written, err
+		}
+		file := filepath.Join(configDir, "devcontainer.json")
+		if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+
+	data, err := yaml.Marshal(&c.Workspace)
+	if err != nil {
+		return written, err
+	}
+	header := fmt.Sprintf("# Converted from %s by 'reactor workspace init --from-compose'\n", composeName)
+	if err := os.WriteFile(workspacePath, append([]byte(header), data...), 0644); err != nil {
+		return written, fmt.Errorf("failed to write %s: %w", workspacePath, err)
+	}
+	written = append(written, workspacePath)
+
+	// The workspace is checked as 'reactor workspace up' would, once its service
+	// directories exist; a conversion it would reject is not left behind
+	if _, err := workspace.ParseWorkspaceFile(workspacePath); err != nil {
+		for _, file := range written {
+			_ = os.Remove(file)
+		}
+		return nil, fmt.Errorf("the converted workspace is not valid, so it was not written: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCompose = `
+services:
+  api:
+    build:
+      context: ./api
+      dockerfile: Dockerfile.dev
+      args:
+        GO_VERSION: "1.22"
+    ports:
+      - "8080:80"
+      - "9090"
+      - "127.0.0.1:5000:5000"
+      - "5353:53/udp"
+      - "3000-3005:3000-3005"
+    environment:
+      DEBUG: "true"
+      PORT: 80
+      TOKEN:
+    env_file:
+      - .env
+      - .env.local
+    command: ["go", "run", "./cmd/api", "--name", "it's"]
+    working_dir: /workspace/cmd
+    volumes:
+      - artifacts:/artifacts
+      - ./src:/src
+      - cache:/cache:ro
+    depends_on: [db]
+  db:
+    image: postgres:16
+    platform: linux/amd64
+    ports:
+      - target: 5432
+        published: 15432
+    environment:
+      - POSTGRES_PASSWORD=secret
+      - PGUSER
+    volumes:
+      - type: volume
+        source: artifacts
+        target: /artifacts
+        read_only: true
+      - type: bind
+        source: ./init
+        target: /docker-entrypoint-initdb.d
+    restart: always
+  worker:
+    build: .
+    command: ./worker --queue jobs
+volumes:
+  artifacts:
+  cache:
+  unused:
+`
+
+func TestConvertCompose(t *testing.T) {
+	dir := t.TempDir()
+	c, err := convertCompose([]byte(testCompose), dir, dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", c.Workspace.Version)
+	assert.Equal(t, []string{"artifacts", "cache"}, c.Workspace.Volumes)
+	assert.Equal(t, workspace.Service{
+		Path:    "api",
+		EnvFile: ".env",
+		WorkDir: "/workspace/cmd",
+		Command: `go run ./cmd/api --name 'it'\''s'`,
+		Volumes: []string{"artifacts:/artifacts", "cache:/cache:ro"},
+	}, c.Workspace.Services["api"])
+	assert.Equal(t, workspace.Service{
+		Path:     "db",
+		Platform: "linux/amd64",
+		Volumes:  []string{"artifacts:/artifacts:ro"},
+	}, c.Workspace.Services["db"])
+	// A build from the workspace directory itself gets a directory of its own
+	assert.Equal(t, workspace.Service{Path: "worker", Command: "./worker --queue jobs"}, c.Workspace.Services["worker"])
+
+	assert.Equal(t, devContainerStub{
+		Name:         "api",
+		Build:        &devContainerBuild{Dockerfile: "Dockerfile.dev", Context: ".."},
+		ForwardPorts: []interface{}{"8080:80", 9090, 5000},
+		ContainerEnv: map[string]string{"DEBUG": "true", "PORT": "80", "TOKEN": "${localEnv:TOKEN}"},
+	}, c.DevContainers["api"])
+	assert.Equal(t, devContainerStub{
+		Name:         "db",
+		Image:        "postgres:16",
+		ForwardPorts: []interface{}{"15432:5432"},
+		ContainerEnv: map[string]string{"POSTGRES_PASSWORD": "secret", "PGUSER": "${localEnv:PGUSER}"},
+	}, c.DevContainers["db"])
+	assert.Equal(t, devContainerStub{
+		Name:  "worker",
+		Build: &devContainerBuild{Context: "../.."},
+	}, c.DevContainers["worker"])
+
+	assert.Equal(t, []string{
+		"service 'api': build 'args' is not converted",
+		"service 'api': port '127.0.0.1:5000:5000' is published on all interfaces instead of 127.0.0.1",
+		"service 'api': port '5353:53/udp' is not converted: only TCP ports are forwarded",
+		"service 'api': port '3000-3005:3000-3005' is not converted: ranges are not supported",
+		"service 'api': env_file '.env.local' is not converted: a workspace service takes one env_file",
+		"service 'api': volume './src:/src' is not converted: only named volumes are, use the service directory or a workspace volume instead",
+		"service 'api': 'depends_on' is not converted",
+		"service 'db': volume 'map[source:./init target:/docker-entrypoint-initdb.d type:bind]' is not converted: only named volumes are, use the service directory or a workspace volume instead",
+		"service 'db': 'restart' is not converted",
+	}, c.Warnings)
+}
+
+func TestConvertCompose_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := convertCompose([]byte("version: '3'\n"), dir, dir)
+	assert.EqualError(t, err, "no services defined")
+
+	_, err = convertCompose([]byte("services:\n  api:\n    ports: [\"80\"]\n"), dir, dir)
+	assert.EqualError(t, err, "service 'api' has neither an image nor a build")
+
+	_, err = convertCompose([]byte("services:\n  api:\n    build: ./web\n  web:\n    image: nginx\n"), dir, dir)
+	assert.EqualError(t, err, "service 'web' needs the directory 'web', which another service builds from")
+
+	// Service names become directories, so they cannot lead out of the workspace
+	_, err = convertCompose([]byte("services:\n  ../x:\n    image: nginx\n"), dir, dir)
+	assert.EqualError(t, err, "service name '../x' must start with a letter or digit and contain only letters, digits, '_', '.' and '-'")
+
+	_, err = convertCompose([]byte("services:\n  api:\n    image: ${REGISTRY:?set it}/api\n"), dir, dir)
+	assert.EqualError(t, err, "variable 'REGISTRY' is required: set it")
+	_, err = convertCompose([]byte("services:\n  api:\n    image: ${REGISTRY/api\n"), dir, dir)
+	assert.EqualError(t, err, "invalid interpolation format in '${REGISTRY/api'")
+}
+
+func TestConvertCompose_Interpolation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("TAG=1.2\nREGISTRY=ghcr.io/dotenv\n"), 0644))
+	t.Setenv("REGISTRY", "ghcr.io/acme")
+	t.Setenv("API_PORT", "")
+	compose := `services:
+  api:
+    image: ${REGISTRY}/api:$TAG
+    ports: ["${API_PORT:-8080}:80"]
+    command: echo $$HOME ${MISSING}
+    environment:
+      DB_PASSWORD: ${DB_PASSWORD}
+      LEVEL: ${LEVEL:-info}
+`
+	c, err := convertCompose([]byte(compose), dir, dir)
+	require.NoError(t, err)
+
+	// The environment wins over .env, and defaults apply to empty variables with :-
+	assert.Equal(t, "ghcr.io/acme/api:1.2", c.DevContainers["api"].Image)
+	assert.Equal(t, []interface{}{"8080:80"}, c.DevContainers["api"].ForwardPorts)
+	assert.Equal(t, "echo $HOME ", c.Workspace.Services["api"].Command)
+	// Environment values are taken from the host when the container is created
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "${localEnv:DB_PASSWORD}", "LEVEL": "${localEnv:LEVEL:info}"}, c.DevContainers["api"].ContainerEnv)
+	assert.Equal(t, []string{"variable 'MISSING' is not set in the environment or .env, so it was converted to an empty string"}, c.Warnings)
+}
+
+func TestWriteComposeConversion(t *testing.T) {
+	dir := t.TempDir()
+	c, err := convertCompose([]byte(testCompose), dir, dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), nil, 0644))
+	workspacePath := filepath.Join(dir, "reactor-workspace.yml")
+
+	written, err := writeComposeConversion(c, workspacePath, "docker-compose.yml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "api", ".devcontainer", "devcontainer.json"),
+		filepath.Join(dir, "db", ".devcontainer", "devcontainer.json"),
+		filepath.Join(dir, "worker", ".devcontainer", "devcontainer.json"),
+		workspacePath,
+	}, written)
+
+	data, err := os.ReadFile(filepath.Join(dir, "db", ".devcontainer", "devcontainer.json"))
+	require.NoError(t, err)
+	var stub map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &stub))
+	assert.Equal(t, "postgres:16", stub["image"])
+	assert.NotContains(t, stub, "build")
+
+	ws, err := workspace.ParseWorkspaceFile(workspacePath)
+	require.NoError(t, err)
+	assert.Len(t, ws.Services, 3)
+
+	// Nothing is overwritten
+	_, err = writeComposeConversion(c, workspacePath, "docker-compose.yml")
+	assert.EqualError(t, err, workspacePath+" already exists")
+	require.NoError(t, os.Remove(workspacePath))
+	_, err = writeComposeConversion(c, workspacePath, "docker-compose.yml")
+	assert.ErrorContains(t, err, filepath.Join(dir, "api", ".devcontainer", "devcontainer.json")+" already exists")
+	assert.NoFileExists(t, workspacePath)
+}
+
+func TestWriteComposeConversion_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	c, err := convertCompose([]byte("services:\n  api:\n    image: nginx\n    env_file: missing.env\n"), dir, dir)
+	require.NoError(t, err)
+	workspacePath := filepath.Join(dir, "reactor-workspace.yml")
+
+	_, err = writeComposeConversion(c, workspacePath, "docker-compose.yml")
+	assert.ErrorContains(t, err, "the converted workspace is not valid, so it was not written: service 'api' env_file 'missing.env' does not exist")
+	assert.NoFileExists(t, workspacePath)
+	assert.NoFileExists(t, filepath.Join(dir, "api", ".devcontainer", "devcontainer.json"))
+}
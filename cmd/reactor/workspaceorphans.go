@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/portforward"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/tunnel"
+	"github.com/dyluth/reactor/pkg/workspace"
+)
+
+// workspaceDownOrphans removes the containers of a workspace instance whose service is
+// no longer in the workspace file, e.g. after a service was renamed or deleted
+func workspaceDownOrphans(ws *workspace.Workspace, endpoints *workspaceEndpoints, workspacePath, workspaceHash string) error {
+	ctx := context.Background()
+	var recorded *state.Workspace
+	if workspaces, err := state.Workspaces(); err == nil {
+		if w, ok := workspaces[workspaceHash]; ok {
+			recorded = &w
+		}
+	}
+
+	orphans := map[string]string{} // service name to the Docker host its container is on
+	for _, host := range orphanSearchHosts(endpoints, recorded) {
+		dockerService, err := endpoints.forHost(host)
+		if err != nil {
+			return err
+		}
+		containers, err := dockerService.ListContainersByLabels(ctx, map[string]string{"com.reactor.workspace.instance": workspaceHash})
+		if err != nil {
+			return fmt.Errorf("failed to list containers%s: %w", describeDockerHostSuffix(host), err)
+		}
+		for _, name := range orphanServices(containers, ws) {
+			orphans[name] = host
+		}
+	}
+
+	fmt.Printf("Workspace: %s\n", workspacePath)
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned containers: every container of the workspace belongs to a service in the file.")
+		return nil
+	}
+
+	names := make([]string, 0, len(orphans))
+	for name, host := range orphans {
+		names = append(names, name)
+		// The services are gone from the file, so stop them where their containers are
+		endpoints.hosts[name] = host
+		if recorded != nil {
+			if configDir := recorded.Services[name].ConfigDir; configDir != "" {
+				if err := tunnel.Stop(configDir); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+				if err := portforward.StopAll(configDir); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	fmt.Printf("Removing containers of services no longer in the workspace: %v\n", names)
+
+	if err := stopServicesInParallel(endpoints, names, workspaceHash); err != nil {
+		return err
+	}
+	forgetStoppedWorkspaceServices(workspaceHash, names)
+	return nil
+}
+
+// orphanSearchHosts returns the Docker hosts that may hold orphaned containers: the
+// default daemon, those of the workspace's services and those recorded when its
+// services were started
+func orphanSearchHosts(endpoints *workspaceEndpoints, recorded *state.Workspace) []string {
+	hosts := append([]string{""}, endpoints.allHosts()...)
+	if recorded != nil {
+		for _, service := range recorded.Services {
+			hosts = append(hosts, service.DockerHost)
+		}
+	}
+	sort.Strings(hosts)
+	return slices.Compact(hosts)
+}
+
+// orphanServices returns the services of containers that are not in the workspace, sorted
+func orphanServices(containers []docker.ContainerInfo, ws *workspace.Workspace) []string {
+	var names []string
+	for _, c := range containers {
+		name := c.Labels["com.reactor.workspace.service"]
+		if _, ok := ws.Services[name]; !ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
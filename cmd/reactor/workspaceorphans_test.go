@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanServices(t *testing.T) {
+	ws := &workspace.Workspace{Services: map[string]workspace.Service{"api": {Path: "./api"}, "web": {Path: "./web"}}}
+	service := func(name string) docker.ContainerInfo {
+		return docker.ContainerInfo{Labels: map[string]string{"com.reactor.workspace.instance": "abc", "com.reactor.workspace.service": name}}
+	}
+
+	containers := []docker.ContainerInfo{service("api"), service("frontend"), service("worker"), service("frontend"), service("web"), {Labels: map[string]string{"com.reactor.workspace.instance": "abc"}}}
+	assert.Equal(t, []string{"frontend", "worker"}, orphanServices(containers, ws))
+	assert.Empty(t, orphanServices([]docker.ContainerInfo{service("api")}, ws))
+}
+
+func TestOrphanSearchHosts(t *testing.T) {
+	endpoints, err := newWorkspaceEndpoints(&workspace.Workspace{Services: map[string]workspace.Service{
+		"ml": {Path: "./ml", DockerHost: "tcp://gpu-box:2376"},
+	}})
+	require.NoError(t, err)
+	defer endpoints.Close()
+
+	// The default daemon is always searched
+	assert.Equal(t, []string{"", "tcp://gpu-box:2376"}, orphanSearchHosts(endpoints, nil))
+
+	// A removed service may have run on a daemon no current service uses
+	recorded := &state.Workspace{Services: map[string]state.WorkspaceService{
+		"ml":     {DockerHost: "tcp://gpu-box:2376"},
+		"legacy": {DockerHost: "tcp://old-box:2376"},
+	}}
+	assert.Equal(t, []string{"", "tcp://gpu-box:2376", "tcp://old-box:2376"}, orphanSearchHosts(endpoints, recorded))
+}
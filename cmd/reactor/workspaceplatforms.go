@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/workspace"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// checkWorkspacePlatforms verifies, before anything is pulled or started, that the image
+// of each service is published for the platform it will run as: its platform setting,
+// or else the platform of its Docker daemon. serviceImages holds the services that use
+// an image rather than a build. Images whose platforms cannot be looked up are skipped
+// with a warning.
+func checkWorkspacePlatforms(ctx context.Context, ws *workspace.Workspace, endpoints *workspaceEndpoints, serviceImages map[string]string) error {
+	names := make([]string, 0, len(serviceImages))
+	for name := range serviceImages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	daemons := map[string]ocispec.Platform{} // by Docker host
+	var problems []string
+	for _, name := range names {
+		imageName := serviceImages[name]
+		dockerService, err := endpoints.forService(name)
+		if err != nil {
+			return err
+		}
+		host := endpoints.host(name)
+		daemon, ok := daemons[host]
+		if !ok {
+			if daemon, err = dockerService.DaemonPlatform(ctx); err != nil {
+				return err
+			}
+			daemons[host] = daemon
+		}
+		platforms, err := dockerService.ImagePlatforms(ctx, imageName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: service '%s': could not verify the platforms of %s: %v\n", name, imageName, err)
+			continue
+		}
+
+		problem, emulated := servicePlatformProblem(name, imageName, ws.Services[name].Platform, daemon, platforms)
+		if problem != "" {
+			problems = append(problems, problem)
+		} else if emulated {
+			fmt.Printf("Service '%s' runs %s as %s under emulation on a %s daemon\n", name, imageName, ws.Services[name].Platform, docker.FormatPlatform(daemon))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("service images do not match the platform they would run as:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// servicePlatformProblem describes why a service's image cannot run as its requested
+// platform, or without one as the daemon's, and reports whether it runs under emulation
+func servicePlatformProblem(name, imageName, requested string, daemon ocispec.Platform, platforms []ocispec.Platform) (string, bool) {
+	if len(platforms) == 0 {
+		return "", false
+	}
+	available := make([]string, len(platforms))
+	for i, platform := range platforms {
+		available[i] = docker.FormatPlatform(platform)
+	}
+
+	if requested == "" {
+		if docker.PlatformSupported(platforms, daemon) {
+			return "", false
+		}
+		return fmt.Sprintf("service '%s': %s is published for %s, not the Docker daemon's %s; set 'platform: %s' on the service to run it under emulation",
+			name, imageName, strings.Join(available, ", "), docker.FormatPlatform(daemon), available[0]), false
+	}
+
+	want, err := docker.ParsePlatform(requested)
+	if err != nil {
+		return fmt.Sprintf("service '%s': %v", name, err), false
+	}
+	if !docker.PlatformSupported(platforms, want) {
+		return fmt.Sprintf("service '%s': %s is not published for platform %s, only for %s", name, imageName, requested, strings.Join(available, ", ")), false
+	}
+	return "", !docker.PlatformSupported([]ocispec.Platform{daemon}, want)
+}
+
+// workspaceImagePlatforms returns the platforms to pull images for, by Docker host and
+// normalized image name. A daemon keeps one platform of an image, so services on the
+// same host must not ask for an image in different platforms.
+func workspaceImagePlatforms(ws *workspace.Workspace, endpoints *workspaceEndpoints, serviceImages map[string]string) (map[string]map[string]string, error) {
+	names := make([]string, 0, len(serviceImages))
+	for name := range serviceImages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	platforms := map[string]map[string]string{}
+	owners := map[string]string{} // the service that set each host's image platform
+	for _, name := range names {
+		platform := ws.Services[name].Platform
+		host := endpoints.host(name)
+		imageName := docker.NormalizeImageName(serviceImages[name])
+		key := host + "\x00" + imageName
+		if owner, ok := owners[key]; ok {
+			if platforms[host][imageName] != platform {
+				return nil, fmt.Errorf("services '%s' and '%s' run %s on the same Docker daemon with different platforms; give them the same platform", owner, name, imageName)
+			}
+			continue
+		}
+		owners[key] = name
+		if platforms[host] == nil {
+			platforms[host] = map[string]string{}
+		}
+		platforms[host][imageName] = platform
+	}
+
+	// Only images with a platform are pulled for one
+	for host, images := range platforms {
+		for imageName, platform := range images {
+			if platform == "" {
+				delete(images, imageName)
+			}
+		}
+		if len(images) == 0 {
+			delete(platforms, host)
+		}
+	}
+	return platforms, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/workspace"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicePlatformProblem(t *testing.T) {
+	amd64 := ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := ocispec.Platform{OS: "linux", Architecture: "arm64"}
+
+	problem, emulated := servicePlatformProblem("api", "node:20", "", arm64, []ocispec.Platform{amd64, arm64})
+	assert.Empty(t, problem)
+	assert.False(t, emulated)
+
+	problem, _ = servicePlatformProblem("db", "legacy/db:1", "", arm64, []ocispec.Platform{amd64})
+	assert.Equal(t, "service 'db': legacy/db:1 is published for linux/amd64, not the Docker daemon's linux/arm64; set 'platform: linux/amd64' on the service to run it under emulation", problem)
+
+	problem, emulated = servicePlatformProblem("db", "legacy/db:1", "linux/amd64", arm64, []ocispec.Platform{amd64})
+	assert.Empty(t, problem)
+	assert.True(t, emulated)
+
+	problem, _ = servicePlatformProblem("db", "legacy/db:1", "linux/s390x", arm64, []ocispec.Platform{amd64})
+	assert.Equal(t, "service 'db': legacy/db:1 is not published for platform linux/s390x, only for linux/amd64", problem)
+
+	// Nothing is known about images without platforms
+	problem, emulated = servicePlatformProblem("db", "legacy/db:1", "", arm64, nil)
+	assert.Empty(t, problem)
+	assert.False(t, emulated)
+}
+
+func TestWorkspaceImagePlatforms(t *testing.T) {
+	ws := &workspace.Workspace{
+		Services: map[string]workspace.Service{
+			"api":    {Path: "./api", Platform: "linux/amd64"},
+			"worker": {Path: "./worker", Platform: "linux/amd64"},
+			"web":    {Path: "./web"},
+		},
+	}
+	endpoints, err := newWorkspaceEndpoints(ws)
+	require.NoError(t, err)
+
+	platforms, err := workspaceImagePlatforms(ws, endpoints, map[string]string{"api": "legacy/api", "worker": "legacy/api:latest", "web": "node:20"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"": {"legacy/api:latest": "linux/amd64"}}, platforms)
+
+	_, err = workspaceImagePlatforms(ws, endpoints, map[string]string{"api": "node:20", "web": "node:20"})
+	assert.EqualError(t, err, "services 'api' and 'web' run node:20 on the same Docker daemon with different platforms; give them the same platform")
+}
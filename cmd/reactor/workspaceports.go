@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dyluth/reactor/pkg/workspace"
+)
+
+// portOffsetEnv sets the port offset of 'reactor workspace up' for one developer, e.g.
+// in their shell profile on a shared dev box
+const portOffsetEnv = "REACTOR_PORT_OFFSET"
+
+// workspacePortOffset returns how far to shift the host ports of a workspace's services:
+// flagValue when --port-offset was given, else REACTOR_PORT_OFFSET, else the workspace
+// file's port_offset
+func workspacePortOffset(ws *workspace.Workspace, flagValue int, flagSet bool) (int, error) {
+	offset, source := ws.PortOffset, "port_offset"
+	if flagSet {
+		offset, source = flagValue, "--port-offset"
+	} else if value := os.Getenv(portOffsetEnv); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("%s '%s' is not a number", portOffsetEnv, value)
+		}
+		offset, source = parsed, portOffsetEnv
+	}
+	if offset < 0 || offset > workspace.MaxPortOffset {
+		return 0, fmt.Errorf("%s %d must be between 0 and %d", source, offset, workspace.MaxPortOffset)
+	}
+	return offset, nil
+}
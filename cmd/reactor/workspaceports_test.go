@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspacePortOffset(t *testing.T) {
+	ws := &workspace.Workspace{PortOffset: 100}
+	t.Setenv(portOffsetEnv, "")
+
+	offset, err := workspacePortOffset(ws, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 100, offset, "the workspace file applies by default")
+
+	t.Setenv(portOffsetEnv, "200")
+	offset, err = workspacePortOffset(ws, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 200, offset, "the environment overrides the workspace file")
+
+	offset, err = workspacePortOffset(ws, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, offset, "the flag overrides both, even when zero")
+
+	t.Setenv(portOffsetEnv, "ten")
+	_, err = workspacePortOffset(ws, 0, false)
+	assert.EqualError(t, err, "REACTOR_PORT_OFFSET 'ten' is not a number")
+
+	_, err = workspacePortOffset(ws, 70000, true)
+	assert.EqualError(t, err, "--port-offset 70000 must be between 0 and 65534")
+}
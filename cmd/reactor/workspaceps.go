@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+// shortInstanceLength is how much of a workspace hash 'workspace ps' prints
+const shortInstanceLength = 12
+
+func newWorkspacePsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ps",
+		Short: "List started workspaces from any directory",
+		Long: `List every workspace started with 'reactor workspace up', wherever its file is.
+
+Each workspace is identified by its instance, a hash of its file's path. Stop a
+workspace from any directory with 'reactor workspace down --instance <instance>';
+the start of the instance is enough when it is unique. Workspaces whose
+containers have all been removed are dropped from the list.
+
+Examples:
+  reactor workspace ps
+  reactor workspace down --instance 3f9a1c2b7d4e`,
+		Args: cobra.NoArgs,
+		RunE: workspacePsHandler,
+	}
+}
+
+func workspacePsHandler(cmd *cobra.Command, args []string) error {
+	workspaces, err := state.Workspaces()
+	if err != nil {
+		return err
+	}
+	if len(workspaces) == 0 {
+		fmt.Println("No started workspaces.")
+		return nil
+	}
+
+	hashes := make([]string, 0, len(workspaces))
+	for hash := range workspaces {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return workspaces[hashes[i]].File < workspaces[hashes[j]].File
+	})
+
+	ctx := context.Background()
+	var rows [][]string
+	for _, hash := range hashes {
+		ws := workspaces[hash]
+		endpoints := newRecordedEndpoints(ws)
+		running, found, err := workspaceContainerCounts(ctx, endpoints, hash)
+		endpoints.Close()
+		status := fmt.Sprintf("%d/%d running", running, len(ws.Services))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot check workspace %s: %v\n", ws.File, err)
+			status = "unknown"
+		} else if found == 0 {
+			// Removed outside reactor, e.g. with 'docker rm'
+			if err := state.ForgetWorkspaceServices(hash); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to forget workspace %s: %v\n", ws.File, err)
+			}
+			continue
+		}
+		started := time.Since(ws.StartedAt).Round(time.Second).String() + " ago"
+		rows = append(rows, []string{hash[:shortInstanceLength], strings.Join(sortedWorkspaceServices(ws), ","), status, started, ws.File})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No started workspaces.")
+		return nil
+	}
+	fmt.Printf("%-14s %-30s %-12s %-16s %s\n", "INSTANCE", "SERVICES", "STATUS", "STARTED", "FILE")
+	for _, row := range rows {
+		fmt.Printf("%-14s %-30s %-12s %-16s %s\n", row[0], row[1], row[2], row[3], row[4])
+	}
+	return nil
+}
+
+// workspaceContainerCounts counts the services of a workspace with a running container
+// and the containers found on the daemons the services were started on
+func workspaceContainerCounts(ctx context.Context, endpoints *workspaceEndpoints, hash string) (running, found int, err error) {
+	runningServices := map[string]bool{}
+	for _, host := range endpoints.allHosts() {
+		dockerService, err := endpoints.forHost(host)
+		if err != nil {
+			return 0, 0, err
+		}
+		containers, err := dockerService.ListContainersByLabels(ctx, map[string]string{workspaceInstanceLabel: hash})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list containers%s: %w", describeDockerHostSuffix(host), err)
+		}
+		found += len(containers)
+		for _, c := range containers {
+			if c.Status == docker.StatusRunning {
+				runningServices[c.Labels[workspaceServiceLabel]] = true
+			}
+		}
+	}
+	return len(runningServices), found, nil
+}
+
+// newRecordedEndpoints returns the Docker daemons of a workspace as recorded when it
+// was started, so it can be managed without its file
+func newRecordedEndpoints(ws state.Workspace) *workspaceEndpoints {
+	endpoints := &workspaceEndpoints{
+		hosts:    make(map[string]string, len(ws.Services)),
+		services: map[string]*docker.Service{},
+	}
+	for name, service := range ws.Services {
+		endpoints.hosts[name] = service.DockerHost
+	}
+	return endpoints
+}
+
+func sortedWorkspaceServices(ws state.Workspace) []string {
+	names := make([]string, 0, len(ws.Services))
+	for name := range ws.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// workspaceDownInstance stops the services of a started workspace by its instance,
+// from any directory
+func workspaceDownInstance(instance string, args []string) error {
+	hash, ws, err := state.FindWorkspace(instance)
+	if err != nil {
+		return err
+	}
+
+	servicesToStop := sortedWorkspaceServices(*ws)
+	if len(args) > 0 {
+		for _, name := range args {
+			if _, ok := ws.Services[name]; !ok {
+				return fmt.Errorf("service '%s' was not started in workspace %s", name, ws.File)
+			}
+		}
+		servicesToStop = args
+	}
+
+	endpoints := newRecordedEndpoints(*ws)
+	defer endpoints.Close()
+
+	fmt.Printf("Stopping workspace services: %v\n", servicesToStop)
+	fmt.Printf("Workspace: %s (instance %s)\n", ws.File, hash[:shortInstanceLength])
+
+	for _, name := range servicesToStop {
+		if configDir := ws.Services[name].ConfigDir; configDir != "" {
+			if err := tunnel.Stop(configDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
+	if err := stopServicesInParallel(endpoints, servicesToStop, hash); err != nil {
+		return err
+	}
+	forgetStoppedWorkspaceServices(hash, servicesToStop)
+	return nil
+}
+
+// recordStartedWorkspace records the started services of a workspace for 'workspace ps'
+func recordStartedWorkspace(workspacePath, workspaceHash string, started map[string]state.WorkspaceService) {
+	if len(started) == 0 {
+		return
+	}
+	file, err := filepath.Abs(workspacePath)
+	if err == nil {
+		err = state.RecordWorkspace(workspaceHash, file, started)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record the workspace: %v\n", err)
+	}
+}
+
+// forgetStoppedWorkspaceServices removes stopped services from the workspace record
+func forgetStoppedWorkspaceServices(workspaceHash string, services []string) {
+	if err := state.ForgetWorkspaceServices(workspaceHash, services...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update the workspace record: %v\n", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecordedEndpoints(t *testing.T) {
+	endpoints := newRecordedEndpoints(state.Workspace{Services: map[string]state.WorkspaceService{
+		"api": {ContainerID: "c1"},
+		"db":  {ContainerID: "c2", DockerHost: "tcp://build-box:2376"},
+	}})
+	assert.Equal(t, "", endpoints.host("api"))
+	assert.Equal(t, "tcp://build-box:2376", endpoints.host("db"))
+	assert.Equal(t, []string{"", "tcp://build-box:2376"}, endpoints.allHosts())
+}
+
+func TestWorkspaceDownInstance(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+	require.NoError(t, state.RecordWorkspace("3f9a1c2b7d4e5f60", "/src/ws/reactor-workspace.yml", map[string]state.WorkspaceService{
+		"api": {ContainerID: "c1"},
+	}))
+
+	err := workspaceDownInstance("ffff", nil)
+	assert.ErrorContains(t, err, "no started workspace with instance 'ffff'")
+
+	err = workspaceDownInstance("3f9a", []string{"web"})
+	assert.ErrorContains(t, err, "service 'web' was not started in workspace /src/ws/reactor-workspace.yml")
+
+	cmd := newWorkspaceCmd()
+	cmd.SetArgs([]string{"down", "--instance", "3f9a", "-f", "other.yml"})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	assert.EqualError(t, cmd.Execute(), "--instance cannot be used with --file")
+}
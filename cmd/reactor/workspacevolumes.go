@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/workspace"
+)
+
+// workspaceServiceMounts returns the Docker volume mounts of a service's workspace volumes
+func workspaceServiceMounts(ws *workspace.Workspace, name, workspaceHash string) []config.Mount {
+	var mounts []config.Mount
+	for _, m := range ws.ServiceVolumes(name) {
+		mounts = append(mounts, config.Mount{
+			Type:     config.MountTypeVolume,
+			Source:   workspace.VolumeName(workspaceHash, m.Volume),
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+	return mounts
+}
+
+// checkSharedVolumeHosts refuses workspaces whose services share a volume across Docker
+// daemons, which each have their own volumes
+func checkSharedVolumeHosts(ws *workspace.Workspace, endpoints *workspaceEndpoints) error {
+	users := ws.VolumeUsers()
+	volumes := make([]string, 0, len(users))
+	for volume := range users {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	for _, volume := range volumes {
+		if hosts := endpoints.distinctHosts(users[volume]); len(hosts) > 1 {
+			return fmt.Errorf("volume '%s' is shared by services on different Docker hosts (%s and %s); services sharing a volume must run on the same daemon",
+				volume, describeDockerHost(hosts[0]), describeDockerHost(hosts[1]))
+		}
+	}
+	return nil
+}
+
+// removeWorkspaceVolumes removes the Docker volumes of a workspace from the daemons of
+// the services that use them. Volumes that were never created are skipped; volumes
+// whose existence cannot be checked are reported, as they may have been left behind.
+func removeWorkspaceVolumes(ctx context.Context, ws *workspace.Workspace, endpoints *workspaceEndpoints, workspaceHash string) error {
+	var failed []string
+	for volume, services := range ws.VolumeUsers() {
+		dockerService, err := endpoints.forService(services[0])
+		if err != nil {
+			return err
+		}
+		name := workspace.VolumeName(workspaceHash, volume)
+		exists, err := dockerService.VolumeExists(ctx, name)
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if err := dockerService.RemoveVolume(ctx, name); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		fmt.Printf("Removed volume %s\n", name)
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to remove workspace volumes: %v", failed)
+	}
+	return nil
+}
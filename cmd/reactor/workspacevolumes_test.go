@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/orchestrator"
+	"github.com/dyluth/reactor/pkg/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceServiceMounts(t *testing.T) {
+	ws := &workspace.Workspace{
+		Volumes: []string{"artifacts"},
+		Services: map[string]workspace.Service{
+			"api":    {Path: "./api", Volumes: []string{"artifacts:/artifacts"}},
+			"worker": {Path: "./worker", MountsFrom: "api:ro"},
+		},
+	}
+
+	assert.Equal(t, []config.Mount{{Type: config.MountTypeVolume, Source: "reactor-ws-0123456789ab-artifacts", Target: "/artifacts", ReadOnly: true}},
+		workspaceServiceMounts(ws, "worker", "0123456789abcdef"))
+
+	serviceConfig := workspaceServiceUpConfig(ws, &workspaceEndpoints{}, "api", "/src/ws", "0123456789abcdef", orchestrator.UpConfig{})
+	assert.Equal(t, "reactor-ws-0123456789ab-artifacts", serviceConfig.Mounts[0].Source)
+}
+
+func TestCheckSharedVolumeHosts(t *testing.T) {
+	ws := &workspace.Workspace{
+		Volumes: []string{"artifacts"},
+		Services: map[string]workspace.Service{
+			"api":    {Path: "./api", Volumes: []string{"artifacts:/artifacts"}},
+			"worker": {Path: "./worker", MountsFrom: "api"},
+		},
+	}
+	endpoints, err := newWorkspaceEndpoints(ws)
+	require.NoError(t, err)
+	assert.NoError(t, checkSharedVolumeHosts(ws, endpoints))
+
+	ws.Services["worker"] = workspace.Service{Path: "./worker", MountsFrom: "api", DockerHost: "tcp://gpu-box:2376"}
+	endpoints, err = newWorkspaceEndpoints(ws)
+	require.NoError(t, err)
+	assert.EqualError(t, checkSharedVolumeHosts(ws, endpoints), "volume 'artifacts' is shared by services on different Docker hosts (default and tcp://gpu-box:2376); services sharing a volume must run on the same daemon")
+}
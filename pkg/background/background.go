@@ -0,0 +1,58 @@
+// Package background starts and stops the processes reactor leaves running after a
+// command returns, such as SSH tunnels and port forwards. Their PIDs are recorded in
+// files, and a PID may be reused by an unrelated process once they exit, so a recorded
+// process is only signalled while its command line still matches.
+package background
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// errNoCommandLine means the platform cannot tell which command a process runs
+var errNoCommandLine = errors.New("command lines of processes cannot be read on this platform")
+
+// Start starts cmd detached from the terminal's process group, so Ctrl+C in the
+// terminal does not stop it and it outlives the command that started it
+func Start(cmd *exec.Cmd) error {
+	detach(cmd)
+	return cmd.Start()
+}
+
+// Running reports whether the process with pid runs and its command line matches.
+// Where command lines cannot be read, a running process is taken to match.
+func Running(pid int, match func(args []string) bool) bool {
+	if pid <= 0 || !exists(pid) {
+		return false
+	}
+	args, err := commandLine(pid)
+	if errors.Is(err, errNoCommandLine) {
+		return true
+	}
+	return err == nil && match(args)
+}
+
+// Stop asks the process with pid to exit when it runs and its command line matches.
+// It reports whether it signalled the process.
+func Stop(pid int, match func(args []string) bool) bool {
+	if !Running(pid, match) {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return terminate(process) == nil
+}
+
+// Contains reports whether args hold want as consecutive arguments
+func Contains(args []string, want ...string) bool {
+	for i := 0; i+len(want) <= len(args); i++ {
+		if slices.Equal(args[i:i+len(want)], want) {
+			return true
+		}
+	}
+	return false
+}
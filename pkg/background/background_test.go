@@ -0,0 +1,46 @@
+package background
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContains(t *testing.T) {
+	args := []string{"/usr/bin/reactor", "ports", "serve", "8080", "abc", "80"}
+	assert.True(t, Contains(args, "ports", "serve", "8080"))
+	assert.False(t, Contains(args, "ports", "serve", "3000"))
+	assert.False(t, Contains(args, "serve", "ports"))
+	assert.True(t, Contains(args))
+}
+
+func TestStartRunningStop(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	require.NoError(t, Start(cmd))
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	pid := cmd.Process.Pid
+
+	isSleep := func(args []string) bool { return Contains(args, "sleep", "30") }
+	isOther := func(args []string) bool { return Contains(args, "ports", "serve") }
+	assert.True(t, Running(pid, isSleep))
+	assert.False(t, Running(pid, isOther), "a process running another command is not the one recorded")
+
+	assert.False(t, Stop(pid, isOther), "a process running another command is left alone")
+	assert.True(t, Running(pid, isSleep))
+
+	assert.True(t, Stop(pid, isSleep))
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the process was not stopped")
+	}
+	assert.False(t, Running(pid, isSleep))
+	assert.False(t, Running(0, isSleep))
+}
@@ -0,0 +1,16 @@
+package background
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// commandLine reads the NUL-separated arguments of a process from /proc
+func commandLine(pid int) ([]string, error) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/cmdline")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00"), nil
+}
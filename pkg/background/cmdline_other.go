@@ -0,0 +1,19 @@
+//go:build unix && !linux
+
+package background
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// commandLine asks ps for the arguments of a process. They come back joined by spaces,
+// so arguments holding spaces are split.
+func commandLine(pid int) ([]string, error) {
+	out, err := exec.Command("ps", "-o", "args=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package background
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+// exists relies on FindProcess, which opens the process and fails when there is none
+func exists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
+
+// terminate kills the process, as Windows cannot deliver SIGTERM
+func terminate(process *os.Process) error {
+	return process.Kill()
+}
+
+func commandLine(pid int) ([]string, error) {
+	return nil, errNoCommandLine
+}
@@ -0,0 +1,55 @@
+// Package config parses devcontainer.json files and resolves them, together with
+// reactor's customizations, account defaults and environment, into the configuration
+// a container is created from.
+//
+// Other tools can reuse the parsing through ParseDevContainer and Resolve, which print
+// nothing and never consult the working directory.
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// DevContainer is a parsed devcontainer.json, including customizations.reactor
+type DevContainer = DevContainerConfig
+
+// ResolveOptions selects the project and configuration Resolve works on
+type ResolveOptions struct {
+	// ProjectRoot is the absolute path of the project holding .devcontainer/devcontainer.json
+	// or .devcontainer.json
+	ProjectRoot string
+	// ConfigName selects .devcontainer/<name>/devcontainer.json instead of the default
+	ConfigName string
+	// Overrides set settings by key, e.g. SettingAccount, above every other layer, as
+	// reactor's command-line flags do
+	Overrides map[string]string
+}
+
+// ParseDevContainer reads a devcontainer.json file. Comments and trailing commas are
+// allowed, as in every devcontainer.json.
+func ParseDevContainer(path string) (*DevContainer, error) {
+	return LoadDevContainerConfig(path)
+}
+
+// Resolve finds the project's devcontainer.json and resolves it into the configuration
+// reactor creates the container from: the account, the image, the state directories
+// and every reactor customization, with relative paths made absolute.
+func Resolve(opts ResolveOptions) (*ResolvedConfig, error) {
+	if opts.ProjectRoot == "" {
+		return nil, fmt.Errorf("a project root is required")
+	}
+	if !filepath.IsAbs(opts.ProjectRoot) {
+		return nil, fmt.Errorf("project root %s must be an absolute path", opts.ProjectRoot)
+	}
+	for key := range opts.Overrides {
+		if _, ok := lookupSetting(key); !ok {
+			return nil, fmt.Errorf("unknown setting '%s'", key)
+		}
+	}
+
+	service := NewServiceWithRoot(filepath.Clean(opts.ProjectRoot))
+	service.SetConfigName(opts.ConfigName)
+	service.SetOverrides(opts.Overrides)
+	return service.ResolveConfiguration()
+}
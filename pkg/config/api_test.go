@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDevContainer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devcontainer.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		// comments are allowed
+		"image": "node:20",
+		"customizations": {"reactor": {"account": "work", "maskPaths": ["node_modules"]}},
+	}`), 0644))
+
+	devContainer, err := ParseDevContainer(path)
+	require.NoError(t, err)
+	assert.Equal(t, "node:20", devContainer.Image)
+	assert.Equal(t, "work", devContainer.Customizations.Reactor.Account)
+	assert.Equal(t, []string{"node_modules"}, devContainer.Customizations.Reactor.MaskPaths)
+
+	_, err = ParseDevContainer(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read devcontainer file")
+}
+
+func TestResolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ACCOUNT", "")
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+
+	projectRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectRoot, ".devcontainer", "gpu"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectRoot, ".devcontainer", "devcontainer.json"), []byte(`{"image": "alpine", "customizations": {"reactor": {"account": "work"}}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectRoot, ".devcontainer", "gpu", "devcontainer.json"), []byte(`{"image": "cuda"}`), 0644))
+
+	resolved, err := Resolve(ResolveOptions{ProjectRoot: projectRoot})
+	require.NoError(t, err)
+	assert.Equal(t, "alpine", resolved.Image)
+	assert.Equal(t, "work", resolved.Account)
+	assert.Equal(t, projectRoot, resolved.ProjectRoot)
+	assert.Equal(t, filepath.Join(home, ".reactor", "work"), resolved.AccountConfigDir)
+
+	resolved, err = Resolve(ResolveOptions{ProjectRoot: projectRoot, ConfigName: "gpu", Overrides: map[string]string{SettingAccount: "ci"}})
+	require.NoError(t, err)
+	assert.Equal(t, "cuda", resolved.Image)
+	assert.Equal(t, "ci", resolved.Account)
+
+	_, err = Resolve(ResolveOptions{})
+	assert.ErrorContains(t, err, "a project root is required")
+	_, err = Resolve(ResolveOptions{ProjectRoot: "project"})
+	assert.ErrorContains(t, err, "must be an absolute path")
+	_, err = Resolve(ResolveOptions{ProjectRoot: projectRoot, Overrides: map[string]string{"acount": "ci"}})
+	assert.ErrorContains(t, err, "unknown setting 'acount'")
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layout of an account archive: a manifest followed by the account files under accountArchivePrefix
+const (
+	accountArchiveManifest = "manifest.json"
+	accountArchivePrefix   = "account/"
+	accountArchiveVersion  = 1
+)
+
+// secretFilePattern matches file names in provider directories that hold credentials
+var secretFilePattern = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|API_?KEY|PRIVATE_?KEY|CREDENTIALS?|OAUTH|AUTH\.JSON$|\.PEM$|\.KEY$|^\.ENV)`)
+
+// AccountArchiveManifest describes the content of an account archive
+type AccountArchiveManifest struct {
+	Version         int       `json:"version"`
+	Account         string    `json:"account"`
+	ExportedAt      time.Time `json:"exportedAt"`
+	IncludesSecrets bool      `json:"includesSecrets"`
+}
+
+// AccountArchiveSummary reports what an export or import touched
+type AccountArchiveSummary struct {
+	Account string
+	Files   []string
+	// SkippedSecrets lists the files left out of an export because they hold credentials
+	SkippedSecrets []string
+}
+
+// ExportAccount writes the account's defaults and provider config directories as a
+// gzipped tar archive. account.env and credential files are only included when
+// includeSecrets is set; overlays, logs and other per-machine state are never included.
+func ExportAccount(account string, w io.Writer, includeSecrets bool) (*AccountArchiveSummary, error) {
+	if err := validateAccountName(account); err != nil {
+		return nil, err
+	}
+	reactorHome, err := GetReactorHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	accountDir := filepath.Join(reactorHome, account)
+	if info, err := os.Stat(accountDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("account '%s' not found in %s", account, reactorHome)
+	}
+
+	files, skipped, err := collectAccountFiles(accountDir, includeSecrets)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifest, err := json.MarshalIndent(AccountArchiveManifest{
+		Version:         accountArchiveVersion,
+		Account:         account,
+		ExportedAt:      time.Now().UTC(),
+		IncludesSecrets: includeSecrets,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode archive manifest: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: accountArchiveManifest, Mode: 0644, Size: int64(len(manifest)), ModTime: time.Now()}); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	for _, rel := range files {
+		if err := addArchiveFile(tw, filepath.Join(accountDir, rel), accountArchivePrefix+filepath.ToSlash(rel)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return &AccountArchiveSummary{Account: account, Files: files, SkippedSecrets: skipped}, nil
+}
+
+// ImportAccount extracts an archive written by ExportAccount into ~/.reactor/<account>.
+// The account name is taken from the manifest unless as is given. Importing into an
+// existing account fails unless force is set, in which case archived files overwrite
+// the existing ones and other files are left in place.
+func ImportAccount(r io.Reader, as string, force bool) (*AccountArchiveSummary, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a reactor account archive: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != accountArchiveManifest {
+		return nil, fmt.Errorf("not a reactor account archive: missing %s", accountArchiveManifest)
+	}
+	var manifest AccountArchiveManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse archive manifest: %w", err)
+	}
+	if manifest.Version != accountArchiveVersion {
+		return nil, fmt.Errorf("unsupported account archive version %d", manifest.Version)
+	}
+
+	account := manifest.Account
+	if as != "" {
+		account = as
+	}
+	if err := validateAccountName(account); err != nil {
+		return nil, err
+	}
+
+	reactorHome, err := GetReactorHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	accountDir := filepath.Join(reactorHome, account)
+	if _, err := os.Stat(accountDir); err == nil && !force {
+		return nil, fmt.Errorf("account '%s' already exists in %s; use --force to overwrite its files", account, reactorHome)
+	}
+	if err := os.MkdirAll(accountDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create account directory: %w", err)
+	}
+
+	summary := &AccountArchiveSummary{Account: account}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %s in account archive", hdr.Name)
+		}
+		rel, err := archiveEntryPath(hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := extractArchiveFile(tr, filepath.Join(accountDir, rel), fs.FileMode(hdr.Mode).Perm()); err != nil {
+			return nil, err
+		}
+		summary.Files = append(summary.Files, rel)
+	}
+	return summary, nil
+}
+
+// collectAccountFiles lists the files to export, relative to the account directory
+func collectAccountFiles(accountDir string, includeSecrets bool) (files, skipped []string, err error) {
+	entries, err := os.ReadDir(accountDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read account directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Name() == AccountDefaultsFileName && entry.Type().IsRegular():
+			files = append(files, entry.Name())
+		case (entry.Name() == AccountEnvFileName || entry.Name() == RegistryCredentialsFileName) && entry.Type().IsRegular():
+			if includeSecrets {
+				files = append(files, entry.Name())
+			} else {
+				skipped = append(skipped, entry.Name())
+			}
+		case entry.IsDir():
+			for _, provider := range providerDirNames() {
+				providerDir := filepath.Join(accountDir, entry.Name(), provider)
+				if info, err := os.Stat(providerDir); err != nil || !info.IsDir() {
+					continue
+				}
+				err := filepath.WalkDir(providerDir, func(p string, d fs.DirEntry, err error) error {
+					if err != nil {
+						return err
+					}
+					if !d.Type().IsRegular() {
+						return nil
+					}
+					rel, err := filepath.Rel(accountDir, p)
+					if err != nil {
+						return err
+					}
+					if !includeSecrets && secretFilePattern.MatchString(d.Name()) {
+						skipped = append(skipped, rel)
+						return nil
+					}
+					files = append(files, rel)
+					return nil
+				})
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to read provider directory %s: %w", providerDir, err)
+				}
+			}
+		}
+	}
+
+	sort.Strings(files)
+	sort.Strings(skipped)
+	return files, skipped, nil
+}
+
+// providerDirNames returns the directory names that built-in providers mount into containers
+func providerDirNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, provider := range BuiltinProviders {
+		for _, mount := range provider.Mounts {
+			if !seen[mount.Source] {
+				seen[mount.Source] = true
+				names = append(names, mount.Source)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func addArchiveFile(tw *tar.Writer, filePath, name string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", filePath, err)
+	}
+	return nil
+}
+
+func extractArchiveFile(r io.Reader, dest string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dest, err)
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return f.Close()
+}
+
+// archiveEntryPath converts an archive entry name to a path inside the account
+// directory, rejecting entries that would escape it
+func archiveEntryPath(name string) (string, error) {
+	if !strings.HasPrefix(name, accountArchivePrefix) {
+		return "", fmt.Errorf("unexpected entry %s in account archive", name)
+	}
+	rel := path.Clean(strings.TrimPrefix(name, accountArchivePrefix))
+	if rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("unsafe path %s in account archive", name)
+	}
+	return filepath.FromSlash(rel), nil
+}
+
+func validateAccountName(account string) error {
+	if account == "" || account == "." || account == ".." || strings.ContainsAny(account, `/\`) {
+		return fmt.Errorf("invalid account name '%s'", account)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportAccount(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+
+	accountDir := filepath.Join(home, ".reactor", "work")
+	writeFile := func(rel, content string) {
+		p := filepath.Join(accountDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+	writeFile(AccountDefaultsFileName, `{"image": "python"}`)
+	writeFile(AccountEnvFileName, "API_TOKEN=secret\n")
+	writeFile("abc123/claude/settings.json", `{"theme": "dark"}`)
+	writeFile("abc123/claude/.credentials.json", `{"token": "secret"}`)
+	writeFile("abc123/gemini/oauth_creds.json", `{}`)
+	writeFile("abc123/overlay/upper/main.go", "package main")
+	writeFile("abc123/build.log", "Step 1/2")
+
+	t.Run("ExcludesSecretsByDefault", func(t *testing.T) {
+		var buf bytes.Buffer
+		summary, err := ExportAccount("work", &buf, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			filepath.Join("abc123", "claude", "settings.json"),
+			AccountDefaultsFileName,
+		}, summary.Files)
+		assert.Equal(t, []string{
+			filepath.Join("abc123", "claude", ".credentials.json"),
+			filepath.Join("abc123", "gemini", "oauth_creds.json"),
+			AccountEnvFileName,
+		}, summary.SkippedSecrets)
+
+		imported, err := ImportAccount(bytes.NewReader(buf.Bytes()), "moved", false)
+		require.NoError(t, err)
+		assert.Equal(t, "moved", imported.Account)
+		assert.Len(t, imported.Files, 2)
+
+		data, err := os.ReadFile(filepath.Join(home, ".reactor", "moved", "abc123", "claude", "settings.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"theme": "dark"}`, string(data))
+		assert.NoFileExists(t, filepath.Join(home, ".reactor", "moved", AccountEnvFileName))
+		assert.NoDirExists(t, filepath.Join(home, ".reactor", "moved", "abc123", "overlay"))
+	})
+
+	t.Run("IncludeSecrets", func(t *testing.T) {
+		var buf bytes.Buffer
+		summary, err := ExportAccount("work", &buf, true)
+		require.NoError(t, err)
+		assert.Empty(t, summary.SkippedSecrets)
+		assert.Len(t, summary.Files, 5)
+
+		imported, err := ImportAccount(bytes.NewReader(buf.Bytes()), "secret-copy", false)
+		require.NoError(t, err)
+		assert.Equal(t, "secret-copy", imported.Account)
+
+		info, err := os.Stat(filepath.Join(home, ".reactor", "secret-copy", "abc123", "claude", ".credentials.json"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("RefusesExistingAccountWithoutForce", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := ExportAccount("work", &buf, false)
+		require.NoError(t, err)
+
+		_, err = ImportAccount(bytes.NewReader(buf.Bytes()), "", false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "account 'work' already exists")
+
+		_, err = ImportAccount(bytes.NewReader(buf.Bytes()), "", true)
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(accountDir, AccountEnvFileName), "force keeps files not in the archive")
+	})
+
+	t.Run("UnknownAccount", func(t *testing.T) {
+		_, err := ExportAccount("missing", &bytes.Buffer{}, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "account 'missing' not found")
+	})
+}
+
+func TestImportAccount_RejectsUnsafeArchives(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+
+	archive := func(entries map[string]string) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		manifest := `{"version": 1, "account": "evil"}`
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: accountArchiveManifest, Mode: 0644, Size: int64(len(manifest))}))
+		_, _ = tw.Write([]byte(manifest))
+		for name, content := range entries {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+			_, _ = tw.Write([]byte(content))
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+		return buf.Bytes()
+	}
+
+	_, err := ImportAccount(bytes.NewReader(archive(map[string]string{"account/../../escape": "x"})), "", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsafe path")
+	assert.NoFileExists(t, filepath.Join(home, ".reactor", "escape"))
+
+	_, err = ImportAccount(bytes.NewReader(archive(nil)), "../outside", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid account name")
+
+	_, err = ImportAccount(bytes.NewReader([]byte("not an archive")), "", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a reactor account archive")
+}
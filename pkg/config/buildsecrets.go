@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// buildIDPattern restricts secret and SSH IDs to the characters BuildKit accepts
+var buildIDPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// BuildSecret is a secret from customizations.reactor.build.secrets, available during the
+// build to RUN --mount=type=secret,id=<ID> instructions without being stored in a layer
+type BuildSecret struct {
+	ID     string
+	Source string // host file with the secret; absolute once resolved
+	Env    string // or host environment variable holding it
+}
+
+// BuildSSH is an SSH agent socket or key from customizations.reactor.build.ssh, available
+// to RUN --mount=type=ssh instructions
+type BuildSSH struct {
+	ID    string   // "default" unless the Dockerfile mounts another id
+	Paths []string // agent sockets or keys; empty for the host's SSH_AUTH_SOCK
+}
+
+// ParseBuildSecret parses a secret written as for 'docker build --secret', e.g.
+// "id=npmrc,src=~/.npmrc" or "id=npm_token,env=NPM_TOKEN"
+func ParseBuildSecret(spec string) (BuildSecret, error) {
+	var secret BuildSecret
+	for _, option := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch strings.ToLower(key) {
+		case "":
+			continue
+		case "id":
+			secret.ID = value
+		case "src", "source":
+			secret.Source = value
+		case "env":
+			secret.Env = value
+		case "type":
+			if value != "file" && value != "env" {
+				return BuildSecret{}, fmt.Errorf("invalid type '%s' in build secret '%s': must be file or env", value, spec)
+			}
+		default:
+			return BuildSecret{}, fmt.Errorf("unknown option '%s' in build secret '%s'", key, spec)
+		}
+	}
+	if !buildIDPattern.MatchString(secret.ID) {
+		return BuildSecret{}, fmt.Errorf("build secret '%s' needs an id of letters, digits, '_', '.' and '-'", spec)
+	}
+	if (secret.Source == "") == (secret.Env == "") {
+		return BuildSecret{}, fmt.Errorf("build secret '%s' needs either src or env", spec)
+	}
+	return secret, nil
+}
+
+// String returns the secret in the form 'docker build --secret' takes
+func (b BuildSecret) String() string {
+	if b.Env != "" {
+		return "id=" + b.ID + ",env=" + b.Env
+	}
+	return "id=" + b.ID + ",src=" + b.Source
+}
+
+// ParseBuildSSH parses an SSH entry written as for 'docker build --ssh', e.g. "default"
+// or "github=~/.ssh/id_ed25519"
+func ParseBuildSSH(spec string) (BuildSSH, error) {
+	id, paths, hasPaths := strings.Cut(strings.TrimSpace(spec), "=")
+	if !buildIDPattern.MatchString(id) {
+		return BuildSSH{}, fmt.Errorf("build ssh '%s' needs an id of letters, digits, '_', '.' and '-'", spec)
+	}
+	ssh := BuildSSH{ID: id}
+	if hasPaths {
+		for _, p := range strings.Split(paths, ",") {
+			if p = strings.TrimSpace(p); p == "" {
+				return BuildSSH{}, fmt.Errorf("build ssh '%s' has an empty path", spec)
+			}
+			ssh.Paths = append(ssh.Paths, p)
+		}
+	}
+	return ssh, nil
+}
+
+// String returns the entry in the form 'docker build --ssh' takes
+func (b BuildSSH) String() string {
+	if len(b.Paths) == 0 {
+		return b.ID
+	}
+	return b.ID + "=" + strings.Join(b.Paths, ",")
+}
+
+// parseBuildCustomizations parses customizations.reactor.build, rejecting duplicate IDs
+func parseBuildCustomizations(build *BuildCustomizations) ([]BuildSecret, []BuildSSH, error) {
+	if build == nil {
+		return nil, nil, nil
+	}
+	var secrets []BuildSecret
+	ids := make(map[string]bool, len(build.Secrets))
+	for i, spec := range build.Secrets {
+		secret, err := ParseBuildSecret(spec)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid customizations.reactor.build.secrets[%d]: %w", i, err)
+		}
+		if ids[secret.ID] {
+			return nil, nil, fmt.Errorf("invalid customizations.reactor.build.secrets[%d]: secret id '%s' is used twice", i, secret.ID)
+		}
+		ids[secret.ID] = true
+		secrets = append(secrets, secret)
+	}
+
+	var sshEntries []BuildSSH
+	ids = make(map[string]bool, len(build.SSH))
+	for i, spec := range build.SSH {
+		ssh, err := ParseBuildSSH(spec)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid customizations.reactor.build.ssh[%d]: %w", i, err)
+		}
+		if ids[ssh.ID] {
+			return nil, nil, fmt.Errorf("invalid customizations.reactor.build.ssh[%d]: ssh id '%s' is used twice", i, ssh.ID)
+		}
+		ids[ssh.ID] = true
+		sshEntries = append(sshEntries, ssh)
+	}
+	return secrets, sshEntries, nil
+}
+
+// resolveBuildPath makes a secret or SSH key path absolute: "~/" starts at the home
+// directory and other relative paths at the devcontainer.json directory
+func resolveBuildPath(p, configDir string) (string, error) {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", p, err)
+		}
+		return filepath.Join(home, rest), nil
+	}
+	if !filepath.IsAbs(p) {
+		return filepath.Join(configDir, p), nil
+	}
+	return p, nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildSecret(t *testing.T) {
+	secret, err := ParseBuildSecret("id=npmrc,src=~/.npmrc")
+	require.NoError(t, err)
+	assert.Equal(t, BuildSecret{ID: "npmrc", Source: "~/.npmrc"}, secret)
+
+	secret, err = ParseBuildSecret("type=env,id=npm_token,env=NPM_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "id=npm_token,env=NPM_TOKEN", secret.String())
+
+	invalid := map[string]string{
+		"src=~/.npmrc":                 "needs an id",
+		"id=npmrc":                     "needs either src or env",
+		"id=npmrc,src=a,env=B":         "needs either src or env",
+		"id=npmrc,type=tmpfs,src=a":    "invalid type 'tmpfs'",
+		"id=npmrc,src=a,required=true": "unknown option 'required'",
+		"id=../npmrc,src=~/.npmrc":     "needs an id",
+	}
+	for spec, message := range invalid {
+		_, err := ParseBuildSecret(spec)
+		assert.ErrorContains(t, err, message, spec)
+	}
+}
+
+func TestParseBuildSSH(t *testing.T) {
+	ssh, err := ParseBuildSSH("default")
+	require.NoError(t, err)
+	assert.Equal(t, "default", ssh.String())
+
+	ssh, err = ParseBuildSSH("github=~/.ssh/id_ed25519,~/.ssh/id_rsa")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"~/.ssh/id_ed25519", "~/.ssh/id_rsa"}, ssh.Paths)
+
+	_, err = ParseBuildSSH("=~/.ssh/id_rsa")
+	assert.ErrorContains(t, err, "needs an id")
+	_, err = ParseBuildSSH("default=")
+	assert.ErrorContains(t, err, "has an empty path")
+}
+
+func TestServiceResolveConfiguration_BuildSecrets(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ACCOUNT", "")
+
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	require.NoError(t, os.MkdirAll(devcontainerDir, 0755))
+	configPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"build": {"dockerfile": "Dockerfile"}, "customizations": {"reactor": {"build": {
+		"secrets": ["id=npmrc,src=~/.npmrc", "id=pip,src=pip.conf", "id=token,env=NPM_TOKEN"],
+		"ssh": ["default", "deploy=keys/deploy"]
+	}}}}`), 0644))
+
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Equal(t, []BuildSecret{
+		{ID: "npmrc", Source: filepath.Join(home, ".npmrc")},
+		{ID: "pip", Source: filepath.Join(devcontainerDir, "pip.conf")},
+		{ID: "token", Env: "NPM_TOKEN"},
+	}, resolved.BuildSecrets)
+	assert.Equal(t, []BuildSSH{{ID: "default"}, {ID: "deploy", Paths: []string{filepath.Join(devcontainerDir, "keys/deploy")}}}, resolved.BuildSSH)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"build": {"dockerfile": "Dockerfile"}, "customizations": {"reactor": {"build": {
+		"secrets": ["id=npmrc,src=a", "id=npmrc,env=B"]
+	}}}}`), 0644))
+	_, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	assert.ErrorContains(t, err, "invalid customizations.reactor.build.secrets[1]: secret id 'npmrc' is used twice")
+}
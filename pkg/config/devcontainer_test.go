@@ -84,6 +84,70 @@ func TestFindDevContainerFile(t *testing.T) {
 	})
 }
 
+func TestSelectDevContainerFile(t *testing.T) {
+	writeConfig := func(t *testing.T, path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"image": "ubuntu"}`), 0644))
+	}
+
+	t.Run("lists the default configuration before the variants", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, filepath.Join(dir, ".devcontainer", "devcontainer.json"))
+		writeConfig(t, filepath.Join(dir, ".devcontainer", "web", "devcontainer.json"))
+		writeConfig(t, filepath.Join(dir, ".devcontainer", "api", "devcontainer.json"))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".devcontainer", "scripts"), 0755))
+
+		files, err := ListDevContainerFiles(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []DevContainerFile{
+			{Path: filepath.Join(dir, ".devcontainer", "devcontainer.json")},
+			{Name: "api", Path: filepath.Join(dir, ".devcontainer", "api", "devcontainer.json")},
+			{Name: "web", Path: filepath.Join(dir, ".devcontainer", "web", "devcontainer.json")},
+		}, files)
+
+		path, found, err := SelectDevContainerFile(dir, "")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, filepath.Join(dir, ".devcontainer", "devcontainer.json"), path)
+
+		path, found, err = SelectDevContainerFile(dir, "api")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, filepath.Join(dir, ".devcontainer", "api", "devcontainer.json"), path)
+
+		_, _, err = SelectDevContainerFile(dir, "db")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no devcontainer configuration 'db'; available: api, web")
+	})
+
+	t.Run("uses the only variant without a default", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, filepath.Join(dir, ".devcontainer", "api", "devcontainer.json"))
+
+		path, found, err := SelectDevContainerFile(dir, "")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, filepath.Join(dir, ".devcontainer", "api", "devcontainer.json"), path)
+	})
+
+	t.Run("requires a name with several variants and no default", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, filepath.Join(dir, ".devcontainer", "api", "devcontainer.json"))
+		writeConfig(t, filepath.Join(dir, ".devcontainer", "web", "devcontainer.json"))
+
+		_, found, err := SelectDevContainerFile(dir, "")
+		require.Error(t, err)
+		assert.False(t, found)
+		assert.Contains(t, err.Error(), "choose one with --config")
+	})
+
+	t.Run("reports nothing found in an empty project", func(t *testing.T) {
+		_, found, err := SelectDevContainerFile(t.TempDir(), "")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+}
+
 func TestLoadDevContainerConfig(t *testing.T) {
 	// Create a temporary directory for testing
 	tmpDir, err := os.MkdirTemp("", "reactor-test-*")
@@ -141,6 +205,47 @@ func TestLoadDevContainerConfig(t *testing.T) {
 		assert.Equal(t, "node", config.RemoteUser)
 	})
 
+	t.Run("loads VS Code template style JSONC with BOM and trailing commas", func(t *testing.T) {
+		configContent := "\ufeff" + `// For format details, see https://aka.ms/devcontainer.json.
+{
+	"name": "Go",
+	// Or use a Dockerfile or Docker Compose file.
+	"image": "mcr.microsoft.com/devcontainers/go:1-1.23-bookworm",
+	"forwardPorts": [
+		8080,
+	],
+	"customizations": {
+		"reactor": {
+			"account": "work", // per-project account
+		},
+	},
+}
+`
+
+		configFile := filepath.Join(tmpDir, "template.json")
+		require.NoError(t, os.WriteFile(configFile, []byte(configContent), 0644))
+
+		config, err := LoadDevContainerConfig(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, "mcr.microsoft.com/devcontainers/go:1-1.23-bookworm", config.Image)
+		assert.Len(t, config.ForwardPorts, 1)
+		assert.Equal(t, "work", config.Customizations.Reactor.Account)
+	})
+
+	t.Run("reports line and column of type errors", func(t *testing.T) {
+		configContent := `{
+	"name": "wrong-type",
+	"image": 42
+}`
+
+		configFile := filepath.Join(tmpDir, "wrongtype.json")
+		require.NoError(t, os.WriteFile(configFile, []byte(configContent), 0644))
+
+		_, err := LoadDevContainerConfig(configFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 3")
+	})
+
 	t.Run("returns error for malformed JSON", func(t *testing.T) {
 		configContent := `{
 			"name": "malformed-json"
@@ -551,3 +656,73 @@ func TestCompleteDataFlowWithDefaultCommand(t *testing.T) {
 	assert.Equal(t, BuiltinProviders["claude"], resolved.Provider)
 	assert.False(t, resolved.Danger) // Default to safe mode
 }
+
+func TestServiceResolveConfiguration_Scan(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, ".devcontainer.json")
+	require.NoError(t, os.WriteFile(configFile, []byte(`{
+		"image": "alpine:latest",
+		"customizations": {
+			"reactor": {
+				"scan": {"scanner": "grype", "severity": "critical", "warnOnly": true}
+			}
+		}
+	}`), 0644))
+
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	require.NotNil(t, resolved.Scan)
+	assert.Equal(t, ScanConfig{Scanner: "grype", Severity: "critical", WarnOnly: true}, *resolved.Scan)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"image": "alpine:latest"}`), 0644))
+	resolved, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Nil(t, resolved.Scan)
+}
+
+func TestServiceResolveConfiguration_InitAndEntrypoint(t *testing.T) {
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	require.NoError(t, os.MkdirAll(devcontainerDir, 0755))
+	configFile := filepath.Join(devcontainerDir, "devcontainer.json")
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"image": "alpine:latest"}`), 0644))
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.True(t, resolved.Init, "init should default to true")
+	assert.Empty(t, resolved.Entrypoint)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{
+		"image": "alpine:latest",
+		"init": false,
+		"customizations": {"reactor": {"entrypoint": "entrypoint.sh"}}
+	}`), 0644))
+	resolved, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.False(t, resolved.Init)
+	assert.Equal(t, filepath.Join(devcontainerDir, "entrypoint.sh"), resolved.Entrypoint)
+}
+
+func TestServiceResolveConfiguration_OverrideCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, ".devcontainer.json")
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"image": "alpine:latest"}`), 0644))
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.False(t, resolved.UseImageCommand, "overrideCommand defaults to true")
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{"image": "postgres:16", "overrideCommand": false}`), 0644))
+	resolved, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.True(t, resolved.UseImageCommand)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`{
+		"image": "postgres:16",
+		"overrideCommand": false,
+		"customizations": {"reactor": {"defaultCommand": "claude"}}
+	}`), 0644))
+	_, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be used with \"overrideCommand\": false")
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	units "github.com/docker/go-units"
+)
+
+// DefaultDiskWarnThreshold is the writable layer size above which 'reactor status'
+// warns when the project sets no diskLimit
+const DefaultDiskWarnThreshold int64 = 10 * units.GiB
+
+// diskWarnRatio is the fraction of diskLimit at which 'reactor status' warns
+const diskWarnRatio = 0.8
+
+// ParseDiskLimit parses a size such as "20g" or "512MB" into bytes (binary units, as docker does)
+func ParseDiskLimit(limit string) (int64, error) {
+	size, err := units.RAMInBytes(limit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s': use a number with an optional unit such as 512m or 20g", limit)
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("invalid size '%s': must be greater than zero", limit)
+	}
+	return size, nil
+}
+
+// DiskWarnThreshold returns the writable layer usage that should trigger a warning:
+// 80% of diskLimit when one is set, otherwise DefaultDiskWarnThreshold
+func DiskWarnThreshold(diskLimit string) int64 {
+	if diskLimit == "" {
+		return DefaultDiskWarnThreshold
+	}
+	size, err := ParseDiskLimit(diskLimit)
+	if err != nil {
+		return DefaultDiskWarnThreshold
+	}
+	return int64(float64(size) * diskWarnRatio)
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiskLimit(t *testing.T) {
+	size, err := ParseDiskLimit("20g")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20*1024*1024*1024), size)
+
+	size, err = ParseDiskLimit("512MB")
+	require.NoError(t, err)
+	assert.Equal(t, int64(512*1024*1024), size)
+
+	_, err = ParseDiskLimit("lots")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid size 'lots'")
+
+	_, err = ParseDiskLimit("0")
+	require.Error(t, err)
+}
+
+func TestDiskWarnThreshold(t *testing.T) {
+	assert.Equal(t, DefaultDiskWarnThreshold, DiskWarnThreshold(""))
+	assert.Equal(t, DefaultDiskWarnThreshold, DiskWarnThreshold("garbage"))
+	assert.Equal(t, int64(8*1024*1024*1024), DiskWarnThreshold("10g"))
+}
+
+func TestServiceResolveConfiguration_DiskLimit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("REACTOR_ACCOUNT", "")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".devcontainer.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {"diskLimit": "20g"}}}`), 0644))
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Equal(t, "20g", resolved.DiskLimit)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {"diskLimit": "huge"}}}`), 0644))
+	_, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid customizations.reactor.diskLimit")
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AccountEnvFileName is the dotenv file in an account directory whose variables
+// are passed to every container started with that account
+const AccountEnvFileName = "account.env"
+
+// Sources of a resolved environment variable, in increasing order of precedence
+const (
+	EnvSourceHostProxy    = "hostProxy"
+	EnvSourceContainerEnv = "containerEnv"
+	EnvSourceAccount      = "account"
+	EnvSourceEnvFile      = "envFile"
+	EnvSourceCLI          = "cli"
+)
+
+// maskedValue replaces secret values when the environment is displayed
+const maskedValue = "********"
+
+// EnvVar is a single resolved environment variable and where its value came from
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+var (
+	localEnvPattern = regexp.MustCompile(`\$\{localEnv:([^}:]+)(?::([^}]*))?\}`)
+	secretPattern   = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|PRIVATE_?KEY|CREDENTIALS?)`)
+)
+
+// AccountEnvFile returns the path of the account-level env file
+func AccountEnvFile(account string) (string, error) {
+	reactorHome, err := GetReactorHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(reactorHome, account, AccountEnvFileName), nil
+}
+
+// ResolveEnvironment merges the host's proxy variables, containerEnv from
+// devcontainer.json, the account env file, host env files given with --env-file and
+// KEY=VALUE overrides from the command line. Later sources win; the result is sorted
+// by name.
+func ResolveEnvironment(resolved *ResolvedConfig, envFiles, overrides []string) ([]EnvVar, error) {
+	return resolveEnvironment(resolved, true, envFiles, overrides)
+}
+
+// ResolveEnvironmentWithoutAccount merges the environment like ResolveEnvironment but
+// leaves out the account env file, for containers that must not see the account's secrets
+func ResolveEnvironmentWithoutAccount(resolved *ResolvedConfig, envFiles, overrides []string) ([]EnvVar, error) {
+	return resolveEnvironment(resolved, false, envFiles, overrides)
+}
+
+func resolveEnvironment(resolved *ResolvedConfig, withAccount bool, envFiles, overrides []string) ([]EnvVar, error) {
+	merged := make(map[string]EnvVar)
+
+	for name, value := range resolved.ProxyEnv {
+		merged[name] = EnvVar{Name: name, Value: value, Source: EnvSourceHostProxy}
+	}
+
+	for name, value := range resolved.ContainerEnv {
+		merged[name] = EnvVar{Name: name, Value: expandLocalEnv(value), Source: EnvSourceContainerEnv}
+	}
+
+	if withAccount {
+		accountEnvFile, err := AccountEnvFile(resolved.Account)
+		if err != nil {
+			return nil, err
+		}
+		accountEnv, err := LoadEnvFile(accountEnvFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for name, value := range accountEnv {
+			merged[name] = EnvVar{Name: name, Value: value, Source: EnvSourceAccount}
+		}
+	}
+
+	fileEnv, err := LoadEnvFiles(envFiles)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range fileEnv {
+		merged[name] = EnvVar{Name: name, Value: value, Source: EnvSourceEnvFile}
+	}
+
+	cliEnv, err := ParseEnvOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range cliEnv {
+		merged[name] = EnvVar{Name: name, Value: value, Source: EnvSourceCLI}
+	}
+
+	vars := make([]EnvVar, 0, len(merged))
+	for _, v := range merged {
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars, nil
+}
+
+// EnvironmentList converts resolved variables to the KEY=VALUE form used by Docker
+func EnvironmentList(vars []EnvVar) []string {
+	list := make([]string, len(vars))
+	for i, v := range vars {
+		list[i] = v.Name + "=" + v.Value
+	}
+	return list
+}
+
+// IsSecretEnvName reports whether a variable name looks like it holds a credential
+func IsSecretEnvName(name string) bool {
+	return secretPattern.MatchString(name)
+}
+
+// MaskSecrets returns a copy of vars with the values of secret-looking variables and
+// the passwords in proxy URLs masked
+func MaskSecrets(vars []EnvVar) []EnvVar {
+	masked := make([]EnvVar, len(vars))
+	for i, v := range vars {
+		if IsSecretEnvName(v.Name) && v.Value != "" {
+			v.Value = maskedValue
+		} else if IsProxyEnvName(v.Name) {
+			v.Value = maskProxyCredentials(v.Value)
+		}
+		masked[i] = v
+	}
+	return masked
+}
+
+// ParseEnvOverrides parses KEY=VALUE pairs given on the command line
+func ParseEnvOverrides(overrides []string) (map[string]string, error) {
+	env := make(map[string]string, len(overrides))
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok || !validEnvName(name) {
+			return nil, fmt.Errorf("invalid environment variable '%s': expected KEY=VALUE", override)
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// LoadEnvFiles reads env files given on the command line and merges them in order,
+// so a variable in a later file wins. Every file must exist.
+func LoadEnvFiles(paths []string) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, path := range paths {
+		fileEnv, err := LoadEnvFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load env file: %w", err)
+		}
+		for name, value := range fileEnv {
+			env[name] = value
+		}
+	}
+	return env, nil
+}
+
+// LoadEnvFile reads a dotenv file. Blank lines and # comments are skipped, an optional
+// 'export ' prefix is allowed and matching surrounding quotes are removed from values.
+func LoadEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !validEnvName(name) {
+			return nil, fmt.Errorf("invalid line %d in %s: expected KEY=VALUE", lineNumber, path)
+		}
+		env[name] = unquote(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return env, nil
+}
+
+// expandLocalEnv substitutes ${localEnv:NAME} and ${localEnv:NAME:default} from the host environment
+func expandLocalEnv(value string) string {
+	return localEnvPattern.ReplaceAllStringFunc(value, func(match string) string {
+		parts := localEnvPattern.FindStringSubmatch(match)
+		if hostValue, ok := os.LookupEnv(parts[1]); ok {
+			return hostValue
+		}
+		return parts[2]
+	})
+}
+
+func validEnvName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t=")
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
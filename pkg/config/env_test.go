@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account.env")
+	require.NoError(t, os.WriteFile(path, []byte(`# team defaults
+export GITHUB_TOKEN="ghp_abc"
+LOG_LEVEL = info
+
+QUOTED='a b'
+EMPTY=
+`), 0644))
+
+	env, err := LoadEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"GITHUB_TOKEN": "ghp_abc",
+		"LOG_LEVEL":    "info",
+		"QUOTED":       "a b",
+		"EMPTY":        "",
+	}, env)
+
+	require.NoError(t, os.WriteFile(path, []byte("not a variable\n"), 0644))
+	_, err = LoadEnvFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid line 1")
+}
+
+func TestParseEnvOverrides(t *testing.T) {
+	env, err := ParseEnvOverrides([]string{"A=1", "B=x=y", "C="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "x=y", "C": ""}, env)
+
+	_, err = ParseEnvOverrides([]string{"NOVALUE"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected KEY=VALUE")
+}
+
+func TestResolveEnvironment(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv("REACTOR_TEST_HOST_VAR", "from-host")
+
+	accountDir := filepath.Join(home, ".reactor", "alice")
+	require.NoError(t, os.MkdirAll(accountDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(accountDir, AccountEnvFileName), []byte("LOG_LEVEL=info\nAPI_TOKEN=secret-value\n"), 0644))
+
+	resolved := &ResolvedConfig{
+		Account: "alice",
+		ContainerEnv: map[string]string{
+			"LOG_LEVEL": "warn",
+			"HOST_VAR":  "${localEnv:REACTOR_TEST_HOST_VAR}",
+			"DEFAULTED": "${localEnv:REACTOR_TEST_UNSET_VAR:fallback}",
+			"EDITOR":    "vim",
+		},
+	}
+
+	vars, err := ResolveEnvironment(resolved, nil, []string{"EDITOR=nano"})
+	require.NoError(t, err)
+	assert.Equal(t, []EnvVar{
+		{Name: "API_TOKEN", Value: "secret-value", Source: EnvSourceAccount},
+		{Name: "DEFAULTED", Value: "fallback", Source: EnvSourceContainerEnv},
+		{Name: "EDITOR", Value: "nano", Source: EnvSourceCLI},
+		{Name: "HOST_VAR", Value: "from-host", Source: EnvSourceContainerEnv},
+		{Name: "LOG_LEVEL", Value: "info", Source: EnvSourceAccount},
+	}, vars)
+
+	assert.Equal(t, []string{"API_TOKEN=secret-value", "DEFAULTED=fallback", "EDITOR=nano", "HOST_VAR=from-host", "LOG_LEVEL=info"}, EnvironmentList(vars))
+
+	t.Run("EnvFilesBetweenAccountAndCLI", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, ".env")
+		second := filepath.Join(dir, ".env.local")
+		require.NoError(t, os.WriteFile(first, []byte("LOG_LEVEL=debug\nEDITOR=emacs\nDB_HOST=db\n"), 0644))
+		require.NoError(t, os.WriteFile(second, []byte("DB_HOST=localhost\n"), 0644))
+
+		vars, err := ResolveEnvironment(resolved, []string{first, second}, []string{"EDITOR=nano"})
+		require.NoError(t, err)
+		assert.Contains(t, vars, EnvVar{Name: "LOG_LEVEL", Value: "debug", Source: EnvSourceEnvFile})
+		assert.Contains(t, vars, EnvVar{Name: "DB_HOST", Value: "localhost", Source: EnvSourceEnvFile})
+		assert.Contains(t, vars, EnvVar{Name: "EDITOR", Value: "nano", Source: EnvSourceCLI})
+
+		_, err = ResolveEnvironment(resolved, []string{filepath.Join(dir, "missing.env")}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load env file")
+	})
+
+	masked := MaskSecrets(vars)
+	assert.Equal(t, "********", masked[0].Value)
+	assert.Equal(t, "fallback", masked[1].Value)
+	assert.Equal(t, "secret-value", vars[0].Value, "masking must not modify the input")
+
+	t.Run("WithoutAccount", func(t *testing.T) {
+		vars, err := ResolveEnvironmentWithoutAccount(resolved, nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, vars, EnvVar{Name: "LOG_LEVEL", Value: "warn", Source: EnvSourceContainerEnv})
+		for _, v := range vars {
+			assert.NotEqual(t, "API_TOKEN", v.Name)
+		}
+	})
+
+	t.Run("NoAccountEnvFile", func(t *testing.T) {
+		vars, err := ResolveEnvironment(&ResolvedConfig{Account: "bob"}, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, vars)
+	})
+}
+
+func TestIsSecretEnvName(t *testing.T) {
+	for _, name := range []string{"GITHUB_TOKEN", "aws_secret_access_key", "DB_PASSWORD", "OPENAI_API_KEY", "APIKEY"} {
+		assert.True(t, IsSecretEnvName(name), name)
+	}
+	for _, name := range []string{"PATH", "LOG_LEVEL", "EDITOR"} {
+		assert.False(t, IsSecretEnvName(name), name)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dyluth/reactor/pkg/debug"
+	usersettings "github.com/dyluth/reactor/pkg/settings"
+)
+
+// ApplyIsolationPrefix sets REACTOR_ISOLATION_PREFIX for the rest of the process from
+// customizations.reactor.isolationPrefix in the project's devcontainer.json or, failing
+// that, isolationPrefix in ~/.reactor/config.yaml. A prefix already in the environment
+// wins. Every part of reactor reads the prefix from the environment, so it has to be
+// applied before any state is touched. It returns the file the prefix came from, empty
+// when none was applied.
+func ApplyIsolationPrefix(projectRoot, configName string) (string, error) {
+	if os.Getenv(usersettings.EnvIsolationPrefix) != "" {
+		return "", nil
+	}
+
+	prefix, origin := "", ""
+	// A missing or broken devcontainer.json is reported by the commands that need one
+	if configPath, found, err := SelectDevContainerFile(projectRoot, configName); err == nil && found {
+		if devConfig, err := LoadDevContainerConfig(configPath); err == nil && devConfig.Customizations != nil && devConfig.Customizations.Reactor != nil {
+			prefix, origin = devConfig.Customizations.Reactor.IsolationPrefix, configPath
+			if err := usersettings.ValidateIsolationPrefix(prefix); err != nil {
+				return "", fmt.Errorf("invalid customizations.reactor.isolationPrefix in %s: %w", configPath, err)
+			}
+		}
+	}
+	if prefix == "" {
+		prefs, err := usersettings.Load()
+		if err != nil {
+			return "", err
+		}
+		prefix, origin = prefs.IsolationPrefix, prefs.Path
+	}
+	if prefix == "" {
+		return "", nil
+	}
+
+	if err := os.Setenv(usersettings.EnvIsolationPrefix, prefix); err != nil {
+		return "", fmt.Errorf("failed to set %s: %w", usersettings.EnvIsolationPrefix, err)
+	}
+	debug.Logf(debug.Config, "isolation prefix %s from %s", prefix, origin)
+	return origin, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyIsolationPrefix(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	preferencesPath := filepath.Join(home, ".reactor", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(preferencesPath), 0755))
+	require.NoError(t, os.WriteFile(preferencesPath, []byte("isolationPrefix: global\n"), 0644))
+
+	projectRoot := t.TempDir()
+	configPath := filepath.Join(projectRoot, ".devcontainer.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {"isolationPrefix": "e2e"}}}`), 0644))
+
+	// The project's prefix wins over the preferences file
+	origin, err := ApplyIsolationPrefix(projectRoot, "")
+	require.NoError(t, err)
+	assert.Equal(t, configPath, origin)
+	assert.Equal(t, "e2e", os.Getenv("REACTOR_ISOLATION_PREFIX"))
+
+	// And the environment over both
+	origin, err = ApplyIsolationPrefix(projectRoot, "")
+	require.NoError(t, err)
+	assert.Empty(t, origin)
+	assert.Equal(t, "e2e", os.Getenv("REACTOR_ISOLATION_PREFIX"))
+
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	origin, err = ApplyIsolationPrefix(t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Equal(t, preferencesPath, origin)
+	assert.Equal(t, "global", os.Getenv("REACTOR_ISOLATION_PREFIX"))
+
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {"isolationPrefix": "a/b"}}}`), 0644))
+	_, err = ApplyIsolationPrefix(projectRoot, "")
+	assert.ErrorContains(t, err, "invalid customizations.reactor.isolationPrefix")
+	assert.Empty(t, os.Getenv("REACTOR_ISOLATION_PREFIX"))
+}
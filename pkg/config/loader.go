@@ -1,12 +1,12 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/tailscale/hujson"
+	"github.com/dyluth/reactor/pkg/jsonc"
 )
 
 // CheckDependencies verifies that required system dependencies are available
@@ -70,6 +70,80 @@ func FindDevContainerFile(dir string) (string, bool, error) {
 	return "", false, nil
 }
 
+// DevContainerFile is one devcontainer.json of a project: the default one, or a variant
+// in a .devcontainer/<name>/ folder as published by repos with several configurations
+type DevContainerFile struct {
+	Name string // folder name of a variant, empty for the default configuration
+	Path string
+}
+
+// ListDevContainerFiles returns the default devcontainer.json, if any, followed by the
+// variants in .devcontainer/<name>/devcontainer.json sorted by name
+func ListDevContainerFiles(dir string) ([]DevContainerFile, error) {
+	var files []DevContainerFile
+	defaultPath, found, err := FindDevContainerFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		files = append(files, DevContainerFile{Path: defaultPath})
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, ".devcontainer"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .devcontainer directory: %w", err)
+	}
+	// os.ReadDir returns entries sorted by name
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, ".devcontainer", entry.Name(), "devcontainer.json")
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, DevContainerFile{Name: entry.Name(), Path: path})
+		}
+	}
+	return files, nil
+}
+
+// SelectDevContainerFile returns the devcontainer.json to use. An empty name selects the
+// default configuration, or the only variant when there is no default; with several
+// variants and no default one must be named.
+func SelectDevContainerFile(dir, name string) (string, bool, error) {
+	files, err := ListDevContainerFiles(dir)
+	if err != nil {
+		return "", false, err
+	}
+
+	var variants []string
+	for _, file := range files {
+		if file.Name == "" {
+			if name == "" {
+				return file.Path, true, nil
+			}
+			continue
+		}
+		if file.Name == name {
+			return file.Path, true, nil
+		}
+		variants = append(variants, file.Name)
+	}
+
+	if name != "" {
+		if len(variants) == 0 {
+			return "", false, fmt.Errorf("no devcontainer configuration '%s': %s does not exist", name, filepath.Join(dir, ".devcontainer", name, "devcontainer.json"))
+		}
+		return "", false, fmt.Errorf("no devcontainer configuration '%s'; available: %s", name, strings.Join(variants, ", "))
+	}
+	switch len(variants) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return files[0].Path, true, nil
+	}
+	return "", false, fmt.Errorf("found several devcontainer configurations (%s); choose one with --config", strings.Join(variants, ", "))
+}
+
 // LoadDevContainerConfig loads and parses a devcontainer.json file
 func LoadDevContainerConfig(filePath string) (*DevContainerConfig, error) {
 	// Read the file
@@ -78,16 +152,10 @@ func LoadDevContainerConfig(filePath string) (*DevContainerConfig, error) {
 		return nil, fmt.Errorf("failed to read devcontainer file %s: %w", filePath, err)
 	}
 
-	// Parse JSONC using hujson to convert to standard JSON
-	standardJSON, err := hujson.Standardize(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSONC in %s: %w", filePath, err)
-	}
-
-	// Unmarshal into DevContainerConfig struct
+	// devcontainer.json is JSONC: comments and trailing commas are allowed
 	var config DevContainerConfig
-	if err := json.Unmarshal(standardJSON, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal devcontainer config in %s: %w", filePath, err)
+	if err := jsonc.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse JSONC in %s: %w", filePath, err)
 	}
 
 	return &config, nil
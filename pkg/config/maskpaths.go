@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maskVolumeUnsafe matches the characters of a masked path that cannot appear in a
+// Docker volume name
+var maskVolumeUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// MaskVolumeName returns the Docker volume masking a path of a project's workspace. The
+// volume is named after the project, not the container, so installed dependencies
+// survive the container being recreated.
+func MaskVolumeName(projectHash, maskPath string) string {
+	return fmt.Sprintf("reactor-mask-%s-%s", projectHash, strings.Trim(maskVolumeUnsafe.ReplaceAllString(maskPath, "_"), "_."))
+}
+
+// parseMaskPaths validates customizations.reactor.maskPaths: paths relative to the
+// project such as "node_modules" or "services/api/.venv"
+func parseMaskPaths(specs []string, projectHash string) ([]string, error) {
+	paths := make([]string, 0, len(specs))
+	volumes := make(map[string]string, len(specs))
+	for i, spec := range specs {
+		cleaned := path.Clean(strings.TrimSpace(spec))
+		switch {
+		case spec == "" || cleaned == ".":
+			return nil, fmt.Errorf("invalid customizations.reactor.maskPaths[%d]: a path in the project is required", i)
+		case path.IsAbs(cleaned):
+			return nil, fmt.Errorf("invalid customizations.reactor.maskPaths[%d]: '%s' must be relative to the project, e.g. node_modules", i, spec)
+		case cleaned == ".." || strings.HasPrefix(cleaned, "../"):
+			return nil, fmt.Errorf("invalid customizations.reactor.maskPaths[%d]: '%s' is outside the project", i, spec)
+		}
+		volume := MaskVolumeName(projectHash, cleaned)
+		if other, ok := volumes[volume]; ok {
+			if other == cleaned {
+				return nil, fmt.Errorf("invalid customizations.reactor.maskPaths[%d]: '%s' is listed twice", i, spec)
+			}
+			return nil, fmt.Errorf("invalid customizations.reactor.maskPaths[%d]: '%s' and '%s' would share the volume %s", i, other, cleaned, volume)
+		}
+		volumes[volume] = cleaned
+		paths = append(paths, cleaned)
+	}
+	return paths, nil
+}
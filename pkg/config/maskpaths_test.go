@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMaskPaths(t *testing.T) {
+	paths, err := parseMaskPaths([]string{"node_modules", "./services/api/.venv/", " target "}, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node_modules", "services/api/.venv", "target"}, paths)
+
+	invalid := map[string][]string{
+		"a path in the project is required": {""},
+		"must be relative to the project":   {"/workspace/node_modules"},
+		"is outside the project":            {"../node_modules"},
+		"is listed twice":                   {"node_modules", "./node_modules"},
+		"would share the volume":            {"web/dist", "web_dist"},
+	}
+	for message, specs := range invalid {
+		_, err := parseMaskPaths(specs, "abc123")
+		assert.ErrorContains(t, err, message, specs)
+	}
+}
+
+func TestMaskVolumeName(t *testing.T) {
+	assert.Equal(t, "reactor-mask-abc123-node_modules", MaskVolumeName("abc123", "node_modules"))
+	assert.Equal(t, "reactor-mask-abc123-services_api_.venv", MaskVolumeName("abc123", "services/api/.venv"))
+	assert.Equal(t, "reactor-mask-abc123-venv", MaskVolumeName("abc123", ".venv"))
+}
+
+func TestServiceResolveConfiguration_MaskPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ACCOUNT", "")
+
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	require.NoError(t, os.MkdirAll(devcontainerDir, 0755))
+	configPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "node:20", "customizations": {"reactor": {"maskPaths": ["node_modules", "web/node_modules"]}}}`), 0644))
+
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node_modules", "web/node_modules"}, resolved.MaskPaths)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "node:20", "customizations": {"reactor": {
+		"maskPaths": ["node_modules"],
+		"mounts": ["type=volume,source=deps,target=/workspace/node_modules"]
+	}}}`), 0644))
+	_, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	assert.ErrorContains(t, err, "'node_modules' is already mounted by customizations.reactor.mounts")
+}
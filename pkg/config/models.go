@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os/user"
+	"path/filepath"
 )
 
 // MountPoint defines a directory mount for providers
@@ -26,19 +27,113 @@ type ProviderInfo struct {
 
 // ResolvedConfig contains fully resolved configuration with all paths
 type ResolvedConfig struct {
-	Provider          ProviderInfo
-	Account           string
-	Image             string
-	ProjectRoot       string
-	ProjectHash       string        // first 8 chars of project path hash
-	AccountConfigDir  string        // ~/.reactor/<account>/
-	ProjectConfigDir  string        // ~/.reactor/<account>/<project-hash>/
-	ForwardPorts      []PortMapping // port forwarding from devcontainer.json
-	RemoteUser        string        // container user from devcontainer.json
-	Build             *Build        // Docker build configuration from devcontainer.json
-	PostCreateCommand interface{}   // post-creation command from devcontainer.json (string or []string)
-	DefaultCommand    string        // default command from reactor customizations
-	Danger            bool
+	Provider             ProviderInfo
+	Account              string
+	Image                string
+	ProjectRoot          string
+	ProjectHash          string            // first 8 chars of project path hash
+	ConfigPath           string            // the devcontainer.json the configuration was read from
+	ConfigName           string            // the .devcontainer/<name>/ variant selected, empty for the default
+	AccountConfigDir     string            // ~/.reactor/<account>/
+	ProjectConfigDir     string            // ~/.reactor/<account>/<project-hash>/
+	CredentialScope      string            // where provider directories live: "project" or "account"
+	CredentialEncryption string            // how provider directories are encrypted at rest: "off", "age" or "keychain"
+	ShellHistory         bool              // keep shell and REPL history in the project config directory (defaults to true)
+	HostRegistries       []string          // registries whose credentials may be taken from the host's Docker config
+	ForwardPorts         []PortMapping     // port forwarding from devcontainer.json
+	ContainerEnv         map[string]string // containerEnv from devcontainer.json (unexpanded)
+	RemoteUser           string            // container user from devcontainer.json
+	Build                *Build            // Docker build configuration from devcontainer.json
+	PostCreateCommand    interface{}       // post-creation command from devcontainer.json (string, []string or object of named commands)
+	PostStartCommand     interface{}       // command run each time the container starts, in the same forms
+	DefaultCommand       string            // default command from reactor customizations
+	Scan                 *ScanConfig       // image scanning settings from reactor customizations
+	Init                 bool              // run an init process as PID 1 (defaults to true)
+	UseImageCommand      bool              // run the image's own CMD/ENTRYPOINT ("overrideCommand": false)
+	Entrypoint           string            // absolute host path of an entrypoint script from reactor customizations
+	DiskLimit            string            // writable layer size limit from reactor customizations, e.g. "20g"
+	Schedules            []Schedule        // recurring in-container commands from reactor customizations
+	Mounts               []Mount           // extra bind, volume and tmpfs mounts from reactor customizations
+	MaskPaths            []string          // project-relative paths covered by volumes, e.g. node_modules
+	Tools                []string          // tools from ToolManifest installed in the container, e.g. ripgrep
+	DNS                  []string          // DNS servers of the container, instead of the daemon's
+	DNSSearch            []string          // DNS search domains of the container
+	ExtraHosts           []string          // additional /etc/hosts entries in name:ip form
+	ProxyEnv             map[string]string // host proxy variables passed to builds and the container, empty when proxyEnv is off
+	BuildSecrets         []BuildSecret     // secrets passed to the image build from reactor customizations
+	BuildSSH             []BuildSSH        // SSH agents and keys passed to the image build
+	Privileged           bool              // run the container in privileged mode ("privileged" in devcontainer.json)
+	CapAdd               []string          // Linux capabilities added to the container, without the CAP_ prefix
+	SecurityOpt          []string          // security options such as "seccomp=unconfined"
+	Settings             *Settings         // layered settings with the source of each value
+	Danger               bool
+}
+
+// Credential scopes select whether provider config directories such as ~/.claude are
+// kept per project or shared by every project of an account
+const (
+	CredentialScopeProject = "project"
+	CredentialScopeAccount = "account"
+)
+
+// SharedProviderDirName is the directory in an account directory holding the provider
+// config directories shared by projects with the account credential scope
+const SharedProviderDirName = "shared"
+
+// Credential encryption modes. Encrypted provider directories are kept in
+// EncryptedCredentialsFileName and only decrypted into tmpfs mounts in the container.
+const (
+	CredentialEncryptionOff      = "off"
+	CredentialEncryptionAge      = "age"
+	CredentialEncryptionKeychain = "keychain"
+)
+
+// EncryptedCredentialsFileName is the file in the provider config directory holding the
+// encrypted provider directories
+const EncryptedCredentialsFileName = "credentials.enc"
+
+// Registry credential files in an account directory, saved by 'reactor accounts login'.
+// The encrypted one is used when credential encryption is on.
+const (
+	RegistryCredentialsFileName          = "registries.json"
+	EncryptedRegistryCredentialsFileName = "registries.enc"
+)
+
+// AgeIdentityFileName is the age identity in an account directory used by the age
+// credential encryption mode
+const AgeIdentityFileName = "age-identity.txt"
+
+// ProviderConfigDir returns the host directory holding the provider config directories
+// mounted into the container
+func (r *ResolvedConfig) ProviderConfigDir() string {
+	if r.CredentialScope == CredentialScopeAccount {
+		return filepath.Join(r.AccountConfigDir, SharedProviderDirName)
+	}
+	return r.ProjectConfigDir
+}
+
+// HistoryDirName is the directory in the project config directory holding the shell
+// and REPL history of the container user
+const HistoryDirName = "history"
+
+// BuildLogFileName is the file in the project config directory that holds the
+// output of the last image build
+const BuildLogFileName = "build.log"
+
+// SBOMFileName is the file in the project config directory that holds the software
+// bill of materials of the last image built with 'reactor build --sbom'
+const SBOMFileName = "sbom.spdx.json"
+
+// ContainerLogDir returns the directory in the project config directory that keeps the
+// output of earlier runs of a container
+func ContainerLogDir(projectConfigDir, containerName string) string {
+	return filepath.Join(projectConfigDir, "logs", containerName)
+}
+
+// CheckpointDir returns the directory in the project config directory that holds the
+// saved process state of a container's checkpoints
+func CheckpointDir(projectConfigDir, containerName string) string {
+	return filepath.Join(projectConfigDir, "checkpoints", containerName)
 }
 
 // Built-in provider mappings (hardcoded but extensible)
@@ -72,13 +167,20 @@ var BuiltinImages = map[string]string{
 
 // DevContainerConfig represents the structure of a devcontainer.json file
 type DevContainerConfig struct {
-	Name              string          `json:"name"`
-	Image             string          `json:"image"`
-	Build             *Build          `json:"build"`
-	ForwardPorts      []interface{}   `json:"forwardPorts"` // Can be int or string "host:container"
-	RemoteUser        string          `json:"remoteUser"`
-	PostCreateCommand interface{}     `json:"postCreateCommand"`
-	Customizations    *Customizations `json:"customizations"`
+	Name              string            `json:"name"`
+	Image             string            `json:"image"`
+	Build             *Build            `json:"build"`
+	ForwardPorts      []interface{}     `json:"forwardPorts"` // Can be int or string "host:container"
+	RemoteUser        string            `json:"remoteUser"`
+	ContainerEnv      map[string]string `json:"containerEnv"`
+	Init              *bool             `json:"init"`
+	Privileged        *bool             `json:"privileged"`
+	CapAdd            []string          `json:"capAdd"`
+	SecurityOpt       []string          `json:"securityOpt"`
+	OverrideCommand   *bool             `json:"overrideCommand"`
+	PostCreateCommand interface{}       `json:"postCreateCommand"`
+	PostStartCommand  interface{}       `json:"postStartCommand"`
+	Customizations    *Customizations   `json:"customizations"`
 }
 
 // Build defines Docker build properties
@@ -94,8 +196,52 @@ type Customizations struct {
 
 // ReactorCustomizations defines reactor-specific settings
 type ReactorCustomizations struct {
-	Account        string `json:"account"`
-	DefaultCommand string `json:"defaultCommand"`
+	Account        string      `json:"account"`
+	DefaultCommand string      `json:"defaultCommand"`
+	Scan           *ScanConfig `json:"scan"`
+	Entrypoint     string      `json:"entrypoint"` // script run under the init process before the container command
+	DiskLimit      string      `json:"diskLimit"`  // writable layer size limit, e.g. "20g"; needs storage driver support
+	Schedules      []Schedule  `json:"schedules"`  // recurring commands run by 'reactor schedule run'
+	// Mounts are extra mounts such as "type=tmpfs,target=/scratch,size=512m" (see ParseMount)
+	Mounts []string `json:"mounts"`
+	// MaskPaths are project paths such as "node_modules" kept in container-local volumes
+	// instead of the workspace bind mount
+	MaskPaths []string `json:"maskPaths"`
+	// CredentialScope is "project" (default) or "account" to share provider directories
+	CredentialScope string `json:"credentialScope"`
+	// ShellHistory keeps shell and REPL history across container recreations (default true)
+	ShellHistory *bool `json:"shellHistory"`
+	// DNS servers, DNS search domains and /etc/hosts entries ("name:ip") of the container
+	DNS        []string `json:"dns"`
+	DNSSearch  []string `json:"dnsSearch"`
+	ExtraHosts []string `json:"extraHosts"`
+	// ProxyEnv passes the host's HTTP_PROXY, HTTPS_PROXY and NO_PROXY to builds and the container (default true)
+	ProxyEnv *bool `json:"proxyEnv"`
+	// Build passes secrets and SSH agents to the image build
+	Build *BuildCustomizations `json:"build"`
+	// IsolationPrefix is used when REACTOR_ISOLATION_PREFIX is not set (see ApplyIsolationPrefix)
+	IsolationPrefix string `json:"isolationPrefix"`
+	// Tools such as "ripgrep" or "gh" installed when the container is created (see ToolManifest)
+	Tools []string `json:"tools"`
+}
+
+// BuildCustomizations passes credentials to a BuildKit build without storing them in a layer
+type BuildCustomizations struct {
+	Secrets []string `json:"secrets"` // as for 'docker build --secret', e.g. "id=npmrc,src=~/.npmrc"
+	SSH     []string `json:"ssh"`     // as for 'docker build --ssh', e.g. "default"
+}
+
+// Schedule is a command run inside the container whenever its cron expression matches
+type Schedule struct {
+	Cron    string `json:"cron"`    // five-field cron expression or a macro such as @hourly
+	Command string `json:"command"` // shell command run with sh -c
+}
+
+// ScanConfig enables a vulnerability scan of the image after 'reactor build'
+type ScanConfig struct {
+	Scanner  string `json:"scanner"`  // trivy or grype; auto-detected when empty
+	Severity string `json:"severity"` // minimum severity that triggers the policy (default: high)
+	WarnOnly bool   `json:"warnOnly"` // report findings without failing the build
 }
 
 // GetSystemUsername returns the current system username as default account
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Mount types accepted in customizations.reactor.mounts
+const (
+	MountTypeBind   = "bind"
+	MountTypeVolume = "volume"
+	MountTypeTmpfs  = "tmpfs"
+)
+
+// Mount is an extra mount from customizations.reactor.mounts
+type Mount struct {
+	Type        string // bind (default), volume or tmpfs
+	Source      string // host path of a bind mount or name of a volume; empty for tmpfs
+	Target      string // absolute path in the container
+	ReadOnly    bool
+	Consistency string // bind mounts only: consistent, cached or delegated (Docker Desktop for Mac)
+	Size        string // tmpfs only: size limit such as "512m"; empty for no limit
+}
+
+// ParseMount parses a mount written as comma-separated options, as in the devcontainer.json
+// "mounts" property, e.g. "type=tmpfs,target=/scratch,size=512m" or
+// "source=../cache,target=/cache,readonly,consistency=cached"
+func ParseMount(spec string) (Mount, error) {
+	m := Mount{Type: MountTypeBind}
+	for _, option := range strings.Split(spec, ",") {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(option), "=")
+		switch strings.ToLower(key) {
+		case "":
+			continue
+		case "type":
+			m.Type = strings.ToLower(value)
+		case "source", "src":
+			m.Source = value
+		case "target", "destination", "dst":
+			m.Target = value
+		case "readonly", "ro":
+			switch {
+			case !hasValue || value == "true" || value == "1":
+				m.ReadOnly = true
+			case value == "false" || value == "0":
+				m.ReadOnly = false
+			default:
+				return Mount{}, fmt.Errorf("invalid readonly value '%s' in mount '%s': use true or false", value, spec)
+			}
+		case "consistency":
+			m.Consistency = strings.ToLower(value)
+		case "size", "tmpfs-size":
+			m.Size = value
+		default:
+			return Mount{}, fmt.Errorf("unknown option '%s' in mount '%s'", key, spec)
+		}
+	}
+
+	if m.Target == "" {
+		return Mount{}, fmt.Errorf("mount '%s' needs a target", spec)
+	}
+	if !path.IsAbs(m.Target) {
+		return Mount{}, fmt.Errorf("mount target '%s' must be an absolute container path", m.Target)
+	}
+	m.Target = path.Clean(m.Target)
+	if m.Target == "/workspace" {
+		return Mount{}, fmt.Errorf("mount target /workspace is the project mount and cannot be replaced")
+	}
+
+	switch m.Type {
+	case MountTypeBind, MountTypeVolume:
+		if m.Source == "" {
+			return Mount{}, fmt.Errorf("%s mount '%s' needs a source", m.Type, spec)
+		}
+		if m.Size != "" {
+			return Mount{}, fmt.Errorf("size applies to tmpfs mounts only, not '%s'", spec)
+		}
+	case MountTypeTmpfs:
+		if m.Source != "" {
+			return Mount{}, fmt.Errorf("tmpfs mount '%s' cannot have a source", spec)
+		}
+		if m.Size != "" {
+			if _, err := ParseDiskLimit(m.Size); err != nil {
+				return Mount{}, fmt.Errorf("mount '%s': %w", spec, err)
+			}
+		}
+	default:
+		return Mount{}, fmt.Errorf("invalid mount type '%s' in '%s': must be bind, volume or tmpfs", m.Type, spec)
+	}
+
+	switch m.Consistency {
+	case "", "default":
+		m.Consistency = ""
+	case "consistent", "cached", "delegated":
+		if m.Type != MountTypeBind {
+			return Mount{}, fmt.Errorf("consistency applies to bind mounts only, not '%s'", spec)
+		}
+	default:
+		return Mount{}, fmt.Errorf("invalid consistency '%s' in mount '%s': must be consistent, cached or delegated", m.Consistency, spec)
+	}
+	return m, nil
+}
+
+// parseMounts parses customizations.reactor.mounts, rejecting two mounts on one target
+func parseMounts(specs []string) ([]Mount, error) {
+	mounts := make([]Mount, 0, len(specs))
+	targets := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		m, err := ParseMount(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid customizations.reactor.mounts[%d]: %w", i, err)
+		}
+		if targets[m.Target] {
+			return nil, fmt.Errorf("invalid customizations.reactor.mounts[%d]: %s is already mounted", i, m.Target)
+		}
+		targets[m.Target] = true
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
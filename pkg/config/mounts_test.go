@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMount(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected Mount
+	}{
+		{"type=tmpfs,target=/scratch,size=512m", Mount{Type: MountTypeTmpfs, Target: "/scratch", Size: "512m"}},
+		{"source=../cache,target=/cache,readonly,consistency=cached", Mount{Type: MountTypeBind, Source: "../cache", Target: "/cache", ReadOnly: true, Consistency: "cached"}},
+		{"type=volume,src=gomod,dst=/go/pkg/mod/", Mount{Type: MountTypeVolume, Source: "gomod", Target: "/go/pkg/mod"}},
+		{"type=bind,source=/data,target=/data,ro=false,consistency=default", Mount{Type: MountTypeBind, Source: "/data", Target: "/data"}},
+	}
+	for _, tt := range tests {
+		m, err := ParseMount(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.expected, m, tt.spec)
+	}
+
+	invalid := map[string]string{
+		"type=tmpfs":                                    "needs a target",
+		"type=tmpfs,target=scratch":                     "must be an absolute container path",
+		"source=/src,target=/workspace":                 "/workspace is the project mount",
+		"target=/cache":                                 "bind mount 'target=/cache' needs a source",
+		"type=tmpfs,source=/tmp,target=/scratch":        "cannot have a source",
+		"type=tmpfs,target=/scratch,size=lots":          "invalid size 'lots'",
+		"source=/src,target=/src,size=1g":               "size applies to tmpfs mounts only",
+		"type=tmpfs,target=/scratch,consistency=cached": "consistency applies to bind mounts only",
+		"source=/src,target=/src,consistency=fast":      "invalid consistency 'fast'",
+		"type=nfs,target=/data":                         "invalid mount type 'nfs'",
+		"source=/src,target=/src,ro=maybe":              "invalid readonly value 'maybe'",
+		"source=/src,target=/src,propagation=slave":     "unknown option 'propagation'",
+	}
+	for spec, message := range invalid {
+		_, err := ParseMount(spec)
+		assert.ErrorContains(t, err, message, spec)
+	}
+}
+
+func TestServiceResolveConfiguration_Mounts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ACCOUNT", "")
+
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	require.NoError(t, os.MkdirAll(devcontainerDir, 0755))
+	configPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {"mounts": [
+		"type=tmpfs,target=/scratch,size=1g",
+		"source=../cache,target=/cache,consistency=delegated",
+		"source=~/.npmrc,target=/home/claude/.npmrc,readonly"
+	]}}}`), 0644))
+
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Equal(t, []Mount{
+		{Type: MountTypeTmpfs, Target: "/scratch", Size: "1g"},
+		{Type: MountTypeBind, Source: filepath.Join(tmpDir, "cache"), Target: "/cache", Consistency: "delegated"},
+		{Type: MountTypeBind, Source: filepath.Join(home, ".npmrc"), Target: "/home/claude/.npmrc", ReadOnly: true},
+	}, resolved.Mounts)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {"mounts": [
+		"type=tmpfs,target=/scratch",
+		"type=tmpfs,target=/scratch/"
+	]}}}`), 0644))
+	_, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	assert.ErrorContains(t, err, "invalid customizations.reactor.mounts[1]: /scratch is already mounted")
+}
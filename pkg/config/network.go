@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// HostGateway is the extraHosts address Docker replaces with the host's IP address
+const HostGateway = "host-gateway"
+
+// hostnamePattern matches DNS names such as "corp.example.com" or "db-1"
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]{0,62})(\.[A-Za-z0-9_]([A-Za-z0-9_-]{0,62}))*\.?$`)
+
+// resolveNetworkSettings validates the dns, dnsSearch and extraHosts settings.
+// extraHosts entries may be written "name:ip" or "name=ip" and are returned as
+// "name:ip", the form Docker stores.
+func resolveNetworkSettings(settings *Settings) (servers, search, hosts []string, err error) {
+	servers = settings.List(SettingDNS)
+	for _, server := range servers {
+		if net.ParseIP(server) == nil {
+			return nil, nil, nil, invalidNetworkSetting(settings, SettingDNS, server, "expected an IP address")
+		}
+	}
+
+	search = settings.List(SettingDNSSearch)
+	for _, domain := range search {
+		// "." clears the daemon's search domains, as in 'docker run --dns-search'
+		if domain != "." && !hostnamePattern.MatchString(domain) {
+			return nil, nil, nil, invalidNetworkSetting(settings, SettingDNSSearch, domain, "expected a domain name")
+		}
+	}
+
+	for _, entry := range settings.List(SettingExtraHosts) {
+		host, err := parseExtraHost(entry)
+		if err != nil {
+			return nil, nil, nil, invalidNetworkSetting(settings, SettingExtraHosts, entry, err.Error())
+		}
+		hosts = append(hosts, host)
+	}
+	return servers, search, hosts, nil
+}
+
+// parseExtraHost validates a "name:ip" or "name=ip" hosts entry; the address may be
+// IPv6 or host-gateway
+func parseExtraHost(entry string) (string, error) {
+	name, address, ok := strings.Cut(entry, "=")
+	if !ok {
+		name, address, ok = strings.Cut(entry, ":")
+	}
+	if !ok || name == "" || address == "" {
+		return "", fmt.Errorf("expected name:ip")
+	}
+	if !hostnamePattern.MatchString(name) {
+		return "", fmt.Errorf("'%s' is not a host name", name)
+	}
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	if address != HostGateway && net.ParseIP(address) == nil {
+		return "", fmt.Errorf("'%s' is not an IP address or %s", address, HostGateway)
+	}
+	return name + ":" + address, nil
+}
+
+func invalidNetworkSetting(settings *Settings, key, value, reason string) error {
+	setting, _ := settings.Lookup(key)
+	return fmt.Errorf("invalid value '%s' for %s from %s: %s", value, key, setting.Origin, reason)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExtraHost(t *testing.T) {
+	valid := map[string]string{
+		"registry.corp:10.0.0.5":            "registry.corp:10.0.0.5",
+		"registry.corp=10.0.0.5":            "registry.corp:10.0.0.5",
+		"host.docker.internal:host-gateway": "host.docker.internal:host-gateway",
+		"ipv6:2001:db8::1":                  "ipv6:2001:db8::1",
+		"ipv6=[2001:db8::1]":                "ipv6:2001:db8::1",
+	}
+	for entry, expected := range valid {
+		host, err := parseExtraHost(entry)
+		require.NoError(t, err, entry)
+		assert.Equal(t, expected, host, entry)
+	}
+
+	invalid := map[string]string{
+		"registry.corp":          "expected name:ip",
+		":10.0.0.5":              "expected name:ip",
+		"bad host:10.0.0.5":      "'bad host' is not a host name",
+		"registry.corp:intranet": "'intranet' is not an IP address or host-gateway",
+	}
+	for entry, message := range invalid {
+		_, err := parseExtraHost(entry)
+		assert.ErrorContains(t, err, message, entry)
+	}
+}
+
+func TestServiceResolveConfiguration_Network(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ACCOUNT", "alice")
+	for _, env := range []string{"REACTOR_DNS", "REACTOR_DNS_SEARCH", "REACTOR_EXTRA_HOSTS"} {
+		t.Setenv(env, "")
+	}
+	clearProxyEnv(t)
+
+	tmpDir := t.TempDir()
+	devcontainerDir := filepath.Join(tmpDir, ".devcontainer")
+	require.NoError(t, os.MkdirAll(devcontainerDir, 0755))
+	configPath := filepath.Join(devcontainerDir, "devcontainer.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine", "customizations": {"reactor": {
+		"dns": ["10.0.0.53", "1.1.1.1"],
+		"dnsSearch": ["corp.example.com"],
+		"extraHosts": ["git.corp=10.0.0.7"]
+	}}}`), 0644))
+
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.53", "1.1.1.1"}, resolved.DNS)
+	assert.Equal(t, []string{"corp.example.com"}, resolved.DNSSearch)
+	assert.Equal(t, []string{"git.corp:10.0.0.7"}, resolved.ExtraHosts)
+
+	t.Run("AccountDefaults", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"image": "alpine"}`), 0644))
+		accountDir := filepath.Join(home, ".reactor", "alice")
+		require.NoError(t, os.MkdirAll(accountDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(accountDir, AccountDefaultsFileName), []byte(`{"dns": ["10.8.0.1"]}`), 0644))
+
+		resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.8.0.1"}, resolved.DNS)
+		assert.Empty(t, resolved.ExtraHosts)
+
+		t.Setenv("REACTOR_DNS", "10.9.0.1, 10.9.0.2")
+		resolved, err = NewServiceWithRoot(tmpDir).ResolveConfiguration()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.9.0.1", "10.9.0.2"}, resolved.DNS)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("REACTOR_DNS", "corp-dns")
+		_, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+		assert.ErrorContains(t, err, "invalid value 'corp-dns' for dns from REACTOR_DNS: expected an IP address")
+	})
+}
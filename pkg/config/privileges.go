@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// capabilityPattern matches Linux capability names such as SYS_ADMIN, with or without
+// the CAP_ prefix, and ALL
+var capabilityPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// resolvePrivileges validates the privileged, capAdd and securityOpt properties of
+// devcontainer.json. Capabilities are returned upper case without the CAP_ prefix, the
+// form 'docker inspect' shows.
+func resolvePrivileges(devConfig *DevContainerConfig) (privileged bool, capAdd, securityOpt []string, err error) {
+	if devConfig.Privileged != nil {
+		privileged = *devConfig.Privileged
+	}
+	for _, capability := range devConfig.CapAdd {
+		name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(capability)), "CAP_")
+		if !capabilityPattern.MatchString(name) {
+			return false, nil, nil, fmt.Errorf("invalid capAdd entry '%s': expected a capability name such as SYS_PTRACE", capability)
+		}
+		capAdd = append(capAdd, name)
+	}
+	for _, option := range devConfig.SecurityOpt {
+		option = strings.TrimSpace(option)
+		// Docker takes "name=value", the older "name:value" and the bare no-new-privileges
+		if option == "" || (option != "no-new-privileges" && !strings.ContainsAny(option, "=:")) {
+			return false, nil, nil, fmt.Errorf("invalid securityOpt entry '%s': expected name=value, e.g. seccomp=unconfined", option)
+		}
+		securityOpt = append(securityOpt, option)
+	}
+	return privileged, capAdd, securityOpt, nil
+}
+
+// ElevatedPrivileges describes the privileges beyond Docker's defaults the container is
+// configured with, empty when there are none. no-new-privileges only restricts the
+// container, so it is not listed.
+func (r *ResolvedConfig) ElevatedPrivileges() []string {
+	var elevated []string
+	if r.Privileged {
+		elevated = append(elevated, "privileged mode")
+	}
+	for _, capability := range r.CapAdd {
+		elevated = append(elevated, "capability "+capability)
+	}
+	for _, option := range r.SecurityOpt {
+		if option != "no-new-privileges" && option != "no-new-privileges=true" && option != "no-new-privileges:true" {
+			elevated = append(elevated, "security option "+option)
+		}
+	}
+	return elevated
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePrivileges(t *testing.T) {
+	privileged := true
+	got, capAdd, securityOpt, err := resolvePrivileges(&DevContainerConfig{
+		Privileged:  &privileged,
+		CapAdd:      []string{"SYS_PTRACE", "cap_net_admin"},
+		SecurityOpt: []string{"seccomp=unconfined", "no-new-privileges"},
+	})
+	require.NoError(t, err)
+	assert.True(t, got)
+	assert.Equal(t, []string{"SYS_PTRACE", "NET_ADMIN"}, capAdd)
+	assert.Equal(t, []string{"seccomp=unconfined", "no-new-privileges"}, securityOpt)
+
+	_, _, _, err = resolvePrivileges(&DevContainerConfig{CapAdd: []string{"sys ptrace"}})
+	assert.ErrorContains(t, err, "invalid capAdd entry 'sys ptrace'")
+	_, _, _, err = resolvePrivileges(&DevContainerConfig{SecurityOpt: []string{"unconfined"}})
+	assert.ErrorContains(t, err, "invalid securityOpt entry 'unconfined'")
+}
+
+func TestElevatedPrivileges(t *testing.T) {
+	assert.Empty(t, (&ResolvedConfig{SecurityOpt: []string{"no-new-privileges"}}).ElevatedPrivileges())
+
+	resolved := &ResolvedConfig{Privileged: true, CapAdd: []string{"SYS_PTRACE"}, SecurityOpt: []string{"seccomp=unconfined", "no-new-privileges:true"}}
+	assert.Equal(t, []string{"privileged mode", "capability SYS_PTRACE", "security option seccomp=unconfined"}, resolved.ElevatedPrivileges())
+}
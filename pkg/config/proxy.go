@@ -0,0 +1,148 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// proxyHostAlias is the name containers reach the host by
+const proxyHostAlias = "host.docker.internal"
+
+// proxyEnvNames are the proxy variables passed from the host. Docker accepts them as
+// build arguments without an ARG instruction and keeps them out of the image history.
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "NO_PROXY", "ALL_PROXY"}
+
+// HostProxyEnv returns the proxy variables set on the host. Tools disagree about the
+// case they read, so a variable set in only one case is returned in both. A proxy on
+// the host's loopback address is rewritten to host.docker.internal, which the
+// container cannot otherwise reach.
+func HostProxyEnv() map[string]string {
+	env := make(map[string]string)
+	for _, upper := range proxyEnvNames {
+		lower := strings.ToLower(upper)
+		upperValue, upperSet := os.LookupEnv(upper)
+		lowerValue, lowerSet := os.LookupEnv(lower)
+		if !upperSet || upperValue == "" {
+			upperValue, upperSet = lowerValue, lowerSet
+		}
+		if !lowerSet || lowerValue == "" {
+			lowerValue, lowerSet = upperValue, upperSet
+		}
+		if upperValue == "" && lowerValue == "" {
+			continue
+		}
+		if upper != "NO_PROXY" {
+			upperValue, lowerValue = containerProxyURL(upperValue), containerProxyURL(lowerValue)
+		}
+		env[upper], env[lower] = upperValue, lowerValue
+	}
+	return env
+}
+
+// IsProxyEnvName reports whether name is one of the proxy variables taken from the host
+func IsProxyEnvName(name string) bool {
+	for _, proxyName := range proxyEnvNames {
+		if name == proxyName || name == strings.ToLower(proxyName) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerProxyURL rewrites a proxy URL on localhost to host.docker.internal
+func containerProxyURL(value string) string {
+	u, ok := parseProxyURL(value)
+	if !ok || !isLoopbackHost(u.Hostname()) {
+		return value
+	}
+	port := u.Port()
+	u.Host = proxyHostAlias
+	if port != "" {
+		u.Host = net.JoinHostPort(proxyHostAlias, port)
+	}
+	if !strings.Contains(value, "://") {
+		return strings.TrimPrefix(u.String(), "http://")
+	}
+	return u.String()
+}
+
+// maskProxyCredentials replaces the password of a proxy URL for display
+func maskProxyCredentials(value string) string {
+	u, ok := parseProxyURL(value)
+	if !ok || u.User == nil {
+		return value
+	}
+	if _, hasPassword := u.User.Password(); !hasPassword {
+		return value
+	}
+	u.User = url.UserPassword(u.User.Username(), maskedValue)
+	masked, _ := url.PathUnescape(u.String())
+	if !strings.Contains(value, "://") {
+		return strings.TrimPrefix(masked, "http://")
+	}
+	return masked
+}
+
+// parseProxyURL parses a proxy address, which is often written without a scheme
+func parseProxyURL(value string) (*url.URL, bool) {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return nil, false
+	}
+	return u, true
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// withProxyGateway adds the host.docker.internal entry a rewritten proxy needs, unless
+// the extraHosts setting already maps the name. Docker Desktop resolves the name
+// itself, but Docker Engine on Linux only with this entry.
+func withProxyGateway(hosts []string, proxyEnv map[string]string) []string {
+	usesGateway := false
+	for name, value := range proxyEnv {
+		if strings.EqualFold(name, "NO_PROXY") {
+			continue
+		}
+		if u, ok := parseProxyURL(value); ok && u.Hostname() == proxyHostAlias {
+			usesGateway = true
+		}
+	}
+	if !usesGateway {
+		return hosts
+	}
+	for _, host := range hosts {
+		if strings.HasPrefix(host, proxyHostAlias+":") {
+			return hosts
+		}
+	}
+	return append(hosts, proxyHostAlias+":"+HostGateway)
+}
+
+// ProxyBuildHosts returns the /etc/hosts entries a build needs to reach the proxy
+func ProxyBuildHosts(proxyEnv map[string]string) []string {
+	return withProxyGateway(nil, proxyEnv)
+}
+
+// ProxyBuildArgs returns the proxy variables as Docker build arguments
+func ProxyBuildArgs(proxyEnv map[string]string) map[string]*string {
+	if len(proxyEnv) == 0 {
+		return nil
+	}
+	args := make(map[string]*string, len(proxyEnv))
+	for name, value := range proxyEnv {
+		value := value
+		args[name] = &value
+	}
+	return args
+}
@@ -3,17 +3,34 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/schedule"
+	usersettings "github.com/dyluth/reactor/pkg/settings"
 )
 
 // Service handles configuration operations
 type Service struct {
 	projectRoot string
+	configName  string            // .devcontainer/<name>/ variant to use, empty for the default
+	overrides   map[string]string // setting values from command-line flags
 }
 
-// NewService creates a new configuration service
+// globalConfigName is the .devcontainer/<name>/ variant selected by the global --config
+// flag, which every configuration of the current directory the process resolves uses
+var globalConfigName string
+
+// SetGlobalConfigName selects the .devcontainer/<name>/ variant for the services
+// NewService creates, as the global --config flag does; empty selects the default
+func SetGlobalConfigName(name string) {
+	globalConfigName = name
+}
+
+// NewService creates a new configuration service for the current directory
 func NewService() *Service {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -22,6 +39,7 @@ func NewService() *Service {
 
 	return &Service{
 		projectRoot: cwd,
+		configName:  globalConfigName,
 	}
 }
 
@@ -32,10 +50,21 @@ func NewServiceWithRoot(projectRoot string) *Service {
 	}
 }
 
+// SetOverrides sets setting values given on the command line, keyed by setting name.
+// They take precedence over every other configuration layer.
+func (s *Service) SetOverrides(overrides map[string]string) {
+	s.overrides = overrides
+}
+
+// SetConfigName selects the devcontainer.json in .devcontainer/<name>/ instead of the default one
+func (s *Service) SetConfigName(name string) {
+	s.configName = name
+}
+
 // ResolveConfiguration loads and resolves configuration using the new devcontainer.json workflow
 func (s *Service) ResolveConfiguration() (*ResolvedConfig, error) {
 	// 1. Find devcontainer.json
-	configPath, found, err := FindDevContainerFile(s.projectRoot)
+	configPath, found, err := SelectDevContainerFile(s.projectRoot, s.configName)
 	if err != nil {
 		return nil, fmt.Errorf("error searching for devcontainer.json: %w", err)
 	}
@@ -45,6 +74,8 @@ func (s *Service) ResolveConfiguration() (*ResolvedConfig, error) {
 			filepath.Join(s.projectRoot, ".devcontainer.json"))
 	}
 
+	debug.Logf(debug.Config, "using %s", configPath)
+
 	// 2. Parse devcontainer.json
 	devConfig, err := LoadDevContainerConfig(configPath)
 	if err != nil {
@@ -52,46 +83,157 @@ func (s *Service) ResolveConfiguration() (*ResolvedConfig, error) {
 	}
 
 	// 3. Map DevContainerConfig to ResolvedConfig
-	return s.mapToResolvedConfig(devConfig)
+	resolved, err := s.mapToResolvedConfig(devConfig, configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The entrypoint script is relative to the devcontainer.json directory
+	if resolved.Entrypoint != "" && !filepath.IsAbs(resolved.Entrypoint) {
+		resolved.Entrypoint = filepath.Join(filepath.Dir(configPath), resolved.Entrypoint)
+	}
+	// So are the sources of bind mounts, unless they start at the home directory
+	for i, m := range resolved.Mounts {
+		if m.Type != MountTypeBind {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(m.Source, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand mount source %s: %w", m.Source, err)
+			}
+			resolved.Mounts[i].Source = filepath.Join(home, rest)
+		} else if !filepath.IsAbs(m.Source) {
+			resolved.Mounts[i].Source = filepath.Join(filepath.Dir(configPath), m.Source)
+		}
+	}
+	// And the files of build secrets and SSH keys
+	for i, secret := range resolved.BuildSecrets {
+		if secret.Source == "" {
+			continue
+		}
+		if resolved.BuildSecrets[i].Source, err = resolveBuildPath(secret.Source, filepath.Dir(configPath)); err != nil {
+			return nil, err
+		}
+	}
+	for _, ssh := range resolved.BuildSSH {
+		for i, p := range ssh.Paths {
+			if ssh.Paths[i], err = resolveBuildPath(p, filepath.Dir(configPath)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	debug.Logf(debug.Config, "project %s: account %s, hash %s, state in %s", resolved.ProjectRoot, resolved.Account, resolved.ProjectHash, resolved.ProjectConfigDir)
+	return resolved, nil
 }
 
 // mapToResolvedConfig transforms DevContainerConfig into ResolvedConfig
-func (s *Service) mapToResolvedConfig(devConfig *DevContainerConfig) (*ResolvedConfig, error) {
-	// Extract account from customizations or use system default
-	account := ""
-	defaultCommand := ""
+func (s *Service) mapToResolvedConfig(devConfig *DevContainerConfig, configPath string) (*ResolvedConfig, error) {
+	// Resolve layered settings: flags > REACTOR_* env vars > devcontainer.json > account defaults > builtin
+	settings, err := ResolveSettings(devConfig, configPath, s.overrides)
+	if err != nil {
+		return nil, err
+	}
+	account := settings.Get(SettingAccount)
+	defaultCommand := settings.Get(SettingDefaultCommand)
+	// Reap zombies from agent-spawned processes unless the project opts out
+	init := settings.Bool(SettingInit)
+
+	entrypoint := ""
+	diskLimit := ""
+	var schedules []Schedule
+	var mountSpecs, maskSpecs, toolSpecs []string
+	var scanConfig *ScanConfig
+	var buildCustomizations *BuildCustomizations
 	if devConfig.Customizations != nil && devConfig.Customizations.Reactor != nil {
-		account = devConfig.Customizations.Reactor.Account
-		defaultCommand = devConfig.Customizations.Reactor.DefaultCommand
+		scanConfig = devConfig.Customizations.Reactor.Scan
+		entrypoint = devConfig.Customizations.Reactor.Entrypoint
+		diskLimit = devConfig.Customizations.Reactor.DiskLimit
+		schedules = devConfig.Customizations.Reactor.Schedules
+		mountSpecs = devConfig.Customizations.Reactor.Mounts
+		maskSpecs = devConfig.Customizations.Reactor.MaskPaths
+		toolSpecs = devConfig.Customizations.Reactor.Tools
+		buildCustomizations = devConfig.Customizations.Reactor.Build
 	}
-	if account == "" {
-		systemUser, err := GetSystemUsername()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get system username for default account: %w", err)
+	if diskLimit != "" {
+		if _, err := ParseDiskLimit(diskLimit); err != nil {
+			return nil, fmt.Errorf("invalid customizations.reactor.diskLimit: %w", err)
+		}
+	}
+	for i, sched := range schedules {
+		if strings.TrimSpace(sched.Command) == "" {
+			return nil, fmt.Errorf("invalid customizations.reactor.schedules[%d]: command is required", i)
+		}
+		if _, err := schedule.ParseCron(sched.Cron); err != nil {
+			return nil, fmt.Errorf("invalid customizations.reactor.schedules[%d]: %w", i, err)
 		}
-		account = systemUser
 	}
 
-	// For now, use claude as default provider until we implement provider-agnostic design
-	providerInfo := BuiltinProviders["claude"]
+	mounts, err := parseMounts(mountSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	tools, err := parseTools(toolSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	buildSecrets, buildSSH, err := parseBuildCustomizations(buildCustomizations)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsServers, dnsSearch, extraHosts, err := resolveNetworkSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	privileged, capAdd, securityOpt, err := resolvePrivileges(devConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	// Use image from devcontainer.json or default
-	image := devConfig.Image
-	if image == "" {
-		image = providerInfo.DefaultImage
+	var proxyEnv map[string]string
+	if settings.Bool(SettingProxyEnv) {
+		proxyEnv = HostProxyEnv()
+		extraHosts = withProxyGateway(extraHosts, proxyEnv)
+	}
+
+	// As in the spec, the image's own command is replaced unless overrideCommand is false
+	overrideCommand := true
+	if devConfig.OverrideCommand != nil {
+		overrideCommand = *devConfig.OverrideCommand
+	}
+	if !overrideCommand && defaultCommand != "" {
+		return nil, fmt.Errorf("customizations.reactor.defaultCommand cannot be used with \"overrideCommand\": false")
 	}
 
+	// For now, use claude as default provider until we implement provider-agnostic design
+	providerInfo := BuiltinProviders["claude"]
+
 	// Parse and validate forwardPorts from devcontainer.json
 	forwardPorts, err := parseForwardPorts(devConfig.ForwardPorts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse forwardPorts from devcontainer.json: %w", err)
 	}
 
-	// Extract remoteUser from devcontainer.json (will be defaulted in core layer if empty)
-	remoteUser := devConfig.RemoteUser
+	// remoteUser is defaulted in the core layer if empty
+	remoteUser := settings.Get(SettingRemoteUser)
 
 	// Generate project hash and paths
 	projectHash := GenerateProjectHash(s.projectRoot)
+	maskPaths, err := parseMaskPaths(maskSpecs, projectHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mounts {
+		for _, maskPath := range maskPaths {
+			if m.Target == path.Join("/workspace", maskPath) {
+				return nil, fmt.Errorf("customizations.reactor.maskPaths entry '%s' is already mounted by customizations.reactor.mounts", maskPath)
+			}
+		}
+	}
 	reactorHome, err := GetReactorHomeDir()
 	if err != nil {
 		return nil, err
@@ -101,19 +243,46 @@ func (s *Service) mapToResolvedConfig(devConfig *DevContainerConfig) (*ResolvedC
 	projectConfigDir := filepath.Join(accountConfigDir, projectHash)
 
 	return &ResolvedConfig{
-		Provider:          providerInfo,
-		Account:           account,
-		Image:             image,
-		ProjectRoot:       s.projectRoot,
-		ProjectHash:       projectHash,
-		AccountConfigDir:  accountConfigDir,
-		ProjectConfigDir:  projectConfigDir,
-		ForwardPorts:      forwardPorts,
-		RemoteUser:        remoteUser,
-		Build:             devConfig.Build,
-		PostCreateCommand: devConfig.PostCreateCommand,
-		DefaultCommand:    defaultCommand,
-		Danger:            false, // Default to safe mode for now
+		Provider:             providerInfo,
+		Account:              account,
+		Image:                settings.Get(SettingImage),
+		ProjectRoot:          s.projectRoot,
+		ProjectHash:          projectHash,
+		ConfigPath:           configPath,
+		ConfigName:           s.configName,
+		AccountConfigDir:     accountConfigDir,
+		ProjectConfigDir:     projectConfigDir,
+		CredentialScope:      settings.Get(SettingCredentialScope),
+		CredentialEncryption: settings.Get(SettingCredentialEncryption),
+		ShellHistory:         settings.Bool(SettingShellHistory),
+		HostRegistries:       settings.List(SettingHostRegistries),
+		ForwardPorts:         forwardPorts,
+		ContainerEnv:         devConfig.ContainerEnv,
+		RemoteUser:           remoteUser,
+		Build:                devConfig.Build,
+		PostCreateCommand:    devConfig.PostCreateCommand,
+		PostStartCommand:     devConfig.PostStartCommand,
+		DefaultCommand:       defaultCommand,
+		Scan:                 scanConfig,
+		Init:                 init,
+		UseImageCommand:      !overrideCommand,
+		Entrypoint:           entrypoint,
+		DiskLimit:            diskLimit,
+		Schedules:            schedules,
+		Mounts:               mounts,
+		MaskPaths:            maskPaths,
+		Tools:                tools,
+		DNS:                  dnsServers,
+		DNSSearch:            dnsSearch,
+		ExtraHosts:           extraHosts,
+		ProxyEnv:             proxyEnv,
+		BuildSecrets:         buildSecrets,
+		BuildSSH:             buildSSH,
+		Privileged:           privileged,
+		CapAdd:               capAdd,
+		SecurityOpt:          securityOpt,
+		Settings:             settings,
+		Danger:               false, // Default to safe mode for now
 	}, nil
 }
 
@@ -134,10 +303,17 @@ func (s *Service) InitializeProject() error {
 		return fmt.Errorf("failed to create .devcontainer directory: %w", err)
 	}
 
-	// Get system username for default account
-	username, err := GetSystemUsername()
+	// Default to the preferred account, then the system username
+	prefs, err := usersettings.Load()
 	if err != nil {
-		return fmt.Errorf("failed to get system username: %w", err)
+		return err
+	}
+	username := prefs.Account
+	if username == "" {
+		username, err = GetSystemUsername()
+		if err != nil {
+			return fmt.Errorf("failed to get system username: %w", err)
+		}
 	}
 
 	// Create basic devcontainer.json template
@@ -176,22 +352,15 @@ func (s *Service) ShowConfiguration() error {
 		return err
 	}
 
-	// Find the devcontainer.json file to show its path
-	configPath, found, err := FindDevContainerFile(s.projectRoot)
-	if err != nil {
-		return fmt.Errorf("error finding devcontainer.json: %w", err)
-	}
-	if !found {
-		return fmt.Errorf("no devcontainer.json found")
-	}
-
+	configPath := resolved.ConfigPath
 	fmt.Printf("DevContainer Configuration (%s):\n", configPath)
 	fmt.Printf("  account:         %s\n", resolved.Account)
 	fmt.Printf("  image:           %s\n", resolved.Image)
 	fmt.Printf("  project root:    %s\n", resolved.ProjectRoot)
 	fmt.Printf("  project hash:    %s\n", resolved.ProjectHash)
 	fmt.Printf("  account dir:     %s\n", resolved.AccountConfigDir)
-	fmt.Printf("  project config:  %s\n\n", resolved.ProjectConfigDir)
+	fmt.Printf("  project config:  %s\n", resolved.ProjectConfigDir)
+	fmt.Printf("  credentials:     %s (%s scope)\n\n", resolved.ProviderConfigDir(), resolved.CredentialScope)
 
 	fmt.Printf("Edit %s to customize your development environment.\n", configPath)
 	fmt.Printf("See https://containers.dev/implementors/json_reference/ for full specification.\n")
@@ -242,7 +411,11 @@ func (s *Service) ListAccounts() error {
 		}
 
 		for _, project := range projectEntries {
-			if project.IsDir() {
+			switch {
+			case !project.IsDir():
+			case project.Name() == SharedProviderDirName:
+				fmt.Printf("    shared credentials\n")
+			default:
 				fmt.Printf("    project: %s\n", project.Name())
 			}
 		}
@@ -264,3 +264,39 @@ func TestParseForwardPorts(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceResolveConfiguration_Schedules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("REACTOR_ACCOUNT", "")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".devcontainer.json")
+	write := func(reactor string) {
+		t.Helper()
+		content := `{"image": "alpine", "customizations": {"reactor": ` + reactor + `}}`
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	write(`{"schedules": [{"cron": "0 * * * *", "command": "make test"}]}`)
+	resolved, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+	if err != nil {
+		t.Fatalf("Expected valid schedules to resolve, got: %v", err)
+	}
+	if len(resolved.Schedules) != 1 || resolved.Schedules[0] != (Schedule{Cron: "0 * * * *", Command: "make test"}) {
+		t.Errorf("Unexpected schedules: %+v", resolved.Schedules)
+	}
+
+	invalid := map[string]string{
+		`{"schedules": [{"cron": "every hour", "command": "make test"}]}`: "invalid customizations.reactor.schedules[0]",
+		`{"schedules": [{"cron": "@daily"}]}`:                             "command is required",
+	}
+	for reactor, want := range invalid {
+		write(reactor)
+		_, err := NewServiceWithRoot(tmpDir).ResolveConfiguration()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q for %s, got: %v", want, reactor, err)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/jsonc"
+	usersettings "github.com/dyluth/reactor/pkg/settings"
+)
+
+// Setting sources in order of precedence, highest first
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceProject = "project"
+	SourceAccount = "account"
+	SourceUser    = "user"
+	SourceDefault = "default"
+)
+
+// Keys of the settings resolved through the precedence engine
+const (
+	SettingAccount              = "account"
+	SettingImage                = "image"
+	SettingRemoteUser           = "remoteUser"
+	SettingDefaultCommand       = "defaultCommand"
+	SettingInit                 = "init"
+	SettingNotify               = "notify"
+	SettingNotifyAfter          = "notifyAfter"
+	SettingCredentialScope      = "credentialScope"
+	SettingCredentialEncryption = "credentialEncryption"
+	SettingShellHistory         = "shellHistory"
+	SettingHostRegistries       = "hostRegistries"
+	SettingDNS                  = "dns"
+	SettingDNSSearch            = "dnsSearch"
+	SettingExtraHosts           = "extraHosts"
+	SettingProxyEnv             = "proxyEnv"
+)
+
+// AccountDefaultsFileName is the JSON file in an account directory holding default
+// setting values for every project that uses the account
+const AccountDefaultsFileName = "defaults.json"
+
+// SettingValue is a resolved setting together with the layer it came from
+type SettingValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Origin string `json:"origin"` // flag, variable or file that supplied the value
+}
+
+// settingDefinition describes where a setting can be read from in each layer
+type settingDefinition struct {
+	key     string
+	project func(*DevContainerConfig) (string, bool)
+	// account reports whether the setting may be set in the account defaults file
+	account bool
+	boolean bool
+	// seconds marks settings holding a whole number of seconds
+	seconds bool
+	// choices lists the allowed values of settings that take one of a fixed set
+	choices []string
+	// list marks comma-separated settings, which account defaults may give as a JSON array
+	list bool
+}
+
+var settingDefinitions = []settingDefinition{
+	{
+		key: SettingAccount,
+		project: func(c *DevContainerConfig) (string, bool) {
+			if c.Customizations == nil || c.Customizations.Reactor == nil {
+				return "", false
+			}
+			return c.Customizations.Reactor.Account, c.Customizations.Reactor.Account != ""
+		},
+	},
+	{
+		key:     SettingImage,
+		project: func(c *DevContainerConfig) (string, bool) { return c.Image, c.Image != "" },
+		account: true,
+	},
+	{
+		key:     SettingRemoteUser,
+		project: func(c *DevContainerConfig) (string, bool) { return c.RemoteUser, c.RemoteUser != "" },
+		account: true,
+	},
+	{
+		key: SettingDefaultCommand,
+		project: func(c *DevContainerConfig) (string, bool) {
+			if c.Customizations == nil || c.Customizations.Reactor == nil {
+				return "", false
+			}
+			return c.Customizations.Reactor.DefaultCommand, c.Customizations.Reactor.DefaultCommand != ""
+		},
+		account: true,
+	},
+	{
+		key: SettingInit,
+		project: func(c *DevContainerConfig) (string, bool) {
+			if c.Init == nil {
+				return "", false
+			}
+			return strconv.FormatBool(*c.Init), true
+		},
+		account: true,
+		boolean: true,
+	},
+	{
+		key:     SettingNotify,
+		project: notInProject,
+		account: true,
+		boolean: true,
+	},
+	{
+		key:     SettingNotifyAfter,
+		project: notInProject,
+		account: true,
+		seconds: true,
+	},
+	{
+		key: SettingCredentialScope,
+		project: func(c *DevContainerConfig) (string, bool) {
+			if c.Customizations == nil || c.Customizations.Reactor == nil {
+				return "", false
+			}
+			return c.Customizations.Reactor.CredentialScope, c.Customizations.Reactor.CredentialScope != ""
+		},
+		account: true,
+		choices: []string{CredentialScopeProject, CredentialScopeAccount},
+	},
+	{
+		key:     SettingCredentialEncryption,
+		project: notInProject,
+		account: true,
+		choices: []string{CredentialEncryptionOff, CredentialEncryptionAge, CredentialEncryptionKeychain},
+	},
+	{
+		key: SettingShellHistory,
+		project: func(c *DevContainerConfig) (string, bool) {
+			if c.Customizations == nil || c.Customizations.Reactor == nil || c.Customizations.Reactor.ShellHistory == nil {
+				return "", false
+			}
+			return strconv.FormatBool(*c.Customizations.Reactor.ShellHistory), true
+		},
+		account: true,
+		boolean: true,
+	},
+	{
+		key:     SettingHostRegistries,
+		project: notInProject,
+		account: true,
+		list:    true,
+	},
+	{
+		key:     SettingDNS,
+		project: reactorList(func(r *ReactorCustomizations) []string { return r.DNS }),
+		account: true,
+		list:    true,
+	},
+	{
+		key:     SettingDNSSearch,
+		project: reactorList(func(r *ReactorCustomizations) []string { return r.DNSSearch }),
+		account: true,
+		list:    true,
+	},
+	{
+		key:     SettingExtraHosts,
+		project: reactorList(func(r *ReactorCustomizations) []string { return r.ExtraHosts }),
+		account: true,
+		list:    true,
+	},
+	{
+		key: SettingProxyEnv,
+		project: func(c *DevContainerConfig) (string, bool) {
+			if c.Customizations == nil || c.Customizations.Reactor == nil || c.Customizations.Reactor.ProxyEnv == nil {
+				return "", false
+			}
+			return strconv.FormatBool(*c.Customizations.Reactor.ProxyEnv), true
+		},
+		account: true,
+		boolean: true,
+	},
+}
+
+// globalOverrides are setting values from global command-line flags such as --account,
+// which every configuration resolved by the process honours
+var globalOverrides = map[string]string{}
+
+// SetGlobalOverride sets a setting for every configuration the process resolves, as a
+// global command-line flag does; an empty value removes it. Overrides given to a
+// single command, such as a workspace service's account, take precedence.
+func SetGlobalOverride(key, value string) error {
+	if _, ok := lookupSetting(key); !ok {
+		return fmt.Errorf("unknown setting '%s'", key)
+	}
+	if value == "" {
+		delete(globalOverrides, key)
+		return nil
+	}
+	globalOverrides[key] = value
+	return nil
+}
+
+// notInProject is the project layer of settings devcontainer.json cannot set
+func notInProject(*DevContainerConfig) (string, bool) {
+	return "", false
+}
+
+// reactorList is the project layer of a list setting in customizations.reactor
+func reactorList(field func(*ReactorCustomizations) []string) func(*DevContainerConfig) (string, bool) {
+	return func(c *DevContainerConfig) (string, bool) {
+		if c.Customizations == nil || c.Customizations.Reactor == nil {
+			return "", false
+		}
+		items := field(c.Customizations.Reactor)
+		return strings.Join(items, ","), len(items) > 0
+	}
+}
+
+// Settings holds the resolved value of every setting
+type Settings struct {
+	values map[string]SettingValue
+}
+
+// Get returns the resolved value of a setting
+func (s *Settings) Get(key string) string {
+	return s.values[key].Value
+}
+
+// Lookup returns a resolved setting and whether the key is known
+func (s *Settings) Lookup(key string) (SettingValue, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Bool returns the resolved value of a boolean setting
+func (s *Settings) Bool(key string) bool {
+	value, _ := strconv.ParseBool(s.values[key].Value)
+	return value
+}
+
+// List returns the items of a comma-separated setting, without empty items
+func (s *Settings) List(key string) []string {
+	var items []string
+	for _, item := range strings.Split(s.values[key].Value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Seconds returns the resolved value of a setting holding a number of seconds
+func (s *Settings) Seconds(key string) time.Duration {
+	value, _ := strconv.Atoi(s.values[key].Value)
+	return time.Duration(value) * time.Second
+}
+
+// Explain returns every setting with its source, in a stable order
+func (s *Settings) Explain() []SettingValue {
+	explained := make([]SettingValue, 0, len(settingDefinitions))
+	for _, def := range settingDefinitions {
+		explained = append(explained, s.values[def.key])
+	}
+	return explained
+}
+
+// SettingEnvVar returns the REACTOR_* environment variable that overrides a setting,
+// e.g. REACTOR_DEFAULT_COMMAND for defaultCommand
+func SettingEnvVar(key string) string {
+	var b strings.Builder
+	b.WriteString("REACTOR_")
+	for i, r := range key {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteString(strings.ToUpper(string(r)))
+	}
+	return b.String()
+}
+
+// ResolveSettings applies the precedence CLI flag > REACTOR_* env var > devcontainer.json >
+// account defaults > builtin defaults to every setting. The account is resolved first
+// because it selects the account defaults file; in place of the account defaults it
+// falls back to the account in the user's preferences (~/.reactor/config.yaml).
+func ResolveSettings(devConfig *DevContainerConfig, configPath string, flags map[string]string) (*Settings, error) {
+	for key := range flags {
+		if _, ok := lookupSetting(key); !ok {
+			return nil, fmt.Errorf("unknown setting '%s'", key)
+		}
+	}
+	if len(globalOverrides) > 0 {
+		merged := make(map[string]string, len(globalOverrides)+len(flags))
+		for key, value := range globalOverrides {
+			merged[key] = value
+		}
+		for key, value := range flags {
+			merged[key] = value
+		}
+		flags = merged
+	}
+
+	settings := &Settings{values: make(map[string]SettingValue, len(settingDefinitions))}
+
+	systemUser, err := GetSystemUsername()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system username for default account: %w", err)
+	}
+	accountDef, _ := lookupSetting(SettingAccount)
+	account := resolveSetting(accountDef, devConfig, configPath, flags, nil, "", systemUser)
+	if account.Source == SourceDefault {
+		prefs, err := usersettings.Load()
+		if err != nil {
+			return nil, err
+		}
+		if prefs.Account != "" {
+			account = SettingValue{Key: SettingAccount, Value: prefs.Account, Source: SourceUser, Origin: prefs.Path}
+		}
+	}
+	settings.values[SettingAccount] = account
+
+	accountDefaults, accountFile, err := loadAccountDefaults(account.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	builtinDefaults := map[string]string{
+		SettingImage:                BuiltinProviders["claude"].DefaultImage,
+		SettingInit:                 "true",
+		SettingNotify:               "false",
+		SettingNotifyAfter:          "30",
+		SettingCredentialScope:      CredentialScopeProject,
+		SettingCredentialEncryption: CredentialEncryptionOff,
+		SettingShellHistory:         "true",
+		SettingProxyEnv:             "true",
+	}
+
+	for _, def := range settingDefinitions {
+		if def.key == SettingAccount {
+			continue
+		}
+		value := resolveSetting(def, devConfig, configPath, flags, accountDefaults, accountFile, builtinDefaults[def.key])
+		if def.boolean {
+			if _, err := strconv.ParseBool(value.Value); err != nil {
+				return nil, fmt.Errorf("invalid value '%s' for %s from %s: expected true or false", value.Value, def.key, value.Origin)
+			}
+		}
+		if def.seconds {
+			if n, err := strconv.Atoi(value.Value); err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid value '%s' for %s from %s: expected a whole number of seconds", value.Value, def.key, value.Origin)
+			}
+		}
+		if len(def.choices) > 0 && !slices.Contains(def.choices, value.Value) {
+			return nil, fmt.Errorf("invalid value '%s' for %s from %s: expected %s", value.Value, def.key, value.Origin, describeChoices(def.choices))
+		}
+		settings.values[def.key] = value
+	}
+
+	for _, def := range settingDefinitions {
+		value := settings.values[def.key]
+		debug.Logf(debug.Config, "setting %s = %q from %s (%s)", def.key, value.Value, value.Source, value.Origin)
+	}
+	return settings, nil
+}
+
+// resolveSetting walks the layers for a single setting, highest precedence first
+func resolveSetting(def settingDefinition, devConfig *DevContainerConfig, configPath string, flags, accountDefaults map[string]string, accountFile, builtinDefault string) SettingValue {
+	if value, ok := flags[def.key]; ok {
+		return SettingValue{Key: def.key, Value: value, Source: SourceFlag, Origin: "command line"}
+	}
+
+	envVar := SettingEnvVar(def.key)
+	if value, ok := os.LookupEnv(envVar); ok && value != "" {
+		return SettingValue{Key: def.key, Value: value, Source: SourceEnv, Origin: envVar}
+	}
+
+	if devConfig != nil {
+		if value, ok := def.project(devConfig); ok {
+			return SettingValue{Key: def.key, Value: value, Source: SourceProject, Origin: configPath}
+		}
+	}
+
+	if value, ok := accountDefaults[def.key]; ok {
+		return SettingValue{Key: def.key, Value: value, Source: SourceAccount, Origin: accountFile}
+	}
+
+	return SettingValue{Key: def.key, Value: builtinDefault, Source: SourceDefault, Origin: "builtin"}
+}
+
+// loadAccountDefaults reads ~/.reactor/<account>/defaults.json. A missing file yields no defaults.
+func loadAccountDefaults(account string) (map[string]string, string, error) {
+	reactorHome, err := GetReactorHomeDir()
+	if err != nil {
+		return nil, "", err
+	}
+	path := filepath.Join(reactorHome, account, AccountDefaultsFileName)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		debug.Logf(debug.Config, "no account defaults at %s", path)
+		return nil, path, nil
+	}
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to read account defaults: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := jsonc.Unmarshal(data, &raw); err != nil {
+		return nil, path, fmt.Errorf("failed to parse account defaults %s: %w", path, err)
+	}
+
+	defaults := make(map[string]string, len(raw))
+	for key, value := range raw {
+		def, ok := lookupSetting(key)
+		if !ok || !def.account {
+			return nil, path, fmt.Errorf("setting '%s' cannot be set in account defaults %s", key, path)
+		}
+		if items, ok := value.([]interface{}); ok && def.list {
+			values := make([]string, len(items))
+			for i, item := range items {
+				values[i] = fmt.Sprint(item)
+			}
+			defaults[key] = strings.Join(values, ",")
+			continue
+		}
+		defaults[key] = fmt.Sprint(value)
+	}
+	return defaults, path, nil
+}
+
+func lookupSetting(key string) (settingDefinition, bool) {
+	for _, def := range settingDefinitions {
+		if def.key == key {
+			return def, true
+		}
+	}
+	return settingDefinition{}, false
+}
+
+// describeChoices lists allowed values as "a or b" or "a, b or c"
+func describeChoices(choices []string) string {
+	if len(choices) < 2 {
+		return strings.Join(choices, "")
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
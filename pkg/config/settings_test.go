@@ -0,0 +1,247 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingEnvVar(t *testing.T) {
+	assert.Equal(t, "REACTOR_ACCOUNT", SettingEnvVar(SettingAccount))
+	assert.Equal(t, "REACTOR_DEFAULT_COMMAND", SettingEnvVar(SettingDefaultCommand))
+	assert.Equal(t, "REACTOR_REMOTE_USER", SettingEnvVar(SettingRemoteUser))
+}
+
+func TestResolveSettings_Precedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	for _, key := range []string{SettingAccount, SettingImage, SettingRemoteUser, SettingDefaultCommand, SettingInit, SettingNotify, SettingNotifyAfter} {
+		t.Setenv(SettingEnvVar(key), "")
+	}
+
+	writeAccountDefaults := func(t *testing.T, account, content string) string {
+		dir := filepath.Join(home, ".reactor", account)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		path := filepath.Join(dir, AccountDefaultsFileName)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	defaultsFile := writeAccountDefaults(t, "work", `{
+	// JSONC is accepted, as in devcontainer.json
+	"image": "account-image", "remoteUser": "dev", "init": false,
+}`)
+
+	devConfig := &DevContainerConfig{
+		Image: "project-image",
+		Customizations: &Customizations{Reactor: &ReactorCustomizations{
+			Account: "work",
+		}},
+	}
+
+	t.Run("ProjectAccountAndDefaultsLayers", func(t *testing.T) {
+		settings, err := ResolveSettings(devConfig, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, SettingValue{Key: SettingAccount, Value: "work", Source: SourceProject, Origin: "/p/.devcontainer.json"}, mustLookup(t, settings, SettingAccount))
+		assert.Equal(t, SettingValue{Key: SettingImage, Value: "project-image", Source: SourceProject, Origin: "/p/.devcontainer.json"}, mustLookup(t, settings, SettingImage))
+		assert.Equal(t, SettingValue{Key: SettingRemoteUser, Value: "dev", Source: SourceAccount, Origin: defaultsFile}, mustLookup(t, settings, SettingRemoteUser))
+		assert.Equal(t, SettingValue{Key: SettingDefaultCommand, Value: "", Source: SourceDefault, Origin: "builtin"}, mustLookup(t, settings, SettingDefaultCommand))
+		assert.False(t, settings.Bool(SettingInit))
+	})
+
+	t.Run("EnvOverridesProject", func(t *testing.T) {
+		t.Setenv("REACTOR_IMAGE", "env-image")
+		settings, err := ResolveSettings(devConfig, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.Equal(t, SettingValue{Key: SettingImage, Value: "env-image", Source: SourceEnv, Origin: "REACTOR_IMAGE"}, mustLookup(t, settings, SettingImage))
+	})
+
+	t.Run("FlagOverridesEverything", func(t *testing.T) {
+		t.Setenv("REACTOR_ACCOUNT", "env-account")
+		settings, err := ResolveSettings(devConfig, "/p/.devcontainer.json", map[string]string{SettingAccount: "personal", SettingInit: "true"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "personal", settings.Get(SettingAccount))
+		assert.Equal(t, SourceFlag, mustLookup(t, settings, SettingAccount).Source)
+		assert.True(t, settings.Bool(SettingInit))
+		// The personal account has no defaults file, so its values fall through to builtin
+		assert.Equal(t, SourceDefault, mustLookup(t, settings, SettingRemoteUser).Source)
+	})
+
+	t.Run("GlobalFlag", func(t *testing.T) {
+		require.NoError(t, SetGlobalOverride(SettingAccount, "personal"))
+		t.Cleanup(func() { _ = SetGlobalOverride(SettingAccount, "") })
+
+		settings, err := ResolveSettings(devConfig, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.Equal(t, SettingValue{Key: SettingAccount, Value: "personal", Source: SourceFlag, Origin: "command line"}, mustLookup(t, settings, SettingAccount))
+
+		// The override of a single command wins
+		settings, err = ResolveSettings(devConfig, "/p/.devcontainer.json", map[string]string{SettingAccount: "team"})
+		require.NoError(t, err)
+		assert.Equal(t, "team", settings.Get(SettingAccount))
+
+		assert.Error(t, SetGlobalOverride("colour", "never"))
+	})
+
+	t.Run("BuiltinDefaults", func(t *testing.T) {
+		settings, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+
+		systemUser, err := GetSystemUsername()
+		require.NoError(t, err)
+		assert.Equal(t, systemUser, settings.Get(SettingAccount))
+		assert.Equal(t, BuiltinProviders["claude"].DefaultImage, settings.Get(SettingImage))
+		assert.True(t, settings.Bool(SettingInit))
+
+		explained := settings.Explain()
+		require.Len(t, explained, 15)
+		assert.Equal(t, SettingAccount, explained[0].Key)
+	})
+
+	t.Run("UserPreferredAccount", func(t *testing.T) {
+		prefsFile := filepath.Join(home, ".reactor", "config.yaml")
+		require.NoError(t, os.WriteFile(prefsFile, []byte("account: work\n"), 0644))
+		t.Cleanup(func() { _ = os.Remove(prefsFile) })
+
+		settings, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.Equal(t, SettingValue{Key: SettingAccount, Value: "work", Source: SourceUser, Origin: prefsFile}, mustLookup(t, settings, SettingAccount))
+		// The preferred account selects its account defaults
+		assert.Equal(t, SourceAccount, mustLookup(t, settings, SettingRemoteUser).Source)
+
+		// A project naming its account wins over the preference
+		settings, err = ResolveSettings(&DevContainerConfig{Customizations: &Customizations{Reactor: &ReactorCustomizations{Account: "personal"}}}, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "personal", settings.Get(SettingAccount))
+	})
+
+	t.Run("InvalidBoolean", func(t *testing.T) {
+		t.Setenv("REACTOR_INIT", "maybe")
+		_, err := ResolveSettings(devConfig, "/p/.devcontainer.json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid value 'maybe' for init from REACTOR_INIT")
+	})
+
+	t.Run("NotificationSettings", func(t *testing.T) {
+		writeAccountDefaults(t, "notified", `{"notify": true, "notifyAfter": 90}`)
+		settings, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", map[string]string{SettingAccount: "notified"})
+		require.NoError(t, err)
+		assert.True(t, settings.Bool(SettingNotify))
+		assert.Equal(t, 90*time.Second, settings.Seconds(SettingNotifyAfter))
+
+		t.Setenv("REACTOR_NOTIFY_AFTER", "soon")
+		_, err = ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid value 'soon' for notifyAfter from REACTOR_NOTIFY_AFTER: expected a whole number of seconds")
+	})
+
+	t.Run("CredentialScope", func(t *testing.T) {
+		settings, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.Equal(t, CredentialScopeProject, settings.Get(SettingCredentialScope))
+
+		writeAccountDefaults(t, "shared", `{"credentialScope": "account"}`)
+		settings, err = ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", map[string]string{SettingAccount: "shared"})
+		require.NoError(t, err)
+		assert.Equal(t, SourceAccount, mustLookup(t, settings, SettingCredentialScope).Source)
+		assert.Equal(t, CredentialScopeAccount, settings.Get(SettingCredentialScope))
+
+		project := &DevContainerConfig{Customizations: &Customizations{Reactor: &ReactorCustomizations{CredentialScope: "global"}}}
+		_, err = ResolveSettings(project, "/p/.devcontainer.json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid value 'global' for credentialScope from /p/.devcontainer.json: expected project or account")
+	})
+
+	t.Run("CredentialEncryption", func(t *testing.T) {
+		settings, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.Equal(t, CredentialEncryptionOff, settings.Get(SettingCredentialEncryption))
+
+		writeAccountDefaults(t, "sealed", `{"credentialEncryption": "keychain"}`)
+		settings, err = ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", map[string]string{SettingAccount: "sealed"})
+		require.NoError(t, err)
+		assert.Equal(t, CredentialEncryptionKeychain, settings.Get(SettingCredentialEncryption))
+
+		t.Setenv("REACTOR_CREDENTIAL_ENCRYPTION", "rot13")
+		_, err = ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid value 'rot13' for credentialEncryption from REACTOR_CREDENTIAL_ENCRYPTION: expected off, age or keychain")
+	})
+
+	t.Run("ShellHistory", func(t *testing.T) {
+		settings, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.True(t, settings.Bool(SettingShellHistory))
+
+		disabled := false
+		project := &DevContainerConfig{Customizations: &Customizations{Reactor: &ReactorCustomizations{ShellHistory: &disabled}}}
+		settings, err = ResolveSettings(project, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.False(t, settings.Bool(SettingShellHistory))
+
+		t.Setenv("REACTOR_SHELL_HISTORY", "true")
+		settings, err = ResolveSettings(project, "/p/.devcontainer.json", nil)
+		require.NoError(t, err)
+		assert.True(t, settings.Bool(SettingShellHistory))
+	})
+
+	t.Run("UnknownFlagSetting", func(t *testing.T) {
+		_, err := ResolveSettings(devConfig, "/p/.devcontainer.json", map[string]string{"colour": "blue"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown setting 'colour'")
+	})
+
+	t.Run("AccountCannotBeSetInAccountDefaults", func(t *testing.T) {
+		writeAccountDefaults(t, "broken", `{"account": "other"}`)
+		_, err := ResolveSettings(&DevContainerConfig{}, "/p/.devcontainer.json", map[string]string{SettingAccount: "broken"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "setting 'account' cannot be set in account defaults")
+	})
+}
+
+func TestServiceResolveConfiguration_AccountOverrideRecomputesPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv("REACTOR_ACCOUNT", "")
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".devcontainer.json"), []byte(`{
+		"image": "alpine:latest",
+		"customizations": {"reactor": {"account": "team"}}
+	}`), 0644))
+
+	service := NewServiceWithRoot(tmpDir)
+	service.SetOverrides(map[string]string{SettingAccount: "personal"})
+	resolved, err := service.ResolveConfiguration()
+	require.NoError(t, err)
+
+	assert.Equal(t, "personal", resolved.Account)
+	assert.Equal(t, filepath.Join(home, ".reactor", "personal"), resolved.AccountConfigDir)
+	assert.Equal(t, filepath.Join(home, ".reactor", "personal", resolved.ProjectHash), resolved.ProjectConfigDir)
+}
+
+func TestResolvedConfig_ProviderConfigDir(t *testing.T) {
+	resolved := &ResolvedConfig{
+		AccountConfigDir: "/home/u/.reactor/work",
+		ProjectConfigDir: "/home/u/.reactor/work/abc123",
+	}
+	assert.Equal(t, "/home/u/.reactor/work/abc123", resolved.ProviderConfigDir())
+
+	resolved.CredentialScope = CredentialScopeAccount
+	assert.Equal(t, "/home/u/.reactor/work/shared", resolved.ProviderConfigDir())
+}
+
+func mustLookup(t *testing.T, settings *Settings, key string) SettingValue {
+	t.Helper()
+	value, ok := settings.Lookup(key)
+	require.True(t, ok, "setting %s not resolved", key)
+	return value
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Distro families a tool can be installed on, detected from the package manager
+const (
+	DistroDebian = "debian" // apt-get: Debian, Ubuntu
+	DistroAlpine = "alpine" // apk
+	DistroFedora = "fedora" // dnf: Fedora, RHEL, Rocky, Alma
+)
+
+// Tool is a utility customizations.reactor.tools can install in a container
+type Tool struct {
+	Command string            // executable whose presence means the tool is installed
+	Install map[string]string // shell snippet run as root per distro family
+}
+
+// packageTool is a tool packaged under the same name by every distro family
+func packageTool(command, pkg string) Tool {
+	return Tool{Command: command, Install: map[string]string{
+		DistroDebian: "apt_install " + pkg,
+		DistroAlpine: "apk add --no-cache " + pkg,
+		DistroFedora: "dnf install -y " + pkg,
+	}}
+}
+
+// ToolManifest lists the tools customizations.reactor.tools accepts
+var ToolManifest = map[string]Tool{
+	"curl":    packageTool("curl", "curl"),
+	"fzf":     packageTool("fzf", "fzf"),
+	"git":     packageTool("git", "git"),
+	"htop":    packageTool("htop", "htop"),
+	"jq":      packageTool("jq", "jq"),
+	"less":    packageTool("less", "less"),
+	"make":    packageTool("make", "make"),
+	"ripgrep": packageTool("rg", "ripgrep"),
+	"tmux":    packageTool("tmux", "tmux"),
+	"unzip":   packageTool("unzip", "unzip"),
+	"vim":     packageTool("vim", "vim"),
+	"fd": {Command: "fd", Install: map[string]string{
+		// Debian names the binary fdfind, as fd is taken by another package
+		DistroDebian: `apt_install fd-find && ln -sf "$(command -v fdfind)" /usr/local/bin/fd`,
+		DistroAlpine: "apk add --no-cache fd",
+		DistroFedora: "dnf install -y fd-find",
+	}},
+	"gh": {Command: "gh", Install: map[string]string{
+		// Debian and Ubuntu only package gh from GitHub's own repository
+		DistroDebian: `apt_install curl ca-certificates && ` +
+			`curl -fsSL https://cli.github.com/packages/githubcli-archive-keyring.gpg -o /usr/share/keyrings/githubcli-archive-keyring.gpg && ` +
+			`echo "deb [arch=$(dpkg --print-architecture) signed-by=/usr/share/keyrings/githubcli-archive-keyring.gpg] https://cli.github.com/packages stable main" > /etc/apt/sources.list.d/github-cli.list && ` +
+			`apt_updated= && apt_install gh`,
+		DistroAlpine: "apk add --no-cache github-cli",
+		DistroFedora: "dnf install -y gh",
+	}},
+}
+
+// ToolNames returns the names of the tools in the manifest, sorted
+func ToolNames() []string {
+	names := make([]string, 0, len(ToolManifest))
+	for name := range ToolManifest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTools validates customizations.reactor.tools: names from ToolManifest
+func parseTools(specs []string) ([]string, error) {
+	tools := make([]string, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for i, spec := range specs {
+		name := strings.ToLower(strings.TrimSpace(spec))
+		if _, ok := ToolManifest[name]; !ok {
+			return nil, fmt.Errorf("invalid customizations.reactor.tools[%d]: unknown tool '%s'; available tools: %s", i, spec, strings.Join(ToolNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid customizations.reactor.tools[%d]: '%s' is listed twice", i, spec)
+		}
+		seen[name] = true
+		tools = append(tools, name)
+	}
+	return tools, nil
+}
+
+// toolScriptPrelude detects the distro family from its package manager. apt_install
+// updates the package index once, and only when a tool is missing.
+const toolScriptPrelude = `set -e
+if command -v apt-get >/dev/null 2>&1; then distro=debian
+elif command -v apk >/dev/null 2>&1; then distro=alpine
+elif command -v dnf >/dev/null 2>&1; then distro=fedora
+else echo "no supported package manager (apt-get, apk or dnf) to install tools with" >&2; exit 1
+fi
+export DEBIAN_FRONTEND=noninteractive
+apt_updated=
+apt_install() {
+  if [ -z "$apt_updated" ]; then apt-get update -qq; apt_updated=1; fi
+  apt-get install -y -qq --no-install-recommends "$@"
+}
+`
+
+// ToolInstallScript returns a shell script, run as root, that installs the tools
+// missing from the container with the snippets for its distro family. Tools already
+// installed are skipped, so the script can run again.
+func ToolInstallScript(tools []string) string {
+	var script strings.Builder
+	script.WriteString(toolScriptPrelude)
+	for _, name := range tools {
+		tool := ToolManifest[name]
+		fmt.Fprintf(&script, "if ! command -v %s >/dev/null 2>&1; then\n  echo \"Installing %s\"\n  case $distro in\n", tool.Command, name)
+		for _, distro := range []string{DistroDebian, DistroAlpine, DistroFedora} {
+			if snippet, ok := tool.Install[distro]; ok {
+				fmt.Fprintf(&script, "  %s) %s ;;\n", distro, snippet)
+			}
+		}
+		fmt.Fprintf(&script, "  *) echo \"%s cannot be installed on $distro\" >&2; exit 1 ;;\n  esac\n", name)
+		// set -e does not stop a snippet's && chain, so check the tool arrived
+		fmt.Fprintf(&script, "  command -v %s >/dev/null 2>&1 || { echo \"failed to install %s\" >&2; exit 1; }\nfi\n", tool.Command, name)
+	}
+	return script.String()
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTools(t *testing.T) {
+	tools, err := parseTools([]string{"ripgrep", " FZF ", "gh"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ripgrep", "fzf", "gh"}, tools)
+
+	_, err = parseTools([]string{"ripgrep", "emacs"})
+	assert.ErrorContains(t, err, "invalid customizations.reactor.tools[1]: unknown tool 'emacs'; available tools: curl, fd, fzf")
+
+	_, err = parseTools([]string{"jq", "jq"})
+	assert.ErrorContains(t, err, "'jq' is listed twice")
+}
+
+func TestToolManifest_CoversEveryDistro(t *testing.T) {
+	for name, tool := range ToolManifest {
+		assert.NotEmpty(t, tool.Command, name)
+		for _, distro := range []string{DistroDebian, DistroAlpine, DistroFedora} {
+			assert.NotEmpty(t, tool.Install[distro], "%s on %s", name, distro)
+		}
+	}
+}
+
+func TestToolInstallScript(t *testing.T) {
+	// A fake apt-get that records its arguments and "installs" each package as an executable
+	bin := t.TempDir()
+	log := filepath.Join(t.TempDir(), "apt.log")
+	aptGet := "#!/bin/sh\necho \"$*\" >> " + log + "\nfor a; do case $a in -*|update|install) ;; *) echo '#!/bin/sh' > " + bin + "/$a; /bin/chmod +x " + bin + "/$a ;; esac; done\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "apt-get"), []byte(aptGet), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "rg"), []byte("#!/bin/sh\n"), 0755))
+
+	cmd := exec.Command("/bin/sh", "-c", ToolInstallScript([]string{"ripgrep", "jq", "make"}))
+	cmd.Env = []string{"PATH=" + bin}
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.Equal(t, "Installing jq\nInstalling make\n", string(output))
+
+	// ripgrep was already installed, and the index is updated once
+	calls, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, "update -qq\ninstall -y -qq --no-install-recommends jq\ninstall -y -qq --no-install-recommends make\n", string(calls))
+
+	// Nothing is left to install on a second run
+	require.NoError(t, os.Remove(log))
+	cmd = exec.Command("/bin/sh", "-c", ToolInstallScript([]string{"ripgrep", "jq", "make"}))
+	cmd.Env = []string{"PATH=" + bin}
+	output, err = cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.Empty(t, string(output))
+	assert.NoFileExists(t, log)
+}
+
+func TestToolInstallScript_NoPackageManager(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", ToolInstallScript([]string{"jq"}))
+	cmd.Env = []string{"PATH=" + t.TempDir()}
+	output, err := cmd.CombinedOutput()
+	assert.Error(t, err)
+	assert.Contains(t, string(output), "no supported package manager")
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -11,6 +12,22 @@ import (
 	"github.com/dyluth/reactor/pkg/docker"
 )
 
+// EntrypointPath is where a reactor-injected entrypoint script is mounted in the container
+const EntrypointPath = "/usr/local/share/reactor/entrypoint"
+
+// HistoryMountPath is where the project's history directory is mounted in the container
+const HistoryMountPath = "/reactor-history"
+
+// HistoryEnvironment points shells and REPLs at files in the history mount. HISTFILE
+// covers bash, zsh and ash; PYTHON_HISTORY needs Python 3.13 or later.
+func HistoryEnvironment() []string {
+	return []string{
+		"HISTFILE=" + HistoryMountPath + "/shell_history",
+		"NODE_REPL_HISTORY=" + HistoryMountPath + "/node_repl_history",
+		"PYTHON_HISTORY=" + HistoryMountPath + "/python_history",
+	}
+}
+
 // PortMapping represents a port forwarding configuration
 type PortMapping struct {
 	HostPort      int
@@ -19,15 +36,27 @@ type PortMapping struct {
 
 // ContainerBlueprint defines the complete specification for creating a container
 type ContainerBlueprint struct {
-	Name         string        // Deterministic container name with isolation support
-	Image        string        // Resolved container image
-	Command      []string      // Command to run in container
-	WorkDir      string        // Working directory in container
-	User         string        // Container user (e.g., "claude")
-	Environment  []string      // Environment variables
-	Mounts       []string      // Volume mounts in "source:target:type" format
-	PortMappings []PortMapping // Port forwarding configurations
-	NetworkMode  string        // Network configuration
+	Name         string            // Deterministic container name with isolation support
+	Image        string            // Resolved container image
+	Entrypoint   []string          // Entrypoint override (reactor-injected script)
+	Command      []string          // Command to run in container
+	Init         bool              // Run an init process as PID 1
+	OpenStdin    bool              // Keep stdin open so the image's own command stays alive
+	DiskLimit    string            // Writable layer size limit, e.g. "20g"
+	WorkDir      string            // Working directory in container
+	User         string            // Container user (e.g., "claude")
+	Environment  []string          // Environment variables
+	Mounts       []string          // Volume mounts in "source:target:type" format
+	ExtraMounts  []docker.Mount    // Mounts from reactor customizations, with their options
+	Tmpfs        map[string]string // In-memory mounts by container path, with mount options
+	PortMappings []PortMapping     // Port forwarding configurations
+	NetworkMode  string            // Network configuration
+	DNS          []string          // DNS servers from the dns setting
+	DNSSearch    []string          // DNS search domains from the dnsSearch setting
+	ExtraHosts   []string          // /etc/hosts entries from the extraHosts setting
+	Privileged   bool              // Run in privileged mode
+	CapAdd       []string          // Linux capabilities to add
+	SecurityOpt  []string          // Security options such as "seccomp=unconfined"
 }
 
 // NewContainerBlueprint creates a container blueprint from resolved configuration
@@ -49,10 +78,15 @@ func NewContainerBlueprint(resolved *config.ResolvedConfig, isDiscovery bool, do
 		// 2. Add provider credential mounts for ALL providers
 		for _, provider := range config.BuiltinProviders {
 			for _, mount := range provider.Mounts {
-				hostPath := filepath.Join(resolved.ProjectConfigDir, mount.Source)
+				hostPath := filepath.Join(resolved.ProviderConfigDir(), mount.Source)
 				dockerMounts = append(dockerMounts, formatDockerMount(hostPath, mount.Target))
 			}
 		}
+
+		// 3. Keep shell and REPL history across container recreations
+		if resolved.ShellHistory {
+			dockerMounts = append(dockerMounts, formatDockerMount(filepath.Join(resolved.ProjectConfigDir, config.HistoryDirName), HistoryMountPath))
+		}
 	}
 
 	// Add Docker socket mount if host integration is enabled
@@ -60,11 +94,36 @@ func NewContainerBlueprint(resolved *config.ResolvedConfig, isDiscovery bool, do
 		dockerMounts = append(dockerMounts, formatDockerMount("/var/run/docker.sock", "/var/run/docker.sock"))
 	}
 
+	// Mount the entrypoint script read-only and run the container command through it
+	var entrypoint []string
+	if resolved.Entrypoint != "" {
+		dockerMounts = append(dockerMounts, formatReadOnlyDockerMount(resolved.Entrypoint, EntrypointPath))
+		entrypoint = []string{EntrypointPath}
+	}
+
+	// Configured mounts; discovery mode keeps only the tmpfs ones, which never touch the host
+	var extraMounts []docker.Mount
+	for _, m := range resolved.Mounts {
+		if isDiscovery && m.Type != config.MountTypeTmpfs {
+			continue
+		}
+		extraMounts = append(extraMounts, blueprintMount(m))
+	}
+
+	// Masked paths of the workspace live in volumes, so dependency directories stay
+	// inside Docker while the source is bind mounted
+	if !isDiscovery {
+		extraMounts = append(extraMounts, MaskMounts(resolved)...)
+	}
+
 	// Set up environment variables
 	environment := []string{}
 	if dockerHostIntegration {
 		environment = append(environment, "REACTOR_DOCKER_HOST_INTEGRATION=true")
 	}
+	if !isDiscovery && resolved.ShellHistory {
+		environment = append(environment, HistoryEnvironment()...)
+	}
 
 	// Determine container user: use RemoteUser from devcontainer.json or default to "claude"
 	user := resolved.RemoteUser
@@ -79,16 +138,87 @@ func NewContainerBlueprint(resolved *config.ResolvedConfig, isDiscovery bool, do
 		command = []string{"/bin/sh", "-c", resolved.DefaultCommand}
 	}
 
+	// With overrideCommand false the image's CMD/ENTRYPOINT runs as-is; stdin is kept
+	// open so images whose default command is a shell do not exit straight away
+	openStdin := false
+	if resolved.UseImageCommand {
+		command = nil
+		openStdin = true
+	}
+
 	return &ContainerBlueprint{
 		Name:         containerName,
 		Image:        resolved.Image,
+		Entrypoint:   entrypoint,
 		Command:      command,
+		Init:         resolved.Init,
+		OpenStdin:    openStdin,
+		DiskLimit:    resolved.DiskLimit,
 		WorkDir:      "/workspace", // Default to mounted project directory
 		User:         user,         // Use remoteUser from devcontainer.json with fallback
 		Environment:  environment,
 		Mounts:       dockerMounts,
+		ExtraMounts:  extraMounts,
 		PortMappings: portMappings,
 		NetworkMode:  "bridge", // Default Docker network
+		DNS:          resolved.DNS,
+		DNSSearch:    resolved.DNSSearch,
+		ExtraHosts:   resolved.ExtraHosts,
+		Privileged:   resolved.Privileged,
+		CapAdd:       resolved.CapAdd,
+		SecurityOpt:  resolved.SecurityOpt,
+	}
+}
+
+// UseOverlayWorkspace replaces the workspace bind mount with a named overlay volume.
+// The host project becomes the read-only lower layer and agent writes are captured separately.
+func (b *ContainerBlueprint) UseOverlayWorkspace(volumeName string) {
+	b.UseVolumeWorkspace(volumeName)
+}
+
+// UseVolumeWorkspace replaces the workspace bind mount with a named volume, such as one
+// holding a repository cloned inside the container
+func (b *ContainerBlueprint) UseVolumeWorkspace(volumeName string) {
+	for i, mount := range b.Mounts {
+		if strings.HasSuffix(strings.Trim(mount, `"`), ":/workspace") {
+			b.Mounts[i] = formatDockerMount(volumeName, "/workspace")
+			return
+		}
+	}
+	b.Mounts = append([]string{formatDockerMount(volumeName, "/workspace")}, b.Mounts...)
+}
+
+// MountWorkspace bind mounts the project at /workspace, for containers created without
+// the other mounts of discovery mode
+func (b *ContainerBlueprint) MountWorkspace(projectRoot string) {
+	b.Mounts = append([]string{formatDockerMount(projectRoot, "/workspace")}, b.Mounts...)
+}
+
+// UseTmpfsCredentials replaces the provider directory bind mounts with in-memory mounts,
+// so decrypted credentials never touch the host disk. reactor copies them in after start.
+func (b *ContainerBlueprint) UseTmpfsCredentials() {
+	targets := make(map[string]bool)
+	for _, provider := range config.BuiltinProviders {
+		for _, mount := range provider.Mounts {
+			targets[mount.Target] = true
+		}
+	}
+
+	mounts := b.Mounts[:0]
+	for _, mount := range b.Mounts {
+		parts := strings.Split(strings.Trim(mount, `"`), ":")
+		if len(parts) >= 2 && targets[parts[1]] {
+			continue
+		}
+		mounts = append(mounts, mount)
+	}
+	b.Mounts = mounts
+
+	if b.Tmpfs == nil {
+		b.Tmpfs = make(map[string]string)
+	}
+	for target := range targets {
+		b.Tmpfs[target] = "rw,nosuid,nodev,mode=1777"
 	}
 }
 
@@ -106,13 +236,25 @@ func (b *ContainerBlueprint) ToContainerSpec() *docker.ContainerSpec {
 	return &docker.ContainerSpec{
 		Name:         b.Name,
 		Image:        b.Image,
+		Entrypoint:   b.Entrypoint,
 		Command:      b.Command,
+		Init:         b.Init,
+		OpenStdin:    b.OpenStdin,
+		DiskLimit:    b.DiskLimit,
 		WorkDir:      b.WorkDir,
 		User:         b.User,
 		Environment:  b.Environment,
 		Mounts:       b.Mounts,
+		ExtraMounts:  b.ExtraMounts,
+		Tmpfs:        b.Tmpfs,
 		PortMappings: dockerPortMappings,
 		NetworkMode:  b.NetworkMode,
+		DNS:          b.DNS,
+		DNSSearch:    b.DNSSearch,
+		ExtraHosts:   b.ExtraHosts,
+		Privileged:   b.Privileged,
+		CapAdd:       b.CapAdd,
+		SecurityOpt:  b.SecurityOpt,
 	}
 }
 
@@ -142,6 +284,84 @@ func GenerateDiscoveryContainerName(account, projectPath, projectHash string) st
 	return baseName
 }
 
+// GenerateEphemeralContainerName creates the name of a throwaway container; suffix keeps
+// several of them for the same project apart
+func GenerateEphemeralContainerName(projectPath, projectHash, suffix string) string {
+	baseName := fmt.Sprintf("reactor-ephemeral-%s-%s-%s", sanitizeContainerName(filepath.Base(projectPath)), projectHash, suffix)
+	if prefix := os.Getenv("REACTOR_ISOLATION_PREFIX"); prefix != "" {
+		return fmt.Sprintf("%s-%s", prefix, baseName)
+	}
+	return baseName
+}
+
+// SessionLabel records the session name on containers started with 'reactor up --name'
+const SessionLabel = "com.reactor.session"
+
+// sessionNamePattern restricts session names to characters valid in container names
+var sessionNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// maxSessionNameLength keeps session container names within a reasonable length
+const maxSessionNameLength = 32
+
+// ValidateSessionName checks that a session name can be embedded in a container name
+func ValidateSessionName(name string) error {
+	if len(name) > maxSessionNameLength {
+		return fmt.Errorf("session name %q is too long (maximum %d characters)", name, maxSessionNameLength)
+	}
+	if !sessionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid session name %q: use lowercase letters, digits, '.', '_' or '-', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// SessionContainerName appends a session name to a project container name so several
+// sessions can run against the same project. An empty session returns the name unchanged.
+func SessionContainerName(containerName, session string) string {
+	if session == "" {
+		return containerName
+	}
+	return fmt.Sprintf("%s-%s", containerName, session)
+}
+
+// SessionConfigDir returns the directory that holds the state of one session of a
+// project, such as its read-only workspace overlay and port forwards: the project
+// config directory itself for the default session, a directory of its own otherwise
+func SessionConfigDir(projectConfigDir, session string) string {
+	if session == "" {
+		return projectConfigDir
+	}
+	return filepath.Join(projectConfigDir, "sessions", session)
+}
+
+// ParseContainerName splits a container name generated for a project, optionally with a
+// session, into its account and session. ok is false when the name was not generated
+// for the project.
+func ParseContainerName(name, projectPath, projectHash string) (account, session string, ok bool) {
+	prefix := "reactor-"
+	if isolationPrefix := os.Getenv("REACTOR_ISOLATION_PREFIX"); isolationPrefix != "" {
+		prefix = isolationPrefix + "-reactor-"
+	}
+	rest, found := strings.CutPrefix(name, prefix)
+	if !found {
+		return "", "", false
+	}
+
+	suffix := "-" + sanitizeContainerName(filepath.Base(projectPath)) + "-" + projectHash
+	i := strings.Index(rest, suffix)
+	if i <= 0 {
+		return "", "", false
+	}
+	account, rest = rest[:i], rest[i+len(suffix):]
+	if rest == "" {
+		return account, "", true
+	}
+	session, found = strings.CutPrefix(rest, "-")
+	if !found || session == "" {
+		return "", "", false
+	}
+	return account, session, true
+}
+
 // sanitizeContainerName ensures the folder name is safe for use in container names
 func sanitizeContainerName(name string) string {
 	// Docker container names must match: [a-zA-Z0-9][a-zA-Z0-9_.-]*
@@ -170,6 +390,35 @@ func sanitizeContainerName(name string) string {
 	return sanitized
 }
 
+// blueprintMount converts a configured mount to its Docker form
+func blueprintMount(m config.Mount) docker.Mount {
+	converted := docker.Mount{
+		Type:        m.Type,
+		Source:      m.Source,
+		Target:      m.Target,
+		ReadOnly:    m.ReadOnly,
+		Consistency: m.Consistency,
+	}
+	if m.Size != "" {
+		// The size was validated when the configuration was resolved
+		converted.TmpfsSize, _ = config.ParseDiskLimit(m.Size)
+	}
+	return converted
+}
+
+// MaskMounts returns the volume mounts covering the project's masked paths
+func MaskMounts(resolved *config.ResolvedConfig) []docker.Mount {
+	var mounts []docker.Mount
+	for _, maskPath := range resolved.MaskPaths {
+		mounts = append(mounts, docker.Mount{
+			Type:   config.MountTypeVolume,
+			Source: config.MaskVolumeName(resolved.ProjectHash, maskPath),
+			Target: path.Join("/workspace", maskPath),
+		})
+	}
+	return mounts
+}
+
 // formatDockerMount creates a properly formatted Docker bind mount string
 // that handles paths with spaces and special characters
 func formatDockerMount(hostPath, containerPath string) string {
@@ -181,6 +430,14 @@ func formatDockerMount(hostPath, containerPath string) string {
 	return fmt.Sprintf("%s:%s", hostPath, containerPath)
 }
 
+// formatReadOnlyDockerMount formats a read-only bind mount, quoting like formatDockerMount
+func formatReadOnlyDockerMount(hostPath, containerPath string) string {
+	if needsQuoting(hostPath) || needsQuoting(containerPath) {
+		return fmt.Sprintf(`"%s:%s:ro"`, hostPath, containerPath)
+	}
+	return fmt.Sprintf("%s:%s:ro", hostPath, containerPath)
+}
+
 // needsQuoting checks if a path contains characters that require quoting
 func needsQuoting(path string) bool {
 	// Check for spaces and other characters that can cause parsing issues
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
 	"github.com/dyluth/reactor/pkg/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -160,6 +161,47 @@ func TestGenerateContainerName(t *testing.T) {
 	}
 }
 
+func TestSessionNames(t *testing.T) {
+	assert.NoError(t, ValidateSessionName("feature-x"))
+	assert.NoError(t, ValidateSessionName("fix_1.2"))
+	assert.Error(t, ValidateSessionName(""))
+	assert.Error(t, ValidateSessionName("Feature"))
+	assert.Error(t, ValidateSessionName("-leading"))
+	assert.Error(t, ValidateSessionName("with space"))
+	assert.Error(t, ValidateSessionName("a-very-long-session-name-that-exceeds-the-limit"))
+
+	assert.Equal(t, "reactor-cam-myproject-abc123", SessionContainerName("reactor-cam-myproject-abc123", ""))
+	assert.Equal(t, "reactor-cam-myproject-abc123-feature-x", SessionContainerName("reactor-cam-myproject-abc123", "feature-x"))
+
+	// Each named session keeps its overlay and port forwards apart from the default one
+	assert.Equal(t, "/home/cam/.reactor/cam/abc123", SessionConfigDir("/home/cam/.reactor/cam/abc123", ""))
+	assert.Equal(t, filepath.Join("/home/cam/.reactor/cam/abc123", "sessions", "feature-x"), SessionConfigDir("/home/cam/.reactor/cam/abc123", "feature-x"))
+}
+
+func TestParseContainerName(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+
+	account, session, ok := ParseContainerName("reactor-cam-my-project-abc123", "/src/my project", "abc123")
+	assert.True(t, ok)
+	assert.Equal(t, "cam", account)
+	assert.Empty(t, session)
+
+	account, session, ok = ParseContainerName("reactor-work-cam-myproject-abc123-feature-x", "/src/myproject", "abc123")
+	assert.True(t, ok)
+	assert.Equal(t, "work-cam", account)
+	assert.Equal(t, "feature-x", session)
+
+	for _, name := range []string{"reactor-cam-other-abc123", "reactor-cam-myproject-abc1234", "reactor-myproject-abc123", "db-cam-myproject-abc123"} {
+		_, _, ok := ParseContainerName(name, "/src/myproject", "abc123")
+		assert.False(t, ok, name)
+	}
+
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "ci")
+	account, _, ok = ParseContainerName("ci-reactor-cam-myproject-abc123", "/src/myproject", "abc123")
+	assert.True(t, ok)
+	assert.Equal(t, "cam", account)
+}
+
 func TestGenerateDiscoveryContainerName(t *testing.T) {
 	testutil.WithIsolatedHome(t)
 
@@ -339,6 +381,78 @@ func TestNewContainerBlueprint(t *testing.T) {
 	}
 }
 
+func TestNewContainerBlueprint_AccountCredentialScope(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		ProjectRoot:      "/home/user/testproject",
+		ProjectHash:      "testhash123",
+		AccountConfigDir: "/home/.reactor/testuser",
+		ProjectConfigDir: "/home/.reactor/testuser/testhash123",
+		CredentialScope:  config.CredentialScopeAccount,
+		Image:            "test-image:latest",
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, nil)
+	assert.Contains(t, blueprint.Mounts, "/home/.reactor/testuser/shared/claude:/home/claude/.claude")
+	assert.Contains(t, blueprint.Mounts, "/home/.reactor/testuser/shared/gemini:/home/claude/.gemini")
+}
+
+func TestContainerBlueprint_UseTmpfsCredentials(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		ProjectRoot:      "/home/user/testproject",
+		ProjectHash:      "testhash123",
+		ProjectConfigDir: "/home/.reactor/testuser/testhash123",
+		Image:            "test-image:latest",
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, nil)
+	blueprint.UseTmpfsCredentials()
+	assert.Equal(t, []string{"/home/user/testproject:/workspace"}, blueprint.Mounts)
+
+	spec := blueprint.ToContainerSpec()
+	assert.Equal(t, map[string]string{
+		"/home/claude/.claude": "rw,nosuid,nodev,mode=1777",
+		"/home/claude/.gemini": "rw,nosuid,nodev,mode=1777",
+	}, spec.Tmpfs)
+}
+
+func TestContainerBlueprint_ShellHistory(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		ProjectRoot:      "/home/user/testproject",
+		ProjectHash:      "testhash123",
+		ProjectConfigDir: "/home/.reactor/testuser/testhash123",
+		Image:            "test-image:latest",
+		ShellHistory:     true,
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, nil)
+	assert.Contains(t, blueprint.Mounts, "/home/.reactor/testuser/testhash123/history:/reactor-history")
+	assert.Contains(t, blueprint.Environment, "HISTFILE=/reactor-history/shell_history")
+	assert.Contains(t, blueprint.Environment, "NODE_REPL_HISTORY=/reactor-history/node_repl_history")
+
+	// Tmpfs credentials replace the provider mounts only
+	blueprint.UseTmpfsCredentials()
+	assert.Contains(t, blueprint.Mounts, "/home/.reactor/testuser/testhash123/history:/reactor-history")
+
+	// Discovery mode has no mounts to keep history in
+	blueprint = NewContainerBlueprint(resolved, true, false, nil)
+	assert.NotContains(t, blueprint.Mounts, "/home/.reactor/testuser/testhash123/history:/reactor-history")
+	assert.NotContains(t, blueprint.Environment, "HISTFILE=/reactor-history/shell_history")
+
+	resolved.ShellHistory = false
+	blueprint = NewContainerBlueprint(resolved, false, false, nil)
+	assert.NotContains(t, blueprint.Mounts, "/home/.reactor/testuser/testhash123/history:/reactor-history")
+	assert.NotContains(t, blueprint.Environment, "HISTFILE=/reactor-history/shell_history")
+}
+
 func TestContainerBlueprintToContainerSpec(t *testing.T) {
 	portMappings := []PortMapping{
 		{HostPort: 8080, ContainerPort: 80},
@@ -577,6 +691,51 @@ func TestNewContainerBlueprint_MultiProviderMounts(t *testing.T) {
 	}
 }
 
+func TestContainerBlueprint_UseOverlayWorkspace(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		Image:            "test-image",
+		ProjectRoot:      "/home/user/myproject",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/.reactor/testuser/abc123",
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, []PortMapping{})
+	mountCount := len(blueprint.Mounts)
+
+	blueprint.UseOverlayWorkspace("reactor-testuser-myproject-abc123-overlay")
+
+	assert.Len(t, blueprint.Mounts, mountCount, "Overlay should replace the workspace mount, not add one")
+	assert.Equal(t, "reactor-testuser-myproject-abc123-overlay:/workspace", blueprint.Mounts[0])
+	assert.NotContains(t, blueprint.Mounts, "/home/user/myproject:/workspace")
+}
+
+func TestContainerBlueprint_MountWorkspace(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:     "testuser",
+		Image:       "test-image",
+		ProjectRoot: "/home/user/myproject",
+		ProjectHash: "abc123",
+	}
+
+	blueprint := NewContainerBlueprint(resolved, true, false, []PortMapping{})
+	blueprint.MountWorkspace(resolved.ProjectRoot)
+
+	assert.Equal(t, []string{"/home/user/myproject:/workspace"}, blueprint.Mounts)
+}
+
+func TestGenerateEphemeralContainerName(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	assert.Equal(t, "reactor-ephemeral-my-project-abc123-f00d", GenerateEphemeralContainerName("/src/my project", "abc123", "f00d"))
+
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "ci")
+	assert.Equal(t, "ci-reactor-ephemeral-my-project-abc123-f00d", GenerateEphemeralContainerName("/src/my project", "abc123", "f00d"))
+}
+
 func TestNewContainerBlueprint_DiscoveryModeSkipsAllMounts(t *testing.T) {
 	testutil.WithIsolatedHome(t)
 
@@ -814,3 +973,119 @@ func TestNewContainerBlueprint_NestedMountPointIteration(t *testing.T) {
 		assert.Contains(t, blueprint.Mounts, expectedMount, "Should contain mount: %s", expectedMount)
 	}
 }
+
+func TestNewContainerBlueprint_InitAndEntrypoint(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		Image:            "test-image",
+		ProjectRoot:      "/home/user/myproject",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/.reactor/testuser/abc123",
+		Init:             true,
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, []PortMapping{})
+	assert.True(t, blueprint.Init)
+	assert.Empty(t, blueprint.Entrypoint)
+
+	resolved.Entrypoint = "/home/user/myproject/.devcontainer/entrypoint.sh"
+	blueprint = NewContainerBlueprint(resolved, false, false, []PortMapping{})
+	assert.Equal(t, []string{EntrypointPath}, blueprint.Entrypoint)
+	assert.Contains(t, blueprint.Mounts, "/home/user/myproject/.devcontainer/entrypoint.sh:"+EntrypointPath+":ro")
+
+	spec := blueprint.ToContainerSpec()
+	assert.True(t, spec.Init)
+	assert.Equal(t, []string{EntrypointPath}, spec.Entrypoint)
+}
+
+func TestNewContainerBlueprint_ExtraMounts(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		Image:            "test-image",
+		ProjectRoot:      "/home/user/myproject",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/.reactor/testuser/abc123",
+		Mounts: []config.Mount{
+			{Type: config.MountTypeTmpfs, Target: "/scratch", Size: "512m"},
+			{Type: config.MountTypeBind, Source: "/home/user/cache", Target: "/cache", ReadOnly: true, Consistency: "cached"},
+		},
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, []PortMapping{})
+	expected := []docker.Mount{
+		{Type: "tmpfs", Target: "/scratch", TmpfsSize: 512 * 1024 * 1024},
+		{Type: "bind", Source: "/home/user/cache", Target: "/cache", ReadOnly: true, Consistency: "cached"},
+	}
+	assert.Equal(t, expected, blueprint.ExtraMounts)
+	assert.Equal(t, expected, blueprint.ToContainerSpec().ExtraMounts)
+	assert.NotContains(t, blueprint.Mounts, "/home/user/cache:/cache", "configured mounts are not bind strings")
+
+	// Discovery mode keeps only the mounts that never touch the host
+	blueprint = NewContainerBlueprint(resolved, true, false, []PortMapping{})
+	assert.Equal(t, expected[:1], blueprint.ExtraMounts)
+}
+
+func TestNewContainerBlueprint_DNS(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		Image:            "test-image",
+		ProjectRoot:      "/home/user/myproject",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/.reactor/testuser/abc123",
+		DNS:              []string{"10.0.0.53"},
+		DNSSearch:        []string{"corp.example.com"},
+		ExtraHosts:       []string{"git.corp:10.0.0.7"},
+	}
+
+	spec := NewContainerBlueprint(resolved, false, false, []PortMapping{}).ToContainerSpec()
+	assert.Equal(t, []string{"10.0.0.53"}, spec.DNS)
+	assert.Equal(t, []string{"corp.example.com"}, spec.DNSSearch)
+	assert.Equal(t, []string{"git.corp:10.0.0.7"}, spec.ExtraHosts)
+}
+
+func TestNewContainerBlueprint_UseImageCommand(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		Image:            "postgres:16",
+		ProjectRoot:      "/home/user/myproject",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/.reactor/testuser/abc123",
+		UseImageCommand:  true,
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, []PortMapping{})
+	assert.Nil(t, blueprint.Command, "image CMD/ENTRYPOINT must not be replaced")
+	assert.Nil(t, blueprint.Entrypoint)
+	assert.True(t, blueprint.OpenStdin)
+	assert.True(t, blueprint.ToContainerSpec().OpenStdin)
+}
+
+func TestNewContainerBlueprint_MaskPaths(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "testuser",
+		Image:            "node:20",
+		ProjectRoot:      "/home/user/myproject",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/.reactor/testuser/abc123",
+		MaskPaths:        []string{"node_modules", "web/.next"},
+	}
+
+	blueprint := NewContainerBlueprint(resolved, false, false, []PortMapping{})
+	assert.Equal(t, []docker.Mount{
+		{Type: config.MountTypeVolume, Source: "reactor-mask-abc123-node_modules", Target: "/workspace/node_modules"},
+		{Type: config.MountTypeVolume, Source: "reactor-mask-abc123-web_.next", Target: "/workspace/web/.next"},
+	}, blueprint.ExtraMounts)
+
+	// Discovery mode does not mount the project, so there is nothing to mask
+	assert.Empty(t, NewContainerBlueprint(resolved, true, false, []PortMapping{}).ExtraMounts)
+}
@@ -0,0 +1,159 @@
+// Package debug writes diagnostic messages for the categories enabled with the
+// REACTOR_DEBUG environment variable (e.g. REACTOR_DEBUG=docker,config) or --verbose.
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvVar lists the enabled categories, comma-separated; "all" enables every one
+const EnvVar = "REACTOR_DEBUG"
+
+// Debug categories, one per package that writes diagnostics
+const (
+	Config       = "config"
+	Docker       = "docker"
+	Orchestrator = "orchestrator"
+	Workspace    = "workspace"
+)
+
+// All enables every category
+const All = "all"
+
+// Categories lists the known categories
+var Categories = []string{Config, Docker, Orchestrator, Workspace}
+
+var (
+	mu      sync.RWMutex
+	loaded  bool // REACTOR_DEBUG has been read
+	enabled = map[string]bool{}
+)
+
+// output receives the messages of enabled categories
+var output io.Writer = os.Stderr
+
+// Parse validates a comma-separated category list and returns the categories it
+// enables. "all", "1" and "true" enable every category.
+func Parse(spec string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case All, "1", "true":
+			return append([]string(nil), Categories...), nil
+		}
+		if !known(name) {
+			return nil, fmt.Errorf("unknown debug category '%s' in %s: must be one of %s or %s", name, EnvVar, strings.Join(Categories, ", "), All)
+		}
+		seen[name] = true
+	}
+
+	categories := make([]string, 0, len(seen))
+	for name := range seen {
+		categories = append(categories, name)
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+func known(name string) bool {
+	for _, category := range Categories {
+		if category == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Configure enables the categories named in REACTOR_DEBUG, and every category when
+// verbose is set. It returns an error for unknown categories.
+func Configure(verbose bool) error {
+	categories, err := Parse(os.Getenv(EnvVar))
+	if err != nil {
+		return err
+	}
+	if verbose {
+		categories = Categories
+	}
+	Enable(categories...)
+	return nil
+}
+
+// Enable turns on the given categories in addition to those already enabled
+func Enable(categories ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	loadLocked()
+	for _, category := range categories {
+		enabled[category] = true
+	}
+}
+
+// Reset turns every category off and restores output to stderr; tests use it
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	loaded = true
+	enabled = map[string]bool{}
+	output = os.Stderr
+}
+
+// SetOutput sends messages to w instead of stderr
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+}
+
+// Enabled reports whether messages of a category are written. Before Configure is
+// called, REACTOR_DEBUG is read on first use so packages used on their own honour it.
+func Enabled(category string) bool {
+	mu.RLock()
+	if loaded {
+		defer mu.RUnlock()
+		return enabled[category]
+	}
+	mu.RUnlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+	loadLocked()
+	return enabled[category]
+}
+
+// loadLocked reads REACTOR_DEBUG once, ignoring unknown categories; Configure reports them
+func loadLocked() {
+	if loaded {
+		return
+	}
+	loaded = true
+	for _, name := range strings.Split(os.Getenv(EnvVar), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == All || name == "1" || name == "true":
+			for _, category := range Categories {
+				enabled[category] = true
+			}
+		case known(name):
+			enabled[name] = true
+		}
+	}
+}
+
+// Logf writes a message prefixed with its category, e.g. "[debug:docker] ...", when
+// the category is enabled
+func Logf(category, format string, args ...interface{}) {
+	if !Enabled(category) {
+		return
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	_, _ = fmt.Fprintf(output, "[debug:%s] %s\n", category, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,60 @@
+package debug
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	categories, err := Parse("docker, Config,docker")
+	require.NoError(t, err)
+	assert.Equal(t, []string{Config, Docker}, categories)
+
+	categories, err = Parse("all")
+	require.NoError(t, err)
+	assert.Equal(t, Categories, categories)
+
+	categories, err = Parse("")
+	require.NoError(t, err)
+	assert.Empty(t, categories)
+
+	_, err = Parse("docker,network")
+	assert.EqualError(t, err, "unknown debug category 'network' in REACTOR_DEBUG: must be one of config, docker, orchestrator, workspace or all")
+}
+
+func TestLogf(t *testing.T) {
+	t.Cleanup(Reset)
+
+	t.Run("only enabled categories are written, with their prefix", func(t *testing.T) {
+		Reset()
+		var out bytes.Buffer
+		SetOutput(&out)
+		t.Setenv(EnvVar, "docker")
+		require.NoError(t, Configure(false))
+
+		Logf(Docker, "pulling %s", "alpine")
+		Logf(Config, "using %s", "devcontainer.json")
+
+		assert.Equal(t, "[debug:docker] pulling alpine\n", out.String())
+		assert.True(t, Enabled(Docker))
+		assert.False(t, Enabled(Config))
+	})
+
+	t.Run("verbose enables every category", func(t *testing.T) {
+		Reset()
+		t.Setenv(EnvVar, "")
+		require.NoError(t, Configure(true))
+		for _, category := range Categories {
+			assert.True(t, Enabled(category), category)
+		}
+	})
+
+	t.Run("unknown categories are an error", func(t *testing.T) {
+		Reset()
+		t.Setenv(EnvVar, "dockr")
+		assert.Error(t, Configure(false))
+	})
+}
@@ -0,0 +1,184 @@
+// Package devcontainer tracks how much of the Dev Container specification reactor
+// implements, so configurations can be checked for properties that would be
+// silently ignored at runtime.
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/jsonc"
+)
+
+// Support describes how reactor handles a devcontainer.json property
+type Support string
+
+const (
+	Supported   Support = "supported"   // Honoured at runtime
+	Ignored     Support = "ignored"     // Accepted but has no effect (e.g. editor metadata)
+	Unsupported Support = "unsupported" // Valid in the spec but not implemented by reactor
+	Unknown     Support = "unknown"     // Not part of the spec
+)
+
+// Property is a devcontainer.json property from the specification reference
+type Property struct {
+	Name    string
+	Support Support
+	Note    string
+}
+
+// properties is the property catalogue from https://containers.dev/implementors/json_reference/.
+// Nested properties use dotted names. Keep this in sync as reactor gains support for fields.
+var properties = []Property{
+	// General properties
+	{Name: "name", Support: Supported},
+	{Name: "$schema", Support: Ignored},
+	{Name: "forwardPorts", Support: Supported},
+	{Name: "portsAttributes", Support: Ignored, Note: "port labels and auto-forward behaviour are editor features"},
+	{Name: "otherPortsAttributes", Support: Ignored, Note: "port labels and auto-forward behaviour are editor features"},
+	{Name: "containerEnv", Support: Supported, Note: "${localEnv:VAR} references are expanded"},
+	{Name: "remoteEnv", Support: Unsupported},
+	{Name: "remoteUser", Support: Supported},
+	{Name: "containerUser", Support: Unsupported},
+	{Name: "updateRemoteUserUID", Support: Unsupported},
+	{Name: "userEnvProbe", Support: Unsupported},
+	{Name: "overrideCommand", Support: Supported},
+	{Name: "shutdownAction", Support: Unsupported},
+	{Name: "init", Support: Supported, Note: "reactor defaults to true"},
+	{Name: "privileged", Support: Supported, Note: "needs confirmation or 'reactor up --allow-privileged'"},
+	{Name: "capAdd", Support: Supported, Note: "needs confirmation or 'reactor up --allow-privileged'"},
+	{Name: "securityOpt", Support: Supported, Note: "needs confirmation or 'reactor up --allow-privileged', except no-new-privileges"},
+	{Name: "mounts", Support: Unsupported},
+	{Name: "features", Support: Unsupported},
+	{Name: "overrideFeatureInstallOrder", Support: Unsupported},
+	{Name: "customizations", Support: Supported, Note: "only customizations.reactor is read; other tools' settings are ignored"},
+	{Name: "hostRequirements", Support: Unsupported},
+	{Name: "secrets", Support: Ignored, Note: "secret recommendations are only shown by supporting tools"},
+
+	// Image or Dockerfile specific properties
+	{Name: "image", Support: Supported},
+	{Name: "build", Support: Supported},
+	{Name: "build.dockerfile", Support: Supported},
+	{Name: "build.context", Support: Supported},
+	{Name: "build.args", Support: Unsupported},
+	{Name: "build.options", Support: Unsupported},
+	{Name: "build.target", Support: Unsupported},
+	{Name: "build.cacheFrom", Support: Unsupported},
+	{Name: "appPort", Support: Unsupported, Note: "use forwardPorts instead"},
+	{Name: "workspaceMount", Support: Unsupported, Note: "the project is always mounted at /workspace"},
+	{Name: "workspaceFolder", Support: Unsupported, Note: "the project is always mounted at /workspace"},
+	{Name: "runArgs", Support: Unsupported},
+
+	// Docker Compose specific properties
+	{Name: "dockerComposeFile", Support: Unsupported},
+	{Name: "service", Support: Unsupported},
+	{Name: "runServices", Support: Unsupported},
+
+	// Lifecycle scripts
+	{Name: "initializeCommand", Support: Unsupported},
+	{Name: "onCreateCommand", Support: Unsupported},
+	{Name: "updateContentCommand", Support: Unsupported},
+	{Name: "postCreateCommand", Support: Supported, Note: "runs when the container is created; 'reactor up --rerun-hooks' runs a changed command again"},
+	{Name: "postStartCommand", Support: Supported, Note: "runs each time 'reactor up' creates or starts the container"},
+	{Name: "postAttachCommand", Support: Unsupported},
+	{Name: "waitFor", Support: Unsupported},
+}
+
+// Properties returns the property catalogue sorted by name
+func Properties() []Property {
+	sorted := append([]Property(nil), properties...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// Lookup returns the catalogue entry for a (possibly dotted) property name
+func Lookup(name string) (Property, bool) {
+	for _, p := range properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Finding reports a property used by a configuration and how reactor handles it
+type Finding struct {
+	Property string
+	Support  Support
+	Note     string
+}
+
+// CheckFile reports the support status of every property used in a devcontainer.json file
+func CheckFile(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read devcontainer file %s: %w", path, err)
+	}
+	findings, err := Check(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return findings, nil
+}
+
+// Check reports the support status of every property used in devcontainer.json content (JSONC allowed)
+func Check(data []byte) ([]Finding, error) {
+	standardJSON, err := jsonc.Standardize(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSONC: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(standardJSON, &raw); err != nil {
+		return nil, fmt.Errorf("devcontainer.json must contain a JSON object: %w", err)
+	}
+
+	var findings []Finding
+	for key, value := range raw {
+		findings = append(findings, findingFor(key))
+
+		// build is the only object whose nested properties vary in support
+		if key == "build" {
+			var build map[string]json.RawMessage
+			if err := json.Unmarshal(value, &build); err == nil {
+				for nested := range build {
+					findings = append(findings, findingFor("build."+nested))
+				}
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Property < findings[j].Property })
+	return findings, nil
+}
+
+func findingFor(name string) Finding {
+	if p, ok := Lookup(name); ok {
+		return Finding{Property: p.Name, Support: p.Support, Note: p.Note}
+	}
+	return Finding{Property: name, Support: Unknown, Note: "not part of the Dev Container specification"}
+}
+
+// Problems filters findings down to properties that will not take effect at runtime
+func Problems(findings []Finding) []Finding {
+	var problems []Finding
+	for _, f := range findings {
+		if f.Support == Unsupported || f.Support == Unknown {
+			problems = append(problems, f)
+		}
+	}
+	return problems
+}
+
+// String formats a finding for display
+func (f Finding) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)", f.Property, f.Support)
+	if f.Note != "" {
+		fmt.Fprintf(&b, ": %s", f.Note)
+	}
+	return b.String()
+}
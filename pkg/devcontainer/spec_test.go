@@ -0,0 +1,72 @@
+package devcontainer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSpecConformance runs every fixture in testdata/spec through the checker and
+// records which properties reactor does not honour. Run with -v for the full report.
+func TestSpecConformance(t *testing.T) {
+	expectedProblems := map[string][]string{
+		"image.jsonc":      nil,
+		"reactor.jsonc":    nil,
+		"dockerfile.jsonc": {"build.args", "build.target", "runArgs", "workspaceFolder"},
+		"compose.jsonc":    {"dockerComposeFile", "runServices", "service", "shutdownAction", "workspaceFolder"},
+		"features.jsonc":   {"features", "onCreateCommand", "remoteEnv"},
+		"typo.jsonc":       {"postCreateComand", "remoteUsr"},
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "spec", "*.jsonc"))
+	require.NoError(t, err)
+	require.Len(t, fixtures, len(expectedProblems), "every fixture needs an expectation")
+
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		t.Run(name, func(t *testing.T) {
+			findings, err := CheckFile(fixture)
+			require.NoError(t, err)
+
+			var report []string
+			for _, f := range findings {
+				report = append(report, f.String())
+			}
+			t.Logf("support report:\n  %s", strings.Join(report, "\n  "))
+
+			var problems []string
+			for _, f := range Problems(findings) {
+				problems = append(problems, f.Property)
+			}
+			assert.Equal(t, expectedProblems[name], problems)
+		})
+	}
+}
+
+func TestCatalogue(t *testing.T) {
+	seen := map[string]bool{}
+	for _, p := range Properties() {
+		assert.False(t, seen[p.Name], "duplicate property %s", p.Name)
+		seen[p.Name] = true
+		assert.NotEqual(t, Unknown, p.Support, "catalogue entries must have a known support level")
+	}
+
+	p, ok := Lookup("postCreateCommand")
+	assert.True(t, ok)
+	assert.Equal(t, Supported, p.Support)
+
+	_, ok = Lookup("notAProperty")
+	assert.False(t, ok)
+}
+
+func TestCheck_InvalidInput(t *testing.T) {
+	_, err := Check([]byte(`{"image": `))
+	assert.Error(t, err)
+
+	_, err = Check([]byte(`["not", "an", "object"]`))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "JSON object")
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// DefaultContextExclusions are left out of every build context. They are applied
+// before .dockerignore, so a "!node_modules" line there sends them anyway.
+var DefaultContextExclusions = []string{".git", "node_modules"}
+
+// ContextFilter decides which files in a build context are sent to the daemon
+type ContextFilter struct {
+	matcher *patternmatcher.PatternMatcher
+	// keep lists files that are always sent because the build needs them
+	keep map[string]bool
+}
+
+// LoadContextFilter reads the ignore file for a build context. As with BuildKit, a
+// <Dockerfile>.dockerignore next to the Dockerfile takes precedence over the
+// .dockerignore at the context root.
+func LoadContextFilter(contextPath, dockerfile string) (*ContextFilter, error) {
+	patterns := append([]string{}, DefaultContextExclusions...)
+
+	candidates := []string{
+		filepath.Join(contextPath, dockerfile+".dockerignore"),
+		filepath.Join(contextPath, ".dockerignore"),
+	}
+	for _, ignorePath := range candidates {
+		file, err := os.Open(ignorePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ignorePath, err)
+		}
+		ignored, err := ignorefile.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", ignorePath, err)
+		}
+		patterns = append(patterns, ignored...)
+		break
+	}
+
+	matcher, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid build context exclusion pattern: %w", err)
+	}
+
+	return &ContextFilter{
+		matcher: matcher,
+		keep: map[string]bool{
+			filepath.ToSlash(filepath.Clean(dockerfile)): true,
+			".dockerignore": true,
+		},
+	}, nil
+}
+
+// Excluded reports whether a path relative to the context root is left out
+func (f *ContextFilter) Excluded(relPath string) (bool, error) {
+	relPath = filepath.ToSlash(relPath)
+	if f.keep[relPath] {
+		return false, nil
+	}
+	return f.matcher.MatchesOrParentMatches(relPath)
+}
+
+// walkBuildContext calls fn for every file and directory of the context that is not excluded
+func walkBuildContext(contextPath string, filter *ContextFilter, fn func(path, relPath string, info os.FileInfo) error) error {
+	return filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(contextPath, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		excluded, err := filter.Excluded(relPath)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			// Without "!" patterns nothing below an excluded directory can be re-included
+			if info.IsDir() && !filter.matcher.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(path, relPath, info)
+	})
+}
+
+// ContextFile is a file that would be sent in a build context
+type ContextFile struct {
+	Path string // relative to the context root, with forward slashes
+	Size int64
+}
+
+// ListBuildContext returns the regular files that a build of the context would send
+func ListBuildContext(contextPath, dockerfile string) ([]ContextFile, error) {
+	filter, err := LoadContextFilter(contextPath, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ContextFile
+	err = walkBuildContext(contextPath, filter, func(path, relPath string, info os.FileInfo) error {
+		if info.Mode().IsRegular() {
+			files = append(files, ContextFile{Path: filepath.ToSlash(relPath), Size: info.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk build context: %w", err)
+	}
+	return files, nil
+}
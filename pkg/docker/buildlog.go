@@ -0,0 +1,148 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/settings"
+)
+
+// Build progress modes, named after 'docker build --progress'
+const (
+	ProgressAuto  = "auto"  // tty when stdout is a terminal, plain otherwise
+	ProgressPlain = "plain" // stream the full build output
+	ProgressTTY   = "tty"   // show only the current step on a single updating line
+)
+
+// buildLogTailLines is how much of the build log is shown when a condensed build fails
+const buildLogTailLines = 30
+
+// ParseProgressMode validates a --progress value; an empty value means auto
+func ParseProgressMode(mode string) (string, error) {
+	switch mode {
+	case "", ProgressAuto:
+		return ProgressAuto, nil
+	case ProgressPlain, ProgressTTY:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid progress mode '%s': must be auto, plain or tty", mode)
+}
+
+// buildLog records build output to the log file and renders it according to the progress mode
+type buildLog struct {
+	out     io.Writer
+	file    *os.File
+	path    string
+	tty     bool
+	partial string   // output after the last newline
+	tail    []string // last buildLogTailLines complete lines
+	steps   int
+}
+
+// newBuildLog opens the log file (when a path is given) and resolves the auto progress mode
+func newBuildLog(logPath, progress string, out io.Writer) (*buildLog, error) {
+	mode, err := ParseProgressMode(progress)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ProgressAuto {
+		mode = ProgressPlain
+		if settings.ColorEnabled(out) {
+			mode = ProgressTTY
+		}
+	}
+
+	l := &buildLog{out: out, path: logPath, tty: mode == ProgressTTY}
+	if logPath != "" {
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create build log directory: %w", err)
+		}
+		l.file, err = os.Create(logPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create build log: %w", err)
+		}
+	}
+	return l, nil
+}
+
+// Write records a chunk of build output
+func (l *buildLog) Write(p []byte) (int, error) {
+	if l.file != nil {
+		if _, err := l.file.Write(p); err != nil {
+			return 0, fmt.Errorf("failed to write build log: %w", err)
+		}
+	}
+	if !l.tty {
+		if _, err := l.out.Write(p); err != nil {
+			return 0, err
+		}
+	}
+
+	lines := strings.Split(l.partial+string(p), "\n")
+	l.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		l.addLine(line)
+	}
+	return len(p), nil
+}
+
+func (l *buildLog) addLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	l.tail = append(l.tail, line)
+	if len(l.tail) > buildLogTailLines {
+		l.tail = l.tail[len(l.tail)-buildLogTailLines:]
+	}
+
+	if !strings.HasPrefix(line, "Step ") {
+		return
+	}
+	l.steps++
+	if l.tty {
+		// Clear the line and show the step, truncated so it does not wrap
+		if len(line) > 100 {
+			line = line[:97] + "..."
+		}
+		_, _ = fmt.Fprintf(l.out, "\r\033[K%s", line)
+	}
+}
+
+// Close flushes any trailing partial line and closes the log file
+func (l *buildLog) Close() error {
+	if l.partial != "" {
+		l.addLine(l.partial)
+		l.partial = ""
+	}
+	if l.tty {
+		_, _ = fmt.Fprint(l.out, "\r\033[K")
+	}
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Summary describes a successful build in one line
+func (l *buildLog) Summary() string {
+	summary := fmt.Sprintf("%d build steps", l.steps)
+	if l.path != "" {
+		summary += ", full log: " + l.path
+	}
+	return summary
+}
+
+// DumpTail writes the end of the log after a failed build. Plain output has already
+// been shown in full, so only the log location is repeated.
+func (l *buildLog) DumpTail(w io.Writer) {
+	if l.tty && len(l.tail) > 0 {
+		_, _ = fmt.Fprintf(w, "Last %d lines of build output:\n", len(l.tail))
+		for _, line := range l.tail {
+			_, _ = fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	if l.path != "" {
+		_, _ = fmt.Fprintf(w, "Full build log: %s\n", l.path)
+	}
+}
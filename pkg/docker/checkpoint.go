@@ -0,0 +1,292 @@
+package docker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/checkpoint"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+)
+
+// Labels on checkpoint images identifying the container and checkpoint they belong to
+const (
+	CheckpointContainerLabel = "com.reactor.checkpoint.container"
+	CheckpointIDLabel        = "com.reactor.checkpoint.id"
+	CheckpointCommandLabel   = "com.reactor.checkpoint.command"
+	CheckpointProcessLabel   = "com.reactor.checkpoint.process"
+	// CheckpointSourceImageLabel names the image the checkpointed container was first
+	// created from; containers restored from a checkpoint carry it too
+	CheckpointSourceImageLabel = "com.reactor.checkpoint.source-image"
+)
+
+// MaxCheckpoints is the number of checkpoints kept per container; older ones are removed
+const MaxCheckpoints = 3
+
+// checkpointIDLayout names checkpoints so they sort by creation time; a random suffix
+// tells apart checkpoints taken within the same second
+const checkpointIDLayout = "20060102-150405"
+
+// newCheckpointID returns a checkpoint ID made of the time and a random suffix
+func newCheckpointID(now time.Time) (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to name checkpoint: %w", err)
+	}
+	return now.UTC().Format(checkpointIDLayout) + "-" + hex.EncodeToString(suffix), nil
+}
+
+// Checkpoint is a saved state of a container. The filesystem is always captured by
+// committing the container to an image; process state is captured as well when the
+// daemon supports CRIU checkpoints.
+type Checkpoint struct {
+	ID            string
+	ContainerName string
+	ImageID       string
+	Command       string // command the checkpoint was taken before, if any
+	Created       time.Time
+	Process       bool // process state was captured and is restored with the filesystem
+	Size          int64
+	// SourceImage is the image the container was first created from, empty for
+	// checkpoints taken before it was recorded
+	SourceImage string
+}
+
+// CheckpointImageRef returns the image reference a container's checkpoint is committed to
+func CheckpointImageRef(containerName, checkpointID string) string {
+	return "reactor-checkpoint/" + strings.ToLower(strings.TrimPrefix(containerName, "/")) + ":" + checkpointID
+}
+
+// CreateCheckpoint saves the state of a running container. Process state is written to
+// processDir when the daemon has experimental checkpoint support; otherwise, or if that
+// fails, only the filesystem is saved. Mounted directories such as the project workspace
+// are not part of the checkpoint. Checkpoints beyond MaxCheckpoints are removed.
+func (s *Service) CreateCheckpoint(ctx context.Context, containerID, containerName, processDir, command string) (Checkpoint, error) {
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if info.State == nil || !info.State.Running {
+		return Checkpoint{}, fmt.Errorf("container %s is not running, start it with 'reactor up'", containerName)
+	}
+
+	now := time.Now().UTC()
+	id, err := newCheckpointID(now)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	cp := Checkpoint{
+		ID:            id,
+		ContainerName: containerName,
+		Command:       command,
+		Created:       now,
+	}
+	if info.Config != nil {
+		cp.SourceImage = info.Config.Labels[CheckpointSourceImageLabel]
+		if cp.SourceImage == "" {
+			cp.SourceImage = info.Config.Image
+		}
+	}
+
+	// Process state first, so the filesystem snapshot is no older than it
+	if s.checkpointsSupported(ctx) {
+		if err := os.MkdirAll(processDir, 0755); err != nil {
+			return Checkpoint{}, fmt.Errorf("failed to create checkpoint directory: %w", err)
+		}
+		err := s.client.CheckpointCreate(ctx, containerID, checkpoint.CreateOptions{
+			CheckpointID:  cp.ID,
+			CheckpointDir: processDir,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to checkpoint processes of %s, saving the filesystem only: %v\n", containerName, err)
+		} else {
+			cp.Process = true
+		}
+	}
+
+	labels := map[string]string{
+		CheckpointContainerLabel: containerName,
+		CheckpointIDLabel:        cp.ID,
+		CheckpointCommandLabel:   command,
+		CheckpointProcessLabel:   fmt.Sprint(cp.Process),
+	}
+	if cp.SourceImage != "" {
+		labels[CheckpointSourceImageLabel] = cp.SourceImage
+	}
+	resp, err := s.client.ContainerCommit(ctx, containerID, container.CommitOptions{
+		Reference: CheckpointImageRef(containerName, cp.ID),
+		Comment:   "reactor checkpoint",
+		Pause:     true,
+		Config:    &container.Config{Labels: labels},
+	})
+	if err != nil {
+		s.removeProcessCheckpoint(processDir, cp.ID)
+		return Checkpoint{}, fmt.Errorf("failed to save container filesystem: %w", err)
+	}
+	cp.ImageID = resp.ID
+
+	if err := s.pruneCheckpoints(ctx, containerName, processDir, MaxCheckpoints); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove old checkpoints: %v\n", err)
+	}
+	return cp, nil
+}
+
+// ListCheckpoints returns the checkpoints of a container, most recent first
+func (s *Service) ListCheckpoints(ctx context.Context, containerName string) ([]Checkpoint, error) {
+	images, err := s.client.ImageList(ctx, image.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", CheckpointContainerLabel+"="+containerName)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	checkpoints := make([]Checkpoint, 0, len(images))
+	for _, img := range images {
+		checkpoints = append(checkpoints, Checkpoint{
+			ID:            img.Labels[CheckpointIDLabel],
+			ContainerName: containerName,
+			ImageID:       img.ID,
+			Command:       img.Labels[CheckpointCommandLabel],
+			Created:       time.Unix(img.Created, 0).UTC(),
+			Process:       img.Labels[CheckpointProcessLabel] == "true",
+			Size:          img.Size,
+			SourceImage:   img.Labels[CheckpointSourceImageLabel],
+		})
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].ID > checkpoints[j].ID })
+	return checkpoints, nil
+}
+
+// RestoreCheckpoint replaces a container with one created from a checkpoint, keeping its
+// name, configuration and mounts, and returns the new container's ID. Process state is
+// restored when the checkpoint has it; if that fails the container is started fresh.
+// The container is only removed once its replacement runs; until then it is stopped
+// and renamed aside, and put back when the replacement cannot be created or started.
+func (s *Service) RestoreCheckpoint(ctx context.Context, containerID string, cp Checkpoint, processDir string) (string, error) {
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if info.ContainerJSONBase == nil || info.Config == nil {
+		return "", fmt.Errorf("container %s has no configuration to restore into", containerID)
+	}
+
+	config := *info.Config
+	config.Image = cp.ImageID
+	if cp.SourceImage != "" {
+		config.Labels = maps.Clone(config.Labels)
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		config.Labels[CheckpointSourceImageLabel] = cp.SourceImage
+	}
+	name := strings.TrimPrefix(info.Name, "/")
+	wasRunning := info.State != nil && info.State.Running
+	defer s.InvalidateContainerCache()
+
+	// The container holds the name and any published host ports, so stop it and move
+	// it aside rather than removing it
+	if wasRunning {
+		if err := s.StopContainer(ctx, containerID); err != nil {
+			return "", err
+		}
+	}
+	if err := s.client.ContainerRename(ctx, containerID, name+restoreAsideSuffix); err != nil {
+		s.putBackContainer(ctx, containerID, name, false, wasRunning)
+		return "", fmt.Errorf("failed to rename container %s aside: %w", name, err)
+	}
+
+	created, err := s.client.ContainerCreate(ctx, &config, info.HostConfig, nil, nil, name)
+	if err != nil {
+		s.putBackContainer(ctx, containerID, name, true, wasRunning)
+		return "", fmt.Errorf("failed to create container from checkpoint %s: %w", cp.ID, err)
+	}
+
+	started := false
+	if cp.Process {
+		err := s.client.ContainerStart(ctx, created.ID, container.StartOptions{
+			CheckpointID:  cp.ID,
+			CheckpointDir: processDir,
+		})
+		if err == nil {
+			started = true
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: failed to restore processes from checkpoint %s, starting the container fresh: %v\n", cp.ID, err)
+		}
+	}
+	if !started {
+		if err := s.client.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+			_ = s.client.ContainerRemove(ctx, created.ID, container.RemoveOptions{Force: true})
+			s.putBackContainer(ctx, containerID, name, true, wasRunning)
+			return "", fmt.Errorf("failed to start container %s from checkpoint %s: %w", name, cp.ID, err)
+		}
+	}
+
+	if err := s.RemoveContainer(ctx, containerID); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove the replaced container %s: %v\n", name+restoreAsideSuffix, err)
+	}
+	return created.ID, nil
+}
+
+// restoreAsideSuffix is added to the name of a container while a checkpoint replaces it
+const restoreAsideSuffix = "-replaced"
+
+// putBackContainer undoes moving a container aside for a restore: it gets its name
+// back when it was renamed, and is started again when it was running
+func (s *Service) putBackContainer(ctx context.Context, containerID, name string, renamed, wasRunning bool) {
+	if renamed {
+		if err := s.client.ContainerRename(ctx, containerID, name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to rename container %s back to %s: %v\n", name+restoreAsideSuffix, name, err)
+			return
+		}
+	}
+	if wasRunning {
+		if err := s.client.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to start container %s again: %v\n", name, err)
+		}
+	}
+}
+
+// RemoveCheckpoint deletes a checkpoint's image and saved process state
+func (s *Service) RemoveCheckpoint(ctx context.Context, cp Checkpoint, processDir string) error {
+	if _, err := s.client.ImageRemove(ctx, cp.ImageID, image.RemoveOptions{Force: true, PruneChildren: true}); err != nil {
+		return fmt.Errorf("failed to remove checkpoint %s: %w", cp.ID, err)
+	}
+	s.removeProcessCheckpoint(processDir, cp.ID)
+	return nil
+}
+
+func (s *Service) pruneCheckpoints(ctx context.Context, containerName, processDir string, keep int) error {
+	checkpoints, err := s.ListCheckpoints(ctx, containerName)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(checkpoints); i++ {
+		if err := s.RemoveCheckpoint(ctx, checkpoints[i], processDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkpointsSupported reports whether the daemon can checkpoint processes with CRIU,
+// which Docker only offers with experimental features enabled
+func (s *Service) checkpointsSupported(ctx context.Context) bool {
+	ping, err := s.client.Ping(ctx)
+	return err == nil && ping.Experimental
+}
+
+func (s *Service) removeProcessCheckpoint(processDir, checkpointID string) {
+	if processDir != "" {
+		_ = os.RemoveAll(filepath.Join(processDir, checkpointID))
+	}
+}
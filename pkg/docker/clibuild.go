@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/dyluth/reactor/pkg/debug"
+)
+
+// dockerCLIBinary runs builds that need a BuildKit session, which the Engine API
+// client cannot provide
+const dockerCLIBinary = "docker"
+
+// Variables so tests can stub out the docker CLI
+var (
+	lookDockerCLI = exec.LookPath
+	runDockerCLI  = func(ctx context.Context, env []string, stdin io.Reader, output io.Writer, args ...string) error {
+		cmd := exec.CommandContext(ctx, dockerCLIBinary, args...)
+		cmd.Env = env
+		cmd.Stdin = stdin
+		cmd.Stdout = output
+		cmd.Stderr = output
+		return cmd.Run()
+	}
+)
+
+// buildWithCLI builds an image with 'docker build', so secrets and SSH agents reach
+// RUN --mount instructions without being stored in a layer. The filtered build context
+// is sent on stdin as it is to the API. Registries are authenticated with the docker
+// CLI's own credentials.
+func (s *Service) buildWithCLI(ctx context.Context, spec BuildSpec, buildContext io.Reader, output io.Writer) error {
+	if _, err := lookDockerCLI(dockerCLIBinary); err != nil {
+		return fmt.Errorf("build secrets and ssh need the docker CLI with BuildKit on PATH: %w", err)
+	}
+
+	env := append(os.Environ(), "DOCKER_BUILDKIT=1")
+	host := s.host
+	if host == "" {
+		host = ProbedHost()
+	}
+	if host != "" {
+		env = append(env, "DOCKER_HOST="+host)
+	}
+
+	// Build arguments may carry proxy credentials, so only the secret and ssh values are logged
+	debug.Logf(debug.Docker, "building %s with the docker CLI, secrets %v, ssh %v", spec.ImageName, spec.Secrets, spec.SSH)
+	if err := runDockerCLI(ctx, env, buildContext, output, cliBuildArgs(spec)...); err != nil {
+		return fmt.Errorf("docker build: %w", err)
+	}
+	return nil
+}
+
+// cliBuildArgs returns the 'docker build' arguments of a build whose context is read
+// from stdin. Secret values never appear: --secret names a file or variable.
+func cliBuildArgs(spec BuildSpec) []string {
+	args := []string{"build", "--progress", "plain", "-f", spec.Dockerfile, "-t", spec.ImageName}
+
+	names := make([]string, 0, len(spec.BuildArgs))
+	for name := range spec.BuildArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := spec.BuildArgs[name]; value != nil {
+			args = append(args, "--build-arg", name+"="+*value)
+		} else {
+			args = append(args, "--build-arg", name)
+		}
+	}
+	for _, host := range spec.ExtraHosts {
+		args = append(args, "--add-host", host)
+	}
+
+	labels := make([]string, 0, len(spec.Labels))
+	for name := range spec.Labels {
+		labels = append(labels, name)
+	}
+	sort.Strings(labels)
+	for _, name := range labels {
+		args = append(args, "--label", name+"="+spec.Labels[name])
+	}
+
+	for _, secret := range spec.Secrets {
+		args = append(args, "--secret", secret)
+	}
+	for _, ssh := range spec.SSH {
+		args = append(args, "--ssh", ssh)
+	}
+	return append(args, "-")
+}
@@ -0,0 +1,166 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// LogDirLabel records the host directory a container's output is archived to when it is removed
+const LogDirLabel = "com.reactor.log-dir"
+
+// MaxArchivedLogs is the number of earlier runs whose output is kept per container
+const MaxArchivedLogs = 5
+
+// archivedLogLayout names archived log files so they sort by the time the run ended
+const archivedLogLayout = "20060102T150405.000Z"
+
+// archiveLogsTimeout bounds archiving a container's output, which happens before the
+// container is removed and must not hold up its removal for long
+const archiveLogsTimeout = 30 * time.Second
+
+// maxArchivedLogSize caps the output archived per run; the rest is left out
+var maxArchivedLogSize int64 = 16 << 20
+
+// errLogCapped stops copying a container's output once maxArchivedLogSize is reached
+var errLogCapped = errors.New("archived output size limit reached")
+
+// cappedWriter writes up to remaining bytes, then fails with errLogCapped
+type cappedWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (c *cappedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= c.remaining {
+		n, err := c.w.Write(p)
+		c.remaining -= int64(n)
+		return n, err
+	}
+	n, err := c.w.Write(p[:c.remaining])
+	c.remaining -= int64(n)
+	if err == nil {
+		err = errLogCapped
+	}
+	return n, err
+}
+
+// LogOptions configures ContainerOutput
+type LogOptions struct {
+	Follow bool
+	Tail   string // number of lines from the end, or "all"
+}
+
+// ContainerOutput copies the output of a container's main process to stdout and stderr
+func (s *Service) ContainerOutput(ctx context.Context, containerID string, opts LogOptions, stdout, stderr io.Writer) error {
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+
+	tail := opts.Tail
+	if tail == "" {
+		tail = "all"
+	}
+	reader, err := s.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Tail:       tail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read logs of container %s: %w", containerID, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	// Without a TTY the daemon multiplexes stdout and stderr
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(stdout, reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, reader)
+	}
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read logs of container %s: %w", containerID, err)
+	}
+	return nil
+}
+
+// archiveContainerLogs saves a container's output to the directory in its LogDirLabel,
+// so it survives the container being removed. Containers without the label are skipped.
+// Output beyond maxArchivedLogSize is left out, and a note says so.
+func (s *Service) archiveContainerLogs(ctx context.Context, containerID string, info container.InspectResponse) error {
+	if info.Config == nil || info.Config.Labels[LogDirLabel] == "" {
+		return nil
+	}
+	logDir := info.Config.Labels[LogDirLabel]
+
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	path := filepath.Join(logDir, time.Now().UTC().Format(archivedLogLayout)+".log")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create log archive: %w", err)
+	}
+	capped := &cappedWriter{w: f, remaining: maxArchivedLogSize}
+	err = s.ContainerOutput(ctx, containerID, LogOptions{}, capped, capped)
+	if errors.Is(err, errLogCapped) {
+		_, err = fmt.Fprintf(f, "\n[output truncated: only the first %d bytes were archived]\n", maxArchivedLogSize)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+
+	return pruneArchivedLogs(logDir, MaxArchivedLogs)
+}
+
+// ArchivedLogs returns the archived output files in logDir, most recent run first
+func ArchivedLogs(logDir string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var logs []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".log") {
+			logs = append(logs, filepath.Join(logDir, entry.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(logs)))
+	return logs, nil
+}
+
+// ArchivedLogTime returns when the run whose output is in an archived log file ended
+func ArchivedLogTime(path string) (time.Time, error) {
+	return time.Parse(archivedLogLayout, strings.TrimSuffix(filepath.Base(path), ".log"))
+}
+
+func pruneArchivedLogs(logDir string, keep int) error {
+	logs, err := ArchivedLogs(logDir)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(logs); i++ {
+		if err := os.Remove(logs[i]); err != nil {
+			return fmt.Errorf("failed to rotate log archive: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// CopyToContainer extracts a tar archive into a directory of a container, with the
+// extracted files owned by the container's user
+func (s *Service) CopyToContainer(ctx context.Context, containerID, dstPath string, archive io.Reader) error {
+	if err := s.client.CopyToContainer(ctx, containerID, dstPath, archive, container.CopyToContainerOptions{CopyUIDGID: true}); err != nil {
+		return fmt.Errorf("failed to copy files to %s in container %s: %w", dstPath, containerID, err)
+	}
+	return nil
+}
+
+// CopyFromContainer returns a tar archive of a path in a container. The archive's first
+// entry is the path itself, named after its base name. The caller must close it.
+func (s *Service) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, error) {
+	reader, _, err := s.client.CopyFromContainer(ctx, containerID, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s from container %s: %w", srcPath, containerID, err)
+	}
+	return reader, nil
+}
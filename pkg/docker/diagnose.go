@@ -0,0 +1,134 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// troubleshootingURL is the README, whose troubleshooting headings the hints link to
+const troubleshootingURL = "https://github.com/dyluth/reactor#"
+
+// Diagnosis is a known Docker failure with a remediation hint
+type Diagnosis struct {
+	Problem string
+	Hint    string
+	DocURL  string
+}
+
+// diagnostic matches a Docker error message (lower-cased) to a diagnosis
+type diagnostic struct {
+	matches   func(msg string) bool
+	diagnosis func() Diagnosis
+}
+
+// diagnostics is the knowledge base, checked in order; the first match wins
+var diagnostics = []diagnostic{
+	{
+		matches: func(msg string) bool {
+			return strings.Contains(msg, "permission denied") &&
+				(strings.Contains(msg, "docker.sock") || strings.Contains(msg, "docker daemon socket"))
+		},
+		diagnosis: func() Diagnosis {
+			hint := "Your user cannot access the Docker socket. Add it to the docker group with 'sudo usermod -aG docker $USER', then log out and back in (or run 'newgrp docker')."
+			if runtime.GOOS == "darwin" {
+				hint = "Your user cannot access the Docker socket. Restart Docker Desktop, and check that DOCKER_HOST points at a socket you own."
+			}
+			return Diagnosis{Problem: "Permission denied on the Docker socket", Hint: hint, DocURL: troubleshootingURL + "permission-denied-on-the-docker-socket"}
+		},
+	},
+	{
+		matches: func(msg string) bool {
+			return strings.Contains(msg, "cannot connect to the docker daemon") || strings.Contains(msg, "is the docker daemon running")
+		},
+		diagnosis: func() Diagnosis {
+			return Diagnosis{
+				Problem: "Docker daemon not running",
+				Hint:    "Start Docker (Docker Desktop, or 'sudo systemctl start docker' on Linux) and check that DOCKER_HOST, if set, points at a running daemon.",
+				DocURL:  troubleshootingURL + "docker-daemon-not-running",
+			}
+		},
+	},
+	{
+		matches: func(msg string) bool {
+			return strings.Contains(msg, "no space left on device")
+		},
+		diagnosis: func() Diagnosis {
+			return Diagnosis{
+				Problem: "No space left on device",
+				Hint:    "Docker has run out of disk space. Check usage with 'docker system df' and free space with 'docker system prune' (add --volumes to include unused volumes). On Docker Desktop, raise the disk image size in Settings > Resources.",
+				DocURL:  troubleshootingURL + "no-space-left-on-device",
+			}
+		},
+	},
+	{
+		matches: func(msg string) bool {
+			return strings.Contains(msg, "exec format error") || strings.Contains(msg, "no matching manifest for")
+		},
+		diagnosis: func() Diagnosis {
+			return Diagnosis{
+				Problem: "Image built for a different CPU architecture",
+				Hint: fmt.Sprintf("The image does not run on this %s host. Use an image published for linux/%s (most official images are multi-arch), or install emulation with 'docker run --privileged --rm tonistiigi/binfmt --install all'.",
+					runtime.GOARCH, runtime.GOARCH),
+				DocURL: troubleshootingURL + "exec-format-error",
+			}
+		},
+	},
+	{
+		matches: func(msg string) bool {
+			return strings.Contains(msg, "manifest unknown") ||
+				(strings.Contains(msg, "manifest for") && strings.Contains(msg, "not found")) ||
+				strings.Contains(msg, "pull access denied") ||
+				strings.Contains(msg, "repository does not exist")
+		},
+		diagnosis: func() Diagnosis {
+			return Diagnosis{
+				Problem: "Image not found",
+				Hint:    "The image or tag does not exist in the registry. Check the \"image\" in devcontainer.json for typos and that the tag is published; for a private registry, run 'docker login <registry>' first.",
+				DocURL:  troubleshootingURL + "image-not-found",
+			}
+		},
+	},
+}
+
+// Diagnose looks up a remediation hint for a Docker error message
+func Diagnose(message string) (Diagnosis, bool) {
+	msg := strings.ToLower(message)
+	for _, d := range diagnostics {
+		if d.matches(msg) {
+			return d.diagnosis(), true
+		}
+	}
+	return Diagnosis{}, false
+}
+
+// DiagnosedError is an error with a remediation hint appended to its message
+type DiagnosedError struct {
+	Err error
+	Diagnosis
+}
+
+func (e *DiagnosedError) Error() string {
+	return fmt.Sprintf("%v\n\nHint: %s\nSee: %s", e.Err, e.Hint, e.DocURL)
+}
+
+func (e *DiagnosedError) Unwrap() error {
+	return e.Err
+}
+
+// WithDiagnosis adds a remediation hint to err when it is a known Docker failure, and
+// returns err unchanged otherwise
+func WithDiagnosis(err error) error {
+	if err == nil {
+		return nil
+	}
+	var diagnosed *DiagnosedError
+	if errors.As(err, &diagnosed) {
+		return err
+	}
+	if d, ok := Diagnose(err.Error()); ok {
+		return &DiagnosedError{Err: err, Diagnosis: d}
+	}
+	return err
+}
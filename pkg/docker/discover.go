@@ -0,0 +1,199 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/settings"
+)
+
+// socketProbeTimeout bounds how long a candidate socket may take to accept a connection
+const socketProbeTimeout = 200 * time.Millisecond
+
+// defaultSocketOrder is the order runtimes are probed in when the dockerSockets
+// preference is not set. The desktop runtimes come first because a socket left at
+// /var/run/docker.sock by one of them may belong to a runtime that is not running.
+var defaultSocketOrder = []string{"colima", "rancher-desktop", "orbstack", "docker-desktop", "default"}
+
+// runtimeSockets are the sockets of the Docker runtimes that can be named in the
+// dockerSockets preference, relative to the home directory unless absolute
+var runtimeSockets = map[string][]string{
+	"colima":          {".colima/default/docker.sock", ".colima/docker.sock"},
+	"rancher-desktop": {".rd/docker.sock"},
+	"orbstack":        {".orbstack/run/docker.sock"},
+	"docker-desktop":  {".docker/run/docker.sock"},
+	"default":         {"/var/run/docker.sock"},
+}
+
+// Endpoint is the Docker daemon reactor connects to and how it was chosen
+type Endpoint struct {
+	Host   string `json:"host"`   // daemon address, e.g. unix:///Users/me/.colima/default/docker.sock
+	Source string `json:"source"` // DOCKER_HOST, docker context "<name>", or the probed runtime
+	// Probes lists the sockets tried, in order, when the endpoint was found by probing
+	Probes []SocketProbe `json:"probes,omitempty"`
+}
+
+// SocketProbe is a candidate socket and whether a daemon answered on it
+type SocketProbe struct {
+	Runtime string `json:"runtime"`
+	Path    string `json:"path"`
+	Live    bool   `json:"live"`
+}
+
+// Sources of endpoints that NewService leaves to the Docker client
+const (
+	SourceDockerHost    = "DOCKER_HOST"
+	SourceClientDefault = "the client default, as no runtime answered"
+)
+
+var (
+	discoverOnce   sync.Once
+	discoveredHost string
+	probedHost     bool // discoveredHost is a probed socket rather than a docker CLI context
+)
+
+// defaultHost returns the daemon address NewService connects to, discovered once per
+// process. An empty address leaves the choice to the Docker client.
+func defaultHost() string {
+	discoverOnce.Do(func() {
+		endpoint, err := DiscoverEndpoint()
+		if err != nil {
+			debug.Logf(debug.Docker, "endpoint discovery failed, using the client default: %v", err)
+			return
+		}
+		if endpoint.Source != SourceDockerHost && endpoint.Source != SourceClientDefault {
+			discoveredHost = endpoint.Host
+			probedHost = len(endpoint.Probes) > 0
+		}
+		debug.Logf(debug.Docker, "using %s from %s", endpoint.Host, endpoint.Source)
+	})
+	return discoveredHost
+}
+
+// ProbedHost returns the socket NewService found by probing, empty when DOCKER_HOST, a
+// docker CLI context or the client default chose the daemon. Child processes that run
+// the docker CLI need it as DOCKER_HOST to reach the same daemon.
+func ProbedHost() string {
+	host := defaultHost()
+	if !probedHost {
+		return ""
+	}
+	return host
+}
+
+// DiscoverEndpoint finds the Docker daemon: DOCKER_HOST when it is exported, then the
+// docker CLI's current context, then the first socket that accepts a connection of the
+// runtimes in the dockerSockets preference (Colima, Rancher Desktop, OrbStack, Docker
+// Desktop and /var/run/docker.sock by default). When nothing answers, the Docker
+// client's default address is returned.
+func DiscoverEndpoint() (Endpoint, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return Endpoint{Host: host, Source: SourceDockerHost}, nil
+	}
+
+	if name := currentContext(); name != "" && name != "default" {
+		host, err := ContextHost(name)
+		if err != nil {
+			return Endpoint{}, err
+		}
+		return Endpoint{Host: host, Source: fmt.Sprintf("docker context %q", name)}, nil
+	}
+
+	order := defaultSocketOrder
+	if prefs, err := settings.Load(); err == nil && len(prefs.DockerSockets) > 0 {
+		order = prefs.DockerSockets
+	}
+	probes, err := probeSockets(order)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	for _, probe := range probes {
+		if probe.Live {
+			return Endpoint{Host: "unix://" + probe.Path, Source: probe.Runtime, Probes: probes}, nil
+		}
+	}
+	return Endpoint{Host: client.DefaultDockerHost, Source: SourceClientDefault, Probes: probes}, nil
+}
+
+// probeSockets dials the sockets of the runtimes or paths in order
+func probeSockets(order []string) ([]SocketProbe, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	var probes []SocketProbe
+	for _, entry := range order {
+		paths, known := runtimeSockets[entry]
+		runtime := entry
+		if !known {
+			if !strings.HasPrefix(entry, "/") && !strings.HasPrefix(entry, "~/") {
+				return nil, fmt.Errorf("unknown Docker runtime '%s' in dockerSockets: use colima, rancher-desktop, orbstack, docker-desktop, default or a socket path", entry)
+			}
+			paths, runtime = []string{entry}, "socket"
+		}
+		for _, path := range paths {
+			if rest, ok := strings.CutPrefix(path, "~/"); ok {
+				path = filepath.Join(home, rest)
+			} else if !filepath.IsAbs(path) {
+				path = filepath.Join(home, path)
+			}
+			probes = append(probes, SocketProbe{Runtime: runtime, Path: path, Live: socketLive(path)})
+		}
+	}
+	return probes, nil
+}
+
+// socketLive reports whether something accepts connections on a unix socket. A socket
+// file left behind by a stopped runtime refuses them.
+func socketLive(path string) bool {
+	conn, err := net.DialTimeout("unix", path, socketProbeTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// currentContext returns the docker CLI context selected with DOCKER_CONTEXT or
+// 'docker context use', empty when none is
+func currentContext() string {
+	if name := os.Getenv("DOCKER_CONTEXT"); name != "" {
+		return name
+	}
+	configDir, err := dockerConfigDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return ""
+	}
+	return cfg.CurrentContext
+}
+
+// dockerConfigDir returns DOCKER_CONFIG or ~/.docker
+func dockerConfigDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the docker config directory: %w", err)
+	}
+	return filepath.Join(home, ".docker"), nil
+}
@@ -0,0 +1,164 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/dyluth/reactor/pkg/debug"
+)
+
+// NewServiceForHost creates a Docker service connected to the daemon at host, e.g.
+// "tcp://build-box:2376" or "ssh://dev@build-box". An empty host uses DOCKER_HOST
+// like NewService.
+func NewServiceForHost(host string) (*Service, error) {
+	if host == "" {
+		return NewService()
+	}
+
+	debug.Logf(debug.Docker, "connecting to %s", host)
+	opts, err := hostOpts(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client for %s: %w", host, err)
+	}
+	opts = append([]client.Opt{client.FromEnv}, append(opts, client.WithAPIVersionNegotiation())...)
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client for %s: %w", host, err)
+	}
+
+	return &Service{
+		client:    cli,
+		listCache: newContainerListCache(containerListCacheTTL),
+		host:      host,
+	}, nil
+}
+
+// hostOpts returns the client options that reach the daemon at host. ssh:// hosts are
+// reached through 'docker system dial-stdio' over ssh, as the docker CLI does. A tcp://
+// host gets the TLS material the docker CLI stores for a context with that endpoint,
+// unless DOCKER_CERT_PATH provides some.
+func hostOpts(host string) ([]client.Opt, error) {
+	if strings.HasPrefix(host, "ssh://") {
+		dial, err := sshDialer(host)
+		if err != nil {
+			return nil, err
+		}
+		// The dialer ignores the address; the client only needs a valid one
+		return []client.Opt{client.WithHost("http://docker.example.com"), client.WithDialContext(dial)}, nil
+	}
+	if strings.HasPrefix(host, "tcp://") && os.Getenv(client.EnvOverrideCertPath) == "" {
+		tlsConfig, err := contextTLS(host)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig != nil {
+			// The client's transport is replaced before WithHost configures it
+			transport := &http.Transport{TLSClientConfig: tlsConfig}
+			return []client.Opt{client.WithHTTPClient(&http.Client{Transport: transport}), client.WithHost(host)}, nil
+		}
+	}
+	return []client.Opt{client.WithHost(host)}, nil
+}
+
+// contextMeta is the part of a docker CLI context's meta.json that names its daemon
+type contextMeta struct {
+	Name      string `json:"Name"`
+	Endpoints struct {
+		Docker struct {
+			Host          string `json:"Host"`
+			SkipTLSVerify bool   `json:"SkipTLSVerify"`
+		} `json:"docker"`
+	} `json:"Endpoints"`
+}
+
+// contextTLS returns the TLS configuration of the docker CLI context whose endpoint is
+// host, built from the ca.pem, cert.pem and key.pem the docker CLI stores for it. It
+// returns nil when no context with TLS material uses host.
+func contextTLS(host string) (*tls.Config, error) {
+	configDir, err := dockerConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	metas, err := filepath.Glob(filepath.Join(configDir, "contexts", "meta", "*", "meta.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, metaPath := range metas {
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+		var meta contextMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Endpoints.Docker.Host != host {
+			continue
+		}
+
+		// TLS material sits under the same hash of the context name as its meta.json
+		tlsDir := filepath.Join(configDir, "contexts", "tls", filepath.Base(filepath.Dir(metaPath)), "docker")
+		options := tlsconfig.Options{InsecureSkipVerify: meta.Endpoints.Docker.SkipTLSVerify, ExclusiveRootPools: true}
+		if ca := filepath.Join(tlsDir, "ca.pem"); fileExists(ca) {
+			options.CAFile = ca
+		}
+		if cert, key := filepath.Join(tlsDir, "cert.pem"), filepath.Join(tlsDir, "key.pem"); fileExists(cert) && fileExists(key) {
+			options.CertFile, options.KeyFile = cert, key
+		}
+		if options.CAFile == "" && options.CertFile == "" && !options.InsecureSkipVerify {
+			continue
+		}
+		tlsConfig, err := tlsconfig.Client(options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the TLS material of docker context '%s': %w", meta.Name, err)
+		}
+		debug.Logf(debug.Docker, "using the TLS material of context %s for %s", meta.Name, host)
+		return tlsConfig, nil
+	}
+	return nil, nil
+}
+
+// fileExists reports whether path names a file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// ContextHost returns the daemon address of a docker CLI context (see 'docker context
+// ls'), read from the contexts stored under DOCKER_CONFIG or ~/.docker. The "default"
+// context returns an empty host, meaning DOCKER_HOST or the local daemon.
+func ContextHost(name string) (string, error) {
+	if name == "default" {
+		return "", nil
+	}
+
+	configDir, err := dockerConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	// The docker CLI stores each context under the sha256 of its name
+	metaPath := filepath.Join(configDir, "contexts", "meta", fmt.Sprintf("%x", sha256.Sum256([]byte(name))), "meta.json")
+	data, err := os.ReadFile(metaPath)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("docker context '%s' not found; create it with 'docker context create %s --docker host=...'", name, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read docker context '%s': %w", name, err)
+	}
+
+	var meta contextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return "", fmt.Errorf("failed to parse docker context '%s': %w", name, err)
+	}
+	if meta.Endpoints.Docker.Host == "" {
+		return "", fmt.Errorf("docker context '%s' has no docker endpoint", name)
+	}
+	debug.Logf(debug.Docker, "context %s uses %s", name, meta.Endpoints.Docker.Host)
+	return meta.Endpoints.Docker.Host, nil
+}
@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// watchedEventActions are the container lifecycle actions reported by ContainerEvents.
+// kill is left out: every stop sends one before die and stop.
+var watchedEventActions = []string{"create", "start", "restart", "die", "oom", "stop", "pause", "unpause", "destroy", "health_status"}
+
+// ContainerEvent is a lifecycle event of a reactor container
+type ContainerEvent struct {
+	Time   time.Time
+	Action string // e.g. start, die, oom or "health_status: unhealthy"
+	ID     string
+	Name   string
+	// Attributes holds the container's labels and event details such as exitCode
+	Attributes map[string]string
+}
+
+// ContainerEvents streams the lifecycle events of reactor containers. Events from since
+// are replayed first; a zero since starts at the current time. With a non-zero until
+// the stream stops at that time, otherwise it runs until ctx is cancelled. The event
+// channel is closed when the stream ends; a failure is sent on the error channel first.
+func (s *Service) ContainerEvents(ctx context.Context, since, until time.Time) (<-chan ContainerEvent, <-chan error) {
+	args := filters.NewArgs(filters.Arg("type", string(events.ContainerEventType)))
+	for _, action := range watchedEventActions {
+		args.Add("event", action)
+	}
+	options := events.ListOptions{Filters: args}
+	if !since.IsZero() {
+		options.Since = strconv.FormatInt(since.Unix(), 10)
+	}
+	if !until.IsZero() {
+		options.Until = strconv.FormatInt(until.Unix(), 10)
+	}
+
+	out := make(chan ContainerEvent)
+	errs := make(chan error, 1)
+	messages, messageErrs := s.client.Events(ctx, options)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-messageErrs:
+				if err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					errs <- fmt.Errorf("failed to read Docker events: %w", err)
+				}
+				return
+			case msg := <-messages:
+				event, ok := s.reactorContainerEvent(msg)
+				if !ok {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, errs
+}
+
+// reactorContainerEvent converts a Docker event, reporting false for containers reactor did not create
+func (s *Service) reactorContainerEvent(msg events.Message) (ContainerEvent, bool) {
+	name := msg.Actor.Attributes["name"]
+	if !s.isReactorContainer(name) {
+		return ContainerEvent{}, false
+	}
+
+	at := time.Unix(0, msg.TimeNano)
+	if msg.TimeNano == 0 {
+		at = time.Unix(msg.Time, 0)
+	}
+	return ContainerEvent{
+		Time:       at,
+		Action:     string(msg.Action),
+		ID:         msg.Actor.ID,
+		Name:       name,
+		Attributes: msg.Actor.Attributes,
+	}, true
+}
@@ -0,0 +1,114 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/dyluth/reactor/pkg/debug"
+)
+
+// ExitError reports a non-zero exit status from a command run inside a container
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
+// ExecOptions configures a command run with ExecCommand
+type ExecOptions struct {
+	Command []string
+	Env     []string  // Optional KEY=VALUE variables added to the command's environment
+	User    string    // Optional user to run as instead of the container's user
+	Tty     bool      // Allocate a TTY; output is then a single raw stream
+	Stdin   io.Reader // Optional; forwarded until EOF, then the command's stdin is closed
+	Stdout  io.Writer
+	Stderr  io.Writer // Receives stderr when no TTY is allocated
+}
+
+// ExecCommand runs a command in a running container, streaming its input and output.
+// A non-zero exit status is returned as an *ExitError.
+func (s *Service) ExecCommand(ctx context.Context, containerID string, opts ExecOptions) error {
+	if len(opts.Command) == 0 {
+		return fmt.Errorf("command array cannot be empty")
+	}
+
+	containerInfo, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if !containerInfo.State.Running {
+		return fmt.Errorf("container %s is not running, start it with 'reactor up'", containerID)
+	}
+
+	spec := newExecSpec(containerInfo, opts.Command, opts.Env, opts.User, opts.Tty)
+	spec.Stdin = opts.Stdin != nil
+	debug.Logf(debug.Docker, "exec in %s as %q in %q (tty: %t): %q", containerID, spec.User, spec.WorkDir, spec.Tty, spec.Command)
+	execResp, err := s.client.ContainerExecCreate(ctx, containerID, spec.options())
+	if err != nil {
+		return fmt.Errorf("failed to create exec instance: %w", err)
+	}
+
+	attachResp, err := s.client.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{
+		Tty: opts.Tty,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach to exec instance: %w", err)
+	}
+	defer attachResp.Close()
+
+	if opts.Tty {
+		termState := NewTerminalState()
+		defer func() { _ = termState.Cleanup() }()
+		if err := termState.Setup(); err != nil {
+			return fmt.Errorf("failed to setup terminal: %w", err)
+		}
+		if size, err := termState.GetTerminalSize(); err == nil {
+			_ = s.resizeContainerTTY(ctx, containerID, execResp.ID, size)
+		}
+	}
+
+	return s.streamExec(ctx, execResp.ID, attachResp, opts.Tty, opts.Stdin, opts.Stdout, opts.Stderr)
+}
+
+// streamExec copies an attached exec's streams until its output ends. Stdin is forwarded
+// until EOF and then the write side is closed so the process sees end of input. Without a
+// TTY the daemon multiplexes stdout and stderr, so the output is demultiplexed; with a TTY
+// it is copied verbatim. Both paths are binary-safe.
+func (s *Service) streamExec(ctx context.Context, execID string, attachResp types.HijackedResponse, tty bool, stdin io.Reader, stdout, stderr io.Writer) error {
+	if stdin != nil {
+		go func() {
+			// Input errors surface as the process exiting early, so they are not reported here
+			_, _ = io.Copy(attachResp.Conn, stdin)
+			_ = attachResp.CloseWrite()
+		}()
+	}
+
+	var err error
+	if tty {
+		_, err = io.Copy(stdout, attachResp.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, attachResp.Reader)
+	}
+	if err != nil {
+		return fmt.Errorf("output copy failed: %w", err)
+	}
+	return s.execExitError(ctx, execID)
+}
+
+// execExitError returns an *ExitError when a finished exec exited non-zero
+func (s *Service) execExitError(ctx context.Context, execID string) error {
+	inspectResp, err := s.client.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect command execution: %w", err)
+	}
+	if inspectResp.ExitCode != 0 {
+		return &ExitError{Code: inspectResp.ExitCode}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"os"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// defaultTerm is the terminal type of TTY sessions when the host's TERM is unset
+const defaultTerm = "xterm-256color"
+
+// ExecSpec is the process configuration of a command reactor runs in a container.
+// Interactive sessions, 'reactor exec', workspace exec, jobs and lifecycle commands
+// all build theirs with newExecSpec, so a command sees the same user, working
+// directory and environment whichever path started it.
+type ExecSpec struct {
+	Command []string
+	User    string   // The container's configured user unless a caller overrides it
+	WorkDir string   // The container's working directory
+	Env     []string // The session environment followed by the caller's KEY=VALUE variables
+	Tty     bool
+	Stdin   bool // Attach stdin
+	Detach  bool // Output is not attached, e.g. for jobs that log inside the container
+}
+
+// newExecSpec returns the spec for running command in the inspected container. user
+// overrides the container's user when it is not empty; env is added after the session
+// environment, so callers can override it.
+func newExecSpec(info container.InspectResponse, command, env []string, user string, tty bool) ExecSpec {
+	spec := ExecSpec{Command: command, User: user, Tty: tty}
+	if info.Config != nil {
+		if spec.User == "" {
+			spec.User = info.Config.User
+		}
+		spec.WorkDir = info.Config.WorkingDir
+	}
+	spec.Env = append(sessionEnv(tty), env...)
+	return spec
+}
+
+// sessionEnv is the environment every command gets on top of the container's own. A
+// TTY session describes the host terminal, so full-screen programs draw the same in
+// 'reactor sessions attach' and 'reactor exec -t'.
+func sessionEnv(tty bool) []string {
+	if !tty {
+		return nil
+	}
+	term := os.Getenv("TERM")
+	if term == "" {
+		term = defaultTerm
+	}
+	env := []string{"TERM=" + term}
+	if colorTerm := os.Getenv("COLORTERM"); colorTerm != "" {
+		env = append(env, "COLORTERM="+colorTerm)
+	}
+	return env
+}
+
+// options converts the spec into the Docker exec configuration
+func (e ExecSpec) options() container.ExecOptions {
+	return container.ExecOptions{
+		AttachStdin:  e.Stdin,
+		AttachStdout: !e.Detach,
+		AttachStderr: !e.Detach,
+		Tty:          e.Tty,
+		Env:          e.Env,
+		User:         e.User,
+		WorkingDir:   e.WorkDir,
+		Cmd:          e.Command,
+	}
+}
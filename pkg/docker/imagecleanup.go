@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+	"github.com/dyluth/reactor/pkg/debug"
+)
+
+// RemoveReplacedImage removes the image a rebuild of imageName replaced, given the ID
+// imageName had before the build, so repeated rebuilds do not pile up dangling images.
+// A shared image is kept: one still tagged under another name, used by a container or
+// with child images such as checkpoints. It reports whether the image was removed.
+func (s *Service) RemoveReplacedImage(ctx context.Context, imageName, previousID string) (bool, error) {
+	if previousID == "" {
+		return false, nil
+	}
+	currentID, err := s.ImageID(ctx, imageName)
+	if err != nil {
+		return false, err
+	}
+	if currentID == previousID {
+		return false, nil
+	}
+
+	// Removing an image by ID also drops its last tag, which someone else put there
+	previous, err := s.client.ImageInspect(ctx, previousID)
+	if errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect replaced image %s: %w", previousID, err)
+	}
+	if len(previous.RepoTags) > 0 {
+		debug.Logf(debug.Docker, "keeping replaced image %s, tagged %v", previousID, previous.RepoTags)
+		return false, nil
+	}
+
+	// Without Force the daemon refuses to remove an image a container or child image uses
+	if _, err := s.client.ImageRemove(ctx, previousID, image.RemoveOptions{PruneChildren: true}); err != nil {
+		if errdefs.IsConflict(err) || errdefs.IsNotFound(err) {
+			debug.Logf(debug.Docker, "keeping replaced image %s: %v", previousID, err)
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to remove replaced image %s: %w", previousID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,164 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+)
+
+// ContainerConfig is the configuration an existing container was created with
+type ContainerConfig struct {
+	Image   string   // image reference the container was created from
+	ImageID string   // ID of the image the container runs
+	Mounts  []string // bind mounts in host:container[:options] form, then other mounts as Mount.String()
+	Env     []string // KEY=value, including the variables the image sets
+	// ImageEnv is the environment the container's image sets itself, nil when the
+	// image is no longer present
+	ImageEnv   []string
+	DNS        []string // DNS servers the container was given, empty for the daemon's
+	DNSSearch  []string // DNS search domains
+	ExtraHosts []string // additional /etc/hosts entries in name:ip form
+	// Privileged, CapAdd and SecurityOpt are the privileges the container was created with
+	Privileged  bool
+	CapAdd      []string
+	SecurityOpt []string
+	// Labels the container was created with, without labels backfilled by adoption
+	Labels map[string]string
+	// WorkDir and Command are the container's working directory and command
+	WorkDir string
+	Command []string
+	// BindSources are the host paths bind mounted into the container
+	BindSources []string
+	// NetworkMode, User and Init are as the container was created
+	NetworkMode string
+	User        string
+	Init        bool
+}
+
+// InspectContainerConfig returns the image, mounts, environment, DNS settings, privileges and command a container was created with
+func (s *Service) InspectContainerConfig(ctx context.Context, containerID string) (ContainerConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return ContainerConfig{}, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+
+	cfg := ContainerConfig{ImageID: info.Image}
+	if info.Config != nil {
+		cfg.Image = info.Config.Image
+		cfg.Env = info.Config.Env
+		cfg.Labels = info.Config.Labels
+		cfg.WorkDir, cfg.Command = info.Config.WorkingDir, info.Config.Cmd
+		cfg.User = info.Config.User
+	}
+	if info.HostConfig != nil {
+		cfg.Mounts = info.HostConfig.Binds
+		for _, bind := range info.HostConfig.Binds {
+			if source, _, _ := strings.Cut(bind, ":"); strings.HasPrefix(source, "/") {
+				cfg.BindSources = append(cfg.BindSources, source)
+			}
+		}
+		for _, m := range fromDockerMounts(info.HostConfig.Mounts) {
+			cfg.Mounts = append(cfg.Mounts, m.String())
+			if m.Type == string(mount.TypeBind) {
+				cfg.BindSources = append(cfg.BindSources, m.Source)
+			}
+		}
+		cfg.NetworkMode = string(info.HostConfig.NetworkMode)
+		cfg.Init = info.HostConfig.Init != nil && *info.HostConfig.Init
+		cfg.DNS, cfg.DNSSearch, cfg.ExtraHosts = info.HostConfig.DNS, info.HostConfig.DNSSearch, info.HostConfig.ExtraHosts
+		cfg.Privileged, cfg.CapAdd, cfg.SecurityOpt = info.HostConfig.Privileged, info.HostConfig.CapAdd, info.HostConfig.SecurityOpt
+	}
+
+	if imageInfo, err := s.client.ImageInspect(ctx, info.Image); err == nil && imageInfo.Config != nil {
+		cfg.ImageEnv = imageInfo.Config.Env
+		if cfg.ImageEnv == nil {
+			cfg.ImageEnv = []string{}
+		}
+	}
+	return cfg, nil
+}
+
+// ImageID returns the ID of a local image, empty when the image is not present
+func (s *Service) ImageID(ctx context.Context, imageName string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	info, err := s.client.ImageInspect(ctx, imageName)
+	if errdefs.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", imageName, err)
+	}
+	return info.ID, nil
+}
+
+// ContainerIP returns the IPv4 address of a running container on its first network by
+// name, which the host can reach directly on Linux
+func (s *Service) ContainerIP(ctx context.Context, containerID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if info.State == nil || !info.State.Running {
+		return "", fmt.Errorf("container %s is not running", containerID)
+	}
+	if info.NetworkSettings != nil {
+		names := make([]string, 0, len(info.NetworkSettings.Networks))
+		for name := range info.NetworkSettings.Networks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if endpoint := info.NetworkSettings.Networks[name]; endpoint != nil && endpoint.IPAddress != "" {
+				return endpoint.IPAddress, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("container %s has no IP address; it may use host networking or no network", containerID)
+}
+
+// ContainerTimes are when a container was last started and last stopped, zero when it
+// never was
+type ContainerTimes struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// InspectContainerTimes returns the start and stop times of containers, inspecting them
+// in parallel. Containers that cannot be inspected are left out.
+func (s *Service) InspectContainerTimes(ctx context.Context, containerIDs []string) map[string]ContainerTimes {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	times := make(map[string]ContainerTimes, len(containerIDs))
+	for _, id := range containerIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			info, err := s.client.ContainerInspect(ctx, id)
+			if err != nil || info.State == nil {
+				return
+			}
+			mu.Lock()
+			times[id] = ContainerTimes{StartedAt: parseDockerTime(info.State.StartedAt), FinishedAt: parseDockerTime(info.State.FinishedAt)}
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return times
+}
@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// JobDir is the directory inside the container that holds the output and process ID
+// of each detached job
+const JobDir = "/tmp/reactor-jobs"
+
+// jobWrapper records the job's process ID and redirects its output before exec'ing the
+// command, so the job can be inspected and stopped later from separate execs
+const jobWrapper = `mkdir -p "$(dirname "$REACTOR_JOB_LOG")" && echo $$ > "$REACTOR_JOB_PID" && exec "$@" > "$REACTOR_JOB_LOG" 2>&1`
+
+// JobLogPath returns the container path of a detached job's output
+func JobLogPath(jobID string) string {
+	return path.Join(JobDir, jobID+".log")
+}
+
+// JobPidPath returns the container path of a detached job's process ID file
+func JobPidPath(jobID string) string {
+	return path.Join(JobDir, jobID+".pid")
+}
+
+// jobFollower prints the log $1 as it grows until the process in the PID file $2 has
+// exited, then gives tail a moment to print the last of the output and stops it
+const jobFollower = `tail -n +1 -f "$1" & tail=$!
+while kill -0 "$(cat "$2")" 2>/dev/null; do sleep 1; done
+sleep 1; kill $tail`
+
+// JobLogsCommand returns the command that prints a detached job's output, following
+// it until the job exits when follow is set
+func JobLogsCommand(jobID string, follow bool) []string {
+	if follow {
+		return []string{"sh", "-c", jobFollower, "sh", JobLogPath(jobID), JobPidPath(jobID)}
+	}
+	return []string{"cat", JobLogPath(jobID)}
+}
+
+// JobStopCommand returns the command that sends SIGTERM to a detached job
+func JobStopCommand(jobID string) []string {
+	return []string{"sh", "-c", fmt.Sprintf(`kill -TERM "$(cat %s)"`, JobPidPath(jobID))}
+}
+
+// StartDetachedExec starts command in a running container without attaching to it and
+// returns the exec ID. Output is written to JobLogPath(jobID) inside the container.
+// env adds KEY=VALUE variables to the command's environment.
+func (s *Service) StartDetachedExec(ctx context.Context, containerID, jobID string, command, env []string) (string, error) {
+	if len(command) == 0 {
+		return "", fmt.Errorf("command array cannot be empty")
+	}
+
+	containerInfo, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if !containerInfo.State.Running {
+		return "", fmt.Errorf("container %s is not running, start it with 'reactor up'", containerID)
+	}
+
+	spec := newExecSpec(containerInfo, append([]string{"sh", "-c", jobWrapper, "sh"}, command...),
+		append([]string{"REACTOR_JOB_LOG=" + JobLogPath(jobID), "REACTOR_JOB_PID=" + JobPidPath(jobID)}, env...), "", false)
+	spec.Detach = true
+	execResp, err := s.client.ContainerExecCreate(ctx, containerID, spec.options())
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec instance: %w", err)
+	}
+	if err := s.client.ContainerExecStart(ctx, execResp.ID, container.ExecStartOptions{Detach: true}); err != nil {
+		return "", fmt.Errorf("failed to start exec instance: %w", err)
+	}
+	return execResp.ID, nil
+}
+
+// ExecStatus reports whether an exec is still running and, once it has finished, its exit code
+func (s *Service) ExecStatus(ctx context.Context, execID string) (running bool, exitCode int, err error) {
+	inspect, err := s.client.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to inspect exec %s: %w", execID, err)
+	}
+	return inspect.Running, inspect.ExitCode, nil
+}
@@ -0,0 +1,193 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/dyluth/reactor/pkg/ui"
+)
+
+// ExecutePostCreateCommand runs the postCreateCommand in the specified container
+// postCreateCommand can be a string, []string, or an object of named commands run in parallel
+func (s *Service) ExecutePostCreateCommand(ctx context.Context, containerID string, postCreateCommand interface{}) error {
+	return s.ExecuteLifecycleCommand(ctx, containerID, "postCreateCommand", postCreateCommand)
+}
+
+// ExecuteLifecycleCommand runs a devcontainer.json lifecycle command (postCreateCommand,
+// postStartCommand, ...) named hook in the specified container. A string runs through
+// the shell and an array runs as-is. An object maps names to commands of either form;
+// they run in parallel with their output prefixed by name, and every failure is reported.
+func (s *Service) ExecuteLifecycleCommand(ctx context.Context, containerID, hook string, command interface{}) error {
+	if command == nil {
+		// No command specified, nothing to do
+		return nil
+	}
+
+	var named map[string][]string
+	var cmdArray []string
+	switch cmd := command.(type) {
+	case map[string]interface{}:
+		named = make(map[string][]string, len(cmd))
+		for name, value := range cmd {
+			args, err := lifecycleCommandArgs(fmt.Sprintf("%s %q", hook, name), value)
+			if err != nil {
+				return err
+			}
+			if len(args) > 0 {
+				named[name] = args
+			}
+		}
+		if len(named) == 0 {
+			return nil
+		}
+	default:
+		args, err := lifecycleCommandArgs(hook, command)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return nil
+		}
+		cmdArray = args
+	}
+
+	// Check if container is running
+	containerInfo, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+
+	if !containerInfo.State.Running {
+		return fmt.Errorf("container %s is not running, cannot execute %s", containerID, hook)
+	}
+
+	if named != nil {
+		return s.runParallelLifecycleCommands(ctx, containerInfo, containerID, hook, named)
+	}
+
+	fmt.Printf("Executing %s: %v\n", hook, cmdArray)
+	spec := newExecSpec(containerInfo, cmdArray, nil, "", false)
+	if err := s.runLifecycleCommand(ctx, containerID, hook, spec, func(line string) { fmt.Println(line) }); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s completed successfully\n", hook)
+	return nil
+}
+
+// lifecycleCommandArgs converts the string or array form of a lifecycle command into
+// exec arguments. An empty command yields no arguments.
+func lifecycleCommandArgs(label string, command interface{}) ([]string, error) {
+	switch cmd := command.(type) {
+	case string:
+		if strings.TrimSpace(cmd) == "" {
+			return nil, nil
+		}
+		// For string commands, we'll execute them through the shell to handle complex commands
+		return []string{"/bin/sh", "-c", cmd}, nil
+	case []interface{}:
+		var args []string
+		for _, v := range cmd {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s array contains non-string element: %v", label, v)
+			}
+			args = append(args, str)
+		}
+		return args, nil
+	case []string:
+		return cmd, nil
+	default:
+		return nil, fmt.Errorf("%s must be a string or array of strings, got %T", label, command)
+	}
+}
+
+// runParallelLifecycleCommands runs the object form of a lifecycle command, prefixing
+// each line of output with the command's name
+func (s *Service) runParallelLifecycleCommands(ctx context.Context, containerInfo container.InspectResponse, containerID, hook string, named map[string][]string) error {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	style := ui.Stdout()
+	var mu sync.Mutex // keeps lines from different commands whole
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		fmt.Printf("Executing %s %q: %v\n", hook, name, named[name])
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			printLine := func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Printf("%s %s\n", style.Service(name), line)
+			}
+			spec := newExecSpec(containerInfo, named[name], nil, "", false)
+			errs[i] = s.runLifecycleCommand(ctx, containerID, fmt.Sprintf("%s %q", hook, name), spec, printLine)
+		}(i, name)
+	}
+	wg.Wait()
+
+	var failures []error
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d %s commands failed:\n%w", len(failures), len(names), hook, errors.Join(failures...))
+	}
+
+	fmt.Printf("%s completed successfully\n", hook)
+	return nil
+}
+
+// runLifecycleCommand executes one command, passing each line of its output to printLine
+func (s *Service) runLifecycleCommand(ctx context.Context, containerID, label string, spec ExecSpec, printLine func(string)) error {
+	execResp, err := s.client.ContainerExecCreate(ctx, containerID, spec.options())
+	if err != nil {
+		return fmt.Errorf("failed to create exec instance for %s: %w", label, err)
+	}
+
+	// Start the exec instance
+	if err := s.client.ContainerExecStart(ctx, execResp.ID, container.ExecStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start %s execution: %w", label, err)
+	}
+
+	// Attach to get output
+	attachResp, err := s.client.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to attach to %s execution: %w", label, err)
+	}
+	defer attachResp.Close()
+
+	// Stream the output
+	scanner := bufio.NewScanner(attachResp.Reader)
+	for scanner.Scan() {
+		printLine(scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s output: %w", label, err)
+	}
+
+	// Wait for the exec to complete and check exit code
+	inspectResp, err := s.client.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s execution: %w", label, err)
+	}
+
+	if inspectResp.ExitCode != 0 {
+		return fmt.Errorf("%s failed with exit code %d", label, inspectResp.ExitCode)
+	}
+	return nil
+}
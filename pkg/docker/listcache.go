@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/dyluth/reactor/pkg/debug"
+)
+
+// AdoptedLabels returns the labels 'reactor sessions adopt' backfilled, by container
+// ID. The CLI points it at the state file; it is nil when nothing is adopted, and the
+// state package cannot be imported here because it depends on this one in tests.
+var AdoptedLabels func() (map[string]map[string]string, error)
+
+// containerListCacheTTL bounds how stale a cached container listing may be. It only
+// needs to cover the lookups made while handling a single command.
+const containerListCacheTTL = time.Second
+
+// containerListCache holds the most recent listing of all containers so that
+// repeated lookups by name or label are filtered in memory instead of each
+// making a daemon round-trip
+type containerListCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	containers []container.Summary
+	fetched    time.Time
+}
+
+func newContainerListCache(ttl time.Duration) *containerListCache {
+	return &containerListCache{ttl: ttl}
+}
+
+// listAllContainers returns every container, including stopped ones, from the cache
+// when it is fresh and from the daemon otherwise
+func (s *Service) listAllContainers(ctx context.Context) ([]container.Summary, error) {
+	cache := s.listCache
+	if cache == nil {
+		containers, err := s.client.ContainerList(ctx, container.ListOptions{All: true})
+		if err != nil {
+			return nil, err
+		}
+		return withAdoptedLabels(containers), nil
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.containers != nil && time.Since(cache.fetched) < cache.ttl {
+		return cache.containers, nil
+	}
+
+	containers, err := s.client.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	if containers == nil {
+		containers = []container.Summary{}
+	}
+	containers = withAdoptedLabels(containers)
+	cache.containers = containers
+	cache.fetched = time.Now()
+	return containers, nil
+}
+
+// withAdoptedLabels adds the labels 'reactor sessions adopt' backfilled to the
+// containers they were recorded for. A label the container has itself is kept.
+func withAdoptedLabels(containers []container.Summary) []container.Summary {
+	if AdoptedLabels == nil {
+		return containers
+	}
+	adopted, err := AdoptedLabels()
+	if err != nil {
+		debug.Logf(debug.Docker, "cannot read adopted container labels: %v", err)
+		return containers
+	}
+	for i, c := range containers {
+		backfilled, ok := adopted[c.ID]
+		if !ok {
+			continue
+		}
+		labels := make(map[string]string, len(c.Labels)+len(backfilled))
+		for key, value := range backfilled {
+			labels[key] = value
+		}
+		for key, value := range c.Labels {
+			labels[key] = value
+		}
+		containers[i].Labels = labels
+	}
+	return containers
+}
+
+// InvalidateContainerCache discards the cached container listing. Service methods that
+// change containers call it themselves; callers that change containers through
+// GetClient must call it before looking containers up again.
+func (s *Service) InvalidateContainerCache() {
+	if s.listCache == nil {
+		return
+	}
+	s.listCache.mu.Lock()
+	s.listCache.containers = nil
+	s.listCache.mu.Unlock()
+}
+
+// ListContainersByLabels returns the containers, including stopped ones, that carry
+// all of the given labels, filtering a single container listing in memory
+func (s *Service) ListContainersByLabels(ctx context.Context, labels map[string]string) ([]ContainerInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	containers, err := s.listAllContainers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var matching []ContainerInfo
+	for _, c := range containers {
+		if !hasLabels(c.Labels, labels) {
+			continue
+		}
+		matching = append(matching, containerInfoFromSummary(c))
+	}
+	return matching, nil
+}
+
+func hasLabels(have, want map[string]string) bool {
+	for key, value := range want {
+		if v, ok := have[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// containerInfoFromSummary converts a container listing entry, using its first name
+func containerInfoFromSummary(c container.Summary) ContainerInfo {
+	name := ""
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+	info := ContainerInfo{
+		ID:     c.ID,
+		Name:   name,
+		Status: containerStatus(c.State),
+		Image:  c.Image,
+		Labels: c.Labels,
+		State:  c.State,
+		Health: parseHealth(c.Status),
+	}
+	if c.Created > 0 {
+		info.Created = time.Unix(c.Created, 0)
+	}
+	return info
+}
+
+// containerStatus maps a raw Docker state onto a ContainerStatus
+func containerStatus(state string) ContainerStatus {
+	switch state {
+	case "running":
+		return StatusRunning
+	case "exited", "stopped":
+		return StatusStopped
+	}
+	return StatusNotFound
+}
@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// Mount is a mount with options a "source:target:mode" bind string cannot express,
+// passed to Docker as a HostConfig.Mounts entry
+type Mount struct {
+	Type        string // bind, volume or tmpfs
+	Source      string // host path or volume name; empty for tmpfs
+	Target      string
+	ReadOnly    bool
+	Consistency string // consistent, cached or delegated; empty for the default
+	TmpfsSize   int64  // tmpfs size limit in bytes, 0 for no limit
+}
+
+// String describes a mount in the --mount option syntax, e.g. "type=tmpfs,target=/scratch,size=536870912"
+func (m Mount) String() string {
+	parts := []string{"type=" + m.Type}
+	if m.Source != "" {
+		parts = append(parts, "source="+m.Source)
+	}
+	parts = append(parts, "target="+m.Target)
+	if m.ReadOnly {
+		parts = append(parts, "readonly")
+	}
+	if m.Consistency != "" {
+		parts = append(parts, "consistency="+m.Consistency)
+	}
+	if m.TmpfsSize > 0 {
+		parts = append(parts, fmt.Sprintf("size=%d", m.TmpfsSize))
+	}
+	return strings.Join(parts, ",")
+}
+
+// toDockerMounts converts mounts to the HostConfig.Mounts form
+func toDockerMounts(mounts []Mount) []mount.Mount {
+	if len(mounts) == 0 {
+		return nil
+	}
+	converted := make([]mount.Mount, len(mounts))
+	for i, m := range mounts {
+		converted[i] = mount.Mount{
+			Type:        mount.Type(m.Type),
+			Source:      m.Source,
+			Target:      m.Target,
+			ReadOnly:    m.ReadOnly,
+			Consistency: mount.Consistency(m.Consistency),
+		}
+		if m.TmpfsSize > 0 {
+			converted[i].TmpfsOptions = &mount.TmpfsOptions{SizeBytes: m.TmpfsSize}
+		}
+	}
+	return converted
+}
+
+// fromDockerMounts converts HostConfig.Mounts entries back into mounts
+func fromDockerMounts(mounts []mount.Mount) []Mount {
+	converted := make([]Mount, len(mounts))
+	for i, m := range mounts {
+		converted[i] = Mount{
+			Type:        string(m.Type),
+			Source:      m.Source,
+			Target:      m.Target,
+			ReadOnly:    m.ReadOnly,
+			Consistency: string(m.Consistency),
+		}
+		if m.Consistency == mount.ConsistencyDefault {
+			converted[i].Consistency = ""
+		}
+		if m.TmpfsOptions != nil {
+			converted[i].TmpfsSize = m.TmpfsOptions.SizeBytes
+		}
+	}
+	return converted
+}
@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// pathAccessScript prints the user's uid and gid, then every argument the user cannot
+// read, write and enter
+const pathAccessScript = `id -u; id -g; for p in "$@"; do [ -r "$p" ] && [ -w "$p" ] && [ -x "$p" ] || echo "$p"; done`
+
+// PathAccess reports which paths the container's user cannot use
+type PathAccess struct {
+	UID          string
+	GID          string
+	Inaccessible []string
+}
+
+// CheckPathAccess checks, as the container's user, that each path can be read and written
+func (s *Service) CheckPathAccess(ctx context.Context, containerID string, paths []string) (PathAccess, error) {
+	var stdout, stderr bytes.Buffer
+	err := s.ExecCommand(ctx, containerID, ExecOptions{
+		Command: append([]string{"sh", "-c", pathAccessScript, "sh"}, paths...),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+	if err != nil {
+		return PathAccess{}, fmt.Errorf("failed to check mount permissions: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) < 2 {
+		return PathAccess{}, fmt.Errorf("failed to check mount permissions: unexpected output %q", stdout.String())
+	}
+	return PathAccess{UID: lines[0], GID: lines[1], Inaccessible: lines[2:]}, nil
+}
+
+// ChownPaths recursively gives paths in the container to uid:gid, running as root
+func (s *Service) ChownPaths(ctx context.Context, containerID, uid, gid string, paths []string) error {
+	var stderr bytes.Buffer
+	err := s.ExecCommand(ctx, containerID, ExecOptions{
+		Command: append([]string{"chown", "-R", uid + ":" + gid}, paths...),
+		User:    "root",
+		Stdout:  &stderr,
+		Stderr:  &stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change owner of %s: %w: %s", strings.Join(paths, ", "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// archAliases maps the uname names some tools report to the architectures images use
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x86-64":  "amd64",
+	"aarch64": "arm64",
+	"armhf":   "arm",
+	"armel":   "arm",
+}
+
+// ParsePlatform parses an "os/arch[/variant]" platform such as linux/arm64 or linux/arm/v7
+func ParsePlatform(value string) (ocispec.Platform, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ocispec.Platform{}, fmt.Errorf("platform '%s' must be os/arch or os/arch/variant, e.g. linux/amd64", value)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return ocispec.Platform{}, fmt.Errorf("platform '%s' must be os/arch or os/arch/variant, e.g. linux/amd64", value)
+		}
+	}
+	platform := ocispec.Platform{OS: strings.ToLower(parts[0]), Architecture: normalizeArch(parts[1])}
+	if len(parts) == 3 {
+		platform.Variant = strings.ToLower(parts[2])
+	}
+	return platform, nil
+}
+
+// FormatPlatform returns a platform in the os/arch[/variant] form docker uses
+func FormatPlatform(platform ocispec.Platform) string {
+	formatted := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		formatted += "/" + platform.Variant
+	}
+	return formatted
+}
+
+// PlatformSupported reports whether an image published for platforms runs on want
+// without emulation. Variants are only compared when both sides name one.
+func PlatformSupported(platforms []ocispec.Platform, want ocispec.Platform) bool {
+	for _, platform := range platforms {
+		if platform.OS != want.OS || normalizeArch(platform.Architecture) != normalizeArch(want.Architecture) {
+			continue
+		}
+		if platform.Variant == "" || want.Variant == "" || platform.Variant == want.Variant {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeArch returns the image architecture name of arch
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(arch)
+	if alias, ok := archAliases[arch]; ok {
+		return alias
+	}
+	return arch
+}
+
+// DaemonPlatform returns the platform containers run on natively on the daemon
+func (s *Service) DaemonPlatform(ctx context.Context) (ocispec.Platform, error) {
+	version, err := s.client.ServerVersion(ctx)
+	if err != nil {
+		return ocispec.Platform{}, fmt.Errorf("failed to get the Docker daemon's platform: %w", err)
+	}
+	return ocispec.Platform{OS: version.Os, Architecture: normalizeArch(version.Arch)}, nil
+}
+
+// ImagePlatforms returns the platforms an image is published for, from its manifest
+// list in the registry. Images the registry does not know, such as local builds, are
+// looked up locally instead.
+func (s *Service) ImagePlatforms(ctx context.Context, imageName string) ([]ocispec.Platform, error) {
+	options, err := s.pullOptions(imageName)
+	if err != nil {
+		return nil, err
+	}
+	inspect, registryErr := s.client.DistributionInspect(ctx, imageName, options.RegistryAuth)
+	if registryErr == nil {
+		var platforms []ocispec.Platform
+		for _, platform := range inspect.Platforms {
+			// Attestation manifests are listed with an unknown platform
+			if platform.OS != "unknown" {
+				platforms = append(platforms, platform)
+			}
+		}
+		return platforms, nil
+	}
+
+	local, err := s.client.ImageInspect(ctx, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect the manifest of %s: %w", imageName, registryErr)
+	}
+	return []ocispec.Platform{{OS: local.Os, Architecture: local.Architecture, Variant: local.Variant}}, nil
+}
+
+// SetImagePlatforms makes pulls of the given images, keyed by normalized name, fetch
+// the named platform instead of the daemon's own
+func (s *Service) SetImagePlatforms(platforms map[string]string) {
+	s.imagePlatforms = platforms
+}
+
+// localImageMatches reports whether the local copy of an image is of the platform it
+// is pulled for, so an image pulled earlier for another platform is pulled again
+func (s *Service) localImageMatches(ctx context.Context, imageName string) bool {
+	want, ok := s.imagePlatforms[NormalizeImageName(imageName)]
+	if !ok {
+		return true
+	}
+	platform, err := ParsePlatform(want)
+	if err != nil {
+		return true
+	}
+	local, err := s.client.ImageInspect(ctx, imageName)
+	if err != nil {
+		return false
+	}
+	return PlatformSupported([]ocispec.Platform{{OS: local.Os, Architecture: local.Architecture, Variant: local.Variant}}, platform)
+}
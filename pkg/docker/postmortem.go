@@ -0,0 +1,194 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// PostMortemFileName is the file in a container's log directory that describes the last
+// time the container exited with an error or was OOM-killed
+const PostMortemFileName = "last-exit.json"
+
+// PostMortemLogLines is the number of output lines kept in a post-mortem
+const PostMortemLogLines = 50
+
+// stoppedMarkerName is the file in a container's log directory holding the ID of a
+// container reactor stopped itself, so the exit code of that stop is not taken for a crash
+const stoppedMarkerName = ".stopped-by-reactor"
+
+// PostMortem records why a container stopped
+type PostMortem struct {
+	ContainerName string    `json:"containerName"`
+	ContainerID   string    `json:"containerId"`
+	Image         string    `json:"image"`
+	ExitCode      int       `json:"exitCode"`
+	OOMKilled     bool      `json:"oomKilled"`
+	Error         string    `json:"error,omitempty"`
+	StartedAt     time.Time `json:"startedAt"`
+	FinishedAt    time.Time `json:"finishedAt"`
+	RestartCount  int       `json:"restartCount"`
+	MemoryLimit   int64     `json:"memoryLimit,omitempty"` // bytes, 0 for no limit
+	Health        string    `json:"health,omitempty"`
+	LastLogLines  []string  `json:"lastLogLines"`
+	CapturedAt    time.Time `json:"capturedAt"`
+}
+
+// CapturePostMortem writes a post-mortem for a container that exited with an error or was
+// OOM-killed and returns it. Nil is returned for containers that are running, exited
+// cleanly, were stopped by reactor or were created without a log directory, and for
+// exits that were already recorded.
+func (s *Service) CapturePostMortem(ctx context.Context, containerID string) (*PostMortem, error) {
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	return s.capturePostMortem(ctx, info)
+}
+
+func (s *Service) capturePostMortem(ctx context.Context, info container.InspectResponse) (*PostMortem, error) {
+	if info.ContainerJSONBase == nil || info.Config == nil || info.State == nil {
+		return nil, nil
+	}
+	logDir := info.Config.Labels[LogDirLabel]
+	state := info.State
+	if logDir == "" || state.Running || state.Restarting || (state.ExitCode == 0 && !state.OOMKilled) {
+		return nil, nil
+	}
+	if stoppedByReactor(logDir, info.ID) {
+		return nil, nil
+	}
+	if previous, err := ReadPostMortem(logDir); err == nil && previous != nil &&
+		previous.ContainerID == info.ID && previous.FinishedAt.Equal(parseDockerTime(state.FinishedAt)) {
+		return nil, nil // this exit was already recorded
+	}
+
+	pm := PostMortem{
+		ContainerName: strings.TrimPrefix(info.Name, "/"),
+		ContainerID:   info.ID,
+		Image:         info.Config.Image,
+		ExitCode:      state.ExitCode,
+		OOMKilled:     state.OOMKilled,
+		Error:         state.Error,
+		StartedAt:     parseDockerTime(state.StartedAt),
+		FinishedAt:    parseDockerTime(state.FinishedAt),
+		RestartCount:  info.RestartCount,
+		CapturedAt:    time.Now().UTC(),
+	}
+	if info.HostConfig != nil {
+		pm.MemoryLimit = info.HostConfig.Memory
+	}
+	if state.Health != nil {
+		pm.Health = state.Health.Status
+	}
+
+	var output bytes.Buffer
+	if err := s.ContainerOutput(ctx, info.ID, LogOptions{Tail: strconv.Itoa(PostMortemLogLines)}, &output, &output); err != nil {
+		pm.LastLogLines = []string{fmt.Sprintf("(output unavailable: %v)", err)}
+	} else if text := strings.TrimRight(output.String(), "\n"); text != "" {
+		pm.LastLogLines = strings.Split(text, "\n")
+	}
+
+	data, err := json.MarshalIndent(pm, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode post-mortem: %w", err)
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	path := filepath.Join(logDir, PostMortemFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write post-mortem: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write post-mortem: %w", err)
+	}
+	return &pm, nil
+}
+
+// reportPostMortem captures a post-mortem before reactor changes a container's state,
+// telling the user when a crash was recorded. Failures only warn.
+func (s *Service) reportPostMortem(ctx context.Context, info container.InspectResponse) {
+	pm, err := s.capturePostMortem(ctx, info)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record why container %s stopped: %v\n", info.ID, err)
+		return
+	}
+	if pm != nil {
+		fmt.Fprintf(os.Stderr, "Warning: container %s stopped at %s: %s; see 'reactor status --last-exit'\n",
+			pm.ContainerName, pm.FinishedAt.Local().Format(time.DateTime), pm.Cause())
+	}
+}
+
+// ReadPostMortem returns the post-mortem in logDir, or nil when no abnormal exit was recorded
+func ReadPostMortem(logDir string) (*PostMortem, error) {
+	data, err := os.ReadFile(filepath.Join(logDir, PostMortemFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read post-mortem: %w", err)
+	}
+	var pm PostMortem
+	if err := json.Unmarshal(data, &pm); err != nil {
+		return nil, fmt.Errorf("failed to parse post-mortem %s: %w", filepath.Join(logDir, PostMortemFileName), err)
+	}
+	return &pm, nil
+}
+
+// Cause explains in a few words why the container stopped
+func (pm PostMortem) Cause() string {
+	switch {
+	case pm.OOMKilled:
+		return "killed by the out-of-memory killer"
+	case pm.ExitCode == 137:
+		return "killed (SIGKILL), possibly out of memory"
+	case pm.ExitCode == 139:
+		return "crashed with a segmentation fault (SIGSEGV)"
+	case pm.ExitCode == 143:
+		return "terminated (SIGTERM)"
+	case pm.ExitCode > 128 && pm.ExitCode < 160:
+		return fmt.Sprintf("killed by signal %d", pm.ExitCode-128)
+	case pm.Error != "":
+		return pm.Error
+	}
+	return fmt.Sprintf("main process exited with code %d", pm.ExitCode)
+}
+
+// markStoppedByReactor records that reactor is stopping a container on purpose
+func markStoppedByReactor(logDir, containerID string) error {
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(logDir, stoppedMarkerName), []byte(containerID), 0644)
+}
+
+// clearStoppedByReactor forgets a stop recorded by markStoppedByReactor, once the container runs again
+func clearStoppedByReactor(logDir string) {
+	_ = os.Remove(filepath.Join(logDir, stoppedMarkerName))
+}
+
+func stoppedByReactor(logDir, containerID string) bool {
+	data, err := os.ReadFile(filepath.Join(logDir, stoppedMarkerName))
+	return err == nil && strings.TrimSpace(string(data)) == containerID
+}
+
+// parseDockerTime parses an RFC 3339 time from the Docker API, returning the zero time
+// for the "0001-01-01T00:00:00Z" Docker reports for events that have not happened
+func parseDockerTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Year() <= 1 {
+		return time.Time{}
+	}
+	return t
+}
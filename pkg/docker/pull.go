@@ -0,0 +1,289 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	units "github.com/docker/go-units"
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/metrics"
+	"github.com/dyluth/reactor/pkg/settings"
+	"github.com/dyluth/reactor/pkg/ui"
+)
+
+// NormalizeImageName returns the canonical short form of an image reference so that
+// e.g. "alpine", "alpine:latest" and "docker.io/library/alpine" compare equal.
+// Names that cannot be parsed are returned unchanged.
+func NormalizeImageName(name string) string {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return name
+	}
+	return reference.FamiliarString(reference.TagNameOnly(named))
+}
+
+// UniqueImages normalizes image names and removes duplicates, keeping the first occurrence order
+func UniqueImages(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var unique []string
+	for _, name := range names {
+		normalized := NormalizeImageName(name)
+		if name == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		unique = append(unique, normalized)
+	}
+	return unique
+}
+
+// pullMessage is one line of the daemon's JSON pull progress stream
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+}
+
+// pullImageStream pulls an image and passes every progress message to onProgress
+func (s *Service) pullImageStream(ctx context.Context, imageName string, onProgress func(pullMessage)) error {
+	options, err := s.pullOptions(imageName)
+	if err != nil {
+		return err
+	}
+	debug.Logf(debug.Docker, "pulling %s (authenticated: %t)", imageName, options.RegistryAuth != "")
+	reader, err := s.client.ImagePull(ctx, imageName, options)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	// Drain the progress stream, surfacing any error reported by the daemon
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var msg pullMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("failed to pull image %s: %s", imageName, msg.Error)
+		}
+		if onProgress != nil {
+			onProgress(msg)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pull output for %s: %w", imageName, err)
+	}
+	return nil
+}
+
+// PullImages pulls every image that is not yet available locally, each unique image
+// once and all of them concurrently, with a combined progress display. The daemon
+// downloads layers shared between the images only once.
+func (s *Service) PullImages(ctx context.Context, images []string, out io.Writer) error {
+	var missing []string
+	for _, imageName := range UniqueImages(images) {
+		exists, err := s.ImageExists(ctx, imageName)
+		if err != nil {
+			return fmt.Errorf("failed to check if image exists: %w", err)
+		}
+		if !exists || !s.localImageMatches(ctx, imageName) {
+			missing = append(missing, imageName)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	defer s.profile.Track(metrics.PhasePull)()
+
+	display := newPullDisplay(missing, out)
+	display.start()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(missing))
+	for i, imageName := range missing {
+		wg.Add(1)
+		go func(i int, imageName string) {
+			defer wg.Done()
+			errs[i] = s.pullImageStream(ctx, imageName, func(msg pullMessage) {
+				display.update(imageName, msg)
+			})
+			display.finish(imageName, errs[i])
+		}(i, imageName)
+	}
+	wg.Wait()
+	display.stop()
+
+	var failed []string
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("image pre-pull failed:\n  - %s", strings.Join(failed, "\n  - "))
+	}
+	return nil
+}
+
+// layerProgress tracks the download of one image layer
+type layerProgress struct {
+	current, total int64
+	done           bool
+}
+
+// imagePullState is the progress of one image in a PullImages call
+type imagePullState struct {
+	layers map[string]*layerProgress
+	done   bool
+	err    error
+}
+
+// pullDisplay renders the progress of concurrent pulls, as one redrawn line per image
+// on a terminal and as start and completion lines otherwise
+type pullDisplay struct {
+	mu     sync.Mutex
+	out    io.Writer
+	tty    bool
+	order  []string
+	images map[string]*imagePullState
+	drawn  int
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func newPullDisplay(images []string, out io.Writer) *pullDisplay {
+	d := &pullDisplay{out: out, order: images, images: make(map[string]*imagePullState, len(images))}
+	for _, imageName := range images {
+		d.images[imageName] = &imagePullState{layers: map[string]*layerProgress{}}
+	}
+	d.tty = settings.ColorEnabled(out)
+	return d
+}
+
+func (d *pullDisplay) start() {
+	if !d.tty {
+		for _, imageName := range d.order {
+			_, _ = fmt.Fprintf(d.out, "Pulling image: %s\n", imageName)
+		}
+		return
+	}
+
+	d.stopCh = make(chan struct{})
+	d.doneCh = make(chan struct{})
+	go func() {
+		defer close(d.doneCh)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stopCh:
+				return
+			case <-ticker.C:
+				d.redraw()
+			}
+		}
+	}()
+}
+
+func (d *pullDisplay) stop() {
+	if d.tty {
+		close(d.stopCh)
+		<-d.doneCh
+		d.redraw()
+	}
+}
+
+// update records a progress message for an image
+func (d *pullDisplay) update(imageName string, msg pullMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Messages without progress details and a layer ID (e.g. "Digest: ...") are informational
+	if msg.ID == "" || strings.HasPrefix(msg.Status, "Pulling from") {
+		return
+	}
+	state := d.images[imageName]
+	layer, ok := state.layers[msg.ID]
+	if !ok {
+		layer = &layerProgress{}
+		state.layers[msg.ID] = layer
+	}
+	switch msg.Status {
+	case "Downloading":
+		layer.current, layer.total = msg.ProgressDetail.Current, msg.ProgressDetail.Total
+	case "Download complete", "Pull complete", "Already exists":
+		layer.done = true
+		layer.current = layer.total
+	}
+}
+
+// finish marks an image as pulled or failed
+func (d *pullDisplay) finish(imageName string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.images[imageName]
+	state.done = true
+	state.err = err
+	if d.tty {
+		return
+	}
+	style := ui.NewStyle(false)
+	if err != nil {
+		_, _ = fmt.Fprintf(d.out, "%s %v\n", style.Failure(), err)
+	} else {
+		_, _ = fmt.Fprintf(d.out, "%s Pulled %s\n", style.Success(), imageName)
+	}
+}
+
+// redraw rewrites the progress block in place
+func (d *pullDisplay) redraw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.drawn > 0 {
+		_, _ = fmt.Fprintf(d.out, "\033[%dA", d.drawn)
+	}
+	for _, imageName := range d.order {
+		_, _ = fmt.Fprintf(d.out, "\r\033[K%s\n", d.images[imageName].line(imageName))
+	}
+	d.drawn = len(d.order)
+}
+
+// line describes the progress of one image
+func (s *imagePullState) line(imageName string) string {
+	switch {
+	case s.err != nil:
+		return fmt.Sprintf("✗ %s: failed", imageName)
+	case s.done:
+		return fmt.Sprintf("✓ %s", imageName)
+	}
+
+	var current, total int64
+	done := 0
+	for _, layer := range s.layers {
+		current += layer.current
+		total += layer.total
+		if layer.done {
+			done++
+		}
+	}
+	if len(s.layers) == 0 {
+		return fmt.Sprintf("  %s: waiting", imageName)
+	}
+	return fmt.Sprintf("  %s: %d/%d layers, %s / %s", imageName, done, len(s.layers),
+		units.HumanSize(float64(current)), units.HumanSize(float64(total)))
+}
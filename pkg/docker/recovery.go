@@ -3,6 +3,9 @@ package docker
 import (
 	"context"
 	"fmt"
+
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/metrics"
 )
 
 // ProvisionContainer implements the three-phase container recovery strategy:
@@ -21,6 +24,7 @@ func (s *Service) ProvisionContainerWithCleanup(ctx context.Context, spec *Conta
 	if err != nil {
 		return ContainerInfo{}, fmt.Errorf("failed to check container existence: %w", err)
 	}
+	debug.Logf(debug.Docker, "container %s: %s (force cleanup: %t)", spec.Name, containerInfo.Status, forceCleanup)
 
 	switch containerInfo.Status {
 	case StatusRunning:
@@ -45,7 +49,10 @@ func (s *Service) ProvisionContainerWithCleanup(ctx context.Context, spec *Conta
 			}
 		} else {
 			// Normal mode: Container exists but is stopped - restart it
-			if err := s.StartContainer(ctx, containerInfo.ID); err != nil {
+			doneStart := s.profile.Track(metrics.PhaseStart)
+			err := s.StartContainer(ctx, containerInfo.ID)
+			doneStart()
+			if err != nil {
 				// If restart fails, remove the broken container and create new one
 				if removeErr := s.RemoveContainer(ctx, containerInfo.ID); removeErr != nil {
 					return ContainerInfo{}, fmt.Errorf("failed to start container %s and failed to remove it: start error: %w, remove error: %v", containerInfo.ID, err, removeErr)
@@ -65,13 +72,18 @@ func (s *Service) ProvisionContainerWithCleanup(ctx context.Context, spec *Conta
 	}
 
 	// Phase 3: Create new container
+	doneCreate := s.profile.Track(metrics.PhaseCreate)
 	newContainer, err := s.CreateContainer(ctx, spec)
+	doneCreate()
 	if err != nil {
 		return ContainerInfo{}, fmt.Errorf("failed to create new container: %w", err)
 	}
 
 	// Start the newly created container
-	if err := s.StartContainer(ctx, newContainer.ID); err != nil {
+	doneStart := s.profile.Track(metrics.PhaseStart)
+	err = s.StartContainer(ctx, newContainer.ID)
+	doneStart()
+	if err != nil {
 		// Clean up failed container
 		if removeErr := s.RemoveContainer(ctx, newContainer.ID); removeErr != nil {
 			return ContainerInfo{}, fmt.Errorf("failed to start new container %s and failed to remove it: start error: %w, remove error: %v", newContainer.ID, err, removeErr)
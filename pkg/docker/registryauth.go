@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// RegistryAuth supplies credentials for pulling images from private registries
+type RegistryAuth interface {
+	// AuthFor returns the credentials for an image's registry, false when there are none
+	AuthFor(imageName string) (registry.AuthConfig, bool, error)
+	// All returns the credentials of every known registry, keyed by server address
+	All() (map[string]registry.AuthConfig, error)
+}
+
+// SetRegistryAuth makes pulls and builds by this service authenticate with auth.
+// Without it the daemon pulls anonymously.
+func (s *Service) SetRegistryAuth(auth RegistryAuth) {
+	s.registryAuth = auth
+}
+
+// RegistryLogin checks credentials with the registry through the daemon, without storing them
+func (s *Service) RegistryLogin(ctx context.Context, auth registry.AuthConfig) error {
+	if _, err := s.client.RegistryLogin(ctx, auth); err != nil {
+		return fmt.Errorf("registry %s rejected the credentials: %w", auth.ServerAddress, err)
+	}
+	return nil
+}
+
+// pullOptions returns the options to pull an image with, including its registry
+// credentials and the platform set with SetImagePlatforms
+func (s *Service) pullOptions(imageName string) (image.PullOptions, error) {
+	options := image.PullOptions{Platform: s.imagePlatforms[NormalizeImageName(imageName)]}
+	if s.registryAuth == nil {
+		return options, nil
+	}
+	auth, ok, err := s.registryAuth.AuthFor(imageName)
+	if err != nil {
+		return image.PullOptions{}, fmt.Errorf("failed to look up registry credentials for %s: %w", imageName, err)
+	}
+	if !ok {
+		return options, nil
+	}
+	encoded, err := registry.EncodeAuthConfig(auth)
+	if err != nil {
+		return image.PullOptions{}, fmt.Errorf("failed to encode registry credentials: %w", err)
+	}
+	options.RegistryAuth = encoded
+	return options, nil
+}
+
+// buildAuthConfigs returns the credentials a build may pull its base images with
+func (s *Service) buildAuthConfigs() (map[string]registry.AuthConfig, error) {
+	if s.registryAuth == nil {
+		return nil, nil
+	}
+	configs, err := s.registryAuth.All()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up registry credentials: %w", err)
+	}
+	return configs, nil
+}
@@ -16,25 +16,54 @@ import (
 
 	"github.com/docker/docker/api/types/build"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/go-connections/nat"
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/metrics"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // Service manages Docker daemon interactions
 type Service struct {
-	client DockerClient
+	client       DockerClient
+	profile      *metrics.Profile
+	listCache    *containerListCache // nil disables caching of container listings
+	registryAuth RegistryAuth        // nil pulls anonymously
+	host         string              // the daemon address when not the default, for docker CLI commands
+	// imagePlatforms are the platforms to pull images for instead of the daemon's,
+	// keyed by normalized image name
+	imagePlatforms map[string]string
 }
 
-// NewService creates a new Docker service with a real Docker client
+// NewService creates a new Docker service with a real Docker client, connected to the
+// daemon DiscoverEndpoint finds. The daemon of a docker CLI context is reached the way
+// NewServiceForHost reaches it, over ssh or with the context's TLS material.
 func NewService() (*Service, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	opts := []client.Opt{client.FromEnv}
+	host := defaultHost()
+	// The Docker client cannot reach an ssh:// DOCKER_HOST on its own
+	if dockerHost := os.Getenv("DOCKER_HOST"); host == "" && strings.HasPrefix(dockerHost, "ssh://") {
+		host = dockerHost
+	}
+	if host != "" {
+		hostOptions, err := hostOpts(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Docker client for %s: %w", host, err)
+		}
+		opts = append(opts, hostOptions...)
+	}
+	opts = append(opts, client.WithAPIVersionNegotiation())
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
 	return &Service{
-		client: cli,
+		client:    cli,
+		listCache: newContainerListCache(containerListCacheTTL),
 	}, nil
 }
 
@@ -46,6 +75,11 @@ func NewServiceWithClient(client DockerClient) *Service {
 	}
 }
 
+// SetProfile records the timing of startup phases performed by this service into p
+func (s *Service) SetProfile(p *metrics.Profile) {
+	s.profile = p
+}
+
 // Close closes the Docker client connection
 func (s *Service) Close() error {
 	return s.client.Close()
@@ -67,18 +101,30 @@ func (s *Service) CheckHealth(ctx context.Context) error {
 	if ping.APIVersion == "" {
 		return fmt.Errorf("docker daemon responded but API version is unknown. Please check your Docker installation")
 	}
+	debug.Logf(debug.Docker, "daemon reachable, API version %s", ping.APIVersion)
 
 	return nil
 }
 
+// APIVersion returns the Docker API version negotiated with the daemon
+func (s *Service) APIVersion(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ping, err := s.client.Ping(ctx)
+	if err != nil {
+		return "", fmt.Errorf("docker daemon is not accessible: %w", err)
+	}
+	s.client.NegotiateAPIVersionPing(ping)
+	return s.client.ClientVersion(), nil
+}
+
 // ContainerExists checks if a container with the given name exists
 func (s *Service) ContainerExists(ctx context.Context, name string) (ContainerInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	containers, err := s.client.ContainerList(ctx, container.ListOptions{
-		All: true, // Include stopped containers
-	})
+	containers, err := s.listAllContainers(ctx)
 	if err != nil {
 		return ContainerInfo{}, fmt.Errorf("failed to list containers: %w", err)
 	}
@@ -87,22 +133,9 @@ func (s *Service) ContainerExists(ctx context.Context, name string) (ContainerIn
 		for _, containerName := range container.Names {
 			// Container names have leading slash, so check with and without
 			if containerName == "/"+name || containerName == name {
-				var status ContainerStatus
-				switch container.State {
-				case "running":
-					status = StatusRunning
-				case "exited", "stopped":
-					status = StatusStopped
-				default:
-					status = StatusNotFound
-				}
-
-				return ContainerInfo{
-					ID:     container.ID,
-					Name:   name,
-					Status: status,
-					Image:  container.Image,
-				}, nil
+				info := containerInfoFromSummary(container)
+				info.Name = name
+				return info, nil
 			}
 		}
 	}
@@ -112,12 +145,58 @@ func (s *Service) ContainerExists(ctx context.Context, name string) (ContainerIn
 	}, nil
 }
 
+// DiskLimitLabel records the writable layer size limit a container was created with
+const DiskLimitLabel = "com.reactor.disk-limit"
+
+// isStorageOptUnsupported reports whether a create error means the storage driver rejected --storage-opt
+func isStorageOptUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "storage-opt") || strings.Contains(msg, "storage opt")
+}
+
+// ContainerDiskUsage returns the size of a container's writable layer in bytes
+func (s *Service) ContainerDiskUsage(ctx context.Context, name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// Computing sizes is expensive, so restrict the listing to the one container
+	containers, err := s.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Size:    true,
+		Filters: filters.NewArgs(filters.Arg("name", "^/"+name+"$")),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get disk usage of container %s: %w", name, err)
+	}
+	if len(containers) == 0 {
+		return 0, fmt.Errorf("container %s not found", name)
+	}
+	return containers[0].SizeRw, nil
+}
+
 // ContainerInfo holds information about a container
 type ContainerInfo struct {
-	ID     string
-	Name   string
-	Status ContainerStatus
-	Image  string
+	ID      string
+	Name    string
+	Status  ContainerStatus
+	Image   string
+	Labels  map[string]string
+	State   string    // Raw Docker state (created, running, restarting, exited, ...)
+	Health  string    // Healthcheck status (starting, healthy, unhealthy) or empty without a healthcheck
+	Created time.Time // when the container was created, zero when unknown
+}
+
+// parseHealth extracts the healthcheck status from a Docker status string such as "Up 5 minutes (unhealthy)"
+func parseHealth(status string) string {
+	switch {
+	case strings.Contains(status, "(health: starting)"):
+		return "starting"
+	case strings.Contains(status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.Contains(status, "(healthy)"):
+		return "healthy"
+	}
+	return ""
 }
 
 // ContainerStatus represents the status of a container
@@ -133,6 +212,7 @@ const (
 func (s *Service) CreateContainer(ctx context.Context, spec *ContainerSpec) (ContainerInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
+	defer s.InvalidateContainerCache()
 
 	// Create port bindings for container and host configuration
 	exposedPorts := nat.PortSet{}
@@ -171,7 +251,9 @@ func (s *Service) CreateContainer(ctx context.Context, spec *ContainerSpec) (Con
 	// Create container configuration
 	containerConfig := &container.Config{
 		Image:        spec.Image,
+		Entrypoint:   spec.Entrypoint,
 		Cmd:          spec.Command,
+		OpenStdin:    spec.OpenStdin,
 		WorkingDir:   spec.WorkDir,
 		User:         spec.User,
 		Env:          spec.Environment,
@@ -182,12 +264,58 @@ func (s *Service) CreateContainer(ctx context.Context, spec *ContainerSpec) (Con
 	// Create host configuration (mounts, network, ports, etc.)
 	hostConfig := &container.HostConfig{
 		Binds:        spec.Mounts,
+		Mounts:       toDockerMounts(spec.ExtraMounts),
+		Tmpfs:        spec.Tmpfs,
 		NetworkMode:  container.NetworkMode(spec.NetworkMode),
 		PortBindings: portBindings,
+		DNS:          spec.DNS,
+		DNSSearch:    spec.DNSSearch,
+		ExtraHosts:   spec.ExtraHosts,
+		Privileged:   spec.Privileged,
+		CapAdd:       spec.CapAdd,
+		SecurityOpt:  spec.SecurityOpt,
+	}
+	if spec.Init {
+		init := true
+		hostConfig.Init = &init
+	}
+	if spec.LogDir != "" {
+		labels[LogDirLabel] = spec.LogDir
+	}
+	if spec.DiskLimit != "" {
+		hostConfig.StorageOpt = map[string]string{"size": spec.DiskLimit}
+		labels[DiskLimitLabel] = spec.DiskLimit
+	}
+
+	debug.Logf(debug.Docker, "creating container %s from %s: %d binds, %d mounts, %d tmpfs, %d env vars, %d ports, network %q",
+		spec.Name, spec.Image, len(spec.Mounts), len(spec.ExtraMounts), len(spec.Tmpfs), len(spec.Environment), len(spec.PortMappings), spec.NetworkMode)
+
+	var platform *ocispec.Platform
+	if spec.Platform != "" {
+		parsed, err := ParsePlatform(spec.Platform)
+		if err != nil {
+			return ContainerInfo{}, err
+		}
+		platform = &parsed
 	}
 
 	// Create the container
-	resp, err := s.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, spec.Name)
+	resp, err := s.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, platform, spec.Name)
+	if err != nil && spec.DiskLimit != "" && isStorageOptUnsupported(err) {
+		// Only some storage drivers (e.g. overlay2 on xfs with pquota) can limit the
+		// writable layer, so fall back to an unlimited container rather than failing
+		fmt.Fprintf(os.Stderr, "Warning: disk limit %s not applied, the storage driver does not support it: %v\n", spec.DiskLimit, err)
+		hostConfig.StorageOpt = nil
+		delete(labels, DiskLimitLabel)
+		resp, err = s.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, platform, spec.Name)
+	}
+	if errdefs.IsConflict(err) {
+		// Another container took the name, e.g. one reactor does not list as its own
+		s.InvalidateContainerCache()
+		if holder, lookupErr := s.ContainerExists(ctx, spec.Name); lookupErr == nil && holder.ID != "" {
+			return ContainerInfo{}, &NameConflictError{Name: spec.Name, Holder: holder}
+		}
+	}
 	if err != nil {
 		return ContainerInfo{}, fmt.Errorf("failed to create container %s: %w", spec.Name, err)
 	}
@@ -200,14 +328,43 @@ func (s *Service) CreateContainer(ctx context.Context, spec *ContainerSpec) (Con
 	}, nil
 }
 
+// NameConflictError reports that the name of a container to create is taken by another
+// container
+type NameConflictError struct {
+	Name   string
+	Holder ContainerInfo
+}
+
+func (e *NameConflictError) Error() string {
+	id := e.Holder.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return fmt.Sprintf("container name %s is already used by container %s (image %s, %s)", e.Name, id, e.Holder.Image, e.Holder.State)
+}
+
 // StartContainer starts a stopped container
 func (s *Service) StartContainer(ctx context.Context, containerID string) error {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
+	defer s.InvalidateContainerCache()
+
+	// Starting resets the exit state, so record why the container stopped first
+	logDir := ""
+	if info, err := s.client.ContainerInspect(ctx, containerID); err == nil {
+		if info.Config != nil {
+			logDir = info.Config.Labels[LogDirLabel]
+		}
+		s.reportPostMortem(ctx, info)
+	}
 
+	debug.Logf(debug.Docker, "starting container %s", containerID)
 	if err := s.client.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
 		return fmt.Errorf("failed to start container %s: %w", containerID, err)
 	}
+	if logDir != "" {
+		clearStoppedByReactor(logDir)
+	}
 
 	return nil
 }
@@ -216,22 +373,48 @@ func (s *Service) StartContainer(ctx context.Context, containerID string) error
 func (s *Service) StopContainer(ctx context.Context, containerID string) error {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
+	defer s.InvalidateContainerCache()
+
+	// A container killed after the timeout exits with 137, which is not a crash
+	logDir := ""
+	if info, err := s.client.ContainerInspect(ctx, containerID); err == nil && info.Config != nil && info.State != nil {
+		if info.State.Running {
+			logDir = info.Config.Labels[LogDirLabel]
+		} else {
+			s.reportPostMortem(ctx, info)
+		}
+	}
+	if logDir != "" {
+		if err := markStoppedByReactor(logDir, containerID); err != nil {
+			debug.Logf(debug.Docker, "failed to mark container %s as stopped by reactor: %v", containerID, err)
+		}
+	}
 
 	timeout := 10 // Give container 10 seconds to stop gracefully
+	debug.Logf(debug.Docker, "stopping container %s (timeout %ds)", containerID, timeout)
 	if err := s.client.ContainerStop(ctx, containerID, container.StopOptions{
 		Timeout: &timeout,
 	}); err != nil {
+		if logDir != "" {
+			clearStoppedByReactor(logDir)
+		}
 		return fmt.Errorf("failed to stop container %s: %w", containerID, err)
 	}
 
 	return nil
 }
 
-// RemoveContainer removes a container (must be stopped first)
+// RemoveContainer removes a container (must be stopped first). The output of containers
+// created with a LogDir is archived first so it can still be read with 'reactor logs',
+// and a post-mortem is written when the container had crashed.
 func (s *Service) RemoveContainer(ctx context.Context, containerID string) error {
+	defer s.InvalidateContainerCache()
+	s.archiveBeforeRemoval(ctx, containerID)
+
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
+	debug.Logf(debug.Docker, "removing container %s", containerID)
 	if err := s.client.ContainerRemove(ctx, containerID, container.RemoveOptions{
 		Force: true, // Force removal even if running
 	}); err != nil {
@@ -241,11 +424,62 @@ func (s *Service) RemoveContainer(ctx context.Context, containerID string) error
 	return nil
 }
 
+// archiveBeforeRemoval records the post-mortem and archives the output of a container
+// about to be removed, within archiveLogsTimeout of its own. Failures are warnings, as
+// the container is removed either way.
+func (s *Service) archiveBeforeRemoval(ctx context.Context, containerID string) {
+	ctx, cancel := context.WithTimeout(ctx, archiveLogsTimeout)
+	defer cancel()
+
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return
+	}
+	s.reportPostMortem(ctx, info)
+	if err := s.archiveContainerLogs(ctx, containerID, info); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to archive output of container %s: %v\n", containerID, err)
+	}
+}
+
+// RenameContainer gives a container a new name, doing nothing when it already has it
+func (s *Service) RenameContainer(ctx context.Context, containerID, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	info, err := s.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if strings.TrimPrefix(info.Name, "/") == name {
+		return nil
+	}
+
+	defer s.InvalidateContainerCache()
+	if err := s.client.ContainerRename(ctx, containerID, name); err != nil {
+		return fmt.Errorf("failed to rename container %s to %s: %w", containerID, name, err)
+	}
+	return nil
+}
+
 // BuildSpec defines the specification for building a Docker image
 type BuildSpec struct {
 	Dockerfile string // Path to Dockerfile relative to context
 	Context    string // Path to build context directory
 	ImageName  string // Name to tag the built image with
+	Progress   string // Progress mode: auto (default), plain or tty
+	LogFile    string // Optional path the full build output is written to
+	// BuildArgs are passed to the build, e.g. the host's proxy variables
+	BuildArgs  map[string]*string
+	ExtraHosts []string // Additional /etc/hosts entries of build containers, in name:ip form
+	// Labels are added to the image, e.g. where it was built from
+	Labels map[string]string
+	// Secrets and SSH are passed as 'docker build --secret' and '--ssh' values, e.g.
+	// "id=npmrc,src=/home/me/.npmrc" and "default"; builds with either run through the
+	// docker CLI
+	Secrets []string
+	SSH     []string
+	// Output receives progress messages and the build output; nil means stdout
+	Output io.Writer
 }
 
 // ContainerSpec defines the specification for creating a container
@@ -258,13 +492,27 @@ type PortMapping struct {
 type ContainerSpec struct {
 	Name         string
 	Image        string
+	Entrypoint   []string // Overrides the image entrypoint when set
 	Command      []string
+	Init         bool   // Run docker-init as PID 1 to forward signals and reap zombies
+	DiskLimit    string // Writable layer size limit (storage-opt size), applied when the driver supports it
+	LogDir       string // Host directory the container's output is archived to when it is removed
+	OpenStdin    bool   // Keep stdin open so an image's own shell CMD does not exit immediately
 	WorkDir      string
 	User         string
 	Environment  []string
-	Mounts       []string      // In "source:target:mode" format
-	PortMappings []PortMapping // Port forwarding configurations
+	Mounts       []string          // In "source:target:mode" format
+	ExtraMounts  []Mount           // Mounts with options, passed as HostConfig.Mounts
+	Tmpfs        map[string]string // In-memory mounts by container path, with mount options
+	PortMappings []PortMapping     // Port forwarding configurations
 	NetworkMode  string
+	DNS          []string          // DNS servers instead of the daemon's
+	DNSSearch    []string          // DNS search domains
+	ExtraHosts   []string          // Additional /etc/hosts entries in "name:ip" form
+	Privileged   bool              // Give the container all capabilities and host devices
+	CapAdd       []string          // Linux capabilities added to the default set
+	SecurityOpt  []string          // Security options, e.g. "seccomp=unconfined"
+	Platform     string            // Run the image for this os/arch, under emulation if need be
 	Labels       map[string]string // Docker labels for container identification
 }
 
@@ -273,9 +521,7 @@ func (s *Service) ListReactorContainers(ctx context.Context) ([]ContainerInfo, e
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	containers, err := s.client.ContainerList(ctx, container.ListOptions{
-		All: true, // Include stopped containers
-	})
+	containers, err := s.listAllContainers(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
@@ -288,22 +534,9 @@ func (s *Service) ListReactorContainers(ctx context.Context) ([]ContainerInfo, e
 
 			// Check if this is a reactor container (with or without isolation prefix)
 			if s.isReactorContainer(name) {
-				var status ContainerStatus
-				switch c.State {
-				case "running":
-					status = StatusRunning
-				case "exited", "stopped":
-					status = StatusStopped
-				default:
-					status = StatusNotFound
-				}
-
-				reactorContainers = append(reactorContainers, ContainerInfo{
-					ID:     c.ID,
-					Name:   name,
-					Status: status,
-					Image:  c.Image,
-				})
+				info := containerInfoFromSummary(c)
+				info.Name = name
+				reactorContainers = append(reactorContainers, info)
 				break // Found matching name, no need to check other names for this container
 			}
 		}
@@ -317,45 +550,18 @@ func (s *Service) ListContainersByLabel(ctx context.Context, labelKey, labelValu
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	containers, err := s.client.ContainerList(ctx, container.ListOptions{
-		All: true, // Include stopped containers
-	})
+	containers, err := s.listAllContainers(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list containers by label %s=%s: %w", labelKey, labelValue, err)
 	}
 
 	var matchingContainers []ContainerInfo
 	for _, c := range containers {
-		// Check if container has the specified label with the specified value
-		if c.Labels != nil {
-			if value, exists := c.Labels[labelKey]; !exists || value != labelValue {
-				continue // Skip containers that don't have the label or have different value
-			}
-		} else {
-			continue // Skip containers with no labels
-		}
-		containerName := ""
-		if len(c.Names) > 0 {
-			// Container names have leading slash, so remove it
-			containerName = strings.TrimPrefix(c.Names[0], "/")
-		}
-
-		var status ContainerStatus
-		switch c.State {
-		case "running":
-			status = StatusRunning
-		case "exited", "stopped":
-			status = StatusStopped
-		default:
-			status = StatusNotFound
+		// Skip containers that don't have the label or have a different value
+		if !hasLabels(c.Labels, map[string]string{labelKey: labelValue}) {
+			continue
 		}
-
-		matchingContainers = append(matchingContainers, ContainerInfo{
-			ID:     c.ID,
-			Name:   containerName,
-			Status: status,
-			Image:  c.Image,
-		})
+		matchingContainers = append(matchingContainers, containerInfoFromSummary(c))
 	}
 
 	return matchingContainers, nil
@@ -504,17 +710,65 @@ func (s *Service) ImageExists(ctx context.Context, imageName string) (bool, erro
 	return false, nil
 }
 
+// EnsureImage pulls an image unless it is already available locally
+func (s *Service) EnsureImage(ctx context.Context, imageName string) error {
+	doneImageCheck := s.profile.Track(metrics.PhaseImageCheck)
+	exists, err := s.ImageExists(ctx, imageName)
+	if err == nil && !exists && !strings.Contains(imageName[strings.LastIndex(imageName, "/")+1:], ":") {
+		// Untagged references are stored locally with the implicit latest tag
+		exists, err = s.ImageExists(ctx, imageName+":latest")
+	}
+	doneImageCheck()
+	if err != nil {
+		return fmt.Errorf("failed to check if image exists: %w", err)
+	}
+	if exists && s.localImageMatches(ctx, imageName) {
+		return nil
+	}
+
+	return s.PullImage(ctx, imageName)
+}
+
+// ImageCommand returns the image's default process: its ENTRYPOINT followed by its CMD
+func (s *Service) ImageCommand(ctx context.Context, imageName string) ([]string, error) {
+	inspect, err := s.client.ImageInspect(ctx, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", imageName, err)
+	}
+	if inspect.Config == nil {
+		return nil, nil
+	}
+
+	command := append([]string{}, inspect.Config.Entrypoint...)
+	return append(command, inspect.Config.Cmd...), nil
+}
+
+// PullImage pulls an image from its registry, waiting for the pull to complete
+func (s *Service) PullImage(ctx context.Context, imageName string) error {
+	defer s.profile.Track(metrics.PhasePull)()
+
+	fmt.Printf("Pulling image: %s\n", imageName)
+	return s.pullImageStream(ctx, imageName, nil)
+}
+
 // BuildImage builds a Docker image from the given BuildSpec
 // It checks if the image already exists and skips building if found, unless forceRebuild is true
 func (s *Service) BuildImage(ctx context.Context, spec BuildSpec, forceRebuild bool) error {
+	out := spec.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
 	// Check if image already exists (unless forcing rebuild)
 	if !forceRebuild {
+		doneImageCheck := s.profile.Track(metrics.PhaseImageCheck)
 		exists, err := s.ImageExists(ctx, spec.ImageName)
+		doneImageCheck()
 		if err != nil {
 			return fmt.Errorf("failed to check if image exists: %w", err)
 		}
 		if exists {
-			fmt.Printf("Image %s already exists, skipping build\n", spec.ImageName)
+			_, _ = fmt.Fprintf(out, "Image %s already exists, skipping build\n", spec.ImageName)
 			return nil
 		}
 	}
@@ -530,42 +784,88 @@ func (s *Service) BuildImage(ctx context.Context, spec BuildSpec, forceRebuild b
 		return fmt.Errorf("dockerfile does not exist: %s", dockerfilePath)
 	}
 
-	fmt.Printf("Building Docker image: %s\n", spec.ImageName)
-	fmt.Printf("Context: %s\n", spec.Context)
-	fmt.Printf("Dockerfile: %s\n", spec.Dockerfile)
+	buildLog, err := newBuildLog(spec.LogFile, spec.Progress, out)
+	if err != nil {
+		return err
+	}
+
+	defer s.profile.Track(metrics.PhaseBuild)()
+	started := time.Now()
+
+	_, _ = fmt.Fprintf(out, "Building Docker image: %s\n", spec.ImageName)
+	_, _ = fmt.Fprintf(out, "Context: %s\n", spec.Context)
+	_, _ = fmt.Fprintf(out, "Dockerfile: %s\n", spec.Dockerfile)
 
 	// Create build context tar archive
-	buildContext, err := s.createBuildContext(spec.Context)
+	buildContext, err := s.createBuildContext(spec.Context, spec.Dockerfile)
 	if err != nil {
+		_ = buildLog.Close()
 		return fmt.Errorf("failed to create build context: %w", err)
 	}
 	defer func() { _ = buildContext.Close() }()
 
+	authConfigs, err := s.buildAuthConfigs()
+	if err != nil {
+		_ = buildLog.Close()
+		return err
+	}
+
+	debug.Logf(debug.Docker, "building %s from %s with %s, credentials for %d registries", spec.ImageName, spec.Context, spec.Dockerfile, len(authConfigs))
+
+	if len(spec.Secrets) > 0 || len(spec.SSH) > 0 {
+		streamErr := s.buildWithCLI(ctx, spec, buildContext, buildLog)
+		if err := buildLog.Close(); err != nil && streamErr == nil {
+			streamErr = err
+		}
+		if streamErr != nil {
+			buildLog.DumpTail(os.Stderr)
+			return fmt.Errorf("build failed: %w", streamErr)
+		}
+		_, _ = fmt.Fprintf(out, "Successfully built image: %s in %s (%s)\n", spec.ImageName, time.Since(started).Round(100*time.Millisecond), buildLog.Summary())
+		return nil
+	}
+
 	// Build the image
 	buildOptions := build.ImageBuildOptions{
-		Context:    buildContext,
-		Dockerfile: spec.Dockerfile,
-		Tags:       []string{spec.ImageName},
-		Remove:     true, // Remove intermediate containers
+		Context:     buildContext,
+		Dockerfile:  spec.Dockerfile,
+		Tags:        []string{spec.ImageName},
+		Remove:      true, // Remove intermediate containers
+		AuthConfigs: authConfigs,
+		BuildArgs:   spec.BuildArgs,
+		ExtraHosts:  spec.ExtraHosts,
+		Labels:      spec.Labels,
 	}
 
 	response, err := s.client.ImageBuild(ctx, buildContext, buildOptions)
 	if err != nil {
+		_ = buildLog.Close()
 		return fmt.Errorf("failed to build image: %w", err)
 	}
 	defer func() { _ = response.Body.Close() }()
 
-	// Stream build output to console with real-time feedback
-	if err := s.streamBuildOutput(response.Body); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+	// Record the build output, streaming or condensing it depending on the progress mode
+	streamErr := s.streamBuildOutput(response.Body, buildLog)
+	if err := buildLog.Close(); err != nil && streamErr == nil {
+		streamErr = err
+	}
+	if streamErr != nil {
+		buildLog.DumpTail(os.Stderr)
+		return fmt.Errorf("build failed: %w", streamErr)
 	}
 
-	fmt.Printf("Successfully built image: %s\n", spec.ImageName)
+	_, _ = fmt.Fprintf(out, "Successfully built image: %s in %s (%s)\n", spec.ImageName, time.Since(started).Round(100*time.Millisecond), buildLog.Summary())
 	return nil
 }
 
-// createBuildContext creates a tar archive of the build context directory
-func (s *Service) createBuildContext(contextPath string) (io.ReadCloser, error) {
+// createBuildContext creates a tar archive of the build context directory, leaving out
+// files excluded by .dockerignore and DefaultContextExclusions
+func (s *Service) createBuildContext(contextPath, dockerfile string) (io.ReadCloser, error) {
+	filter, err := LoadContextFilter(contextPath, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
 	pr, pw := io.Pipe()
 
 	go func() {
@@ -573,22 +873,7 @@ func (s *Service) createBuildContext(contextPath string) (io.ReadCloser, error)
 		tw := tar.NewWriter(pw)
 		defer func() { _ = tw.Close() }()
 
-		err := filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			// Get relative path from context directory
-			relPath, err := filepath.Rel(contextPath, path)
-			if err != nil {
-				return err
-			}
-
-			// Skip if this is the context directory itself
-			if relPath == "." {
-				return nil
-			}
-
+		err := walkBuildContext(contextPath, filter, func(path, relPath string, info os.FileInfo) error {
 			// Create tar header
 			header, err := tar.FileInfoHeader(info, "")
 			if err != nil {
@@ -627,8 +912,8 @@ func (s *Service) createBuildContext(contextPath string) (io.ReadCloser, error)
 	return pr, nil
 }
 
-// streamBuildOutput processes Docker build output and streams it to console
-func (s *Service) streamBuildOutput(reader io.Reader) error {
+// streamBuildOutput processes Docker build output and writes it to the build log
+func (s *Service) streamBuildOutput(reader io.Reader, out io.Writer) error {
 	scanner := bufio.NewScanner(reader)
 
 	for scanner.Scan() {
@@ -639,127 +924,31 @@ func (s *Service) streamBuildOutput(reader io.Reader) error {
 
 		line := scanner.Text()
 		if err := json.Unmarshal([]byte(line), &buildOutput); err != nil {
-			// If we can't parse as JSON, just print the raw line
-			fmt.Print(line + "\n")
+			// If we can't parse as JSON, just record the raw line
+			if _, err := io.WriteString(out, line+"\n"); err != nil {
+				return err
+			}
 			continue
 		}
 
 		// Handle build errors
 		if buildOutput.Error != "" {
+			_, _ = io.WriteString(out, "ERROR: "+buildOutput.Error+"\n")
 			return fmt.Errorf("build error: %s", buildOutput.Error)
 		}
 
 		// Stream build output preserving ANSI colors
 		if buildOutput.Stream != "" {
-			fmt.Print(buildOutput.Stream)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading build output: %w", err)
-	}
-
-	return nil
-}
-
-// ExecutePostCreateCommand runs the postCreateCommand in the specified container
-// postCreateCommand can be either a string or []string (array of strings)
-func (s *Service) ExecutePostCreateCommand(ctx context.Context, containerID string, postCreateCommand interface{}) error {
-	if postCreateCommand == nil {
-		// No postCreateCommand specified, nothing to do
-		return nil
-	}
-
-	// Parse postCreateCommand into command array
-	var cmdArray []string
-	switch cmd := postCreateCommand.(type) {
-	case string:
-		if strings.TrimSpace(cmd) == "" {
-			// Empty command, nothing to do
-			return nil
-		}
-		// For string commands, we'll execute them through the shell to handle complex commands
-		cmdArray = []string{"/bin/sh", "-c", cmd}
-	case []interface{}:
-		if len(cmd) == 0 {
-			// Empty array, nothing to do
-			return nil
-		}
-		// Convert []interface{} to []string
-		for _, v := range cmd {
-			if str, ok := v.(string); ok {
-				cmdArray = append(cmdArray, str)
-			} else {
-				return fmt.Errorf("postCreateCommand array contains non-string element: %v", v)
+			if _, err := io.WriteString(out, buildOutput.Stream); err != nil {
+				return err
 			}
 		}
-	case []string:
-		if len(cmd) == 0 {
-			// Empty array, nothing to do
-			return nil
-		}
-		cmdArray = cmd
-	default:
-		return fmt.Errorf("postCreateCommand must be a string or array of strings, got %T", postCreateCommand)
-	}
-
-	// Check if container is running
-	containerInfo, err := s.client.ContainerInspect(ctx, containerID)
-	if err != nil {
-		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
-	}
-
-	if !containerInfo.State.Running {
-		return fmt.Errorf("container %s is not running, cannot execute postCreateCommand", containerID)
-	}
-
-	fmt.Printf("Executing postCreateCommand: %v\n", cmdArray)
-
-	// Create exec instance for postCreateCommand
-	execConfig := container.ExecOptions{
-		AttachStdout: true,
-		AttachStderr: true,
-		Cmd:          cmdArray,
-	}
-
-	execResp, err := s.client.ContainerExecCreate(ctx, containerID, execConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create exec instance for postCreateCommand: %w", err)
-	}
-
-	// Start the exec instance
-	if err := s.client.ContainerExecStart(ctx, execResp.ID, container.ExecStartOptions{}); err != nil {
-		return fmt.Errorf("failed to start postCreateCommand execution: %w", err)
-	}
-
-	// Attach to get output
-	attachResp, err := s.client.ContainerExecAttach(ctx, execResp.ID, container.ExecStartOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to attach to postCreateCommand execution: %w", err)
-	}
-	defer attachResp.Close()
-
-	// Stream the output
-	scanner := bufio.NewScanner(attachResp.Reader)
-	for scanner.Scan() {
-		fmt.Println(scanner.Text())
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading postCreateCommand output: %w", err)
-	}
-
-	// Wait for the exec to complete and check exit code
-	inspectResp, err := s.client.ContainerExecInspect(ctx, execResp.ID)
-	if err != nil {
-		return fmt.Errorf("failed to inspect postCreateCommand execution: %w", err)
-	}
-
-	if inspectResp.ExitCode != 0 {
-		return fmt.Errorf("postCreateCommand failed with exit code %d", inspectResp.ExitCode)
+		return fmt.Errorf("error reading build output: %w", err)
 	}
 
-	fmt.Println("postCreateCommand completed successfully")
 	return nil
 }
 
@@ -780,15 +969,10 @@ func (s *Service) ExecuteInteractiveCommand(ctx context.Context, containerID str
 	}
 
 	// Create exec instance with interactive settings
-	execConfig := container.ExecOptions{
-		AttachStdout: true,
-		AttachStderr: true,
-		AttachStdin:  true,
-		Tty:          true,
-		Cmd:          command,
-	}
+	spec := newExecSpec(containerInfo, command, nil, "", true)
+	spec.Stdin = true
 
-	execResp, err := s.client.ContainerExecCreate(ctx, containerID, execConfig)
+	execResp, err := s.client.ContainerExecCreate(ctx, containerID, spec.options())
 	if err != nil {
 		return fmt.Errorf("failed to create exec instance: %w", err)
 	}
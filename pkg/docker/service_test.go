@@ -1,12 +1,19 @@
 package docker
 
 import (
+	"archive/tar"
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -15,9 +22,19 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/checkpoint"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/dyluth/reactor/pkg/metrics"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -76,6 +93,20 @@ func (m *MockDockerClient) Ping(ctx context.Context) (types.Ping, error) {
 	return args.Get(0).(types.Ping), args.Error(1)
 }
 
+func (m *MockDockerClient) ClientVersion() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockDockerClient) NegotiateAPIVersionPing(ping types.Ping) {
+	m.Called(ping)
+}
+
+func (m *MockDockerClient) ServerVersion(ctx context.Context) (types.Version, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(types.Version), args.Error(1)
+}
+
 func (m *MockDockerClient) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -146,6 +177,37 @@ func (m *MockDockerClient) ContainerDiff(ctx context.Context, containerID string
 	return args.Get(0).([]container.FilesystemChange), args.Error(1)
 }
 
+func (m *MockDockerClient) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	args := m.Called(ctx, containerID, options)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockDockerClient) ContainerStats(ctx context.Context, containerID string, stream bool) (container.StatsResponseReader, error) {
+	args := m.Called(ctx, containerID, stream)
+	return args.Get(0).(container.StatsResponseReader), args.Error(1)
+}
+
+func (m *MockDockerClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+	args := m.Called(ctx, containerID, dstPath, content, options)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	args := m.Called(ctx, containerID, srcPath)
+	if args.Get(0) == nil {
+		return nil, container.PathStat{}, args.Error(2)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(container.PathStat), args.Error(2)
+}
+
+func (m *MockDockerClient) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	args := m.Called(ctx, containerID, newContainerName)
+	return args.Error(0)
+}
+
 func (m *MockDockerClient) ContainerKill(ctx context.Context, containerID string, signal string) error {
 	args := m.Called(ctx, containerID, signal)
 	return args.Error(0)
@@ -161,11 +223,36 @@ func (m *MockDockerClient) ContainerResize(ctx context.Context, containerID stri
 	return args.Error(0)
 }
 
+func (m *MockDockerClient) ContainerCommit(ctx context.Context, containerID string, options container.CommitOptions) (container.CommitResponse, error) {
+	args := m.Called(ctx, containerID, options)
+	return args.Get(0).(container.CommitResponse), args.Error(1)
+}
+
+func (m *MockDockerClient) CheckpointCreate(ctx context.Context, containerID string, options checkpoint.CreateOptions) error {
+	args := m.Called(ctx, containerID, options)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	args := m.Called(ctx, imageID, options)
+	return args.Get(0).([]image.DeleteResponse), args.Error(1)
+}
+
 func (m *MockDockerClient) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
 	args := m.Called(ctx, refStr, options)
 	return args.Get(0).(io.ReadCloser), args.Error(1)
 }
 
+func (m *MockDockerClient) RegistryLogin(ctx context.Context, auth registry.AuthConfig) (registry.AuthenticateOKBody, error) {
+	args := m.Called(ctx, auth)
+	return args.Get(0).(registry.AuthenticateOKBody), args.Error(1)
+}
+
+func (m *MockDockerClient) DistributionInspect(ctx context.Context, imageRef, encodedRegistryAuth string) (registry.DistributionInspect, error) {
+	args := m.Called(ctx, imageRef, encodedRegistryAuth)
+	return args.Get(0).(registry.DistributionInspect), args.Error(1)
+}
+
 func (m *MockDockerClient) ImageBuild(ctx context.Context, buildContext io.Reader, options build.ImageBuildOptions) (build.ImageBuildResponse, error) {
 	args := m.Called(ctx, buildContext, options)
 	return args.Get(0).(build.ImageBuildResponse), args.Error(1)
@@ -176,6 +263,31 @@ func (m *MockDockerClient) ImageList(ctx context.Context, options image.ListOpti
 	return args.Get(0).([]image.Summary), args.Error(1) //nolint:staticcheck // image.Summary not available in this Docker client version
 }
 
+func (m *MockDockerClient) ImageInspect(ctx context.Context, imageID string, inspectOpts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	args := m.Called(ctx, imageID)
+	return args.Get(0).(image.InspectResponse), args.Error(1)
+}
+
+func (m *MockDockerClient) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(volume.Volume), args.Error(1)
+}
+
+func (m *MockDockerClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	args := m.Called(ctx, volumeID, force)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	args := m.Called(ctx, volumeID)
+	return args.Get(0).(volume.Volume), args.Error(1)
+}
+
+func (m *MockDockerClient) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).(chan events.Message), args.Get(1).(chan error)
+}
+
 // Test utilities
 func setupTestService() (*Service, *MockDockerClient) {
 	mockClient := &MockDockerClient{}
@@ -200,6 +312,30 @@ func TestContainerExists_NotFound(t *testing.T) {
 	assert.Empty(t, containerInfo.Name)
 }
 
+func TestListContainers_AdoptedLabels(t *testing.T) {
+	AdoptedLabels = func() (map[string]map[string]string, error) {
+		return map[string]map[string]string{"c1": {
+			"com.reactor.project": "/home/alice/api",
+			"com.reactor.session": "backfilled",
+		}}, nil
+	}
+	defer func() { AdoptedLabels = nil }()
+
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+	mockClient.On("ContainerList", mock.Anything, container.ListOptions{All: true}).Return([]container.Summary{
+		{ID: "c1", Names: []string{"/reactor-alice-api-abc123"}, State: "running", Labels: map[string]string{"com.reactor.session": "own"}},
+		{ID: "c2", Names: []string{"/reactor-alice-web-def456"}, State: "running"},
+	}, nil)
+
+	matching, err := service.ListContainersByLabels(context.Background(), map[string]string{"com.reactor.project": "/home/alice/api"})
+	assert.NoError(t, err)
+	if assert.Len(t, matching, 1) {
+		assert.Equal(t, "c1", matching[0].ID)
+		assert.Equal(t, "own", matching[0].Labels["com.reactor.session"], "a label the container has wins")
+	}
+}
+
 func TestContainerExists_Running(t *testing.T) {
 	service, mockClient := setupTestService()
 	defer mockClient.AssertExpectations(t)
@@ -224,6 +360,35 @@ func TestContainerExists_Running(t *testing.T) {
 	assert.Equal(t, "test-image:latest", containerInfo.Image)
 }
 
+func TestContainerExists_Health(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	containers := []container.Summary{
+		{
+			ID:     "test-id-123",
+			Names:  []string{"/test-container"},
+			State:  "running",
+			Status: "Up 2 minutes (unhealthy)",
+		},
+	}
+	mockClient.On("ContainerList", mock.Anything, container.ListOptions{All: true}).Return(containers, nil)
+
+	containerInfo, err := service.ContainerExists(context.Background(), "test-container")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "running", containerInfo.State)
+	assert.Equal(t, "unhealthy", containerInfo.Health)
+}
+
+func TestParseHealth(t *testing.T) {
+	assert.Equal(t, "starting", parseHealth("Up 3 seconds (health: starting)"))
+	assert.Equal(t, "healthy", parseHealth("Up 5 minutes (healthy)"))
+	assert.Equal(t, "unhealthy", parseHealth("Up 5 minutes (unhealthy)"))
+	assert.Equal(t, "", parseHealth("Up 5 minutes"))
+	assert.Equal(t, "", parseHealth("Exited (0) 2 hours ago"))
+}
+
 func TestContainerExists_Stopped(t *testing.T) {
 	service, mockClient := setupTestService()
 	defer mockClient.AssertExpectations(t)
@@ -269,6 +434,7 @@ func TestStartContainer_Success(t *testing.T) {
 	defer mockClient.AssertExpectations(t)
 
 	// Mock successful container start
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStart", mock.Anything, "test-id-123", container.StartOptions{}).Return(nil)
 
 	err := service.StartContainer(context.Background(), "test-id-123")
@@ -282,6 +448,7 @@ func TestStartContainer_Error(t *testing.T) {
 
 	// Mock container start failure
 	expectedError := errors.New("container failed to start")
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStart", mock.Anything, "test-id-123", container.StartOptions{}).Return(expectedError)
 
 	err := service.StartContainer(context.Background(), "test-id-123")
@@ -298,6 +465,7 @@ func TestStopContainer_Success(t *testing.T) {
 	// Mock successful container stop
 	timeout := 10
 	expectedOptions := container.StopOptions{Timeout: &timeout}
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStop", mock.Anything, "test-id-123", expectedOptions).Return(nil)
 
 	err := service.StopContainer(context.Background(), "test-id-123")
@@ -313,6 +481,7 @@ func TestStopContainer_Error(t *testing.T) {
 	expectedError := errors.New("container failed to stop")
 	timeout := 10
 	expectedOptions := container.StopOptions{Timeout: &timeout}
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStop", mock.Anything, "test-id-123", expectedOptions).Return(expectedError)
 
 	err := service.StopContainer(context.Background(), "test-id-123")
@@ -328,6 +497,7 @@ func TestRemoveContainer_Success(t *testing.T) {
 
 	// Mock successful container removal
 	expectedOptions := container.RemoveOptions{Force: true}
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{}, nil) // no log directory to archive to
 	mockClient.On("ContainerRemove", mock.Anything, "test-id-123", expectedOptions).Return(nil)
 
 	err := service.RemoveContainer(context.Background(), "test-id-123")
@@ -342,6 +512,7 @@ func TestRemoveContainer_Error(t *testing.T) {
 	// Mock container removal failure
 	expectedError := errors.New("container failed to remove")
 	expectedOptions := container.RemoveOptions{Force: true}
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{}, nil) // no log directory to archive to
 	mockClient.On("ContainerRemove", mock.Anything, "test-id-123", expectedOptions).Return(expectedError)
 
 	err := service.RemoveContainer(context.Background(), "test-id-123")
@@ -351,6 +522,207 @@ func TestRemoveContainer_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "container failed to remove")
 }
 
+func TestService_CapturePostMortem(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	logDir := filepath.Join(t.TempDir(), "logs")
+	crashed := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         "crashed-id",
+			Name:       "/reactor-alice-proj",
+			HostConfig: &container.HostConfig{Resources: container.Resources{Memory: 2 << 30}},
+			State: &container.State{
+				ExitCode:   137,
+				OOMKilled:  true,
+				StartedAt:  "2026-10-16T09:00:00Z",
+				FinishedAt: "2026-10-16T10:30:00.5Z",
+			},
+		},
+		Config: &container.Config{Image: "node:20", Labels: map[string]string{LogDirLabel: logDir}},
+	}
+	var stream bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&stream, stdcopy.Stdout).Write([]byte("building index\n"))
+	_, _ = stdcopy.NewStdWriter(&stream, stdcopy.Stderr).Write([]byte("allocating 4GB\n"))
+
+	mockClient.On("ContainerInspect", mock.Anything, "crashed-id").Return(crashed, nil)
+	mockClient.On("ContainerLogs", mock.Anything, "crashed-id", container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: "50"}).
+		Return(io.NopCloser(&stream), nil).Once()
+
+	pm, err := service.CapturePostMortem(context.Background(), "crashed-id")
+	assert.NoError(t, err)
+	if assert.NotNil(t, pm) {
+		assert.Equal(t, "killed by the out-of-memory killer", pm.Cause())
+	}
+
+	saved, err := ReadPostMortem(logDir)
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, "reactor-alice-proj", saved.ContainerName)
+		assert.Equal(t, "node:20", saved.Image)
+		assert.Equal(t, 137, saved.ExitCode)
+		assert.True(t, saved.OOMKilled)
+		assert.Equal(t, int64(2<<30), saved.MemoryLimit)
+		assert.Equal(t, 90*time.Minute+500*time.Millisecond, saved.FinishedAt.Sub(saved.StartedAt))
+		assert.Equal(t, []string{"building index", "allocating 4GB"}, saved.LastLogLines)
+	}
+
+	// The same exit is not recorded twice
+	pm, err = service.CapturePostMortem(context.Background(), "crashed-id")
+	assert.NoError(t, err)
+	assert.Nil(t, pm)
+}
+
+func TestService_CapturePostMortem_Skipped(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "logs")
+	labels := map[string]string{LogDirLabel: logDir}
+	tests := []struct {
+		name   string
+		state  *container.State
+		labels map[string]string
+	}{
+		{"running", &container.State{Running: true}, labels},
+		{"clean exit", &container.State{ExitCode: 0}, labels},
+		{"no log directory", &container.State{ExitCode: 1}, nil},
+		{"stopped by reactor", &container.State{ExitCode: 137}, labels},
+	}
+	assert.NoError(t, markStoppedByReactor(logDir, "test-id-123"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockClient := setupTestService()
+			defer mockClient.AssertExpectations(t)
+
+			mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{ID: "test-id-123", State: tt.state},
+				Config:            &container.Config{Labels: tt.labels},
+			}, nil)
+
+			pm, err := service.CapturePostMortem(context.Background(), "test-id-123")
+			assert.NoError(t, err)
+			assert.Nil(t, pm)
+		})
+	}
+	assert.NoFileExists(t, filepath.Join(logDir, PostMortemFileName))
+}
+
+func TestStopContainer_MarksStoppedByReactor(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	logDir := filepath.Join(t.TempDir(), "logs")
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{ID: "test-id-123", State: &container.State{Running: true}},
+		Config:            &container.Config{Labels: map[string]string{LogDirLabel: logDir}},
+	}, nil)
+	mockClient.On("ContainerStop", mock.Anything, "test-id-123", mock.Anything).Return(nil)
+	mockClient.On("ContainerStart", mock.Anything, "test-id-123", container.StartOptions{}).Return(nil)
+
+	assert.NoError(t, service.StopContainer(context.Background(), "test-id-123"))
+	assert.True(t, stoppedByReactor(logDir, "test-id-123"), "the exit code of a stop is not a crash")
+	assert.False(t, stoppedByReactor(logDir, "other-id"))
+
+	assert.NoError(t, service.StartContainer(context.Background(), "test-id-123"))
+	assert.False(t, stoppedByReactor(logDir, "test-id-123"), "a later crash of the restarted container is recorded")
+}
+
+func TestPostMortem_Cause(t *testing.T) {
+	assert.Equal(t, "killed by the out-of-memory killer", PostMortem{ExitCode: 137, OOMKilled: true}.Cause())
+	assert.Equal(t, "killed (SIGKILL), possibly out of memory", PostMortem{ExitCode: 137}.Cause())
+	assert.Equal(t, "crashed with a segmentation fault (SIGSEGV)", PostMortem{ExitCode: 139}.Cause())
+	assert.Equal(t, "killed by signal 6", PostMortem{ExitCode: 134}.Cause())
+	assert.Equal(t, "main process exited with code 2", PostMortem{ExitCode: 2}.Cause())
+	assert.Equal(t, "OCI runtime error", PostMortem{ExitCode: 127, Error: "OCI runtime error"}.Cause())
+}
+
+func TestRenameContainer(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ContainerInspect", mock.Anything, "abc123").Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{Name: "/silly_turing"},
+	}, nil)
+	mockClient.On("ContainerRename", mock.Anything, "abc123", "reactor-alice-api-1234").Return(nil)
+
+	assert.NoError(t, service.RenameContainer(context.Background(), "abc123", "reactor-alice-api-1234"))
+}
+
+func TestRenameContainer_AlreadyNamed(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ContainerInspect", mock.Anything, "abc123").Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{Name: "/reactor-alice-api-1234"},
+	}, nil)
+
+	assert.NoError(t, service.RenameContainer(context.Background(), "abc123", "reactor-alice-api-1234"))
+}
+
+func TestRemoveContainer_ArchivesLogs(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	logDir := filepath.Join(t.TempDir(), "logs")
+	for _, old := range []string{"20260101T000000.000Z.log", "20260102T000000.000Z.log", "20260103T000000.000Z.log", "20260104T000000.000Z.log", "20260105T000000.000Z.log"} {
+		assert.NoError(t, os.MkdirAll(logDir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(logDir, old), []byte("old run"), 0644))
+	}
+
+	var stream bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&stream, stdcopy.Stdout).Write([]byte("agent started\n"))
+	_, _ = stdcopy.NewStdWriter(&stream, stdcopy.Stderr).Write([]byte("warning: low memory\n"))
+
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{
+		Config: &container.Config{Labels: map[string]string{LogDirLabel: logDir}},
+	}, nil)
+	mockClient.On("ContainerLogs", mock.Anything, "test-id-123", container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: "all"}).
+		Return(io.NopCloser(&stream), nil)
+	mockClient.On("ContainerRemove", mock.Anything, "test-id-123", container.RemoveOptions{Force: true}).Return(nil)
+
+	assert.NoError(t, service.RemoveContainer(context.Background(), "test-id-123"))
+
+	logs, err := ArchivedLogs(logDir)
+	assert.NoError(t, err)
+	assert.Len(t, logs, MaxArchivedLogs, "the oldest run is rotated out")
+	assert.NoFileExists(t, filepath.Join(logDir, "20260101T000000.000Z.log"))
+
+	data, err := os.ReadFile(logs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "agent started\nwarning: low memory\n", string(data))
+
+	ended, err := ArchivedLogTime(logs[0])
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), ended, time.Minute)
+}
+
+func TestRemoveContainer_CapsArchivedLogs(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+	defer func(size int64) { maxArchivedLogSize = size }(maxArchivedLogSize)
+	maxArchivedLogSize = 10
+
+	logDir := filepath.Join(t.TempDir(), "logs")
+	var stream bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&stream, stdcopy.Stdout).Write([]byte("0123456789 and much more\n"))
+
+	mockClient.On("ContainerInspect", mock.Anything, "test-id-123").Return(container.InspectResponse{
+		Config: &container.Config{Labels: map[string]string{LogDirLabel: logDir}},
+	}, nil)
+	mockClient.On("ContainerLogs", mock.Anything, "test-id-123", mock.Anything).Return(io.NopCloser(&stream), nil)
+	mockClient.On("ContainerRemove", mock.Anything, "test-id-123", container.RemoveOptions{Force: true}).Return(nil)
+
+	assert.NoError(t, service.RemoveContainer(context.Background(), "test-id-123"))
+
+	logs, err := ArchivedLogs(logDir)
+	assert.NoError(t, err)
+	if !assert.Len(t, logs, 1) {
+		return
+	}
+	data, err := os.ReadFile(logs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789\n[output truncated: only the first 10 bytes were archived]\n", string(data))
+}
+
 func TestCreateContainer_Success(t *testing.T) {
 	service, mockClient := setupTestService()
 	defer mockClient.AssertExpectations(t)
@@ -385,6 +757,194 @@ func TestCreateContainer_Success(t *testing.T) {
 	assert.Equal(t, "test-image:latest", containerInfo.Image)
 }
 
+func TestCreateContainer_InitAndEntrypoint(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	spec := &ContainerSpec{
+		Name:       "test-container",
+		Image:      "test-image:latest",
+		Entrypoint: []string{"/usr/local/share/reactor/entrypoint"},
+		Command:    []string{"/bin/sh"},
+		Init:       true,
+	}
+
+	mockClient.On("ContainerCreate", mock.Anything,
+		mock.MatchedBy(func(c *container.Config) bool {
+			return len(c.Entrypoint) == 1 && c.Entrypoint[0] == "/usr/local/share/reactor/entrypoint"
+		}),
+		mock.MatchedBy(func(h *container.HostConfig) bool { return h.Init != nil && *h.Init }),
+		mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "init-id"}, nil)
+
+	_, err := service.CreateContainer(context.Background(), spec)
+	assert.NoError(t, err)
+}
+
+func TestCreateContainer_ExtraMounts(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	spec := &ContainerSpec{
+		Name:   "test-container",
+		Image:  "test-image:latest",
+		Mounts: []string{"/src:/workspace"},
+		ExtraMounts: []Mount{
+			{Type: "tmpfs", Target: "/scratch", TmpfsSize: 1 << 30},
+			{Type: "bind", Source: "/home/u/cache", Target: "/cache", ReadOnly: true, Consistency: "delegated"},
+		},
+	}
+
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything,
+		mock.MatchedBy(func(h *container.HostConfig) bool {
+			return assert.ObjectsAreEqual([]string{"/src:/workspace"}, h.Binds) &&
+				assert.ObjectsAreEqual([]mount.Mount{
+					{Type: mount.TypeTmpfs, Target: "/scratch", TmpfsOptions: &mount.TmpfsOptions{SizeBytes: 1 << 30}},
+					{Type: mount.TypeBind, Source: "/home/u/cache", Target: "/cache", ReadOnly: true, Consistency: mount.ConsistencyDelegated},
+				}, h.Mounts)
+		}),
+		mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "mounts-id"}, nil)
+
+	_, err := service.CreateContainer(context.Background(), spec)
+	assert.NoError(t, err)
+}
+
+func TestCreateContainer_DNS(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	spec := &ContainerSpec{
+		Name:       "test-container",
+		Image:      "test-image:latest",
+		DNS:        []string{"10.0.0.53"},
+		DNSSearch:  []string{"corp.example.com"},
+		ExtraHosts: []string{"git.corp:10.0.0.7", "host.docker.internal:host-gateway"},
+	}
+
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything,
+		mock.MatchedBy(func(h *container.HostConfig) bool {
+			return assert.ObjectsAreEqual(spec.DNS, h.DNS) &&
+				assert.ObjectsAreEqual(spec.DNSSearch, h.DNSSearch) &&
+				assert.ObjectsAreEqual(spec.ExtraHosts, h.ExtraHosts)
+		}),
+		mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "dns-id"}, nil)
+
+	_, err := service.CreateContainer(context.Background(), spec)
+	assert.NoError(t, err)
+}
+
+func TestCreateContainer_Privileges(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	spec := &ContainerSpec{
+		Name:        "test-container",
+		Image:       "test-image:latest",
+		Privileged:  true,
+		CapAdd:      []string{"SYS_PTRACE"},
+		SecurityOpt: []string{"seccomp=unconfined"},
+	}
+
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything,
+		mock.MatchedBy(func(h *container.HostConfig) bool {
+			return h.Privileged &&
+				assert.ObjectsAreEqual(spec.CapAdd, []string(h.CapAdd)) &&
+				assert.ObjectsAreEqual(spec.SecurityOpt, h.SecurityOpt)
+		}),
+		mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "privileged-id"}, nil)
+
+	_, err := service.CreateContainer(context.Background(), spec)
+	assert.NoError(t, err)
+}
+
+func TestMount_String(t *testing.T) {
+	assert.Equal(t, "type=tmpfs,target=/scratch,size=1024", Mount{Type: "tmpfs", Target: "/scratch", TmpfsSize: 1024}.String())
+	assert.Equal(t, "type=bind,source=/a,target=/b,readonly,consistency=cached", Mount{Type: "bind", Source: "/a", Target: "/b", ReadOnly: true, Consistency: "cached"}.String())
+}
+
+func TestService_CreateContainer_DiskLimit(t *testing.T) {
+	t.Run("Applied", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+
+		mockClient.On("ContainerCreate", mock.Anything,
+			mock.MatchedBy(func(c *container.Config) bool { return c.Labels[DiskLimitLabel] == "20g" }),
+			mock.MatchedBy(func(h *container.HostConfig) bool { return h.StorageOpt["size"] == "20g" }),
+			mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "limited-id"}, nil)
+
+		_, err := service.CreateContainer(context.Background(), &ContainerSpec{Name: "test-container", Image: "alpine", DiskLimit: "20g"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("UnsupportedDriverFallsBack", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+
+		mockClient.On("ContainerCreate", mock.Anything, mock.Anything,
+			mock.MatchedBy(func(h *container.HostConfig) bool { return h.StorageOpt != nil }),
+			mock.Anything, mock.Anything, "test-container").
+			Return(container.CreateResponse{}, errors.New("--storage-opt is supported only for overlay over xfs with 'pquota' mount option")).Once()
+		mockClient.On("ContainerCreate", mock.Anything,
+			mock.MatchedBy(func(c *container.Config) bool { _, ok := c.Labels[DiskLimitLabel]; return !ok }),
+			mock.MatchedBy(func(h *container.HostConfig) bool { return h.StorageOpt == nil }),
+			mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "unlimited-id"}, nil).Once()
+
+		info, err := service.CreateContainer(context.Background(), &ContainerSpec{Name: "test-container", Image: "alpine", DiskLimit: "20g"})
+		assert.NoError(t, err)
+		assert.Equal(t, "unlimited-id", info.ID)
+	})
+
+	t.Run("OtherErrorsAreReturned", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+
+		mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, "test-container").
+			Return(container.CreateResponse{}, errors.New("no such image")).Once()
+
+		_, err := service.CreateContainer(context.Background(), &ContainerSpec{Name: "test-container", Image: "alpine", DiskLimit: "20g"})
+		assert.Error(t, err)
+	})
+}
+
+func TestService_ContainerDiskUsage(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ContainerList", mock.Anything, mock.MatchedBy(func(o container.ListOptions) bool {
+		return o.Size && o.Filters.ExactMatch("name", "^/reactor-dev-api-abc$")
+	})).Return([]container.Summary{{ID: "abc", SizeRw: 4096}}, nil).Once()
+
+	usage, err := service.ContainerDiskUsage(context.Background(), "reactor-dev-api-abc")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4096), usage)
+
+	mockClient.On("ContainerList", mock.Anything, mock.Anything).Return([]container.Summary{}, nil).Once()
+	_, err = service.ContainerDiskUsage(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestService_ImageCommand(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ImageInspect", mock.Anything, "postgres:16").Return(image.InspectResponse{
+		Config: &dockerspec.DockerOCIImageConfig{
+			ImageConfig: ocispec.ImageConfig{
+				Entrypoint: []string{"docker-entrypoint.sh"},
+				Cmd:        []string{"postgres"},
+			},
+		},
+	}, nil)
+	mockClient.On("ImageInspect", mock.Anything, "missing").Return(image.InspectResponse{}, errors.New("no such image"))
+
+	command, err := service.ImageCommand(context.Background(), "postgres:16")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docker-entrypoint.sh", "postgres"}, command)
+
+	_, err = service.ImageCommand(context.Background(), "missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to inspect image missing")
+}
+
 func TestCreateContainer_Error(t *testing.T) {
 	service, mockClient := setupTestService()
 	defer mockClient.AssertExpectations(t)
@@ -407,6 +967,25 @@ func TestCreateContainer_Error(t *testing.T) {
 	assert.Equal(t, ContainerInfo{}, containerInfo)
 }
 
+func TestCreateContainer_NameConflict(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	spec := &ContainerSpec{Name: "reactor-demo", Image: "test-image:latest"}
+	mockClient.On("ContainerCreate", mock.Anything, mock.AnythingOfType("*container.Config"), mock.AnythingOfType("*container.HostConfig"), mock.Anything, mock.Anything, "reactor-demo").Return(container.CreateResponse{}, errdefs.Conflict(errors.New("name already in use")))
+	mockClient.On("ContainerList", mock.Anything, container.ListOptions{All: true}).Return([]container.Summary{
+		{ID: "0123456789abcdef", Names: []string{"/reactor-demo"}, Image: "postgres:16", State: "created"},
+	}, nil)
+
+	_, err := service.CreateContainer(context.Background(), spec)
+
+	var conflict *NameConflictError
+	if assert.ErrorAs(t, err, &conflict) {
+		assert.Equal(t, "0123456789abcdef", conflict.Holder.ID)
+	}
+	assert.EqualError(t, err, "container name reactor-demo is already used by container 0123456789ab (image postgres:16, created)")
+}
+
 // Test timeouts to ensure our context handling works
 func TestContainerExists_Timeout(t *testing.T) {
 	service, mockClient := setupTestService()
@@ -478,6 +1057,7 @@ func TestProvisionContainer_StoppedContainerRestarts(t *testing.T) {
 	mockClient.On("ContainerList", mock.Anything, container.ListOptions{All: true}).Return(containers, nil)
 
 	// Mock successful restart
+	mockClient.On("ContainerInspect", mock.Anything, "existing-id-456").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStart", mock.Anything, "existing-id-456", container.StartOptions{}).Return(nil)
 
 	containerInfo, err := service.ProvisionContainer(context.Background(), spec)
@@ -512,12 +1092,14 @@ func TestProvisionContainer_StoppedContainerRestartFails_CreatesNew(t *testing.T
 	mockClient.On("ContainerStart", mock.Anything, "broken-id-789", container.StartOptions{}).Return(errors.New("container corrupted"))
 
 	// Mock removal of broken container
+	mockClient.On("ContainerInspect", mock.Anything, "broken-id-789").Return(container.InspectResponse{}, nil) // no log directory to archive to
 	mockClient.On("ContainerRemove", mock.Anything, "broken-id-789", container.RemoveOptions{Force: true}).Return(nil)
 
 	// Mock creation of new container
 	mockClient.On("ContainerCreate", mock.Anything, mock.AnythingOfType("*container.Config"), mock.AnythingOfType("*container.HostConfig"), mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "new-id-999"}, nil)
 
 	// Mock start of new container
+	mockClient.On("ContainerInspect", mock.Anything, "new-id-999").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStart", mock.Anything, "new-id-999", container.StartOptions{}).Return(nil)
 
 	containerInfo, err := service.ProvisionContainer(context.Background(), spec)
@@ -544,6 +1126,7 @@ func TestProvisionContainer_NoContainerExists_CreatesNew(t *testing.T) {
 	mockClient.On("ContainerCreate", mock.Anything, mock.AnythingOfType("*container.Config"), mock.AnythingOfType("*container.HostConfig"), mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "new-id-111"}, nil)
 
 	// Mock start of new container
+	mockClient.On("ContainerInspect", mock.Anything, "new-id-111").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStart", mock.Anything, "new-id-111", container.StartOptions{}).Return(nil)
 
 	containerInfo, err := service.ProvisionContainer(context.Background(), spec)
@@ -596,6 +1179,7 @@ func TestProvisionContainer_StartNewContainerFails_CleansUp(t *testing.T) {
 	mockClient.On("ContainerStart", mock.Anything, "new-id-222", container.StartOptions{}).Return(errors.New("port already in use"))
 
 	// Mock cleanup of failed container
+	mockClient.On("ContainerInspect", mock.Anything, "new-id-222").Return(container.InspectResponse{}, nil) // no log directory to archive to
 	mockClient.On("ContainerRemove", mock.Anything, "new-id-222", container.RemoveOptions{Force: true}).Return(nil)
 
 	containerInfo, err := service.ProvisionContainer(context.Background(), spec)
@@ -628,10 +1212,12 @@ func TestProvisionContainerWithCleanup_ForceCleanup(t *testing.T) {
 
 	// Mock forced cleanup of existing container
 	mockClient.On("ContainerStop", mock.Anything, "existing-running-id", mock.AnythingOfType("container.StopOptions")).Return(nil)
+	mockClient.On("ContainerInspect", mock.Anything, "existing-running-id").Return(container.InspectResponse{}, nil) // no log directory to archive to
 	mockClient.On("ContainerRemove", mock.Anything, "existing-running-id", container.RemoveOptions{Force: true}).Return(nil)
 
 	// Mock creation and start of new container
 	mockClient.On("ContainerCreate", mock.Anything, mock.AnythingOfType("*container.Config"), mock.AnythingOfType("*container.HostConfig"), mock.Anything, mock.Anything, "test-container").Return(container.CreateResponse{ID: "clean-new-id"}, nil)
+	mockClient.On("ContainerInspect", mock.Anything, "clean-new-id").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStart", mock.Anything, "clean-new-id", container.StartOptions{}).Return(nil)
 
 	containerInfo, err := service.ProvisionContainerWithCleanup(context.Background(), spec, true)
@@ -1226,17 +1812,311 @@ func TestService_AttachInteractiveSession_AttachFailure(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
-func TestTerminalState_ChannelInitialization(t *testing.T) {
-	state := NewTerminalState()
+func TestService_AttachSessionCommand_UsesCommand(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	service := NewServiceWithClient(mockClient)
 
-	// Channels should be initialized
-	assert.NotNil(t, state.SignalChan)
-	assert.NotNil(t, state.ResizeChan)
+	containerID := "test-container-id"
+	containerState := container.State{Running: true}
+	mockClient.On("ContainerInspect", mock.Anything, containerID).Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &containerState},
+	}, nil)
 
-	// Channels should be buffered
-	select {
-	case state.ResizeChan <- TTYSize{Rows: 25, Cols: 81}:
-		// Should not block
+	command := []string{"/bin/sh", "-c", "claude"}
+	mockClient.On("ContainerExecCreate", mock.Anything, containerID, mock.MatchedBy(func(opts container.ExecOptions) bool {
+		return assert.ObjectsAreEqual(command, opts.Cmd)
+	})).Return(container.ExecCreateResponse{}, errors.New("exec creation failed"))
+
+	err := service.AttachSessionCommand(context.Background(), containerID, command)
+
+	assert.Error(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestService_StreamExec(t *testing.T) {
+	// Build a multiplexed output stream as the daemon sends it for non-TTY execs
+	var output bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write([]byte("hello from agent\n"))
+	_, _ = stdcopy.NewStdWriter(&output, stdcopy.Stderr).Write([]byte("warning\n"))
+
+	tests := []struct {
+		name     string
+		exitCode int
+		wantErr  string
+	}{
+		{name: "success", exitCode: 0},
+		{name: "non-zero exit", exitCode: 3, wantErr: "command exited with code 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockClient := setupTestService()
+			defer mockClient.AssertExpectations(t)
+			mockClient.On("ContainerExecInspect", mock.Anything, "exec-id").Return(container.ExecInspect{ExitCode: tt.exitCode}, nil)
+
+			// Capture what the session forwards from stdin
+			clientConn, serverConn := net.Pipe()
+			received := make(chan string, 1)
+			go func() {
+				data := make([]byte, len("echo hello\n"))
+				_, _ = io.ReadFull(serverConn, data)
+				received <- string(data)
+			}()
+
+			attachResp := types.HijackedResponse{
+				Conn:   clientConn,
+				Reader: bufio.NewReader(bytes.NewReader(output.Bytes())),
+			}
+
+			var stdout, stderr bytes.Buffer
+			err := service.streamExec(context.Background(), "exec-id", attachResp, false, strings.NewReader("echo hello\n"), &stdout, &stderr)
+			if tt.wantErr != "" {
+				var exitErr *ExitError
+				assert.ErrorAs(t, err, &exitErr)
+				assert.Equal(t, tt.exitCode, exitErr.Code)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, "hello from agent\n", stdout.String())
+			assert.Equal(t, "warning\n", stderr.String())
+
+			assert.Equal(t, "echo hello\n", <-received)
+			_ = clientConn.Close()
+		})
+	}
+}
+
+func TestService_StreamExec_TTYIsBinarySafe(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+	mockClient.On("ContainerExecInspect", mock.Anything, "exec-id").Return(container.ExecInspect{}, nil)
+
+	// A TTY stream is raw bytes with no multiplexing headers
+	binary := []byte{0x00, 0x01, 0xff, '\n', 0x1b, '[', 'm'}
+	clientConn, _ := net.Pipe()
+	defer func() { _ = clientConn.Close() }()
+	attachResp := types.HijackedResponse{
+		Conn:   clientConn,
+		Reader: bufio.NewReader(bytes.NewReader(binary)),
+	}
+
+	var stdout bytes.Buffer
+	err := service.streamExec(context.Background(), "exec-id", attachResp, true, nil, &stdout, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, stdout.Bytes())
+}
+
+func TestService_ExecCommand_Validation(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	err := service.ExecCommand(context.Background(), "container-id", ExecOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "command array cannot be empty")
+
+	containerJSON := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: false}},
+	}
+	mockClient.On("ContainerInspect", mock.Anything, "container-id").Return(containerJSON, nil)
+
+	err = service.ExecCommand(context.Background(), "container-id", ExecOptions{Command: []string{"ls"}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not running")
+}
+
+func TestService_CreateCheckpoint(t *testing.T) {
+	running := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+	}
+
+	t.Run("FilesystemOnlyWithoutExperimentalDaemon", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		processDir := filepath.Join(t.TempDir(), "checkpoints")
+
+		withImage := running
+		withImage.Config = &container.Config{Image: "node:20"}
+		mockClient.On("ContainerInspect", mock.Anything, "container-id").Return(withImage, nil)
+		mockClient.On("Ping", mock.Anything).Return(types.Ping{Experimental: false}, nil)
+		mockClient.On("ContainerCommit", mock.Anything, "container-id", mock.MatchedBy(func(opts container.CommitOptions) bool {
+			return strings.HasPrefix(opts.Reference, "reactor-checkpoint/reactor-alice-proj:") && opts.Pause &&
+				opts.Config.Labels[CheckpointContainerLabel] == "reactor-alice-proj" &&
+				opts.Config.Labels[CheckpointSourceImageLabel] == "node:20" &&
+				opts.Config.Labels[CheckpointCommandLabel] == "rm -rf /opt" &&
+				opts.Config.Labels[CheckpointProcessLabel] == "false"
+		})).Return(container.CommitResponse{ID: "sha256:snap"}, nil)
+		mockClient.On("ImageList", mock.Anything, mock.AnythingOfType("image.ListOptions")).Return([]image.Summary{}, nil)
+
+		cp, err := service.CreateCheckpoint(context.Background(), "container-id", "reactor-alice-proj", processDir, "rm -rf /opt")
+		assert.NoError(t, err)
+		assert.Equal(t, "sha256:snap", cp.ImageID)
+		assert.False(t, cp.Process)
+		assert.Equal(t, "node:20", cp.SourceImage)
+		assert.Regexp(t, `^\d{8}-\d{6}-[0-9a-f]{6}$`, cp.ID, "checkpoints of the same second get different IDs")
+		assert.NoDirExists(t, processDir)
+	})
+
+	t.Run("CheckpointsProcessesAndPrunesOldest", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		processDir := filepath.Join(t.TempDir(), "checkpoints")
+
+		mockClient.On("ContainerInspect", mock.Anything, "container-id").Return(running, nil)
+		mockClient.On("Ping", mock.Anything).Return(types.Ping{Experimental: true}, nil)
+		mockClient.On("CheckpointCreate", mock.Anything, "container-id", mock.MatchedBy(func(opts checkpoint.CreateOptions) bool {
+			return opts.CheckpointDir == processDir && !opts.Exit && opts.CheckpointID != ""
+		})).Return(nil)
+		mockClient.On("ContainerCommit", mock.Anything, "container-id", mock.MatchedBy(func(opts container.CommitOptions) bool {
+			return opts.Config.Labels[CheckpointProcessLabel] == "true"
+		})).Return(container.CommitResponse{ID: "sha256:new"}, nil)
+
+		summary := func(id, imageID string) image.Summary {
+			return image.Summary{ID: imageID, Labels: map[string]string{CheckpointIDLabel: id}}
+		}
+		mockClient.On("ImageList", mock.Anything, mock.AnythingOfType("image.ListOptions")).Return([]image.Summary{
+			summary("20260101-000001", "sha256:a"),
+			summary("20260101-000004", "sha256:d"),
+			summary("20260101-000002", "sha256:b"),
+			summary("20260101-000003", "sha256:c"),
+		}, nil)
+		mockClient.On("ImageRemove", mock.Anything, "sha256:a", image.RemoveOptions{Force: true, PruneChildren: true}).Return([]image.DeleteResponse{}, nil)
+
+		assert.NoError(t, os.MkdirAll(filepath.Join(processDir, "20260101-000001"), 0755))
+
+		cp, err := service.CreateCheckpoint(context.Background(), "container-id", "reactor-alice-proj", processDir, "")
+		assert.NoError(t, err)
+		assert.True(t, cp.Process)
+		assert.NoDirExists(t, filepath.Join(processDir, "20260101-000001"))
+	})
+
+	t.Run("ContainerNotRunning", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+
+		mockClient.On("ContainerInspect", mock.Anything, "container-id").Return(container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: false}},
+		}, nil)
+
+		_, err := service.CreateCheckpoint(context.Background(), "container-id", "reactor-alice-proj", t.TempDir(), "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is not running")
+	})
+}
+
+func TestService_RestoreCheckpoint(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	info := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			Name:       "/reactor-alice-proj",
+			State:      &container.State{Running: true},
+			HostConfig: &container.HostConfig{Binds: []string{"/src:/workspace"}},
+		},
+		Config: &container.Config{Image: "node:20", Labels: map[string]string{"com.reactor.managed": "true"}},
+	}
+	mockClient.On("ContainerInspect", mock.Anything, "old-id").Return(info, nil)
+	// The original container is only removed once its replacement runs
+	mockClient.On("ContainerStop", mock.Anything, "old-id", mock.Anything).Return(nil).Once()
+	mockClient.On("ContainerRename", mock.Anything, "old-id", "reactor-alice-proj-replaced").Return(nil).Once()
+	mockClient.On("ContainerCreate", mock.Anything, mock.MatchedBy(func(cfg *container.Config) bool {
+		return cfg.Image == "sha256:snap" && cfg.Labels["com.reactor.managed"] == "true" && cfg.Labels[CheckpointSourceImageLabel] == "node:20"
+	}), info.HostConfig, (*network.NetworkingConfig)(nil), (*ocispec.Platform)(nil), "reactor-alice-proj").Return(container.CreateResponse{ID: "new-id"}, nil)
+	mockClient.On("ContainerStart", mock.Anything, "new-id", container.StartOptions{CheckpointID: "20260101-000001", CheckpointDir: "/cp"}).Return(errors.New("criu failed"))
+	mockClient.On("ContainerStart", mock.Anything, "new-id", container.StartOptions{}).Return(nil)
+	mockClient.On("ContainerRemove", mock.Anything, "old-id", container.RemoveOptions{Force: true}).Return(nil).Once()
+
+	id, err := service.RestoreCheckpoint(context.Background(), "old-id", Checkpoint{ID: "20260101-000001", ImageID: "sha256:snap", Process: true, SourceImage: "node:20"}, "/cp")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-id", id)
+	assert.Equal(t, "node:20", info.Config.Image, "the inspected config must not be modified")
+	assert.NotContains(t, info.Config.Labels, CheckpointSourceImageLabel)
+}
+
+func TestService_RestoreCheckpoint_PutsBackOnFailure(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	info := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			Name:       "/reactor-alice-proj",
+			State:      &container.State{Running: true},
+			HostConfig: &container.HostConfig{},
+		},
+		Config: &container.Config{Image: "node:20"},
+	}
+	mockClient.On("ContainerInspect", mock.Anything, "old-id").Return(info, nil)
+	mockClient.On("ContainerStop", mock.Anything, "old-id", mock.Anything).Return(nil).Once()
+	mockClient.On("ContainerRename", mock.Anything, "old-id", "reactor-alice-proj-replaced").Return(nil).Once()
+	mockClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, (*network.NetworkingConfig)(nil), (*ocispec.Platform)(nil), "reactor-alice-proj").Return(container.CreateResponse{}, errors.New("no space left on device"))
+	mockClient.On("ContainerRename", mock.Anything, "old-id", "reactor-alice-proj").Return(nil).Once()
+	mockClient.On("ContainerStart", mock.Anything, "old-id", container.StartOptions{}).Return(nil).Once()
+
+	_, err := service.RestoreCheckpoint(context.Background(), "old-id", Checkpoint{ID: "20260101-000001", ImageID: "sha256:snap"}, "/cp")
+	assert.ErrorContains(t, err, "failed to create container from checkpoint 20260101-000001: no space left on device")
+	mockClient.AssertNotCalled(t, "ContainerRemove", mock.Anything, "old-id", mock.Anything)
+}
+
+func TestService_StartDetachedExec(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	containerJSON := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+	}
+	mockClient.On("ContainerInspect", mock.Anything, "container-id").Return(containerJSON, nil)
+	mockClient.On("ContainerExecCreate", mock.Anything, "container-id", mock.MatchedBy(func(opts container.ExecOptions) bool {
+		return !opts.AttachStdout && !opts.Tty &&
+			opts.Cmd[0] == "sh" && opts.Cmd[len(opts.Cmd)-3] == "npm" && opts.Cmd[len(opts.Cmd)-1] == "watch" &&
+			opts.Env[0] == "REACTOR_JOB_LOG=/tmp/reactor-jobs/7.log" && opts.Env[1] == "REACTOR_JOB_PID=/tmp/reactor-jobs/7.pid" &&
+			opts.Env[2] == "LOG_LEVEL=debug"
+	})).Return(container.ExecCreateResponse{ID: "exec-7"}, nil)
+	mockClient.On("ContainerExecStart", mock.Anything, "exec-7", container.ExecStartOptions{Detach: true}).Return(nil)
+
+	execID, err := service.StartDetachedExec(context.Background(), "container-id", "7", []string{"npm", "run", "watch"}, []string{"LOG_LEVEL=debug"})
+	assert.NoError(t, err)
+	assert.Equal(t, "exec-7", execID)
+
+	_, err = service.StartDetachedExec(context.Background(), "container-id", "8", nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "command array cannot be empty")
+}
+
+func TestService_ExecStatus(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ContainerExecInspect", mock.Anything, "exec-1").Return(container.ExecInspect{Running: false, ExitCode: 3}, nil)
+	mockClient.On("ContainerExecInspect", mock.Anything, "exec-gone").Return(container.ExecInspect{}, errors.New("no such exec"))
+
+	running, exitCode, err := service.ExecStatus(context.Background(), "exec-1")
+	assert.NoError(t, err)
+	assert.False(t, running)
+	assert.Equal(t, 3, exitCode)
+
+	_, _, err = service.ExecStatus(context.Background(), "exec-gone")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to inspect exec exec-gone")
+}
+
+func TestJobCommands(t *testing.T) {
+	assert.Equal(t, []string{"cat", "/tmp/reactor-jobs/3.log"}, JobLogsCommand("3", false))
+	assert.Equal(t, []string{"sh", "-c", jobFollower, "sh", "/tmp/reactor-jobs/3.log", "/tmp/reactor-jobs/3.pid"}, JobLogsCommand("3", true))
+	assert.Equal(t, []string{"sh", "-c", `kill -TERM "$(cat /tmp/reactor-jobs/3.pid)"`}, JobStopCommand("3"))
+}
+
+func TestTerminalState_ChannelInitialization(t *testing.T) {
+	state := NewTerminalState()
+
+	// Channels should be initialized
+	assert.NotNil(t, state.SignalChan)
+	assert.NotNil(t, state.ResizeChan)
+
+	// Channels should be buffered
+	select {
+	case state.ResizeChan <- TTYSize{Rows: 25, Cols: 81}:
+		// Should not block
 	default:
 		t.Error("Resize channel should be buffered")
 	}
@@ -1401,6 +2281,7 @@ func TestProvisionContainerWithCleanup_StopFailure(t *testing.T) {
 		}}, nil)
 
 	// Mock stop container failure
+	mockClient.On("ContainerInspect", mock.Anything, "existing-id").Return(container.InspectResponse{}, nil)
 	mockClient.On("ContainerStop", mock.Anything, "existing-id", mock.AnythingOfType("container.StopOptions")).Return(errors.New("failed to stop"))
 
 	// Should get error when stop fails during force cleanup
@@ -1430,6 +2311,7 @@ func TestProvisionContainerWithCleanup_RemoveFailureDuringCleanup(t *testing.T)
 		}}, nil)
 
 	// Mock remove container failure during force cleanup
+	mockClient.On("ContainerInspect", mock.Anything, "existing-id").Return(container.InspectResponse{}, nil) // no log directory to archive to
 	mockClient.On("ContainerRemove", mock.Anything, "existing-id", mock.AnythingOfType("container.RemoveOptions")).Return(errors.New("failed to remove"))
 
 	// Should get error when remove fails during force cleanup
@@ -1510,6 +2392,55 @@ func TestImageExists_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to list images")
 }
 
+func TestEnsureImage_UntaggedImageExists(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	// Untagged references match the local latest tag, so no pull happens
+	mockClient.On("ImageList", mock.Anything, image.ListOptions{}).Return(
+		[]image.Summary{ //nolint:staticcheck // image.Summary not available in this Docker client version
+			{RepoTags: []string{"alpine:latest"}},
+		}, nil)
+
+	profile := metrics.NewProfile()
+	service.SetProfile(profile)
+
+	err := service.EnsureImage(context.Background(), "alpine")
+	assert.NoError(t, err)
+	assert.Len(t, profile.Phases, 1)
+	assert.Equal(t, metrics.PhaseImageCheck, profile.Phases[0].Name)
+}
+
+func TestEnsureImage_PullsMissingImage(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ImageList", mock.Anything, image.ListOptions{}).Return(
+		[]image.Summary{}, nil) //nolint:staticcheck // image.Summary not available in this Docker client version
+	mockClient.On("ImagePull", mock.Anything, "ghcr.io/org/agent:v1", image.PullOptions{}).Return(
+		io.NopCloser(strings.NewReader(`{"status":"Pulling fs layer"}`+"\n"+`{"status":"Download complete"}`+"\n")), nil)
+
+	profile := metrics.NewProfile()
+	service.SetProfile(profile)
+
+	err := service.EnsureImage(context.Background(), "ghcr.io/org/agent:v1")
+	assert.NoError(t, err)
+	assert.Len(t, profile.Phases, 2)
+	assert.Equal(t, metrics.PhasePull, profile.Phases[1].Name)
+}
+
+func TestPullImage_StreamError(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ImagePull", mock.Anything, "missing:v1", image.PullOptions{}).Return(
+		io.NopCloser(strings.NewReader(`{"error":"manifest unknown"}`+"\n")), nil)
+
+	err := service.PullImage(context.Background(), "missing:v1")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "manifest unknown")
+}
+
 // POST CREATE COMMAND FUNCTIONALITY TESTS
 
 func TestExecutePostCreateCommand_NilCommand(t *testing.T) {
@@ -1865,6 +2796,57 @@ func TestExecutePostCreateCommand_InterfaceArrayWithInvalidType(t *testing.T) {
 	assert.Contains(t, err.Error(), "postCreateCommand array contains non-string element: 123")
 }
 
+func TestExecutePostCreateCommand_ObjectCommand_RunsAllAndAggregatesFailures(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	containerID := "test-container"
+	command := map[string]interface{}{
+		"install": "npm install",
+		"lint":    []interface{}{"make", "lint"},
+		"docs":    "make docs",
+		"skipped": "",
+	}
+
+	containerJSON := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			State: &container.State{Running: true},
+		},
+	}
+	mockClient.On("ContainerInspect", mock.Anything, containerID).Return(containerJSON, nil).Once()
+
+	exitCodes := map[string]int{"npm install": 0, "make lint": 2, "make docs": 1}
+	for cmd, exitCode := range exitCodes {
+		cmd := cmd
+		execID := "exec-" + strings.ReplaceAll(cmd, " ", "-")
+		mockClient.On("ContainerExecCreate", mock.Anything, containerID, mock.MatchedBy(func(config container.ExecOptions) bool {
+			return strings.Join(config.Cmd, " ") == cmd || (len(config.Cmd) == 3 && config.Cmd[2] == cmd)
+		})).Return(container.ExecCreateResponse{ID: execID}, nil).Once()
+		mockClient.On("ContainerExecStart", mock.Anything, execID, mock.AnythingOfType("container.ExecStartOptions")).Return(nil).Once()
+		mockClient.On("ContainerExecAttach", mock.Anything, execID, mock.AnythingOfType("container.ExecStartOptions")).Return(NewMockHijackedResponse(cmd+" output\n"), nil).Once()
+		mockClient.On("ContainerExecInspect", mock.Anything, execID).Return(container.ExecInspect{ExitCode: exitCode}, nil).Once()
+	}
+
+	err := service.ExecutePostCreateCommand(context.Background(), containerID, command)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "2 of 3 postCreateCommand commands failed")
+	assert.Contains(t, err.Error(), `postCreateCommand "docs" failed with exit code 1`)
+	assert.Contains(t, err.Error(), `postCreateCommand "lint" failed with exit code 2`)
+	assert.NotContains(t, err.Error(), "install")
+}
+
+func TestExecutePostCreateCommand_ObjectCommand_InvalidEntry(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	command := map[string]interface{}{"install": 42}
+
+	// No mocks needed since function should return early
+	err := service.ExecutePostCreateCommand(context.Background(), "test-container", command)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `postCreateCommand "install" must be a string or array of strings, got int`)
+}
+
 // TestBuildImage test suite
 
 func TestBuildImage_Success(t *testing.T) {
@@ -1949,6 +2931,82 @@ func TestBuildImage_ForceRebuild(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestBuildImage_ProxyBuildArgs(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	service := &Service{client: mockClient}
+
+	workspaceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(workspaceDir, "Dockerfile"), []byte("FROM alpine:latest\n"), 0644)
+	assert.NoError(t, err)
+
+	proxy := "http://host.docker.internal:3128"
+	spec := BuildSpec{
+		Context:    workspaceDir,
+		Dockerfile: "Dockerfile",
+		ImageName:  "test-image:latest",
+		BuildArgs:  map[string]*string{"HTTP_PROXY": &proxy},
+		ExtraHosts: []string{"host.docker.internal:host-gateway"},
+	}
+
+	mockResponse := build.ImageBuildResponse{
+		Body: io.NopCloser(strings.NewReader(`{"stream":"Successfully built abc123\n"}`)),
+	}
+	mockClient.On("ImageBuild", mock.Anything, mock.Anything, mock.MatchedBy(func(opts build.ImageBuildOptions) bool {
+		return opts.BuildArgs["HTTP_PROXY"] != nil && *opts.BuildArgs["HTTP_PROXY"] == proxy &&
+			len(opts.ExtraHosts) == 1 && opts.ExtraHosts[0] == "host.docker.internal:host-gateway"
+	})).Return(mockResponse, nil)
+
+	err = service.BuildImage(context.Background(), spec, true)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestBuildImage_SecretsUseDockerCLI(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	service := &Service{client: mockClient, host: "tcp://build-box:2376"}
+
+	workspaceDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(workspaceDir, "Dockerfile"), []byte("FROM alpine:latest\nRUN --mount=type=secret,id=npmrc cat /run/secrets/npmrc\n"), 0644)
+	assert.NoError(t, err)
+
+	proxy := "http://proxy:3128"
+	spec := BuildSpec{
+		Context:    workspaceDir,
+		Dockerfile: "Dockerfile",
+		ImageName:  "test-image:latest",
+		BuildArgs:  map[string]*string{"HTTP_PROXY": &proxy},
+		Labels:     map[string]string{"com.reactor.version": "dev"},
+		Secrets:    []string{"id=npmrc,src=/home/me/.npmrc"},
+		SSH:        []string{"default"},
+	}
+
+	var gotArgs, gotEnv []string
+	var gotContext []byte
+	oldLook, oldRun := lookDockerCLI, runDockerCLI
+	t.Cleanup(func() { lookDockerCLI, runDockerCLI = oldLook, oldRun })
+	lookDockerCLI = func(string) (string, error) { return "/usr/bin/docker", nil }
+	runDockerCLI = func(ctx context.Context, env []string, stdin io.Reader, output io.Writer, args ...string) error {
+		gotArgs, gotEnv = args, env
+		gotContext, _ = io.ReadAll(stdin)
+		_, _ = io.WriteString(output, "#5 [2/2] RUN --mount=type=secret,id=npmrc cat /run/secrets/npmrc\n")
+		return nil
+	}
+
+	err = service.BuildImage(context.Background(), spec, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build", "--progress", "plain", "-f", "Dockerfile", "-t", "test-image:latest",
+		"--build-arg", "HTTP_PROXY=http://proxy:3128", "--label", "com.reactor.version=dev",
+		"--secret", "id=npmrc,src=/home/me/.npmrc", "--ssh", "default", "-"}, gotArgs)
+	assert.Contains(t, gotEnv, "DOCKER_BUILDKIT=1")
+	assert.Contains(t, gotEnv, "DOCKER_HOST=tcp://build-box:2376")
+	assert.NotEmpty(t, gotContext, "the build context is sent on stdin")
+	mockClient.AssertNotCalled(t, "ImageBuild", mock.Anything, mock.Anything, mock.Anything)
+
+	lookDockerCLI = func(string) (string, error) { return "", exec.ErrNotFound }
+	err = service.BuildImage(context.Background(), spec, true)
+	assert.ErrorContains(t, err, "build secrets and ssh need the docker CLI with BuildKit on PATH")
+}
+
 func TestBuildImage_ImageExistsSkipBuild(t *testing.T) {
 	mockClient := &MockDockerClient{}
 	service := &Service{client: mockClient}
@@ -2482,3 +3540,1042 @@ func TestListContainersByLabel_EmptyNames(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestParseProgressMode(t *testing.T) {
+	for input, expected := range map[string]string{"": ProgressAuto, "auto": ProgressAuto, "plain": ProgressPlain, "tty": ProgressTTY} {
+		mode, err := ParseProgressMode(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+
+	_, err := ParseProgressMode("fancy")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid progress mode 'fancy'")
+}
+
+func TestBuildLog(t *testing.T) {
+	output := "Step 1/2 : FROM alpine\n ---> abc\nStep 2/2 : RUN make\nmake: *** [all] Error 2\n"
+
+	t.Run("PlainStreamsEverything", func(t *testing.T) {
+		var out bytes.Buffer
+		log, err := newBuildLog("", ProgressPlain, &out)
+		assert.NoError(t, err)
+		_, err = log.Write([]byte(output))
+		assert.NoError(t, err)
+		assert.NoError(t, log.Close())
+
+		assert.Equal(t, output, out.String())
+		assert.Equal(t, "2 build steps", log.Summary())
+	})
+
+	t.Run("TTYShowsStepsAndDumpsTail", func(t *testing.T) {
+		logPath := filepath.Join(t.TempDir(), "project", "build.log")
+		var out, stderr bytes.Buffer
+		log, err := newBuildLog(logPath, ProgressTTY, &out)
+		assert.NoError(t, err)
+		// Chunks do not have to end on line boundaries
+		_, _ = log.Write([]byte(output[:30]))
+		_, _ = log.Write([]byte(output[30:]))
+		assert.NoError(t, log.Close())
+
+		assert.Contains(t, out.String(), "Step 2/2 : RUN make")
+		assert.NotContains(t, out.String(), "Error 2")
+
+		logged, err := os.ReadFile(logPath)
+		assert.NoError(t, err)
+		assert.Equal(t, output, string(logged))
+
+		log.DumpTail(&stderr)
+		assert.Contains(t, stderr.String(), "Last 4 lines of build output")
+		assert.Contains(t, stderr.String(), "  make: *** [all] Error 2")
+		assert.Contains(t, stderr.String(), "Full build log: "+logPath)
+	})
+
+	t.Run("AutoIsPlainWithoutTerminal", func(t *testing.T) {
+		var out bytes.Buffer
+		log, err := newBuildLog("", ProgressAuto, &out)
+		assert.NoError(t, err)
+		assert.False(t, log.tty)
+	})
+}
+
+func TestBuildImage_WritesBuildLog(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	service := &Service{client: mockClient}
+
+	workspaceDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workspaceDir, "Dockerfile"), []byte("FROM alpine:latest\n"), 0644))
+	logPath := filepath.Join(t.TempDir(), "build.log")
+
+	buildOutput := `{"stream":"Step 1/1 : FROM alpine:latest\n"}` + "\n" +
+		`{"errorDetail":{"message":"pull access denied"},"error":"pull access denied"}` + "\n"
+	mockClient.On("ImageBuild", mock.Anything, mock.Anything, mock.Anything).Return(build.ImageBuildResponse{
+		Body: io.NopCloser(strings.NewReader(buildOutput)),
+	}, nil)
+
+	err := service.BuildImage(context.Background(), BuildSpec{
+		Context:    workspaceDir,
+		Dockerfile: "Dockerfile",
+		ImageName:  "test-image:latest",
+		Progress:   ProgressTTY,
+		LogFile:    logPath,
+	}, true)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pull access denied")
+
+	logged, readErr := os.ReadFile(logPath)
+	assert.NoError(t, readErr)
+	assert.Equal(t, "Step 1/1 : FROM alpine:latest\nERROR: pull access denied\n", string(logged))
+}
+
+func TestListBuildContext(t *testing.T) {
+	contextDir := t.TempDir()
+	writeFile := func(rel, content string) {
+		path := filepath.Join(contextDir, rel)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	writeFile("Dockerfile", "FROM alpine\n")
+	writeFile("main.go", "package main\n")
+	writeFile(".git/HEAD", "ref: refs/heads/main\n")
+	writeFile("node_modules/left-pad/index.js", "module.exports = 1\n")
+	writeFile("build/output.bin", "binary")
+	writeFile("docs/keep.md", "keep")
+	writeFile("docs/drop.md", "drop")
+
+	paths := func() []string {
+		files, err := ListBuildContext(contextDir, "Dockerfile")
+		assert.NoError(t, err)
+		var paths []string
+		for _, f := range files {
+			paths = append(paths, f.Path)
+		}
+		return paths
+	}
+
+	t.Run("DefaultExclusions", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Dockerfile", "main.go", "build/output.bin", "docs/keep.md", "docs/drop.md"}, paths())
+	})
+
+	t.Run("DockerIgnore", func(t *testing.T) {
+		writeFile(".dockerignore", "# build artefacts\nbuild\ndocs\n!docs/keep.md\nDockerfile\n")
+		defer func() { _ = os.Remove(filepath.Join(contextDir, ".dockerignore")) }()
+
+		// The Dockerfile and .dockerignore are always sent
+		assert.ElementsMatch(t, []string{".dockerignore", "Dockerfile", "main.go", "docs/keep.md"}, paths())
+	})
+
+	t.Run("ReincludeDefaultExclusion", func(t *testing.T) {
+		writeFile(".dockerignore", "!node_modules\n")
+		defer func() { _ = os.Remove(filepath.Join(contextDir, ".dockerignore")) }()
+
+		assert.Contains(t, paths(), "node_modules/left-pad/index.js")
+		assert.NotContains(t, paths(), ".git/HEAD")
+	})
+
+	t.Run("DockerfileSpecificIgnoreWins", func(t *testing.T) {
+		writeFile(".dockerignore", "main.go\n")
+		writeFile("Dockerfile.dockerignore", "build\n")
+		defer func() {
+			_ = os.Remove(filepath.Join(contextDir, ".dockerignore"))
+			_ = os.Remove(filepath.Join(contextDir, "Dockerfile.dockerignore"))
+		}()
+
+		assert.Contains(t, paths(), "main.go")
+		assert.NotContains(t, paths(), "build/output.bin")
+	})
+}
+
+func TestCreateBuildContext_HonorsDockerIgnore(t *testing.T) {
+	contextDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM alpine\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(contextDir, "secret.env"), []byte("TOKEN=x\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(contextDir, ".dockerignore"), []byte("*.env\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(contextDir, ".git"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(contextDir, ".git", "config"), []byte("[core]\n"), 0644))
+
+	service := &Service{}
+	reader, err := service.createBuildContext(contextDir, "Dockerfile")
+	assert.NoError(t, err)
+	defer func() { _ = reader.Close() }()
+
+	var names []string
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.ElementsMatch(t, []string{".dockerignore", "Dockerfile"}, names)
+}
+
+func TestUniqueImages(t *testing.T) {
+	assert.Equal(t, "alpine:latest", NormalizeImageName("docker.io/library/alpine"))
+	assert.Equal(t, "ghcr.io/org/agent:v1", NormalizeImageName("ghcr.io/org/agent:v1"))
+	assert.Equal(t, "Not Valid", NormalizeImageName("Not Valid"))
+
+	assert.Equal(t, []string{"alpine:latest", "node:20"},
+		UniqueImages([]string{"alpine", "node:20", "alpine:latest", "", "docker.io/library/node:20"}))
+}
+
+func TestService_PullImages(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ImageList", mock.Anything, image.ListOptions{}).Return([]image.Summary{
+		{ID: "sha256:node", RepoTags: []string{"node:20"}},
+	}, nil)
+	progress := `{"status":"Pulling from library/alpine","id":"latest"}` + "\n" +
+		`{"status":"Downloading","id":"abc","progressDetail":{"current":512,"total":1024}}` + "\n" +
+		`{"status":"Pull complete","id":"abc"}` + "\n"
+	// alpine is needed by two services but pulled once; node:20 is already present
+	mockClient.On("ImagePull", mock.Anything, "alpine:latest", image.PullOptions{}).Return(
+		io.NopCloser(strings.NewReader(progress)), nil).Once()
+
+	var out bytes.Buffer
+	err := service.PullImages(context.Background(), []string{"alpine", "node:20", "alpine:latest"}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "Pulling image: alpine:latest\n[ok] Pulled alpine:latest\n", out.String())
+}
+
+func TestService_PullImages_ReportsFailures(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ImageList", mock.Anything, image.ListOptions{}).Return([]image.Summary{}, nil)
+	mockClient.On("ImagePull", mock.Anything, "alpine:latest", image.PullOptions{}).Return(
+		io.NopCloser(strings.NewReader("")), nil)
+	mockClient.On("ImagePull", mock.Anything, "private/agent:latest", image.PullOptions{}).Return(
+		io.NopCloser(strings.NewReader(`{"error":"pull access denied"}`+"\n")), nil)
+
+	var out bytes.Buffer
+	err := service.PullImages(context.Background(), []string{"alpine", "private/agent"}, &out)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to pull image private/agent:latest: pull access denied")
+	assert.NotContains(t, err.Error(), "alpine")
+}
+
+// stubRegistryAuth returns credentials for ghcr.io images only
+type stubRegistryAuth struct{}
+
+func (stubRegistryAuth) AuthFor(imageName string) (registry.AuthConfig, bool, error) {
+	if !strings.HasPrefix(imageName, "ghcr.io/") {
+		return registry.AuthConfig{}, false, nil
+	}
+	return registry.AuthConfig{Username: "bot", Password: "s3cret", ServerAddress: "ghcr.io"}, true, nil
+}
+
+func (a stubRegistryAuth) All() (map[string]registry.AuthConfig, error) {
+	auth, _, _ := a.AuthFor("ghcr.io/")
+	return map[string]registry.AuthConfig{"ghcr.io": auth}, nil
+}
+
+func TestService_PullImage_RegistryAuth(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+	service.SetRegistryAuth(stubRegistryAuth{})
+
+	encoded, err := registry.EncodeAuthConfig(registry.AuthConfig{Username: "bot", Password: "s3cret", ServerAddress: "ghcr.io"})
+	assert.NoError(t, err)
+	mockClient.On("ImagePull", mock.Anything, "ghcr.io/org/private:1", image.PullOptions{RegistryAuth: encoded}).Return(
+		io.NopCloser(strings.NewReader("")), nil)
+	mockClient.On("ImagePull", mock.Anything, "alpine:latest", image.PullOptions{}).Return(
+		io.NopCloser(strings.NewReader("")), nil)
+
+	assert.NoError(t, service.PullImage(context.Background(), "ghcr.io/org/private:1"))
+	assert.NoError(t, service.PullImage(context.Background(), "alpine:latest"))
+
+	configs, err := service.buildAuthConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, "bot", configs["ghcr.io"].Username)
+}
+
+func TestImagePullState_Line(t *testing.T) {
+	state := &imagePullState{layers: map[string]*layerProgress{}}
+	assert.Equal(t, "  alpine:latest: waiting", state.line("alpine:latest"))
+
+	state.layers["a"] = &layerProgress{current: 1000, total: 1000, done: true}
+	state.layers["b"] = &layerProgress{current: 500, total: 3000}
+	assert.Equal(t, "  alpine:latest: 1/2 layers, 1.5kB / 4kB", state.line("alpine:latest"))
+
+	state.done = true
+	assert.Equal(t, "✓ alpine:latest", state.line("alpine:latest"))
+}
+
+func TestService_ContainerListCache(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	service := NewServiceWithClient(mockClient)
+	service.listCache = newContainerListCache(time.Minute)
+	defer mockClient.AssertExpectations(t)
+
+	containers := []container.Summary{
+		{ID: "api-id", Names: []string{"/reactor-ws-api-abc"}, State: "running", Labels: map[string]string{
+			"com.reactor.workspace.instance": "ws1", "com.reactor.workspace.service": "api"}},
+		{ID: "db-id", Names: []string{"/reactor-ws-db-def"}, State: "exited", Labels: map[string]string{
+			"com.reactor.workspace.instance": "ws1", "com.reactor.workspace.service": "db"}},
+		{ID: "other-id", Names: []string{"/unrelated"}, State: "running"},
+	}
+	mockClient.On("ContainerList", mock.Anything, container.ListOptions{All: true}).Return(containers, nil).Twice()
+
+	// Lookups by name and by label share a single listing
+	api, err := service.ContainerExists(context.Background(), "reactor-ws-api-abc")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusRunning, api.Status)
+
+	workspaceContainers, err := service.ListContainersByLabels(context.Background(), map[string]string{"com.reactor.workspace.instance": "ws1"})
+	assert.NoError(t, err)
+	assert.Len(t, workspaceContainers, 2)
+
+	db, err := service.ListContainersByLabels(context.Background(), map[string]string{
+		"com.reactor.workspace.instance": "ws1", "com.reactor.workspace.service": "db"})
+	assert.NoError(t, err)
+	if assert.Len(t, db, 1) {
+		assert.Equal(t, "reactor-ws-db-def", db[0].Name)
+		assert.Equal(t, StatusStopped, db[0].Status)
+	}
+
+	// Changing a container discards the cached listing
+	mockClient.On("ContainerInspect", mock.Anything, "api-id").Return(container.InspectResponse{}, nil)
+	mockClient.On("ContainerStop", mock.Anything, "api-id", mock.Anything).Return(nil).Once()
+	assert.NoError(t, service.StopContainer(context.Background(), "api-id"))
+
+	_, err = service.ContainerExists(context.Background(), "reactor-ws-api-abc")
+	assert.NoError(t, err)
+	mockClient.AssertNumberOfCalls(t, "ContainerList", 2)
+}
+
+func TestService_ContainerListCache_Expires(t *testing.T) {
+	mockClient := &MockDockerClient{}
+	service := NewServiceWithClient(mockClient)
+	service.listCache = newContainerListCache(0)
+
+	mockClient.On("ContainerList", mock.Anything, container.ListOptions{All: true}).Return([]container.Summary{}, nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := service.ContainerExists(context.Background(), "missing")
+		assert.NoError(t, err)
+	}
+	mockClient.AssertNumberOfCalls(t, "ContainerList", 3)
+}
+
+func TestService_CheckPathAccess(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	containerID := "test-container"
+	mockClient.On("ContainerInspect", mock.Anything, containerID).Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+	}, nil)
+	mockClient.On("ContainerExecCreate", mock.Anything, containerID, mock.MatchedBy(func(opts container.ExecOptions) bool {
+		return opts.User == "" && opts.Cmd[0] == "sh" && opts.Cmd[len(opts.Cmd)-1] == "/workspace"
+	})).Return(container.ExecCreateResponse{ID: "exec-id"}, nil)
+
+	var output bytes.Buffer
+	_, _ = stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write([]byte("1000\n1000\n/home/claude/.claude\n"))
+	mockClient.On("ContainerExecAttach", mock.Anything, "exec-id", mock.AnythingOfType("container.ExecStartOptions")).Return(NewMockHijackedResponse(output.String()), nil)
+	mockClient.On("ContainerExecInspect", mock.Anything, "exec-id").Return(container.ExecInspect{}, nil)
+
+	access, err := service.CheckPathAccess(context.Background(), containerID, []string{"/home/claude/.claude", "/home/claude/.gemini", "/workspace"})
+	assert.NoError(t, err)
+	assert.Equal(t, PathAccess{UID: "1000", GID: "1000", Inaccessible: []string{"/home/claude/.claude"}}, access)
+}
+
+func TestService_ChownPaths_RunsAsRoot(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	containerID := "test-container"
+	mockClient.On("ContainerInspect", mock.Anything, containerID).Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+	}, nil)
+	mockClient.On("ContainerExecCreate", mock.Anything, containerID, mock.MatchedBy(func(opts container.ExecOptions) bool {
+		return opts.User == "root" && strings.Join(opts.Cmd, " ") == "chown -R 1000:1001 /home/claude/.claude"
+	})).Return(container.ExecCreateResponse{ID: "exec-id"}, nil)
+	mockClient.On("ContainerExecAttach", mock.Anything, "exec-id", mock.AnythingOfType("container.ExecStartOptions")).Return(NewMockHijackedResponse(""), nil)
+	mockClient.On("ContainerExecInspect", mock.Anything, "exec-id").Return(container.ExecInspect{ExitCode: 1}, nil)
+
+	err := service.ChownPaths(context.Background(), containerID, "1000", "1001", []string{"/home/claude/.claude"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to change owner of /home/claude/.claude")
+}
+
+func TestService_APIVersion(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	ping := types.Ping{APIVersion: "1.45"}
+	mockClient.On("Ping", mock.Anything).Return(ping, nil)
+	mockClient.On("NegotiateAPIVersionPing", ping).Return()
+	mockClient.On("ClientVersion").Return("1.45")
+
+	version, err := service.APIVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "1.45", version)
+}
+
+func TestService_ContainerStats(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	body := `{
+		"pids_stats": {"current": 42},
+		"memory_stats": {"usage": 600, "limit": 4000, "stats": {"inactive_file": 100}},
+		"cpu_stats": {"cpu_usage": {"total_usage": 3000}, "system_cpu_usage": 20000, "online_cpus": 4},
+		"precpu_stats": {"cpu_usage": {"total_usage": 1000}, "system_cpu_usage": 10000}
+	}`
+	for i := 0; i < 2; i++ {
+		mockClient.On("ContainerStats", mock.Anything, "container-id", false).Return(container.StatsResponseReader{Body: io.NopCloser(strings.NewReader(body))}, nil).Once()
+	}
+	mockClient.On("ContainerStats", mock.Anything, "stopped-id", false).Return(container.StatsResponseReader{}, errors.New("not running"))
+
+	stats, err := service.ContainerStats(context.Background(), "container-id")
+	assert.NoError(t, err)
+	assert.Equal(t, ContainerStats{CPUPercent: 80, MemoryUsage: 500, MemoryLimit: 4000, PIDs: 42}, stats)
+
+	collected := service.CollectStats(context.Background(), []string{"container-id", "stopped-id"})
+	assert.Len(t, collected, 1)
+	assert.Equal(t, uint64(42), collected["container-id"].PIDs)
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		message string
+		problem string
+	}{
+		{"permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock", "Permission denied on the Docker socket"},
+		{"Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", "Docker daemon not running"},
+		{"failed to create container: write /var/lib/docker/tmp: no space left on device", "No space left on device"},
+		{"Error response from daemon: manifest for node:99 not found: manifest unknown", "Image not found"},
+		{"pull access denied for myorg/private, repository does not exist or may require 'docker login'", "Image not found"},
+		{"exec /usr/local/bin/entrypoint: exec format error", "Image built for a different CPU architecture"},
+		{"no matching manifest for linux/arm64/v8 in the manifest list entries", "Image built for a different CPU architecture"},
+	}
+	for _, tt := range tests {
+		d, ok := Diagnose(tt.message)
+		assert.True(t, ok, tt.message)
+		assert.Equal(t, tt.problem, d.Problem, tt.message)
+		assert.NotEmpty(t, d.Hint)
+		assert.True(t, strings.HasPrefix(d.DocURL, troubleshootingURL))
+	}
+
+	_, ok := Diagnose("container name already in use")
+	assert.False(t, ok)
+}
+
+func TestWithDiagnosis(t *testing.T) {
+	assert.NoError(t, WithDiagnosis(nil))
+
+	plain := errors.New("invalid session name")
+	assert.Equal(t, plain, WithDiagnosis(plain))
+
+	cause := errors.New("no space left on device")
+	err := WithDiagnosis(fmt.Errorf("failed to create container: %w", cause))
+	assert.ErrorIs(t, err, cause)
+	assert.Contains(t, err.Error(), "failed to create container: no space left on device\n\nHint: ")
+	assert.Contains(t, err.Error(), "See: "+troubleshootingURL+"no-space-left-on-device")
+
+	assert.Equal(t, err, WithDiagnosis(err), "diagnosed errors are not wrapped twice")
+}
+
+func TestCopyToContainer(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	archive := strings.NewReader("tar")
+	mockClient.On("CopyToContainer", mock.Anything, "abc123", "/home/claude/.claude", archive, container.CopyToContainerOptions{CopyUIDGID: true}).Return(nil).Once()
+	assert.NoError(t, service.CopyToContainer(context.Background(), "abc123", "/home/claude/.claude", archive))
+
+	mockClient.On("CopyToContainer", mock.Anything, "abc123", "/missing", archive, mock.Anything).Return(errors.New("no such directory")).Once()
+	err := service.CopyToContainer(context.Background(), "abc123", "/missing", archive)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to copy files to /missing in container abc123")
+}
+
+func TestCopyFromContainer(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("CopyFromContainer", mock.Anything, "abc123", "/home/claude/.claude").Return(io.NopCloser(strings.NewReader("tar")), container.PathStat{Name: ".claude"}, nil).Once()
+	reader, err := service.CopyFromContainer(context.Background(), "abc123", "/home/claude/.claude")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "tar", string(data))
+
+	mockClient.On("CopyFromContainer", mock.Anything, "abc123", "/missing").Return(nil, container.PathStat{}, errors.New("not found")).Once()
+	_, err = service.CopyFromContainer(context.Background(), "abc123", "/missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to copy /missing from container abc123")
+}
+
+func TestService_InspectContainerConfig(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	mockClient.On("ContainerInspect", mock.Anything, "abc").Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			Image: "sha256:img",
+			HostConfig: &container.HostConfig{
+				Binds: []string{"/src:/workspace", "history:/history"},
+				Mounts: []mount.Mount{
+					{Type: mount.TypeTmpfs, Target: "/scratch", Consistency: mount.ConsistencyDefault},
+					{Type: mount.TypeBind, Source: "/var/run/docker.sock", Target: "/var/run/docker.sock", Consistency: mount.ConsistencyDefault},
+				},
+				NetworkMode: "bridge",
+			},
+		},
+		Config: &container.Config{Image: "alpine:3.19", Env: []string{"PATH=/bin", "FOO=bar"}, User: "dev"},
+	}, nil)
+	mockClient.On("ImageInspect", mock.Anything, "sha256:img").Return(image.InspectResponse{
+		Config: &dockerspec.DockerOCIImageConfig{
+			ImageConfig: ocispec.ImageConfig{Env: []string{"PATH=/bin"}},
+		},
+	}, nil)
+
+	cfg, err := service.InspectContainerConfig(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, ContainerConfig{
+		Image:       "alpine:3.19",
+		ImageID:     "sha256:img",
+		Mounts:      []string{"/src:/workspace", "history:/history", "type=tmpfs,target=/scratch", "type=bind,source=/var/run/docker.sock,target=/var/run/docker.sock"},
+		Env:         []string{"PATH=/bin", "FOO=bar"},
+		ImageEnv:    []string{"PATH=/bin"},
+		BindSources: []string{"/src", "/var/run/docker.sock"},
+		NetworkMode: "bridge",
+		User:        "dev",
+	}, cfg)
+}
+
+func TestContextHost(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+
+	writeContext := func(name, meta string) {
+		dir := filepath.Join(configDir, "contexts", "meta", fmt.Sprintf("%x", sha256.Sum256([]byte(name))))
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0644))
+	}
+	writeContext("build-box", `{"Name":"build-box","Endpoints":{"docker":{"Host":"ssh://dev@build-box","SkipTLSVerify":false}}}`)
+	writeContext("empty", `{"Name":"empty","Endpoints":{}}`)
+
+	host, err := ContextHost("build-box")
+	assert.NoError(t, err)
+	assert.Equal(t, "ssh://dev@build-box", host)
+
+	host, err = ContextHost("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "", host)
+
+	_, err = ContextHost("empty")
+	assert.ErrorContains(t, err, "docker context 'empty' has no docker endpoint")
+
+	_, err = ContextHost("missing")
+	assert.ErrorContains(t, err, "docker context 'missing' not found")
+}
+
+func TestSSHDialArgs(t *testing.T) {
+	args, err := sshDialArgs("ssh://dev@build-box:2222")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-o", "ConnectTimeout=30", "-T", "-l", "dev", "-p", "2222", "--", "build-box", "docker", "system", "dial-stdio"}, args)
+
+	args, err = sshDialArgs("ssh://build-box/run/user/1000/docker.sock")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-o", "ConnectTimeout=30", "-T", "--", "build-box", "docker", "--host", "unix:///run/user/1000/docker.sock", "system", "dial-stdio"}, args)
+
+	_, err = sshDialArgs("ssh://")
+	assert.ErrorContains(t, err, "expected ssh://[user@]host[:port]")
+}
+
+// stubSSHDaemon replaces ssh with a stub that records its arguments in the returned
+// file and answers a ping like a daemon relayed by 'docker system dial-stdio'
+func stubSSHDaemon(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "ssh")
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\n" +
+		"read -r request\n" +
+		"printf 'HTTP/1.1 200 OK\\r\\nApi-Version: 1.47\\r\\nContent-Type: text/plain\\r\\nContent-Length: 0\\r\\n\\r\\n'\n" +
+		"cat > /dev/null\n"
+	assert.NoError(t, os.WriteFile(stub, []byte(script), 0755))
+	original := sshBinary
+	sshBinary = stub
+	t.Cleanup(func() { sshBinary = original })
+	return filepath.Join(dir, "args")
+}
+
+// pingVersion pings the daemon of a service and returns its API version
+func pingVersion(t *testing.T, service *Service) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ping, err := service.client.Ping(ctx)
+	assert.NoError(t, err)
+	return ping.APIVersion
+}
+
+func TestNewServiceForHost_SSH(t *testing.T) {
+	argsFile := stubSSHDaemon(t)
+
+	service, err := NewServiceForHost("ssh://dev@build-box")
+	assert.NoError(t, err)
+	defer func() { _ = service.Close() }()
+	assert.Equal(t, "1.47", pingVersion(t, service))
+
+	args, err := os.ReadFile(argsFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "-o ConnectTimeout=30 -T -l dev -- build-box docker system dial-stdio\n", string(args))
+}
+
+func TestNewService_SSHContext(t *testing.T) {
+	argsFile := stubSSHDaemon(t)
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "build-box")
+	dir := filepath.Join(configDir, "contexts", "meta", fmt.Sprintf("%x", sha256.Sum256([]byte("build-box"))))
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "meta.json"), []byte(`{"Name":"build-box","Endpoints":{"docker":{"Host":"ssh://build-box:2222"}}}`), 0644))
+
+	// The endpoint is discovered once per process
+	resetDiscovery := func() {
+		discoverOnce = sync.Once{}
+		discoveredHost, probedHost = "", false
+	}
+	resetDiscovery()
+	t.Cleanup(resetDiscovery)
+
+	service, err := NewService()
+	assert.NoError(t, err)
+	defer func() { _ = service.Close() }()
+	assert.Equal(t, "1.47", pingVersion(t, service), "the context's daemon is reached over ssh")
+
+	args, err := os.ReadFile(argsFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "-o ConnectTimeout=30 -T -p 2222 -- build-box docker system dial-stdio\n", string(args))
+}
+
+func TestCommandConn(t *testing.T) {
+	conn, err := newCommandConn("cat")
+	assert.NoError(t, err)
+	_, err = conn.Write([]byte("hello"))
+	assert.NoError(t, err)
+	assert.NoError(t, conn.CloseWrite())
+	data, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.NoError(t, conn.Close())
+
+	// A command that fails explains why
+	conn, err = newCommandConn("sh", "-c", "echo 'Could not resolve hostname build-box' >&2")
+	assert.NoError(t, err)
+	_, err = io.ReadAll(conn)
+	assert.ErrorContains(t, err, "Could not resolve hostname build-box")
+	assert.NoError(t, conn.Close())
+}
+
+func TestContextTLS(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+	writeContext := func(name, meta, ca string) {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))
+		dir := filepath.Join(configDir, "contexts", "meta", hash)
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0644))
+		if ca != "" {
+			tlsDir := filepath.Join(configDir, "contexts", "tls", hash, "docker")
+			assert.NoError(t, os.MkdirAll(tlsDir, 0755))
+			assert.NoError(t, os.WriteFile(filepath.Join(tlsDir, "ca.pem"), []byte(ca), 0644))
+		}
+	}
+	// A daemon that only speaks TLS
+	daemon := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.47")
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer daemon.Close()
+	daemonHost := "tcp://" + daemon.Listener.Addr().String()
+	writeContext("insecure", `{"Name":"insecure","Endpoints":{"docker":{"Host":"`+daemonHost+`","SkipTLSVerify":true}}}`, "")
+	writeContext("broken", `{"Name":"broken","Endpoints":{"docker":{"Host":"tcp://ci-box:2376"}}}`, "not a certificate")
+	writeContext("plain", `{"Name":"plain","Endpoints":{"docker":{"Host":"tcp://lab-box:2375"}}}`, "")
+
+	tlsConfig, err := contextTLS(daemonHost)
+	assert.NoError(t, err)
+	if assert.NotNil(t, tlsConfig) {
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+	}
+
+	_, err = contextTLS("tcp://ci-box:2376")
+	assert.ErrorContains(t, err, "failed to load the TLS material of docker context 'broken'")
+
+	tlsConfig, err = contextTLS("tcp://lab-box:2375")
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig, "a context without TLS material connects in plain text")
+
+	t.Setenv(client.EnvOverrideCertPath, "")
+	service, err := NewServiceForHost(daemonHost)
+	assert.NoError(t, err)
+	defer func() { _ = service.Close() }()
+	assert.Equal(t, "1.47", pingVersion(t, service), "the context's TLS settings are used")
+}
+
+func TestDiscoverEndpoint(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv("DOCKER_CONFIG", filepath.Join(home, ".docker"))
+	t.Setenv("DOCKER_CONTEXT", "")
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("REACTOR_DOCKER_SOCKETS", "")
+
+	// A stopped runtime's socket file is skipped in favour of one that answers
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".colima", "default"), 0755))
+	stale, err := net.Listen("unix", filepath.Join(home, ".colima", "default", "docker.sock"))
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, stale.Close())
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".orbstack", "run"), 0755))
+	orbstack, err := net.Listen("unix", filepath.Join(home, ".orbstack", "run", "docker.sock"))
+	assert.NoError(t, err)
+	defer orbstack.Close()
+
+	endpoint, err := DiscoverEndpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, "orbstack", endpoint.Source)
+	assert.Equal(t, "unix://"+filepath.Join(home, ".orbstack", "run", "docker.sock"), endpoint.Host)
+	assert.Equal(t, "colima", endpoint.Probes[0].Runtime)
+	assert.False(t, endpoint.Probes[0].Live)
+
+	t.Run("Preference", func(t *testing.T) {
+		t.Setenv("REACTOR_DOCKER_SOCKETS", "docker-desktop,~/.orbstack/run/docker.sock")
+		endpoint, err := DiscoverEndpoint()
+		assert.NoError(t, err)
+		assert.Equal(t, "socket", endpoint.Source)
+		assert.Len(t, endpoint.Probes, 2)
+
+		t.Setenv("REACTOR_DOCKER_SOCKETS", "podman")
+		_, err = DiscoverEndpoint()
+		assert.ErrorContains(t, err, "unknown Docker runtime 'podman'")
+
+		t.Setenv("REACTOR_DOCKER_SOCKETS", "docker-desktop")
+		endpoint, err = DiscoverEndpoint()
+		assert.NoError(t, err)
+		assert.Equal(t, SourceClientDefault, endpoint.Source)
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		t.Setenv("DOCKER_CONTEXT", "missing")
+		_, err := DiscoverEndpoint()
+		assert.ErrorContains(t, err, "docker context 'missing' not found")
+	})
+
+	t.Run("DockerHost", func(t *testing.T) {
+		t.Setenv("DOCKER_HOST", "tcp://build-box:2376")
+		endpoint, err := DiscoverEndpoint()
+		assert.NoError(t, err)
+		assert.Equal(t, Endpoint{Host: "tcp://build-box:2376", Source: SourceDockerHost}, endpoint)
+	})
+}
+
+func TestService_ContainerEvents(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+
+	messages := make(chan events.Message, 2)
+	errs := make(chan error, 1)
+	messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionStart, TimeNano: 1e9,
+		Actor: events.Actor{ID: "other", Attributes: map[string]string{"name": "postgres"}}}
+	messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionOOM, Time: 2,
+		Actor: events.Actor{ID: "abc", Attributes: map[string]string{"name": "reactor-alice-proj-1234", "com.reactor.project": "/src/proj"}}}
+
+	since := time.Unix(100, 0)
+	mockClient.On("Events", mock.Anything, mock.MatchedBy(func(options events.ListOptions) bool {
+		return options.Since == "100" && options.Until == "" &&
+			options.Filters.ExactMatch("type", "container") && options.Filters.Match("event", "oom") && !options.Filters.Match("event", "kill")
+	})).Return(messages, errs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out, outErrs := service.ContainerEvents(ctx, since, time.Time{})
+
+	event := <-out
+	assert.Equal(t, ContainerEvent{
+		Time:       time.Unix(2, 0),
+		Action:     "oom",
+		ID:         "abc",
+		Name:       "reactor-alice-proj-1234",
+		Attributes: map[string]string{"name": "reactor-alice-proj-1234", "com.reactor.project": "/src/proj"},
+	}, event)
+
+	errs <- errors.New("connection reset")
+	_, open := <-out
+	assert.False(t, open)
+	assert.ErrorContains(t, <-outErrs, "failed to read Docker events: connection reset")
+}
+
+func TestNewExecSpec(t *testing.T) {
+	t.Setenv("TERM", "screen-256color")
+	t.Setenv("COLORTERM", "truecolor")
+	info := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+		Config:            &container.Config{User: "claude", WorkingDir: "/workspace"},
+	}
+
+	spec := newExecSpec(info, []string{"make", "test"}, []string{"CI=1"}, "", true)
+	assert.Equal(t, ExecSpec{
+		Command: []string{"make", "test"},
+		User:    "claude",
+		WorkDir: "/workspace",
+		Env:     []string{"TERM=screen-256color", "COLORTERM=truecolor", "CI=1"},
+		Tty:     true,
+	}, spec)
+
+	spec = newExecSpec(info, []string{"chown", "-R", "1000", "/home"}, nil, "root", false)
+	assert.Equal(t, "root", spec.User, "callers can override the user")
+	assert.Empty(t, spec.Env, "commands without a TTY get no terminal variables")
+
+	t.Setenv("TERM", "")
+	assert.Equal(t, []string{"TERM=xterm-256color", "COLORTERM=truecolor"}, sessionEnv(true))
+
+	options := ExecSpec{Command: []string{"sh"}, Detach: true}.options()
+	assert.False(t, options.AttachStdout)
+	assert.False(t, options.AttachStderr)
+}
+
+func TestExecPaths_ShareExecSpec(t *testing.T) {
+	t.Setenv("TERM", "xterm")
+	t.Setenv("COLORTERM", "")
+	service, mockClient := setupTestService()
+	containerID := "container-id"
+	mockClient.On("ContainerInspect", mock.Anything, containerID).Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+		Config:            &container.Config{User: "node", WorkingDir: "/workspace"},
+	}, nil)
+
+	var created []container.ExecOptions
+	mockClient.On("ContainerExecCreate", mock.Anything, containerID, mock.AnythingOfType("container.ExecOptions")).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(2).(container.ExecOptions))
+	}).Return(container.ExecCreateResponse{ID: "exec-id"}, nil)
+	mockClient.On("ContainerExecStart", mock.Anything, "exec-id", mock.AnythingOfType("container.ExecStartOptions")).Return(nil)
+	mockClient.On("ContainerExecAttach", mock.Anything, "exec-id", mock.AnythingOfType("container.ExecStartOptions")).Return(NewMockHijackedResponse(""), nil)
+	mockClient.On("ContainerExecInspect", mock.Anything, "exec-id").Return(container.ExecInspect{}, nil)
+
+	var out bytes.Buffer
+	assert.NoError(t, service.ExecCommand(context.Background(), containerID, ExecOptions{Command: []string{"env"}, Stdout: &out, Stderr: &out}))
+	assert.NoError(t, service.ExecuteInteractiveCommand(context.Background(), containerID, []string{"env"}))
+	assert.NoError(t, service.ExecutePostCreateCommand(context.Background(), containerID, "env"))
+	_, err := service.StartDetachedExec(context.Background(), containerID, "1", []string{"env"}, nil)
+	assert.NoError(t, err)
+
+	if !assert.Len(t, created, 4) {
+		return
+	}
+	for _, options := range created {
+		assert.Equal(t, "node", options.User, "%q", options.Cmd)
+		assert.Equal(t, "/workspace", options.WorkingDir, "%q", options.Cmd)
+	}
+	assert.Empty(t, created[0].Env)
+	assert.Equal(t, []string{"TERM=xterm"}, created[1].Env, "TTY commands get the session's terminal")
+	assert.Equal(t, created[0].Env, created[2].Env, "lifecycle commands match exec without a TTY")
+}
+
+func TestParsePlatform(t *testing.T) {
+	platform, err := ParsePlatform("linux/x86_64")
+	assert.NoError(t, err)
+	assert.Equal(t, ocispec.Platform{OS: "linux", Architecture: "amd64"}, platform)
+
+	platform, err = ParsePlatform("linux/arm/v7")
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/arm/v7", FormatPlatform(platform))
+
+	for _, invalid := range []string{"linux", "linux/", "linux/arm/v7/extra"} {
+		_, err := ParsePlatform(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPlatformSupported(t *testing.T) {
+	published := []ocispec.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+	}
+	assert.True(t, PlatformSupported(published, ocispec.Platform{OS: "linux", Architecture: "x86_64"}))
+	assert.True(t, PlatformSupported(published, ocispec.Platform{OS: "linux", Architecture: "arm"}))
+	assert.False(t, PlatformSupported(published, ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v6"}))
+	assert.False(t, PlatformSupported(published, ocispec.Platform{OS: "linux", Architecture: "arm64"}))
+	assert.False(t, PlatformSupported(nil, ocispec.Platform{OS: "linux", Architecture: "amd64"}))
+}
+
+func TestService_ImagePlatforms(t *testing.T) {
+	ctx := context.Background()
+	mockClient := &MockDockerClient{}
+	service := NewServiceWithClient(mockClient)
+
+	mockClient.On("DistributionInspect", ctx, "node:20", "").Return(registry.DistributionInspect{
+		Platforms: []ocispec.Platform{
+			{OS: "linux", Architecture: "amd64"},
+			{OS: "linux", Architecture: "arm64"},
+			{OS: "unknown", Architecture: "unknown"},
+		},
+	}, nil)
+	platforms, err := service.ImagePlatforms(ctx, "node:20")
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 2)
+
+	// Images the registry does not know are looked up locally
+	mockClient.On("DistributionInspect", ctx, "my-local:dev", "").Return(registry.DistributionInspect{}, errors.New("not found"))
+	mockClient.On("ImageInspect", ctx, "my-local:dev").Return(image.InspectResponse{Os: "linux", Architecture: "arm64"}, nil)
+	platforms, err = service.ImagePlatforms(ctx, "my-local:dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []ocispec.Platform{{OS: "linux", Architecture: "arm64"}}, platforms)
+
+	mockClient.On("DistributionInspect", ctx, "missing:1", "").Return(registry.DistributionInspect{}, errors.New("manifest unknown"))
+	mockClient.On("ImageInspect", ctx, "missing:1").Return(image.InspectResponse{}, errors.New("no such image"))
+	_, err = service.ImagePlatforms(ctx, "missing:1")
+	assert.ErrorContains(t, err, "failed to inspect the manifest of missing:1: manifest unknown")
+
+	mockClient.On("ServerVersion", ctx).Return(types.Version{Os: "linux", Arch: "aarch64"}, nil)
+	daemon, err := service.DaemonPlatform(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/arm64", FormatPlatform(daemon))
+}
+
+func TestService_PullImagesForPlatform(t *testing.T) {
+	ctx := context.Background()
+	mockClient := &MockDockerClient{}
+	service := NewServiceWithClient(mockClient)
+	service.SetImagePlatforms(map[string]string{"node:20": "linux/amd64"})
+
+	// The local copy was pulled for the daemon's own platform, so it is pulled again
+	mockClient.On("ImageList", mock.Anything, mock.Anything).Return([]image.Summary{{RepoTags: []string{"node:20"}}}, nil)
+	mockClient.On("ImageInspect", mock.Anything, "node:20").Return(image.InspectResponse{Os: "linux", Architecture: "arm64"}, nil)
+	mockClient.On("ImagePull", mock.Anything, "node:20", image.PullOptions{Platform: "linux/amd64"}).Return(io.NopCloser(strings.NewReader(`{"status":"Downloaded"}`)), nil)
+
+	assert.NoError(t, service.PullImages(ctx, []string{"node:20"}, io.Discard))
+	mockClient.AssertExpectations(t)
+}
+
+func TestRemoveReplacedImage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RemovesUntaggedPreviousBuild", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ImageInspect", mock.Anything, "reactor-build:abc").Return(image.InspectResponse{ID: "sha256:new"}, nil)
+		mockClient.On("ImageInspect", mock.Anything, "sha256:old").Return(image.InspectResponse{ID: "sha256:old"}, nil)
+		mockClient.On("ImageRemove", mock.Anything, "sha256:old", image.RemoveOptions{PruneChildren: true}).Return([]image.DeleteResponse{}, nil)
+
+		removed, err := service.RemoveReplacedImage(ctx, "reactor-build:abc", "sha256:old")
+		assert.NoError(t, err)
+		assert.True(t, removed)
+	})
+
+	t.Run("UnchangedBuild", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ImageInspect", mock.Anything, "reactor-build:abc").Return(image.InspectResponse{ID: "sha256:old"}, nil)
+
+		removed, err := service.RemoveReplacedImage(ctx, "reactor-build:abc", "sha256:old")
+		assert.NoError(t, err)
+		assert.False(t, removed)
+
+		removed, err = service.RemoveReplacedImage(ctx, "reactor-build:abc", "")
+		assert.NoError(t, err)
+		assert.False(t, removed)
+	})
+
+	t.Run("KeepsTaggedImage", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ImageInspect", mock.Anything, "reactor-build:abc").Return(image.InspectResponse{ID: "sha256:new"}, nil)
+		mockClient.On("ImageInspect", mock.Anything, "sha256:old").Return(image.InspectResponse{ID: "sha256:old", RepoTags: []string{"myapp:v1"}}, nil)
+
+		removed, err := service.RemoveReplacedImage(ctx, "reactor-build:abc", "sha256:old")
+		assert.NoError(t, err)
+		assert.False(t, removed)
+		mockClient.AssertNotCalled(t, "ImageRemove", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("KeepsImageInUse", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ImageInspect", mock.Anything, "reactor-build:abc").Return(image.InspectResponse{ID: "sha256:new"}, nil)
+		mockClient.On("ImageInspect", mock.Anything, "sha256:old").Return(image.InspectResponse{ID: "sha256:old"}, nil)
+		mockClient.On("ImageRemove", mock.Anything, "sha256:old", image.RemoveOptions{PruneChildren: true}).Return([]image.DeleteResponse(nil), errdefs.Conflict(errors.New("image is being used by stopped container")))
+
+		removed, err := service.RemoveReplacedImage(ctx, "reactor-build:abc", "sha256:old")
+		assert.NoError(t, err)
+		assert.False(t, removed)
+	})
+
+	t.Run("RemoveFails", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ImageInspect", mock.Anything, "reactor-build:abc").Return(image.InspectResponse{ID: "sha256:new"}, nil)
+		mockClient.On("ImageInspect", mock.Anything, "sha256:old").Return(image.InspectResponse{ID: "sha256:old"}, nil)
+		mockClient.On("ImageRemove", mock.Anything, "sha256:old", image.RemoveOptions{PruneChildren: true}).Return([]image.DeleteResponse(nil), errors.New("daemon unavailable"))
+
+		_, err := service.RemoveReplacedImage(ctx, "reactor-build:abc", "sha256:old")
+		assert.ErrorContains(t, err, "failed to remove replaced image sha256:old: daemon unavailable")
+	})
+}
+
+func TestService_ContainerIP(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("FirstNetworkByName", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ContainerInspect", mock.Anything, "abc").Return(container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+			NetworkSettings: &container.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+				"none":   {},
+				"bridge": {IPAddress: "172.17.0.5"},
+				"shared": {IPAddress: "172.20.0.3"},
+			}},
+		}, nil)
+
+		ip, err := service.ContainerIP(ctx, "abc")
+		assert.NoError(t, err)
+		assert.Equal(t, "172.17.0.5", ip)
+	})
+
+	t.Run("NotRunning", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ContainerInspect", mock.Anything, "abc").Return(container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{}},
+		}, nil)
+
+		_, err := service.ContainerIP(ctx, "abc")
+		assert.EqualError(t, err, "container abc is not running")
+	})
+
+	t.Run("HostNetwork", func(t *testing.T) {
+		service, mockClient := setupTestService()
+		defer mockClient.AssertExpectations(t)
+		mockClient.On("ContainerInspect", mock.Anything, "abc").Return(container.InspectResponse{
+			ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true}},
+			NetworkSettings:   &container.NetworkSettings{Networks: map[string]*network.EndpointSettings{"host": {}}},
+		}, nil)
+
+		_, err := service.ContainerIP(ctx, "abc")
+		assert.ErrorContains(t, err, "has no IP address")
+	})
+}
+
+func TestService_InspectContainerTimes(t *testing.T) {
+	service, mockClient := setupTestService()
+	defer mockClient.AssertExpectations(t)
+	mockClient.On("ContainerInspect", mock.Anything, "running").Return(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{State: &container.State{Running: true, StartedAt: "2026-10-16T09:00:00.5Z", FinishedAt: "0001-01-01T00:00:00Z"}},
+	}, nil)
+	mockClient.On("ContainerInspect", mock.Anything, "gone").Return(container.InspectResponse{}, errors.New("no such container"))
+
+	times := service.InspectContainerTimes(context.Background(), []string{"running", "gone"})
+	assert.Equal(t, map[string]ContainerTimes{
+		"running": {StartedAt: time.Date(2026, 10, 16, 9, 0, 0, 5e8, time.UTC)},
+	}, times)
+}
@@ -10,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/dyluth/reactor/pkg/metrics"
 	"github.com/moby/term"
 )
 
@@ -42,7 +43,7 @@ func (ts *TerminalState) Setup() error {
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
 
-	if !term.IsTerminal(os.Stdin.Fd()) {
+	if !IsInteractiveTerminal() {
 		return nil // Not a terminal, skip TTY setup
 	}
 
@@ -114,6 +115,12 @@ func (ts *TerminalState) GetTerminalSize() (TTYSize, error) {
 	}, nil
 }
 
+// IsInteractiveTerminal reports whether both stdin and stdout are terminals.
+// When either is redirected (CI, pipes, here-docs) sessions run without raw mode or a TTY.
+func IsInteractiveTerminal() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
 // StartSignalHandling begins signal forwarding to container
 func (ts *TerminalState) StartSignalHandling() {
 	// Register for signals we want to forward
@@ -125,8 +132,17 @@ func (ts *TerminalState) StartSignalHandling() {
 	)
 }
 
+// defaultSessionCommand is the shell started when attaching without a specific command
+var defaultSessionCommand = []string{"/bin/bash"}
+
 // AttachInteractiveSession attaches to a running container with enhanced TTY support
 func (s *Service) AttachInteractiveSession(ctx context.Context, containerID string) error {
+	return s.AttachSessionCommand(ctx, containerID, defaultSessionCommand)
+}
+
+// AttachSessionCommand attaches to a running container, running command instead of the
+// default shell. A command that exits non-zero is reported as an *ExitError.
+func (s *Service) AttachSessionCommand(ctx context.Context, containerID string, command []string) error {
 	// Check if container is running
 	containerInfo, err := s.client.ContainerInspect(ctx, containerID)
 	if err != nil {
@@ -150,18 +166,14 @@ func (s *Service) AttachInteractiveSession(ctx context.Context, containerID stri
 		return fmt.Errorf("failed to setup terminal: %w", err)
 	}
 
-	isTerminal := term.IsTerminal(os.Stdin.Fd())
+	isTerminal := IsInteractiveTerminal()
 
 	// Create exec instance for interactive shell
-	execConfig := container.ExecOptions{
-		AttachStdin:  true,
-		AttachStdout: true,
-		AttachStderr: true,
-		Tty:          isTerminal,
-		Cmd:          []string{"/bin/bash"}, // Default to bash, could be configurable
-	}
+	spec := newExecSpec(containerInfo, command, nil, "", isTerminal)
+	spec.Stdin = true
 
-	execResp, err := s.client.ContainerExecCreate(ctx, containerID, execConfig)
+	doneAttach := s.profile.Track(metrics.PhaseAttach)
+	execResp, err := s.client.ContainerExecCreate(ctx, containerID, spec.options())
 	if err != nil {
 		return fmt.Errorf("failed to create exec instance: %w", err)
 	}
@@ -171,11 +183,17 @@ func (s *Service) AttachInteractiveSession(ctx context.Context, containerID stri
 		Detach: false,
 		Tty:    isTerminal,
 	})
+	doneAttach()
 	if err != nil {
 		return fmt.Errorf("failed to attach to exec instance: %w", err)
 	}
 	defer attachResp.Close()
 
+	// Without a terminal, stream plain stdin/stdout so the session can be scripted
+	if !isTerminal {
+		return s.streamExec(ctx, execResp.ID, attachResp, false, os.Stdin, os.Stdout, os.Stderr)
+	}
+
 	// Start signal handling for terminal
 	if isTerminal {
 		termState.StartSignalHandling()
@@ -208,14 +226,17 @@ func (s *Service) AttachInteractiveSession(ctx context.Context, containerID stri
 		}
 	}()
 
-	// Copy container output to stdout
+	// Copy container output to stdout. The output ends with the session's process, so
+	// its exit status is the session's result.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		_, err := io.Copy(os.Stdout, attachResp.Reader)
 		if err != nil && err != io.EOF {
 			errChan <- fmt.Errorf("stdout copy failed: %w", err)
+			return
 		}
+		errChan <- s.execExitError(ctx, execResp.ID)
 	}()
 
 	// Handle signals and terminal resize if in TTY mode
@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshBinary is the ssh client used to reach ssh:// daemons; tests replace it with a stub
+var sshBinary = "ssh"
+
+// sshDialArgs returns the ssh arguments that run 'docker system dial-stdio' on the host
+// of an ssh:// address, which relays the connection to that host's daemon
+func sshDialArgs(host string) ([]string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+	if u.Scheme != "ssh" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid docker host %q: expected ssh://[user@]host[:port]", host)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid docker host %q: ssh hosts take no query or fragment", host)
+	}
+
+	args := []string{"-o", "ConnectTimeout=30", "-T"}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	args = append(args, "--", u.Hostname(), "docker")
+	// A path names the daemon's socket on the remote host
+	if u.Path != "" && u.Path != "/" {
+		args = append(args, "--host", "unix://"+u.Path)
+	}
+	return append(args, "system", "dial-stdio"), nil
+}
+
+// sshDialer returns a dial function that connects to the daemon of an ssh:// host
+func sshDialer(host string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	args, err := sshDialArgs(host)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return newCommandConn(sshBinary, args...)
+	}, nil
+}
+
+// commandConn is a connection to the standard input and output of a command
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr syncBuffer
+
+	waitOnce  sync.Once
+	closeOnce sync.Once
+}
+
+// newCommandConn starts name with args and connects to it. The command is not tied to
+// a context, as the connection outlives the dial that opens it.
+func newCommandConn(name string, args ...string) (*commandConn, error) {
+	c := &commandConn{cmd: exec.Command(name, args...)}
+	c.cmd.Stderr = &c.stderr
+	var err error
+	if c.stdin, err = c.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if c.stdout, err = c.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return c, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF {
+		// The command is exiting; once it has, its error output says why, e.g. that ssh
+		// could not connect
+		c.wait()
+		if message := strings.TrimSpace(c.stderr.String()); message != "" {
+			return n, fmt.Errorf("%s: %s", c.cmd.Path, message)
+		}
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// CloseWrite closes the command's standard input, so attached streams can signal EOF
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		_ = c.stdin.Close()
+		_ = c.cmd.Process.Kill()
+		c.wait()
+	})
+	return nil
+}
+
+// wait waits for the command to exit and its error output to be collected
+func (c *commandConn) wait() {
+	c.waitOnce.Do(func() { _ = c.cmd.Wait() })
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+// Deadlines are not supported on pipes to a command; the client relies on contexts
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// commandAddr is the address of a commandConn
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
+
+// syncBuffer collects a command's error output, which exec copies from a goroutine of
+// its own while the connection may read it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
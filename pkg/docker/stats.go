@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ContainerStats is a single resource usage sample of a running container
+type ContainerStats struct {
+	CPUPercent  float64 // share of one CPU, so a busy container on 4 CPUs can reach 400%
+	MemoryUsage uint64  // bytes in use, excluding reclaimable page cache
+	MemoryLimit uint64
+	PIDs        uint64
+}
+
+// ContainerStats queries the stats API once for a running container. The daemon takes
+// two samples about a second apart so CPU usage can be calculated.
+func (s *Service) ContainerStats(ctx context.Context, containerID string) (ContainerStats, error) {
+	resp, err := s.client.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to get stats of container %s: %w", containerID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var raw container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to decode stats of container %s: %w", containerID, err)
+	}
+	return statsFromResponse(raw), nil
+}
+
+// CollectStats queries the stats of several containers in parallel. Containers whose
+// stats cannot be read are left out of the result.
+func (s *Service) CollectStats(ctx context.Context, containerIDs []string) map[string]ContainerStats {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	stats := make(map[string]ContainerStats, len(containerIDs))
+
+	for _, id := range containerIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			sample, err := s.ContainerStats(ctx, id)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			stats[id] = sample
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return stats
+}
+
+// statsFromResponse calculates usage the way 'docker stats' does
+func statsFromResponse(raw container.StatsResponse) ContainerStats {
+	stats := ContainerStats{
+		MemoryUsage: raw.MemoryStats.Usage,
+		MemoryLimit: raw.MemoryStats.Limit,
+		PIDs:        raw.PidsStats.Current,
+	}
+
+	// Page cache can be reclaimed, so it is not counted as used (cgroup v2, then v1)
+	cache, ok := raw.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = raw.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < stats.MemoryUsage {
+		stats.MemoryUsage -= cache
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(raw.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(raw.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+	return stats
+}
@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+)
+
+// CreateOverlayVolume creates a local volume that mounts an overlay filesystem.
+// The lower directory is never written to; all changes land in the upper directory.
+func (s *Service) CreateOverlayVolume(ctx context.Context, name, mountOptions string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	labels := map[string]string{"com.reactor.overlay": "true"}
+	// Add test label if REACTOR_ISOLATION_PREFIX is set (indicates test run)
+	if os.Getenv("REACTOR_ISOLATION_PREFIX") != "" {
+		labels["com.reactor.test"] = "true"
+	}
+
+	if _, err := s.client.VolumeCreate(ctx, volume.CreateOptions{
+		Name:   name,
+		Driver: "local",
+		DriverOpts: map[string]string{
+			"type":   "overlay",
+			"device": "overlay",
+			"o":      mountOptions,
+		},
+		Labels: labels,
+	}); err != nil {
+		return fmt.Errorf("failed to create overlay volume %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// VolumeExists reports whether a volume exists
+func (s *Service) VolumeExists(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := s.client.VolumeInspect(ctx, name)
+	if errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// RemoveVolume removes a volume by name
+func (s *Service) RemoveVolume(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := s.client.VolumeRemove(ctx, name, true); err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+
+	return nil
+}
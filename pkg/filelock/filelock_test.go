@@ -0,0 +1,57 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock_SerializesUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	require.NoError(t, WriteFile(path, []byte("0"), 0644))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := Lock(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer unlock()
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			n, _ := strconv.Atoi(string(data))
+			assert.NoError(t, WriteFile(path, []byte(strconv.Itoa(n+1)), 0644))
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "20", string(data), "no update is lost")
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "state.json")
+
+	require.NoError(t, WriteFile(path, []byte(`{"a":1}`), 0600))
+	require.NoError(t, WriteFile(path, []byte(`{"a":2}`), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":2}`, string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	entries, err := os.ReadDir(filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+}
@@ -0,0 +1,174 @@
+// Package hooks runs user executables on the host at points in reactor's lifecycle,
+// so VPN setup, notifications or telemetry can be integrated without changing reactor.
+//
+// A hook is an executable at ~/.reactor/hooks/<event>, or any number of executables in
+// the directory ~/.reactor/hooks/<event>/, which run in name order. Each hook receives
+// the event as JSON on stdin and in the REACTOR_HOOK_EVENT environment variable.
+// Events are also posted to the webhooks in the user's preferences.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+)
+
+// Event names a point in reactor's lifecycle where hooks run
+type Event string
+
+// Events hooks can be installed for
+const (
+	PreUp            Event = "pre-up"
+	PostUp           Event = "post-up"
+	PreDown          Event = "pre-down"
+	PostDown         Event = "post-down"
+	PostExec         Event = "post-exec"
+	PostCreateFailed Event = "post-create-failed"
+)
+
+// Events lists every event, in lifecycle order
+var Events = []Event{PreUp, PostUp, PreDown, PostDown, PostExec, PostCreateFailed}
+
+// Timeout bounds how long a single hook may run
+const Timeout = 2 * time.Minute
+
+// Payload describes the event a hook is run for
+type Payload struct {
+	Event         Event     `json:"event"`
+	Time          time.Time `json:"time"`
+	ProjectRoot   string    `json:"projectRoot"`
+	Account       string    `json:"account"`
+	ContainerName string    `json:"containerName,omitempty"`
+	ContainerID   string    `json:"containerId,omitempty"`
+	Session       string    `json:"session,omitempty"`
+	Command       []string  `json:"command,omitempty"`  // post-exec: the command that ran
+	ExitCode      *int      `json:"exitCode,omitempty"` // post-exec: its exit code, when it ran to completion
+	// DurationSeconds is how long a post-exec command ran, in whole seconds
+	DurationSeconds int64  `json:"durationSeconds,omitempty"`
+	Error           string `json:"error,omitempty"` // post-create-failed: why the lifecycle command failed
+}
+
+// Dir returns the directory hooks are installed in
+func Dir() (string, error) {
+	home, err := config.GetReactorHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "hooks"), nil
+}
+
+// Find returns the hooks installed in dir for an event, in the order they run
+func Find(dir string, event Event) ([]string, error) {
+	path := filepath.Join(dir, string(event))
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		if !isExecutable(info) {
+			return nil, fmt.Errorf("hook %s is not executable (chmod +x it to enable it)", path)
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook directory %s: %w", path, err)
+	}
+	var hooks []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.IsDir() || !isExecutable(info) {
+			continue
+		}
+		hooks = append(hooks, filepath.Join(path, entry.Name()))
+	}
+	sort.Strings(hooks)
+	return hooks, nil
+}
+
+// Run runs the hooks in dir for payload.Event, stopping at the first that fails.
+// Hook output is written to out.
+func Run(ctx context.Context, dir string, payload Payload, out io.Writer) error {
+	hooks, err := Find(dir, payload.Event)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	if payload.Time.IsZero() {
+		payload.Time = time.Now().UTC()
+	}
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s hook payload: %w", payload.Event, err)
+	}
+
+	for _, hook := range hooks {
+		if err := runHook(ctx, hook, payload.Event, input, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fire runs the installed hooks for an event, with their output on stderr, then posts
+// it to the configured webhooks. A failing pre-* hook is returned so the operation can
+// be aborted; failures of other hooks and of webhooks only produce a warning.
+func Fire(ctx context.Context, payload Payload) error {
+	if payload.Time.IsZero() {
+		payload.Time = time.Now().UTC()
+	}
+	dir, err := Dir()
+	if err == nil {
+		err = Run(ctx, dir, payload, os.Stderr)
+	}
+	if err == nil || !strings.HasPrefix(string(payload.Event), "pre-") {
+		// A pre-* hook that fails aborts the operation, so it is not announced
+		postConfiguredWebhooks(ctx, payload)
+	}
+	if err == nil {
+		return nil
+	}
+	if strings.HasPrefix(string(payload.Event), "pre-") {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	return nil
+}
+
+func runHook(ctx context.Context, hook string, event Event, input []byte, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.Env = append(os.Environ(), "REACTOR_HOOK_EVENT="+string(event))
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s hook %s timed out after %s", event, hook, Timeout)
+		}
+		return fmt.Errorf("%s hook %s failed: %w", event, hook, err)
+	}
+	return nil
+}
+
+func isExecutable(info os.FileInfo) bool {
+	return info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,95 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeHook(t *testing.T, path, script string, mode os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), mode))
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+
+	hooks, err := Find(dir, PreUp)
+	require.NoError(t, err)
+	assert.Empty(t, hooks)
+
+	writeHook(t, filepath.Join(dir, "pre-up"), "exit 0\n", 0755)
+	hooks, err = Find(dir, PreUp)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "pre-up")}, hooks)
+
+	// Directory hooks run in name order and non-executables are skipped
+	writeHook(t, filepath.Join(dir, "post-up", "20-notify"), "exit 0\n", 0755)
+	writeHook(t, filepath.Join(dir, "post-up", "10-vpn"), "exit 0\n", 0755)
+	writeHook(t, filepath.Join(dir, "post-up", "README"), "", 0644)
+	hooks, err = Find(dir, PostUp)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "post-up", "10-vpn"), filepath.Join(dir, "post-up", "20-notify")}, hooks)
+
+	writeHook(t, filepath.Join(dir, "pre-down"), "exit 0\n", 0644)
+	_, err = Find(dir, PreDown)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not executable")
+}
+
+func TestRun_PassesPayload(t *testing.T) {
+	dir := t.TempDir()
+	writeHook(t, filepath.Join(dir, "post-exec"), `echo "event=$REACTOR_HOOK_EVENT"; cat`+"\n", 0755)
+
+	code := 3
+	var out bytes.Buffer
+	err := Run(context.Background(), dir, Payload{
+		Event:         PostExec,
+		ProjectRoot:   "/src/app",
+		Account:       "work",
+		ContainerName: "reactor-work-app-abc123",
+		Command:       []string{"make", "test"},
+		ExitCode:      &code,
+	}, &out)
+	require.NoError(t, err)
+
+	first, rest, _ := bytes.Cut(out.Bytes(), []byte("\n"))
+	assert.Equal(t, "event=post-exec", string(first))
+	var payload Payload
+	require.NoError(t, json.Unmarshal(rest, &payload))
+	assert.Equal(t, PostExec, payload.Event)
+	assert.Equal(t, []string{"make", "test"}, payload.Command)
+	require.NotNil(t, payload.ExitCode)
+	assert.Equal(t, 3, *payload.ExitCode)
+	assert.False(t, payload.Time.IsZero())
+}
+
+func TestRun_StopsAtFirstFailure(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "ran")
+	writeHook(t, filepath.Join(dir, "pre-up", "10-fail"), "exit 2\n", 0755)
+	writeHook(t, filepath.Join(dir, "pre-up", "20-after"), "touch "+marker+"\n", 0755)
+
+	err := Run(context.Background(), dir, Payload{Event: PreUp}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pre-up hook "+filepath.Join(dir, "pre-up", "10-fail")+" failed")
+	assert.NoFileExists(t, marker)
+}
+
+func TestFire_OnlyPreHooksFail(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	writeHook(t, filepath.Join(home, ".reactor", "hooks", "pre-down"), "exit 1\n", 0755)
+	writeHook(t, filepath.Join(home, ".reactor", "hooks", "post-up"), "exit 1\n", 0755)
+
+	assert.Error(t, Fire(context.Background(), Payload{Event: PreDown}))
+	assert.NoError(t, Fire(context.Background(), Payload{Event: PostUp}))
+}
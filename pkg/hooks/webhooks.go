@@ -0,0 +1,139 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/settings"
+)
+
+// WebhookTimeout bounds how long a single webhook request may take
+const WebhookTimeout = 10 * time.Second
+
+// postConfiguredWebhooks posts an event to the webhooks in the user's preferences,
+// printing a warning for each that fails
+func postConfiguredWebhooks(ctx context.Context, payload Payload) {
+	prefs, err := settings.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: webhooks not sent: %v\n", err)
+		return
+	}
+	client := &http.Client{Timeout: WebhookTimeout}
+	for _, err := range PostWebhooks(ctx, client, prefs.Webhooks, payload) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// PostWebhooks posts payload to every webhook subscribed to its event and returns the
+// errors of those that failed
+func PostWebhooks(ctx context.Context, client *http.Client, webhooks []settings.Webhook, payload Payload) []error {
+	var errs []error
+	for i, webhook := range webhooks {
+		for _, event := range webhook.Events {
+			if !slices.Contains(Events, Event(event)) {
+				errs = append(errs, fmt.Errorf("webhook %d subscribes to unknown event '%s'", i+1, event))
+			}
+		}
+		if !webhookWants(webhook, payload) {
+			continue
+		}
+		if err := postWebhook(ctx, client, webhook, payload); err != nil {
+			errs = append(errs, fmt.Errorf("%s webhook %d: %w", payload.Event, i+1, err))
+		}
+	}
+	return errs
+}
+
+// webhookWants reports whether a webhook subscribes to an event; post-exec events of
+// commands shorter than its MinDuration are left out
+func webhookWants(webhook settings.Webhook, payload Payload) bool {
+	if len(webhook.Events) > 0 && !slices.Contains(webhook.Events, string(payload.Event)) {
+		return false
+	}
+	if payload.Event == PostExec && time.Duration(payload.DurationSeconds)*time.Second < webhook.MinExecDuration() {
+		return false
+	}
+	return true
+}
+
+func postWebhook(ctx context.Context, client *http.Client, webhook settings.Webhook, payload Payload) error {
+	body, err := WebhookBody(webhook, payload)
+	if err != nil {
+		return err
+	}
+	target := os.ExpandEnv(webhook.URL)
+	if target == "" {
+		return fmt.Errorf("url %s is empty", webhook.URL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "reactor")
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error includes the URL, which may be a secret
+		return fmt.Errorf("request failed: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("server responded %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// WebhookBody renders the JSON a webhook receives for an event: its template applied to
+// the payload, or the payload itself. Templates can quote values with the json function,
+// e.g. {"text": {{printf "%s is up" .ContainerName | json}}}.
+func WebhookBody(webhook settings.Webhook, payload Payload) ([]byte, error) {
+	if webhook.Template == "" {
+		return json.Marshal(payload)
+	}
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": templateJSON}).Option("missingkey=error").Parse(webhook.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, payload); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("template did not produce valid JSON: %s", body.String())
+	}
+	return body.Bytes(), nil
+}
+
+// templateJSON encodes a template value as JSON, e.g. to quote a string
+func templateJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// unwrapURLError drops the method and URL net/http adds to request errors
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
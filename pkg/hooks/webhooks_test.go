@@ -0,0 +1,87 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer records the requests it receives and responds with status
+func webhookServer(t *testing.T, status int) (*httptest.Server, *[]*http.Request, *[]string) {
+	t.Helper()
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("no_service"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &bodies
+}
+
+func TestPostWebhooks_EventsAndTemplate(t *testing.T) {
+	server, requests, bodies := webhookServer(t, http.StatusOK)
+	t.Setenv("TEST_WEBHOOK_URL", server.URL)
+	t.Setenv("TEST_WEBHOOK_TOKEN", "s3cret")
+	webhooks := []settings.Webhook{
+		{
+			URL:      "${TEST_WEBHOOK_URL}/slack",
+			Events:   []string{"post-up"},
+			Template: `{"text": {{printf "%s is up in %s" .ContainerName .ProjectRoot | json}}}`,
+			Headers:  map[string]string{"Authorization": "Bearer ${TEST_WEBHOOK_TOKEN}"},
+		},
+		{URL: server.URL + "/all"},
+	}
+
+	errs := PostWebhooks(context.Background(), http.DefaultClient, webhooks, Payload{Event: PostUp, ContainerName: `reactor-"demo"`, ProjectRoot: "/src/demo"})
+	assert.Empty(t, errs)
+	require.Len(t, *requests, 2)
+	assert.Equal(t, "/slack", (*requests)[0].URL.Path)
+	assert.Equal(t, "Bearer s3cret", (*requests)[0].Header.Get("Authorization"))
+	assert.Equal(t, "application/json", (*requests)[0].Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"text": "reactor-\"demo\" is up in /src/demo"}`, (*bodies)[0])
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal([]byte((*bodies)[1]), &payload))
+	assert.Equal(t, PostUp, payload.Event)
+	assert.Equal(t, "/src/demo", payload.ProjectRoot)
+
+	// Only the webhook without an event filter gets post-down
+	errs = PostWebhooks(context.Background(), http.DefaultClient, webhooks, Payload{Event: PostDown})
+	assert.Empty(t, errs)
+	assert.Len(t, *requests, 3)
+}
+
+func TestPostWebhooks_MinDuration(t *testing.T) {
+	server, requests, _ := webhookServer(t, http.StatusOK)
+	webhooks := []settings.Webhook{{URL: server.URL, Events: []string{"post-exec"}, MinDuration: "5m"}}
+
+	assert.Empty(t, PostWebhooks(context.Background(), http.DefaultClient, webhooks, Payload{Event: PostExec, DurationSeconds: 60}))
+	assert.Empty(t, *requests)
+	assert.Empty(t, PostWebhooks(context.Background(), http.DefaultClient, webhooks, Payload{Event: PostExec, DurationSeconds: 600}))
+	assert.Len(t, *requests, 1)
+}
+
+func TestPostWebhooks_Errors(t *testing.T) {
+	server, _, _ := webhookServer(t, http.StatusNotFound)
+
+	errs := PostWebhooks(context.Background(), http.DefaultClient, []settings.Webhook{
+		{URL: server.URL},
+		{URL: server.URL, Events: []string{"post-start"}},
+		{URL: server.URL, Template: `{"text": {{.ContainerName}}}`, Events: []string{"post-up"}},
+	}, Payload{Event: PostUp, ContainerName: "demo"})
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "post-up webhook 1: server responded 404 Not Found: no_service")
+	assert.EqualError(t, errs[1], "webhook 2 subscribes to unknown event 'post-start'")
+	assert.Contains(t, errs[2].Error(), "post-up webhook 3: template did not produce valid JSON")
+}
@@ -35,6 +35,7 @@ func TestBuildFunctionality(t *testing.T) {
 	defer func() { _ = os.Chdir(originalWD) }()
 
 	t.Run("basic_dockerfile_build", func(t *testing.T) {
+		testutil.SkipIfOffline(t, "the image build installs packages")
 		isolationPrefix := "build-basic-" + randomString(8)
 
 		// Create separate directory for this subtest
@@ -106,6 +107,7 @@ CMD ["/bin/sh"]`
 	})
 
 	t.Run("build_with_custom_context", func(t *testing.T) {
+		testutil.SkipIfOffline(t, "the image build installs npm packages")
 		isolationPrefix := "build-context-" + randomString(8)
 
 		// Create separate directory for this subtest
@@ -302,6 +304,7 @@ FROM alpine:latest`
 	})
 
 	t.Run("build_creates_reusable_image", func(t *testing.T) {
+		testutil.SkipIfOffline(t, "the image build installs packages")
 		isolationPrefix := "build-reuse-" + randomString(8)
 
 		// Create separate directory for this subtest
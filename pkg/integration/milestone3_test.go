@@ -15,6 +15,7 @@ import (
 
 // TestAccountBasedCredentialMounting tests the account-based credential mounting feature
 func TestAccountBasedCredentialMounting(t *testing.T) {
+	testutil.RequireImage(t, "alpine:latest")
 	homeDir, testDir, cleanup := testutil.SetupIsolatedTest(t)
 	defer cleanup()
 
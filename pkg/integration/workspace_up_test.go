@@ -243,6 +243,9 @@ func TestWorkspaceContainerNamingConventions(t *testing.T) {
 
 // setupTestWorkspaceWithImages creates a complete workspace structure for testing
 func setupTestWorkspaceWithImages(t *testing.T, tmpDir string) {
+	// The locally built test image keeps the suite hermetic
+	image := testutil.EnsureTestImage(t)
+
 	// Create service directories
 	apiDir := filepath.Join(tmpDir, "services", "api", ".devcontainer")
 	frontendDir := filepath.Join(tmpDir, "services", "frontend", ".devcontainer")
@@ -255,8 +258,7 @@ func setupTestWorkspaceWithImages(t *testing.T, tmpDir string) {
 
 	err := os.WriteFile(apiDevcontainer, []byte(`{
 		"name": "api-service",
-		"image": "node:18-alpine",
-		"remoteUser": "node",
+		"image": "`+image+`",
 		"customizations": {
 			"reactor": {
 				"account": "api-account",
@@ -268,8 +270,7 @@ func setupTestWorkspaceWithImages(t *testing.T, tmpDir string) {
 
 	err = os.WriteFile(frontendDevcontainer, []byte(`{
 		"name": "frontend-service",
-		"image": "node:18-alpine", 
-		"remoteUser": "node",
+		"image": "`+image+`",
 		"customizations": {
 			"reactor": {
 				"account": "frontend-account",
@@ -307,14 +308,14 @@ func setupWorkspaceWithPortConflicts(t *testing.T, tmpDir string) {
 
 	err := os.WriteFile(service1Devcontainer, []byte(`{
 		"name": "service1",
-		"image": "node:18-alpine",
+		"image": "`+testutil.TestImage+`",
 		"forwardPorts": [3000]
 	}`), 0644)
 	require.NoError(t, err)
 
 	err = os.WriteFile(service2Devcontainer, []byte(`{
-		"name": "service2", 
-		"image": "node:18-alpine",
+		"name": "service2",
+		"image": "`+testutil.TestImage+`",
 		"forwardPorts": [3000]
 	}`), 0644)
 	require.NoError(t, err)
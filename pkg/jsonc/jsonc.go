@@ -0,0 +1,140 @@
+// Package jsonc reads and edits JSON with comments and trailing commas (JSONC), the
+// format VS Code and the devcontainer templates use for devcontainer.json.
+package jsonc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tailscale/hujson"
+)
+
+// utf8BOM is written by some Windows editors at the start of the file
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Standardize converts JSONC to standard JSON. Comments and trailing commas are
+// replaced with whitespace, so offsets into the result match offsets into data.
+func Standardize(data []byte) ([]byte, error) {
+	return hujson.Standardize(stripBOM(data))
+}
+
+// Unmarshal parses JSONC into v. Syntax and type errors report the line and column
+// in data where they occurred.
+func Unmarshal(data []byte, v any) error {
+	data = stripBOM(data)
+	standard, err := hujson.Standardize(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(standard, v); err != nil {
+		return withPosition(data, err)
+	}
+	return nil
+}
+
+// Set sets the value at path in a JSONC document, creating missing parent objects,
+// and returns the updated document. Comments, trailing commas and the formatting of
+// the rest of the document are preserved.
+func Set(data []byte, path []string, value any) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("path must not be empty")
+	}
+	hasBOM := bytes.HasPrefix(data, utf8BOM)
+	root, err := hujson.Parse(stripBOM(data))
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value for %s: %w", strings.Join(path, "."), err)
+	}
+
+	for i := range path {
+		pointer := jsonPointer(path[:i+1])
+		last := i == len(path)-1
+		existing := root.Find(pointer)
+
+		if !last {
+			if existing == nil {
+				if err := patch(&root, "add", pointer, []byte("{}")); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if _, ok := existing.Value.(*hujson.Object); !ok {
+				return nil, fmt.Errorf("cannot set %s: %s is not an object", strings.Join(path, "."), strings.Join(path[:i+1], "."))
+			}
+			continue
+		}
+
+		op := "add"
+		if existing != nil {
+			op = "replace"
+		}
+		if err := patch(&root, op, pointer, encoded); err != nil {
+			return nil, err
+		}
+	}
+
+	out := root.Pack()
+	if hasBOM {
+		out = append(append([]byte{}, utf8BOM...), out...)
+	}
+	return out, nil
+}
+
+func patch(root *hujson.Value, op, pointer string, value []byte) error {
+	p, err := json.Marshal([]map[string]any{{"op": op, "path": pointer, "value": json.RawMessage(value)}})
+	if err != nil {
+		return err
+	}
+	if err := root.Patch(p); err != nil {
+		return fmt.Errorf("failed to update %s: %w", pointer, err)
+	}
+	return nil
+}
+
+// jsonPointer builds an RFC 6901 pointer from object keys
+func jsonPointer(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		b.WriteByte('/')
+		key = strings.ReplaceAll(key, "~", "~0")
+		b.WriteString(strings.ReplaceAll(key, "/", "~1"))
+	}
+	return b.String()
+}
+
+func stripBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
+
+// withPosition adds the line and column to errors that only carry a byte offset
+func withPosition(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := lineColumn(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		line, column := lineColumn(data, typeErr.Offset)
+		return fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+	return err
+}
+
+// lineColumn converts a byte offset into a 1-based line and column. For type errors
+// encoding/json reports the offset just after the offending value.
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	column := len(prefix) - bytes.LastIndexByte(prefix, '\n')
+	return line, column
+}
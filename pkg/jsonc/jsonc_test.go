@@ -0,0 +1,90 @@
+package jsonc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type config struct {
+		Name  string `json:"name"`
+		Ports []int  `json:"ports"`
+	}
+
+	t.Run("CommentsTrailingCommasAndBOM", func(t *testing.T) {
+		data := []byte("\ufeff// leading comment\n{\n\t\"name\": \"dev\", /* inline */\n\t\"ports\": [3000, 8080,],\n}\n")
+		var c config
+		require.NoError(t, Unmarshal(data, &c))
+		assert.Equal(t, config{Name: "dev", Ports: []int{3000, 8080}}, c)
+	})
+
+	t.Run("SyntaxErrorHasPosition", func(t *testing.T) {
+		var c config
+		err := Unmarshal([]byte("{\n\t\"name\": \"dev\"\n\t\"ports\": []\n}"), &c)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 3")
+	})
+
+	t.Run("TypeErrorHasPosition", func(t *testing.T) {
+		var c config
+		err := Unmarshal([]byte("{\n\t// the name\n\t\"name\": 1\n}"), &c)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "line 3, column")
+		assert.Contains(t, err.Error(), "cannot unmarshal number")
+	})
+}
+
+func TestStandardize_PreservesOffsets(t *testing.T) {
+	data := []byte("{\"a\": 1, // comment\n}")
+	standard, err := Standardize(data)
+	require.NoError(t, err)
+	assert.Len(t, standard, len(data))
+	assert.JSONEq(t, `{"a": 1}`, string(standard))
+}
+
+func TestSet(t *testing.T) {
+	original := "{\n\t// base image\n\t\"image\": \"ubuntu\", // pinned later\n\t\"features\": {},\n}\n"
+
+	t.Run("ReplacesValueAndKeepsComments", func(t *testing.T) {
+		out, err := Set([]byte(original), []string{"image"}, "debian")
+		require.NoError(t, err)
+		assert.Equal(t, "{\n\t// base image\n\t\"image\": \"debian\", // pinned later\n\t\"features\": {},\n}\n", string(out))
+	})
+
+	t.Run("CreatesMissingParents", func(t *testing.T) {
+		out, err := Set([]byte(original), []string{"customizations", "reactor", "account"}, "work")
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "// base image")
+		assert.Contains(t, string(out), "// pinned later")
+
+		var c struct {
+			Customizations struct {
+				Reactor struct {
+					Account string `json:"account"`
+				} `json:"reactor"`
+			} `json:"customizations"`
+		}
+		require.NoError(t, Unmarshal(out, &c))
+		assert.Equal(t, "work", c.Customizations.Reactor.Account)
+	})
+
+	t.Run("KeepsBOM", func(t *testing.T) {
+		out, err := Set([]byte("\ufeff{}"), []string{"image"}, "alpine")
+		require.NoError(t, err)
+		assert.Equal(t, "\ufeff{\"image\":\"alpine\"}", string(out))
+	})
+
+	t.Run("ParentNotAnObject", func(t *testing.T) {
+		_, err := Set([]byte(original), []string{"image", "tag"}, "x")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "image is not an object")
+	})
+
+	t.Run("EscapesKeys", func(t *testing.T) {
+		out, err := Set([]byte("{}"), []string{"a/b~c"}, 1)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a/b~c": 1}`, string(out))
+	})
+}
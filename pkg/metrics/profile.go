@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Startup phases recorded for 'reactor up'
+const (
+	PhaseConfigResolve = "config-resolve"
+	PhaseImageCheck    = "image-check"
+	PhasePull          = "pull"
+	PhaseBuild         = "build"
+	PhaseCreate        = "create"
+	PhaseStart         = "start"
+	PhasePostCreate    = "post-create"
+	PhaseAttach        = "attach"
+)
+
+// profileHistoryFile stores recent startup profiles in the project config dir
+const profileHistoryFile = "startup-profiles.json"
+
+// maxProfileHistory bounds how many startup profiles are kept per project
+const maxProfileHistory = 20
+
+// Phase is the measured duration of a single startup phase
+type Phase struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Profile collects phase timings for one startup. A nil *Profile is valid and
+// records nothing, so callers can thread it through without checks.
+type Profile struct {
+	mu        sync.Mutex
+	StartedAt time.Time     `json:"startedAt"`
+	Total     time.Duration `json:"total"`
+	Phases    []Phase       `json:"phases"`
+}
+
+// NewProfile starts a new startup profile
+func NewProfile() *Profile {
+	return &Profile{StartedAt: time.Now()}
+}
+
+// Track starts timing a phase and returns a function that records it when called
+func (p *Profile) Track(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.Phases = append(p.Phases, Phase{Name: name, Duration: time.Since(start)})
+		// Total runs until the end of the last phase, so an interactive session
+		// that follows the attach phase does not count towards startup time
+		p.Total = time.Since(p.StartedAt)
+	}
+}
+
+// Print writes a human-readable timing table
+func (p *Profile) Print(w io.Writer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, _ = fmt.Fprintf(w, "Startup profile:\n")
+	for _, phase := range p.Phases {
+		_, _ = fmt.Fprintf(w, "  %-16s %10s\n", phase.Name, phase.Duration.Round(time.Millisecond))
+	}
+	_, _ = fmt.Fprintf(w, "  %-16s %10s\n", "total", p.Total.Round(time.Millisecond))
+}
+
+// Save appends the profile to the project's startup history, keeping the most recent entries
+func Save(projectConfigDir string, p *Profile) error {
+	if p == nil {
+		return nil
+	}
+
+	history, err := LoadHistory(projectConfigDir)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	history = append(history, Profile{StartedAt: p.StartedAt, Total: p.Total, Phases: append([]Phase(nil), p.Phases...)})
+	p.mu.Unlock()
+	if len(history) > maxProfileHistory {
+		history = history[len(history)-maxProfileHistory:]
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode startup profiles: %w", err)
+	}
+
+	if err := os.MkdirAll(projectConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create project config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectConfigDir, profileHistoryFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to save startup profile: %w", err)
+	}
+	return nil
+}
+
+// LoadHistory returns the saved startup profiles for a project, oldest first
+func LoadHistory(projectConfigDir string) ([]Profile, error) {
+	data, err := os.ReadFile(filepath.Join(projectConfigDir, profileHistoryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read startup profiles: %w", err)
+	}
+
+	var history []Profile
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse startup profiles: %w", err)
+	}
+	return history, nil
+}
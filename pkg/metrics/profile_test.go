@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileTrack(t *testing.T) {
+	p := NewProfile()
+	done := p.Track(PhaseConfigResolve)
+	time.Sleep(time.Millisecond)
+	done()
+	p.Track(PhaseCreate)()
+
+	require.Len(t, p.Phases, 2)
+	assert.Equal(t, PhaseConfigResolve, p.Phases[0].Name)
+	assert.GreaterOrEqual(t, p.Phases[0].Duration, time.Millisecond)
+	assert.Equal(t, PhaseCreate, p.Phases[1].Name)
+	assert.GreaterOrEqual(t, p.Total, p.Phases[0].Duration)
+
+	var buf bytes.Buffer
+	p.Print(&buf)
+	assert.Contains(t, buf.String(), "Startup profile:")
+	assert.Contains(t, buf.String(), PhaseConfigResolve)
+	assert.Contains(t, buf.String(), "total")
+}
+
+func TestNilProfileIsNoop(t *testing.T) {
+	var p *Profile
+	p.Track(PhaseBuild)()
+	p.Print(&bytes.Buffer{})
+	assert.NoError(t, Save(t.TempDir(), p))
+}
+
+func TestSaveKeepsRecentHistory(t *testing.T) {
+	configDir := t.TempDir()
+
+	history, err := LoadHistory(configDir)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	for i := 0; i < maxProfileHistory+5; i++ {
+		p := NewProfile()
+		p.Phases = []Phase{{Name: PhaseStart, Duration: time.Duration(i) * time.Millisecond}}
+		require.NoError(t, Save(configDir, p))
+	}
+
+	history, err = LoadHistory(configDir)
+	require.NoError(t, err)
+	require.Len(t, history, maxProfileHistory)
+	assert.Equal(t, time.Duration(maxProfileHistory+4)*time.Millisecond, history[len(history)-1].Phases[0].Duration)
+}
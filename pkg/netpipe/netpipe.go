@@ -0,0 +1,25 @@
+// Package netpipe joins two network connections, for reactor's TCP proxies: the
+// on-demand proxy of workspace services and the port forwards of 'reactor ports'.
+package netpipe
+
+import (
+	"io"
+	"net"
+)
+
+// Pipe copies data both ways until either side closes
+func Pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	copyHalf := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		// Let the other side see EOF while still reading its reply
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			_ = tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go copyHalf(a, b)
+	go copyHalf(b, a)
+	<-done
+	<-done
+}
@@ -0,0 +1,54 @@
+package netpipe
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listen accepts one connection on a local port and hands it to serve
+func listen(t *testing.T, serve func(net.Conn)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		serve(conn)
+	}()
+	return listener.Addr().String()
+}
+
+func TestPipe(t *testing.T) {
+	// The upstream only answers once the client has finished sending
+	upstreamAddress := listen(t, func(conn net.Conn) {
+		data, _ := io.ReadAll(conn)
+		_, _ = conn.Write(append([]byte("echo: "), data...))
+	})
+	proxyAddress := listen(t, func(conn net.Conn) {
+		upstream, err := net.Dial("tcp", upstreamAddress)
+		if err != nil {
+			return
+		}
+		defer func() { _ = upstream.Close() }()
+		Pipe(conn, upstream)
+	})
+
+	client, err := net.Dial("tcp", proxyAddress)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+	_, err = client.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, client.(*net.TCPConn).CloseWrite())
+
+	reply, err := io.ReadAll(client)
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hello", string(reply))
+}
@@ -0,0 +1,93 @@
+// Package notify shows desktop notifications when long-running operations such as
+// image builds finish, so developers can switch to other work while they run.
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+)
+
+// maxErrorLength keeps failure notifications to a line or two
+const maxErrorLength = 120
+
+// Options controls when notifications are shown
+type Options struct {
+	Enabled bool
+	After   time.Duration // only operations that take at least this long notify
+}
+
+// runCommand runs the notification program; tests replace it
+var runCommand = func(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
+
+// FromSettings reads the notify and notifyAfter settings
+func FromSettings(settings *config.Settings) Options {
+	if settings == nil {
+		return Options{}
+	}
+	return Options{
+		Enabled: settings.Bool(config.SettingNotify),
+		After:   settings.Seconds(config.SettingNotifyAfter),
+	}
+}
+
+// Finished notifies that an operation started at start has finished, or failed with
+// err, if notifications are enabled and it took at least opts.After. A notification
+// that cannot be shown only produces a warning.
+func Finished(opts Options, operation string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	if !opts.Enabled || elapsed < opts.After {
+		return
+	}
+
+	elapsed = elapsed.Round(time.Second)
+	message := fmt.Sprintf("%s finished in %s", operation, elapsed)
+	if err != nil {
+		message = fmt.Sprintf("%s failed after %s: %s", operation, elapsed, summarize(err))
+	}
+
+	name, args, cmdErr := command(runtime.GOOS, "reactor", message)
+	if cmdErr == nil {
+		cmdErr = runCommand(name, args...)
+	}
+	if cmdErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to show desktop notification: %v\n", cmdErr)
+	}
+}
+
+// command returns the program and arguments that show a notification on goos
+func command(goos, title, message string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return "osascript", []string{"-e", script}, nil
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return "", nil, fmt.Errorf("notify-send not found; install libnotify to get notifications")
+		}
+		return "notify-send", []string{"--app-name=reactor", title, message}, nil
+	}
+	return "", nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// summarize returns the first line of an error, shortened for a notification
+func summarize(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	if len(line) > maxErrorLength {
+		line = line[:maxErrorLength-3] + "..."
+	}
+	return line
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureNotifications(t *testing.T) *[]string {
+	t.Helper()
+	var calls []string
+	original := runCommand
+	runCommand = func(name string, args ...string) error {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { runCommand = original })
+	return &calls
+}
+
+func TestFinished_OnlyNotifiesSlowOperationsWhenEnabled(t *testing.T) {
+	calls := captureNotifications(t)
+
+	Finished(Options{Enabled: false}, "Image build", time.Now().Add(-time.Hour), nil)
+	Finished(Options{Enabled: true, After: time.Minute}, "Image build", time.Now(), nil)
+	assert.Empty(t, *calls)
+}
+
+func TestFinished_ReportsFailure(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("uses notify-send")
+	}
+	calls := captureNotifications(t)
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "notify-send"), []byte("#!/bin/sh\n"), 0755))
+	t.Setenv("PATH", bin)
+
+	Finished(Options{Enabled: true}, "Image build", time.Now(), errors.New("build failed: exit code 1"))
+	assert.Equal(t, []string{"notify-send --app-name=reactor reactor Image build failed after 0s: build failed: exit code 1"}, *calls)
+}
+
+func TestCommand(t *testing.T) {
+	name, args, err := command("darwin", "reactor", `Image build failed after 2m0s: "Dockerfile" not found`)
+	require.NoError(t, err)
+	assert.Equal(t, "osascript", name)
+	assert.Equal(t, []string{"-e", `display notification "Image build failed after 2m0s: \"Dockerfile\" not found" with title "reactor"`}, args)
+
+	_, _, err = command("windows", "reactor", "done")
+	assert.Error(t, err)
+}
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, "build failed: step 3", summarize(errors.New("build failed: step 3\nfull log follows")))
+	long := summarize(errors.New(strings.Repeat("x", 200)))
+	assert.Len(t, long, maxErrorLength)
+	assert.True(t, strings.HasSuffix(long, "..."))
+}
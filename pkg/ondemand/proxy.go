@@ -0,0 +1,254 @@
+// Package ondemand listens on a container's forwarded ports itself and starts the
+// container on the first connection, then proxies traffic to the ports it publishes.
+// An idle container can be stopped again, so rarely used services only consume
+// resources while they are in use.
+package ondemand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/netpipe"
+)
+
+// Route forwards connections on a host port to a port of the container
+type Route struct {
+	ListenPort    int
+	ContainerPort int
+}
+
+// Backend is a started container: the host port each container port is published on
+type Backend map[int]int
+
+// dialTimeout bounds how long a connection waits for the container to accept it; a
+// service usually needs a moment after the container starts before it listens
+const dialTimeout = 30 * time.Second
+
+// dialRetryInterval is the wait between attempts to reach the container
+const dialRetryInterval = 250 * time.Millisecond
+
+// Proxy starts a container on demand and forwards connections to it
+type Proxy struct {
+	Routes []Route
+	// Host is the address the proxy listens on, 127.0.0.1 when empty
+	Host string
+	// Start starts the container and reports where its ports are published
+	Start func(ctx context.Context) (Backend, error)
+	// Stop stops the container once it has been idle for IdleTimeout; zero keeps it running
+	Stop        func(ctx context.Context) error
+	IdleTimeout time.Duration
+	// Logf reports starts, stops and failed connections
+	Logf func(format string, args ...any)
+
+	mu       sync.Mutex
+	backend  Backend
+	active   int
+	lastUsed time.Time
+}
+
+// Serve listens on every route and handles connections until ctx is cancelled. It fails
+// without serving anything if any port cannot be bound.
+func (p *Proxy) Serve(ctx context.Context) error {
+	host := p.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+
+	listeners := make([]net.Listener, 0, len(p.Routes))
+	defer func() {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+	}()
+	for _, route := range p.Routes {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(route.ListenPort)))
+		if err != nil {
+			return fmt.Errorf("failed to listen on port %d: %w", route.ListenPort, err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, listener := range listeners {
+		wg.Add(1)
+		go func(listener net.Listener, route Route) {
+			defer wg.Done()
+			p.accept(ctx, listener, route)
+		}(listener, p.Routes[i])
+	}
+	if p.IdleTimeout > 0 && p.Stop != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.stopWhenIdle(ctx)
+		}()
+	}
+
+	<-ctx.Done()
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (p *Proxy) accept(ctx context.Context, listener net.Listener, route Route) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			p.logf("Failed to accept a connection on port %d: %v", route.ListenPort, err)
+			continue
+		}
+		go p.handle(ctx, conn, route)
+	}
+}
+
+// handle starts the container if needed and pipes the connection to it
+func (p *Proxy) handle(ctx context.Context, conn net.Conn, route Route) {
+	defer func() { _ = conn.Close() }()
+
+	backend, err := p.acquire(ctx)
+	if err != nil {
+		p.logf("Failed to start the container for a connection on port %d: %v", route.ListenPort, err)
+		return
+	}
+	defer p.release()
+
+	hostPort, ok := backend[route.ContainerPort]
+	if !ok {
+		p.logf("The container does not publish port %d; run 'reactor down' to recreate it", route.ContainerPort)
+		return
+	}
+	upstream, err := dialWithRetry(ctx, net.JoinHostPort("127.0.0.1", strconv.Itoa(hostPort)))
+	if err != nil {
+		// The container may have been stopped behind the proxy's back; start it next time
+		p.forget()
+		p.logf("Failed to reach container port %d: %v", route.ContainerPort, err)
+		return
+	}
+	defer func() { _ = upstream.Close() }()
+
+	netpipe.Pipe(conn, upstream)
+}
+
+// acquire returns the running container, starting it on first use. Connections that
+// arrive while it starts wait for the same start; a failed start is retried by the
+// next connection.
+func (p *Proxy) acquire(ctx context.Context) (Backend, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.backend == nil {
+		p.logf("Connection received, starting the container...")
+		backend, err := p.Start(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.backend = backend
+		p.logf("Container started")
+	}
+	p.active++
+	p.lastUsed = time.Now()
+	return p.backend, nil
+}
+
+func (p *Proxy) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	p.lastUsed = time.Now()
+}
+
+// forget drops the started container so the next connection runs Start again
+func (p *Proxy) forget() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backend = nil
+}
+
+// stopWhenIdle stops the container once no connection has been open for IdleTimeout
+func (p *Proxy) stopWhenIdle(ctx context.Context) {
+	ticker := time.NewTicker(min(p.IdleTimeout/4, 15*time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.stopIfIdle(ctx, time.Now())
+	}
+}
+
+// stopIfIdle stops the container if it is running, unused and was last used before now-IdleTimeout
+func (p *Proxy) stopIfIdle(ctx context.Context, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.backend == nil || p.active > 0 || now.Sub(p.lastUsed) < p.IdleTimeout {
+		return
+	}
+	p.logf("No connections for %s, stopping the container", p.IdleTimeout)
+	if err := p.Stop(ctx); err != nil {
+		p.logf("Failed to stop the container: %v", err)
+		return
+	}
+	p.backend = nil
+}
+
+func (p *Proxy) logf(format string, args ...any) {
+	if p.Logf != nil {
+		p.Logf(format, args...)
+	}
+}
+
+// dialWithRetry connects to address, retrying until the service accepts or dialTimeout passes
+func dialWithRetry(ctx context.Context, address string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			return conn, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(dialRetryInterval):
+		}
+	}
+}
+
+// FreePorts returns n host ports that are free at the time of the call, for the
+// container to publish its ports on while the proxy holds the forwarded ones
+func FreePorts(n int) ([]int, error) {
+	ports := make([]int, 0, n)
+	listeners := make([]net.Listener, 0, n)
+	defer func() {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		// Keep each listener open until all are picked, so no port is returned twice
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a free port: %w", err)
+		}
+		listeners = append(listeners, listener)
+		ports = append(ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+	return ports, nil
+}
@@ -0,0 +1,163 @@
+package ondemand
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startEchoServer stands in for the container, echoing one line per connection
+func startEchoServer(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				_, _ = fmt.Fprintf(conn, "echo: %s", line)
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// serve runs the proxy until the test ends and returns its listen port
+func serve(t *testing.T, p *Proxy) int {
+	t.Helper()
+	ports, err := FreePorts(1)
+	require.NoError(t, err)
+	p.Routes = []Route{{ListenPort: ports[0], ContainerPort: 80}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	// Wait for the listener without connecting, which would start the container
+	require.Eventually(t, func() bool {
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", ports[0]))
+		if err != nil {
+			return true
+		}
+		_ = listener.Close()
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	return ports[0]
+}
+
+func roundTrip(t *testing.T, port int, message string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = fmt.Fprintf(conn, "%s\n", message)
+	require.NoError(t, err)
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	return reply
+}
+
+func TestProxy_StartsOnFirstConnection(t *testing.T) {
+	backendPort := startEchoServer(t)
+	var starts atomic.Int32
+	p := &Proxy{
+		Start: func(ctx context.Context) (Backend, error) {
+			starts.Add(1)
+			return Backend{80: backendPort}, nil
+		},
+	}
+	port := serve(t, p)
+
+	assert.Equal(t, "echo: hello\n", roundTrip(t, port, "hello"))
+	assert.Equal(t, "echo: again\n", roundTrip(t, port, "again"))
+	assert.Equal(t, int32(1), starts.Load())
+}
+
+func TestProxy_RetriesFailedStart(t *testing.T) {
+	backendPort := startEchoServer(t)
+	var starts atomic.Int32
+	p := &Proxy{
+		Start: func(ctx context.Context) (Backend, error) {
+			if starts.Add(1) == 1 {
+				return nil, errors.New("image pull failed")
+			}
+			return Backend{80: backendPort}, nil
+		},
+	}
+	port := serve(t, p)
+
+	// The first connection is dropped when the start fails; the next one starts it
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	assert.Error(t, err)
+	_ = conn.Close()
+
+	assert.Equal(t, "echo: hello\n", roundTrip(t, port, "hello"))
+	assert.Equal(t, int32(2), starts.Load())
+}
+
+func TestProxy_StopIfIdle(t *testing.T) {
+	var stops int
+	p := &Proxy{
+		IdleTimeout: time.Minute,
+		Start:       func(ctx context.Context) (Backend, error) { return Backend{80: 8080}, nil },
+		Stop:        func(ctx context.Context) error { stops++; return nil },
+	}
+	ctx := context.Background()
+
+	// Not started yet
+	p.stopIfIdle(ctx, time.Now().Add(time.Hour))
+	assert.Equal(t, 0, stops)
+
+	_, err := p.acquire(ctx)
+	require.NoError(t, err)
+	p.stopIfIdle(ctx, time.Now().Add(time.Hour))
+	assert.Equal(t, 0, stops, "an open connection keeps the container running")
+
+	p.release()
+	p.stopIfIdle(ctx, time.Now().Add(30*time.Second))
+	assert.Equal(t, 0, stops)
+	p.stopIfIdle(ctx, time.Now().Add(2*time.Minute))
+	assert.Equal(t, 1, stops)
+	assert.Nil(t, p.backend, "the next connection starts the container again")
+}
+
+func TestProxy_ServeFailsOnBusyPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	busy := listener.Addr().(*net.TCPAddr).Port
+
+	p := &Proxy{Routes: []Route{{ListenPort: busy, ContainerPort: 80}}}
+	err = p.Serve(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("failed to listen on port %d", busy))
+}
+
+func TestFreePorts(t *testing.T) {
+	ports, err := FreePorts(3)
+	require.NoError(t, err)
+	require.Len(t, ports, 3)
+	assert.NotEqual(t, ports[0], ports[1])
+	assert.NotEqual(t, ports[1], ports[2])
+	assert.NotEqual(t, ports[0], ports[2])
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// CloneRepoLabel records the repository cloned into the workspace volume of a container
+// started with CloneRepo
+const CloneRepoLabel = "com.reactor.clone-repo"
+
+// ProvisioningClone is the provisioning step cloning the repository into the workspace
+const ProvisioningClone = "clone"
+
+// cloneExistsOutput is printed by cloneScript when the workspace already holds a clone
+const cloneExistsOutput = "reactor-clone-exists"
+
+// cloneURLPattern matches the URLs git clones: scheme URLs and scp-like
+// user@host:path addresses
+var cloneURLPattern = regexp.MustCompile(`^([a-z][a-z0-9+.-]*://[^\s]+|[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^\s]+)$`)
+
+// cloneScript clones the repository given as $1 into the empty workspace volume named
+// $2. A volume that already holds a clone of it, as when the container is reused or
+// recreated after 'reactor down', is left as is and cloneExistsOutput printed; a clone
+// of another repository is refused rather than silently used.
+const cloneScript = `if [ -e /workspace/.git ]; then
+  origin=$(git -C /workspace remote get-url origin 2>/dev/null)
+  if [ "$origin" != "$1" ]; then
+    echo "volume $2 already holds a clone of ${origin:-another repository}; remove it with 'docker volume rm $2' to clone $1" >&2
+    exit 1
+  fi
+  echo ` + cloneExistsOutput + `
+  exit 0
+fi
+if ! command -v git >/dev/null 2>&1; then
+  echo 'git is not installed in the image; add "git" to customizations.reactor.tools' >&2
+  exit 1
+fi
+git clone -- "$1" /workspace`
+
+// CloneVolumeName returns the volume holding the clone of a container's workspace
+func CloneVolumeName(containerName string) string {
+	return containerName + "-clone"
+}
+
+// validateCloneRepo checks that a --clone-repo value is a URL git can clone
+func validateCloneRepo(url string) error {
+	if !cloneURLPattern.MatchString(url) {
+		return fmt.Errorf("invalid --clone-repo '%s': use a URL such as https://github.com/org/repo.git or git@github.com:org/repo.git", url)
+	}
+	return nil
+}
+
+// cloneRepository clones the repository into the container's workspace volume as the
+// container user. The new volume is owned by root, so it is given to that user first.
+func cloneRepository(ctx context.Context, dockerService *docker.Service, containerID, url, volume string, p progress) error {
+	access, err := dockerService.CheckPathAccess(ctx, containerID, []string{workspaceMountTarget})
+	if err != nil {
+		return err
+	}
+	if len(access.Inaccessible) > 0 {
+		if err := dockerService.ChownPaths(ctx, containerID, access.UID, access.GID, access.Inaccessible); err != nil {
+			return err
+		}
+	}
+
+	var output bytes.Buffer
+	err = dockerService.ExecCommand(ctx, containerID, docker.ExecOptions{
+		Command: []string{"sh", "-c", cloneScript, "sh", url, volume},
+		Stdout:  &output,
+		Stderr:  &output,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone %s into the workspace: %w: %s", url, err, strings.TrimSpace(output.String()))
+	}
+	if strings.TrimSpace(output.String()) != cloneExistsOutput {
+		p.info(PhaseSetup, "Cloned %s into the workspace", url)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCloneRepo(t *testing.T) {
+	for _, url := range []string{
+		"https://github.com/org/repo.git",
+		"ssh://git@example.com:2222/org/repo",
+		"git@github.com:org/repo.git",
+		"file:///srv/git/repo.git",
+	} {
+		assert.NoError(t, validateCloneRepo(url), url)
+	}
+	for _, url := range []string{"", "repo", "--upload-pack=evil", "https://example.com/a repo", "/srv/git/repo"} {
+		assert.Error(t, validateCloneRepo(url), url)
+	}
+}
+
+func TestNewContainerSpec_CloneRepo(t *testing.T) {
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		MaskPaths:        []string{"node_modules"},
+	}
+
+	spec, _, err := newContainerSpec(UpConfig{CloneRepo: "https://github.com/org/repo.git"}, resolved, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, spec.Mounts, "reactor-work-app-abc123-clone:/workspace")
+	assert.NotContains(t, spec.Mounts, "/src/app:/workspace")
+	assert.Equal(t, "https://github.com/org/repo.git", spec.Labels[CloneRepoLabel])
+	// git clones into an empty /workspace only
+	assert.Empty(t, spec.ExtraMounts)
+}
+
+func TestUp_CloneRepoFlagCombinations(t *testing.T) {
+	_, _, err := Up(context.Background(), UpConfig{CloneRepo: "https://github.com/org/repo.git", ReadOnlyWorkspace: true, DryRun: true})
+	assert.EqualError(t, err, "--clone-repo cannot be used with discovery mode, --read-only-workspace or --ephemeral")
+
+	_, _, err = Up(context.Background(), UpConfig{CloneRepo: "not a url", DryRun: true})
+	assert.ErrorContains(t, err, "invalid --clone-repo 'not a url'")
+}
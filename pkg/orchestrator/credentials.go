@@ -0,0 +1,397 @@
+package orchestrator
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/vault"
+)
+
+// Labels on containers whose provider directories are decrypted into tmpfs mounts. They
+// record where and how to re-encrypt the directories when the container is taken down.
+const (
+	CredentialEncryptionLabel = "com.reactor.credentials.encryption"
+	CredentialStoreLabel      = "com.reactor.credentials.store"
+	CredentialAccountLabel    = "com.reactor.credentials.account"
+)
+
+// credentialEncryptionMode returns the resolved encryption mode, off when unset
+func credentialEncryptionMode(resolved *config.ResolvedConfig) string {
+	if resolved.CredentialEncryption == "" {
+		return config.CredentialEncryptionOff
+	}
+	return resolved.CredentialEncryption
+}
+
+// containerCredentialEncryption returns the encryption mode a container was created with
+func containerCredentialEncryption(labels map[string]string) string {
+	if mode := labels[CredentialEncryptionLabel]; mode != "" {
+		return mode
+	}
+	return config.CredentialEncryptionOff
+}
+
+// credentialsEncrypted reports whether the provider directories are encrypted at rest
+func credentialsEncrypted(resolved *config.ResolvedConfig) bool {
+	return credentialEncryptionMode(resolved) != config.CredentialEncryptionOff
+}
+
+// credentialStorePath returns the encrypted archive holding the provider directories
+func credentialStorePath(resolved *config.ResolvedConfig) string {
+	return filepath.Join(resolved.ProviderConfigDir(), config.EncryptedCredentialsFileName)
+}
+
+// setCredentialLabels records the encryption settings on a container spec
+func setCredentialLabels(spec *docker.ContainerSpec, resolved *config.ResolvedConfig) {
+	if spec.Labels == nil {
+		spec.Labels = make(map[string]string)
+	}
+	spec.Labels[CredentialEncryptionLabel] = resolved.CredentialEncryption
+	spec.Labels[CredentialStoreLabel] = credentialStorePath(resolved)
+	spec.Labels[CredentialAccountLabel] = resolved.Account
+}
+
+// newCredentialCipher returns the cipher for an encryption mode. The age identity is
+// kept in the account directory, next to the per-project directories it protects.
+func newCredentialCipher(mode, account string) (vault.Cipher, error) {
+	switch mode {
+	case config.CredentialEncryptionAge:
+		reactorHome, err := config.GetReactorHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		return vault.NewAge(filepath.Join(reactorHome, account, config.AgeIdentityFileName))
+	case config.CredentialEncryptionKeychain:
+		return vault.NewKeychain(account)
+	}
+	return nil, fmt.Errorf("unknown credential encryption mode '%s'", mode)
+}
+
+// restoreCredentials decrypts the provider directories into the container's tmpfs
+// mounts. Plaintext directories left from before encryption was turned on are
+// encrypted and removed first.
+func restoreCredentials(ctx context.Context, dockerService *docker.Service, containerID string, resolved *config.ResolvedConfig, p progress) error {
+	c, err := newCredentialCipher(resolved.CredentialEncryption, resolved.Account)
+	if err != nil {
+		return err
+	}
+	store := credentialStorePath(resolved)
+	if err := migratePlaintextCredentials(resolved.ProviderConfigDir(), store, c, p); err != nil {
+		return err
+	}
+
+	return copyCredentials(ctx, dockerService, containerID, store, c)
+}
+
+// RestoreContainerCredentials decrypts the provider directories into the tmpfs mounts of
+// a container created with credential encryption, as 'reactor up' does when it creates
+// one. It is for containers replaced outside of 'reactor up', such as by a checkpoint
+// restore; other containers are left alone.
+func RestoreContainerCredentials(ctx context.Context, dockerService *docker.Service, info docker.ContainerInfo) error {
+	mode := containerCredentialEncryption(info.Labels)
+	if mode == config.CredentialEncryptionOff {
+		return nil
+	}
+	store := info.Labels[CredentialStoreLabel]
+	if store == "" {
+		return fmt.Errorf("container %s has no %s label", info.Name, CredentialStoreLabel)
+	}
+	c, err := newCredentialCipher(mode, info.Labels[CredentialAccountLabel])
+	if err != nil {
+		return err
+	}
+	return copyCredentials(ctx, dockerService, info.ID, store, c)
+}
+
+// copyCredentials decrypts store into the container's provider mounts; a missing store
+// leaves them empty
+func copyCredentials(ctx context.Context, dockerService *docker.Service, containerID, store string, c vault.Cipher) error {
+	data, err := vault.ReadFile(store, c)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	archives, err := splitCredentialArchive(data)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			archive, ok := archives[mount.Source]
+			if !ok {
+				continue
+			}
+			if err := dockerService.CopyToContainer(ctx, containerID, mount.Target, bytes.NewReader(archive)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SaveCredentials re-encrypts the provider directories of a running container created
+// with credential encryption, so changes agents made (e.g. refreshed tokens) survive
+// the container. Other containers are left alone.
+func SaveCredentials(ctx context.Context, dockerService *docker.Service, info docker.ContainerInfo) error {
+	mode := containerCredentialEncryption(info.Labels)
+	if mode == config.CredentialEncryptionOff {
+		return nil
+	}
+	// tmpfs contents do not outlive the container's process, so there is nothing to save
+	if info.Status != docker.StatusRunning {
+		return nil
+	}
+	store := info.Labels[CredentialStoreLabel]
+	if store == "" {
+		return fmt.Errorf("container %s has no %s label", info.Name, CredentialStoreLabel)
+	}
+	c, err := newCredentialCipher(mode, info.Labels[CredentialAccountLabel])
+	if err != nil {
+		return err
+	}
+
+	archives := make(map[string][]byte)
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			reader, err := dockerService.CopyFromContainer(ctx, info.ID, mount.Target)
+			if err != nil {
+				return err
+			}
+			archive, err := io.ReadAll(reader)
+			_ = reader.Close()
+			if err != nil {
+				return fmt.Errorf("failed to read %s from container %s: %w", mount.Target, info.Name, err)
+			}
+			archives[mount.Source] = archive
+		}
+	}
+
+	data, err := mergeCredentialArchives(archives)
+	if err != nil {
+		return err
+	}
+	return vault.WriteFile(store, data, c)
+}
+
+// migratePlaintextCredentials encrypts provider directories written before encryption
+// was turned on into store and removes them. Nothing happens once store exists.
+func migratePlaintextCredentials(providerConfigDir, store string, c vault.Cipher, p progress) error {
+	if _, err := os.Stat(store); err == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	var migrated []string
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			dir := filepath.Join(providerConfigDir, mount.Source)
+			found, err := addDirToArchive(tw, dir, mount.Source)
+			if err != nil {
+				return err
+			}
+			if found {
+				migrated = append(migrated, dir)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if len(migrated) == 0 {
+		return nil
+	}
+
+	if err := vault.WriteFile(store, buf.Bytes(), c); err != nil {
+		return err
+	}
+	for _, dir := range migrated {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove plaintext credentials in %s: %w", dir, err)
+		}
+	}
+	p.info(PhaseSetup, "Encrypted existing credentials into %s and removed the plaintext copies", store)
+	return nil
+}
+
+// addDirToArchive adds the contents of dir under prefix, reporting whether dir held any files
+func addDirToArchive(tw *tar.Writer, dir, prefix string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		found = true
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read provider directory %s: %w", dir, err)
+	}
+	return found, nil
+}
+
+// mergeCredentialArchives combines the archives copied out of the container, keyed by
+// provider directory name, into one archive with each directory's entries under its name
+func mergeCredentialArchives(archives map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			archive, ok := archives[mount.Source]
+			if !ok {
+				continue
+			}
+			// The first component of every entry is the directory's own base name
+			err := rewriteArchive(archive, tw, func(name string) (string, bool) {
+				_, rel, _ := strings.Cut(name, "/")
+				if rel == "" {
+					return "", false
+				}
+				return mount.Source + "/" + rel, true
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// splitCredentialArchive is the reverse of mergeCredentialArchives: it returns an archive
+// for each provider directory, with entries relative to that directory
+func splitCredentialArchive(data []byte) (map[string][]byte, error) {
+	writers := make(map[string]*tar.Writer)
+	buffers := make(map[string]*bytes.Buffer)
+	entries := make(map[string]int)
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			buffers[mount.Source] = &bytes.Buffer{}
+			writers[mount.Source] = tar.NewWriter(buffers[mount.Source])
+		}
+	}
+
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials archive: %w", err)
+		}
+		source, rel, _ := strings.Cut(header.Name, "/")
+		tw, ok := writers[source]
+		if !ok || rel == "" {
+			continue
+		}
+		if err := copyArchiveEntry(tw, tr, header, rel); err != nil {
+			return nil, err
+		}
+		entries[source]++
+	}
+
+	archives := make(map[string][]byte)
+	for source, tw := range writers {
+		if err := tw.Close(); err != nil {
+			return nil, err
+		}
+		if entries[source] > 0 {
+			archives[source] = buffers[source].Bytes()
+		}
+	}
+	return archives, nil
+}
+
+// rewriteArchive copies the entries of archive to tw, renamed by rename; entries it
+// rejects are dropped
+func rewriteArchive(archive []byte, tw *tar.Writer, rename func(name string) (string, bool)) error {
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read credentials archive: %w", err)
+		}
+		name, ok := rename(header.Name)
+		if !ok {
+			continue
+		}
+		if err := copyArchiveEntry(tw, tr, header, name); err != nil {
+			return err
+		}
+	}
+}
+
+// copyArchiveEntry writes an entry under a new name, refusing names that escape the directory
+func copyArchiveEntry(tw *tar.Writer, tr *tar.Reader, header *tar.Header, name string) error {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("credentials archive entry %s is outside its directory", header.Name)
+	}
+	copied := *header
+	copied.Name = name
+	if err := tw.WriteHeader(&copied); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, tr)
+	return err
+}
@@ -0,0 +1,164 @@
+package orchestrator
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainCipher stores data unchanged so tests can read what was "encrypted"
+type plainCipher struct{}
+
+func (plainCipher) Encrypt(plaintext []byte) ([]byte, error)  { return plaintext, nil }
+func (plainCipher) Decrypt(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+
+type archiveEntry struct {
+	name, body string
+}
+
+func buildArchive(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0600, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.name[len(e.name)-1] == '/' {
+			header = &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	entries := make(map[string]string)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[header.Name] = string(body)
+	}
+}
+
+func TestCredentialArchives_RoundTrip(t *testing.T) {
+	// CopyFromContainer names entries after the directory's base name
+	merged, err := mergeCredentialArchives(map[string][]byte{
+		"claude": buildArchive(t,
+			archiveEntry{name: ".claude/"},
+			archiveEntry{name: ".claude/.credentials.json", body: "claude-token"},
+			archiveEntry{name: ".claude/projects/"},
+			archiveEntry{name: ".claude/projects/state.json", body: "{}"},
+		),
+		"gemini": buildArchive(t,
+			archiveEntry{name: ".gemini/"},
+			archiveEntry{name: ".gemini/oauth_creds.json", body: "gemini-token"},
+		),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"claude/.credentials.json":   "claude-token",
+		"claude/projects/":           "",
+		"claude/projects/state.json": "{}",
+		"gemini/oauth_creds.json":    "gemini-token",
+	}, readArchive(t, merged))
+
+	split, err := splitCredentialArchive(merged)
+	require.NoError(t, err)
+	require.Len(t, split, 2)
+	assert.Equal(t, map[string]string{
+		".credentials.json":   "claude-token",
+		"projects/":           "",
+		"projects/state.json": "{}",
+	}, readArchive(t, split["claude"]))
+	assert.Equal(t, map[string]string{"oauth_creds.json": "gemini-token"}, readArchive(t, split["gemini"]))
+}
+
+func TestSplitCredentialArchive_RejectsEscapingEntries(t *testing.T) {
+	_, err := splitCredentialArchive(buildArchive(t, archiveEntry{name: "claude/../../.bashrc", body: "evil"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside its directory")
+
+	// Unknown providers are ignored rather than extracted anywhere
+	split, err := splitCredentialArchive(buildArchive(t, archiveEntry{name: "other/file", body: "x"}))
+	require.NoError(t, err)
+	assert.Empty(t, split)
+}
+
+func TestMigratePlaintextCredentials(t *testing.T) {
+	providerDir := t.TempDir()
+	store := filepath.Join(providerDir, config.EncryptedCredentialsFileName)
+
+	// Empty provider directories are left alone and no store is written
+	require.NoError(t, os.MkdirAll(filepath.Join(providerDir, "claude"), 0755))
+	require.NoError(t, migratePlaintextCredentials(providerDir, store, plainCipher{}, progress{}))
+	assert.NoFileExists(t, store)
+	assert.DirExists(t, filepath.Join(providerDir, "claude"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(providerDir, "claude", "projects"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(providerDir, "claude", ".credentials.json"), []byte("claude-token"), 0600))
+	require.NoError(t, migratePlaintextCredentials(providerDir, store, plainCipher{}, progress{}))
+
+	data, err := os.ReadFile(store)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"claude/.credentials.json": "claude-token",
+		"claude/projects/":         "",
+	}, readArchive(t, data))
+	assert.NoDirExists(t, filepath.Join(providerDir, "claude"))
+
+	// Once the store exists, new plaintext is not folded in
+	require.NoError(t, os.MkdirAll(filepath.Join(providerDir, "gemini"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(providerDir, "gemini", "oauth_creds.json"), []byte("x"), 0600))
+	require.NoError(t, migratePlaintextCredentials(providerDir, store, plainCipher{}, progress{}))
+	assert.FileExists(t, filepath.Join(providerDir, "gemini", "oauth_creds.json"))
+}
+
+func TestCredentialLabels(t *testing.T) {
+	resolved := &config.ResolvedConfig{
+		Account:              "work",
+		ProjectConfigDir:     "/home/.reactor/work/hash",
+		CredentialEncryption: config.CredentialEncryptionAge,
+	}
+	assert.True(t, credentialsEncrypted(resolved))
+
+	spec := &docker.ContainerSpec{}
+	setCredentialLabels(spec, resolved)
+	assert.Equal(t, map[string]string{
+		CredentialEncryptionLabel: "age",
+		CredentialStoreLabel:      "/home/.reactor/work/hash/credentials.enc",
+		CredentialAccountLabel:    "work",
+	}, spec.Labels)
+	assert.Equal(t, "age", containerCredentialEncryption(spec.Labels))
+
+	assert.False(t, credentialsEncrypted(&config.ResolvedConfig{}))
+	assert.Equal(t, config.CredentialEncryptionOff, containerCredentialEncryption(nil))
+}
+
+func TestRestoreContainerCredentials_Labels(t *testing.T) {
+	// Containers created without encryption have nothing to restore
+	assert.NoError(t, RestoreContainerCredentials(context.Background(), nil, docker.ContainerInfo{Name: "reactor-plain"}))
+
+	err := RestoreContainerCredentials(context.Background(), nil, docker.ContainerInfo{
+		Name:   "reactor-sealed",
+		Labels: map[string]string{CredentialEncryptionLabel: config.CredentialEncryptionAge},
+	})
+	assert.EqualError(t, err, "container reactor-sealed has no "+CredentialStoreLabel+" label")
+}
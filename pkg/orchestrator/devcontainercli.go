@@ -0,0 +1,236 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// DevcontainerCLILabel marks containers provisioned by the devcontainer CLI; its value is
+// the reactor container name so the CLI finds the same container on the next up
+const DevcontainerCLILabel = "com.reactor.devcontainer-cli"
+
+// devcontainerCLIBinary is the executable of the official devcontainers/cli
+const devcontainerCLIBinary = "devcontainer"
+
+// Variables so tests can stub out the devcontainer CLI
+var (
+	lookPath           = exec.LookPath
+	runDevcontainerCLI = func(ctx context.Context, args ...string) ([]byte, error) {
+		debug.Logf(debug.Orchestrator, "running %s %s", devcontainerCLIBinary, strings.Join(redactRemoteEnv(args), " "))
+		var stdout bytes.Buffer
+		cmd := exec.CommandContext(ctx, devcontainerCLIBinary, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr // build and lifecycle output
+		if host := docker.ProbedHost(); host != "" {
+			cmd.Env = append(os.Environ(), "DOCKER_HOST="+host)
+		}
+		err := cmd.Run()
+		return stdout.Bytes(), err
+	}
+)
+
+// redactRemoteEnv hides the values of --remote-env arguments, which may hold secrets
+func redactRemoteEnv(args []string) []string {
+	redacted := append([]string(nil), args...)
+	for i := 1; i < len(redacted); i++ {
+		if redacted[i-1] == "--remote-env" {
+			name, _, _ := strings.Cut(redacted[i], "=")
+			redacted[i] = name + "=***"
+		}
+	}
+	return redacted
+}
+
+// devcontainerCLIResult is the JSON the devcontainer CLI prints when 'up' finishes
+type devcontainerCLIResult struct {
+	Outcome               string `json:"outcome"`
+	ContainerID           string `json:"containerId"`
+	RemoteUser            string `json:"remoteUser"`
+	RemoteWorkspaceFolder string `json:"remoteWorkspaceFolder"`
+	Message               string `json:"message"`
+	Description           string `json:"description"`
+}
+
+// devcontainerCLIOptions is what reactor still controls when the CLI provisions the container
+type devcontainerCLIOptions struct {
+	ProjectRoot  string
+	ConfigPath   string
+	Labels       map[string]string
+	Mounts       []string // --mount values, e.g. type=bind,source=/host,target=/container
+	RemoteEnv    []string // KEY=VALUE
+	ForceRebuild bool
+}
+
+// validateDevcontainerCLIMode rejects options that reactor implements itself and
+// cannot hand over to the devcontainer CLI
+func validateDevcontainerCLIMode(upConfig UpConfig) error {
+	unsupported := map[string]bool{
+		"--discovery-mode":      upConfig.DiscoveryMode,
+		"--ephemeral":           upConfig.Ephemeral,
+		"--read-only-workspace": upConfig.ReadOnlyWorkspace,
+		"--clone-repo":          upConfig.CloneRepo != "",
+		"--no-init":             upConfig.DisableInit,
+		"--port":                len(upConfig.CLIPortMappings) > 0,
+		"--port-offset":         upConfig.PortOffset != 0,
+	}
+	var flags []string
+	for flag, set := range unsupported {
+		if set {
+			flags = append(flags, flag)
+		}
+	}
+	if len(flags) > 0 {
+		sort.Strings(flags)
+		return fmt.Errorf("--use-devcontainer-cli cannot be used with %s", strings.Join(flags, ", "))
+	}
+	return nil
+}
+
+// upWithDevcontainerCLI provisions the container with the devcontainer CLI and renames
+// it to the reactor container name, so sessions, attach and down work as usual.
+// It returns the container ID and the folder the project is mounted at in it.
+func upWithDevcontainerCLI(ctx context.Context, dockerService *docker.Service, containerName string, opts devcontainerCLIOptions) (string, string, error) {
+	if _, err := lookPath(devcontainerCLIBinary); err != nil {
+		return "", "", fmt.Errorf("--use-devcontainer-cli requires the devcontainer CLI; install it with 'npm install -g @devcontainers/cli'")
+	}
+
+	// A container reactor created itself has different mounts and no CLI metadata
+	existing, err := dockerService.ContainerExists(ctx, containerName)
+	if err == nil && existing.Status != docker.StatusNotFound && existing.Labels[DevcontainerCLILabel] == "" {
+		return "", "", fmt.Errorf("existing container %s was not created by the devcontainer CLI; run 'reactor down' first to recreate it", containerName)
+	}
+
+	labels := make(map[string]string, len(opts.Labels)+1)
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	labels[DevcontainerCLILabel] = containerName
+
+	output, runErr := runDevcontainerCLI(ctx, devcontainerUpArgs(opts, labels)...)
+	result, parseErr := parseDevcontainerCLIResult(output)
+	if parseErr != nil {
+		if runErr != nil {
+			return "", "", fmt.Errorf("devcontainer up failed: %w", runErr)
+		}
+		return "", "", parseErr
+	}
+	if result.Outcome != "success" {
+		return "", "", fmt.Errorf("devcontainer up failed: %s", result.failure())
+	}
+
+	if err := dockerService.RenameContainer(ctx, result.ContainerID, containerName); err != nil {
+		return "", "", err
+	}
+	return result.ContainerID, result.RemoteWorkspaceFolder, nil
+}
+
+// devcontainerUpArgs builds the 'devcontainer up' command line. The labels identify the
+// container, so the CLI reuses it on the next run instead of creating another.
+func devcontainerUpArgs(opts devcontainerCLIOptions, labels map[string]string) []string {
+	args := []string{"up", "--workspace-folder", opts.ProjectRoot}
+	if opts.ConfigPath != "" {
+		args = append(args, "--config", opts.ConfigPath)
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--id-label", k+"="+labels[k])
+	}
+
+	for _, m := range opts.Mounts {
+		args = append(args, "--mount", m)
+	}
+	for _, env := range opts.RemoteEnv {
+		args = append(args, "--remote-env", env)
+	}
+	if opts.ForceRebuild {
+		args = append(args, "--remove-existing-container", "--build-no-cache")
+	}
+	return args
+}
+
+// parseDevcontainerCLIResult reads the result object, which is the last line the CLI prints on stdout
+func parseDevcontainerCLIResult(output []byte) (*devcontainerCLIResult, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return nil, fmt.Errorf("devcontainer up printed no result")
+	}
+
+	var result devcontainerCLIResult
+	if err := json.Unmarshal([]byte(last), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse devcontainer up result %q: %w", last, err)
+	}
+	if result.Outcome == "success" && result.ContainerID == "" {
+		return nil, fmt.Errorf("devcontainer up did not report a container ID")
+	}
+	return &result, nil
+}
+
+func (r *devcontainerCLIResult) failure() string {
+	switch {
+	case r.Message != "" && r.Description != "":
+		return r.Message + ": " + r.Description
+	case r.Message != "":
+		return r.Message
+	case r.Description != "":
+		return r.Description
+	}
+	return "outcome " + r.Outcome
+}
+
+// devcontainerCLIMounts returns the reactor-managed mounts to add on top of the CLI's own:
+// provider config directories, the history directory and, with host integration, the
+// Docker socket. The CLI
+// mounts the workspace and runs init and lifecycle commands itself.
+// Unlike -v, --mount does not create missing host directories, so they are created here.
+func devcontainerCLIMounts(resolved *config.ResolvedConfig, dockerHostIntegration bool) ([]string, error) {
+	if err := ensureProviderDirs(resolved); err != nil {
+		return nil, err
+	}
+	var mounts []string
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			mounts = append(mounts, bindMount(filepath.Join(resolved.ProviderConfigDir(), mount.Source), mount.Target))
+		}
+	}
+	if resolved.ShellHistory {
+		if err := ensureHistoryDir(resolved); err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, bindMount(filepath.Join(resolved.ProjectConfigDir, config.HistoryDirName), core.HistoryMountPath))
+	}
+	if dockerHostIntegration {
+		mounts = append(mounts, bindMount("/var/run/docker.sock", "/var/run/docker.sock"))
+	}
+	return mounts, nil
+}
+
+func bindMount(source, target string) string {
+	return fmt.Sprintf("type=bind,source=%s,target=%s", source, target)
+}
+
+func sortedProviderNames() []string {
+	names := make([]string, 0, len(config.BuiltinProviders))
+	for name := range config.BuiltinProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDevcontainerCLIMode(t *testing.T) {
+	assert.NoError(t, validateDevcontainerCLIMode(UpConfig{UseDevcontainerCLI: true, SessionName: "x", ForceRebuild: true}))
+
+	err := validateDevcontainerCLIMode(UpConfig{UseDevcontainerCLI: true, ReadOnlyWorkspace: true, CLIPortMappings: []string{"8080:80"}})
+	require.Error(t, err)
+	assert.Equal(t, "--use-devcontainer-cli cannot be used with --port, --read-only-workspace", err.Error())
+}
+
+func TestDevcontainerUpArgs(t *testing.T) {
+	args := devcontainerUpArgs(devcontainerCLIOptions{
+		ProjectRoot:  "/home/alice/api",
+		ConfigPath:   "/home/alice/api/.devcontainer/devcontainer.json",
+		Mounts:       []string{"type=bind,source=/home/alice/.reactor/alice/abc/claude,target=/home/claude/.claude"},
+		RemoteEnv:    []string{"API_TOKEN=secret"},
+		ForceRebuild: true,
+	}, map[string]string{
+		DevcontainerCLILabel:  "reactor-alice-api-abc",
+		"com.reactor.session": "feature",
+	})
+
+	assert.Equal(t, []string{
+		"up", "--workspace-folder", "/home/alice/api",
+		"--config", "/home/alice/api/.devcontainer/devcontainer.json",
+		"--id-label", "com.reactor.devcontainer-cli=reactor-alice-api-abc",
+		"--id-label", "com.reactor.session=feature",
+		"--mount", "type=bind,source=/home/alice/.reactor/alice/abc/claude,target=/home/claude/.claude",
+		"--remote-env", "API_TOKEN=secret",
+		"--remove-existing-container", "--build-no-cache",
+	}, args)
+}
+
+func TestParseDevcontainerCLIResult(t *testing.T) {
+	result, err := parseDevcontainerCLIResult([]byte("[2 ms] Start: Run\n{\"outcome\":\"success\",\"containerId\":\"abc123\",\"remoteUser\":\"vscode\",\"remoteWorkspaceFolder\":\"/workspaces/api\"}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", result.ContainerID)
+	assert.Equal(t, "vscode", result.RemoteUser)
+
+	result, err = parseDevcontainerCLIResult([]byte(`{"outcome":"error","message":"Command failed","description":"An error occurred setting up the container."}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Command failed: An error occurred setting up the container.", result.failure())
+
+	_, err = parseDevcontainerCLIResult(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "printed no result")
+
+	_, err = parseDevcontainerCLIResult([]byte(`{"outcome":"success"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not report a container ID")
+}
+
+func TestDevcontainerCLIMounts(t *testing.T) {
+	projectConfigDir := filepath.Join(t.TempDir(), "abc")
+	mounts, err := devcontainerCLIMounts(&config.ResolvedConfig{ProjectConfigDir: projectConfigDir, ShellHistory: true}, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"type=bind,source=" + filepath.Join(projectConfigDir, "claude") + ",target=/home/claude/.claude",
+		"type=bind,source=" + filepath.Join(projectConfigDir, "gemini") + ",target=/home/claude/.gemini",
+		"type=bind,source=" + filepath.Join(projectConfigDir, "history") + ",target=/reactor-history",
+		"type=bind,source=/var/run/docker.sock,target=/var/run/docker.sock",
+	}, mounts)
+	assert.DirExists(t, filepath.Join(projectConfigDir, "claude"), "bind mount sources must exist")
+	assert.DirExists(t, filepath.Join(projectConfigDir, "history"), "bind mount sources must exist")
+}
+
+func TestUpWithDevcontainerCLI_MissingBinary(t *testing.T) {
+	origLookPath := lookPath
+	t.Cleanup(func() { lookPath = origLookPath })
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	_, _, err := upWithDevcontainerCLI(context.Background(), nil, "reactor-alice-api-abc", devcontainerCLIOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "npm install -g @devcontainers/cli")
+}
@@ -0,0 +1,167 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// checkDrift compares an existing container with the one the current configuration
+// would create. A container that cannot be inspected is reported as having no drift.
+func checkDrift(ctx context.Context, dockerService *docker.Service, existing docker.ContainerInfo, spec *docker.ContainerSpec, p progress) []string {
+	actual, err := dockerService.InspectContainerConfig(ctx, existing.ID)
+	if err != nil {
+		p.warn(PhaseContainer, "cannot check container %s for configuration changes: %v", existing.Name, err)
+		return nil
+	}
+	currentImageID, err := dockerService.ImageID(ctx, spec.Image)
+	if err != nil {
+		debug.Logf(debug.Orchestrator, "cannot compare image IDs: %v", err)
+	}
+	return containerDrift(spec, actual, currentImageID)
+}
+
+// containerDrift lists how an existing container differs from spec: its image, mounts,
+// DNS settings, privileges and environment. Only the names of environment variables
+// are reported, as their values may be secrets. currentImageID is the ID spec's image
+// has now, empty when unknown.
+func containerDrift(spec *docker.ContainerSpec, actual docker.ContainerConfig, currentImageID string) []string {
+	var drift []string
+
+	if docker.NormalizeImageName(spec.Image) != docker.NormalizeImageName(actual.Image) {
+		drift = append(drift, fmt.Sprintf("image is %s, configured %s", actual.Image, spec.Image))
+	} else if currentImageID != "" && actual.ImageID != "" && currentImageID != actual.ImageID {
+		drift = append(drift, fmt.Sprintf("image %s has been rebuilt or pulled since the container was created", spec.Image))
+	}
+
+	wantMounts := append([]string(nil), spec.Mounts...)
+	for _, m := range spec.ExtraMounts {
+		wantMounts = append(wantMounts, m.String())
+	}
+	added, removed := diffStrings(wantMounts, actual.Mounts)
+	for _, mount := range added {
+		drift = append(drift, "mount added: "+mount)
+	}
+	for _, mount := range removed {
+		drift = append(drift, "mount removed: "+mount)
+	}
+
+	// Containers inspected without a working directory or command are not compared
+	if spec.WorkDir != "" && actual.WorkDir != "" && spec.WorkDir != actual.WorkDir {
+		drift = append(drift, fmt.Sprintf("working directory is %s, configured %s", actual.WorkDir, spec.WorkDir))
+	}
+	if len(spec.Command) > 0 && len(actual.Command) > 0 && !slices.Equal(spec.Command, actual.Command) {
+		drift = append(drift, fmt.Sprintf("command is %q, configured %q", strings.Join(actual.Command, " "), strings.Join(spec.Command, " ")))
+	}
+
+	if spec.Privileged != actual.Privileged {
+		drift = append(drift, fmt.Sprintf("privileged is %t, configured %t", actual.Privileged, spec.Privileged))
+	}
+
+	settings := []struct {
+		name       string
+		want, have []string
+	}{
+		{"DNS server", spec.DNS, actual.DNS},
+		{"DNS search domain", spec.DNSSearch, actual.DNSSearch},
+		{"extra host", spec.ExtraHosts, actual.ExtraHosts},
+		{"capability", spec.CapAdd, actual.CapAdd},
+		{"security option", spec.SecurityOpt, actual.SecurityOpt},
+	}
+	for _, n := range settings {
+		added, removed := diffStrings(n.want, n.have)
+		for _, value := range added {
+			drift = append(drift, n.name+" added: "+value)
+		}
+		for _, value := range removed {
+			drift = append(drift, n.name+" removed: "+value)
+		}
+	}
+
+	configured := envMap(spec.Environment)
+	current := envMap(actual.Env)
+	imageEnv := envMap(actual.ImageEnv)
+	var changed []string
+	for _, name := range sortedKeys(configured) {
+		value, ok := current[name]
+		switch {
+		case !ok:
+			changed = append(changed, "environment variable added: "+name)
+		case value != configured[name]:
+			changed = append(changed, "environment variable changed: "+name)
+		}
+	}
+	for _, name := range sortedKeys(current) {
+		if _, ok := configured[name]; ok {
+			continue
+		}
+		// Variables the image sets itself are expected; without the image they cannot be told apart
+		if actual.ImageEnv == nil {
+			continue
+		}
+		if value, ok := imageEnv[name]; ok && value == current[name] {
+			continue
+		}
+		changed = append(changed, "environment variable removed: "+name)
+	}
+	return append(drift, changed...)
+}
+
+// diffStrings returns the entries only in want and those only in have, sorted
+func diffStrings(want, have []string) (added, removed []string) {
+	haveSet := make(map[string]bool, len(have))
+	for _, s := range have {
+		haveSet[s] = true
+	}
+	wantSet := make(map[string]bool, len(want))
+	for _, s := range want {
+		wantSet[s] = true
+		if !haveSet[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range have {
+		if !wantSet[s] {
+			removed = append(removed, s)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// envMap parses KEY=value entries; later entries win as they do in Docker
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		m[name] = value
+	}
+	return m
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// removeDriftedContainer removes a container so it is created afresh, saving its
+// encrypted credentials first as 'reactor down' does
+func removeDriftedContainer(ctx context.Context, dockerService *docker.Service, info docker.ContainerInfo) error {
+	if err := SaveCredentials(ctx, dockerService, info); err != nil {
+		return fmt.Errorf("failed to save encrypted credentials, container %s was left running: %w", info.Name, err)
+	}
+	if err := dockerService.RemoveContainer(ctx, info.ID); err != nil {
+		return fmt.Errorf("failed to remove container %s: %w", info.Name, err)
+	}
+	return nil
+}
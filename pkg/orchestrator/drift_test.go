@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerDrift(t *testing.T) {
+	spec := &docker.ContainerSpec{
+		Image:       "ghcr.io/acme/dev:1",
+		Mounts:      []string{"/src:/workspace", "/home/u/.reactor/work/abc/claude:/home/claude/.claude"},
+		Environment: []string{"API_URL=http://api", "TOKEN=secret"},
+	}
+	matching := docker.ContainerConfig{
+		Image:    "ghcr.io/acme/dev:1",
+		ImageID:  "sha256:aaa",
+		Mounts:   []string{"/home/u/.reactor/work/abc/claude:/home/claude/.claude", "/src:/workspace"},
+		Env:      []string{"PATH=/usr/bin", "API_URL=http://api", "TOKEN=secret"},
+		ImageEnv: []string{"PATH=/usr/bin"},
+	}
+
+	t.Run("no drift", func(t *testing.T) {
+		assert.Empty(t, containerDrift(spec, matching, "sha256:aaa"))
+		assert.Empty(t, containerDrift(spec, matching, ""))
+	})
+
+	t.Run("image name", func(t *testing.T) {
+		actual := matching
+		actual.Image = "ghcr.io/acme/dev:0"
+		assert.Equal(t, []string{"image is ghcr.io/acme/dev:0, configured ghcr.io/acme/dev:1"}, containerDrift(spec, actual, ""))
+	})
+
+	t.Run("image rebuilt", func(t *testing.T) {
+		assert.Equal(t, []string{"image ghcr.io/acme/dev:1 has been rebuilt or pulled since the container was created"}, containerDrift(spec, matching, "sha256:bbb"))
+	})
+
+	t.Run("mounts", func(t *testing.T) {
+		actual := matching
+		actual.Mounts = []string{"/src:/workspace", "/var/run/docker.sock:/var/run/docker.sock"}
+		assert.Equal(t, []string{
+			"mount added: /home/u/.reactor/work/abc/claude:/home/claude/.claude",
+			"mount removed: /var/run/docker.sock:/var/run/docker.sock",
+		}, containerDrift(spec, actual, ""))
+	})
+
+	t.Run("mount options", func(t *testing.T) {
+		withScratch := *spec
+		withScratch.ExtraMounts = []docker.Mount{{Type: "tmpfs", Target: "/scratch", TmpfsSize: 1024}}
+		actual := matching
+		actual.Mounts = append(append([]string(nil), matching.Mounts...), "type=tmpfs,target=/scratch,size=1024")
+		assert.Empty(t, containerDrift(&withScratch, actual, ""))
+
+		withScratch.ExtraMounts[0].TmpfsSize = 2048
+		assert.Equal(t, []string{
+			"mount added: type=tmpfs,target=/scratch,size=2048",
+			"mount removed: type=tmpfs,target=/scratch,size=1024",
+		}, containerDrift(&withScratch, actual, ""))
+	})
+
+	t.Run("dns", func(t *testing.T) {
+		withDNS := *spec
+		withDNS.DNS = []string{"10.0.0.53"}
+		withDNS.ExtraHosts = []string{"git.corp:10.0.0.7"}
+		actual := matching
+		actual.ExtraHosts = []string{"git.corp:10.0.0.7"}
+		assert.Equal(t, []string{"DNS server added: 10.0.0.53"}, containerDrift(&withDNS, actual, ""))
+
+		actual.DNSSearch = []string{"corp.example.com"}
+		actual.DNS = []string{"10.0.0.53"}
+		assert.Equal(t, []string{"DNS search domain removed: corp.example.com"}, containerDrift(&withDNS, actual, ""))
+	})
+
+	t.Run("privileges", func(t *testing.T) {
+		withPrivileges := *spec
+		withPrivileges.Privileged = true
+		withPrivileges.CapAdd = []string{"SYS_PTRACE"}
+		actual := matching
+		actual.SecurityOpt = []string{"seccomp=unconfined"}
+		assert.Equal(t, []string{
+			"privileged is false, configured true",
+			"capability added: SYS_PTRACE",
+			"security option removed: seccomp=unconfined",
+		}, containerDrift(&withPrivileges, actual, ""))
+	})
+
+	t.Run("working directory and command", func(t *testing.T) {
+		withCommand := *spec
+		withCommand.WorkDir = "/workspace/api"
+		withCommand.Command = []string{"/bin/sh", "-c", "npm run dev"}
+		actual := matching
+		assert.Empty(t, containerDrift(&withCommand, actual, ""))
+
+		actual.WorkDir = "/workspace"
+		actual.Command = []string{"/bin/sh"}
+		assert.Equal(t, []string{
+			"working directory is /workspace, configured /workspace/api",
+			`command is "/bin/sh", configured "/bin/sh -c npm run dev"`,
+		}, containerDrift(&withCommand, actual, ""))
+	})
+
+	t.Run("environment names only", func(t *testing.T) {
+		actual := matching
+		actual.Env = []string{"PATH=/usr/bin", "TOKEN=old-secret", "DEBUG=1"}
+		drift := containerDrift(spec, actual, "")
+		assert.Equal(t, []string{
+			"environment variable added: API_URL",
+			"environment variable changed: TOKEN",
+			"environment variable removed: DEBUG",
+		}, drift)
+		for _, entry := range drift {
+			assert.NotContains(t, entry, "secret")
+		}
+	})
+
+	t.Run("image variables are not reported without the image", func(t *testing.T) {
+		actual := matching
+		actual.ImageEnv = nil
+		actual.Env = append(actual.Env, "LANG=C.UTF-8")
+		assert.Empty(t, containerDrift(spec, actual, ""))
+	})
+}
@@ -0,0 +1,145 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// dryRun prints the container Up would create for the resolved configuration
+func dryRun(upConfig UpConfig, resolved *config.ResolvedConfig, environment []config.EnvVar, finalPorts []PortMapping) error {
+	if resolved.Build != nil {
+		buildSpec, err := createBuildSpecFromConfig(resolved)
+		if err != nil {
+			return fmt.Errorf("failed to create build specification: %w", err)
+		}
+		resolved.Image = buildSpec.ImageName
+	}
+
+	spec, _, err := newContainerSpec(upConfig, resolved, environment, finalPorts, nil)
+	if err != nil {
+		return err
+	}
+	printContainerSpec(os.Stdout, spec, resolved.Build != nil)
+	return nil
+}
+
+// printContainerSpec describes the container 'reactor up --dry-run' would create.
+// Values of secret-looking environment variables are masked.
+func printContainerSpec(w io.Writer, spec *docker.ContainerSpec, builtImage bool) {
+	image := spec.Image
+	if builtImage {
+		image += " (built from the devcontainer.json build configuration)"
+	}
+
+	fmt.Fprintf(w, "Dry run: no container was created. 'reactor up' would create:\n")
+	fmt.Fprintf(w, "  Name:        %s\n", spec.Name)
+	fmt.Fprintf(w, "  Image:       %s\n", image)
+	if spec.Platform != "" {
+		fmt.Fprintf(w, "  Platform:    %s\n", spec.Platform)
+	}
+	fmt.Fprintf(w, "  User:        %s\n", spec.User)
+	if len(spec.Entrypoint) > 0 {
+		fmt.Fprintf(w, "  Entrypoint:  %s\n", shellJoin(spec.Entrypoint))
+	}
+	if len(spec.Command) > 0 {
+		fmt.Fprintf(w, "  Command:     %s\n", shellJoin(spec.Command))
+	} else {
+		fmt.Fprintf(w, "  Command:     (image default)\n")
+	}
+	fmt.Fprintf(w, "  Work dir:    %s\n", spec.WorkDir)
+	fmt.Fprintf(w, "  Init:        %t\n", spec.Init)
+	fmt.Fprintf(w, "  Network:     %s\n", spec.NetworkMode)
+	if spec.DiskLimit != "" {
+		fmt.Fprintf(w, "  Disk limit:  %s\n", spec.DiskLimit)
+	}
+
+	if len(spec.PortMappings) > 0 {
+		ports := make([]string, len(spec.PortMappings))
+		for i, pm := range spec.PortMappings {
+			ports[i] = fmt.Sprintf("%d->%d", pm.HostPort, pm.ContainerPort)
+		}
+		fmt.Fprintf(w, "  Ports:       %s\n", strings.Join(ports, ", "))
+	}
+	if len(spec.DNS) > 0 {
+		fmt.Fprintf(w, "  DNS:         %s\n", strings.Join(spec.DNS, ", "))
+	}
+	if len(spec.DNSSearch) > 0 {
+		fmt.Fprintf(w, "  DNS search:  %s\n", strings.Join(spec.DNSSearch, ", "))
+	}
+	if len(spec.ExtraHosts) > 0 {
+		fmt.Fprintf(w, "  Extra hosts: %s\n", strings.Join(spec.ExtraHosts, ", "))
+	}
+	if spec.Privileged {
+		fmt.Fprintf(w, "  Privileged:  true\n")
+	}
+	if len(spec.CapAdd) > 0 {
+		fmt.Fprintf(w, "  Cap add:     %s\n", strings.Join(spec.CapAdd, ", "))
+	}
+	if len(spec.SecurityOpt) > 0 {
+		fmt.Fprintf(w, "  Security:    %s\n", strings.Join(spec.SecurityOpt, ", "))
+	}
+
+	fmt.Fprintf(w, "  Mounts:\n")
+	if len(spec.Mounts) == 0 && len(spec.ExtraMounts) == 0 && len(spec.Tmpfs) == 0 {
+		fmt.Fprintf(w, "    (none)\n")
+	}
+	for _, mount := range spec.Mounts {
+		fmt.Fprintf(w, "    %s\n", mount)
+	}
+	for _, mount := range spec.ExtraMounts {
+		fmt.Fprintf(w, "    %s\n", mount)
+	}
+	tmpfsTargets := make([]string, 0, len(spec.Tmpfs))
+	for target := range spec.Tmpfs {
+		tmpfsTargets = append(tmpfsTargets, target)
+	}
+	sort.Strings(tmpfsTargets)
+	for _, target := range tmpfsTargets {
+		fmt.Fprintf(w, "    tmpfs:%s\n", target)
+	}
+
+	fmt.Fprintf(w, "  Environment:\n")
+	if len(spec.Environment) == 0 {
+		fmt.Fprintf(w, "    (none)\n")
+	}
+	vars := make([]config.EnvVar, len(spec.Environment))
+	for i, entry := range spec.Environment {
+		name, value, _ := strings.Cut(entry, "=")
+		vars[i] = config.EnvVar{Name: name, Value: value}
+	}
+	for _, v := range config.MaskSecrets(vars) {
+		fmt.Fprintf(w, "    %s=%s\n", v.Name, v.Value)
+	}
+
+	fmt.Fprintf(w, "  Labels:\n")
+	if len(spec.Labels) == 0 {
+		fmt.Fprintf(w, "    (none)\n")
+	}
+	keys := make([]string, 0, len(spec.Labels))
+	for k := range spec.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "    %s=%s\n", k, spec.Labels[k])
+	}
+}
+
+// shellJoin joins a command so arguments with spaces stay recognisable
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,165 @@
+package orchestrator
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContainerSpec(t *testing.T) {
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		Init:             true,
+	}
+	environment := []config.EnvVar{{Name: "API_TOKEN", Value: "s3cret"}, {Name: "LOG_LEVEL", Value: "debug"}}
+	upConfig := UpConfig{
+		SessionName:       "feature",
+		NamePrefix:        "reactor-ws-api-",
+		ReadOnlyWorkspace: true,
+		Labels:            map[string]string{"com.reactor.workspace.service": "api"},
+	}
+
+	spec, overlayVolume, err := newContainerSpec(upConfig, resolved, environment, []PortMapping{{HostPort: 8080, ContainerPort: 80}}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "reactor-ws-api-reactor-work-app-abc123-feature", spec.Name)
+	assert.NotEmpty(t, overlayVolume)
+	assert.Contains(t, spec.Mounts, overlayVolume+":/workspace")
+	assert.Equal(t, "true", spec.Labels[ReadOnlyWorkspaceLabel])
+	assert.Equal(t, "api", spec.Labels["com.reactor.workspace.service"])
+	assert.Contains(t, spec.Environment, "LOG_LEVEL=debug")
+	assert.Equal(t, config.ContainerLogDir(resolved.ProjectConfigDir, spec.Name), spec.LogDir)
+	assert.NotContains(t, spec.Labels, ConfigNameLabel)
+
+	var out bytes.Buffer
+	printContainerSpec(&out, spec, false)
+	assert.Contains(t, out.String(), "Name:        reactor-ws-api-reactor-work-app-abc123-feature")
+	assert.Contains(t, out.String(), "Image:       ghcr.io/example/dev:1\n")
+	assert.Contains(t, out.String(), "Ports:       8080->80")
+	assert.Contains(t, out.String(), "LOG_LEVEL=debug")
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Contains(t, out.String(), "com.reactor.workspace.service=api")
+}
+
+func TestNewContainerSpec_ConfigName(t *testing.T) {
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		ConfigName:       "backend",
+	}
+
+	spec, _, err := newContainerSpec(UpConfig{}, resolved, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "backend", spec.Labels[ConfigNameLabel])
+}
+
+func TestNewContainerSpec_WorkDirAndCommand(t *testing.T) {
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		DefaultCommand:   "sleep infinity",
+	}
+
+	spec, _, err := newContainerSpec(UpConfig{}, resolved, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/workspace", spec.WorkDir)
+	assert.Equal(t, []string{"/bin/sh", "-c", "sleep infinity"}, spec.Command)
+
+	resolved.UseImageCommand = true
+	spec, _, err = newContainerSpec(UpConfig{WorkDir: "/workspace/services/api", Command: "npm run dev"}, resolved, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/workspace/services/api", spec.WorkDir)
+	assert.Equal(t, []string{"/bin/sh", "-c", "npm run dev"}, spec.Command)
+}
+
+func TestAddMounts(t *testing.T) {
+	resolved := &config.ResolvedConfig{
+		Account:     "work",
+		Image:       "ghcr.io/example/dev:1",
+		ProjectRoot: "/src/app",
+		ProjectHash: "abc123",
+		Mounts:      []config.Mount{{Type: config.MountTypeTmpfs, Target: "/scratch"}},
+	}
+	artifacts := config.Mount{Type: config.MountTypeVolume, Source: "reactor-ws-0123456789ab-artifacts", Target: "/artifacts"}
+
+	require.NoError(t, addMounts(resolved, []config.Mount{artifacts}))
+	spec, _, err := newContainerSpec(UpConfig{}, resolved, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, spec.ExtraMounts, 2)
+	assert.Equal(t, "reactor-ws-0123456789ab-artifacts", spec.ExtraMounts[1].Source)
+	assert.Equal(t, "/artifacts", spec.ExtraMounts[1].Target)
+
+	err = addMounts(resolved, []config.Mount{{Type: config.MountTypeVolume, Source: "cache", Target: "/scratch"}})
+	assert.EqualError(t, err, "cannot mount cache at /scratch, which customizations.reactor.mounts already uses")
+}
+
+func TestNewContainerSpec_Ephemeral(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		ShellHistory:     true,
+		CredentialScope:  "project",
+		Mounts: []config.Mount{
+			{Type: config.MountTypeTmpfs, Target: "/scratch"},
+			{Type: config.MountTypeBind, Source: "/home/me/data", Target: "/data"},
+		},
+	}
+
+	spec, _, err := newContainerSpec(UpConfig{Ephemeral: true, ephemeralID: "f00d"}, resolved, nil, []PortMapping{{HostPort: 8080, ContainerPort: 80}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "reactor-ephemeral-app-abc123-f00d", spec.Name)
+	assert.Equal(t, []string{"/src/app:/workspace"}, spec.Mounts, "only the workspace is mounted")
+	require.Len(t, spec.ExtraMounts, 1)
+	assert.Equal(t, "/scratch", spec.ExtraMounts[0].Target)
+	assert.Empty(t, spec.LogDir)
+	assert.Equal(t, "true", spec.Labels[EphemeralLabel])
+	assert.NotContains(t, spec.Labels, CredentialScopeLabel)
+	for _, env := range spec.Environment {
+		assert.NotContains(t, env, "HISTFILE")
+	}
+	assert.Equal(t, "reactor-ephemeral-app-abc123-f00d", upContainerName(UpConfig{Ephemeral: true, ephemeralID: "f00d"}, resolved))
+}
+
+func TestShellJoin(t *testing.T) {
+	assert.Equal(t, `/bin/sh -c "npm run dev"`, shellJoin([]string{"/bin/sh", "-c", "npm run dev"}))
+	assert.Equal(t, `echo ""`, shellJoin([]string{"echo", ""}))
+}
+
+func TestCheckOverlayHost(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		assert.ErrorContains(t, checkOverlayHost(""), "only the case on Linux")
+		return
+	}
+	t.Setenv("DOCKER_HOST", "")
+	assert.NoError(t, checkOverlayHost(""))
+	assert.NoError(t, checkOverlayHost("unix:///var/run/docker.sock"))
+	assert.ErrorContains(t, checkOverlayHost("ssh://me@build-box"), "remote Docker daemon ssh://me@build-box")
+
+	t.Setenv("DOCKER_HOST", "tcp://build-box:2376")
+	assert.ErrorContains(t, checkOverlayHost(""), "remote Docker daemon tcp://build-box:2376")
+}
+
+func TestCheckRemoteBindMounts(t *testing.T) {
+	assert.NoError(t, checkRemoteBindMounts(UpConfig{}))
+	assert.NoError(t, checkRemoteBindMounts(UpConfig{DockerHost: "unix:///run/other.sock"}))
+	assert.ErrorContains(t, checkRemoteBindMounts(UpConfig{DockerHost: "tcp://build-box:2376"}), "set shared_paths: true")
+	assert.NoError(t, checkRemoteBindMounts(UpConfig{DockerHost: "ssh://dev@build-box", SharedHostPaths: true}))
+}
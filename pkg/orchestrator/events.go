@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"fmt"
+)
+
+// Phase is a stage of Up or Down reported in progress events
+type Phase string
+
+// Phases of Up and Down, in the order they run
+const (
+	PhaseConfig     Phase = "config"     // resolving devcontainer.json and settings
+	PhaseImage      Phase = "image"      // building or pulling the image
+	PhaseContainer  Phase = "container"  // creating, reusing or removing the container
+	PhaseSetup      Phase = "setup"      // restoring credentials, checking mount permissions, installing tools and tunnelling ports
+	PhasePostCreate Phase = "postCreate" // running postCreateCommand and postStartCommand
+	PhaseDone       Phase = "done"
+)
+
+// phasePercent estimates how far Up or Down has got when a phase starts
+var phasePercent = map[Phase]int{
+	PhaseConfig:     0,
+	PhaseImage:      20,
+	PhaseContainer:  60,
+	PhaseSetup:      80,
+	PhasePostCreate: 90,
+	PhaseDone:       100,
+}
+
+// Levels of progress events
+const (
+	LevelProgress = "progress" // a phase started; renderers may show it as a step or ignore it
+	LevelInfo     = "info"     // a message for the user
+	LevelDetail   = "detail"   // a message only produced with UpConfig.Verbose
+	LevelWarning  = "warning"  // something the user should act on; Up carries on
+)
+
+// Event is a structured progress update from Up or Down
+type Event struct {
+	Phase   Phase    `json:"phase"`
+	Service string   `json:"service,omitempty"` // the workspace service, empty for a single project
+	Level   string   `json:"level"`
+	Message string   `json:"message,omitempty"`
+	Details []string `json:"details,omitempty"` // items listed under the message, e.g. drifted settings
+	Percent int      `json:"pct"`
+}
+
+// EventFunc receives the progress events of Up or Down. It is called from the
+// goroutine running them, so it must not block for long.
+type EventFunc func(Event)
+
+// progress sends the events of one Up or Down call; a nil EventFunc drops them
+type progress struct {
+	events  EventFunc
+	service string
+}
+
+func (p progress) send(phase Phase, level, message string, details []string) {
+	if p.events == nil {
+		return
+	}
+	p.events(Event{Phase: phase, Service: p.service, Level: level, Message: message, Details: details, Percent: phasePercent[phase]})
+}
+
+// phase reports that a phase started
+func (p progress) phase(phase Phase) {
+	p.send(phase, LevelProgress, "", nil)
+}
+
+func (p progress) info(phase Phase, format string, args ...any) {
+	p.send(phase, LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+func (p progress) detail(phase Phase, format string, args ...any) {
+	p.send(phase, LevelDetail, fmt.Sprintf(format, args...), nil)
+}
+
+func (p progress) warn(phase Phase, format string, args ...any) {
+	p.send(phase, LevelWarning, fmt.Sprintf(format, args...), nil)
+}
@@ -0,0 +1,43 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUp_Events(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, ".devcontainer"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".devcontainer", "devcontainer.json"), []byte(`{"image": "alpine"}`), 0644))
+
+	var events []Event
+	_, _, err := Up(context.Background(), UpConfig{
+		ProjectDirectory:      projectDir,
+		DryRun:                true,
+		DockerHostIntegration: true,
+		Service:               "api",
+		Events:                func(e Event) { events = append(events, e) },
+	})
+	require.NoError(t, err)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, Event{Phase: PhaseConfig, Service: "api", Level: LevelProgress, Percent: 0}, events[0])
+	assert.Equal(t, LevelWarning, events[1].Level)
+	assert.Equal(t, "Docker host integration enabled!", events[1].Message)
+	assert.Len(t, events[1].Details, 3)
+	assert.Equal(t, Event{Phase: PhaseDone, Service: "api", Level: LevelProgress, Percent: 100}, events[2])
+}
+
+func TestProgress_NilEvents(t *testing.T) {
+	assert.NotPanics(t, func() {
+		progress{}.info(PhaseContainer, "Container provisioned: %s", "x")
+	})
+}
@@ -0,0 +1,225 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/hooks"
+	"github.com/dyluth/reactor/pkg/metrics"
+)
+
+// Labels recording a hash of the lifecycle commands a container was created with, so
+// a later edit to devcontainer.json can be detected and the command run again
+const (
+	PostCreateHashLabel = "com.reactor.postcreate-hash"
+	PostStartHashLabel  = "com.reactor.poststart-hash"
+)
+
+// lifecycleStateFileName records, per container ID, the hashes of lifecycle commands
+// re-run and the tools installed after the container was created; labels cannot be
+// changed afterwards
+const lifecycleStateFileName = "lifecycle.json"
+
+// lifecycleHook is a devcontainer.json lifecycle command whose changes are tracked
+type lifecycleHook struct {
+	Name    string // the devcontainer.json property
+	Label   string
+	Command interface{}
+}
+
+// lifecycleHooks returns the tracked lifecycle commands in the order they run
+func lifecycleHooks(resolved *config.ResolvedConfig) []lifecycleHook {
+	return []lifecycleHook{
+		{Name: "postCreateCommand", Label: PostCreateHashLabel, Command: resolved.PostCreateCommand},
+		{Name: "postStartCommand", Label: PostStartHashLabel, Command: resolved.PostStartCommand},
+	}
+}
+
+// lifecycleHash returns a short hash of a lifecycle command in any of its forms, empty
+// when there is no command. The object form hashes the same whatever its key order.
+func lifecycleHash(command interface{}) string {
+	if command == nil {
+		return ""
+	}
+	data, err := json.Marshal(command)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum[:6])
+}
+
+// setLifecycleLabels records the lifecycle commands a new container is created with
+func setLifecycleLabels(spec *docker.ContainerSpec, resolved *config.ResolvedConfig) {
+	for _, hook := range lifecycleHooks(resolved) {
+		spec.Labels[hook.Label] = lifecycleHash(hook.Command)
+	}
+}
+
+// changedLifecycleHooks returns the lifecycle commands that differ from the ones the
+// container was set up with. Containers created before the labels existed are not
+// compared. applied holds the hashes of commands re-run since the container was created.
+func changedLifecycleHooks(labels, applied map[string]string, resolved *config.ResolvedConfig) []lifecycleHook {
+	var changed []lifecycleHook
+	for _, hook := range lifecycleHooks(resolved) {
+		current, ok := applied[hook.Label]
+		if !ok {
+			current, ok = labels[hook.Label]
+		}
+		if ok && current != lifecycleHash(hook.Command) {
+			changed = append(changed, hook)
+		}
+	}
+	return changed
+}
+
+// runLifecycleCommands runs postCreateCommand in a container that was just created and
+// postStartCommand whenever the container was started. In a reused container, commands
+// edited since it was created are run again once confirmed, or with RerunHooks. Each
+// command is recorded as a step of track.
+func runLifecycleCommands(ctx context.Context, dockerService *docker.Service, upConfig UpConfig, resolved *config.ResolvedConfig, containerID string, labels map[string]string, created, started bool, track *provisioning, p progress) error {
+	tracked := lifecycleHooks(resolved)
+	run := map[string]bool{}
+	var changed []lifecycleHook
+	if created {
+		for _, hook := range tracked {
+			run[hook.Name] = true
+		}
+	} else {
+		changed = changedLifecycleHooks(labels, appliedLifecycleHashes(resolved.ProjectConfigDir, containerID), resolved)
+		confirmed, err := confirmRerunHooks(upConfig, changed, p)
+		if err != nil {
+			return err
+		}
+		if confirmed {
+			for _, hook := range changed {
+				run[hook.Name] = true
+			}
+		}
+		run["postStartCommand"] = run["postStartCommand"] || started
+	}
+
+	var due []lifecycleHook
+	for _, hook := range tracked {
+		if run[hook.Name] && hook.Command != nil {
+			due = append(due, hook)
+		}
+	}
+	if len(due) > 0 {
+		p.phase(PhasePostCreate)
+		defer upConfig.Profile.Track(metrics.PhasePostCreate)()
+	}
+	for _, hook := range due {
+		p.info(PhasePostCreate, "Running %s...", hook.Name)
+		track.step(hook.Name)
+		if err := dockerService.ExecuteLifecycleCommand(ctx, containerID, hook.Name, hook.Command); err != nil {
+			err = fmt.Errorf("%s execution failed: %w", hook.Name, err)
+			payload := upHookPayload(hooks.PostCreateFailed, upConfig, resolved, containerID)
+			payload.Error = err.Error()
+			_ = hooks.Fire(ctx, payload)
+			return err
+		}
+		p.info(PhasePostCreate, "%s completed.", hook.Name)
+	}
+
+	if applied := appliedHooks(changed, run); len(applied) > 0 {
+		if err := recordAppliedLifecycleHashes(resolved.ProjectConfigDir, containerID, applied); err != nil {
+			p.warn(PhasePostCreate, "failed to record the re-run lifecycle commands: %v", err)
+		}
+	}
+	return nil
+}
+
+// appliedHooks returns the changed hooks whose new command ran. A changed
+// postStartCommand also runs, without a re-run being confirmed, whenever the container
+// is started, and is then no longer pending.
+func appliedHooks(changed []lifecycleHook, run map[string]bool) []lifecycleHook {
+	var applied []lifecycleHook
+	for _, hook := range changed {
+		if run[hook.Name] {
+			applied = append(applied, hook)
+		}
+	}
+	return applied
+}
+
+// confirmRerunHooks decides whether lifecycle commands changed since the container
+// was created run again: with RerunHooks, or when ConfirmRerunHooks agrees. Without
+// either the user is told how to run them.
+func confirmRerunHooks(upConfig UpConfig, changed []lifecycleHook, p progress) (bool, error) {
+	if len(changed) == 0 {
+		return false, nil
+	}
+	if upConfig.RerunHooks {
+		return true, nil
+	}
+	names := hookNames(changed)
+	if upConfig.ConfirmRerunHooks != nil {
+		return upConfig.ConfirmRerunHooks(names)
+	}
+	p.warn(PhasePostCreate, "%s changed since the container was created; run 'reactor up --rerun-hooks' to run the new command in the existing container", strings.Join(names, " and "))
+	return false, nil
+}
+
+// appliedLifecycleHashes returns the values recorded for a container in place of its
+// labels: the hashes of the lifecycle commands re-run and the tools installed in it
+func appliedLifecycleHashes(projectConfigDir, containerID string) map[string]string {
+	return loadLifecycleState(projectConfigDir)[containerID]
+}
+
+// recordAppliedLifecycleHashes records that hooks were re-run in a container
+func recordAppliedLifecycleHashes(projectConfigDir, containerID string, hooks []lifecycleHook) error {
+	values := make(map[string]string, len(hooks))
+	for _, hook := range hooks {
+		values[hook.Label] = lifecycleHash(hook.Command)
+	}
+	return recordAppliedLabels(projectConfigDir, containerID, values)
+}
+
+// recordAppliedLabels records values that replace labels of a container
+func recordAppliedLabels(projectConfigDir, containerID string, values map[string]string) error {
+	state := loadLifecycleState(projectConfigDir)
+	if state[containerID] == nil {
+		state[containerID] = make(map[string]string)
+	}
+	for label, value := range values {
+		state[containerID][label] = value
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(projectConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create project config directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(projectConfigDir, lifecycleStateFileName), data, 0644)
+}
+
+// loadLifecycleState reads the re-run lifecycle hashes of a project's containers; a
+// missing or unreadable file counts as none
+func loadLifecycleState(projectConfigDir string) map[string]map[string]string {
+	var state map[string]map[string]string
+	if data, err := os.ReadFile(filepath.Join(projectConfigDir, lifecycleStateFileName)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	if state == nil {
+		state = make(map[string]map[string]string)
+	}
+	return state
+}
+
+// hookNames lists the devcontainer.json properties of hooks for messages
+func hookNames(hooks []lifecycleHook) []string {
+	names := make([]string, len(hooks))
+	for i, hook := range hooks {
+		names[i] = hook.Name
+	}
+	return names
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleHash(t *testing.T) {
+	assert.Empty(t, lifecycleHash(nil))
+	assert.NotEqual(t, lifecycleHash("npm install"), lifecycleHash("npm ci"))
+	assert.NotEqual(t, lifecycleHash("npm install"), lifecycleHash([]interface{}{"npm", "install"}))
+	assert.Equal(t,
+		lifecycleHash(map[string]interface{}{"deps": "npm ci", "db": "make migrate"}),
+		lifecycleHash(map[string]interface{}{"db": "make migrate", "deps": "npm ci"}))
+}
+
+func TestChangedLifecycleHooks(t *testing.T) {
+	created := &config.ResolvedConfig{PostCreateCommand: "npm install"}
+	spec := &docker.ContainerSpec{Labels: map[string]string{}}
+	setLifecycleLabels(spec, created)
+	assert.Equal(t, "", spec.Labels[PostStartHashLabel])
+
+	assert.Empty(t, changedLifecycleHooks(spec.Labels, nil, created))
+	// Containers created without the labels are not compared
+	assert.Empty(t, changedLifecycleHooks(nil, nil, &config.ResolvedConfig{PostCreateCommand: "npm ci"}))
+
+	edited := &config.ResolvedConfig{PostCreateCommand: "npm ci", PostStartCommand: "make serve"}
+	changed := changedLifecycleHooks(spec.Labels, nil, edited)
+	assert.Equal(t, []string{"postCreateCommand", "postStartCommand"}, hookNames(changed))
+
+	dir := t.TempDir()
+	require.NoError(t, recordAppliedLifecycleHashes(dir, "c1", changed[:1]))
+	applied := appliedLifecycleHashes(dir, "c1")
+	assert.Equal(t, []string{"postStartCommand"}, hookNames(changedLifecycleHooks(spec.Labels, applied, edited)))
+	assert.Empty(t, appliedLifecycleHashes(dir, "c2"))
+}
+
+func TestConfirmRerunHooks(t *testing.T) {
+	changed := []lifecycleHook{{Name: "postCreateCommand"}}
+	var events []Event
+	p := progress{events: func(e Event) { events = append(events, e) }}
+
+	rerun, err := confirmRerunHooks(UpConfig{}, nil, p)
+	require.NoError(t, err)
+	assert.False(t, rerun)
+
+	rerun, err = confirmRerunHooks(UpConfig{RerunHooks: true}, changed, p)
+	require.NoError(t, err)
+	assert.True(t, rerun)
+
+	var asked []string
+	rerun, err = confirmRerunHooks(UpConfig{ConfirmRerunHooks: func(names []string) (bool, error) {
+		asked = names
+		return false, nil
+	}}, changed, p)
+	require.NoError(t, err)
+	assert.False(t, rerun)
+	assert.Equal(t, []string{"postCreateCommand"}, asked)
+	assert.Empty(t, events)
+
+	rerun, err = confirmRerunHooks(UpConfig{}, changed, p)
+	require.NoError(t, err)
+	assert.False(t, rerun)
+	require.Len(t, events, 1)
+	assert.Equal(t, LevelWarning, events[0].Level)
+	assert.Contains(t, events[0].Message, "reactor up --rerun-hooks")
+}
+
+func TestAppliedHooks(t *testing.T) {
+	changed := []lifecycleHook{{Name: "postCreateCommand"}, {Name: "postStartCommand"}}
+
+	// Declining the re-run still starts the container, which runs the new postStartCommand
+	applied := appliedHooks(changed, map[string]bool{"postStartCommand": true})
+	assert.Equal(t, []string{"postStartCommand"}, hookNames(applied))
+
+	applied = appliedHooks(changed, map[string]bool{"postCreateCommand": true, "postStartCommand": true})
+	assert.Equal(t, []string{"postCreateCommand", "postStartCommand"}, hookNames(applied))
+
+	assert.Empty(t, appliedHooks(changed, map[string]bool{}))
+	assert.Empty(t, appliedHooks(nil, map[string]bool{"postStartCommand": true}))
+}
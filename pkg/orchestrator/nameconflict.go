@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// reactorLabelPrefix starts the labels reactor gives its containers
+const reactorLabelPrefix = "com.reactor."
+
+// ownedByReactor reports whether a container was created by reactor: it has reactor
+// labels or, when made by a reactor from before the labels, mounts the project at
+// /workspace
+func ownedByReactor(labels map[string]string, mounts []string) bool {
+	for key := range labels {
+		if strings.HasPrefix(key, reactorLabelPrefix) {
+			return true
+		}
+	}
+	for _, mount := range mounts {
+		if parts := strings.Split(mount, ":"); len(parts) >= 2 && parts[1] == "/workspace" {
+			return true
+		}
+	}
+	return false
+}
+
+// foreignNameHolder returns the container that holds the name of the project's
+// container when reactor did not create it, nil when the name is free or reactor's
+func foreignNameHolder(ctx context.Context, dockerService *docker.Service, name string, existing docker.ContainerInfo) (*docker.NameConflictError, error) {
+	if existing.ID == "" || ownedByReactor(existing.Labels, nil) {
+		return nil, nil
+	}
+	actual, err := dockerService.InspectContainerConfig(ctx, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ownedByReactor(actual.Labels, actual.Mounts) {
+		return nil, nil
+	}
+	return &docker.NameConflictError{Name: name, Holder: existing}, nil
+}
+
+// confirmReplace decides whether the container holding the name is removed: with
+// ReplaceConflicting, or when ConfirmReplace agrees
+func confirmReplace(upConfig UpConfig, conflict *docker.NameConflictError) (bool, error) {
+	if upConfig.ReplaceConflicting {
+		return true, nil
+	}
+	if upConfig.ConfirmReplace != nil {
+		return upConfig.ConfirmReplace(conflict.Holder)
+	}
+	return false, nil
+}
+
+// replaceNameHolder removes the container holding the name once confirmed, and
+// otherwise explains how to go on
+func replaceNameHolder(ctx context.Context, dockerService *docker.Service, upConfig UpConfig, conflict *docker.NameConflictError, p progress) error {
+	replace, err := confirmReplace(upConfig, conflict)
+	if err != nil {
+		return err
+	}
+	if !replace {
+		return nameConflictError(conflict, upConfig.Service)
+	}
+	id := conflict.Holder.ID
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	p.info(PhaseContainer, "Removing container %s (image %s), which held the name %s", id, conflict.Holder.Image, conflict.Name)
+	return dockerService.RemoveContainer(ctx, conflict.Holder.ID)
+}
+
+// nameConflictError adds to err, when the container name was taken, how to free it
+// with the command that ran into it: 'reactor workspace up' for a workspace service
+func nameConflictError(err error, service string) error {
+	var conflict *docker.NameConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+	if ownedByReactor(conflict.Holder.Labels, nil) {
+		return fmt.Errorf("%w; another 'reactor up' may be creating it, try again", err)
+	}
+	if service != "" {
+		return fmt.Errorf("%w, which reactor did not create; run 'reactor workspace up --replace' to remove it", err)
+	}
+	return fmt.Errorf("%w, which reactor did not create; run 'reactor up --replace' to remove it, or 'reactor up --name <session>' to give this project's container another name", err)
+}
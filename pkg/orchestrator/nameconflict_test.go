@@ -0,0 +1,55 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnedByReactor(t *testing.T) {
+	assert.True(t, ownedByReactor(map[string]string{ProjectLabel: "/src/demo"}, nil))
+	assert.True(t, ownedByReactor(map[string]string{"com.reactor.workspace.service": "api"}, nil))
+	// Containers of a reactor from before the labels mount the project at /workspace
+	assert.True(t, ownedByReactor(map[string]string{}, []string{"/src/demo:/workspace"}))
+	assert.False(t, ownedByReactor(map[string]string{"maintainer": "postgres"}, []string{"/data:/var/lib/postgresql/data", "/src:/workspace-cache"}))
+	assert.False(t, ownedByReactor(nil, nil))
+}
+
+func TestConfirmReplace(t *testing.T) {
+	conflict := &docker.NameConflictError{Name: "reactor-demo", Holder: docker.ContainerInfo{ID: "abc"}}
+
+	replace, err := confirmReplace(UpConfig{}, conflict)
+	assert.NoError(t, err)
+	assert.False(t, replace)
+
+	replace, err = confirmReplace(UpConfig{ReplaceConflicting: true}, conflict)
+	assert.NoError(t, err)
+	assert.True(t, replace)
+
+	var asked docker.ContainerInfo
+	replace, err = confirmReplace(UpConfig{ConfirmReplace: func(holder docker.ContainerInfo) (bool, error) {
+		asked = holder
+		return true, nil
+	}}, conflict)
+	assert.NoError(t, err)
+	assert.True(t, replace)
+	assert.Equal(t, "abc", asked.ID)
+}
+
+func TestNameConflictError(t *testing.T) {
+	foreign := &docker.NameConflictError{Name: "reactor-demo", Holder: docker.ContainerInfo{ID: "abc", Image: "postgres:16", State: "created"}}
+	err := nameConflictError(foreign, "")
+	assert.ErrorIs(t, err, foreign)
+	assert.EqualError(t, err, "container name reactor-demo is already used by container abc (image postgres:16, created), which reactor did not create; run 'reactor up --replace' to remove it, or 'reactor up --name <session>' to give this project's container another name")
+
+	// A workspace service is started again with workspace up
+	assert.ErrorContains(t, nameConflictError(foreign, "api"), "which reactor did not create; run 'reactor workspace up --replace' to remove it")
+
+	ours := &docker.NameConflictError{Name: "reactor-demo", Holder: docker.ContainerInfo{ID: "abc", Image: "ubuntu", State: "created", Labels: map[string]string{ProjectLabel: "/src/demo"}}}
+	assert.Contains(t, nameConflictError(ours, "").Error(), "another 'reactor up' may be creating it, try again")
+
+	other := errors.New("image not found")
+	assert.Equal(t, other, nameConflictError(other, ""))
+}
@@ -2,17 +2,27 @@ package orchestrator
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dyluth/reactor/pkg/config"
 	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/debug"
 	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/hooks"
+	"github.com/dyluth/reactor/pkg/metrics"
+	"github.com/dyluth/reactor/pkg/notify"
+	"github.com/dyluth/reactor/pkg/overlay"
+	"github.com/dyluth/reactor/pkg/portforward"
+	"github.com/dyluth/reactor/pkg/tunnel"
 )
 
 // UpConfig contains all necessary, pre-resolved parameters for an 'up' operation.
@@ -24,6 +34,9 @@ type UpConfig struct {
 	// from the devcontainer.json file will be used.
 	AccountOverride string
 
+	// An optional .devcontainer/<name>/ configuration to use instead of the default devcontainer.json
+	ConfigName string
+
 	// A flag to force a rebuild of the container image.
 	ForceRebuild bool
 
@@ -36,14 +49,147 @@ type UpConfig struct {
 	// CLI-provided port mappings that override devcontainer.json ports
 	CLIPortMappings []string
 
+	// Host ports to publish on instead of the configured ones, keyed by the configured
+	// host port; the on-demand proxy holds the configured ports itself
+	ProxiedHostPorts map[int]int
+
+	// Added to every host port, including CLI ones, so several copies of a workspace
+	// can publish the same ports on one Docker host
+	PortOffset int
+
+	// Pull and run the image for this os/arch platform instead of the daemon's own,
+	// under emulation when they differ
+	Platform string
+
+	// Host dotenv files (--env-file) applied after the account env file, later files win
+	EnvFiles []string
+
+	// Mounts added to those of customizations.reactor.mounts, e.g. the volumes a
+	// workspace shares between services
+	Mounts []config.Mount
+
+	// CLI-provided KEY=VALUE environment variables that override containerEnv and all env files
+	EnvOverrides []string
+
 	// Enable discovery mode (no mounts)
 	DiscoveryMode bool
 
 	// Enable Docker host integration (dangerous)
 	DockerHostIntegration bool
 
+	// Create a throwaway container that mounts only the workspace: no provider
+	// directories, history or account env file, and nothing written under ~/.reactor
+	Ephemeral   bool
+	ephemeralID string // tells apart the ephemeral containers of a project
+
+	// Create a container with the privileged, capAdd and securityOpt properties of
+	// devcontainer.json without asking
+	AllowPrivileged bool
+
+	// ConfirmPrivileges asks whether to create a container with the listed elevated
+	// privileges; when nil, Up refuses unless AllowPrivileged is set
+	ConfirmPrivileges func(requested []string) (bool, error)
+
+	// An optional Docker daemon to run the container on (e.g. "tcp://build-box:2376")
+	// instead of the one DOCKER_HOST selects
+	DockerHost string
+
+	// The bind mount sources exist at the same paths on the machine of a remote
+	// DockerHost, so the container may be created there
+	SharedHostPaths bool
+
+	// Run without an init process as PID 1, overriding devcontainer.json
+	DisableInit bool
+
+	// Delegate building and provisioning to the devcontainer CLI; reactor still names the
+	// container and provides account mounts, so attach and sessions work as usual
+	UseDevcontainerCLI bool
+
+	// Mount the project read-only and capture writes in an overlay upper directory
+	ReadOnlyWorkspace bool
+
+	// A repository URL cloned inside the container into a named volume used as the
+	// workspace instead of the project directory, so no host checkout is needed
+	CloneRepo string
+
+	// Chown provider config directories the container user cannot write to
+	FixPermissions bool
+
+	// Print the container that would be created instead of calling Docker
+	DryRun bool
+
+	// An optional session name that allows several containers for the same project
+	SessionName string
+
 	// Enable verbose output
 	Verbose bool
+	// RecreateOnDrift removes an existing container whose image, mounts, environment or
+	// ports no longer match the configuration instead of only warning about it
+	RecreateOnDrift bool
+
+	// An optional profile that records the duration of each startup phase
+	Profile *metrics.Profile
+
+	// Events receives progress events instead of Up printing them; nil discards them
+	Events EventFunc
+
+	// The workspace service the container is for, reported in events
+	Service string
+
+	// Run postCreateCommand and postStartCommand again in an existing container when
+	// devcontainer.json changed them since it was created, without asking
+	RerunHooks bool
+
+	// ConfirmRerunHooks asks whether to run the named changed lifecycle commands again;
+	// when nil, Up only warns about them unless RerunHooks is set
+	ConfirmRerunHooks func(changed []string) (bool, error)
+
+	// Remove a container reactor did not create that holds the container's name
+	// without asking
+	ReplaceConflicting bool
+
+	// ConfirmReplace asks whether to remove the container holding the name; when nil,
+	// Up refuses unless ReplaceConflicting is set
+	ConfirmReplace func(holder docker.ContainerInfo) (bool, error)
+
+	// PoolSize starts a warm pool of this many idle copies of the container, for 'reactor
+	// pool start', instead of creating the container
+	PoolSize int
+
+	// DeferPoolRefill is handed the refill of the warm pool the container was claimed
+	// from, to run while the session runs; the caller must wait for it before exiting.
+	// When nil, Up refills the pool before returning.
+	DeferPoolRefill func(refill func())
+
+	// WorkDir and Command replace the container's working directory and command, for
+	// workspace services that cannot edit a shared devcontainer.json; Command is run
+	// with /bin/sh -c
+	WorkDir string
+	Command string
+}
+
+// ReadOnlyWorkspaceLabel marks containers whose workspace is an overlay over the read-only project
+const ReadOnlyWorkspaceLabel = "com.reactor.readonly-workspace"
+
+// CredentialScopeLabel records whether a container mounts per-project or shared provider directories
+const CredentialScopeLabel = "com.reactor.credential-scope"
+
+// ConfigNameLabel records the .devcontainer/<name>/ configuration a container was created from
+const ConfigNameLabel = "com.reactor.devcontainer-config"
+
+// ProjectLabel records the project directory a container was created for
+const ProjectLabel = "com.reactor.project"
+
+// AccountLabel records the account a container was created for
+const AccountLabel = "com.reactor.account"
+
+// EphemeralLabel marks throwaway containers created with 'reactor up --ephemeral'
+const EphemeralLabel = "com.reactor.ephemeral"
+
+// usesAccountState reports whether the container mounts the account's provider
+// directories and history, and keeps its state under ~/.reactor
+func (c UpConfig) usesAccountState() bool {
+	return !c.DiscoveryMode && !c.Ephemeral
 }
 
 // PortMapping represents a port forwarding configuration
@@ -55,9 +201,13 @@ type PortMapping struct {
 // Up orchestrates the entire 'reactor up' logic for a single service.
 // It returns the final resolved config and container ID on success.
 func Up(ctx context.Context, upConfig UpConfig) (*config.ResolvedConfig, string, error) {
-	// Check dependencies first
-	if err := config.CheckDependencies(); err != nil {
-		return nil, "", err
+	p := progress{events: upConfig.Events, service: upConfig.Service}
+
+	// Check dependencies first; a dry run does not need Docker
+	if !upConfig.DryRun {
+		if err := config.CheckDependencies(); err != nil {
+			return nil, "", err
+		}
 	}
 
 	// Validate flag combinations
@@ -68,6 +218,68 @@ func Up(ctx context.Context, upConfig UpConfig) (*config.ResolvedConfig, string,
 		if upConfig.DockerHostIntegration {
 			return nil, "", fmt.Errorf("discovery mode cannot be used with docker host integration")
 		}
+		if upConfig.ReadOnlyWorkspace {
+			return nil, "", fmt.Errorf("discovery mode cannot be used with a read-only workspace")
+		}
+	}
+
+	if upConfig.Ephemeral {
+		if upConfig.DiscoveryMode {
+			return nil, "", fmt.Errorf("--ephemeral cannot be used with discovery mode")
+		}
+		if upConfig.DockerHostIntegration {
+			return nil, "", fmt.Errorf("--ephemeral cannot be used with docker host integration")
+		}
+		if upConfig.ReadOnlyWorkspace {
+			return nil, "", fmt.Errorf("--ephemeral cannot be used with a read-only workspace")
+		}
+		if upConfig.SessionName != "" {
+			return nil, "", fmt.Errorf("--ephemeral cannot be used with --name; every ephemeral container gets its own name")
+		}
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, "", fmt.Errorf("failed to name ephemeral container: %w", err)
+		}
+		upConfig.ephemeralID = hex.EncodeToString(suffix)
+	}
+
+	if upConfig.CloneRepo != "" {
+		if err := validateCloneRepo(upConfig.CloneRepo); err != nil {
+			return nil, "", err
+		}
+		if upConfig.DiscoveryMode || upConfig.ReadOnlyWorkspace || upConfig.Ephemeral {
+			return nil, "", fmt.Errorf("--clone-repo cannot be used with discovery mode, --read-only-workspace or --ephemeral")
+		}
+	}
+
+	if upConfig.PoolSize > 0 && (upConfig.DiscoveryMode || upConfig.Ephemeral || upConfig.ReadOnlyWorkspace || upConfig.CloneRepo != "" || upConfig.UseDevcontainerCLI || upConfig.DryRun) {
+		return nil, "", fmt.Errorf("a warm pool cannot be used with discovery mode, --ephemeral, --read-only-workspace, --clone-repo, --use-devcontainer-cli or --dry-run")
+	}
+
+	if upConfig.DryRun && upConfig.UseDevcontainerCLI {
+		return nil, "", fmt.Errorf("--dry-run cannot be used with --use-devcontainer-cli")
+	}
+
+	if upConfig.UseDevcontainerCLI {
+		if err := validateDevcontainerCLIMode(upConfig); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if upConfig.ReadOnlyWorkspace {
+		if err := checkOverlayHost(upConfig.DockerHost); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := checkRemoteBindMounts(upConfig); err != nil {
+		return nil, "", err
+	}
+
+	if upConfig.SessionName != "" {
+		if err := core.ValidateSessionName(upConfig.SessionName); err != nil {
+			return nil, "", err
+		}
 	}
 
 	// Parse and validate CLI port mappings
@@ -88,63 +300,87 @@ func Up(ctx context.Context, upConfig UpConfig) (*config.ResolvedConfig, string,
 		return nil, "", fmt.Errorf("failed to change to project directory %s: %w", upConfig.ProjectDirectory, err)
 	}
 
+	p.phase(PhaseConfig)
+	doneConfigResolve := upConfig.Profile.Track(metrics.PhaseConfigResolve)
 	configService := config.NewService()
+	configService.SetConfigName(upConfig.ConfigName)
+	configService.SetOverrides(settingOverrides(upConfig))
 	resolved, err := configService.ResolveConfiguration()
+	doneConfigResolve()
 	if err != nil {
 		return nil, "", err
 	}
+	if err := addMounts(resolved, upConfig.Mounts); err != nil {
+		return nil, "", err
+	}
 
-	// Apply account override if provided
-	if upConfig.AccountOverride != "" {
-		resolved.Account = upConfig.AccountOverride
-		// TODO: In future milestones, we might need to recalculate paths when account changes
+	// Merge containerEnv, the account env file and CLI overrides; an ephemeral container
+	// does not get the account's secrets
+	resolveEnvironment := config.ResolveEnvironment
+	if upConfig.Ephemeral {
+		resolveEnvironment = config.ResolveEnvironmentWithoutAccount
+	}
+	environment, err := resolveEnvironment(resolved, upConfig.EnvFiles, upConfig.EnvOverrides)
+	if err != nil {
+		return nil, "", fmt.Errorf("environment error: %w", err)
 	}
 
 	// Merge devcontainer.json ports with CLI ports (CLI takes precedence on conflicts)
-	finalPorts := mergePortMappings(resolved.ForwardPorts, cliPorts)
-
-	// Check for port conflicts on final merged list
-	if len(finalPorts) > 0 {
-		conflictPorts := checkPortConflicts(finalPorts)
-		if len(conflictPorts) > 0 {
-			fmt.Printf("⚠️  WARNING: The following host ports may already be in use:\n")
-			for _, port := range conflictPorts {
-				fmt.Printf("   Port %d - containers may fail to start or port forwarding may not work\n", port)
-			}
-			fmt.Printf("   Consider using different host ports or stopping conflicting services.\n\n")
-		}
+	mergedPorts, err := offsetHostPorts(mergePortMappings(resolved.ForwardPorts, cliPorts), upConfig.PortOffset)
+	if err != nil {
+		return nil, "", err
 	}
+	finalPorts := remapHostPorts(mergedPorts, upConfig.ProxiedHostPorts)
 
 	// Security warning for Docker host integration
 	if upConfig.DockerHostIntegration {
-		fmt.Printf("⚠️  WARNING: Docker host integration enabled!\n")
-		fmt.Printf("   This gives the container full access to your host Docker daemon.\n")
-		fmt.Printf("   Only use this flag with trusted images and AI agents.\n")
-		fmt.Printf("   The container can create, modify, and delete other containers.\n\n")
+		p.send(PhaseConfig, LevelWarning, "Docker host integration enabled!", []string{
+			"This gives the container full access to your host Docker daemon.",
+			"Only use this flag with trusted images and AI agents.",
+			"The container can create, modify, and delete other containers.",
+		})
 	}
 
 	// Display resolved configuration for debugging
 	if upConfig.Verbose {
-		fmt.Printf("Resolved configuration:\n")
-		fmt.Printf("  Provider: %s\n", resolved.Provider.Name)
-		fmt.Printf("  Account: %s\n", resolved.Account)
-		fmt.Printf("  Image: %s\n", resolved.Image)
-		fmt.Printf("  Danger: %t\n", resolved.Danger)
-		fmt.Printf("  Project: %s\n", resolved.ProjectRoot)
-		fmt.Printf("  Config Dir: %s\n", resolved.ProjectConfigDir)
+		details := []string{
+			"Provider: " + resolved.Provider.Name,
+			"Account: " + resolved.Account,
+			"Image: " + resolved.Image,
+			fmt.Sprintf("Danger: %t", resolved.Danger),
+			"Project: " + resolved.ProjectRoot,
+			"Config Dir: " + resolved.ProjectConfigDir,
+		}
 		if upConfig.ForceRebuild {
-			fmt.Printf("  Rebuild: enabled\n")
+			details = append(details, "Rebuild: enabled")
 		}
+		p.send(PhaseConfig, LevelDetail, "Resolved configuration:", details)
+	}
+
+	if err := checkPolicy(upConfig, resolved, environment, finalPorts); err != nil {
+		return nil, "", err
+	}
+
+	if upConfig.DryRun {
+		if err := dryRun(upConfig, resolved, environment, finalPorts); err != nil {
+			return nil, "", err
+		}
+		p.phase(PhaseDone)
+		return resolved, "", nil
+	}
+
+	if err := hooks.Fire(ctx, upHookPayload(hooks.PreUp, upConfig, resolved, "")); err != nil {
+		return nil, "", err
 	}
 
 	// Initialize Docker service
-	dockerService, err := docker.NewService()
+	dockerService, err := docker.NewServiceForHost(upConfig.DockerHost)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to initialize Docker service: %w", err)
 	}
 	defer func() {
 		if err := dockerService.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+			p.warn(PhaseDone, "failed to close Docker service: %v", err)
 		}
 	}()
 
@@ -152,79 +388,192 @@ func Up(ctx context.Context, upConfig UpConfig) (*config.ResolvedConfig, string,
 	if err := dockerService.CheckHealth(ctx); err != nil {
 		return nil, "", fmt.Errorf("docker daemon not available: %w", err)
 	}
+	dockerService.SetProfile(upConfig.Profile)
+	registryAuth, err := RegistryResolver(resolved)
+	if err != nil {
+		return nil, "", err
+	}
+	dockerService.SetRegistryAuth(registryAuth)
+	if upConfig.Platform != "" {
+		if resolved.Build != nil {
+			return nil, "", fmt.Errorf("platform %s can only be set for an image, not a devcontainer.json build configuration", upConfig.Platform)
+		}
+		dockerService.SetImagePlatforms(map[string]string{docker.NormalizeImageName(resolved.Image): upConfig.Platform})
+	}
+
+	if upConfig.UseDevcontainerCLI {
+		if credentialsEncrypted(resolved) {
+			return nil, "", fmt.Errorf("--use-devcontainer-cli cannot be used with credential encryption")
+		}
+		if resolved.Build != nil && (len(resolved.BuildSecrets) > 0 || len(resolved.BuildSSH) > 0) {
+			return nil, "", fmt.Errorf("--use-devcontainer-cli cannot be used with customizations.reactor.build secrets or ssh")
+		}
+		containerID, err := upViaDevcontainerCLI(ctx, dockerService, upConfig, resolved, environment, p)
+		if err != nil {
+			return nil, "", err
+		}
+		_ = hooks.Fire(ctx, upHookPayload(hooks.PostUp, upConfig, resolved, containerID))
+		p.phase(PhaseDone)
+		return resolved, containerID, nil
+	}
 
 	// Handle image building if build configuration is present
+	p.phase(PhaseImage)
 	finalImageName := resolved.Image // Default to resolved image
+	// Builds of the project's image that a rebuild replaces, removed once unused
+	var replacedImageIDs []string
+	debug.Logf(debug.Orchestrator, "image source: build %t, image %s, force rebuild %t", resolved.Build != nil, resolved.Image, upConfig.ForceRebuild)
 	if resolved.Build != nil {
 		// Build takes precedence over image
 		buildSpec, err := createBuildSpecFromConfig(resolved)
 		if err != nil {
 			return nil, "", fmt.Errorf("failed to create build specification: %w", err)
 		}
+		if upConfig.Ephemeral {
+			buildSpec.LogFile = ""
+		}
 
 		// Check if we should force rebuild
 		forceRebuild := upConfig.ForceRebuild
-		if err := dockerService.BuildImage(ctx, buildSpec, forceRebuild); err != nil {
+		if forceRebuild {
+			if previousID, err := dockerService.ImageID(ctx, buildSpec.ImageName); err == nil && previousID != "" {
+				replacedImageIDs = append(replacedImageIDs, previousID)
+			}
+		}
+		buildStart := time.Now()
+		err = dockerService.BuildImage(ctx, buildSpec, forceRebuild)
+		notify.Finished(notify.FromSettings(resolved.Settings), "Image build", buildStart, err)
+		if err != nil {
 			return nil, "", fmt.Errorf("build failed: %w", err)
 		}
 
 		// Use the built image for container creation
 		finalImageName = buildSpec.ImageName
 		if upConfig.Verbose {
-			fmt.Printf("[INFO] Using built image: %s\n", finalImageName)
+			p.detail(PhaseImage, "Using built image: %s", finalImageName)
 		}
+	} else if err := dockerService.EnsureImage(ctx, finalImageName); err != nil {
+		return nil, "", err
 	}
 
 	// Update resolved config to use final image name
 	resolved.Image = finalImageName
+	p.phase(PhaseContainer)
 
-	// Convert final merged port mappings to core format
-	corePortMappings := make([]core.PortMapping, len(finalPorts))
-	for i, pm := range finalPorts {
-		corePortMappings[i] = core.PortMapping{
-			HostPort:      pm.HostPort,
-			ContainerPort: pm.ContainerPort,
+	containerSpec, overlayVolume, err := newContainerSpec(upConfig, resolved, environment, finalPorts, func(image string) ([]string, error) {
+		return dockerService.ImageCommand(ctx, image)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	debug.Logf(debug.Orchestrator, "container spec %s: image %s, user %q, workdir %s", containerSpec.Name, containerSpec.Image, containerSpec.User, containerSpec.WorkDir)
+	for _, mount := range containerSpec.Mounts {
+		debug.Logf(debug.Orchestrator, "mount %s", mount)
+	}
+	for _, mount := range containerSpec.ExtraMounts {
+		debug.Logf(debug.Orchestrator, "mount %s", mount)
+	}
+	if upConfig.PoolSize > 0 {
+		if err := startPool(ctx, dockerService, upConfig, resolved, containerSpec, p); err != nil {
+			return nil, "", err
 		}
+		p.phase(PhaseDone)
+		return resolved, "", nil
 	}
 
-	// Create container blueprint with internal mount construction
-	blueprint := core.NewContainerBlueprint(resolved, upConfig.DiscoveryMode, upConfig.DockerHostIntegration, corePortMappings)
-	containerSpec := blueprint.ToContainerSpec()
+	// A container reactor did not create may hold the name; never reuse it
+	existingContainer, err := dockerService.ContainerExists(ctx, containerSpec.Name)
+	if err == nil {
+		conflict, err := foreignNameHolder(ctx, dockerService, containerSpec.Name, existingContainer)
+		if err != nil {
+			return nil, "", err
+		}
+		if conflict != nil {
+			if err := replaceNameHolder(ctx, dockerService, upConfig, conflict, p); err != nil {
+				return nil, "", err
+			}
+			existingContainer = docker.ContainerInfo{Status: docker.StatusNotFound}
+		}
+	}
 
-	// Apply workspace labels if provided
-	if len(upConfig.Labels) > 0 {
-		if containerSpec.Labels == nil {
-			containerSpec.Labels = make(map[string]string)
+	// An existing container keeps its original mounts, so refuse to silently switch modes
+	wasRunning := err == nil && existingContainer.Status == docker.StatusRunning
+	if err == nil {
+		debug.Logf(debug.Orchestrator, "existing container %s: %s", containerSpec.Name, existingContainer.Status)
+	}
+	persisted, hasPorts := persistedPorts(existingContainer.Labels, p)
+	if err == nil && existingContainer.Status != docker.StatusNotFound && !upConfig.DiscoveryMode {
+		wasReadOnly := existingContainer.Labels[ReadOnlyWorkspaceLabel] == "true"
+		if wasReadOnly != upConfig.ReadOnlyWorkspace || existingContainer.Labels[CloneRepoLabel] != upConfig.CloneRepo {
+			return nil, "", fmt.Errorf("existing container %s was created with a different workspace mode; run 'reactor down' first to recreate it", containerSpec.Name)
 		}
-		for k, v := range upConfig.Labels {
-			containerSpec.Labels[k] = v
+		if scope, ok := existingContainer.Labels[CredentialScopeLabel]; ok && scope != resolved.CredentialScope {
+			return nil, "", fmt.Errorf("existing container %s mounts %s-scoped credentials; run 'reactor down' first to switch to the %s credential scope", containerSpec.Name, scope, resolved.CredentialScope)
+		}
+		if mode := containerCredentialEncryption(existingContainer.Labels); mode != credentialEncryptionMode(resolved) {
+			return nil, "", fmt.Errorf("existing container %s was created with credential encryption %s; run 'reactor down' first to switch to %s", containerSpec.Name, mode, credentialEncryptionMode(resolved))
+		}
+		if name := existingContainer.Labels[ConfigNameLabel]; name != resolved.ConfigName {
+			return nil, "", fmt.Errorf("existing container %s was created from %s; run 'reactor down' first, or use --name to run %s alongside it", containerSpec.Name, describeConfigName(name), describeConfigName(resolved.ConfigName))
+		}
+
+		// Nor does it pick up later edits to devcontainer.json, so report them or recreate it
+		drift := checkDrift(ctx, dockerService, existingContainer, containerSpec, p)
+		if upConfig.RecreateOnDrift && hasPorts && !samePortMappings(persisted, finalPorts) {
+			drift = append(drift, fmt.Sprintf("ports are %s, configured %s", describePorts(persisted), describePorts(finalPorts)))
+		}
+		if len(drift) > 0 && upConfig.RecreateOnDrift {
+			p.send(PhaseContainer, LevelInfo, fmt.Sprintf("Recreating container %s, its configuration has changed:", containerSpec.Name), drift)
+			if resolved.Build != nil {
+				if actual, err := dockerService.InspectContainerConfig(ctx, existingContainer.ID); err == nil && actual.ImageID != "" {
+					replacedImageIDs = append(replacedImageIDs, actual.ImageID)
+				}
+			}
+			if err := removeDriftedContainer(ctx, dockerService, existingContainer); err != nil {
+				return nil, "", err
+			}
+			existingContainer = docker.ContainerInfo{Status: docker.StatusNotFound}
+			wasRunning = false
+		} else if len(drift) > 0 {
+			p.send(PhaseContainer, LevelWarning, fmt.Sprintf("existing container %s does not match the current configuration; run 'reactor up --recreate-on-drift' (or 'reactor down' first) to apply the changes:", containerSpec.Name), drift)
+		}
+	}
+	if err == nil && existingContainer.Status != docker.StatusNotFound && !upConfig.DiscoveryMode {
+		// It also keeps publishing the ports it was created with, so report those
+		if hasPorts && !samePortMappings(persisted, finalPorts) {
+			if len(cliPorts) > 0 && upConfig.ProxiedHostPorts == nil {
+				return nil, "", fmt.Errorf("existing container %s publishes ports %s; run 'reactor down' first to change its port mappings", containerSpec.Name, describePorts(persisted))
+			}
+			p.info(PhaseContainer, "Reusing port mappings of existing container: %s", describePorts(persisted))
+			finalPorts = persisted
+			setContainerPorts(containerSpec, finalPorts)
 		}
 	}
 
-	// Apply name prefix if provided
-	if upConfig.NamePrefix != "" {
-		containerSpec.Name = upConfig.NamePrefix + containerSpec.Name
+	// Docker only reports a taken host port when the container starts, so check up front
+	// unless the container is already running and holding its ports
+	if len(finalPorts) > 0 && (err != nil || existingContainer.Status != docker.StatusRunning) {
+		if err := checkHostPortsAvailable(finalPorts); err != nil {
+			return nil, "", err
+		}
 	}
 
 	// Enhanced verbose output showing container naming and discovery
 	if upConfig.Verbose {
-		fmt.Printf("[INFO] Project: %s (%s)\n", filepath.Base(resolved.ProjectRoot), resolved.ProjectRoot)
-		fmt.Printf("[INFO] Container name: %s\n", containerSpec.Name)
+		p.detail(PhaseContainer, "Project: %s (%s)", filepath.Base(resolved.ProjectRoot), resolved.ProjectRoot)
+		p.detail(PhaseContainer, "Container name: %s", containerSpec.Name)
 		if upConfig.DiscoveryMode {
-			fmt.Printf("[INFO] Discovery mode: no mounts will be created\n")
+			p.detail(PhaseContainer, "Discovery mode: no mounts will be created")
 		}
 		if upConfig.DockerHostIntegration {
-			fmt.Printf("[INFO] Docker host integration: Docker socket will be mounted\n")
+			p.detail(PhaseContainer, "Docker host integration: Docker socket will be mounted")
+		}
+		if upConfig.ReadOnlyWorkspace {
+			upperDir, _ := overlay.Dirs(core.SessionConfigDir(resolved.ProjectConfigDir, upConfig.SessionName))
+			p.detail(PhaseContainer, "Read-only workspace: changes are captured in %s", upperDir)
 		}
 		if len(finalPorts) > 0 {
-			fmt.Printf("[INFO] Port forwarding: ")
-			for i, pm := range finalPorts {
-				if i > 0 {
-					fmt.Printf(", ")
-				}
-				fmt.Printf("%d->%d", pm.HostPort, pm.ContainerPort)
-			}
-			fmt.Printf("\n")
+			p.detail(PhaseContainer, "Port forwarding: %s", describePorts(finalPorts))
 		}
 	}
 
@@ -234,66 +583,380 @@ func Up(ctx context.Context, upConfig UpConfig) (*config.ResolvedConfig, string,
 		if err == nil {
 			switch existingContainer.Status {
 			case docker.StatusRunning:
-				fmt.Printf("[INFO] Found existing container: running\n")
+				p.detail(PhaseContainer, "Found existing container: running")
 			case docker.StatusStopped:
-				fmt.Printf("[INFO] Found existing container: stopped (will be restarted)\n")
+				p.detail(PhaseContainer, "Found existing container: stopped (will be restarted)")
 			case docker.StatusNotFound:
-				fmt.Printf("[INFO] No existing container found (will create new one)\n")
+				p.detail(PhaseContainer, "No existing container found (will create new one)")
 			}
 		}
 	}
 
+	if err := prepareHostDirs(upConfig, resolved); err != nil {
+		return nil, "", err
+	}
+	// Only once the existing container passed its checks, so a refused 'reactor up'
+	// leaves no volume behind
+	if overlayVolume != "" {
+		if err := createOverlayVolume(ctx, dockerService, resolved, core.SessionConfigDir(resolved.ProjectConfigDir, upConfig.SessionName), overlayVolume); err != nil {
+			return nil, "", err
+		}
+	}
+
 	// Provision container using recovery strategy (with cleanup for discovery mode)
 	var containerInfo docker.ContainerInfo
 	if upConfig.DiscoveryMode {
 		// In discovery mode, check if we need to clean up existing container
 		existingContainer, checkErr := dockerService.ContainerExists(ctx, containerSpec.Name)
 		if checkErr == nil && existingContainer.Status != docker.StatusNotFound {
-			fmt.Printf("Discovery mode: removing existing container for clean environment\n")
+			p.info(PhaseContainer, "Discovery mode: removing existing container for clean environment")
+		}
+		if err := confirmPrivileges(upConfig, resolved); err != nil {
+			return nil, "", err
 		}
 		containerInfo, err = dockerService.ProvisionContainerWithCleanup(ctx, containerSpec, true)
 	} else {
+		// A container that already exists was confirmed when it was created
+		if existingContainer.Status != docker.StatusRunning && existingContainer.Status != docker.StatusStopped {
+			if err := confirmPrivileges(upConfig, resolved); err != nil {
+				return nil, "", err
+			}
+			// An idle container of a warm pool becomes the project's container
+			claimPoolContainer(ctx, dockerService, upConfig, containerSpec, p)
+		}
 		containerInfo, err = dockerService.ProvisionContainer(ctx, containerSpec)
 	}
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to provision container: %w", err)
+		return nil, "", fmt.Errorf("failed to provision container: %w", nameConflictError(err, upConfig.Service))
 	}
 
-	fmt.Printf("Container provisioned: %s\n", containerInfo.Name)
+	p.info(PhaseContainer, "Container provisioned: %s", containerInfo.Name)
 	if upConfig.Verbose {
-		fmt.Printf("Container ID: %s\n", containerInfo.ID)
-		fmt.Printf("Status: %s\n", containerInfo.Status)
+		p.detail(PhaseContainer, "Container ID: %s", containerInfo.ID)
+		p.detail(PhaseContainer, "Status: %s", containerInfo.Status)
 	}
+	removeReplacedImages(ctx, dockerService, finalImageName, replacedImageIDs, p)
 
-	// Execute postCreateCommand if specified
-	if resolved.PostCreateCommand != nil {
-		if upConfig.Verbose {
-			fmt.Printf("[INFO] Executing postCreateCommand...\n")
-		} else {
-			fmt.Printf("Running postCreateCommand...\n")
+	// A started container has empty tmpfs mounts, so decrypt the credentials into them
+	p.phase(PhaseSetup)
+	if upConfig.usesAccountState() && credentialsEncrypted(resolved) && !wasRunning {
+		if err := restoreCredentials(ctx, dockerService, containerInfo.ID, resolved, p); err != nil {
+			return nil, "", fmt.Errorf("failed to restore encrypted credentials: %w", err)
 		}
+	}
 
-		if err := dockerService.ExecutePostCreateCommand(ctx, containerInfo.ID, resolved.PostCreateCommand); err != nil {
-			return nil, "", fmt.Errorf("postCreateCommand execution failed: %w", err)
+	// Agents fail on first run when their config directories are not writable
+	if upConfig.usesAccountState() {
+		checkMountPermissions(ctx, dockerService, containerInfo.ID, workspaceMountTarget, resolved.ShellHistory, upConfig.FixPermissions, upConfig.Verbose, p)
+	}
+	if upConfig.usesAccountState() && upConfig.CloneRepo == "" {
+		fixMaskOwnership(ctx, dockerService, containerInfo.ID, resolved, p)
+	}
+
+	// Record the steps below so 'reactor sessions attach' waits until they are done; an
+	// ephemeral container leaves no state behind
+	track := &provisioning{name: containerInfo.Name, id: containerInfo.ID, off: upConfig.Ephemeral}
+	defer track.finish()
+
+	// Install customizations.reactor.tools before postCreateCommand, which may use them
+	created := upConfig.DiscoveryMode || (existingContainer.Status != docker.StatusRunning && existingContainer.Status != docker.StatusStopped)
+	appliedTools := appliedLifecycleHashes(resolved.ProjectConfigDir, containerInfo.ID)
+	if !upConfig.DiscoveryMode && toolsDue(resolved.Tools, existingContainer.Labels, appliedTools, created) {
+		track.step(ProvisioningTools)
+		installed := installTools(ctx, dockerService, containerInfo.ID, resolved.Tools, upConfig.Verbose, p)
+		// The label only says what a new container was meant to get; record what it got
+		if !upConfig.Ephemeral && (!created || !installed) {
+			recordAppliedTools(resolved.ProjectConfigDir, containerInfo.ID, resolved.Tools, installed, p)
 		}
+	}
+	if upConfig.CloneRepo != "" {
+		track.step(ProvisioningClone)
+		if err := cloneRepository(ctx, dockerService, containerInfo.ID, upConfig.CloneRepo, CloneVolumeName(containerInfo.Name), p); err != nil {
+			return nil, "", err
+		}
+	}
 
-		if upConfig.Verbose {
-			fmt.Printf("[INFO] postCreateCommand completed successfully\n")
-		} else {
-			fmt.Printf("postCreateCommand completed.\n")
+	// Published ports live on a remote daemon's host, so tunnel them back to localhost.
+	// The tunnel keeps its state in the session's config directory, so not for ephemeral
+	// containers.
+	if !upConfig.Ephemeral {
+		if err := startPortTunnel(upConfig.DockerHost, core.SessionConfigDir(resolved.ProjectConfigDir, upConfig.SessionName), finalPorts, p); err != nil {
+			p.warn(PhaseSetup, "%v", err)
 		}
 	}
+	resolved.ForwardPorts = configPortMappings(finalPorts)
+
+	// Run postCreateCommand in a new container and postStartCommand whenever it starts
+	if err := runLifecycleCommands(ctx, dockerService, upConfig, resolved, containerInfo.ID, existingContainer.Labels, created, !wasRunning, track, p); err != nil {
+		return nil, "", err
+	}
 
+	_ = hooks.Fire(ctx, upHookPayload(hooks.PostUp, upConfig, resolved, containerInfo.ID))
+	p.phase(PhaseDone)
 	return resolved, containerInfo.ID, nil
 }
 
+// upHookPayload describes an up event to hooks
+func upHookPayload(event hooks.Event, upConfig UpConfig, resolved *config.ResolvedConfig, containerID string) hooks.Payload {
+	return hooks.Payload{
+		Event:         event,
+		ProjectRoot:   resolved.ProjectRoot,
+		Account:       resolved.Account,
+		ContainerName: upContainerName(upConfig, resolved),
+		ContainerID:   containerID,
+		Session:       upConfig.SessionName,
+	}
+}
+
+// addMounts adds mounts to the resolved customizations.reactor.mounts, which must not
+// use the same targets
+func addMounts(resolved *config.ResolvedConfig, mounts []config.Mount) error {
+	for _, m := range mounts {
+		for _, existing := range resolved.Mounts {
+			if existing.Target == m.Target {
+				return fmt.Errorf("cannot mount %s at %s, which customizations.reactor.mounts already uses", m.Source, m.Target)
+			}
+		}
+		resolved.Mounts = append(resolved.Mounts, m)
+	}
+	return nil
+}
+
+// upContainerName returns the name of the container Up creates for a project
+func upContainerName(upConfig UpConfig, resolved *config.ResolvedConfig) string {
+	if upConfig.Ephemeral {
+		return upConfig.NamePrefix + core.GenerateEphemeralContainerName(resolved.ProjectRoot, resolved.ProjectHash, upConfig.ephemeralID)
+	}
+	return upConfig.NamePrefix + core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), upConfig.SessionName)
+}
+
+// newContainerSpec builds the container Up creates from the resolved configuration without
+// calling Docker. imageCommand looks up the image's own command for an injected entrypoint
+// and may be nil when the image is not available; the name of the read-only workspace
+// overlay volume, if any, is returned with the spec.
+func newContainerSpec(upConfig UpConfig, resolved *config.ResolvedConfig, environment []config.EnvVar, finalPorts []PortMapping, imageCommand func(image string) ([]string, error)) (*docker.ContainerSpec, string, error) {
+	// Convert final merged port mappings to core format
+	corePortMappings := make([]core.PortMapping, len(finalPorts))
+	for i, pm := range finalPorts {
+		corePortMappings[i] = core.PortMapping{
+			HostPort:      pm.HostPort,
+			ContainerPort: pm.ContainerPort,
+		}
+	}
+
+	// Create container blueprint with internal mount construction. An ephemeral container
+	// is set up like discovery mode but keeps the workspace.
+	blueprint := core.NewContainerBlueprint(resolved, !upConfig.usesAccountState(), upConfig.DockerHostIntegration, corePortMappings)
+	blueprint.Name = core.SessionContainerName(blueprint.Name, upConfig.SessionName)
+	if upConfig.Ephemeral {
+		blueprint.Name = core.GenerateEphemeralContainerName(resolved.ProjectRoot, resolved.ProjectHash, upConfig.ephemeralID)
+		blueprint.MountWorkspace(resolved.ProjectRoot)
+	}
+	blueprint.Environment = append(blueprint.Environment, config.EnvironmentList(environment)...)
+
+	if upConfig.WorkDir != "" {
+		blueprint.WorkDir = upConfig.WorkDir
+	}
+
+	// A workspace command wins; otherwise an injected entrypoint replaces the image's,
+	// so hand it the image's own command to exec
+	if upConfig.Command != "" {
+		blueprint.Command = []string{"/bin/sh", "-c", upConfig.Command}
+	} else if resolved.UseImageCommand && len(blueprint.Entrypoint) > 0 && imageCommand != nil {
+		command, err := imageCommand(resolved.Image)
+		if err != nil {
+			return nil, "", err
+		}
+		blueprint.Command = command
+	}
+
+	// Encrypted credentials are decrypted into memory instead of bind mounted
+	if upConfig.usesAccountState() && credentialsEncrypted(resolved) {
+		blueprint.UseTmpfsCredentials()
+	}
+
+	// Swap the workspace bind mount for an overlay volume in read-only workspace mode
+	overlayVolume := ""
+	if upConfig.ReadOnlyWorkspace {
+		overlayVolume = overlay.VolumeName(upConfig.NamePrefix + blueprint.Name)
+		blueprint.UseOverlayWorkspace(overlayVolume)
+	}
+	// Or for the volume the repository is cloned into. git clones into an empty
+	// directory only, so the masked paths' volumes are not mounted inside it.
+	if upConfig.CloneRepo != "" {
+		blueprint.UseVolumeWorkspace(CloneVolumeName(upConfig.NamePrefix + blueprint.Name))
+		masks := core.MaskMounts(resolved)
+		blueprint.ExtraMounts = slices.DeleteFunc(blueprint.ExtraMounts, func(m docker.Mount) bool {
+			return slices.Contains(masks, m)
+		})
+	}
+
+	containerSpec := blueprint.ToContainerSpec()
+
+	// Apply workspace labels if provided
+	if len(upConfig.Labels) > 0 {
+		if containerSpec.Labels == nil {
+			containerSpec.Labels = make(map[string]string)
+		}
+		for k, v := range upConfig.Labels {
+			containerSpec.Labels[k] = v
+		}
+	}
+
+	if containerSpec.Labels == nil {
+		containerSpec.Labels = make(map[string]string)
+	}
+	containerSpec.Labels[ProjectLabel] = resolved.ProjectRoot
+	containerSpec.Labels[AccountLabel] = resolved.Account
+	containerSpec.Platform = upConfig.Platform
+	setLifecycleLabels(containerSpec, resolved)
+	setToolsLabel(containerSpec, resolved)
+	if upConfig.CloneRepo != "" {
+		containerSpec.Labels[CloneRepoLabel] = upConfig.CloneRepo
+	}
+	if upConfig.Ephemeral {
+		containerSpec.Labels[EphemeralLabel] = "true"
+	}
+
+	// Apply name prefix if provided
+	if upConfig.NamePrefix != "" {
+		containerSpec.Name = upConfig.NamePrefix + containerSpec.Name
+	}
+
+	// Keep the container's output on the host once the container is removed
+	if upConfig.usesAccountState() {
+		containerSpec.LogDir = config.ContainerLogDir(resolved.ProjectConfigDir, containerSpec.Name)
+	}
+
+	// Record the effective ports so a reused container reports what it publishes
+	if upConfig.usesAccountState() {
+		setContainerPorts(containerSpec, finalPorts)
+		if resolved.CredentialScope != "" {
+			containerSpec.Labels[CredentialScopeLabel] = resolved.CredentialScope
+		}
+		if credentialsEncrypted(resolved) {
+			setCredentialLabels(containerSpec, resolved)
+		}
+	}
+
+	if overlayVolume != "" || upConfig.SessionName != "" || resolved.ConfigName != "" {
+		if containerSpec.Labels == nil {
+			containerSpec.Labels = make(map[string]string)
+		}
+		if overlayVolume != "" {
+			containerSpec.Labels[ReadOnlyWorkspaceLabel] = "true"
+		}
+		if upConfig.SessionName != "" {
+			containerSpec.Labels[core.SessionLabel] = upConfig.SessionName
+		}
+		if resolved.ConfigName != "" {
+			containerSpec.Labels[ConfigNameLabel] = resolved.ConfigName
+		}
+	}
+
+	return containerSpec, overlayVolume, nil
+}
+
+// upViaDevcontainerCLI is the --use-devcontainer-cli path of Up: the CLI builds the image,
+// creates the container and runs lifecycle commands, reactor supplies naming and mounts
+func upViaDevcontainerCLI(ctx context.Context, dockerService *docker.Service, upConfig UpConfig, resolved *config.ResolvedConfig, environment []config.EnvVar, p progress) (string, error) {
+	containerName := upConfig.NamePrefix + core.SessionContainerName(core.GenerateContainerName(resolved.Account, resolved.ProjectRoot, resolved.ProjectHash), upConfig.SessionName)
+
+	configPath, err := filepath.Abs(resolved.ConfigPath)
+	if err != nil {
+		return "", err
+	}
+	// The CLI's --mount takes bind and volume mounts without options
+	if len(resolved.Mounts) > 0 {
+		return "", fmt.Errorf("--use-devcontainer-cli cannot be used with customizations.reactor.mounts; use the devcontainer.json \"mounts\" property instead")
+	}
+	if len(resolved.MaskPaths) > 0 {
+		return "", fmt.Errorf("--use-devcontainer-cli cannot be used with customizations.reactor.maskPaths; use volume entries in the devcontainer.json \"mounts\" property instead")
+	}
+	// Nor does it have options for the container's DNS configuration. The extraHosts
+	// setting is checked rather than resolved.ExtraHosts, which may hold the entry added
+	// for a proxy on the host's loopback address.
+	if len(resolved.DNS) > 0 || len(resolved.DNSSearch) > 0 || len(resolved.Settings.List(config.SettingExtraHosts)) > 0 {
+		return "", fmt.Errorf("--use-devcontainer-cli cannot be used with the dns, dnsSearch or extraHosts settings; use \"runArgs\" (--dns, --dns-search, --add-host) in devcontainer.json instead")
+	}
+	mounts, err := devcontainerCLIMounts(resolved, upConfig.DockerHostIntegration)
+	if err != nil {
+		return "", err
+	}
+
+	labels := make(map[string]string, len(upConfig.Labels)+1)
+	for k, v := range upConfig.Labels {
+		labels[k] = v
+	}
+	if upConfig.SessionName != "" {
+		labels[core.SessionLabel] = upConfig.SessionName
+	}
+	if resolved.ConfigName != "" {
+		labels[ConfigNameLabel] = resolved.ConfigName
+	}
+
+	// containerEnv is applied by the CLI from devcontainer.json, so only pass env files and -e overrides
+	var remoteEnv []string
+	for _, v := range environment {
+		if v.Source != config.EnvSourceContainerEnv {
+			remoteEnv = append(remoteEnv, v.Name+"="+v.Value)
+		}
+	}
+	if resolved.ShellHistory {
+		remoteEnv = append(remoteEnv, core.HistoryEnvironment()...)
+	}
+
+	if len(resolved.ForwardPorts) > 0 {
+		p.warn(PhaseConfig, "forwardPorts are not published in devcontainer CLI mode; use appPort in devcontainer.json instead")
+	}
+	p.phase(PhaseContainer)
+	// The CLI applies privileged, capAdd and securityOpt from devcontainer.json itself
+	if existing, err := dockerService.ContainerExists(ctx, containerName); err != nil || existing.Status == docker.StatusNotFound {
+		if err := confirmPrivileges(upConfig, resolved); err != nil {
+			return "", err
+		}
+	}
+	if upConfig.Verbose {
+		p.detail(PhaseContainer, "Provisioning with the devcontainer CLI")
+		p.detail(PhaseContainer, "Container name: %s", containerName)
+	}
+
+	containerID, workspaceFolder, err := upWithDevcontainerCLI(ctx, dockerService, containerName, devcontainerCLIOptions{
+		ProjectRoot:  resolved.ProjectRoot,
+		ConfigPath:   configPath,
+		Labels:       labels,
+		Mounts:       mounts,
+		RemoteEnv:    remoteEnv,
+		ForceRebuild: upConfig.ForceRebuild,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p.info(PhaseContainer, "Container provisioned: %s", containerName)
+	p.phase(PhaseSetup)
+	// The CLI mounts the project where devcontainer.json's workspaceFolder says
+	checkMountPermissions(ctx, dockerService, containerID, workspaceFolder, resolved.ShellHistory, upConfig.FixPermissions, upConfig.Verbose, p)
+	return containerID, nil
+}
+
 // Down orchestrates the 'reactor down' logic for a single service.
-func Down(ctx context.Context, projectDirectory string) error {
+// An empty sessionName targets the project's default container. Progress is sent to
+// events, which may be nil.
+func Down(ctx context.Context, projectDirectory, sessionName string, events EventFunc) error {
+	p := progress{events: events}
+
 	// Check dependencies first
 	if err := config.CheckDependencies(); err != nil {
 		return err
 	}
 
+	if sessionName != "" {
+		if err := core.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+	}
+
 	// Load configuration to get container information
 	// First change to the project directory to ensure relative paths work correctly
 	originalWD, err := os.Getwd()
@@ -306,6 +969,7 @@ func Down(ctx context.Context, projectDirectory string) error {
 		return fmt.Errorf("failed to change to project directory %s: %w", projectDirectory, err)
 	}
 
+	p.phase(PhaseConfig)
 	configService := config.NewService()
 	resolved, err := configService.ResolveConfiguration()
 	if err != nil {
@@ -319,7 +983,7 @@ func Down(ctx context.Context, projectDirectory string) error {
 	}
 	defer func() {
 		if err := dockerService.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to close Docker service: %v\n", err)
+			p.warn(PhaseDone, "failed to close Docker service: %v", err)
 		}
 	}()
 
@@ -330,29 +994,188 @@ func Down(ctx context.Context, projectDirectory string) error {
 
 	// Create a basic container blueprint to get the expected container name
 	blueprint := core.NewContainerBlueprint(resolved, false, false, nil)
+	blueprint.Name = core.SessionContainerName(blueprint.Name, sessionName)
 	containerSpec := blueprint.ToContainerSpec()
 
 	// Check if container exists
+	p.phase(PhaseContainer)
 	containerInfo, err := dockerService.ContainerExists(ctx, containerSpec.Name)
 	if err != nil {
 		return fmt.Errorf("failed to check container existence: %w", err)
 	}
 
 	if containerInfo.Status == docker.StatusNotFound {
-		fmt.Printf("No container found for project: %s\n", containerSpec.Name)
+		p.info(PhaseDone, "No container found for project: %s", containerSpec.Name)
 		return nil
 	}
 
+	downPayload := func(event hooks.Event) hooks.Payload {
+		return hooks.Payload{
+			Event:         event,
+			ProjectRoot:   resolved.ProjectRoot,
+			Account:       resolved.Account,
+			ContainerName: containerInfo.Name,
+			ContainerID:   containerInfo.ID,
+			Session:       sessionName,
+		}
+	}
+	if err := hooks.Fire(ctx, downPayload(hooks.PreDown)); err != nil {
+		return err
+	}
+
+	// Keep the container running if its credentials cannot be saved, so nothing is lost
+	if err := SaveCredentials(ctx, dockerService, containerInfo); err != nil {
+		return fmt.Errorf("failed to save encrypted credentials, container %s was left running: %w", containerInfo.Name, err)
+	}
+
 	// Stop and remove the container
-	fmt.Printf("Stopping and removing container: %s\n", containerInfo.Name)
+	p.info(PhaseContainer, "Stopping and removing container: %s", containerInfo.Name)
 	if err := dockerService.RemoveContainer(ctx, containerInfo.ID); err != nil {
 		return fmt.Errorf("failed to remove container: %w", err)
 	}
 
-	fmt.Printf("Container removed successfully.\n")
+	sessionDir := core.SessionConfigDir(resolved.ProjectConfigDir, sessionName)
+	if err := tunnel.Stop(sessionDir); err != nil {
+		p.warn(PhaseContainer, "%v", err)
+	}
+	if err := portforward.StopAll(sessionDir); err != nil {
+		p.warn(PhaseContainer, "%v", err)
+	}
+
+	// Remove the overlay volume if one was used; the upper directory stays on the host
+	// so changes can still be reviewed with 'reactor diff --workspace'
+	if containerInfo.Labels[ReadOnlyWorkspaceLabel] == "true" {
+		if err := dockerService.RemoveVolume(ctx, overlay.VolumeName(containerSpec.Name)); err != nil {
+			p.warn(PhaseContainer, "%v", err)
+		}
+	}
+	// The clone may hold work not yet pushed, so its volume is kept for the next 'reactor up'
+	if repo := containerInfo.Labels[CloneRepoLabel]; repo != "" {
+		p.info(PhaseContainer, "The clone of %s is kept in volume %s; remove it with 'docker volume rm %s'", repo, CloneVolumeName(containerSpec.Name), CloneVolumeName(containerSpec.Name))
+	}
+
+	_ = hooks.Fire(ctx, downPayload(hooks.PostDown))
+	p.info(PhaseDone, "Container removed successfully.")
+	return nil
+}
+
+// confirmPrivileges asks before a container is created with the elevated privileges
+// devcontainer.json requests, unless AllowPrivileged is set
+func confirmPrivileges(upConfig UpConfig, resolved *config.ResolvedConfig) error {
+	requested := resolved.ElevatedPrivileges()
+	if len(requested) == 0 || upConfig.AllowPrivileged {
+		return nil
+	}
+	if upConfig.ConfirmPrivileges == nil {
+		return fmt.Errorf("%s requests elevated privileges (%s); pass --allow-privileged to create the container with them", resolved.ConfigPath, strings.Join(requested, ", "))
+	}
+	confirmed, err := upConfig.ConfirmPrivileges(requested)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("container not created: elevated privileges were not confirmed")
+	}
+	return nil
+}
+
+// describeConfigName names a configuration in messages
+func describeConfigName(name string) string {
+	if name == "" {
+		return "the default devcontainer.json"
+	}
+	return fmt.Sprintf("configuration '%s'", name)
+}
+
+// settingOverrides maps command-line options onto the settings they override
+func settingOverrides(upConfig UpConfig) map[string]string {
+	overrides := make(map[string]string)
+	if upConfig.AccountOverride != "" {
+		overrides[config.SettingAccount] = upConfig.AccountOverride
+	}
+	if upConfig.DisableInit {
+		overrides[config.SettingInit] = "false"
+	}
+	return overrides
+}
+
+// startPortTunnel forwards the published host ports over SSH when the Docker daemon is
+// remote; dockerHost is the daemon the container runs on, empty for DOCKER_HOST
+func startPortTunnel(dockerHost, projectConfigDir string, ports []PortMapping, p progress) error {
+	if dockerHost == "" {
+		dockerHost = os.Getenv("DOCKER_HOST")
+	}
+	remote, isRemote, err := tunnel.DetectRemote(dockerHost)
+	if err != nil || !isRemote || len(ports) == 0 {
+		return err
+	}
+
+	hostPorts := make([]int, len(ports))
+	for i, pm := range ports {
+		hostPorts[i] = pm.HostPort
+	}
+
+	if err := tunnel.Start(projectConfigDir, remote, hostPorts); err != nil {
+		return err
+	}
+
+	forwards := make([]string, len(hostPorts))
+	for i, port := range hostPorts {
+		forwards[i] = fmt.Sprintf("localhost:%d -> %s:%d", port, remote.Target, port)
+	}
+	p.send(PhaseSetup, LevelInfo, fmt.Sprintf("Port forwarding via SSH tunnel to %s:", remote.Target), forwards)
+	return nil
+}
+
+// checkOverlayHost refuses a read-only workspace where the overlay volume cannot be
+// mounted: its layers are host paths under ~/.reactor, which a remote daemon does not
+// have, and Docker Desktop shares them over virtiofs, which overlayfs rejects.
+// dockerHost is the daemon the container runs on, empty for DOCKER_HOST.
+func checkOverlayHost(dockerHost string) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("--read-only-workspace needs the Docker daemon to run on this machine, which is only the case on Linux; use --clone-repo to keep the agent's changes away from the project instead")
+	}
+	if dockerHost == "" {
+		dockerHost = os.Getenv("DOCKER_HOST")
+	}
+	if _, isRemote, err := tunnel.DetectRemote(dockerHost); err != nil {
+		return err
+	} else if isRemote {
+		return fmt.Errorf("--read-only-workspace cannot be used with the remote Docker daemon %s, which does not have the overlay's directories; use --clone-repo to keep the agent's changes away from the project instead", dockerHost)
+	}
 	return nil
 }
 
+// checkRemoteBindMounts refuses to create a container on a remote DockerHost, whose
+// bind mounts of the project and of ~/.reactor name paths on this machine: Docker would
+// create them on the remote machine as empty directories owned by root, leaving the
+// agent without its workspace or credentials
+func checkRemoteBindMounts(upConfig UpConfig) error {
+	if upConfig.DockerHost == "" || upConfig.SharedHostPaths {
+		return nil
+	}
+	if _, isRemote, err := tunnel.DetectRemote(upConfig.DockerHost); err != nil || !isRemote {
+		return err
+	}
+	return fmt.Errorf("the remote Docker daemon %s cannot bind mount the project and ~/.reactor from this machine; set shared_paths: true on the service when they exist at the same paths there, e.g. on a shared home directory", upConfig.DockerHost)
+}
+
+// createOverlayVolume prepares the overlay directories for a read-only workspace in the
+// session's config directory and creates the volume that layers them over the host project.
+func createOverlayVolume(ctx context.Context, dockerService *docker.Service, resolved *config.ResolvedConfig, sessionDir, volumeName string) error {
+	upperDir, workDir, err := overlay.Prepare(sessionDir)
+	if err != nil {
+		return err
+	}
+
+	mountOptions, err := overlay.MountOptions(resolved.ProjectRoot, upperDir, workDir)
+	if err != nil {
+		return err
+	}
+
+	return dockerService.CreateOverlayVolume(ctx, volumeName, mountOptions)
+}
+
 // parsePortMappings parses and validates port mapping strings in the format "host:container"
 func parsePortMappings(portStrings []string) ([]PortMapping, error) {
 	var mappings []PortMapping
@@ -390,33 +1213,6 @@ func parsePortMappings(portStrings []string) ([]PortMapping, error) {
 	return mappings, nil
 }
 
-// checkPortConflicts checks if any of the host ports are already in use
-func checkPortConflicts(mappings []PortMapping) []int {
-	var conflictPorts []int
-
-	for _, pm := range mappings {
-		// Try to listen on the host port briefly to check if it's available
-		addr := fmt.Sprintf(":%d", pm.HostPort)
-		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
-		if err == nil {
-			// Port is open (something is listening)
-			_ = conn.Close()
-			conflictPorts = append(conflictPorts, pm.HostPort)
-		} else {
-			// Try to bind to the port to see if it's available
-			listener, err := net.Listen("tcp", addr)
-			if err != nil {
-				// Port is likely in use or restricted
-				conflictPorts = append(conflictPorts, pm.HostPort)
-			} else {
-				_ = listener.Close()
-			}
-		}
-	}
-
-	return conflictPorts
-}
-
 // mergePortMappings merges devcontainer.json ports with CLI ports
 // CLI ports take precedence on host port conflicts
 func mergePortMappings(devcontainerPorts []config.PortMapping, cliPorts []PortMapping) []PortMapping {
@@ -455,23 +1251,35 @@ func mergePortMappings(devcontainerPorts []config.PortMapping, cliPorts []PortMa
 	return result
 }
 
+// removeReplacedImages removes the earlier builds of the project's image now that the
+// container no longer uses them; builds still in use elsewhere are kept
+func removeReplacedImages(ctx context.Context, dockerService *docker.Service, imageName string, replacedIDs []string, p progress) {
+	for _, id := range slices.Compact(replacedIDs) {
+		removed, err := dockerService.RemoveReplacedImage(ctx, imageName, id)
+		if err != nil {
+			p.warn(PhaseContainer, "%v", err)
+			continue
+		}
+		if removed {
+			p.info(PhaseContainer, "Removed the previous build of %s", imageName)
+		}
+	}
+}
+
+// BuildSpecFor returns the image build 'reactor up' performs for a configuration with a
+// build property, for commands that build the image without starting a container
+func BuildSpecFor(resolved *config.ResolvedConfig) (docker.BuildSpec, error) {
+	return createBuildSpecFromConfig(resolved)
+}
+
 // createBuildSpecFromConfig creates a BuildSpec from ResolvedConfig
 func createBuildSpecFromConfig(resolved *config.ResolvedConfig) (docker.BuildSpec, error) {
 	if resolved.Build == nil {
 		return docker.BuildSpec{}, fmt.Errorf("build configuration is nil")
 	}
 
-	// Find the devcontainer.json file to determine context base directory
-	configPath, found, err := config.FindDevContainerFile(resolved.ProjectRoot)
-	if err != nil {
-		return docker.BuildSpec{}, fmt.Errorf("failed to find devcontainer.json: %w", err)
-	}
-	if !found {
-		return docker.BuildSpec{}, fmt.Errorf("devcontainer.json not found")
-	}
-
-	// Get directory containing devcontainer.json
-	configDir := filepath.Dir(configPath)
+	// Resolve paths relative to the directory containing devcontainer.json
+	configDir := filepath.Dir(resolved.ConfigPath)
 
 	// Resolve build context relative to devcontainer.json directory
 	var contextPath string
@@ -498,9 +1306,23 @@ func createBuildSpecFromConfig(resolved *config.ResolvedConfig) (docker.BuildSpe
 	// Create image name using project hash
 	imageName := fmt.Sprintf("reactor-build:%s", resolved.ProjectHash)
 
+	var secrets, ssh []string
+	for _, secret := range resolved.BuildSecrets {
+		secrets = append(secrets, secret.String())
+	}
+	for _, entry := range resolved.BuildSSH {
+		ssh = append(ssh, entry.String())
+	}
+
 	return docker.BuildSpec{
 		Dockerfile: dockerfile,
 		Context:    contextPath,
 		ImageName:  imageName,
+		LogFile:    filepath.Join(resolved.ProjectConfigDir, config.BuildLogFileName),
+		BuildArgs:  config.ProxyBuildArgs(resolved.ProxyEnv),
+		ExtraHosts: config.ProxyBuildHosts(resolved.ProxyEnv),
+		Labels:     imageProvenanceLabels(resolved, time.Now()),
+		Secrets:    secrets,
+		SSH:        ssh,
 	}, nil
 }
@@ -0,0 +1,156 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/core"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// workspaceMountTarget is where reactor mounts the project directory in the container
+const workspaceMountTarget = "/workspace"
+
+// prepareHostDirs creates the host directories the container bind mounts before it is
+// created
+func prepareHostDirs(upConfig UpConfig, resolved *config.ResolvedConfig) error {
+	if upConfig.usesAccountState() && !credentialsEncrypted(resolved) {
+		if err := ensureProviderDirs(resolved); err != nil {
+			return err
+		}
+	}
+	if upConfig.usesAccountState() && resolved.ShellHistory {
+		if err := ensureHistoryDir(resolved); err != nil {
+			return err
+		}
+	}
+	// Only containers with account state mount the masked paths' volumes
+	if upConfig.usesAccountState() && !upConfig.ReadOnlyWorkspace && upConfig.CloneRepo == "" {
+		if err := ensureMaskDirs(resolved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureProviderDirs creates the account's provider directories before they are mounted.
+// Docker creates missing bind mount sources owned by root, which the container user
+// cannot write to.
+func ensureProviderDirs(resolved *config.ResolvedConfig) error {
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			source := filepath.Join(resolved.ProviderConfigDir(), mount.Source)
+			if err := os.MkdirAll(source, 0755); err != nil {
+				return fmt.Errorf("failed to create provider directory %s: %w", source, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ensureHistoryDir creates the project's history directory before it is mounted
+func ensureHistoryDir(resolved *config.ResolvedConfig) error {
+	dir := filepath.Join(resolved.ProjectConfigDir, config.HistoryDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory %s: %w", dir, err)
+	}
+	return nil
+}
+
+// ensureMaskDirs creates the project's masked directories on the host, so Docker does
+// not create their mountpoints in the project owned by root
+func ensureMaskDirs(resolved *config.ResolvedConfig) error {
+	for _, maskPath := range resolved.MaskPaths {
+		dir := filepath.Join(resolved.ProjectRoot, filepath.FromSlash(maskPath))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create masked directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// fixMaskOwnership gives the masked paths' volumes to the container user. A new volume
+// is owned by root unless the image has the directory; the volumes belong to reactor,
+// so they are chowned without asking.
+func fixMaskOwnership(ctx context.Context, dockerService *docker.Service, containerID string, resolved *config.ResolvedConfig, p progress) {
+	if len(resolved.MaskPaths) == 0 {
+		return
+	}
+	targets := make([]string, len(resolved.MaskPaths))
+	for i, maskPath := range resolved.MaskPaths {
+		targets[i] = path.Join(workspaceMountTarget, maskPath)
+	}
+	access, err := dockerService.CheckPathAccess(ctx, containerID, targets)
+	if err != nil {
+		p.warn(PhaseSetup, "%v", err)
+		return
+	}
+	if len(access.Inaccessible) == 0 {
+		return
+	}
+	if err := dockerService.ChownPaths(ctx, containerID, access.UID, access.GID, access.Inaccessible); err != nil {
+		p.warn(PhaseSetup, "%v", err)
+		return
+	}
+	p.detail(PhaseSetup, "Masked paths %s now owned by uid %s", strings.Join(access.Inaccessible, ", "), access.UID)
+}
+
+// providerMountTargets returns the container paths the provider directories are mounted at
+func providerMountTargets() []string {
+	var targets []string
+	for _, name := range sortedProviderNames() {
+		for _, mount := range config.BuiltinProviders[name].Mounts {
+			targets = append(targets, mount.Target)
+		}
+	}
+	return targets
+}
+
+// checkMountPermissions verifies the container user can write to the provider directories,
+// the history directory and the project, mounted at projectTarget; an empty projectTarget
+// leaves the project out. With fix, the directories it cannot use are chowned to that
+// user; the project directory is only ever reported, never chowned.
+func checkMountPermissions(ctx context.Context, dockerService *docker.Service, containerID, projectTarget string, shellHistory, fix, verbose bool, p progress) {
+	targets := providerMountTargets()
+	if shellHistory {
+		targets = append(targets, core.HistoryMountPath)
+	}
+	if projectTarget != "" {
+		targets = append(targets, projectTarget)
+	}
+	access, err := dockerService.CheckPathAccess(ctx, containerID, targets)
+	if err != nil {
+		p.warn(PhaseSetup, "%v", err)
+		return
+	}
+	if verbose {
+		p.detail(PhaseSetup, "Container user: uid %s, gid %s", access.UID, access.GID)
+	}
+
+	var providerDirs []string
+	for _, path := range access.Inaccessible {
+		if path == projectTarget {
+			p.warn(PhaseSetup, "the container user (uid %s) cannot write to the project directory %s; check the ownership of the project on the host", access.UID, projectTarget)
+			continue
+		}
+		providerDirs = append(providerDirs, path)
+	}
+	if len(providerDirs) == 0 {
+		return
+	}
+
+	if !fix {
+		p.warn(PhaseSetup, "the container user (uid %s) cannot write to %s; agents may fail to save their configuration. Run 'reactor up --fix-permissions' to change their owner", access.UID, strings.Join(providerDirs, ", "))
+		return
+	}
+	if err := dockerService.ChownPaths(ctx, containerID, access.UID, access.GID, providerDirs); err != nil {
+		p.warn(PhaseSetup, "%v", err)
+		return
+	}
+	p.info(PhaseSetup, "Fixed permissions: %s now owned by uid %s", strings.Join(providerDirs, ", "), access.UID)
+}
@@ -0,0 +1,37 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureProviderDirs(t *testing.T) {
+	projectConfigDir := filepath.Join(t.TempDir(), "account", "hash")
+	require.NoError(t, ensureProviderDirs(&config.ResolvedConfig{ProjectConfigDir: projectConfigDir}))
+
+	for _, name := range []string{"claude", "gemini"} {
+		info, err := os.Stat(filepath.Join(projectConfigDir, name))
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+	}
+}
+
+func TestProviderMountTargets(t *testing.T) {
+	assert.Equal(t, []string{"/home/claude/.claude", "/home/claude/.gemini"}, providerMountTargets())
+}
+
+func TestEnsureMaskDirs(t *testing.T) {
+	projectRoot := t.TempDir()
+	require.NoError(t, ensureMaskDirs(&config.ResolvedConfig{ProjectRoot: projectRoot, MaskPaths: []string{"node_modules", "web/.next"}}))
+
+	for _, dir := range []string{"node_modules", "web/.next"} {
+		info, err := os.Stat(filepath.Join(projectRoot, dir))
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+	}
+}
@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/policy"
+)
+
+// checkPolicy refuses a container the policy file does not allow. It runs before any
+// image is pulled or built, for every engine, so a policy cannot be sidestepped with
+// --use-devcontainer-cli or a build configuration.
+func checkPolicy(upConfig UpConfig, resolved *config.ResolvedConfig, environment []config.EnvVar, finalPorts []PortMapping) error {
+	p, err := policy.Load()
+	if err != nil || p == nil {
+		return err
+	}
+	spec, _, err := newContainerSpec(upConfig, resolved, environment, finalPorts, nil)
+	if err != nil {
+		return err
+	}
+	container, err := policyContainer(upConfig, resolved, spec)
+	if err != nil {
+		return err
+	}
+	return p.Check(container)
+}
+
+// CheckContainerPolicy refuses to recreate an existing container, e.g. from a
+// checkpoint, when the policy file does not allow its configuration. image is the
+// image the container was first created from.
+func CheckContainerPolicy(cfg docker.ContainerConfig, image string) error {
+	p, err := policy.Load()
+	if err != nil || p == nil {
+		return err
+	}
+	privileges := &config.ResolvedConfig{Privileged: cfg.Privileged, CapAdd: cfg.CapAdd, SecurityOpt: cfg.SecurityOpt}
+	return p.Check(policy.Container{
+		Image:              image,
+		BindMounts:         cfg.BindSources,
+		ElevatedPrivileges: privileges.ElevatedPrivileges(),
+		SecurityOpt:        cfg.SecurityOpt,
+		NetworkMode:        cfg.NetworkMode,
+		User:               cfg.User,
+		Init:               cfg.Init,
+	})
+}
+
+// policyContainer describes the container for the policy; a built image is judged by
+// the base images of its Dockerfile
+func policyContainer(upConfig UpConfig, resolved *config.ResolvedConfig, spec *docker.ContainerSpec) (policy.Container, error) {
+	container := policy.Container{
+		Image:                 resolved.Image,
+		DockerHostIntegration: upConfig.DockerHostIntegration,
+		BindMounts:            bindMountSources(spec, resolved),
+		ElevatedPrivileges:    resolved.ElevatedPrivileges(),
+		SecurityOpt:           spec.SecurityOpt,
+		NetworkMode:           spec.NetworkMode,
+		User:                  spec.User,
+		Init:                  spec.Init,
+	}
+	if resolved.Build != nil {
+		buildSpec, err := createBuildSpecFromConfig(resolved)
+		if err != nil {
+			return policy.Container{}, err
+		}
+		dockerfile := buildSpec.Dockerfile
+		if !filepath.IsAbs(dockerfile) {
+			dockerfile = filepath.Join(buildSpec.Context, dockerfile)
+		}
+		container.Image = ""
+		if container.BaseImages, err = policy.BaseImages(dockerfile); err != nil {
+			return policy.Container{}, err
+		}
+	}
+	return container, nil
+}
+
+// bindMountSources returns the host paths the container bind mounts: those of the
+// blueprint, in "source:target[:mode]" form, and the bind mounts of reactor
+// customizations. Named volumes have no host path and are left out.
+func bindMountSources(spec *docker.ContainerSpec, resolved *config.ResolvedConfig) []string {
+	var sources []string
+	for _, mount := range spec.Mounts {
+		source, _, _ := strings.Cut(strings.Trim(mount, `"`), ":")
+		if filepath.IsAbs(source) {
+			sources = append(sources, source)
+		}
+	}
+	for _, mount := range resolved.Mounts {
+		if mount.Type == config.MountTypeBind {
+			sources = append(sources, mount.Source)
+		}
+	}
+	return sources
+}
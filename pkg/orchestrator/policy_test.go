@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	dir := t.TempDir()
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		ConfigPath:       filepath.Join(dir, "devcontainer.json"),
+		RemoteUser:       "root",
+		Privileged:       true,
+	}
+
+	// Without a policy file everything is allowed
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(policy.EnvPolicy, "")
+	assert.NoError(t, checkPolicy(UpConfig{DockerHostIntegration: true}, resolved, nil, nil))
+
+	policyFile := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("forbid:\n  dockerHostIntegration: true\n  privileged: true\nallowedRegistries: [ghcr.io/example]\nrequireHardened: true\n"), 0644))
+	t.Setenv(policy.EnvPolicy, policyFile)
+	err := checkPolicy(UpConfig{DockerHostIntegration: true, AllowPrivileged: true}, resolved, nil, nil)
+	var violation *policy.ViolationError
+	require.ErrorAs(t, err, &violation)
+	assert.Equal(t, policyFile, violation.Path)
+	assert.Contains(t, violation.Violations, "docker host integration is forbidden")
+	assert.Contains(t, violation.Violations, "elevated privileges are forbidden (privileged mode)")
+	assert.Contains(t, violation.Violations, "hardened containers require a remoteUser other than root")
+	assert.Contains(t, violation.Violations, "hardened containers require an init process (init is off)")
+
+	// A built image is judged by the base images of its Dockerfile
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM ghcr.io/example/base:1 AS base\nFROM base\n"), 0644))
+	built := *resolved
+	built.Image = ""
+	built.Build = &config.Build{Dockerfile: "Dockerfile"}
+	built.Privileged = false
+	built.RemoteUser = "dev"
+	built.Init = true
+	built.SecurityOpt = []string{"no-new-privileges"}
+	assert.NoError(t, checkPolicy(UpConfig{}, &built, nil, nil))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM ubuntu:24.04\n"), 0644))
+	assert.ErrorContains(t, checkPolicy(UpConfig{}, &built, nil, nil), "base image ubuntu:24.04 is not from an allowed registry (ghcr.io/example)")
+}
+
+func TestCheckPolicy_DockerSocketMount(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("forbid:\n  dockerHostIntegration: true\n"), 0644))
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(policy.EnvPolicy, policyFile)
+
+	resolved := &config.ResolvedConfig{
+		Account:          "work",
+		Image:            "ghcr.io/example/dev:1",
+		ProjectRoot:      "/src/app",
+		ProjectHash:      "abc123",
+		ProjectConfigDir: "/home/me/.reactor/work/abc123",
+		Mounts:           []config.Mount{{Type: config.MountTypeVolume, Source: "cache", Target: "/cache"}},
+	}
+	assert.NoError(t, checkPolicy(UpConfig{}, resolved, nil, nil))
+
+	resolved.Mounts = append(resolved.Mounts, config.Mount{Type: config.MountTypeBind, Source: "/var/run/docker.sock", Target: "/var/run/docker.sock"})
+	assert.ErrorContains(t, checkPolicy(UpConfig{}, resolved, nil, nil), "docker host integration is forbidden (bind mount of /var/run/docker.sock)")
+}
+
+func TestCheckContainerPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("forbid:\n  privileged: true\nallowedImages: ['node:*']\n"), 0644))
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(policy.EnvPolicy, policyFile)
+
+	assert.NoError(t, CheckContainerPolicy(docker.ContainerConfig{BindSources: []string{"/src/app"}}, "node:20"))
+
+	err := CheckContainerPolicy(docker.ContainerConfig{CapAdd: []string{"SYS_ADMIN"}}, "sha256:0123")
+	var violation *policy.ViolationError
+	require.ErrorAs(t, err, &violation)
+	assert.Equal(t, []string{
+		"elevated privileges are forbidden (capability SYS_ADMIN)",
+		"image sha256:0123 does not match an allowed image (node:*)",
+	}, violation.Violations)
+}
@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/pool"
+)
+
+// startPool starts a warm pool of upConfig.PoolSize idle copies of the container spec
+// describes. The spec has passed the policy check like any other.
+func startPool(ctx context.Context, dockerService *docker.Service, upConfig UpConfig, resolved *config.ResolvedConfig, spec *docker.ContainerSpec, p progress) error {
+	// Only one container can publish a host port, so idle copies could not start
+	if len(spec.PortMappings) > 0 {
+		return fmt.Errorf("a warm pool cannot be kept for %s, as it publishes ports that only one container can hold", spec.Name)
+	}
+	if err := confirmPrivileges(upConfig, resolved); err != nil {
+		return err
+	}
+	if err := prepareHostDirs(upConfig, resolved); err != nil {
+		return err
+	}
+	created, err := pool.Start(ctx, dockerService, spec, upConfig.PoolSize)
+	if err != nil {
+		return fmt.Errorf("failed to fill the warm pool of %s: %w", spec.Name, err)
+	}
+	p.info(PhaseContainer, "Warm pool of %s: %d idle container(s), %d created", spec.Name, upConfig.PoolSize, created)
+	return nil
+}
+
+// claimPoolContainer makes an idle container of the warm pool of spec, if there is
+// one, the container spec describes, and has the pool refilled
+func claimPoolContainer(ctx context.Context, dockerService *docker.Service, upConfig UpConfig, spec *docker.ContainerSpec, p progress) {
+	// A throwaway container leaves the pool alone
+	if upConfig.Ephemeral {
+		return
+	}
+	claimed, err := pool.Claim(ctx, dockerService, spec)
+	if err != nil {
+		p.warn(PhaseContainer, "cannot claim a warm pool container: %v", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	p.info(PhaseContainer, "Claimed a warm pool container")
+
+	poolSpec := *spec
+	refill := func() { refillPool(upConfig.DockerHost, &poolSpec) }
+	if upConfig.DeferPoolRefill != nil {
+		upConfig.DeferPoolRefill(refill)
+		return
+	}
+	refill()
+}
+
+// refillPool tops up the warm pool of spec over a connection of its own, as it may run
+// after Up returned. It runs alongside the session, so failures are only logged.
+func refillPool(host string, spec *docker.ContainerSpec) {
+	dockerService, err := docker.NewServiceForHost(host)
+	if err != nil {
+		debug.Logf(debug.Docker, "cannot refill the warm pool of %s: %v", spec.Name, err)
+		return
+	}
+	defer func() { _ = dockerService.Close() }()
+
+	created, err := pool.Refill(context.Background(), dockerService, spec)
+	if err != nil {
+		debug.Logf(debug.Docker, "cannot refill the warm pool of %s: %v", spec.Name, err)
+		return
+	}
+	if created > 0 {
+		debug.Logf(debug.Docker, "refilled the warm pool of %s with %d container(s)", spec.Name, created)
+	}
+}
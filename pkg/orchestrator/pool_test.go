@@ -0,0 +1,21 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUp_PoolFlagCombinations(t *testing.T) {
+	// A dry run does not need Docker, so every case is one
+	for _, upConfig := range []UpConfig{
+		{PoolSize: 2, DryRun: true, Ephemeral: true},
+		{PoolSize: 2, DryRun: true, ReadOnlyWorkspace: true},
+		{PoolSize: 2, DryRun: true, CloneRepo: "https://github.com/org/repo.git"},
+		{PoolSize: 2, DryRun: true},
+	} {
+		_, _, err := Up(context.Background(), upConfig)
+		assert.EqualError(t, err, "a warm pool cannot be used with discovery mode, --ephemeral, --read-only-workspace, --clone-repo, --use-devcontainer-cli or --dry-run")
+	}
+}
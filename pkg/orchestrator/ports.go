@@ -0,0 +1,305 @@
+package orchestrator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// PortsLabel records the host:container port mappings a container was created with, so
+// a reused container keeps reporting the ports it actually publishes
+const PortsLabel = "com.reactor.ports"
+
+// formatPortsLabel encodes port mappings as "host:container,host:container"
+func formatPortsLabel(mappings []PortMapping) string {
+	parts := make([]string, len(mappings))
+	for i, pm := range mappings {
+		parts[i] = fmt.Sprintf("%d:%d", pm.HostPort, pm.ContainerPort)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parsePortsLabel decodes the value of PortsLabel; an empty value means no ports
+func parsePortsLabel(value string) ([]PortMapping, error) {
+	if value == "" {
+		return nil, nil
+	}
+	mappings, err := parsePortMappings(strings.Split(value, ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s label %q: %w", PortsLabel, value, err)
+	}
+	return mappings, nil
+}
+
+// samePortMappings reports whether two sets of mappings publish the same ports, in any order
+func samePortMappings(a, b []PortMapping) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[PortMapping]int, len(a))
+	for _, pm := range a {
+		seen[pm]++
+	}
+	for _, pm := range b {
+		if seen[pm] == 0 {
+			return false
+		}
+		seen[pm]--
+	}
+	return true
+}
+
+// persistedPorts returns the port mappings an existing container was created with.
+// Containers created before the label was introduced report ok=false.
+func persistedPorts(labels map[string]string, p progress) ([]PortMapping, bool) {
+	value, ok := labels[PortsLabel]
+	if !ok {
+		return nil, false
+	}
+	mappings, err := parsePortsLabel(value)
+	if err != nil {
+		p.warn(PhaseContainer, "%v", err)
+		return nil, false
+	}
+	return mappings, true
+}
+
+// setContainerPorts publishes mappings from a new container and records them in its labels
+func setContainerPorts(spec *docker.ContainerSpec, mappings []PortMapping) {
+	spec.PortMappings = make([]docker.PortMapping, len(mappings))
+	for i, pm := range mappings {
+		spec.PortMappings[i] = docker.PortMapping{HostPort: pm.HostPort, ContainerPort: pm.ContainerPort}
+	}
+	if spec.Labels == nil {
+		spec.Labels = make(map[string]string)
+	}
+	spec.Labels[PortsLabel] = formatPortsLabel(mappings)
+}
+
+// configPortMappings converts mappings back to the form devcontainer.json ports resolve to
+func configPortMappings(mappings []PortMapping) []config.PortMapping {
+	result := make([]config.PortMapping, len(mappings))
+	for i, pm := range mappings {
+		result[i] = config.PortMapping{HostPort: pm.HostPort, ContainerPort: pm.ContainerPort}
+	}
+	return result
+}
+
+// describePorts formats mappings for display as "host->container, host->container"
+func describePorts(mappings []PortMapping) string {
+	parts := make([]string, len(mappings))
+	for i, pm := range mappings {
+		parts[i] = fmt.Sprintf("%d->%d", pm.HostPort, pm.ContainerPort)
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkHostPortsAvailable binds each host port briefly to make sure Docker will be able
+// to publish it, and names the process holding any port that is taken
+func checkHostPortsAvailable(mappings []PortMapping) error {
+	var busy []string
+	for _, pm := range mappings {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", pm.HostPort))
+		if err == nil {
+			_ = listener.Close()
+			continue
+		}
+		// Only a port that is already bound is a conflict; e.g. permission errors on
+		// privileged ports do not apply to the Docker daemon
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			continue
+		}
+
+		if holder := portHolder(pm.HostPort); holder != "" {
+			busy = append(busy, fmt.Sprintf("port %d is in use by %s", pm.HostPort, holder))
+		} else {
+			busy = append(busy, fmt.Sprintf("port %d is already in use", pm.HostPort))
+		}
+	}
+
+	if len(busy) == 0 {
+		return nil
+	}
+	return fmt.Errorf("host ports are not available:\n  - %s\nStop the process holding the port or map a different host port with --port host:container",
+		strings.Join(busy, "\n  - "))
+}
+
+// portHolder describes the process listening on a TCP port, or returns "" when it
+// cannot be determined (e.g. the process belongs to another user)
+func portHolder(port int) string {
+	if runtime.GOOS == "linux" {
+		return procPortHolder("/proc", port)
+	}
+	return lsofPortHolder(port)
+}
+
+// procPortHolder finds the listening socket for a port in /proc/net/tcp{,6} and then
+// the process that owns a file descriptor for that socket
+func procPortHolder(procRoot string, port int) string {
+	inodes := map[string]bool{}
+	for _, table := range []string{"net/tcp", "net/tcp6"} {
+		for _, inode := range listeningSocketInodes(filepath.Join(procRoot, table), port) {
+			inodes["socket:["+inode+"]"] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return ""
+	}
+
+	pids, err := filepath.Glob(filepath.Join(procRoot, "[0-9]*"))
+	if err != nil {
+		return ""
+	}
+	for _, pidDir := range pids {
+		fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name()))
+			if err != nil || !inodes[link] {
+				continue
+			}
+			comm, _ := os.ReadFile(filepath.Join(pidDir, "comm"))
+			return formatHolder(strings.TrimSpace(string(comm)), filepath.Base(pidDir))
+		}
+	}
+	return ""
+}
+
+// listeningSocketInodes returns the inodes of sockets in LISTEN state on a port
+func listeningSocketInodes(tablePath string, port int) []string {
+	file, err := os.Open(tablePath)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	const stateListen = "0A"
+	var inodes []string
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != stateListen {
+			continue
+		}
+		colon := strings.LastIndex(fields[1], ":")
+		if colon < 0 {
+			continue
+		}
+		localPort, err := strconv.ParseInt(fields[1][colon+1:], 16, 32)
+		if err != nil || int(localPort) != port {
+			continue
+		}
+		inodes = append(inodes, fields[9])
+	}
+	return inodes
+}
+
+// lsofPortHolder asks lsof for the listener on a port where /proc is not available
+func lsofPortHolder(port int) string {
+	if _, err := exec.LookPath("lsof"); err != nil {
+		return ""
+	}
+	output, err := exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return ""
+	}
+
+	// -F output is one field per line: p<pid> then c<command>
+	var pid, command string
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "p") && pid == "":
+			pid = line[1:]
+		case strings.HasPrefix(line, "c") && command == "":
+			command = line[1:]
+		}
+	}
+	if pid == "" {
+		return ""
+	}
+	return formatHolder(command, pid)
+}
+
+func formatHolder(command, pid string) string {
+	if command == "" {
+		return "pid " + pid
+	}
+	return fmt.Sprintf("%s (pid %s)", command, pid)
+}
+
+// remapHostPorts publishes mappings on other host ports, keyed by their configured host port
+func remapHostPorts(mappings []PortMapping, hostPorts map[int]int) []PortMapping {
+	if hostPorts == nil {
+		return mappings
+	}
+	remapped := make([]PortMapping, len(mappings))
+	for i, pm := range mappings {
+		remapped[i] = pm
+		if hostPort, ok := hostPorts[pm.HostPort]; ok {
+			remapped[i].HostPort = hostPort
+		}
+	}
+	return remapped
+}
+
+// offsetHostPorts shifts the host port of every mapping by offset
+func offsetHostPorts(mappings []PortMapping, offset int) ([]PortMapping, error) {
+	if offset == 0 {
+		return mappings, nil
+	}
+	shifted := make([]PortMapping, len(mappings))
+	for i, pm := range mappings {
+		shifted[i] = pm
+		shifted[i].HostPort += offset
+		if shifted[i].HostPort < 1 || shifted[i].HostPort > 65535 {
+			return nil, fmt.Errorf("host port %d with port offset %d is outside the valid range 1-65535", pm.HostPort, offset)
+		}
+	}
+	return shifted, nil
+}
+
+// ForwardedPorts returns the ports 'reactor up' would forward for a project: the
+// devcontainer.json forwardPorts merged with the CLI port mappings and shifted by the
+// port offset. Docker is not used.
+func ForwardedPorts(upConfig UpConfig) ([]PortMapping, error) {
+	cliPorts, err := parsePortMappings(upConfig.CLIPortMappings)
+	if err != nil {
+		return nil, fmt.Errorf("CLI port mapping error: %w", err)
+	}
+
+	originalWD, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	defer func() { _ = os.Chdir(originalWD) }()
+	if err := os.Chdir(upConfig.ProjectDirectory); err != nil {
+		return nil, fmt.Errorf("failed to change to project directory %s: %w", upConfig.ProjectDirectory, err)
+	}
+
+	configService := config.NewService()
+	configService.SetConfigName(upConfig.ConfigName)
+	configService.SetOverrides(settingOverrides(upConfig))
+	resolved, err := configService.ResolveConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	return offsetHostPorts(mergePortMappings(resolved.ForwardPorts, cliPorts), upConfig.PortOffset)
+}
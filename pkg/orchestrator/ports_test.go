@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHostPortsAvailable(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	busyPort := listener.Addr().(*net.TCPAddr).Port
+
+	free, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	freePort := free.Addr().(*net.TCPAddr).Port
+	require.NoError(t, free.Close())
+
+	assert.NoError(t, checkHostPortsAvailable([]PortMapping{{HostPort: freePort, ContainerPort: 80}}))
+
+	err = checkHostPortsAvailable([]PortMapping{{HostPort: freePort, ContainerPort: 80}, {HostPort: busyPort, ContainerPort: 3000}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("port %d", busyPort))
+	assert.NotContains(t, err.Error(), fmt.Sprintf("port %d", freePort))
+	if runtime.GOOS == "linux" {
+		assert.Contains(t, err.Error(), fmt.Sprintf("(pid %d)", os.Getpid()))
+	}
+
+	require.NoError(t, listener.Close())
+	assert.NoError(t, checkHostPortsAvailable([]PortMapping{{HostPort: busyPort, ContainerPort: 3000}}))
+}
+
+func TestProcPortHolder(t *testing.T) {
+	procRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "net"), 0755))
+
+	// 0x1F90 = 8080 listening (0A), 0x1F91 = 8081 established (01)
+	tcpTable := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+		"   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 55555 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 0100007F:1F91 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 66666 1 0000000000000000 20 4 30 10 -1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "net", "tcp"), []byte(tcpTable), 0644))
+
+	pidDir := filepath.Join(procRoot, "4242")
+	require.NoError(t, os.MkdirAll(filepath.Join(pidDir, "fd"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pidDir, "comm"), []byte("node\n"), 0644))
+	require.NoError(t, os.Symlink("socket:[55555]", filepath.Join(pidDir, "fd", strconv.Itoa(3))))
+
+	assert.Equal(t, "node (pid 4242)", procPortHolder(procRoot, 8080))
+	assert.Equal(t, "", procPortHolder(procRoot, 8081), "non-listening sockets are ignored")
+	assert.Equal(t, "", procPortHolder(procRoot, 9090))
+}
+
+func TestPortsLabel(t *testing.T) {
+	mappings := []PortMapping{{HostPort: 8080, ContainerPort: 3000}, {HostPort: 9229, ContainerPort: 9229}}
+	assert.Equal(t, "8080:3000,9229:9229", formatPortsLabel(mappings))
+
+	parsed, err := parsePortsLabel(formatPortsLabel(mappings))
+	require.NoError(t, err)
+	assert.Equal(t, mappings, parsed)
+
+	parsed, err = parsePortsLabel("")
+	require.NoError(t, err)
+	assert.Empty(t, parsed)
+
+	_, err = parsePortsLabel("8080")
+	assert.Error(t, err)
+}
+
+func TestPersistedPorts(t *testing.T) {
+	_, ok := persistedPorts(map[string]string{}, progress{})
+	assert.False(t, ok, "containers without the label have no known ports")
+
+	mappings, ok := persistedPorts(map[string]string{PortsLabel: ""}, progress{})
+	assert.True(t, ok)
+	assert.Empty(t, mappings)
+
+	mappings, ok = persistedPorts(map[string]string{PortsLabel: "8081:3000"}, progress{})
+	assert.True(t, ok)
+	assert.Equal(t, []PortMapping{{HostPort: 8081, ContainerPort: 3000}}, mappings)
+}
+
+func TestSamePortMappings(t *testing.T) {
+	a := []PortMapping{{HostPort: 8080, ContainerPort: 3000}, {HostPort: 9229, ContainerPort: 9229}}
+	b := []PortMapping{{HostPort: 9229, ContainerPort: 9229}, {HostPort: 8080, ContainerPort: 3000}}
+
+	assert.True(t, samePortMappings(a, b))
+	assert.True(t, samePortMappings(nil, []PortMapping{}))
+	assert.False(t, samePortMappings(a, a[:1]))
+	assert.False(t, samePortMappings(a[:1], []PortMapping{{HostPort: 8081, ContainerPort: 3000}}))
+}
+
+func TestRemapHostPorts(t *testing.T) {
+	mappings := []PortMapping{{HostPort: 8080, ContainerPort: 3000}, {HostPort: 9229, ContainerPort: 9229}}
+
+	assert.Equal(t, mappings, remapHostPorts(mappings, nil))
+	assert.Equal(t, []PortMapping{{HostPort: 41000, ContainerPort: 3000}, {HostPort: 9229, ContainerPort: 9229}},
+		remapHostPorts(mappings, map[int]int{8080: 41000}))
+	assert.Equal(t, 8080, mappings[0].HostPort, "the input is not modified")
+}
+
+func TestOffsetHostPorts(t *testing.T) {
+	mappings := []PortMapping{{HostPort: 8080, ContainerPort: 3000}, {HostPort: 9229, ContainerPort: 9229}}
+
+	shifted, err := offsetHostPorts(mappings, 100)
+	require.NoError(t, err)
+	assert.Equal(t, []PortMapping{{HostPort: 8180, ContainerPort: 3000}, {HostPort: 9329, ContainerPort: 9229}}, shifted)
+	assert.Equal(t, 8080, mappings[0].HostPort, "the input is not modified")
+
+	_, err = offsetHostPorts([]PortMapping{{HostPort: 65000, ContainerPort: 80}}, 1000)
+	assert.EqualError(t, err, "host port 65000 with port offset 1000 is outside the valid range 1-65535")
+}
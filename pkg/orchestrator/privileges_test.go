@@ -0,0 +1,28 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmPrivileges(t *testing.T) {
+	resolved := &config.ResolvedConfig{ConfigPath: ".devcontainer/devcontainer.json", CapAdd: []string{"SYS_PTRACE"}}
+
+	assert.NoError(t, confirmPrivileges(UpConfig{}, &config.ResolvedConfig{}))
+	assert.NoError(t, confirmPrivileges(UpConfig{AllowPrivileged: true}, resolved))
+	assert.EqualError(t, confirmPrivileges(UpConfig{}, resolved),
+		".devcontainer/devcontainer.json requests elevated privileges (capability SYS_PTRACE); pass --allow-privileged to create the container with them")
+
+	var asked []string
+	confirm := func(answer bool) func([]string) (bool, error) {
+		return func(requested []string) (bool, error) {
+			asked = requested
+			return answer, nil
+		}
+	}
+	assert.NoError(t, confirmPrivileges(UpConfig{ConfirmPrivileges: confirm(true)}, resolved))
+	assert.Equal(t, []string{"capability SYS_PTRACE"}, asked)
+	assert.ErrorContains(t, confirmPrivileges(UpConfig{ConfirmPrivileges: confirm(false)}, resolved), "elevated privileges were not confirmed")
+}
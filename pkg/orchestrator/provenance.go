@@ -0,0 +1,34 @@
+package orchestrator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+)
+
+// ReactorVersion is the version of reactor recorded on the images it builds. The CLI
+// sets it from its build information.
+var ReactorVersion = "dev"
+
+// Labels recording where an image reactor built came from
+const (
+	ImageCreatedLabel    = "org.opencontainers.image.created" // RFC 3339 build time
+	ImageVersionLabel    = "com.reactor.version"              // version of reactor that built the image
+	ImageConfigHashLabel = "com.reactor.devcontainer-hash"    // sha256 of the devcontainer.json built from
+)
+
+// imageProvenanceLabels returns the labels of an image built at now for a configuration.
+// The devcontainer.json hash is left out when the file cannot be read.
+func imageProvenanceLabels(resolved *config.ResolvedConfig, now time.Time) map[string]string {
+	labels := map[string]string{
+		ImageCreatedLabel: now.UTC().Format(time.RFC3339),
+		ImageVersionLabel: ReactorVersion,
+	}
+	if data, err := os.ReadFile(resolved.ConfigPath); err == nil {
+		labels[ImageConfigHashLabel] = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	}
+	return labels
+}
@@ -0,0 +1,31 @@
+package orchestrator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageProvenanceLabels(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "devcontainer.json")
+	content := []byte(`{"build": {"dockerfile": "Dockerfile"}}`)
+	require.NoError(t, os.WriteFile(configPath, content, 0644))
+	now := time.Date(2026, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	labels := imageProvenanceLabels(&config.ResolvedConfig{ConfigPath: configPath}, now)
+	assert.Equal(t, map[string]string{
+		ImageCreatedLabel:    "2026-03-01T11:30:00Z",
+		ImageVersionLabel:    ReactorVersion,
+		ImageConfigHashLabel: fmt.Sprintf("sha256:%x", sha256.Sum256(content)),
+	}, labels)
+
+	labels = imageProvenanceLabels(&config.ResolvedConfig{ConfigPath: filepath.Join(t.TempDir(), "missing.json")}, now)
+	assert.NotContains(t, labels, ImageConfigHashLabel)
+}
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"github.com/dyluth/reactor/pkg/debug"
+	"github.com/dyluth/reactor/pkg/state"
+)
+
+// ProvisioningTools is the provisioning step installing customizations.reactor.tools;
+// the lifecycle steps are named after their devcontainer.json property
+const ProvisioningTools = "tools"
+
+// provisioning records in the state file which step of provisioning a container is at,
+// so 'reactor sessions attach' can wait until the container is ready
+type provisioning struct {
+	name    string
+	id      string
+	started bool
+	// off leaves the state file alone, for ephemeral containers nothing attaches to
+	off bool
+}
+
+// step records that provisioning has reached phase
+func (t *provisioning) step(phase string) {
+	if t.off {
+		return
+	}
+	t.started = true
+	if err := state.SetProvisioning(t.name, t.id, phase); err != nil {
+		debug.Logf(debug.Orchestrator, "cannot record provisioning of %s: %v", t.name, err)
+	}
+}
+
+// finish records that provisioning is over, whether or not it succeeded
+func (t *provisioning) finish() {
+	if !t.started {
+		return
+	}
+	if err := state.FinishProvisioning(t.name); err != nil {
+		debug.Logf(debug.Orchestrator, "cannot record the end of provisioning of %s: %v", t.name, err)
+	}
+}
@@ -0,0 +1,39 @@
+package orchestrator
+
+import (
+	"path/filepath"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/registryauth"
+	"github.com/dyluth/reactor/pkg/vault"
+)
+
+// AccountRegistryStore returns the store of an account's registry credentials. With
+// credential encryption on it is encrypted like the provider directories; otherwise it
+// is a plaintext file readable by the owner only.
+func AccountRegistryStore(account, encryption string) (*registryauth.Store, error) {
+	reactorHome, err := config.GetReactorHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	accountDir := filepath.Join(reactorHome, account)
+	if encryption == "" || encryption == config.CredentialEncryptionOff {
+		return registryauth.NewStore(filepath.Join(accountDir, config.RegistryCredentialsFileName), nil), nil
+	}
+
+	var c vault.Cipher
+	if c, err = newCredentialCipher(encryption, account); err != nil {
+		return nil, err
+	}
+	return registryauth.NewStore(filepath.Join(accountDir, config.EncryptedRegistryCredentialsFileName), c), nil
+}
+
+// RegistryResolver returns the registry credentials a project pulls and builds images
+// with: those of its account, then the host's for the account's allowlisted registries
+func RegistryResolver(resolved *config.ResolvedConfig) (*registryauth.Resolver, error) {
+	store, err := AccountRegistryStore(resolved.Account, resolved.CredentialEncryption)
+	if err != nil {
+		return nil, err
+	}
+	return &registryauth.Resolver{Store: store, HostRegistries: resolved.HostRegistries}, nil
+}
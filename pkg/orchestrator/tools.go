@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// ToolsLabel records the customizations.reactor.tools a container was created with
+const ToolsLabel = "com.reactor.tools"
+
+// toolsFailureLines is how much of a failed install's output a warning shows
+const toolsFailureLines = 10
+
+// setToolsLabel records the tools a new container is created with
+func setToolsLabel(spec *docker.ContainerSpec, resolved *config.ResolvedConfig) {
+	if len(resolved.Tools) > 0 {
+		spec.Labels[ToolsLabel] = strings.Join(resolved.Tools, ",")
+	}
+}
+
+// toolsDue reports whether tools are installed: in a container that was just created,
+// or in a reused one whose tools changed since, or whose last install failed. applied
+// holds what later installs recorded, as the label cannot be changed. The install skips
+// tools already there.
+func toolsDue(tools []string, labels, applied map[string]string, created bool) bool {
+	if len(tools) == 0 {
+		return false
+	}
+	current, ok := applied[ToolsLabel]
+	if !ok {
+		current = labels[ToolsLabel]
+	}
+	return created || current != strings.Join(tools, ",")
+}
+
+// recordAppliedTools records the tools installed in a container, none when the install
+// failed, so the next 'reactor up' installs them again
+func recordAppliedTools(projectConfigDir, containerID string, tools []string, installed bool, p progress) {
+	value := ""
+	if installed {
+		value = strings.Join(tools, ",")
+	}
+	if err := recordAppliedLabels(projectConfigDir, containerID, map[string]string{ToolsLabel: value}); err != nil {
+		p.warn(PhaseSetup, "failed to record the installed tools: %v", err)
+	}
+}
+
+// installTools installs customizations.reactor.tools as root with the snippets for the
+// container's distro and reports whether it succeeded. A failure is a warning: the
+// container is usable without them.
+func installTools(ctx context.Context, dockerService *docker.Service, containerID string, tools []string, verbose bool, p progress) bool {
+	p.info(PhaseSetup, "Installing tools: %s", strings.Join(tools, ", "))
+	var output bytes.Buffer
+	err := dockerService.ExecCommand(ctx, containerID, docker.ExecOptions{
+		Command: []string{"sh", "-c", config.ToolInstallScript(tools)},
+		User:    "root",
+		Stdout:  &output,
+		Stderr:  &output,
+	})
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		if len(lines) > toolsFailureLines {
+			lines = lines[len(lines)-toolsFailureLines:]
+		}
+		p.warn(PhaseSetup, "failed to install customizations.reactor.tools: %v\n%s", err, strings.Join(lines, "\n"))
+		return false
+	}
+	if verbose {
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			p.detail(PhaseSetup, "%s", line)
+		}
+	}
+	return true
+}
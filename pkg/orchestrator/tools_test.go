@@ -0,0 +1,46 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetToolsLabel(t *testing.T) {
+	spec := &docker.ContainerSpec{Labels: map[string]string{}}
+	setToolsLabel(spec, &config.ResolvedConfig{})
+	assert.NotContains(t, spec.Labels, ToolsLabel)
+
+	setToolsLabel(spec, &config.ResolvedConfig{Tools: []string{"ripgrep", "gh"}})
+	assert.Equal(t, "ripgrep,gh", spec.Labels[ToolsLabel])
+}
+
+func TestToolsDue(t *testing.T) {
+	tools := []string{"ripgrep", "gh"}
+	assert.False(t, toolsDue(nil, nil, nil, true), "no tools configured")
+	assert.True(t, toolsDue(tools, nil, nil, true), "new container")
+	assert.False(t, toolsDue(tools, map[string]string{ToolsLabel: "ripgrep,gh"}, nil, false), "reused with the same tools")
+	assert.True(t, toolsDue(tools, map[string]string{ToolsLabel: "ripgrep"}, nil, false), "a tool was added")
+	assert.True(t, toolsDue(tools, map[string]string{}, nil, false), "created before tools were configured")
+}
+
+func TestRecordAppliedTools(t *testing.T) {
+	dir := t.TempDir()
+	tools := []string{"ripgrep", "gh"}
+	labels := map[string]string{ToolsLabel: "ripgrep"}
+
+	// Installing the added tool is recorded, so it is not installed on every 'reactor up'
+	recordAppliedTools(dir, "c1", tools, true, progress{})
+	assert.False(t, toolsDue(tools, labels, appliedLifecycleHashes(dir, "c1"), false))
+
+	// A failed install is retried, even in a container labelled with the tools
+	recordAppliedTools(dir, "c2", tools, false, progress{})
+	assert.True(t, toolsDue(tools, map[string]string{ToolsLabel: "ripgrep,gh"}, appliedLifecycleHashes(dir, "c2"), false))
+
+	// Re-run lifecycle hashes are kept alongside
+	require.NoError(t, recordAppliedLifecycleHashes(dir, "c1", []lifecycleHook{{Label: PostStartHashLabel, Command: "make serve"}}))
+	assert.Equal(t, "ripgrep,gh", appliedLifecycleHashes(dir, "c1")[ToolsLabel])
+}
@@ -0,0 +1,120 @@
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Apply copies a single change from the overlay upper directory onto the host
+// project and then drops it from the upper directory so it is not applied twice.
+func Apply(lowerDir, upperDir string, change Change) error {
+	hostPath := filepath.Join(lowerDir, change.Path)
+	overlayPath := filepath.Join(upperDir, change.Path)
+
+	switch change.Kind {
+	case KindDeleted:
+		if err := os.RemoveAll(hostPath); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", change.Path, err)
+		}
+	case KindAdded, KindChanged:
+		if err := copyFile(overlayPath, hostPath); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", change.Path, err)
+		}
+	default:
+		return fmt.Errorf("unknown change kind %q for %s", change.Kind, change.Path)
+	}
+
+	if err := os.Remove(overlayPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("applied %s but failed to clear it from the overlay: %w", change.Path, err)
+	}
+	return nil
+}
+
+// copyFile copies a regular file or symlink, preserving its permissions
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, info.Mode().Perm())
+}
+
+// IgnoredPaths returns the subset of changes whose paths are ignored by git in the
+// project. Projects that are not git repositories have no ignored paths.
+func IgnoredPaths(projectRoot string, changes []Change) (map[string]bool, error) {
+	ignored := make(map[string]bool)
+	if len(changes) == 0 {
+		return ignored, nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return ignored, nil
+	}
+
+	check := exec.Command("git", "-C", projectRoot, "rev-parse", "--is-inside-work-tree")
+	if err := check.Run(); err != nil {
+		return ignored, nil
+	}
+
+	var input bytes.Buffer
+	for _, change := range changes {
+		input.WriteString(filepath.ToSlash(change.Path))
+		input.WriteByte('\n')
+	}
+
+	var out, stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", projectRoot, "check-ignore", "--no-index", "--stdin")
+	cmd.Stdin = &input
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// check-ignore exits with 1 when no paths are ignored
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("failed to check .gitignore rules: %v %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ignored[filepath.FromSlash(line)] = true
+		}
+	}
+	return ignored, nil
+}
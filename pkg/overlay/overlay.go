@@ -0,0 +1,143 @@
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Change kinds mirror the codes used by 'reactor diff' for container changes
+const (
+	KindAdded   = "A"
+	KindChanged = "C"
+	KindDeleted = "D"
+)
+
+// Change describes a single file modification recorded in the overlay upper directory
+type Change struct {
+	Kind string // A (Added), C (Changed), D (Deleted)
+	Path string // Path relative to the workspace root
+}
+
+// Dirs returns the overlay upper and work directories for a project.
+// They live in the project config dir so changes survive container recreation.
+func Dirs(projectConfigDir string) (upperDir, workDir string) {
+	overlayDir := filepath.Join(projectConfigDir, "overlay")
+	return filepath.Join(overlayDir, "upper"), filepath.Join(overlayDir, "work")
+}
+
+// Prepare creates the overlay upper and work directories for a project
+func Prepare(projectConfigDir string) (upperDir, workDir string, err error) {
+	upperDir, workDir = Dirs(projectConfigDir)
+	for _, dir := range []string{upperDir, workDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", "", fmt.Errorf("failed to create overlay directory %s: %w", dir, err)
+		}
+	}
+	return upperDir, workDir, nil
+}
+
+// VolumeName returns the Docker volume name backing a container's overlay workspace
+func VolumeName(containerName string) string {
+	return containerName + "-overlay"
+}
+
+// MountOptions returns the overlay mount option string for the Docker local volume driver
+func MountOptions(lowerDir, upperDir, workDir string) (string, error) {
+	for _, dir := range []string{lowerDir, upperDir, workDir} {
+		if strings.ContainsAny(dir, ",:") {
+			return "", fmt.Errorf("path %s cannot be used for a read-only workspace: overlay paths must not contain ',' or ':'", dir)
+		}
+	}
+	return fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", lowerDir, upperDir, workDir), nil
+}
+
+// Changes walks the overlay upper directory and classifies each entry against the
+// lower (host) directory. Overlay whiteouts (character devices) are reported as
+// deletions; directories themselves are not reported, only the files within them.
+func Changes(lowerDir, upperDir string) ([]Change, error) {
+	if _, err := os.Stat(upperDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var changes []Change
+	err := filepath.Walk(upperDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(upperDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." || info.IsDir() {
+			return nil
+		}
+
+		if info.Mode()&os.ModeCharDevice != 0 {
+			changes = append(changes, Change{Kind: KindDeleted, Path: relPath})
+			return nil
+		}
+
+		if _, err := os.Lstat(filepath.Join(lowerDir, relPath)); err == nil {
+			changes = append(changes, Change{Kind: KindChanged, Path: relPath})
+		} else {
+			changes = append(changes, Change{Kind: KindAdded, Path: relPath})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overlay changes: %w", err)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// WritePatch renders the changes as a unified diff (git format) to w.
+// It relies on 'git diff --no-index', which works outside of git repositories.
+func WritePatch(w io.Writer, lowerDir, upperDir string, changes []Change) error {
+	for _, change := range changes {
+		oldPath := filepath.Join(lowerDir, change.Path)
+		newPath := filepath.Join(upperDir, change.Path)
+		switch change.Kind {
+		case KindAdded:
+			oldPath = os.DevNull
+		case KindDeleted:
+			newPath = os.DevNull
+		}
+
+		var out, stderr bytes.Buffer
+		cmd := exec.Command("git", "diff", "--no-index", "--binary", "--no-color", oldPath, newPath)
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			// git diff exits with 1 when differences are found
+			if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+				return fmt.Errorf("failed to diff %s: %v %s", change.Path, err, strings.TrimSpace(stderr.String()))
+			}
+		}
+
+		if _, err := io.WriteString(w, rewritePatchPaths(out.String(), lowerDir, upperDir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewritePatchPaths replaces absolute host paths in git output with workspace-relative ones
+// so the patch applies cleanly with 'git apply' from the project root
+func rewritePatchPaths(patch, lowerDir, upperDir string) string {
+	for _, dir := range []string{lowerDir, upperDir} {
+		prefix := filepath.ToSlash(dir)
+		prefix = strings.TrimPrefix(prefix, "/")
+		patch = strings.ReplaceAll(patch, "a/"+prefix+"/", "a/")
+		patch = strings.ReplaceAll(patch, "b/"+prefix+"/", "b/")
+	}
+	return patch
+}
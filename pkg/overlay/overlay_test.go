@@ -0,0 +1,146 @@
+package overlay
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepare(t *testing.T) {
+	configDir := t.TempDir()
+
+	upperDir, workDir, err := Prepare(configDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(configDir, "overlay", "upper"), upperDir)
+	assert.Equal(t, filepath.Join(configDir, "overlay", "work"), workDir)
+	assert.DirExists(t, upperDir)
+	assert.DirExists(t, workDir)
+}
+
+func TestMountOptions(t *testing.T) {
+	opts, err := MountOptions("/home/user/project", "/upper", "/work")
+	require.NoError(t, err)
+	assert.Equal(t, "lowerdir=/home/user/project,upperdir=/upper,workdir=/work", opts)
+
+	_, err = MountOptions("/home/user/my,project", "/upper", "/work")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must not contain")
+
+	_, err = MountOptions("/home/user/project", "/up:per", "/work")
+	assert.Error(t, err)
+}
+
+func TestChanges(t *testing.T) {
+	t.Run("classifies added and changed files", func(t *testing.T) {
+		lowerDir := t.TempDir()
+		upperDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(lowerDir, "main.go"), []byte("package main\n"), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(upperDir, "pkg"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(upperDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(upperDir, "pkg", "new.go"), []byte("package pkg\n"), 0644))
+
+		changes, err := Changes(lowerDir, upperDir)
+		require.NoError(t, err)
+		assert.Equal(t, []Change{
+			{Kind: KindChanged, Path: "main.go"},
+			{Kind: KindAdded, Path: filepath.Join("pkg", "new.go")},
+		}, changes)
+	})
+
+	t.Run("missing upper directory has no changes", func(t *testing.T) {
+		changes, err := Changes(t.TempDir(), filepath.Join(t.TempDir(), "missing"))
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
+func TestWritePatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	lowerDir := t.TempDir()
+	upperDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(lowerDir, "README.md"), []byte("hello\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(upperDir, "README.md"), []byte("hello world\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(upperDir, "NEW.md"), []byte("new\n"), 0644))
+
+	changes, err := Changes(lowerDir, upperDir)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePatch(&buf, lowerDir, upperDir, changes))
+	patch := buf.String()
+
+	assert.Contains(t, patch, "--- a/README.md")
+	assert.Contains(t, patch, "+++ b/README.md")
+	assert.Contains(t, patch, "+hello world")
+	assert.Contains(t, patch, "+++ b/NEW.md")
+	assert.NotContains(t, patch, lowerDir)
+	assert.NotContains(t, patch, upperDir)
+}
+
+func TestApply(t *testing.T) {
+	lowerDir := t.TempDir()
+	upperDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(lowerDir, "old.txt"), []byte("old\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(lowerDir, "edit.txt"), []byte("before\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(upperDir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(upperDir, "edit.txt"), []byte("after\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(upperDir, "nested", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+
+	require.NoError(t, Apply(lowerDir, upperDir, Change{Kind: KindChanged, Path: "edit.txt"}))
+	require.NoError(t, Apply(lowerDir, upperDir, Change{Kind: KindAdded, Path: filepath.Join("nested", "run.sh")}))
+	require.NoError(t, Apply(lowerDir, upperDir, Change{Kind: KindDeleted, Path: "old.txt"}))
+
+	content, err := os.ReadFile(filepath.Join(lowerDir, "edit.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "after\n", string(content))
+
+	info, err := os.Stat(filepath.Join(lowerDir, "nested", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	assert.NoFileExists(t, filepath.Join(lowerDir, "old.txt"))
+	assert.NoFileExists(t, filepath.Join(upperDir, "edit.txt"), "applied changes should be cleared from the overlay")
+
+	changes, err := Changes(lowerDir, upperDir)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestIgnoredPaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	changes := []Change{
+		{Kind: KindAdded, Path: "main.go"},
+		{Kind: KindAdded, Path: filepath.Join("build", "out.bin")},
+		{Kind: KindAdded, Path: "debug.log"},
+	}
+
+	t.Run("not a git repository", func(t *testing.T) {
+		ignored, err := IgnoredPaths(t.TempDir(), changes)
+		require.NoError(t, err)
+		assert.Empty(t, ignored)
+	})
+
+	t.Run("gitignore rules are honoured", func(t *testing.T) {
+		projectDir := t.TempDir()
+		require.NoError(t, exec.Command("git", "-C", projectDir, "init", "-q").Run())
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gitignore"), []byte("build/\n*.log\n"), 0644))
+
+		ignored, err := IgnoredPaths(projectDir, changes)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{
+			filepath.Join("build", "out.bin"): true,
+			"debug.log":                       true,
+		}, ignored)
+	})
+}
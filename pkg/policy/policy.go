@@ -0,0 +1,313 @@
+// Package policy loads the optional policy file that restricts the containers 'reactor
+// up' may create: it can forbid dangerous options, limit the registries and images
+// used, and require hardened containers. The file is ~/.reactor/policy.yaml, or the
+// file REACTOR_POLICY names, e.g. one shared by every user of a machine.
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/dyluth/reactor/pkg/settings"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the policy file in the reactor home directory
+const FileName = "policy.yaml"
+
+// EnvPolicy names a policy file to use instead of the one in the reactor home directory
+const EnvPolicy = "REACTOR_POLICY"
+
+// Policy restricts the containers reactor creates; the zero value allows everything
+type Policy struct {
+	// Forbid lists container options that may not be used
+	Forbid Forbid `yaml:"forbid,omitempty"`
+	// AllowedRegistries are registries, optionally with a path such as ghcr.io/myorg,
+	// that the image and the base images of a built image must come from; empty allows
+	// every registry. Docker Hub is docker.io.
+	AllowedRegistries []string `yaml:"allowedRegistries,omitempty"`
+	// AllowedImages are patterns, such as "ghcr.io/myorg/*" or "node:*", that the image
+	// and the base images of a built image must match; empty allows every image. A '*'
+	// does not match '/'.
+	AllowedImages []string `yaml:"allowedImages,omitempty"`
+	// RequireHardened only allows hardened containers (see HardeningViolations)
+	RequireHardened bool `yaml:"requireHardened,omitempty"`
+
+	// Path is the policy file the policy was read from
+	Path string `yaml:"-"`
+}
+
+// Forbid lists the dangerous container options a policy can forbid
+type Forbid struct {
+	// DockerHostIntegration forbids mounting the host's Docker socket
+	DockerHostIntegration bool `yaml:"dockerHostIntegration,omitempty"`
+	// Privileged forbids privileged mode, added capabilities and security options that
+	// loosen confinement, even with --allow-privileged
+	Privileged bool `yaml:"privileged,omitempty"`
+	// HostNetwork forbids sharing the host's network namespace
+	HostNetwork bool `yaml:"hostNetwork,omitempty"`
+}
+
+// Container describes the container 'reactor up' is about to create
+type Container struct {
+	Image                 string   // image the container runs; empty when it is built
+	BaseImages            []string // FROM images of the Dockerfile when the image is built
+	DockerHostIntegration bool
+	BindMounts            []string // host paths bind mounted into the container
+	ElevatedPrivileges    []string // as listed by config.ResolvedConfig.ElevatedPrivileges
+	SecurityOpt           []string
+	NetworkMode           string
+	User                  string
+	Init                  bool
+}
+
+// ViolationError lists every rule of a policy a container breaks
+type ViolationError struct {
+	Path       string
+	Violations []string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("the policy in %s does not allow this container: %s", e.Path, strings.Join(e.Violations, "; "))
+}
+
+// Path returns the policy file: the one REACTOR_POLICY names, or policy.yaml next to
+// the preferences file, which respects REACTOR_ISOLATION_PREFIX
+func Path() (string, error) {
+	if path := os.Getenv(EnvPolicy); path != "" {
+		return path, nil
+	}
+	prefsPath, err := settings.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(prefsPath), FileName), nil
+}
+
+// Load reads the policy file. It returns nil when there is no policy; a file named by
+// REACTOR_POLICY must exist, so a mistyped path does not silently lift the policy.
+func Load() (*Policy, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && os.Getenv(EnvPolicy) == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return parse(path, data)
+}
+
+func parse(file string, data []byte) (*Policy, error) {
+	policy := &Policy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", file, err)
+	}
+	policy.Path = file
+
+	for i, registry := range policy.AllowedRegistries {
+		if strings.TrimSpace(registry) == "" || strings.ContainsAny(registry, ":@*") {
+			return nil, fmt.Errorf("invalid policy %s: allowedRegistries[%d] '%s' must be a registry such as ghcr.io or ghcr.io/myorg", file, i, registry)
+		}
+		policy.AllowedRegistries[i] = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+	}
+	for i, pattern := range policy.AllowedImages {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("invalid policy %s: allowedImages[%d] '%s' is not a valid pattern", file, i, pattern)
+		}
+	}
+	return policy, nil
+}
+
+// Check returns a *ViolationError listing every rule the container breaks, nil when it
+// complies. A nil policy allows every container.
+func (p *Policy) Check(c Container) error {
+	if p == nil {
+		return nil
+	}
+	var violations []string
+	if p.Forbid.DockerHostIntegration {
+		if access, ok := c.dockerHostAccess(); ok {
+			violations = append(violations, "docker host integration is forbidden"+access)
+		}
+	}
+	if p.Forbid.Privileged && len(c.ElevatedPrivileges) > 0 {
+		violations = append(violations, fmt.Sprintf("elevated privileges are forbidden (%s)", strings.Join(c.ElevatedPrivileges, ", ")))
+	}
+	if p.Forbid.HostNetwork && c.NetworkMode == "host" {
+		violations = append(violations, "host networking is forbidden")
+	}
+
+	images := c.BaseImages
+	kind := "base image"
+	if c.Image != "" {
+		images = []string{c.Image}
+		kind = "image"
+	}
+	for _, image := range images {
+		if reason := p.imageViolation(image); reason != "" {
+			violations = append(violations, fmt.Sprintf("%s %s %s", kind, image, reason))
+		}
+	}
+
+	if p.RequireHardened {
+		for _, v := range HardeningViolations(c) {
+			violations = append(violations, "hardened containers require "+v)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ViolationError{Path: p.Path, Violations: violations}
+}
+
+// imageViolation explains why an image is not allowed, empty when it is
+func (p *Policy) imageViolation(image string) string {
+	if len(p.AllowedRegistries) == 0 && len(p.AllowedImages) == 0 {
+		return ""
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "cannot be checked against the allowed images, as it is not a plain image reference"
+	}
+	if len(p.AllowedRegistries) > 0 && !slices.ContainsFunc(p.AllowedRegistries, func(registry string) bool {
+		return named.Name() == registry || strings.HasPrefix(named.Name(), registry+"/")
+	}) {
+		return fmt.Sprintf("is not from an allowed registry (%s)", strings.Join(p.AllowedRegistries, ", "))
+	}
+	if len(p.AllowedImages) > 0 {
+		// Patterns may name images as 'docker pull' shows them or fully qualified
+		tagged := reference.TagNameOnly(named)
+		forms := []string{reference.FamiliarString(tagged), tagged.String()}
+		if !slices.ContainsFunc(p.AllowedImages, func(pattern string) bool {
+			return slices.ContainsFunc(forms, func(form string) bool {
+				matched, _ := path.Match(pattern, form)
+				return matched
+			})
+		}) {
+			return fmt.Sprintf("does not match an allowed image (%s)", strings.Join(p.AllowedImages, ", "))
+		}
+	}
+	return ""
+}
+
+// dockerSocketPaths are where Docker daemons usually listen on the host
+var dockerSocketPaths = []string{"/var/run/docker.sock", "/run/docker.sock"}
+
+// dockerHostAccess reports whether the container reaches the host's Docker daemon: with
+// --docker-host-integration, or a bind mount of a Docker socket or of a directory
+// holding one, such as / or /var/run. The explanation names the mount, and is empty for
+// --docker-host-integration.
+func (c Container) dockerHostAccess() (string, bool) {
+	if c.DockerHostIntegration {
+		return "", true
+	}
+	sockets := dockerSocketPaths
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "unix://") {
+		sockets = append(slices.Clone(sockets), strings.TrimPrefix(host, "unix://"))
+	}
+	for _, source := range c.BindMounts {
+		source = filepath.Clean(source)
+		if filepath.Base(source) == "docker.sock" {
+			return " (bind mount of " + source + ")", true
+		}
+		for _, socket := range sockets {
+			if source == "/" || strings.HasPrefix(socket, source+"/") {
+				return " (bind mount of " + source + ", which holds " + socket + ")", true
+			}
+		}
+	}
+	return "", false
+}
+
+// HardeningViolations lists what keeps a container from being hardened. A hardened
+// container has no elevated privileges, no Docker socket and no host networking, runs
+// with the no-new-privileges security option as a user other than root, and has an
+// init process to reap zombies.
+func HardeningViolations(c Container) []string {
+	var violations []string
+	if len(c.ElevatedPrivileges) > 0 {
+		violations = append(violations, fmt.Sprintf("no elevated privileges (%s)", strings.Join(c.ElevatedPrivileges, ", ")))
+	}
+	if access, ok := c.dockerHostAccess(); ok {
+		violations = append(violations, "no docker host integration"+access)
+	}
+	if c.NetworkMode == "host" {
+		violations = append(violations, "no host networking")
+	}
+	if !slices.ContainsFunc(c.SecurityOpt, func(option string) bool {
+		return option == "no-new-privileges" || option == "no-new-privileges=true" || option == "no-new-privileges:true"
+	}) {
+		violations = append(violations, `the no-new-privileges security option ("securityOpt": ["no-new-privileges"])`)
+	}
+	if user, _, _ := strings.Cut(c.User, ":"); user == "" || user == "root" || user == "0" {
+		violations = append(violations, "a remoteUser other than root")
+	}
+	if !c.Init {
+		violations = append(violations, "an init process (init is off)")
+	}
+	return violations
+}
+
+// BaseImages returns the images a Dockerfile's FROM instructions build on, leaving out
+// scratch and earlier stages of the same file
+func BaseImages(dockerfile string) ([]string, error) {
+	data, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	var images []string
+	stages := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var line string
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "#") {
+			continue
+		}
+		// Join continuation lines into one instruction
+		if strings.HasSuffix(text, "\\") {
+			line += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+		line += text
+		fields := strings.Fields(line)
+		line = ""
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+		image := args[0]
+		earlierStage := stages[strings.ToLower(image)]
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages[strings.ToLower(args[2])] = true
+		}
+		if image != "scratch" && !earlierStage {
+			images = append(images, image)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	return images, nil
+}
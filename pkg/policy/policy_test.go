@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hardened is a container that every hardening rule accepts
+var hardened = Container{
+	Image:       "ghcr.io/myorg/dev:1",
+	SecurityOpt: []string{"no-new-privileges"},
+	NetworkMode: "bridge",
+	User:        "claude",
+	Init:        true,
+}
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv(EnvPolicy, "")
+
+	// No file means no policy
+	p, err := Load()
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	path := filepath.Join(home, ".reactor", FileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("forbid:\n  privileged: true\nallowedRegistries: [ghcr.io/myorg/]\nrequireHardened: true\n"), 0644))
+	p, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, path, p.Path)
+	assert.True(t, p.Forbid.Privileged)
+	assert.Equal(t, []string{"ghcr.io/myorg"}, p.AllowedRegistries)
+	assert.True(t, p.RequireHardened)
+
+	// REACTOR_POLICY points elsewhere, and the file it names must exist
+	t.Setenv(EnvPolicy, filepath.Join(home, "missing.yaml"))
+	_, err = Load()
+	assert.ErrorContains(t, err, "failed to read policy")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := parse("policy.yaml", []byte("forbid:\n  runArgs: true\n"))
+	assert.ErrorContains(t, err, "failed to parse policy policy.yaml")
+
+	_, err = parse("policy.yaml", []byte("allowedRegistries: [ghcr.io:443]\n"))
+	assert.ErrorContains(t, err, "allowedRegistries[0] 'ghcr.io:443' must be a registry")
+
+	_, err = parse("policy.yaml", []byte("allowedImages: ['node:[']\n"))
+	assert.ErrorContains(t, err, "allowedImages[0] 'node:[' is not a valid pattern")
+}
+
+func TestCheck_Forbid(t *testing.T) {
+	var none *Policy
+	assert.NoError(t, none.Check(Container{DockerHostIntegration: true}))
+
+	p := &Policy{Path: "policy.yaml", Forbid: Forbid{DockerHostIntegration: true, Privileged: true, HostNetwork: true}}
+	assert.NoError(t, p.Check(hardened))
+
+	err := p.Check(Container{DockerHostIntegration: true, ElevatedPrivileges: []string{"privileged mode", "capability SYS_ADMIN"}, NetworkMode: "host"})
+	var violation *ViolationError
+	require.ErrorAs(t, err, &violation)
+	assert.Equal(t, []string{
+		"docker host integration is forbidden",
+		"elevated privileges are forbidden (privileged mode, capability SYS_ADMIN)",
+		"host networking is forbidden",
+	}, violation.Violations)
+	assert.ErrorContains(t, err, "the policy in policy.yaml does not allow this container: docker host integration is forbidden; ")
+}
+
+func TestCheck_Images(t *testing.T) {
+	p := &Policy{Path: "policy.yaml", AllowedRegistries: []string{"ghcr.io/myorg", "docker.io/library"}}
+	assert.NoError(t, p.Check(Container{Image: "ghcr.io/myorg/dev:1"}))
+	assert.NoError(t, p.Check(Container{Image: "node:20"}))
+	assert.ErrorContains(t, p.Check(Container{Image: "ghcr.io/myorganisation/dev"}), "image ghcr.io/myorganisation/dev is not from an allowed registry (ghcr.io/myorg, docker.io/library)")
+	assert.ErrorContains(t, p.Check(Container{Image: "someone/tool"}), "not from an allowed registry")
+
+	p = &Policy{Path: "policy.yaml", AllowedImages: []string{"node:*", "ghcr.io/myorg/*"}}
+	assert.NoError(t, p.Check(Container{Image: "node"}), "latest is implied")
+	assert.NoError(t, p.Check(Container{Image: "docker.io/library/node:20"}))
+	assert.NoError(t, p.Check(Container{Image: "ghcr.io/myorg/dev:1"}))
+	assert.ErrorContains(t, p.Check(Container{Image: "python:3"}), "image python:3 does not match an allowed image (node:*, ghcr.io/myorg/*)")
+
+	// A built image is judged by its base images
+	err := p.Check(Container{BaseImages: []string{"node:20", "alpine:3", "${BASE}"}})
+	var violation *ViolationError
+	require.ErrorAs(t, err, &violation)
+	assert.Equal(t, []string{
+		"base image alpine:3 does not match an allowed image (node:*, ghcr.io/myorg/*)",
+		"base image ${BASE} cannot be checked against the allowed images, as it is not a plain image reference",
+	}, violation.Violations)
+}
+
+func TestHardeningViolations(t *testing.T) {
+	assert.Empty(t, HardeningViolations(hardened))
+
+	c := hardened
+	c.SecurityOpt = []string{"seccomp=unconfined"}
+	c.ElevatedPrivileges = []string{"security option seccomp=unconfined"}
+	c.User = "root"
+	c.Init = false
+	assert.Equal(t, []string{
+		"no elevated privileges (security option seccomp=unconfined)",
+		`the no-new-privileges security option ("securityOpt": ["no-new-privileges"])`,
+		"a remoteUser other than root",
+		"an init process (init is off)",
+	}, HardeningViolations(c))
+
+	p := &Policy{Path: "policy.yaml", RequireHardened: true}
+	c = hardened
+	c.User = "0:0"
+	assert.ErrorContains(t, p.Check(c), "hardened containers require a remoteUser other than root")
+}
+
+func TestBaseImages(t *testing.T) {
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	content := `# syntax=docker/dockerfile:1
+FROM --platform=$BUILDPLATFORM golang:1.23 AS build
+RUN go build ./...
+FROM scratch AS empty
+from build as test
+FROM \
+  gcr.io/distroless/base
+COPY --from=build /app /app
+`
+	require.NoError(t, os.WriteFile(dockerfile, []byte(content), 0644))
+	images, err := BaseImages(dockerfile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"golang:1.23", "gcr.io/distroless/base"}, images)
+
+	_, err = BaseImages(filepath.Join(t.TempDir(), "Dockerfile"))
+	assert.ErrorContains(t, err, "failed to read Dockerfile")
+}
+
+func TestCheck_DockerSocketMounts(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///home/me/.colima/default/docker.sock")
+	p := &Policy{Path: "policy.yaml", Forbid: Forbid{DockerHostIntegration: true}}
+	assert.NoError(t, p.Check(Container{BindMounts: []string{"/src/app", "/home/me/.reactor/work/abc123/claude"}}))
+
+	for mount, reason := range map[string]string{
+		"/var/run/docker.sock": "bind mount of /var/run/docker.sock",
+		"/tmp/docker.sock":     "bind mount of /tmp/docker.sock",
+		"/":                    "bind mount of /, which holds /var/run/docker.sock",
+		"/var/run/":            "bind mount of /var/run, which holds /var/run/docker.sock",
+		"/home/me/.colima":     "bind mount of /home/me/.colima, which holds /home/me/.colima/default/docker.sock",
+	} {
+		assert.ErrorContains(t, p.Check(Container{BindMounts: []string{"/src/app", mount}}), "docker host integration is forbidden ("+reason+")", mount)
+	}
+
+	assert.Contains(t, HardeningViolations(Container{BindMounts: []string{"/run"}}), "no docker host integration (bind mount of /run, which holds /run/docker.sock)")
+}
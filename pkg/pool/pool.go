@@ -0,0 +1,191 @@
+// Package pool keeps idle copies of a project's container created and running, so
+// 'reactor up' can take one over instead of waiting for a container to be created and
+// started. A pool container is created from the very container spec 'reactor up'
+// would use, with its mounts, environment and labels, under a pool name; 'reactor up'
+// claims it by renaming it to the project's container name. Pools are keyed by a hash
+// of the spec, so a container is only claimed when nothing but its name differs.
+package pool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+)
+
+// Label marks pool containers; its value is the Key of the spec they were created from
+const Label = "com.reactor.pool"
+
+// namePrefix starts the names of idle pool containers. They are not named reactor-...,
+// so session commands do not list them until they are claimed.
+const namePrefix = "reactorpool-"
+
+// Key identifies the pool of containers created from spec. Everything but the name
+// counts, and the order of mounts and environment variables does not.
+func Key(spec *docker.ContainerSpec) string {
+	canonical := *spec
+	canonical.Name = ""
+	canonical.Mounts = slices.Sorted(slices.Values(spec.Mounts))
+	canonical.Environment = slices.Sorted(slices.Values(spec.Environment))
+	// ContainerSpec holds only JSON-encodable values, and maps encode in key order
+	data, _ := json.Marshal(canonical)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// ContainerName returns the name of an idle container in the pool with key
+func ContainerName(key string, slot int) string {
+	return fmt.Sprintf("%s%d", poolNamePrefix(key), slot)
+}
+
+// poolNamePrefix starts the names of the idle containers of the pool with key
+func poolNamePrefix(key string) string {
+	name := fmt.Sprintf("%s%s-", namePrefix, key[:12])
+	if prefix := os.Getenv("REACTOR_ISOLATION_PREFIX"); prefix != "" {
+		name = prefix + "-" + name
+	}
+	return name
+}
+
+// Idle returns the idle containers in the pool with key, sorted by name. Claimed
+// containers keep the pool label but no longer have a pool name.
+func Idle(ctx context.Context, dockerService *docker.Service, key string) ([]docker.ContainerInfo, error) {
+	containers, err := dockerService.ListContainersByLabel(ctx, Label, key)
+	if err != nil {
+		return nil, err
+	}
+	idle := containers[:0]
+	for _, c := range containers {
+		if strings.HasPrefix(c.Name, poolNamePrefix(key)) {
+			idle = append(idle, c)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].Name < idle[j].Name })
+	return idle, nil
+}
+
+// Fill creates and starts containers from spec until its pool has size idle ones, and
+// returns how many it created. Idle containers left stopped, e.g. by a refill that was
+// interrupted, are started first. The image must be present.
+func Fill(ctx context.Context, dockerService *docker.Service, spec *docker.ContainerSpec, size int) (int, error) {
+	key := Key(spec)
+	idle, err := Idle(ctx, dockerService, key)
+	if err != nil {
+		return 0, err
+	}
+	taken := make(map[string]bool, len(idle))
+	running := 0
+	for _, c := range idle {
+		if c.Status != docker.StatusRunning {
+			if err := dockerService.StartContainer(ctx, c.ID); err != nil {
+				if err := dockerService.RemoveContainer(ctx, c.ID); err != nil {
+					return 0, err
+				}
+				continue
+			}
+		}
+		taken[c.Name] = true
+		running++
+	}
+
+	created := 0
+	for slot := 1; running+created < size; slot++ {
+		name := ContainerName(key, slot)
+		if taken[name] {
+			continue
+		}
+		poolSpec := *spec
+		poolSpec.Name = name
+		poolSpec.Labels = maps.Clone(spec.Labels)
+		if poolSpec.Labels == nil {
+			poolSpec.Labels = make(map[string]string)
+		}
+		poolSpec.Labels[Label] = key
+		info, err := dockerService.CreateContainer(ctx, &poolSpec)
+		if err != nil {
+			return created, err
+		}
+		if err := dockerService.StartContainer(ctx, info.ID); err != nil {
+			_ = dockerService.RemoveContainer(ctx, info.ID)
+			return created, fmt.Errorf("failed to start pool container %s: %w", name, err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+// Claim takes an idle container out of the pool of spec by renaming it to spec.Name,
+// so it becomes the container 'reactor up' was about to create. It returns false when
+// the pool has no idle containers.
+func Claim(ctx context.Context, dockerService *docker.Service, spec *docker.ContainerSpec) (bool, error) {
+	idle, err := Idle(ctx, dockerService, Key(spec))
+	if err != nil {
+		return false, err
+	}
+	// Another 'reactor up' may claim the same container, so try the next one when the
+	// rename fails
+	for i := len(idle) - 1; i >= 0; i-- {
+		if err = dockerService.RenameContainer(ctx, idle[i].ID, spec.Name); err == nil {
+			return true, nil
+		}
+	}
+	return false, err
+}
+
+// Drain removes every idle container of the pool with key and returns how many it removed
+func Drain(ctx context.Context, dockerService *docker.Service, key string) (int, error) {
+	idle, err := Idle(ctx, dockerService, key)
+	if err != nil {
+		return 0, err
+	}
+	for i, c := range idle {
+		if err := dockerService.RemoveContainer(ctx, c.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(idle), nil
+}
+
+// Start records a pool of size idle containers created from spec and fills it. The
+// image must be present.
+func Start(ctx context.Context, dockerService *docker.Service, spec *docker.ContainerSpec, size int) (int, error) {
+	if size < 1 {
+		return 0, fmt.Errorf("pool size must be at least 1")
+	}
+	if err := state.SetPool(Key(spec), state.Pool{Size: size, Container: spec.Name, Image: spec.Image}); err != nil {
+		return 0, err
+	}
+	return Fill(ctx, dockerService, spec, size)
+}
+
+// Stop removes the idle containers of the pool with key and forgets the pool
+func Stop(ctx context.Context, dockerService *docker.Service, key string) (int, error) {
+	removed, err := Drain(ctx, dockerService, key)
+	if err != nil {
+		return removed, err
+	}
+	return removed, state.ForgetPool(key)
+}
+
+// Refill tops up the pool of spec to the size it was started with. It does nothing
+// when no pool was started for the spec.
+func Refill(ctx context.Context, dockerService *docker.Service, spec *docker.ContainerSpec) (int, error) {
+	pools, err := state.Pools()
+	if err != nil {
+		return 0, err
+	}
+	pool, ok := pools[Key(spec)]
+	if !ok {
+		return 0, nil
+	}
+	return Fill(ctx, dockerService, spec, pool.Size)
+}
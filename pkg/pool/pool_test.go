@@ -0,0 +1,250 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/dyluth/reactor/pkg/docker"
+	"github.com/dyluth/reactor/pkg/state"
+	"github.com/dyluth/reactor/pkg/testutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDaemon keeps containers in memory, implementing the calls the pool makes
+type fakeDaemon struct {
+	docker.DockerClient
+	containers map[string]*fakeContainer // by ID
+	nextID     int
+	failStart  bool
+}
+
+type fakeContainer struct {
+	name    string
+	image   string
+	labels  map[string]string
+	binds   []string
+	running bool
+}
+
+func newFakeDaemon() *fakeDaemon {
+	return &fakeDaemon{containers: make(map[string]*fakeContainer)}
+}
+
+func (f *fakeDaemon) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	var summaries []container.Summary
+	for id, c := range f.containers {
+		state := "created"
+		if c.running {
+			state = "running"
+		}
+		summaries = append(summaries, container.Summary{ID: id, Names: []string{"/" + c.name}, Image: c.image, Labels: c.labels, State: state})
+	}
+	return summaries, nil
+}
+
+func (f *fakeDaemon) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	for _, c := range f.containers {
+		if c.name == containerName {
+			return container.CreateResponse{}, fmt.Errorf("conflict: name %s is in use", containerName)
+		}
+	}
+	f.nextID++
+	id := fmt.Sprintf("c%d", f.nextID)
+	f.containers[id] = &fakeContainer{name: containerName, image: config.Image, labels: config.Labels, binds: hostConfig.Binds}
+	return container.CreateResponse{ID: id}, nil
+}
+
+func (f *fakeDaemon) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	if f.failStart {
+		return errors.New("exec: sleep: not found")
+	}
+	f.containers[containerID].running = true
+	return nil
+}
+
+func (f *fakeDaemon) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	delete(f.containers, containerID)
+	return nil
+}
+
+func (f *fakeDaemon) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	for _, c := range f.containers {
+		if c.name == newContainerName {
+			return fmt.Errorf("conflict: name %s is in use", newContainerName)
+		}
+	}
+	f.containers[containerID].name = newContainerName
+	return nil
+}
+
+func (f *fakeDaemon) ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error) {
+	c, ok := f.containers[containerID]
+	if !ok {
+		return container.InspectResponse{}, fmt.Errorf("no such container: %s", containerID)
+	}
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{ID: containerID, Name: "/" + c.name, State: &container.State{Running: c.running}},
+		Config:            &container.Config{Image: c.image, Labels: c.labels},
+	}, nil
+}
+
+// names returns the names of the daemon's containers, sorted
+func (f *fakeDaemon) names() []string {
+	var names []string
+	for _, c := range f.containers {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func testSpec() *docker.ContainerSpec {
+	return &docker.ContainerSpec{
+		Name:        "reactor-me-app-abc123",
+		Image:       "ghcr.io/acme/dev:1",
+		Mounts:      []string{"/src/app:/workspace", "/home/me/.reactor/me/claude:/home/claude/.claude"},
+		Environment: []string{"A=1", "B=2"},
+		Labels:      map[string]string{"com.reactor.project": "/src/app"},
+	}
+}
+
+func TestKey(t *testing.T) {
+	spec := testSpec()
+	key := Key(spec)
+	assert.Len(t, key, 64)
+
+	renamed := testSpec()
+	renamed.Name = "reactorpool-0123-1"
+	assert.Equal(t, key, Key(renamed), "the name does not count")
+
+	reordered := testSpec()
+	reordered.Mounts = []string{reordered.Mounts[1], reordered.Mounts[0]}
+	reordered.Environment = []string{"B=2", "A=1"}
+	assert.Equal(t, key, Key(reordered), "the order of mounts and environment variables does not count")
+
+	otherImage := testSpec()
+	otherImage.Image = "ghcr.io/acme/dev:2"
+	assert.NotEqual(t, key, Key(otherImage))
+
+	otherMount := testSpec()
+	otherMount.Mounts = append(otherMount.Mounts, "/var/run/docker.sock:/var/run/docker.sock")
+	assert.NotEqual(t, key, Key(otherMount))
+}
+
+func TestContainerName(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	key := Key(testSpec())
+
+	name := ContainerName(key, 1)
+	assert.Equal(t, "reactorpool-"+key[:12]+"-1", name)
+	assert.False(t, strings.HasPrefix(name, "reactor-"), "idle containers are not listed as sessions")
+
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "ci")
+	assert.Equal(t, "ci-"+name, ContainerName(key, 1))
+}
+
+func TestStart_Size(t *testing.T) {
+	_, err := Start(context.Background(), nil, testSpec(), 0)
+	assert.EqualError(t, err, "pool size must be at least 1")
+}
+
+func TestStartClaimRefill(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	ctx := context.Background()
+	daemon := newFakeDaemon()
+	dockerService := docker.NewServiceWithClient(daemon)
+	spec := testSpec()
+	key := Key(spec)
+
+	created, err := Start(ctx, dockerService, spec, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, []string{ContainerName(key, 1), ContainerName(key, 2)}, daemon.names())
+	for _, c := range daemon.containers {
+		assert.True(t, c.running)
+		assert.Equal(t, spec.Mounts, c.binds, "idle containers are created from the full spec")
+		assert.Equal(t, key, c.labels[Label])
+		assert.Equal(t, "/src/app", c.labels["com.reactor.project"])
+	}
+	assert.NotContains(t, spec.Labels, Label, "the spec is not changed")
+	pools, err := state.Pools()
+	require.NoError(t, err)
+	assert.Equal(t, state.Pool{Size: 2, Container: spec.Name, Image: spec.Image, StartedAt: pools[key].StartedAt}, pools[key])
+
+	// Starting again tops up rather than adding containers
+	created, err = Start(ctx, dockerService, spec, 2)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	// A container created from another spec does not claim from the pool
+	other := testSpec()
+	other.Environment = append(other.Environment, "C=3")
+	claimed, err := Claim(ctx, dockerService, other)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// The claimed container becomes the project's container and leaves the pool
+	claimed, err = Claim(ctx, dockerService, spec)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, []string{spec.Name, ContainerName(key, 1)}, daemon.names())
+	idle, err := Idle(ctx, dockerService, key)
+	require.NoError(t, err)
+	assert.Len(t, idle, 1)
+
+	created, err = Refill(ctx, dockerService, spec)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.Equal(t, []string{spec.Name, ContainerName(key, 1), ContainerName(key, 2)}, daemon.names())
+
+	// Nothing is refilled for a spec without a pool
+	created, err = Refill(ctx, dockerService, other)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	removed, err := Stop(ctx, dockerService, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, []string{spec.Name}, daemon.names(), "the claimed container is not removed")
+	pools, err = state.Pools()
+	require.NoError(t, err)
+	assert.Empty(t, pools)
+}
+
+func TestFill_StartsInterruptedContainers(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	ctx := context.Background()
+	daemon := newFakeDaemon()
+	dockerService := docker.NewServiceWithClient(daemon)
+	spec := testSpec()
+	key := Key(spec)
+
+	// A refill interrupted between create and start leaves a container that never ran
+	daemon.containers["c0"] = &fakeContainer{name: ContainerName(key, 1), image: spec.Image, labels: map[string]string{Label: key}}
+
+	created, err := Fill(ctx, dockerService, spec, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.True(t, daemon.containers["c0"].running)
+	assert.Equal(t, []string{ContainerName(key, 1), ContainerName(key, 2)}, daemon.names())
+}
+
+func TestFill_StartFailure(t *testing.T) {
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	daemon := newFakeDaemon()
+	daemon.failStart = true
+
+	created, err := Fill(context.Background(), docker.NewServiceWithClient(daemon), testSpec(), 1)
+	assert.ErrorContains(t, err, "failed to start pool container")
+	assert.Zero(t, created)
+	assert.Empty(t, daemon.containers, "a container that cannot start is removed")
+}
@@ -0,0 +1,254 @@
+// Package portforward forwards host ports to a running container's IP address from a
+// background reactor process. Docker cannot publish ports on an existing container,
+// so this adds ports without recreating it. The container's IP address is looked up
+// for every connection, as a restarted container may get another.
+package portforward
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/background"
+	"github.com/dyluth/reactor/pkg/filelock"
+	"github.com/dyluth/reactor/pkg/netpipe"
+)
+
+// stateFile records the running forwards in the project config dir
+const stateFile = "ports.json"
+
+// Forward is a host port forwarded to a port of a container
+type Forward struct {
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	ContainerID   string `json:"containerId"`
+	Target        string `json:"target"` // container IP and port when the forward was added
+	PID           int    `json:"pid"`
+}
+
+// ServeArgs are the arguments the background process serving a forward runs with,
+// after the reactor binary. They identify the process, so another one that got its
+// PID after it exited is never signalled.
+func ServeArgs(f Forward) []string {
+	return []string{"ports", "serve", strconv.Itoa(f.HostPort), f.ContainerID, strconv.Itoa(f.ContainerPort)}
+}
+
+// running reports whether the process serving f still runs
+func (f Forward) running() bool {
+	return background.Running(f.PID, func(args []string) bool {
+		return background.Contains(args, ServeArgs(f)...)
+	})
+}
+
+// stop asks the process serving f to exit; it may already have exited
+func (f Forward) stop() {
+	background.Stop(f.PID, func(args []string) bool {
+		return background.Contains(args, ServeArgs(f)...)
+	})
+}
+
+// ParseSpec parses "host:container" or "port", which forwards a port under its own number
+func ParseSpec(spec string) (hostPort, containerPort int, err error) {
+	host, container, found := strings.Cut(spec, ":")
+	if !found {
+		container = host
+	}
+	if hostPort, err = parsePort(host); err != nil {
+		return 0, 0, fmt.Errorf("invalid port '%s': %w", spec, err)
+	}
+	if containerPort, err = parsePort(container); err != nil {
+		return 0, 0, fmt.Errorf("invalid port '%s': %w", spec, err)
+	}
+	return hostPort, containerPort, nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("ports must be numbers between 1 and 65535")
+	}
+	return port, nil
+}
+
+// Start launches executable with ServeArgs in the background to serve f and records
+// it in the project config dir. The host port is checked first, so a port in use
+// fails here rather than in the background process.
+func Start(projectConfigDir string, f Forward, executable string) (Forward, error) {
+	unlock, err := lock(projectConfigDir)
+	if err != nil {
+		return Forward{}, err
+	}
+	defer unlock()
+	forwards, err := List(projectConfigDir)
+	if err != nil {
+		return Forward{}, err
+	}
+	for _, existing := range forwards {
+		if existing.HostPort == f.HostPort {
+			return Forward{}, fmt.Errorf("port %d is already forwarded to container port %d; remove it first", f.HostPort, existing.ContainerPort)
+		}
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(f.HostPort)))
+	if err != nil {
+		return Forward{}, fmt.Errorf("port %d is not available: %w", f.HostPort, err)
+	}
+	_ = listener.Close()
+
+	cmd := exec.Command(executable, ServeArgs(f)...)
+	// Detach from the terminal's process group so the forward outlives the command
+	if err := background.Start(cmd); err != nil {
+		return Forward{}, fmt.Errorf("failed to start port forward: %w", err)
+	}
+	f.PID = cmd.Process.Pid
+	if err := save(projectConfigDir, append(forwards, f)); err != nil {
+		_ = cmd.Process.Kill()
+		return Forward{}, err
+	}
+	// The forward outlives this process; reap it in the background while we are still running
+	go func() { _ = cmd.Wait() }()
+	return f, nil
+}
+
+// List returns the running forwards of a project sorted by host port. Forwards whose
+// process has exited are dropped.
+func List(projectConfigDir string) ([]Forward, error) {
+	data, err := os.ReadFile(filepath.Join(projectConfigDir, stateFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read port forwards: %w", err)
+	}
+	var recorded []Forward
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to read port forwards: %w", err)
+	}
+	var forwards []Forward
+	for _, f := range recorded {
+		if f.running() {
+			forwards = append(forwards, f)
+		}
+	}
+	sort.Slice(forwards, func(i, j int) bool { return forwards[i].HostPort < forwards[j].HostPort })
+	return forwards, nil
+}
+
+// Remove stops the forward of a host port, reporting whether there was one
+func Remove(projectConfigDir string, hostPort int) (bool, error) {
+	unlock, err := lock(projectConfigDir)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	forwards, err := List(projectConfigDir)
+	if err != nil {
+		return false, err
+	}
+	var kept []Forward
+	found := false
+	for _, f := range forwards {
+		if f.HostPort == hostPort {
+			f.stop()
+			found = true
+			continue
+		}
+		kept = append(kept, f)
+	}
+	if !found {
+		return false, nil
+	}
+	return true, save(projectConfigDir, kept)
+}
+
+// StopAll stops every forward of a project, as when its container is removed
+func StopAll(projectConfigDir string) error {
+	unlock, err := lock(projectConfigDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	forwards, err := List(projectConfigDir)
+	if err != nil {
+		return err
+	}
+	for _, f := range forwards {
+		f.stop()
+	}
+	if err := os.Remove(filepath.Join(projectConfigDir, stateFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove port forward state: %w", err)
+	}
+	return nil
+}
+
+// Serve accepts connections on the host port and pipes each to the address target
+// returns for it until ctx is cancelled
+func Serve(ctx context.Context, hostPort int, target func(ctx context.Context) (string, error), logf func(format string, args ...any)) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(hostPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", hostPort, err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	var dialer net.Dialer
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			logf("Failed to accept a connection on port %d: %v", hostPort, err)
+			continue
+		}
+		go func() {
+			defer func() { _ = conn.Close() }()
+			address, err := target(ctx)
+			if err != nil {
+				logf("Failed to find where to forward port %d: %v", hostPort, err)
+				return
+			}
+			upstream, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				logf("Failed to reach %s: %v", address, err)
+				return
+			}
+			defer func() { _ = upstream.Close() }()
+			netpipe.Pipe(conn, upstream)
+		}()
+	}
+}
+
+// lock serializes changes to the forwards of a project
+func lock(projectConfigDir string) (func(), error) {
+	return filelock.Lock(filepath.Join(projectConfigDir, stateFile))
+}
+
+// save records the forwards of a project, removing the file when there are none;
+// callers hold the lock
+func save(projectConfigDir string, forwards []Forward) error {
+	path := filepath.Join(projectConfigDir, stateFile)
+	if len(forwards) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove port forward state: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(forwards, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode port forwards: %w", err)
+	}
+	if err := filelock.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save port forwards: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package portforward
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpec(t *testing.T) {
+	host, container, err := ParseSpec("8080:80")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, host)
+	assert.Equal(t, 80, container)
+
+	host, container, err = ParseSpec("3000")
+	require.NoError(t, err)
+	assert.Equal(t, 3000, host)
+	assert.Equal(t, 3000, container)
+
+	for _, spec := range []string{"", "http", "8080:", "0", "70000", "8080:80:80"} {
+		_, _, err := ParseSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+// freePort returns a host port that is free at the time of the call
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// echoServer starts an upstream that answers each line with prefix and the line
+func echoServer(t *testing.T, prefix string) string {
+	t.Helper()
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = upstream.Close() })
+	go func() {
+		for {
+			conn, err := upstream.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				_, _ = conn.Write([]byte(prefix + line))
+			}()
+		}
+	}()
+	return upstream.Addr().String()
+}
+
+func TestServe(t *testing.T) {
+	// The target moves, as a container's IP address does when it is restarted
+	first, second := echoServer(t, "first "), echoServer(t, "second ")
+	var mu sync.Mutex
+	target := first
+	resolve := func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return target, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	port := freePort(t)
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, port, resolve, t.Logf) }()
+
+	send := func() string {
+		var conn net.Conn
+		var err error
+		require.Eventually(t, func() bool {
+			conn, err = net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+			return err == nil
+		}, 5*time.Second, 20*time.Millisecond)
+		defer func() { _ = conn.Close() }()
+		_, err = conn.Write([]byte("hello\n"))
+		require.NoError(t, err)
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		return reply
+	}
+	assert.Equal(t, "first hello\n", send())
+
+	mu.Lock()
+	target = second
+	mu.Unlock()
+	assert.Equal(t, "second hello\n", send(), "every connection looks the target up")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// serveStub returns a stand-in for the reactor binary that stays alive like the
+// process serving a forward would
+func serveStub(t *testing.T) string {
+	t.Helper()
+	stub := filepath.Join(t.TempDir(), "reactor")
+	require.NoError(t, os.WriteFile(stub, []byte("#!/bin/sh\nsleep 60\n"), 0755))
+	return stub
+}
+
+func TestStartListRemove(t *testing.T) {
+	dir := t.TempDir()
+	port := freePort(t)
+
+	stub := serveStub(t)
+
+	f, err := Start(dir, Forward{HostPort: port, ContainerPort: 80, ContainerID: "abc", Target: "172.17.0.2:80"}, stub)
+	require.NoError(t, err)
+	assert.NotZero(t, f.PID)
+
+	forwards, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, forwards, 1)
+	assert.Equal(t, f, forwards[0])
+
+	_, err = Start(dir, Forward{HostPort: port, ContainerPort: 81}, stub)
+	assert.EqualError(t, err, "port "+strconv.Itoa(port)+" is already forwarded to container port 80; remove it first")
+
+	removed, err := Remove(dir, port+1)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	removed, err = Remove(dir, port)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoFileExists(t, filepath.Join(dir, stateFile))
+}
+
+func TestList_DropsExitedForwards(t *testing.T) {
+	dir := t.TempDir()
+	f, err := Start(dir, Forward{HostPort: freePort(t), ContainerPort: 80, ContainerID: "abc"}, serveStub(t))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = StopAll(dir) })
+
+	// A forward whose PID now belongs to another process, here the test, has exited too
+	require.NoError(t, save(dir, []Forward{f, {HostPort: 8080, ContainerPort: 80, PID: os.Getpid()}, {HostPort: 9090, ContainerPort: 90, PID: 0}}))
+
+	forwards, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, forwards, 1)
+	assert.Equal(t, f.HostPort, forwards[0].HostPort)
+}
+
+func TestStart_PortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	_, err = Start(t.TempDir(), Forward{HostPort: port, ContainerPort: 80}, "sleep")
+	assert.ErrorContains(t, err, "port "+strconv.Itoa(port)+" is not available")
+}
@@ -0,0 +1,133 @@
+// Package prefetch finds the dev container configurations of a directory tree and
+// fetches their images ahead of time with a limited number running at once.
+package prefetch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+)
+
+// skippedDirs are never searched for dev container configurations
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Target is one dev container configuration whose image is prefetched
+type Target struct {
+	// Dir is the absolute project directory
+	Dir string
+	// ConfigName selects .devcontainer/<name>/devcontainer.json, empty for the default
+	ConfigName string
+	// Account overrides the project's account, as a workspace service's account does
+	Account string
+	// Label names the target in progress output
+	Label string
+}
+
+// Discover returns a target for every devcontainer.json under root, including the
+// .devcontainer/<name>/ variants. Hidden directories (such as .git), node_modules and
+// vendor are not searched.
+func Discover(root string) ([]Target, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	var targets []Target
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than failing the whole search
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+
+		files, err := config.ListDevContainerFiles(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			label := rel
+			if file.Name != "" {
+				label = filepath.Join(rel, ".devcontainer", file.Name)
+			}
+			targets = append(targets, Target{Dir: path, ConfigName: file.Name, Label: label})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", root, err)
+	}
+	return targets, nil
+}
+
+// Job fetches one image. Fetch returns what it did, e.g. "pulled" or "up to date".
+type Job struct {
+	Name  string
+	Fetch func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of a job
+type Result struct {
+	Job     Job
+	Outcome string
+	Err     error
+	Elapsed time.Duration
+}
+
+// Run runs the jobs with at most limit at a time and returns their results in job
+// order. done, when set, is called as each job finishes; calls are never concurrent.
+func Run(ctx context.Context, jobs []Job, limit int, done func(Result)) []Result {
+	if limit < 1 {
+		limit = 1
+	}
+
+	results := make([]Result, len(jobs))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job Job) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			result := Result{Job: job}
+			// Jobs still waiting for a slot when the context is cancelled are not started
+			if result.Err = ctx.Err(); result.Err == nil {
+				started := time.Now()
+				result.Outcome, result.Err = job.Fetch(ctx)
+				result.Elapsed = time.Since(started)
+			}
+			<-sem
+			results[i] = result
+
+			if done != nil {
+				mu.Lock()
+				done(result)
+				mu.Unlock()
+			}
+		}(i, job)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,109 @@
+package prefetch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`{"image": "alpine"}`), 0644))
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	writeConfig(t, filepath.Join(root, ".devcontainer", "devcontainer.json"))
+	writeConfig(t, filepath.Join(root, "services", "api", ".devcontainer.json"))
+	writeConfig(t, filepath.Join(root, "services", "web", ".devcontainer", "devcontainer.json"))
+	writeConfig(t, filepath.Join(root, "services", "web", ".devcontainer", "gpu", "devcontainer.json"))
+	// Never searched
+	writeConfig(t, filepath.Join(root, "node_modules", "pkg", ".devcontainer", "devcontainer.json"))
+	writeConfig(t, filepath.Join(root, ".git", "x", ".devcontainer.json"))
+
+	targets, err := Discover(root)
+	require.NoError(t, err)
+
+	var labels []string
+	for _, target := range targets {
+		labels = append(labels, target.Label)
+		assert.True(t, filepath.IsAbs(target.Dir))
+	}
+	assert.Equal(t, []string{
+		".",
+		filepath.Join("services", "api"),
+		filepath.Join("services", "web"),
+		filepath.Join("services", "web", ".devcontainer", "gpu"),
+	}, labels)
+	assert.Equal(t, "gpu", targets[3].ConfigName)
+	assert.Equal(t, filepath.Join(root, "services", "web"), targets[3].Dir)
+}
+
+func TestDiscover_Empty(t *testing.T) {
+	targets, err := Discover(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+}
+
+func TestRun_LimitsConcurrency(t *testing.T) {
+	var running, peak int32
+	jobs := make([]Job, 8)
+	for i := range jobs {
+		jobs[i] = Job{Name: string(rune('a' + i)), Fetch: func(ctx context.Context) (string, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return "pulled", nil
+		}}
+	}
+
+	var done []string
+	results := Run(context.Background(), jobs, 3, func(result Result) {
+		done = append(done, result.Job.Name)
+	})
+
+	assert.LessOrEqual(t, peak, int32(3))
+	assert.Len(t, done, 8)
+	require.Len(t, results, 8)
+	for i, result := range results {
+		assert.Equal(t, jobs[i].Name, result.Job.Name)
+		assert.Equal(t, "pulled", result.Outcome)
+		assert.NoError(t, result.Err)
+	}
+}
+
+func TestRun_ReportsFailures(t *testing.T) {
+	jobs := []Job{
+		{Name: "ok", Fetch: func(ctx context.Context) (string, error) { return "present", nil }},
+		{Name: "bad", Fetch: func(ctx context.Context) (string, error) { return "", errors.New("denied") }},
+	}
+	results := Run(context.Background(), jobs, 0, nil)
+	assert.NoError(t, results[0].Err)
+	assert.EqualError(t, results[1].Err, "denied")
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	started := false
+	results := Run(ctx, []Job{{Name: "a", Fetch: func(ctx context.Context) (string, error) {
+		started = true
+		return "pulled", nil
+	}}}, 1, nil)
+	assert.False(t, started)
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+}
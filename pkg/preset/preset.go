@@ -0,0 +1,223 @@
+package preset
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/config"
+)
+
+const (
+	// ArtifactType identifies reactor presets in OCI manifests
+	ArtifactType = "application/vnd.reactor.preset.v1"
+
+	// LayerMediaType is the media type of the preset content layer
+	LayerMediaType = "application/vnd.reactor.preset.layer.v1.tar+gzip"
+
+	// maxPresetSize caps both packaged and fetched presets to keep registries and
+	// projects free of accidental large payloads
+	maxPresetSize = 10 * 1024 * 1024
+)
+
+// Package archives the dev container configuration of a project into a gzipped tar.
+// When the configuration lives in .devcontainer/, the whole directory is included
+// (Dockerfile, feature config and scripts). For a root .devcontainer.json, the file
+// itself plus a build Dockerfile located inside the project are included.
+// It returns the archive and the list of packaged paths relative to projectRoot.
+func Package(projectRoot string) ([]byte, []string, error) {
+	configPath, found, err := config.FindDevContainerFile(projectRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error searching for devcontainer.json: %w", err)
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("no devcontainer.json found in %s. Run 'reactor config init' to create one", projectRoot)
+	}
+
+	var files []string
+	if filepath.Base(filepath.Dir(configPath)) == ".devcontainer" {
+		devcontainerDir := filepath.Dir(configPath)
+		err := filepath.Walk(devcontainerDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				relPath, err := filepath.Rel(projectRoot, path)
+				if err != nil {
+					return err
+				}
+				files = append(files, relPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read .devcontainer directory: %w", err)
+		}
+	} else {
+		files = append(files, ".devcontainer.json")
+		if dockerfile := referencedDockerfile(configPath, projectRoot); dockerfile != "" {
+			files = append(files, dockerfile)
+		}
+	}
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for _, relPath := range files {
+		data, err := os.ReadFile(filepath.Join(projectRoot, relPath))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+
+		header := &tar.Header{
+			Name:     filepath.ToSlash(relPath),
+			Mode:     0644,
+			Size:     int64(len(data)),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, nil, fmt.Errorf("failed to write archive header for %s: %w", relPath, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, nil, fmt.Errorf("failed to write %s to archive: %w", relPath, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+
+	if buf.Len() > maxPresetSize {
+		return nil, nil, fmt.Errorf("preset is too large (%d bytes, limit %d bytes)", buf.Len(), maxPresetSize)
+	}
+
+	return buf.Bytes(), files, nil
+}
+
+// Extract unpacks a preset archive into targetDir. It refuses to overwrite existing
+// files and rejects entries that would escape the target directory.
+// It returns the list of extracted paths relative to targetDir.
+func Extract(archive []byte, targetDir string) ([]string, error) {
+	entries, err := readArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for conflicts before writing anything
+	var conflicts []string
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join(targetDir, entry.path)); err == nil {
+			conflicts = append(conflicts, entry.path)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("preset files would conflict with existing files: %s\n"+
+			"Please remove these files or run the command in a different directory",
+			strings.Join(conflicts, ", "))
+	}
+
+	var extracted []string
+	for _, entry := range entries {
+		filePath := filepath.Join(targetDir, entry.path)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", entry.path, err)
+		}
+		if err := os.WriteFile(filePath, entry.data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file %s: %w", entry.path, err)
+		}
+		extracted = append(extracted, entry.path)
+	}
+
+	return extracted, nil
+}
+
+type archiveEntry struct {
+	path string
+	data []byte
+}
+
+// readArchive decodes and validates all regular file entries of a preset archive
+func readArchive(archive []byte) ([]archiveEntry, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("invalid preset archive: %w", err)
+	}
+	defer func() { _ = gr.Close() }()
+
+	var entries []archiveEntry
+	var total int64
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Security check: entries must stay within the target directory
+		cleanPath := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("preset archive contains unsafe path: %s", header.Name)
+		}
+
+		total += header.Size
+		if total > maxPresetSize {
+			return nil, fmt.Errorf("preset archive exceeds size limit of %d bytes", maxPresetSize)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxPresetSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from preset archive: %w", header.Name, err)
+		}
+		entries = append(entries, archiveEntry{path: cleanPath, data: data})
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("preset archive is empty")
+	}
+
+	return entries, nil
+}
+
+// referencedDockerfile returns the build Dockerfile of a root .devcontainer.json,
+// relative to projectRoot, when it exists inside the project
+func referencedDockerfile(configPath, projectRoot string) string {
+	devConfig, err := config.LoadDevContainerConfig(configPath)
+	if err != nil || devConfig.Build == nil {
+		return ""
+	}
+
+	dockerfile := devConfig.Build.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	contextDir := filepath.Dir(configPath)
+	if devConfig.Build.Context != "" && !filepath.IsAbs(devConfig.Build.Context) {
+		contextDir = filepath.Join(contextDir, devConfig.Build.Context)
+	}
+
+	relPath, err := filepath.Rel(projectRoot, filepath.Join(contextDir, dockerfile))
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(projectRoot, relPath)); err != nil {
+		return ""
+	}
+	return relPath
+}
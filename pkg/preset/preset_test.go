@@ -0,0 +1,88 @@
+package preset
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageAndExtract(t *testing.T) {
+	t.Run("devcontainer directory is packaged in full", func(t *testing.T) {
+		projectDir := t.TempDir()
+		devcontainerDir := filepath.Join(projectDir, ".devcontainer")
+		require.NoError(t, os.MkdirAll(filepath.Join(devcontainerDir, "scripts"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(devcontainerDir, "devcontainer.json"), []byte(`{"build": {"dockerfile": "Dockerfile"}}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(devcontainerDir, "Dockerfile"), []byte("FROM alpine\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(devcontainerDir, "scripts", "setup.sh"), []byte("#!/bin/sh\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n"), 0644))
+
+		archive, files, err := Package(projectDir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(".devcontainer", "Dockerfile"),
+			filepath.Join(".devcontainer", "devcontainer.json"),
+			filepath.Join(".devcontainer", "scripts", "setup.sh"),
+		}, files)
+
+		targetDir := t.TempDir()
+		extracted, err := Extract(archive, targetDir)
+		require.NoError(t, err)
+		assert.Len(t, extracted, 3)
+
+		content, err := os.ReadFile(filepath.Join(targetDir, ".devcontainer", "Dockerfile"))
+		require.NoError(t, err)
+		assert.Equal(t, "FROM alpine\n", string(content))
+		assert.NoFileExists(t, filepath.Join(targetDir, "main.go"))
+	})
+
+	t.Run("root devcontainer.json includes referenced Dockerfile", func(t *testing.T) {
+		projectDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".devcontainer.json"), []byte(`{
+			// JSONC comments are allowed
+			"build": {"dockerfile": "Dockerfile.dev"}
+		}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Dockerfile.dev"), []byte("FROM alpine\n"), 0644))
+
+		_, files, err := Package(projectDir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{".devcontainer.json", "Dockerfile.dev"}, files)
+	})
+
+	t.Run("missing devcontainer.json", func(t *testing.T) {
+		_, _, err := Package(t.TempDir())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no devcontainer.json found")
+	})
+
+	t.Run("extract refuses to overwrite existing files", func(t *testing.T) {
+		projectDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".devcontainer.json"), []byte(`{"image": "alpine"}`), 0644))
+		archive, _, err := Package(projectDir)
+		require.NoError(t, err)
+
+		_, err = Extract(archive, projectDir)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "conflict with existing files")
+	})
+
+	t.Run("extract rejects path traversal", func(t *testing.T) {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil.sh", Mode: 0644, Size: 4, Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte("evil"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		_, err = Extract(buf.Bytes(), t.TempDir())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unsafe path")
+	})
+}
@@ -0,0 +1,427 @@
+package preset
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/registryauth"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// emptyConfig is the OCI empty descriptor content used as artifact config
+var emptyConfig = []byte("{}")
+
+// Reference identifies a preset in an OCI registry
+type Reference struct {
+	Registry   string // registry host, e.g. ghcr.io or localhost:5000
+	Repository string // repository path, e.g. org/presets/python-agent
+	Tag        string // tag or digest (sha256:...)
+}
+
+// String returns the canonical form of the reference
+func (r Reference) String() string {
+	if strings.HasPrefix(r.Tag, "sha256:") {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Tag)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// ParseReference parses an OCI reference such as ghcr.io/org/preset:v1 or
+// registry.local:5000/preset@sha256:<hex>. The tag defaults to "latest" and
+// references without a registry host resolve to Docker Hub.
+func ParseReference(ref string) (Reference, error) {
+	ref = strings.TrimPrefix(ref, "oci://")
+	if ref == "" {
+		return Reference{}, fmt.Errorf("reference cannot be empty")
+	}
+
+	var result Reference
+	name := ref
+	if idx := strings.Index(ref, "@"); idx >= 0 {
+		name = ref[:idx]
+		result.Tag = ref[idx+1:]
+		if !strings.HasPrefix(result.Tag, "sha256:") {
+			return Reference{}, fmt.Errorf("invalid digest in reference '%s': only sha256 digests are supported", ref)
+		}
+	} else if idx := strings.LastIndex(ref, ":"); idx > strings.LastIndex(ref, "/") {
+		name = ref[:idx]
+		result.Tag = ref[idx+1:]
+	}
+	if result.Tag == "" {
+		result.Tag = "latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		result.Registry = parts[0]
+		result.Repository = parts[1]
+	} else {
+		result.Registry = "docker.io"
+		result.Repository = name
+		if !strings.Contains(name, "/") {
+			result.Repository = "library/" + name
+		}
+	}
+
+	if result.Repository == "" || strings.HasSuffix(result.Repository, "/") {
+		return Reference{}, fmt.Errorf("invalid reference '%s': missing repository name", ref)
+	}
+	if result.Repository != strings.ToLower(result.Repository) {
+		return Reference{}, fmt.Errorf("invalid reference '%s': repository name must be lowercase", ref)
+	}
+
+	return result, nil
+}
+
+// Client pushes and pulls presets using the OCI distribution API
+type Client struct {
+	httpClient *http.Client
+	token      string
+}
+
+// NewClient creates a registry client with sensible timeouts
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Push uploads a preset archive and tags it at ref. It returns the manifest digest.
+func (c *Client) Push(ref Reference, archive []byte, annotations map[string]string) (string, error) {
+	configDigest, err := c.uploadBlob(ref, emptyConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload preset config: %w", err)
+	}
+	layerDigest, err := c.uploadBlob(ref, archive)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload preset content: %w", err)
+	}
+
+	manifest := presetManifest{
+		SchemaVersion: 2,
+		MediaType:     ocispec.MediaTypeImageManifest,
+		ArtifactType:  ArtifactType,
+		Config: presetDescriptor{
+			MediaType: ocispec.MediaTypeEmptyJSON,
+			Digest:    configDigest,
+			Size:      int64(len(emptyConfig)),
+		},
+		Layers: []presetDescriptor{{
+			MediaType: LayerMediaType,
+			Digest:    layerDigest,
+			Size:      int64(len(archive)),
+		}},
+		Annotations: annotations,
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.endpoint(ref, "manifests/"+ref.Tag), bytes.NewReader(manifestData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", ocispec.MediaTypeImageManifest)
+	resp, err := c.do(ref, pushScope, req, manifestData)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", registryError("manifest upload", resp)
+	}
+
+	return digestOf(manifestData), nil
+}
+
+// Pull downloads the preset archive referenced by ref, verifying its digest and, for a
+// reference pinned by digest, the manifest's
+func (c *Client) Pull(ref Reference) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, c.endpoint(ref, "manifests/"+ref.Tag), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ocispec.MediaTypeImageManifest)
+	resp, err := c.do(ref, pullScope, req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, registryError("manifest fetch", resp)
+	}
+
+	manifestData, err := io.ReadAll(io.LimitReader(resp.Body, maxPresetSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	// A pinned reference is only as good as the manifest it resolves to, which names the
+	// digest the content is checked against
+	if strings.HasPrefix(ref.Tag, "sha256:") && digestOf(manifestData) != ref.Tag {
+		return nil, fmt.Errorf("manifest digest mismatch: expected %s, got %s", ref.Tag, digestOf(manifestData))
+	}
+	var manifest presetManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if manifest.ArtifactType != ArtifactType || len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != LayerMediaType {
+		return nil, fmt.Errorf("%s is not a reactor preset (artifact type %q)", ref, manifest.ArtifactType)
+	}
+
+	layer := manifest.Layers[0]
+	if layer.Size > maxPresetSize {
+		return nil, fmt.Errorf("preset content is too large (%d bytes, limit %d bytes)", layer.Size, maxPresetSize)
+	}
+
+	req, err = http.NewRequest(http.MethodGet, c.endpoint(ref, "blobs/"+layer.Digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	blobResp, err := c.do(ref, pullScope, req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch preset content: %w", err)
+	}
+	defer func() { _ = blobResp.Body.Close() }()
+	if blobResp.StatusCode != http.StatusOK {
+		return nil, registryError("blob fetch", blobResp)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(blobResp.Body, maxPresetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preset content: %w", err)
+	}
+	if digestOf(data) != layer.Digest {
+		return nil, fmt.Errorf("preset content digest mismatch: expected %s, got %s", layer.Digest, digestOf(data))
+	}
+
+	return data, nil
+}
+
+// uploadBlob uploads data as a blob unless the registry already has it
+func (c *Client) uploadBlob(ref Reference, data []byte) (string, error) {
+	digest := digestOf(data)
+
+	req, err := http.NewRequest(http.MethodHead, c.endpoint(ref, "blobs/"+digest), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(ref, pushScope, req, nil)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return digest, nil
+	}
+
+	req, err = http.NewRequest(http.MethodPost, c.endpoint(ref, "blobs/uploads/"), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err = c.do(ref, pushScope, req, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		defer func() { _ = resp.Body.Close() }()
+		return "", registryError("blob upload start", resp)
+	}
+	_ = resp.Body.Close()
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", fmt.Errorf("registry returned invalid upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	req, err = http.NewRequest(http.MethodPut, location.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.do(ref, pushScope, req, data)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusCreated {
+		return "", registryError("blob upload", resp)
+	}
+
+	return digest, nil
+}
+
+// Token scopes: the actions a registry token is requested for
+const (
+	pullScope = "pull"
+	pushScope = "pull,push"
+)
+
+// do executes a request, performing the registry token handshake for the actions of
+// scope on 401 responses
+func (c *Client) do(ref Reference, scope string, req *http.Request, body []byte) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	_ = resp.Body.Close()
+
+	token, err := c.authenticate(ref, scope, challenge)
+	if err != nil {
+		return nil, err
+	}
+	c.token = token
+
+	retry := req.Clone(req.Context())
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	retry.Header.Set("Authorization", c.token)
+	return c.httpClient.Do(retry)
+}
+
+// authenticate resolves an Authorization header value for a WWW-Authenticate challenge,
+// asking for a token that allows the actions of scope
+func (c *Client) authenticate(ref Reference, scope, challenge string) (string, error) {
+	credential, _, err := registryauth.HostDockerCredential(ref.Registry)
+	if err != nil {
+		return "", err
+	}
+	username, password := credential.Username, credential.Password
+
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" {
+			return "", fmt.Errorf("registry %s requires authentication. Run 'docker login %s' first", ref.Registry, ref.Registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", fmt.Errorf("registry %s returned an invalid auth challenge", ref.Registry)
+		}
+		query := realm.Query()
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		query.Set("scope", fmt.Sprintf("repository:%s:%s", ref.Repository, scope))
+		realm.RawQuery = query.Encode()
+
+		req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to obtain registry token: %w", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry %s denied access (status %d). Run 'docker login %s' first", ref.Registry, resp.StatusCode, ref.Registry)
+		}
+
+		var tokenResp struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+			return "", fmt.Errorf("failed to decode registry token: %w", err)
+		}
+		token := tokenResp.Token
+		if token == "" {
+			token = tokenResp.AccessToken
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication scheme %q", ref.Registry, scheme)
+	}
+}
+
+// endpoint builds a distribution API URL for the reference repository
+func (c *Client) endpoint(ref Reference, path string) string {
+	host := ref.Registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if loopbackHost(host) {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, host, ref.Repository, path)
+}
+
+// loopbackHost reports whether a registry host, with an optional port, is this machine,
+// the only registries spoken to over plain HTTP
+func loopbackHost(host string) bool {
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+	return hostname == "localhost" || hostname == "127.0.0.1" || hostname == "::1"
+}
+
+// parseChallenge splits a WWW-Authenticate header into scheme and parameters
+func parseChallenge(header string) (string, map[string]string) {
+	params := make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	for _, part := range strings.Split(rest, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return scheme, params
+}
+
+// registryError formats an unexpected registry response
+func registryError(operation string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return fmt.Errorf("registry %s failed with status %d: %s", operation, resp.StatusCode, message)
+}
+
+// digestOf returns the sha256 digest string of data
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+type presetManifest struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	MediaType     string             `json:"mediaType"`
+	ArtifactType  string             `json:"artifactType"`
+	Config        presetDescriptor   `json:"config"`
+	Layers        []presetDescriptor `json:"layers"`
+	Annotations   map[string]string  `json:"annotations,omitempty"`
+}
+
+type presetDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
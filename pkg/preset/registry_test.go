@@ -0,0 +1,216 @@
+package preset
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected Reference
+		wantErr  bool
+	}{
+		{ref: "ghcr.io/org/presets/python:v1", expected: Reference{Registry: "ghcr.io", Repository: "org/presets/python", Tag: "v1"}},
+		{ref: "oci://ghcr.io/org/preset", expected: Reference{Registry: "ghcr.io", Repository: "org/preset", Tag: "latest"}},
+		{ref: "localhost:5000/preset:dev", expected: Reference{Registry: "localhost:5000", Repository: "preset", Tag: "dev"}},
+		{ref: "team/preset:1.0", expected: Reference{Registry: "docker.io", Repository: "team/preset", Tag: "1.0"}},
+		{ref: "preset", expected: Reference{Registry: "docker.io", Repository: "library/preset", Tag: "latest"}},
+		{ref: "ghcr.io/org/preset@sha256:abc", expected: Reference{Registry: "ghcr.io", Repository: "org/preset", Tag: "sha256:abc"}},
+		{ref: "", wantErr: true},
+		{ref: "ghcr.io/org/preset@md5:abc", wantErr: true},
+		{ref: "ghcr.io/Org/Preset:v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ParseReference(tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ref)
+		})
+	}
+}
+
+func TestLoopbackHost(t *testing.T) {
+	for _, host := range []string{"localhost", "localhost:5000", "127.0.0.1", "127.0.0.1:5000", "[::1]", "[::1]:5000"} {
+		assert.True(t, loopbackHost(host), host)
+	}
+	for _, host := range []string{"localhost.evil.example", "localhost.evil.example:5000", "127.0.0.1.evil.example", "ghcr.io", "registry.local:5000"} {
+		assert.False(t, loopbackHost(host), host)
+	}
+}
+
+// fakeRegistry is a minimal in-memory OCI distribution registry
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/test/preset/")
+	switch {
+	case r.Method == http.MethodPost && path == "blobs/uploads/":
+		w.Header().Set("Location", "/v2/test/preset/blobs/uploads/session-1")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "blobs/uploads/"):
+		data, _ := io.ReadAll(r.Body)
+		digest := r.URL.Query().Get("digest")
+		if digest != digestOf(data) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[digest] = data
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "blobs/"):
+		data, ok := f.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+		data, _ := io.ReadAll(r.Body)
+		f.manifests[strings.TrimPrefix(path, "manifests/")] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "manifests/"):
+		data, ok := f.manifests[strings.TrimPrefix(path, "manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClientPushAndPull(t *testing.T) {
+	registry := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/test/preset:v1")
+	require.NoError(t, err)
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".devcontainer.json"), []byte(`{"image": "alpine"}`), 0644))
+	archive, _, err := Package(projectDir)
+	require.NoError(t, err)
+
+	client := NewClient()
+	digest, err := client.Push(ref, archive, map[string]string{"org.opencontainers.image.title": "test"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(digest, "sha256:"))
+	assert.Len(t, registry.blobs, 2)
+
+	pulled, err := client.Pull(ref)
+	require.NoError(t, err)
+	assert.Equal(t, archive, pulled)
+
+	t.Run("missing tag", func(t *testing.T) {
+		missing := ref
+		missing.Tag = "v2"
+		_, err := client.Pull(missing)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "status 404")
+	})
+
+	t.Run("pinned digest", func(t *testing.T) {
+		pinned := ref
+		pinned.Tag = digest
+		registry.manifests[digest] = registry.manifests["v1"]
+		pulled, err := client.Pull(pinned)
+		require.NoError(t, err)
+		assert.Equal(t, archive, pulled)
+
+		// A registry serving another manifest under the digest is caught
+		registry.manifests[digest] = append(registry.manifests["v1"], ' ')
+		_, err = client.Pull(pinned)
+		assert.ErrorContains(t, err, "manifest digest mismatch")
+	})
+
+	t.Run("non-preset artifact", func(t *testing.T) {
+		registry.manifests["image"] = []byte(`{"schemaVersion":2,"artifactType":"application/vnd.other","layers":[]}`)
+		other := ref
+		other.Tag = "image"
+		_, err := client.Pull(other)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not a reactor preset")
+	})
+}
+
+func TestClientTokenScope(t *testing.T) {
+	var scopes []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			scopes = append(scopes, r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token": "t"}`))
+		case r.Header.Get("Authorization") == "":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/test/preset:v1")
+	require.NoError(t, err)
+
+	_, err = NewClient().Pull(ref)
+	assert.ErrorContains(t, err, "status 404")
+	_, err = NewClient().Push(ref, []byte("content"), nil)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"repository:test/preset:pull", "repository:test/preset:pull,push"}, scopes)
+}
+
+func TestClientCredentialHelper(t *testing.T) {
+	var authorized bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "me" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		authorized = true
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	// Docker Desktop keeps credentials in the store its credsStore names, not in auths
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"credsStore": "test"}`), 0644))
+	t.Setenv("DOCKER_CONFIG", configDir)
+	binDir := t.TempDir()
+	helper := "#!/bin/sh\necho '{\"Username\": \"me\", \"Secret\": \"secret\"}'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "docker-credential-test"), []byte(helper), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/test/preset:v1")
+	require.NoError(t, err)
+	_, err = NewClient().Pull(ref)
+	assert.ErrorContains(t, err, "status 404")
+	assert.True(t, authorized, "the credential helper's credential is sent")
+}
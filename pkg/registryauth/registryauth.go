@@ -0,0 +1,328 @@
+// Package registryauth keeps the container registry credentials of an account, so
+// private base images are pulled with the identity of the account in use rather than
+// whatever the host's Docker CLI happens to be logged in as.
+package registryauth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/dyluth/reactor/pkg/vault"
+)
+
+// DockerHub is the registry host of images without a registry in their name
+const DockerHub = "docker.io"
+
+// dockerHubServer is the key the Docker CLI and daemon use for Docker Hub credentials
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// Credential is a username and password (or token) for one registry
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Store is the file holding an account's credentials, keyed by registry host
+type Store struct {
+	path   string
+	cipher vault.Cipher
+}
+
+// NewStore returns the store at path. With a nil cipher the file is kept in plaintext,
+// readable by the owner only.
+func NewStore(path string, c vault.Cipher) *Store {
+	return &Store{path: path, cipher: c}
+}
+
+// Path returns the file the credentials are kept in
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns every stored credential. A missing file holds no credentials.
+func (s *Store) Load() (map[string]Credential, error) {
+	var data []byte
+	var err error
+	if s.cipher != nil {
+		data, err = vault.ReadFile(s.path, s.cipher)
+	} else {
+		data, err = os.ReadFile(s.path)
+	}
+	if os.IsNotExist(err) {
+		return map[string]Credential{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry credentials: %w", err)
+	}
+
+	creds := map[string]Credential{}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse registry credentials %s: %w", s.path, err)
+	}
+	return creds, nil
+}
+
+// Set stores the credential for a registry, replacing any previous one
+func (s *Store) Set(host string, cred Credential) error {
+	creds, err := s.Load()
+	if err != nil {
+		return err
+	}
+	creds[NormalizeHost(host)] = cred
+	return s.save(creds)
+}
+
+// Remove deletes the credential for a registry, reporting whether there was one
+func (s *Store) Remove(host string) (bool, error) {
+	creds, err := s.Load()
+	if err != nil {
+		return false, err
+	}
+	host = NormalizeHost(host)
+	if _, ok := creds[host]; !ok {
+		return false, nil
+	}
+	delete(creds, host)
+	return true, s.save(creds)
+}
+
+func (s *Store) save(creds map[string]Credential) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if s.cipher != nil {
+		return vault.WriteFile(s.path, data, s.cipher)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", s.path, err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write registry credentials: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(s.path, 0600)
+}
+
+// ImageHost returns the registry host of an image reference, DockerHub for names
+// without one. Names that cannot be parsed are assumed to be on Docker Hub.
+func ImageHost(imageName string) string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return DockerHub
+	}
+	return reference.Domain(named)
+}
+
+// NormalizeHost turns the forms a registry is written in ("https://ghcr.io/",
+// "index.docker.io", "https://index.docker.io/v1/") into a bare host such as "ghcr.io"
+func NormalizeHost(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(host), "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DockerHub
+	}
+	return host
+}
+
+// ServerAddress returns the key the Docker daemon expects credentials for a host under
+func ServerAddress(host string) string {
+	if host == DockerHub {
+		return dockerHubServer
+	}
+	return host
+}
+
+// runCredentialHelper runs a Docker credential helper with input on stdin; tests replace it
+var runCredentialHelper = func(helper, action, input string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, action)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String() + string(out)); msg != "" {
+			return nil, fmt.Errorf("docker-credential-%s: %w: %s", helper, err, msg)
+		}
+		return nil, fmt.Errorf("docker-credential-%s: %w", helper, err)
+	}
+	return out, nil
+}
+
+// HostDockerCredential reads the host Docker CLI's credential for a registry host from
+// $DOCKER_CONFIG/config.json (default ~/.docker): an inline "auth" entry, or the
+// credential helper configured for the registry or as the default store.
+func HostDockerCredential(host string) (Credential, bool, error) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return Credential{}, false, nil
+		}
+		configDir = filepath.Join(homeDir, ".docker")
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return Credential{}, false, nil
+	}
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+		CredsStore  string            `json:"credsStore"`
+		CredHelpers map[string]string `json:"credHelpers"`
+	}
+	if err := json.Unmarshal(data, &dockerConfig); err != nil {
+		return Credential{}, false, fmt.Errorf("failed to parse Docker config: %w", err)
+	}
+
+	host = NormalizeHost(host)
+	candidates := []string{host, "https://" + host}
+	if host == DockerHub {
+		candidates = append(candidates, dockerHubServer)
+	}
+
+	for _, candidate := range candidates {
+		if helper := dockerConfig.CredHelpers[candidate]; helper != "" {
+			return helperCredential(helper, candidate)
+		}
+	}
+	for _, candidate := range candidates {
+		entry, ok := dockerConfig.Auths[candidate]
+		if !ok || entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		if username, password, ok := strings.Cut(string(decoded), ":"); ok {
+			return Credential{Username: username, Password: password}, true, nil
+		}
+	}
+	if dockerConfig.CredsStore != "" {
+		return helperCredential(dockerConfig.CredsStore, ServerAddress(host))
+	}
+	return Credential{}, false, nil
+}
+
+// helperCredential asks a credential helper for a server's credential. Helpers report
+// a missing credential as an error, which is treated as having none.
+func helperCredential(helper, server string) (Credential, bool, error) {
+	out, err := runCredentialHelper(helper, "get", server)
+	if err != nil {
+		return Credential{}, false, nil
+	}
+	var resp struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return Credential{}, false, fmt.Errorf("failed to parse docker-credential-%s output: %w", helper, err)
+	}
+	if resp.Secret == "" {
+		return Credential{}, false, nil
+	}
+	return Credential{Username: resp.Username, Password: resp.Secret}, true, nil
+}
+
+// Resolver finds the credentials to pull images with: the account's own first, then
+// the host's Docker credentials for registries on the HostRegistries allowlist
+type Resolver struct {
+	Store          *Store
+	HostRegistries []string
+}
+
+// AuthFor returns the credentials for an image's registry, false when there are none
+func (r *Resolver) AuthFor(imageName string) (registry.AuthConfig, bool, error) {
+	return r.lookup(ImageHost(imageName))
+}
+
+// All returns the credentials of every registry the resolver knows, keyed by the
+// server address the Docker daemon expects, for builds whose base images may come
+// from any of them
+func (r *Resolver) All() (map[string]registry.AuthConfig, error) {
+	hosts := map[string]bool{}
+	if r.Store != nil {
+		creds, err := r.Store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for host := range creds {
+			hosts[host] = true
+		}
+	}
+	for _, host := range r.HostRegistries {
+		hosts[NormalizeHost(host)] = true
+	}
+
+	sorted := make([]string, 0, len(hosts))
+	for host := range hosts {
+		sorted = append(sorted, host)
+	}
+	sort.Strings(sorted)
+
+	configs := make(map[string]registry.AuthConfig, len(sorted))
+	for _, host := range sorted {
+		auth, ok, err := r.lookup(host)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			configs[auth.ServerAddress] = auth
+		}
+	}
+	return configs, nil
+}
+
+func (r *Resolver) lookup(host string) (registry.AuthConfig, bool, error) {
+	host = NormalizeHost(host)
+	cred, ok, err := r.accountCredential(host)
+	if err != nil {
+		return registry.AuthConfig{}, false, err
+	}
+	if !ok && r.allowsHost(host) {
+		if cred, ok, err = HostDockerCredential(host); err != nil {
+			return registry.AuthConfig{}, false, err
+		}
+	}
+	if !ok {
+		return registry.AuthConfig{}, false, nil
+	}
+	return registry.AuthConfig{
+		Username:      cred.Username,
+		Password:      cred.Password,
+		ServerAddress: ServerAddress(host),
+	}, true, nil
+}
+
+func (r *Resolver) accountCredential(host string) (Credential, bool, error) {
+	if r.Store == nil {
+		return Credential{}, false, nil
+	}
+	creds, err := r.Store.Load()
+	if err != nil {
+		return Credential{}, false, err
+	}
+	cred, ok := creds[host]
+	return cred, ok, nil
+}
+
+// allowsHost reports whether the host's Docker credentials may be used for a registry
+func (r *Resolver) allowsHost(host string) bool {
+	return slices.ContainsFunc(r.HostRegistries, func(allowed string) bool {
+		return NormalizeHost(allowed) == host
+	})
+}
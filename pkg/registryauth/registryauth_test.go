@@ -0,0 +1,208 @@
+package registryauth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xorCipher is a reversible stand-in for the age and keychain ciphers
+type xorCipher struct{}
+
+func (xorCipher) Encrypt(plaintext []byte) ([]byte, error)  { return xor(plaintext), nil }
+func (xorCipher) Decrypt(ciphertext []byte) ([]byte, error) { return xor(ciphertext), nil }
+
+func xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ 0x5a
+	}
+	return out
+}
+
+// writeDockerConfig points DOCKER_CONFIG at a directory holding config.json
+func writeDockerConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0600))
+	t.Setenv("DOCKER_CONFIG", dir)
+}
+
+// stubCredentialHelper answers credential helper requests from a map keyed by helper and server
+func stubCredentialHelper(t *testing.T, secrets map[string]string) {
+	t.Helper()
+	original := runCredentialHelper
+	t.Cleanup(func() { runCredentialHelper = original })
+	runCredentialHelper = func(helper, action, input string) ([]byte, error) {
+		secret, ok := secrets[helper+" "+input]
+		if action != "get" || !ok {
+			return nil, fmt.Errorf("credentials not found in native keychain")
+		}
+		return []byte(fmt.Sprintf(`{"ServerURL":%q,"Username":"helper-user","Secret":%q}`, input, secret)), nil
+	}
+}
+
+func TestStore(t *testing.T) {
+	t.Run("plaintext round trip is owner-only", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "work", "registries.json")
+		store := NewStore(path, nil)
+
+		creds, err := store.Load()
+		require.NoError(t, err)
+		assert.Empty(t, creds)
+
+		require.NoError(t, store.Set("https://ghcr.io/", Credential{Username: "bot", Password: "token"}))
+		creds, err = store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Credential{"ghcr.io": {Username: "bot", Password: "token"}}, creds)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		removed, err := store.Remove("ghcr.io")
+		require.NoError(t, err)
+		assert.True(t, removed)
+		removed, err = store.Remove("ghcr.io")
+		require.NoError(t, err)
+		assert.False(t, removed)
+	})
+
+	t.Run("encrypted store does not keep the password in plaintext", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "registries.enc")
+		store := NewStore(path, xorCipher{})
+		require.NoError(t, store.Set("registry.example.com", Credential{Username: "bot", Password: "s3cret"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(data, []byte("s3cret")))
+
+		creds, err := NewStore(path, xorCipher{}).Load()
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", creds["registry.example.com"].Password)
+	})
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io":                      "ghcr.io",
+		"https://ghcr.io/":             "ghcr.io",
+		"localhost:5000":               "localhost:5000",
+		"index.docker.io":              DockerHub,
+		"https://index.docker.io/v1/":  DockerHub,
+		"registry-1.docker.io":         DockerHub,
+		" http://registry.example.com": "registry.example.com",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, NormalizeHost(input), input)
+	}
+}
+
+func TestImageHost(t *testing.T) {
+	assert.Equal(t, DockerHub, ImageHost("ubuntu:22.04"))
+	assert.Equal(t, DockerHub, ImageHost("library/ubuntu"))
+	assert.Equal(t, "ghcr.io", ImageHost("ghcr.io/acme/base:latest"))
+	assert.Equal(t, "localhost:5000", ImageHost("localhost:5000/base"))
+}
+
+func TestHostDockerCredential(t *testing.T) {
+	t.Run("inline auth", func(t *testing.T) {
+		auth := base64.StdEncoding.EncodeToString([]byte("alice:pw"))
+		writeDockerConfig(t, `{"auths": {"https://index.docker.io/v1/": {"auth": "`+auth+`"}}}`)
+
+		cred, ok, err := HostDockerCredential("docker.io")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, Credential{Username: "alice", Password: "pw"}, cred)
+
+		_, ok, err = HostDockerCredential("ghcr.io")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("per-registry helper takes precedence over the default store", func(t *testing.T) {
+		writeDockerConfig(t, `{"credsStore": "desktop", "credHelpers": {"ghcr.io": "gh"}}`)
+		stubCredentialHelper(t, map[string]string{
+			"gh ghcr.io":                          "gh-token",
+			"desktop https://index.docker.io/v1/": "hub-token",
+		})
+
+		cred, ok, err := HostDockerCredential("ghcr.io")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "gh-token", cred.Password)
+
+		cred, ok, err = HostDockerCredential("docker.io")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "hub-token", cred.Password)
+
+		_, ok, err = HostDockerCredential("registry.example.com")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("missing config holds no credentials", func(t *testing.T) {
+		t.Setenv("DOCKER_CONFIG", t.TempDir())
+		_, ok, err := HostDockerCredential("ghcr.io")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestResolver(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("host-user:host-pw"))
+	writeDockerConfig(t, `{"auths": {"ghcr.io": {"auth": "`+auth+`"}, "quay.io": {"auth": "`+auth+`"}}}`)
+
+	store := NewStore(filepath.Join(t.TempDir(), "registries.json"), nil)
+	require.NoError(t, store.Set("registry.example.com", Credential{Username: "acct", Password: "acct-pw"}))
+	require.NoError(t, store.Set("quay.io", Credential{Username: "acct", Password: "quay-pw"}))
+	resolver := &Resolver{Store: store, HostRegistries: []string{"https://ghcr.io", "quay.io"}}
+
+	t.Run("account credentials", func(t *testing.T) {
+		config, ok, err := resolver.AuthFor("registry.example.com/team/base:1")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "acct", config.Username)
+		assert.Equal(t, "registry.example.com", config.ServerAddress)
+	})
+
+	t.Run("account credentials take precedence over the host's", func(t *testing.T) {
+		config, ok, err := resolver.AuthFor("quay.io/team/base")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "quay-pw", config.Password)
+	})
+
+	t.Run("host credentials only for allowlisted registries", func(t *testing.T) {
+		config, ok, err := resolver.AuthFor("ghcr.io/acme/base")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "host-user", config.Username)
+
+		_, ok, err = (&Resolver{Store: store}).AuthFor("ghcr.io/acme/base")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("no credentials for other registries", func(t *testing.T) {
+		_, ok, err := resolver.AuthFor("ubuntu:22.04")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("all credentials keyed by server address", func(t *testing.T) {
+		configs, err := resolver.All()
+		require.NoError(t, err)
+		assert.Len(t, configs, 3)
+		assert.Contains(t, configs, "ghcr.io")
+		assert.Contains(t, configs, "quay.io")
+		assert.Contains(t, configs, "registry.example.com")
+	})
+}
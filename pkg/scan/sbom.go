@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+)
+
+// Supported SBOM generators; trivy is also a vulnerability scanner
+const (
+	SBOMToolSyft  = "syft"
+	SBOMToolTrivy = "trivy"
+)
+
+// SBOMFormat is the format SBOMs are generated in
+const SBOMFormat = "spdx-json"
+
+// DetectSBOMTool returns the first supported SBOM generator found on PATH
+func DetectSBOMTool() (string, error) {
+	for _, candidate := range []string{SBOMToolSyft, SBOMToolTrivy} {
+		if _, err := lookPath(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no SBOM generator found: install syft or trivy to generate an SBOM")
+}
+
+// GenerateSBOM returns a software bill of materials of an image in SPDX JSON
+func GenerateSBOM(ctx context.Context, tool, image string) ([]byte, error) {
+	var args []string
+	switch tool {
+	case SBOMToolSyft:
+		args = []string{"scan", image, "--quiet", "--output", SBOMFormat}
+	case SBOMToolTrivy:
+		args = []string{"image", "--quiet", "--format", SBOMFormat, image}
+	default:
+		return nil, fmt.Errorf("unsupported SBOM generator '%s'", tool)
+	}
+
+	output, err := runScanner(ctx, tool, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate an SBOM of image %s: %w", image, err)
+	}
+	return output, nil
+}
@@ -0,0 +1,31 @@
+package scan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectSBOMTool(t *testing.T) {
+	stubScanner(t, "", SBOMToolTrivy, SBOMToolSyft)
+	tool, err := DetectSBOMTool()
+	require.NoError(t, err)
+	assert.Equal(t, SBOMToolSyft, tool)
+
+	stubScanner(t, "", ScannerGrype)
+	_, err = DetectSBOMTool()
+	assert.EqualError(t, err, "no SBOM generator found: install syft or trivy to generate an SBOM")
+}
+
+func TestGenerateSBOM(t *testing.T) {
+	args := stubScanner(t, `{"spdxVersion": "SPDX-2.3"}`, SBOMToolTrivy)
+	sbom, err := GenerateSBOM(context.Background(), SBOMToolTrivy, "reactor-build:abc")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"spdxVersion": "SPDX-2.3"}`, string(sbom))
+	assert.Equal(t, []string{"trivy", "image", "--quiet", "--format", "spdx-json", "reactor-build:abc"}, *args)
+
+	_, err = GenerateSBOM(context.Background(), "cyclonedx", "reactor-build:abc")
+	assert.Error(t, err)
+}
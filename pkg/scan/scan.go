@@ -0,0 +1,230 @@
+// Package scan runs an external vulnerability scanner (trivy or grype) against
+// a built image and summarises the findings by severity.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Severity levels in ascending order of importance
+const (
+	SeverityUnknown  = "UNKNOWN"
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// Supported scanners
+const (
+	ScannerTrivy = "trivy"
+	ScannerGrype = "grype"
+)
+
+// DefaultSeverity is the threshold used when none is configured
+const DefaultSeverity = SeverityHigh
+
+var severityRank = map[string]int{
+	SeverityUnknown:  0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// Variables so tests can stub out the scanner binaries
+var (
+	lookPath   = exec.LookPath
+	runScanner = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("%s failed: %v %s", name, err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	}
+)
+
+// Vulnerability is a single finding reported by a scanner
+type Vulnerability struct {
+	ID               string
+	Package          string
+	InstalledVersion string
+	Severity         string
+}
+
+// Result holds the findings of one image scan
+type Result struct {
+	Scanner         string
+	Image           string
+	Vulnerabilities []Vulnerability
+}
+
+// ParseSeverity normalises a severity threshold, accepting any case. UNKNOWN is only
+// reported for findings, so it is not a threshold.
+func ParseSeverity(severity string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(severity))
+	if _, ok := severityRank[normalized]; !ok || normalized == SeverityUnknown {
+		return "", fmt.Errorf("invalid severity '%s': must be one of low, medium, high, critical", severity)
+	}
+	return normalized, nil
+}
+
+// DetectScanner returns the requested scanner, or the first supported one found on PATH
+func DetectScanner(scanner string) (string, error) {
+	if scanner != "" {
+		if scanner != ScannerTrivy && scanner != ScannerGrype {
+			return "", fmt.Errorf("unsupported scanner '%s': must be '%s' or '%s'", scanner, ScannerTrivy, ScannerGrype)
+		}
+		if _, err := lookPath(scanner); err != nil {
+			return "", fmt.Errorf("scanner '%s' not found in PATH", scanner)
+		}
+		return scanner, nil
+	}
+
+	for _, candidate := range []string{ScannerTrivy, ScannerGrype} {
+		if _, err := lookPath(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no image scanner found: install trivy or grype to use image scanning")
+}
+
+// Run scans an image with the given scanner
+func Run(ctx context.Context, scanner, image string) (*Result, error) {
+	var args []string
+	var parse func([]byte) ([]Vulnerability, error)
+	switch scanner {
+	case ScannerTrivy:
+		args = []string{"image", "--quiet", "--format", "json", image}
+		parse = parseTrivy
+	case ScannerGrype:
+		args = []string{image, "--quiet", "--output", "json"}
+		parse = parseGrype
+	default:
+		return nil, fmt.Errorf("unsupported scanner '%s'", scanner)
+	}
+
+	output, err := runScanner(ctx, scanner, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan image %s: %w", image, err)
+	}
+
+	vulnerabilities, err := parse(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", scanner, err)
+	}
+
+	sort.SliceStable(vulnerabilities, func(i, j int) bool {
+		return severityRank[vulnerabilities[i].Severity] > severityRank[vulnerabilities[j].Severity]
+	})
+	return &Result{Scanner: scanner, Image: image, Vulnerabilities: vulnerabilities}, nil
+}
+
+// Counts returns the number of vulnerabilities per severity
+func (r *Result) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, v := range r.Vulnerabilities {
+		counts[v.Severity]++
+	}
+	return counts
+}
+
+// AtOrAbove returns the vulnerabilities whose severity meets the threshold
+func (r *Result) AtOrAbove(threshold string) []Vulnerability {
+	var matches []Vulnerability
+	for _, v := range r.Vulnerabilities {
+		if severityRank[v.Severity] >= severityRank[threshold] {
+			matches = append(matches, v)
+		}
+	}
+	return matches
+}
+
+// Summary formats the per-severity counts, most severe first
+func (r *Result) Summary() string {
+	counts := r.Counts()
+	parts := make([]string, 0, len(severityRank))
+	for _, severity := range []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown} {
+		parts = append(parts, fmt.Sprintf("%s: %d", severity, counts[severity]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// normalizeSeverity maps scanner-specific severities onto reactor's levels
+func normalizeSeverity(severity string) string {
+	normalized := strings.ToUpper(severity)
+	if normalized == "NEGLIGIBLE" {
+		return SeverityLow
+	}
+	if _, ok := severityRank[normalized]; !ok {
+		return SeverityUnknown
+	}
+	return normalized
+}
+
+func parseTrivy(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string
+				PkgName          string
+				InstalledVersion string
+				Severity         string
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulnerabilities []Vulnerability
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			vulnerabilities = append(vulnerabilities, Vulnerability{
+				ID:               v.VulnerabilityID,
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				Severity:         normalizeSeverity(v.Severity),
+			})
+		}
+	}
+	return vulnerabilities, nil
+}
+
+func parseGrype(data []byte) ([]Vulnerability, error) {
+	var report struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	var vulnerabilities []Vulnerability
+	for _, m := range report.Matches {
+		vulnerabilities = append(vulnerabilities, Vulnerability{
+			ID:               m.Vulnerability.ID,
+			Package:          m.Artifact.Name,
+			InstalledVersion: m.Artifact.Version,
+			Severity:         normalizeSeverity(m.Vulnerability.Severity),
+		})
+	}
+	return vulnerabilities, nil
+}
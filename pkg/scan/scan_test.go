@@ -0,0 +1,139 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const trivyOutput = `{
+  "Results": [
+    {
+      "Target": "debian 12",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-0001", "PkgName": "openssl", "InstalledVersion": "3.0.1", "Severity": "MEDIUM"},
+        {"VulnerabilityID": "CVE-2024-0002", "PkgName": "libc6", "InstalledVersion": "2.36", "Severity": "CRITICAL"}
+      ]
+    },
+    {"Target": "app/package-lock.json"}
+  ]
+}`
+
+const grypeOutput = `{
+  "matches": [
+    {"vulnerability": {"id": "GHSA-xxxx", "severity": "High"}, "artifact": {"name": "lodash", "version": "4.17.0"}},
+    {"vulnerability": {"id": "CVE-2023-9999", "severity": "Negligible"}, "artifact": {"name": "bash", "version": "5.2"}}
+  ]
+}`
+
+func stubScanner(t *testing.T, output string, available ...string) *[]string {
+	t.Helper()
+	var calledArgs []string
+
+	origLookPath, origRun := lookPath, runScanner
+	t.Cleanup(func() { lookPath, runScanner = origLookPath, origRun })
+
+	lookPath = func(file string) (string, error) {
+		for _, name := range available {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	runScanner = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calledArgs = append([]string{name}, args...)
+		return []byte(output), nil
+	}
+	return &calledArgs
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity(" high ")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityHigh, severity)
+
+	_, err = ParseSeverity("severe")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid severity 'severe'")
+
+	_, err = ParseSeverity("unknown")
+	assert.ErrorContains(t, err, "invalid severity 'unknown'")
+}
+
+func TestDetectScanner(t *testing.T) {
+	t.Run("PrefersTrivy", func(t *testing.T) {
+		stubScanner(t, "", ScannerGrype, ScannerTrivy)
+		scanner, err := DetectScanner("")
+		require.NoError(t, err)
+		assert.Equal(t, ScannerTrivy, scanner)
+	})
+
+	t.Run("FallsBackToGrype", func(t *testing.T) {
+		stubScanner(t, "", ScannerGrype)
+		scanner, err := DetectScanner("")
+		require.NoError(t, err)
+		assert.Equal(t, ScannerGrype, scanner)
+	})
+
+	t.Run("NoneInstalled", func(t *testing.T) {
+		stubScanner(t, "")
+		_, err := DetectScanner("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "install trivy or grype")
+	})
+
+	t.Run("RequestedScannerMissing", func(t *testing.T) {
+		stubScanner(t, "", ScannerTrivy)
+		_, err := DetectScanner(ScannerGrype)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "scanner 'grype' not found")
+	})
+
+	t.Run("UnsupportedScanner", func(t *testing.T) {
+		stubScanner(t, "")
+		_, err := DetectScanner("clair")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported scanner 'clair'")
+	})
+}
+
+func TestRun_Trivy(t *testing.T) {
+	calledArgs := stubScanner(t, trivyOutput, ScannerTrivy)
+
+	result, err := Run(context.Background(), ScannerTrivy, "reactor-build:abc")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"trivy", "image", "--quiet", "--format", "json", "reactor-build:abc"}, *calledArgs)
+	require.Len(t, result.Vulnerabilities, 2)
+	assert.Equal(t, Vulnerability{ID: "CVE-2024-0002", Package: "libc6", InstalledVersion: "2.36", Severity: SeverityCritical}, result.Vulnerabilities[0])
+	assert.Equal(t, SeverityMedium, result.Vulnerabilities[1].Severity)
+
+	assert.Len(t, result.AtOrAbove(SeverityHigh), 1)
+	assert.Len(t, result.AtOrAbove(SeverityMedium), 2)
+	assert.Equal(t, "CRITICAL: 1, HIGH: 0, MEDIUM: 1, LOW: 0, UNKNOWN: 0", result.Summary())
+}
+
+func TestRun_Grype(t *testing.T) {
+	calledArgs := stubScanner(t, grypeOutput, ScannerGrype)
+
+	result, err := Run(context.Background(), ScannerGrype, "reactor-build:abc")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"grype", "reactor-build:abc", "--quiet", "--output", "json"}, *calledArgs)
+	require.Len(t, result.Vulnerabilities, 2)
+	assert.Equal(t, SeverityHigh, result.Vulnerabilities[0].Severity)
+	assert.Equal(t, SeverityLow, result.Vulnerabilities[1].Severity, "negligible maps to low")
+	assert.Equal(t, map[string]int{SeverityHigh: 1, SeverityLow: 1}, result.Counts())
+}
+
+func TestRun_InvalidOutput(t *testing.T) {
+	stubScanner(t, "not json", ScannerTrivy)
+
+	_, err := Run(context.Background(), ScannerTrivy, "image")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse trivy output")
+}
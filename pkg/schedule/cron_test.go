@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron_Errors(t *testing.T) {
+	tests := map[string]string{
+		"* * * *":       "expected 5 fields, got 4",
+		"60 * * * *":    "invalid value '60' in minute field: must be 0-59",
+		"* * 0 * *":     "invalid value '0' in day of month field",
+		"*/0 * * * *":   "invalid step '0' in minute field",
+		"5-1 * * * *":   "invalid range '5-1' in minute field",
+		"* * * JAN *":   "invalid value 'JAN' in month field",
+		"@fortnightly":  "expected 5 fields, got 1",
+		"* * * * * * *": "expected 5 fields, got 7",
+	}
+	for expr, want := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseCron(expr)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), want)
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2026, time.March, 14, 10, 17, 30, 0, time.UTC) // a Saturday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 14, 10, 18, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, time.March, 14, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * 1-5", time.Date(2026, time.March, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2026, time.March, 16, 0, 0, 0, 0, time.UTC)}, // either day field matches
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Next(base))
+			assert.True(t, c.Matches(tt.want))
+		})
+	}
+
+	never, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(base).IsZero())
+}
@@ -0,0 +1,159 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxOutputBytes bounds the command output kept with each result
+const maxOutputBytes = 4096
+
+// Task is a command run whenever its cron expression matches
+type Task struct {
+	Cron    string
+	Command string
+}
+
+// Executor runs a command, writing its combined output to out, and returns its exit code
+type Executor func(ctx context.Context, command string, out io.Writer) (int, error)
+
+// Result is the outcome of one scheduled run
+type Result struct {
+	Task      Task
+	StartedAt time.Time
+	Duration  time.Duration
+	ExitCode  int
+	Err       error
+	Output    string // tail of the combined output
+}
+
+// Runner executes tasks at the minutes their cron expressions match. A task whose
+// previous run is still going when it comes due again is skipped for that minute.
+type Runner struct {
+	tasks    []Task
+	crons    []*Cron
+	exec     Executor
+	onResult func(Result)
+
+	mu      sync.Mutex // guards running and serialises onResult
+	running map[int]bool
+	wg      sync.WaitGroup
+	now     func() time.Time
+}
+
+// NewRunner validates the tasks' cron expressions and returns a runner for them.
+// onResult is called once per finished run, never concurrently.
+func NewRunner(tasks []Task, exec Executor, onResult func(Result)) (*Runner, error) {
+	crons := make([]*Cron, len(tasks))
+	for i, task := range tasks {
+		c, err := ParseCron(task.Cron)
+		if err != nil {
+			return nil, err
+		}
+		crons[i] = c
+	}
+	return &Runner{
+		tasks:    tasks,
+		crons:    crons,
+		exec:     exec,
+		onResult: onResult,
+		running:  make(map[int]bool),
+		now:      time.Now,
+	}, nil
+}
+
+// Next returns when task i runs next after t
+func (r *Runner) Next(i int, t time.Time) time.Time {
+	return r.crons[i].Next(t)
+}
+
+// Run starts due tasks at the beginning of every minute until ctx is cancelled, then
+// waits for runs in progress to finish
+func (r *Runner) Run(ctx context.Context) error {
+	defer r.wg.Wait()
+	for {
+		now := r.now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		r.runDue(ctx, next)
+	}
+}
+
+// runDue starts every task scheduled for the minute containing t
+func (r *Runner) runDue(ctx context.Context, t time.Time) {
+	for i, c := range r.crons {
+		if !c.Matches(t) {
+			continue
+		}
+
+		r.mu.Lock()
+		busy := r.running[i]
+		r.running[i] = true
+		r.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(i int) {
+			defer r.wg.Done()
+			result := r.runTask(ctx, r.tasks[i])
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.running[i] = false
+			if r.onResult != nil {
+				r.onResult(result)
+			}
+		}(i)
+	}
+}
+
+func (r *Runner) runTask(ctx context.Context, task Task) Result {
+	var out tailBuffer
+	started := r.now()
+	exitCode, err := r.exec(ctx, task.Command, &out)
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("command exited with code %d", exitCode)
+	}
+	return Result{
+		Task:      task,
+		StartedAt: started,
+		Duration:  r.now().Sub(started),
+		ExitCode:  exitCode,
+		Err:       err,
+		Output:    out.String(),
+	}
+}
+
+// tailBuffer keeps the last maxOutputBytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p)
+	if excess := b.buf.Len() - maxOutputBytes; excess > 0 {
+		b.buf.Next(excess)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
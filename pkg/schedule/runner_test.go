@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunnerRunDue(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var calls []string
+	exec := func(ctx context.Context, command string, out io.Writer) (int, error) {
+		mu.Lock()
+		calls = append(calls, command)
+		mu.Unlock()
+		if command == "slow" {
+			<-release
+		}
+		_, _ = fmt.Fprintf(out, "ran %s\n", command)
+		if command == "fail" {
+			return 2, nil
+		}
+		return 0, nil
+	}
+
+	var results []Result
+	runner, err := NewRunner([]Task{
+		{Cron: "0 * * * *", Command: "make test"},
+		{Cron: "*/5 * * * *", Command: "fail"},
+		{Cron: "* * * * *", Command: "slow"},
+	}, exec, func(r Result) { results = append(results, r) })
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	runner.runDue(ctx, time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC))
+	// "slow" is still running, so it is skipped this minute
+	runner.runDue(ctx, time.Date(2026, time.March, 14, 11, 1, 0, 0, time.UTC))
+	close(release)
+	runner.wg.Wait()
+
+	assert.ElementsMatch(t, []string{"make test", "fail", "slow"}, calls)
+	require.Len(t, results, 3)
+	for _, r := range results {
+		switch r.Task.Command {
+		case "fail":
+			assert.Equal(t, 2, r.ExitCode)
+			assert.EqualError(t, r.Err, "command exited with code 2")
+		default:
+			assert.NoError(t, r.Err)
+		}
+		assert.Equal(t, "ran "+r.Task.Command+"\n", r.Output)
+	}
+
+	_, err = NewRunner([]Task{{Cron: "bad", Command: "x"}}, exec, nil)
+	require.Error(t, err)
+}
+
+func TestTailBuffer(t *testing.T) {
+	var b tailBuffer
+	_, _ = b.Write([]byte(strings.Repeat("a", maxOutputBytes)))
+	_, _ = b.Write([]byte("end"))
+	assert.Len(t, b.String(), maxOutputBytes)
+	assert.True(t, strings.HasSuffix(b.String(), "aend"))
+}
@@ -0,0 +1,307 @@
+// Package settings loads user-level preferences from ~/.reactor/config.yaml. Each
+// preference can be overridden with a REACTOR_* environment variable, and commands
+// with a matching flag (such as --json or --account) override both.
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/moby/term"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the preferences file in the reactor home directory
+const FileName = "config.yaml"
+
+// Output formats
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Color modes, named after 'git --color'
+const (
+	ColorAuto   = "auto"   // ANSI escape sequences only on terminals
+	ColorAlways = "always" // also when output is redirected
+	ColorNever  = "never"
+)
+
+// Engines that provision the container in 'reactor up'
+const (
+	EngineReactor         = "reactor"
+	EngineDevcontainerCLI = "devcontainer-cli"
+)
+
+// Environment variables that override the preferences file. The account is
+// overridden with REACTOR_ACCOUNT, like every other account setting.
+const (
+	EnvOutput      = "REACTOR_OUTPUT"
+	EnvColor       = "REACTOR_COLOR"
+	EnvEngine      = "REACTOR_ENGINE"
+	EnvUpdateCheck = "REACTOR_UPDATE_CHECK"
+	// EnvDockerSockets is a comma-separated list, like the dockerSockets preference
+	EnvDockerSockets = "REACTOR_DOCKER_SOCKETS"
+	// EnvIsolationPrefix keeps reactor's state, containers and volumes apart from the
+	// unprefixed ones, e.g. for tests or a second copy of reactor
+	EnvIsolationPrefix = "REACTOR_ISOLATION_PREFIX"
+)
+
+// isolationPrefixPattern restricts isolation prefixes to characters valid in directory,
+// container and volume names
+var isolationPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Preferences are the user's defaults for every project
+type Preferences struct {
+	// Output is the default format of commands that can print JSON: text or json
+	Output string `yaml:"output,omitempty"`
+	// Account is used by projects whose devcontainer.json names no account,
+	// instead of the system user name
+	Account string `yaml:"account,omitempty"`
+	// UpdateCheck enables the daily check for a newer release; nil means enabled
+	UpdateCheck *bool `yaml:"updateCheck,omitempty"`
+	// Color controls ANSI escape sequences in progress output: auto, always or never
+	Color string `yaml:"color,omitempty"`
+	// Engine provisions containers in 'reactor up': reactor or devcontainer-cli
+	Engine string `yaml:"engine,omitempty"`
+	// DockerSockets are the Docker runtimes (colima, rancher-desktop, orbstack,
+	// docker-desktop, default) or socket paths probed in order when DOCKER_HOST and
+	// the docker CLI context name no daemon
+	DockerSockets []string `yaml:"dockerSockets,omitempty"`
+	// Webhooks receive a JSON POST when the events they subscribe to happen
+	Webhooks []Webhook `yaml:"webhooks,omitempty"`
+	// IsolationPrefix is used when REACTOR_ISOLATION_PREFIX is not set and the project
+	// names none. Only ~/.reactor/config.yaml is consulted: the prefix decides which
+	// reactor home, and so which preferences file, is used.
+	IsolationPrefix string `yaml:"isolationPrefix,omitempty"`
+
+	// Path is the preferences file, whether or not it exists
+	Path string `yaml:"-"`
+}
+
+// Webhook is an HTTP endpoint, such as a Slack incoming webhook, told about events
+type Webhook struct {
+	// URL receives the POST; ${VAR} references are read from the environment, so
+	// secret URLs need not be written to the file
+	URL string `yaml:"url"`
+	// Events are the hook events sent, e.g. post-up or post-exec; empty means all
+	Events []string `yaml:"events,omitempty"`
+	// Template is a Go template producing the JSON body from the event; empty sends
+	// the event itself
+	Template string `yaml:"template,omitempty"`
+	// Headers are added to the request; ${VAR} references are expanded like the URL
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// MinDuration only sends post-exec events for commands that ran at least this
+	// long, e.g. 5m
+	MinDuration string `yaml:"minDuration,omitempty"`
+}
+
+// MinExecDuration returns the parsed MinDuration, zero when unset
+func (w Webhook) MinExecDuration() time.Duration {
+	d, _ := time.ParseDuration(w.MinDuration)
+	return d
+}
+
+// validate checks a webhook read from the preferences file
+func (w Webhook) validate() error {
+	if w.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") && !strings.HasPrefix(w.URL, "$") {
+		return fmt.Errorf("url '%s' must start with http:// or https://", w.URL)
+	}
+	if w.MinDuration != "" {
+		if d, err := time.ParseDuration(w.MinDuration); err != nil || d < 0 {
+			return fmt.Errorf("minDuration '%s' must be a duration such as 30s or 5m", w.MinDuration)
+		}
+	}
+	return nil
+}
+
+// Path returns ~/.reactor/config.yaml, or the isolated home's file when
+// REACTOR_ISOLATION_PREFIX is set, as for the rest of reactor's state
+func Path() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	dirname := ".reactor"
+	if prefix := os.Getenv(EnvIsolationPrefix); prefix != "" {
+		dirname = ".reactor-" + prefix
+	}
+	return filepath.Join(homeDir, dirname, FileName), nil
+}
+
+// Load reads the preferences file and applies the environment overrides. A missing
+// file yields the defaults.
+func Load() (*Preferences, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := prefs.applyEnv(); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func loadFile(path string) (*Preferences, error) {
+	prefs := &Preferences{Path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preferences: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(prefs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse preferences %s: %w", path, err)
+	}
+	prefs.Path = path
+
+	checks := []struct{ key, value string }{
+		{"output", prefs.Output},
+		{"color", prefs.Color},
+		{"engine", prefs.Engine},
+	}
+	for _, check := range checks {
+		if err := validate(check.key, check.value); err != nil {
+			return nil, fmt.Errorf("invalid preferences %s: %w", path, err)
+		}
+	}
+	if err := ValidateIsolationPrefix(prefs.IsolationPrefix); err != nil {
+		return nil, fmt.Errorf("invalid preferences %s: isolationPrefix %w", path, err)
+	}
+	for i, webhook := range prefs.Webhooks {
+		if err := webhook.validate(); err != nil {
+			return nil, fmt.Errorf("invalid preferences %s: webhook %d: %w", path, i+1, err)
+		}
+	}
+	return prefs, nil
+}
+
+// applyEnv overrides preferences with the REACTOR_* environment variables that are set
+func (p *Preferences) applyEnv() error {
+	overrides := []struct {
+		env, key string
+		target   *string
+	}{
+		{EnvOutput, "output", &p.Output},
+		{EnvColor, "color", &p.Color},
+		{EnvEngine, "engine", &p.Engine},
+	}
+	for _, o := range overrides {
+		value := os.Getenv(o.env)
+		if value == "" {
+			continue
+		}
+		if err := validate(o.key, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", o.env, err)
+		}
+		*o.target = value
+	}
+
+	if value := os.Getenv(EnvDockerSockets); value != "" {
+		p.DockerSockets = nil
+		for _, socket := range strings.Split(value, ",") {
+			if socket = strings.TrimSpace(socket); socket != "" {
+				p.DockerSockets = append(p.DockerSockets, socket)
+			}
+		}
+	}
+
+	if value := os.Getenv(EnvUpdateCheck); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s '%s': expected true or false", EnvUpdateCheck, value)
+		}
+		p.UpdateCheck = &enabled
+	}
+	return nil
+}
+
+// validate checks a preference that takes one of a fixed set of values; empty means the default
+func validate(key, value string) error {
+	choices := map[string][]string{
+		"output": {OutputText, OutputJSON},
+		"color":  {ColorAuto, ColorAlways, ColorNever},
+		"engine": {EngineReactor, EngineDevcontainerCLI},
+	}[key]
+	if value == "" || slices.Contains(choices, value) {
+		return nil
+	}
+	return fmt.Errorf("%s '%s' must be %s or %s", key, value, strings.Join(choices[:len(choices)-1], ", "), choices[len(choices)-1])
+}
+
+// ValidateIsolationPrefix checks an isolation prefix; empty means none
+func ValidateIsolationPrefix(prefix string) error {
+	if prefix == "" || isolationPrefixPattern.MatchString(prefix) {
+		return nil
+	}
+	return fmt.Errorf("'%s' must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", prefix)
+}
+
+// JSONOutput reports whether commands that can print JSON do so by default
+func (p *Preferences) JSONOutput() bool {
+	return p.Output == OutputJSON
+}
+
+// UpdateCheckEnabled reports whether reactor may check for a newer release
+func (p *Preferences) UpdateCheckEnabled() bool {
+	return p.UpdateCheck == nil || *p.UpdateCheck
+}
+
+// UseDevcontainerCLI reports whether 'reactor up' provisions with the devcontainer CLI by default
+func (p *Preferences) UseDevcontainerCLI() bool {
+	return p.Engine == EngineDevcontainerCLI
+}
+
+// colorDisabled is set by --no-color, which overrides the preference and the environment
+var colorDisabled atomic.Bool
+
+// DisableColor turns off ANSI escape sequences for the rest of the process, as the
+// --no-color flag does
+func DisableColor() {
+	colorDisabled.Store(true)
+}
+
+// ColorEnabled reports whether ANSI escape sequences may be written to out. NO_COLOR
+// turns them off unless the color preference is "always"; DisableColor turns them off
+// regardless.
+func ColorEnabled(out io.Writer) bool {
+	if colorDisabled.Load() {
+		return false
+	}
+	mode := ColorAuto
+	if prefs, err := Load(); err == nil && prefs.Color != "" {
+		mode = prefs.Color
+	}
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	return ok && term.IsTerminal(f.Fd())
+}
@@ -0,0 +1,164 @@
+package settings
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHome points the preferences at a temporary home without overrides
+func setupHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	for _, env := range []string{EnvOutput, EnvColor, EnvEngine, EnvUpdateCheck, EnvDockerSockets, "NO_COLOR"} {
+		t.Setenv(env, "")
+	}
+	return home
+}
+
+func writePreferences(t *testing.T, home, content string) string {
+	path := filepath.Join(home, ".reactor", FileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	home := setupHome(t)
+
+	prefs, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".reactor", FileName), prefs.Path)
+	assert.False(t, prefs.JSONOutput())
+	assert.True(t, prefs.UpdateCheckEnabled())
+	assert.False(t, prefs.UseDevcontainerCLI())
+	assert.Empty(t, prefs.Account)
+
+	// An empty file is the same as none
+	writePreferences(t, home, "# nothing yet\n")
+	_, err = Load()
+	require.NoError(t, err)
+}
+
+func TestLoad_File(t *testing.T) {
+	home := setupHome(t)
+	path := writePreferences(t, home, "output: json\naccount: work\nupdateCheck: false\ncolor: never\nengine: devcontainer-cli\ndockerSockets: [orbstack, colima]\nisolationPrefix: e2e\n")
+
+	prefs, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, path, prefs.Path)
+	assert.True(t, prefs.JSONOutput())
+	assert.Equal(t, "work", prefs.Account)
+	assert.False(t, prefs.UpdateCheckEnabled())
+	assert.Equal(t, ColorNever, prefs.Color)
+	assert.True(t, prefs.UseDevcontainerCLI())
+	assert.Equal(t, []string{"orbstack", "colima"}, prefs.DockerSockets)
+	assert.Equal(t, "e2e", prefs.IsolationPrefix)
+
+	t.Run("EnvOverridesFile", func(t *testing.T) {
+		t.Setenv(EnvDockerSockets, "/tmp/docker.sock, docker-desktop")
+		t.Setenv(EnvOutput, "text")
+		t.Setenv(EnvEngine, "reactor")
+		t.Setenv(EnvUpdateCheck, "true")
+		prefs, err := Load()
+		require.NoError(t, err)
+		assert.False(t, prefs.JSONOutput())
+		assert.False(t, prefs.UseDevcontainerCLI())
+		assert.True(t, prefs.UpdateCheckEnabled())
+		assert.Equal(t, []string{"/tmp/docker.sock", "docker-desktop"}, prefs.DockerSockets)
+	})
+
+	t.Run("IsolationPrefix", func(t *testing.T) {
+		t.Setenv("REACTOR_ISOLATION_PREFIX", "test")
+		prefs, err := Load()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".reactor-test", FileName), prefs.Path)
+		assert.Empty(t, prefs.Account)
+	})
+}
+
+func TestLoad_Webhooks(t *testing.T) {
+	home := setupHome(t)
+	writePreferences(t, home, "webhooks:\n  - url: ${SLACK_WEBHOOK_URL}\n    events: [post-exec]\n    minDuration: 5m\n  - url: https://example.com/hook\n")
+
+	prefs, err := Load()
+	require.NoError(t, err)
+	require.Len(t, prefs.Webhooks, 2)
+	assert.Equal(t, "${SLACK_WEBHOOK_URL}", prefs.Webhooks[0].URL)
+	assert.Equal(t, []string{"post-exec"}, prefs.Webhooks[0].Events)
+	assert.Equal(t, 5*time.Minute, prefs.Webhooks[0].MinExecDuration())
+	assert.Zero(t, prefs.Webhooks[1].MinExecDuration())
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	home := setupHome(t)
+
+	writePreferences(t, home, "color: rainbow\n")
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "color 'rainbow' must be auto, always or never")
+
+	writePreferences(t, home, "outputFormat: json\n")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field outputFormat not found")
+
+	writePreferences(t, home, "webhooks:\n  - url: hooks.slack.com/services/T0\n")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook 1: url 'hooks.slack.com/services/T0' must start with http:// or https://")
+
+	writePreferences(t, home, "webhooks:\n  - url: https://example.com/hook\n    minDuration: 5\n")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "minDuration '5' must be a duration")
+
+	writePreferences(t, home, "isolationPrefix: ../e2e\n")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "isolationPrefix '../e2e' must start with a letter or digit")
+
+	writePreferences(t, home, "")
+	t.Setenv(EnvEngine, "podman")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid REACTOR_ENGINE: engine 'podman' must be reactor or devcontainer-cli")
+
+	t.Setenv(EnvEngine, "")
+	t.Setenv(EnvUpdateCheck, "sometimes")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid REACTOR_UPDATE_CHECK 'sometimes'")
+}
+
+func TestColorEnabled(t *testing.T) {
+	home := setupHome(t)
+	var buf bytes.Buffer
+
+	assert.False(t, ColorEnabled(&buf), "auto only colors terminals")
+
+	writePreferences(t, home, "color: always\n")
+	assert.True(t, ColorEnabled(&buf))
+	t.Setenv("NO_COLOR", "1")
+	assert.True(t, ColorEnabled(&buf), "an explicit always wins over NO_COLOR")
+
+	t.Setenv(EnvColor, "never")
+	assert.False(t, ColorEnabled(os.Stdout))
+}
+
+func TestDisableColor(t *testing.T) {
+	home := setupHome(t)
+	writePreferences(t, home, "color: always\n")
+	t.Cleanup(func() { colorDisabled.Store(false) })
+
+	var buf bytes.Buffer
+	require.True(t, ColorEnabled(&buf))
+	DisableColor()
+	assert.False(t, ColorEnabled(&buf), "--no-color wins over the preference")
+}
@@ -0,0 +1,605 @@
+// Package state persists small pieces of cross-project reactor state, such as the
+// most recently used container, in the reactor home directory.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/config"
+	"github.com/dyluth/reactor/pkg/filelock"
+)
+
+const stateFileName = "state.json"
+
+// maxScheduleRuns bounds the scheduled run history kept in the state file
+const maxScheduleRuns = 100
+
+// maxExecHistory bounds the commands kept per project for 'reactor exec --history'
+const maxExecHistory = 50
+
+// Session records a container that a user attached to or ran a command in
+type Session struct {
+	ContainerName string    `json:"containerName"`
+	ProjectRoot   string    `json:"projectRoot,omitempty"`
+	UsedAt        time.Time `json:"usedAt"`
+}
+
+// SessionNote is the alias and free-text note given to a container with
+// 'reactor sessions rename', to keep track of what an agent session is working on
+type SessionNote struct {
+	Alias     string    `json:"alias,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// AdoptedContainer records the labels 'reactor sessions adopt' backfilled for a container
+// created without them, by an older version or by hand. Docker cannot add labels to an
+// existing container, so they are merged in when containers are listed.
+type AdoptedContainer struct {
+	ID        string            `json:"id"` // the labels only apply to this container, not a later one with its name
+	Labels    map[string]string `json:"labels"`
+	AdoptedAt time.Time         `json:"adoptedAt"`
+}
+
+// Provisioning records a container in which 'reactor up' is installing tools or
+// running lifecycle commands, so attaching can wait until it is ready
+type Provisioning struct {
+	ID        string    `json:"id"`    // the provisioning applies to this container, not a later one with its name
+	Phase     string    `json:"phase"` // the step running, e.g. "postCreateCommand"
+	PID       int       `json:"pid"`   // the reactor up process
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Running reports whether the reactor up process provisioning the container still runs
+func (p Provisioning) Running() bool {
+	if p.PID <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(p.PID)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// Pool is a warm pool started with 'reactor pool start'
+type Pool struct {
+	Size      int       `json:"size"`      // idle containers to keep
+	Container string    `json:"container"` // the container its idle containers stand in for
+	Image     string    `json:"image"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Workspace is a workspace started with 'reactor workspace up'
+type Workspace struct {
+	File      string                      `json:"file"` // absolute path of the workspace file
+	Services  map[string]WorkspaceService `json:"services"`
+	StartedAt time.Time                   `json:"startedAt"`
+}
+
+// WorkspaceService is a service started by 'reactor workspace up'
+type WorkspaceService struct {
+	ContainerID string `json:"containerId"`
+	DockerHost  string `json:"dockerHost,omitempty"` // empty for the default daemon
+	ConfigDir   string `json:"configDir,omitempty"`  // the project config directory, for stopping port tunnels
+}
+
+// Job records a command started with 'reactor exec --detach'
+type Job struct {
+	ID            string    `json:"id"`
+	ContainerName string    `json:"containerName"`
+	ExecID        string    `json:"execId"`
+	Command       []string  `json:"command"`
+	ProjectRoot   string    `json:"projectRoot,omitempty"`
+	StartedAt     time.Time `json:"startedAt"`
+}
+
+// ScheduleRun records the outcome of one run of a scheduled command
+type ScheduleRun struct {
+	ContainerName string        `json:"containerName"`
+	Cron          string        `json:"cron"`
+	Command       string        `json:"command"`
+	StartedAt     time.Time     `json:"startedAt"`
+	Duration      time.Duration `json:"duration"`
+	ExitCode      int           `json:"exitCode"`
+	Error         string        `json:"error,omitempty"`
+	Output        string        `json:"output,omitempty"` // tail of the combined output
+}
+
+// ExecRun records a command run with 'reactor exec', for re-running it with --last
+type ExecRun struct {
+	Command  []string  `json:"command"`
+	Session  string    `json:"session,omitempty"`
+	Detached bool      `json:"detached,omitempty"`
+	RanAt    time.Time `json:"ranAt"`
+	ExitCode *int      `json:"exitCode,omitempty"` // nil when detached or the command did not complete
+}
+
+// State is the content of the reactor state file
+type State struct {
+	LastSession  *Session      `json:"lastSession,omitempty"`
+	Jobs         []Job         `json:"jobs,omitempty"`
+	LastJobID    int           `json:"lastJobId,omitempty"`
+	ScheduleRuns []ScheduleRun `json:"scheduleRuns,omitempty"`
+	// SessionNotes, AdoptedContainers and Provisioning are keyed by container name
+	SessionNotes      map[string]SessionNote      `json:"sessionNotes,omitempty"`
+	AdoptedContainers map[string]AdoptedContainer `json:"adoptedContainers,omitempty"`
+	Provisioning      map[string]Provisioning     `json:"provisioning,omitempty"`
+	// Pools are keyed by the hash of the container spec of their idle containers
+	Pools map[string]Pool `json:"pools,omitempty"`
+	// Workspaces are keyed by workspace hash
+	Workspaces map[string]Workspace `json:"workspaces,omitempty"`
+	// ExecHistory is keyed by project root, oldest command first
+	ExecHistory map[string][]ExecRun `json:"execHistory,omitempty"`
+}
+
+// Path returns the location of the state file
+func Path() (string, error) {
+	reactorHome, err := config.GetReactorHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(reactorHome, stateFileName), nil
+}
+
+// Load reads the state file; a missing file yields an empty state
+func Load() (*State, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the state file atomically. Changes to the state loaded from the file
+// should go through Update, so concurrent reactor processes do not lose each other's.
+func Save(state *State) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := filelock.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// errNoChange tells Update that change left the state as it was, so it is not written
+var errNoChange = errors.New("state unchanged")
+
+// Update loads the state, applies change and saves the result while holding the state
+// file's lock, so that several reactor processes, e.g. sessions started in parallel,
+// do not overwrite each other's changes
+func Update(change func(state *State) error) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := Load()
+	if err != nil {
+		return err
+	}
+	if err := change(state); err != nil {
+		if errors.Is(err, errNoChange) {
+			return nil
+		}
+		return err
+	}
+	return Save(state)
+}
+
+// RecordSession marks a container as the most recently used one
+func RecordSession(containerName, projectRoot string) error {
+	return Update(func(state *State) error {
+		state.LastSession = &Session{
+			ContainerName: containerName,
+			ProjectRoot:   projectRoot,
+			UsedAt:        time.Now().UTC(),
+		}
+		return nil
+	})
+}
+
+// LastSession returns the most recently used container, or nil if none was recorded
+func LastSession() (*Session, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.LastSession, nil
+}
+
+// NextJobID reserves the ID for a new detached job. IDs are small increasing numbers
+// so they are easy to type.
+func NextJobID() (string, error) {
+	var id int
+	err := Update(func(state *State) error {
+		state.LastJobID++
+		id = state.LastJobID
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(id), nil
+}
+
+// RecordJob adds a started detached job
+func RecordJob(job Job) error {
+	return Update(func(state *State) error {
+		state.Jobs = append(state.Jobs, job)
+		return nil
+	})
+}
+
+// Jobs returns the recorded detached jobs, oldest first
+func Jobs() ([]Job, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.Jobs, nil
+}
+
+// FindJob returns the detached job with the given ID
+func FindJob(id string) (*Job, error) {
+	jobs, err := Jobs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("job %s not found; run 'reactor jobs list' to see known jobs", id)
+}
+
+// ForgetJobs removes detached jobs from the state file
+func ForgetJobs(ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return Update(func(state *State) error {
+		forget := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			forget[id] = true
+		}
+		kept := state.Jobs[:0]
+		for _, job := range state.Jobs {
+			if !forget[job.ID] {
+				kept = append(kept, job)
+			}
+		}
+		state.Jobs = kept
+		return nil
+	})
+}
+
+// RecordScheduleRun appends a scheduled run, keeping only the most recent runs
+func RecordScheduleRun(run ScheduleRun) error {
+	return Update(func(state *State) error {
+		state.ScheduleRuns = append(state.ScheduleRuns, run)
+		if excess := len(state.ScheduleRuns) - maxScheduleRuns; excess > 0 {
+			state.ScheduleRuns = state.ScheduleRuns[excess:]
+		}
+		return nil
+	})
+}
+
+// LastScheduleRun returns the most recent run of a command in a container, or nil if it never ran
+func LastScheduleRun(containerName, cron, command string) (*ScheduleRun, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(state.ScheduleRuns) - 1; i >= 0; i-- {
+		run := state.ScheduleRuns[i]
+		if run.ContainerName == containerName && run.Cron == cron && run.Command == command {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+// RecordExecRun appends a command run in a project, keeping only the most recent ones.
+// Running the same command in the same session again replaces the earlier entry, so
+// re-running with --last does not fill the history.
+func RecordExecRun(projectRoot string, run ExecRun) error {
+	return Update(func(state *State) error {
+		if state.ExecHistory == nil {
+			state.ExecHistory = make(map[string][]ExecRun)
+		}
+		runs := state.ExecHistory[projectRoot]
+		if n := len(runs); n > 0 && runs[n-1].Session == run.Session && slices.Equal(runs[n-1].Command, run.Command) {
+			runs = runs[:n-1]
+		}
+		runs = append(runs, run)
+		if excess := len(runs) - maxExecHistory; excess > 0 {
+			runs = runs[excess:]
+		}
+		state.ExecHistory[projectRoot] = runs
+		return nil
+	})
+}
+
+// ExecHistory returns the commands run in a project, oldest first
+func ExecHistory(projectRoot string) ([]ExecRun, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.ExecHistory[projectRoot], nil
+}
+
+// SetSessionNote stores the alias and note of a container, replacing earlier ones. An
+// entry with neither is removed. Aliases are unique, so an alias another container
+// already has is an error.
+func SetSessionNote(containerName, alias, note string) error {
+	return Update(func(state *State) error {
+		if alias != "" {
+			for name, existing := range state.SessionNotes {
+				if name != containerName && existing.Alias == alias {
+					return fmt.Errorf("alias '%s' is already used by container %s", alias, name)
+				}
+			}
+		}
+		if alias == "" && note == "" {
+			delete(state.SessionNotes, containerName)
+			return nil
+		}
+		if state.SessionNotes == nil {
+			state.SessionNotes = make(map[string]SessionNote)
+		}
+		state.SessionNotes[containerName] = SessionNote{Alias: alias, Note: note, UpdatedAt: time.Now().UTC()}
+		return nil
+	})
+}
+
+// SessionNotes returns the aliases and notes of containers, keyed by container name
+func SessionNotes() (map[string]SessionNote, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.SessionNotes, nil
+}
+
+// ResolveSessionAlias returns the container with the given alias, or "" if no
+// container has it
+func ResolveSessionAlias(alias string) (string, error) {
+	notes, err := SessionNotes()
+	if err != nil {
+		return "", err
+	}
+	for name, note := range notes {
+		if note.Alias == alias {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// AdoptContainer records the labels backfilled for a container, replacing earlier ones
+func AdoptContainer(containerName string, adopted AdoptedContainer) error {
+	return Update(func(state *State) error {
+		if state.AdoptedContainers == nil {
+			state.AdoptedContainers = make(map[string]AdoptedContainer)
+		}
+		adopted.AdoptedAt = time.Now().UTC()
+		state.AdoptedContainers[containerName] = adopted
+		return nil
+	})
+}
+
+// AdoptedLabels returns the backfilled labels of adopted containers, keyed by container ID
+func AdoptedLabels() (map[string]map[string]string, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]map[string]string, len(state.AdoptedContainers))
+	for _, adopted := range state.AdoptedContainers {
+		labels[adopted.ID] = adopted.Labels
+	}
+	return labels, nil
+}
+
+// SetProvisioning records the step of provisioning a container is at, by this process
+func SetProvisioning(containerName, containerID, phase string) error {
+	return Update(func(state *State) error {
+		if state.Provisioning == nil {
+			state.Provisioning = make(map[string]Provisioning)
+		}
+		startedAt := time.Now().UTC()
+		if previous, ok := state.Provisioning[containerName]; ok && previous.ID == containerID && previous.PID == os.Getpid() {
+			startedAt = previous.StartedAt
+		}
+		state.Provisioning[containerName] = Provisioning{ID: containerID, Phase: phase, PID: os.Getpid(), StartedAt: startedAt}
+		return nil
+	})
+}
+
+// FinishProvisioning records that a container is ready
+func FinishProvisioning(containerName string) error {
+	return Update(func(state *State) error {
+		if _, ok := state.Provisioning[containerName]; !ok {
+			return errNoChange
+		}
+		delete(state.Provisioning, containerName)
+		return nil
+	})
+}
+
+// ProvisioningOf returns the provisioning recorded for a container, nil when there is none
+func ProvisioningOf(containerName, containerID string) (*Provisioning, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := state.Provisioning[containerName]; ok && p.ID == containerID {
+		return &p, nil
+	}
+	return nil, nil
+}
+
+// ForgetContainers removes the aliases, notes and adoption records of removed containers
+func ForgetContainers(containerNames ...string) error {
+	return Update(func(state *State) error {
+		forgotten := false
+		for _, name := range containerNames {
+			if _, ok := state.SessionNotes[name]; ok {
+				delete(state.SessionNotes, name)
+				forgotten = true
+			}
+			if _, ok := state.AdoptedContainers[name]; ok {
+				delete(state.AdoptedContainers, name)
+				forgotten = true
+			}
+			if _, ok := state.Provisioning[name]; ok {
+				delete(state.Provisioning, name)
+				forgotten = true
+			}
+		}
+		if !forgotten {
+			return errNoChange
+		}
+		return nil
+	})
+}
+
+// SetPool records a warm pool under its key, the hash of the container spec its idle
+// containers are created from
+func SetPool(key string, pool Pool) error {
+	return Update(func(state *State) error {
+		if state.Pools == nil {
+			state.Pools = make(map[string]Pool)
+		}
+		pool.StartedAt = time.Now().UTC()
+		state.Pools[key] = pool
+		return nil
+	})
+}
+
+// Pools returns the warm pools by key
+func Pools() (map[string]Pool, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.Pools, nil
+}
+
+// ForgetPool removes the warm pool with key
+func ForgetPool(key string) error {
+	return Update(func(state *State) error {
+		if _, ok := state.Pools[key]; !ok {
+			return errNoChange
+		}
+		delete(state.Pools, key)
+		return nil
+	})
+}
+
+// RecordWorkspace records the services started in a workspace, adding them to the
+// services already recorded for it
+func RecordWorkspace(hash, file string, services map[string]WorkspaceService) error {
+	return Update(func(state *State) error {
+		if state.Workspaces == nil {
+			state.Workspaces = make(map[string]Workspace)
+		}
+		ws, ok := state.Workspaces[hash]
+		if !ok || ws.Services == nil {
+			ws = Workspace{Services: make(map[string]WorkspaceService)}
+		}
+		ws.File = file
+		ws.StartedAt = time.Now().UTC()
+		for name, service := range services {
+			ws.Services[name] = service
+		}
+		state.Workspaces[hash] = ws
+		return nil
+	})
+}
+
+// Workspaces returns the started workspaces, keyed by workspace hash
+func Workspaces() (map[string]Workspace, error) {
+	state, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.Workspaces, nil
+}
+
+// FindWorkspace returns the hash and record of the workspace whose hash starts with
+// prefix, as printed by 'reactor workspace ps'
+func FindWorkspace(prefix string) (string, *Workspace, error) {
+	workspaces, err := Workspaces()
+	if err != nil {
+		return "", nil, err
+	}
+	var matches []string
+	for hash := range workspaces {
+		if strings.HasPrefix(hash, prefix) {
+			matches = append(matches, hash)
+		}
+	}
+	switch {
+	case prefix == "" || len(matches) == 0:
+		return "", nil, fmt.Errorf("no started workspace with instance '%s'", prefix)
+	case len(matches) > 1:
+		return "", nil, fmt.Errorf("instance '%s' matches %d workspaces; give more of the hash", prefix, len(matches))
+	}
+	ws := workspaces[matches[0]]
+	return matches[0], &ws, nil
+}
+
+// ForgetWorkspaceServices removes stopped services from the record of a workspace,
+// and the workspace itself once none is left; no services forgets the workspace
+func ForgetWorkspaceServices(hash string, services ...string) error {
+	return Update(func(state *State) error {
+		ws, ok := state.Workspaces[hash]
+		if !ok {
+			return errNoChange
+		}
+		for _, name := range services {
+			delete(ws.Services, name)
+		}
+		if len(services) == 0 || len(ws.Services) == 0 {
+			delete(state.Workspaces, hash)
+		}
+		return nil
+	})
+}
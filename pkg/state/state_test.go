@@ -0,0 +1,310 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastSession(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	session, err := LastSession()
+	require.NoError(t, err)
+	assert.Nil(t, session, "no session recorded yet")
+
+	require.NoError(t, RecordSession("reactor-alice-api-abc123", "/home/alice/api"))
+	require.NoError(t, RecordSession("reactor-alice-web-def456", "/home/alice/web"))
+
+	session, err = LastSession()
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, "reactor-alice-web-def456", session.ContainerName)
+	assert.Equal(t, "/home/alice/web", session.ProjectRoot)
+	assert.False(t, session.UsedAt.IsZero())
+}
+
+func TestLoad_CorruptFile(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	path, err := Path()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0644))
+
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse state file")
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	// Every update sees the ones before it, so no job ID is handed out twice
+	const n = 20
+	ids := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := NextJobID()
+			assert.NoError(t, err)
+			ids[i], _ = strconv.Atoi(id)
+		}()
+	}
+	wg.Wait()
+	sort.Ints(ids)
+	for i, id := range ids {
+		assert.Equal(t, i+1, id)
+	}
+
+	state, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, n, state.LastJobID)
+}
+
+func TestJobs(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	first, err := NextJobID()
+	require.NoError(t, err)
+	second, err := NextJobID()
+	require.NoError(t, err)
+	assert.Equal(t, "1", first)
+	assert.Equal(t, "2", second)
+
+	require.NoError(t, RecordSession("reactor-alice-api-abc123", "/home/alice/api"))
+	require.NoError(t, RecordJob(Job{ID: first, ContainerName: "reactor-alice-api-abc123", ExecID: "exec-1", Command: []string{"npm", "run", "watch"}}))
+	require.NoError(t, RecordJob(Job{ID: second, ContainerName: "reactor-alice-api-abc123", ExecID: "exec-2", Command: []string{"go", "test", "./..."}}))
+
+	job, err := FindJob("2")
+	require.NoError(t, err)
+	assert.Equal(t, "exec-2", job.ExecID)
+
+	require.NoError(t, ForgetJobs("1"))
+	jobs, err := Jobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "2", jobs[0].ID)
+
+	_, err = FindJob("1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "job 1 not found")
+
+	session, err := LastSession()
+	require.NoError(t, err)
+	assert.Equal(t, "reactor-alice-api-abc123", session.ContainerName, "jobs leave the last session untouched")
+
+	third, err := NextJobID()
+	require.NoError(t, err)
+	assert.Equal(t, "3", third, "IDs are not reused after a job is forgotten")
+}
+
+func TestScheduleRuns(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	run, err := LastScheduleRun("reactor-alice-api-abc123", "@hourly", "make test")
+	require.NoError(t, err)
+	assert.Nil(t, run)
+
+	for i := 0; i < maxScheduleRuns+5; i++ {
+		require.NoError(t, RecordScheduleRun(ScheduleRun{ContainerName: "reactor-alice-api-abc123", Cron: "@hourly", Command: "make test", ExitCode: i}))
+	}
+	require.NoError(t, RecordScheduleRun(ScheduleRun{ContainerName: "reactor-alice-web-def456", Cron: "@hourly", Command: "make test", ExitCode: 1}))
+
+	run, err = LastScheduleRun("reactor-alice-api-abc123", "@hourly", "make test")
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, maxScheduleRuns+4, run.ExitCode)
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	assert.Len(t, loaded.ScheduleRuns, maxScheduleRuns)
+}
+
+func TestSessionNotes(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	require.NoError(t, SetSessionNote("reactor-alice-api-abc123", "fix-login-bug", "OAuth redirect loops"))
+	require.NoError(t, SetSessionNote("reactor-alice-web-def456", "", "styling pass"))
+
+	notes, err := SessionNotes()
+	require.NoError(t, err)
+	assert.Equal(t, "fix-login-bug", notes["reactor-alice-api-abc123"].Alias)
+	assert.Equal(t, "OAuth redirect loops", notes["reactor-alice-api-abc123"].Note)
+	assert.False(t, notes["reactor-alice-api-abc123"].UpdatedAt.IsZero())
+
+	name, err := ResolveSessionAlias("fix-login-bug")
+	require.NoError(t, err)
+	assert.Equal(t, "reactor-alice-api-abc123", name)
+	name, err = ResolveSessionAlias("unknown")
+	require.NoError(t, err)
+	assert.Empty(t, name)
+
+	err = SetSessionNote("reactor-alice-web-def456", "fix-login-bug", "")
+	assert.ErrorContains(t, err, "alias 'fix-login-bug' is already used by container reactor-alice-api-abc123")
+
+	require.NoError(t, SetSessionNote("reactor-alice-web-def456", "", ""))
+	require.NoError(t, ForgetContainers("reactor-alice-api-abc123", "reactor-gone"))
+	notes, err = SessionNotes()
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+}
+
+func TestAdoptedContainers(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	labels, err := AdoptedLabels()
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+
+	require.NoError(t, AdoptContainer("reactor-alice-api-abc123", AdoptedContainer{ID: "c1", Labels: map[string]string{"com.reactor.project": "/home/alice/api"}}))
+	labels, err = AdoptedLabels()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{"c1": {"com.reactor.project": "/home/alice/api"}}, labels)
+
+	require.NoError(t, ForgetContainers("reactor-alice-api-abc123"))
+	labels, err = AdoptedLabels()
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+}
+
+func TestProvisioning(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	p, err := ProvisioningOf("reactor-alice-api-abc123", "c1")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	require.NoError(t, SetProvisioning("reactor-alice-api-abc123", "c1", "tools"))
+	first, err := ProvisioningOf("reactor-alice-api-abc123", "c1")
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, "tools", first.Phase)
+	assert.Equal(t, os.Getpid(), first.PID)
+	assert.True(t, first.Running())
+
+	// Later steps keep the time provisioning started
+	require.NoError(t, SetProvisioning("reactor-alice-api-abc123", "c1", "postCreateCommand"))
+	p, err = ProvisioningOf("reactor-alice-api-abc123", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, "postCreateCommand", p.Phase)
+	assert.Equal(t, first.StartedAt, p.StartedAt)
+
+	// A later container with the same name is not being provisioned
+	p, err = ProvisioningOf("reactor-alice-api-abc123", "c2")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	require.NoError(t, FinishProvisioning("reactor-alice-api-abc123"))
+	p, err = ProvisioningOf("reactor-alice-api-abc123", "c1")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	require.NoError(t, SetProvisioning("reactor-alice-web-def456", "c3", "postCreateCommand"))
+	require.NoError(t, ForgetContainers("reactor-alice-web-def456"))
+	p, err = ProvisioningOf("reactor-alice-web-def456", "c3")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	assert.False(t, Provisioning{PID: 0}.Running())
+}
+
+func TestPools(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	require.NoError(t, SetPool("0123abcd", Pool{Size: 2, Container: "reactor-alice-web-abc123", Image: "ghcr.io/acme/dev:1"}))
+	pools, err := Pools()
+	require.NoError(t, err)
+	assert.Equal(t, 2, pools["0123abcd"].Size)
+	assert.Equal(t, "reactor-alice-web-abc123", pools["0123abcd"].Container)
+	assert.False(t, pools["0123abcd"].StartedAt.IsZero())
+
+	require.NoError(t, ForgetPool("0123abcd"))
+	require.NoError(t, ForgetPool("0123abcd"))
+	pools, err = Pools()
+	require.NoError(t, err)
+	assert.Empty(t, pools)
+}
+
+func TestWorkspaces(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	require.NoError(t, RecordWorkspace("abc123", "/src/ws/reactor-workspace.yml", map[string]WorkspaceService{
+		"api": {ContainerID: "c1"},
+	}))
+	require.NoError(t, RecordWorkspace("abc123", "/src/ws/reactor-workspace.yml", map[string]WorkspaceService{
+		"db": {ContainerID: "c2", DockerHost: "tcp://build-box:2376"},
+	}))
+	require.NoError(t, RecordWorkspace("abd456", "/src/other/reactor-workspace.yml", map[string]WorkspaceService{
+		"web": {ContainerID: "c3"},
+	}))
+
+	hash, ws, err := FindWorkspace("abc")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", hash)
+	assert.Equal(t, "/src/ws/reactor-workspace.yml", ws.File)
+	assert.Len(t, ws.Services, 2)
+	assert.Equal(t, "tcp://build-box:2376", ws.Services["db"].DockerHost)
+
+	_, _, err = FindWorkspace("ab")
+	assert.ErrorContains(t, err, "matches 2 workspaces")
+	_, _, err = FindWorkspace("ffff")
+	assert.ErrorContains(t, err, "no started workspace with instance 'ffff'")
+
+	require.NoError(t, ForgetWorkspaceServices("abc123", "api"))
+	_, ws, err = FindWorkspace("abc")
+	require.NoError(t, err)
+	assert.Len(t, ws.Services, 1)
+
+	require.NoError(t, ForgetWorkspaceServices("abc123", "db"))
+	require.NoError(t, ForgetWorkspaceServices("abd456"))
+	workspaces, err := Workspaces()
+	require.NoError(t, err)
+	assert.Empty(t, workspaces)
+}
+
+func TestExecHistory(t *testing.T) {
+	testutil.WithIsolatedHome(t)
+
+	runs, err := ExecHistory("/home/alice/api")
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	failed := 1
+	require.NoError(t, RecordExecRun("/home/alice/api", ExecRun{Command: []string{"npm", "test"}, ExitCode: &failed}))
+	require.NoError(t, RecordExecRun("/home/alice/api", ExecRun{Command: []string{"npm", "run", "lint"}}))
+	require.NoError(t, RecordExecRun("/home/alice/web", ExecRun{Command: []string{"make"}}))
+	// Repeating the last command replaces it rather than adding another entry
+	require.NoError(t, RecordExecRun("/home/alice/api", ExecRun{Command: []string{"npm", "run", "lint"}, Session: "feature-x"}))
+	require.NoError(t, RecordExecRun("/home/alice/api", ExecRun{Command: []string{"npm", "run", "lint"}, Session: "feature-x"}))
+
+	runs, err = ExecHistory("/home/alice/api")
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	assert.Equal(t, []string{"npm", "test"}, runs[0].Command)
+	require.NotNil(t, runs[0].ExitCode)
+	assert.Equal(t, 1, *runs[0].ExitCode)
+	assert.Equal(t, "", runs[1].Session)
+	assert.Equal(t, "feature-x", runs[2].Session)
+
+	for i := 0; i < maxExecHistory+5; i++ {
+		require.NoError(t, RecordExecRun("/home/alice/api", ExecRun{Command: []string{"echo", strconv.Itoa(i)}}))
+	}
+	runs, err = ExecHistory("/home/alice/api")
+	require.NoError(t, err)
+	assert.Len(t, runs, maxExecHistory)
+	assert.Equal(t, []string{"echo", strconv.Itoa(maxExecHistory + 4)}, runs[len(runs)-1].Command)
+
+	runs, err = ExecHistory("/home/alice/web")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
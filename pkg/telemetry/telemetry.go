@@ -0,0 +1,234 @@
+// Package telemetry keeps opt-in usage statistics: how often each command and flag is
+// used, how long commands take and the classes of error they fail with. Nothing that
+// identifies a user or project is recorded, no arguments, flag values, paths, project
+// or account names, and runs are only aggregated into counters. The statistics stay in
+// the reactor home directory until they are exported.
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/filelock"
+	"github.com/dyluth/reactor/pkg/settings"
+)
+
+// FileName holds the statistics in the reactor home directory, next to config.yaml
+const FileName = "telemetry.json"
+
+// EnvTelemetry set to "off" (or false or 0) pauses recording, e.g. in CI, without
+// discarding the statistics. DO_NOT_TRACK set to anything but 0 does the same.
+const EnvTelemetry = "REACTOR_TELEMETRY"
+
+// ErrorOther is the class of errors that match no more specific class
+const ErrorOther = "other"
+
+// Stats are the aggregated statistics of every run since telemetry was enabled
+type Stats struct {
+	Enabled bool      `json:"enabled"`
+	Since   time.Time `json:"since"`
+	// Version, OS and Arch are those of the reactor that recorded last
+	Version  string              `json:"version,omitempty"`
+	OS       string              `json:"os,omitempty"`
+	Arch     string              `json:"arch,omitempty"`
+	Commands map[string]*Command `json:"commands,omitempty"`
+}
+
+// Command aggregates the runs of one command, e.g. "up" or "sessions attach"
+type Command struct {
+	Runs     int   `json:"runs"`
+	Failures int   `json:"failures"`
+	TotalMs  int64 `json:"totalMs"`
+	MaxMs    int64 `json:"maxMs"`
+	// Flags counts the runs each flag was given on, by name only
+	Flags map[string]int `json:"flags,omitempty"`
+	// Errors counts failed runs by error class, e.g. docker-daemon-not-running
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// Run is one command run to record
+type Run struct {
+	Command    string
+	Flags      []string // names of the flags given, without values
+	Duration   time.Duration
+	ErrorClass string // empty when the command succeeded
+	Version    string
+}
+
+// Path returns the statistics file, in the isolated reactor home when
+// REACTOR_ISOLATION_PREFIX is set
+func Path() (string, error) {
+	prefsPath, err := settings.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(prefsPath), FileName), nil
+}
+
+// Paused reports whether the environment pauses recording, naming the variable that does
+func Paused() (string, bool) {
+	switch os.Getenv(EnvTelemetry) {
+	case "off", "false", "0":
+		return EnvTelemetry, true
+	}
+	if value := os.Getenv("DO_NOT_TRACK"); value != "" && value != "0" {
+		return "DO_NOT_TRACK", true
+	}
+	return "", false
+}
+
+// Load reads the statistics; a missing file means telemetry was never enabled
+func Load() (*Stats, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Stats{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry: %w", err)
+	}
+	var stats Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse telemetry %s: %w", path, err)
+	}
+	return &stats, nil
+}
+
+// save writes the statistics atomically; callers hold the lock
+func save(stats *Stats) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry: %w", err)
+	}
+	if err := filelock.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write telemetry: %w", err)
+	}
+	return nil
+}
+
+// lock serializes changes to the statistics, as every reactor command records its run
+func lock() (func(), error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return filelock.Lock(path)
+}
+
+// Enable starts recording. Statistics already collected are kept.
+func Enable(now time.Time) (*Stats, error) {
+	unlock, err := lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	stats, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if !stats.Enabled {
+		stats = &Stats{Enabled: true, Since: now.UTC()}
+	}
+	return stats, save(stats)
+}
+
+// Disable stops recording and deletes the statistics collected so far
+func Disable() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete telemetry: %w", err)
+	}
+	return nil
+}
+
+// Record adds a run to the statistics when telemetry is enabled and not paused
+func Record(run Run) error {
+	if _, paused := Paused(); paused {
+		return nil
+	}
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	stats, err := Load()
+	if err != nil || !stats.Enabled {
+		return err
+	}
+	stats.add(run)
+	return save(stats)
+}
+
+// add aggregates a run into the statistics
+func (s *Stats) add(run Run) {
+	s.Version, s.OS, s.Arch = run.Version, runtime.GOOS, runtime.GOARCH
+	if s.Commands == nil {
+		s.Commands = make(map[string]*Command)
+	}
+	c := s.Commands[run.Command]
+	if c == nil {
+		c = &Command{}
+		s.Commands[run.Command] = c
+	}
+	ms := run.Duration.Milliseconds()
+	c.Runs++
+	c.TotalMs += ms
+	c.MaxMs = max(c.MaxMs, ms)
+	for _, flag := range run.Flags {
+		if c.Flags == nil {
+			c.Flags = make(map[string]int)
+		}
+		c.Flags[flag]++
+	}
+	if run.ErrorClass != "" {
+		c.Failures++
+		if c.Errors == nil {
+			c.Errors = make(map[string]int)
+		}
+		c.Errors[run.ErrorClass]++
+	}
+}
+
+// CommandNames returns the recorded commands, most used first
+func (s *Stats) CommandNames() []string {
+	names := make([]string, 0, len(s.Commands))
+	for name := range s.Commands {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := s.Commands[names[i]], s.Commands[names[j]]
+		if a.Runs != b.Runs {
+			return a.Runs > b.Runs
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Average returns the mean duration of a command's runs
+func (c *Command) Average() time.Duration {
+	if c.Runs == 0 {
+		return 0
+	}
+	return time.Duration(c.TotalMs/int64(c.Runs)) * time.Millisecond
+}
@@ -0,0 +1,110 @@
+package telemetry
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHome points telemetry at a temporary home with recording not paused
+func setupHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv(EnvTelemetry, "")
+	t.Setenv("DO_NOT_TRACK", "")
+	return home
+}
+
+func TestRecord_OnlyWhenEnabled(t *testing.T) {
+	home := setupHome(t)
+	path, err := Path()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".reactor", FileName), path)
+
+	// Opt-in: nothing is written until telemetry is enabled
+	require.NoError(t, Record(Run{Command: "up", Duration: time.Second}))
+	assert.NoFileExists(t, path)
+
+	since := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	_, err = Enable(since)
+	require.NoError(t, err)
+	require.NoError(t, Record(Run{Command: "up", Flags: []string{"rebuild"}, Duration: 3 * time.Second, Version: "v1.2.0"}))
+	require.NoError(t, Record(Run{Command: "up", Duration: time.Second, ErrorClass: "docker-daemon-not-running"}))
+	require.NoError(t, Record(Run{Command: "sessions attach", Flags: []string{"no-wait", "name"}, Duration: time.Second}))
+
+	stats, err := Load()
+	require.NoError(t, err)
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, since, stats.Since)
+	assert.Equal(t, []string{"up", "sessions attach"}, stats.CommandNames())
+	up := stats.Commands["up"]
+	assert.Equal(t, &Command{Runs: 2, Failures: 1, TotalMs: 4000, MaxMs: 3000, Flags: map[string]int{"rebuild": 1}, Errors: map[string]int{"docker-daemon-not-running": 1}}, up)
+	assert.Equal(t, 2*time.Second, up.Average())
+
+	// Enabling again keeps the statistics
+	_, err = Enable(time.Now())
+	require.NoError(t, err)
+	stats, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, since, stats.Since)
+	assert.Len(t, stats.Commands, 2)
+
+	// Disabling deletes them
+	require.NoError(t, Disable())
+	assert.NoFileExists(t, path)
+	require.NoError(t, Disable())
+	stats, err = Load()
+	require.NoError(t, err)
+	assert.False(t, stats.Enabled)
+}
+
+func TestRecord_Paused(t *testing.T) {
+	setupHome(t)
+	_, err := Enable(time.Now())
+	require.NoError(t, err)
+
+	t.Setenv(EnvTelemetry, "off")
+	variable, paused := Paused()
+	assert.True(t, paused)
+	assert.Equal(t, EnvTelemetry, variable)
+	require.NoError(t, Record(Run{Command: "up"}))
+
+	t.Setenv(EnvTelemetry, "")
+	t.Setenv("DO_NOT_TRACK", "1")
+	variable, paused = Paused()
+	assert.True(t, paused)
+	assert.Equal(t, "DO_NOT_TRACK", variable)
+	require.NoError(t, Record(Run{Command: "up"}))
+
+	stats, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, stats.Commands)
+}
+
+func TestRecord_Concurrent(t *testing.T) {
+	setupHome(t)
+	_, err := Enable(time.Now())
+	require.NoError(t, err)
+
+	// Commands run in parallel, e.g. in several terminals, each count
+	const n = 20
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, Record(Run{Command: "exec"}))
+		}()
+	}
+	wg.Wait()
+
+	stats, err := Load()
+	require.NoError(t, err)
+	require.Contains(t, stats.Commands, "exec")
+	assert.Equal(t, n, stats.Commands["exec"].Runs)
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/dyluth/reactor/pkg/ui"
 )
 
 // GenerateFromTemplate creates a complete project from the specified template
@@ -43,7 +45,7 @@ func GenerateFromTemplate(templateName, targetDir string) error {
 		}
 	}
 
-	fmt.Printf("✅ Generated %s project '%s' with %d files\n", templateName, projectName, len(template.Files))
+	fmt.Printf("%s Generated %s project '%s' with %d files\n", ui.Stdout().Success(), templateName, projectName, len(template.Files))
 	fmt.Printf("Next steps:\n")
 	fmt.Printf("  cd %s\n", targetDir)
 	fmt.Printf("  reactor up\n")
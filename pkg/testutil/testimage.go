@@ -0,0 +1,173 @@
+package testutil
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/docker"
+)
+
+// TestImage is a tiny image built locally from scratch and a static binary, so tests can
+// start containers without pulling anything. Its /bin/sh understands "sh -c" scripts
+// with ;, &&, || and for loops, and the sleep, echo, true, false, id and [ commands,
+// enough for reactor's permission probe; use a real image for anything more.
+const TestImage = "reactor-test/sleep:latest"
+
+// OfflineEnvVar turns on offline mode: tests that need an image that is not available
+// locally are skipped instead of pulling it
+const OfflineEnvVar = "REACTOR_TEST_OFFLINE"
+
+//go:embed testimage/main.go
+var testImageSource []byte
+
+const testImageDockerfile = `FROM scratch
+COPY testimage /bin/sh
+COPY testimage /bin/sleep
+COPY testimage /bin/echo
+COPY testimage /bin/true
+COPY testimage /bin/false
+COPY testimage /bin/id
+COPY testimage /bin/test
+COPY testimage /bin/[
+LABEL com.reactor.test=true
+CMD ["/bin/sleep", "infinity"]
+`
+
+// Offline reports whether REACTOR_TEST_OFFLINE is set, i.e. tests must not use the network
+func Offline() bool {
+	switch os.Getenv(OfflineEnvVar) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// BuildTestImage builds TestImage unless it already exists. The binary is compiled from
+// embedded source with the local Go toolchain for linux on the host's architecture, and
+// the image has no base layer, so neither step needs network access.
+func BuildTestImage(ctx context.Context, dockerService *docker.Service) error {
+	exists, err := dockerService.ImageExists(ctx, TestImage)
+	if err != nil {
+		return fmt.Errorf("failed to check for test image: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	buildDir, err := os.MkdirTemp("", "reactor-test-image-*")
+	if err != nil {
+		return fmt.Errorf("failed to create test image build directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(buildDir) }()
+
+	srcDir := filepath.Join(buildDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		return fmt.Errorf("failed to create test image build directory: %w", err)
+	}
+	files := map[string][]byte{
+		filepath.Join(srcDir, "main.go"):         testImageSource,
+		filepath.Join(srcDir, "go.mod"):          []byte("module reactortestimage\n\ngo 1.23\n"),
+		filepath.Join(buildDir, "Dockerfile"):    []byte(testImageDockerfile),
+		filepath.Join(buildDir, ".dockerignore"): []byte("src\n"),
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	goBuild := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags=-s -w", "-o", filepath.Join(buildDir, "testimage"), ".")
+	goBuild.Dir = srcDir
+	goBuild.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux", "GOARCH="+runtime.GOARCH, "GOWORK=off")
+	if output, err := goBuild.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to compile test image binary: %w\n%s", err, output)
+	}
+
+	spec := docker.BuildSpec{
+		Dockerfile: "Dockerfile",
+		Context:    buildDir,
+		ImageName:  TestImage,
+		Progress:   "plain",
+	}
+	if err := dockerService.BuildImage(ctx, spec, false); err != nil {
+		return fmt.Errorf("failed to build test image: %w", err)
+	}
+	return nil
+}
+
+// EnsureTestImage builds TestImage for a test and returns its name. The test is skipped
+// when Docker is not available.
+//
+// Usage:
+//
+//	func TestSomething(t *testing.T) {
+//	    image := testutil.EnsureTestImage(t)
+//	    // write a devcontainer.json with "image": image
+//	}
+func EnsureTestImage(t testing.TB) string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	dockerService := availableDocker(ctx, t)
+	defer func() { _ = dockerService.Close() }()
+
+	if err := BuildTestImage(ctx, dockerService); err != nil {
+		t.Fatalf("Failed to build test image: %v", err)
+	}
+	return TestImage
+}
+
+// RequireImage skips the test in offline mode when an image is not available locally.
+// Outside offline mode the image is left for reactor to pull as usual.
+func RequireImage(t testing.TB, image string) {
+	t.Helper()
+	if !Offline() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dockerService := availableDocker(ctx, t)
+	defer func() { _ = dockerService.Close() }()
+
+	exists, err := dockerService.ImageExists(ctx, image)
+	if err != nil {
+		t.Fatalf("Failed to check for image %s: %v", image, err)
+	}
+	if !exists {
+		t.Skipf("Image %s is not available locally and %s is set", image, OfflineEnvVar)
+	}
+}
+
+// SkipIfOffline skips a test that needs the network, e.g. to install packages in a build
+func SkipIfOffline(t testing.TB, reason string) {
+	t.Helper()
+	if Offline() {
+		t.Skipf("%s is set: %s", OfflineEnvVar, reason)
+	}
+}
+
+// availableDocker connects to the Docker daemon, skipping the test when it is not running
+func availableDocker(ctx context.Context, t testing.TB) *docker.Service {
+	t.Helper()
+
+	dockerService, err := docker.NewService()
+	if err != nil {
+		t.Skipf("Docker not available: %v", err)
+	}
+	if err := dockerService.CheckHealth(ctx); err != nil {
+		_ = dockerService.Close()
+		t.Skipf("Docker not available: %v", err)
+	}
+	return dockerService
+}
@@ -0,0 +1,419 @@
+// Command testimage is the only binary in reactor's hermetic test image. It is
+// installed as /bin/sh, /bin/sleep, /bin/echo, /bin/id and a few more, and
+// behaves as the command it is invoked as, supporting just enough for reactor to
+// start a container, probe its permissions and run simple commands in it without
+// pulling a base image.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	os.Exit(run(filepath.Base(os.Args[0]), os.Args[1:]))
+}
+
+// run executes one command and returns its exit status
+func run(name string, args []string) int {
+	switch name {
+	case "sh":
+		return shell(args)
+	case "sleep":
+		return sleep(args)
+	case "echo":
+		fmt.Println(strings.Join(args, " "))
+		return 0
+	case "id":
+		return id(args)
+	case "test", "[":
+		return test(name, args)
+	case "true", ":":
+		return 0
+	case "false":
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%s: not found\n", name)
+	return 127
+}
+
+// shell runs "sh -c <script> [name [args...]]", or reads one command per line from
+// stdin. Scripts may chain commands with ;, newlines, && and ||, loop with
+// "for v in ...; do ...; done" and expand $1, $@ and loop variables; pipes,
+// redirections and field splitting are not supported.
+func shell(args []string) int {
+	if len(args) >= 2 && args[0] == "-c" {
+		var params []string
+		if len(args) > 3 {
+			params = args[3:]
+		}
+		return runScript(args[1], params)
+	}
+
+	status := 0
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		status = runScript(scanner.Text(), nil)
+	}
+	return status
+}
+
+// interpreter runs one parsed script
+type interpreter struct {
+	tokens []string
+	pos    int
+	params []string
+	vars   map[string]string
+	exited bool
+}
+
+// runScript parses and runs script with the positional parameters params
+func runScript(script string, params []string) int {
+	tokens, err := tokenize(script)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sh: %v\n", err)
+		return 2
+	}
+	in := &interpreter{tokens: tokens, params: params, vars: map[string]string{}}
+	status, err := in.list(true, "")
+	if err == nil && !in.exited && in.pos < len(in.tokens) {
+		err = fmt.Errorf("syntax error near '%s'", in.tokens[in.pos])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sh: %v\n", err)
+		return 2
+	}
+	return status
+}
+
+// tokenize splits a script into words, keeping their quotes, and the operators ;, &&,
+// || and newline
+func tokenize(script string) ([]string, error) {
+	var tokens []string
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(script[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			word.WriteString(script[i : i+end+2])
+			inWord = true
+			i += end + 1
+		case c == ' ' || c == '\t':
+			flush()
+		case c == ';' || c == '\n':
+			flush()
+			tokens = append(tokens, ";")
+		case (c == '&' || c == '|') && i+1 < len(script) && script[i+1] == c:
+			flush()
+			tokens = append(tokens, script[i:i+2])
+			i++
+		case c == '&' || c == '|' || c == '<' || c == '>':
+			return nil, fmt.Errorf("'%c' is not supported", c)
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// list runs commands separated by ; until the end of the script or the keyword end.
+// Nothing runs when run is false, so the body of a loop can be skipped.
+func (in *interpreter) list(run bool, end string) (int, error) {
+	status := 0
+	for in.pos < len(in.tokens) && !in.exited {
+		switch in.tokens[in.pos] {
+		case ";":
+			in.pos++
+			continue
+		case end:
+			return status, nil
+		}
+		s, err := in.andOr(run)
+		if err != nil {
+			return 0, err
+		}
+		if run {
+			status = s
+		}
+	}
+	if end != "" && !in.exited {
+		return 0, fmt.Errorf("missing '%s'", end)
+	}
+	return status, nil
+}
+
+// andOr runs commands joined by && and ||
+func (in *interpreter) andOr(run bool) (int, error) {
+	status, err := in.command(run)
+	if err != nil {
+		return 0, err
+	}
+	for in.pos < len(in.tokens) && (in.tokens[in.pos] == "&&" || in.tokens[in.pos] == "||") {
+		op := in.tokens[in.pos]
+		in.pos++
+		next := run && !in.exited && (op == "&&") == (status == 0)
+		s, err := in.command(next)
+		if err != nil {
+			return 0, err
+		}
+		if next {
+			status = s
+		}
+	}
+	return status, nil
+}
+
+// command runs a for loop or a simple command
+func (in *interpreter) command(run bool) (int, error) {
+	if in.pos < len(in.tokens) && in.tokens[in.pos] == "for" {
+		return in.forLoop(run)
+	}
+	var words []string
+	for in.pos < len(in.tokens) && !isOperator(in.tokens[in.pos]) {
+		words = append(words, in.tokens[in.pos])
+		in.pos++
+	}
+	if len(words) == 0 {
+		return 0, fmt.Errorf("missing command")
+	}
+	if !run {
+		return 0, nil
+	}
+	var fields []string
+	for _, word := range words {
+		fields = append(fields, in.expand(word)...)
+	}
+	return in.simple(fields), nil
+}
+
+// forLoop runs "for name in words; do list; done"
+func (in *interpreter) forLoop(run bool) (int, error) {
+	in.pos++
+	if in.pos+1 >= len(in.tokens) || in.tokens[in.pos+1] != "in" {
+		return 0, fmt.Errorf("syntax error in for loop")
+	}
+	name := in.tokens[in.pos]
+	in.pos += 2
+	var values []string
+	for in.pos < len(in.tokens) && in.tokens[in.pos] != ";" {
+		values = append(values, in.expand(in.tokens[in.pos])...)
+		in.pos++
+	}
+	for in.pos < len(in.tokens) && in.tokens[in.pos] == ";" {
+		in.pos++
+	}
+	if in.pos >= len(in.tokens) || in.tokens[in.pos] != "do" {
+		return 0, fmt.Errorf("missing 'do'")
+	}
+	in.pos++
+	body := in.pos
+
+	status := 0
+	if !run || len(values) == 0 {
+		if _, err := in.list(false, "done"); err != nil {
+			return 0, err
+		}
+	}
+	for _, value := range values {
+		if !run {
+			break
+		}
+		in.vars[name] = value
+		in.pos = body
+		s, err := in.list(true, "done")
+		if err != nil {
+			return 0, err
+		}
+		status = s
+		// exit stops the script where it is
+		if in.exited {
+			return status, nil
+		}
+	}
+	in.pos++ // done
+	return status, nil
+}
+
+func isOperator(token string) bool {
+	return token == ";" || token == "&&" || token == "||"
+}
+
+// expand strips the quotes of a word and substitutes $name, ${name}, $1 to $9 and
+// $@, which yields one field per positional parameter
+func (in *interpreter) expand(word string) []string {
+	if word == "$@" || word == `"$@"` {
+		return in.params
+	}
+	var out strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == quote:
+			quote = 0
+		case c == '$' && quote != '\'' && i+1 < len(word):
+			name, n := variableName(word[i+1:])
+			if n == 0 {
+				out.WriteByte(c)
+				continue
+			}
+			out.WriteString(in.lookup(name))
+			i += n
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return []string{out.String()}
+}
+
+// variableName returns the name of the variable s starts with and the length of its
+// reference, 0 when s does not start with one
+func variableName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+	if s[0] >= '0' && s[0] <= '9' {
+		return s[:1], 1
+	}
+	n := 0
+	for n < len(s) && (s[n] == '_' || s[n] >= 'a' && s[n] <= 'z' || s[n] >= 'A' && s[n] <= 'Z' || n > 0 && s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	return s[:n], n
+}
+
+func (in *interpreter) lookup(name string) string {
+	if index, err := strconv.Atoi(name); err == nil {
+		if index >= 1 && index <= len(in.params) {
+			return in.params[index-1]
+		}
+		return ""
+	}
+	if value, ok := in.vars[name]; ok {
+		return value
+	}
+	return os.Getenv(name)
+}
+
+// simple runs one command with its expanded arguments
+func (in *interpreter) simple(fields []string) int {
+	if len(fields) > 0 && fields[0] == "exec" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return 0
+	}
+	if fields[0] == "exit" {
+		in.exited = true
+		if len(fields) > 1 {
+			if code, err := strconv.Atoi(fields[1]); err == nil {
+				return code
+			}
+			return 2
+		}
+		return 0
+	}
+	return run(filepath.Base(fields[0]), fields[1:])
+}
+
+// id prints the user's uid with -u and gid with -g
+func id(args []string) int {
+	switch {
+	case len(args) == 1 && args[0] == "-u":
+		fmt.Println(os.Getuid())
+	case len(args) == 1 && args[0] == "-g":
+		fmt.Println(os.Getgid())
+	default:
+		fmt.Fprintln(os.Stderr, "id: only -u and -g are supported")
+		return 1
+	}
+	return 0
+}
+
+// test checks whether a path exists (-e), is a directory (-d) or a regular file (-f), or
+// whether the user may read (-r), write (-w) or enter or execute (-x) it
+func test(name string, args []string) int {
+	if name == "[" {
+		if len(args) == 0 || args[len(args)-1] != "]" {
+			fmt.Fprintln(os.Stderr, "[: missing ]")
+			return 2
+		}
+		args = args[:len(args)-1]
+	}
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "%s: only unary file tests are supported\n", name)
+		return 2
+	}
+	ok := false
+	switch args[0] {
+	case "-e", "-d", "-f":
+		info, err := os.Stat(args[1])
+		ok = err == nil && (args[0] == "-e" || args[0] == "-d" && info.IsDir() || args[0] == "-f" && info.Mode().IsRegular())
+	case "-r":
+		ok = syscall.Access(args[1], 4) == nil
+	case "-w":
+		ok = syscall.Access(args[1], 2) == nil
+	case "-x":
+		ok = syscall.Access(args[1], 1) == nil
+	default:
+		fmt.Fprintf(os.Stderr, "%s: unsupported test %s\n", name, args[0])
+		return 2
+	}
+	if ok {
+		return 0
+	}
+	return 1
+}
+
+// sleep waits for a number of seconds or "infinity", exiting early on SIGTERM or SIGINT
+func sleep(args []string) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	var timeout <-chan time.Time
+	if len(args) > 0 && args[0] != "infinity" {
+		d, err := time.ParseDuration(args[0])
+		if err != nil {
+			d, err = time.ParseDuration(args[0] + "s")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sleep: invalid time interval '%s'\n", args[0])
+			return 1
+		}
+		timeout = time.After(d)
+	}
+
+	select {
+	case <-timeout:
+		return 0
+	case <-signals:
+		return 0
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	assert.Equal(t, 0, run("true", nil))
+	assert.Equal(t, 1, run("false", nil))
+	assert.Equal(t, 0, run("echo", []string{"hello"}))
+	assert.Equal(t, 0, run("sleep", []string{"0"}))
+	assert.Equal(t, 1, run("sleep", []string{"soon"}))
+	assert.Equal(t, 127, run("node", nil))
+}
+
+func TestShellCommand(t *testing.T) {
+	assert.Equal(t, 0, shell([]string{"-c", "echo ready; sleep 0.01"}))
+	assert.Equal(t, 0, shell([]string{"-c", "exec /bin/true"}))
+	assert.Equal(t, 1, shell([]string{"-c", "true; false"}))
+	assert.Equal(t, 3, shell([]string{"-c", "exit 3; true"}))
+	assert.Equal(t, 0, shell([]string{"-c", "false || true && echo ok"}))
+	assert.Equal(t, 2, shell([]string{"-c", "echo > out"}))
+	assert.Equal(t, 127, shell([]string{"-c", "apk add curl"}))
+}
+
+// captureStdout returns what f prints
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	require.NoError(t, w.Close())
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestShellPermissionProbe(t *testing.T) {
+	// The script reactor runs to find the mounts the container user cannot use
+	const probe = `id -u; id -g; for p in "$@"; do [ -r "$p" ] && [ -w "$p" ] && [ -x "$p" ] || echo "$p"; done`
+	usable := t.TempDir()
+	locked := filepath.Join(t.TempDir(), "locked")
+	require.NoError(t, os.Mkdir(locked, 0500))
+	missing := filepath.Join(usable, "missing")
+
+	var status int
+	out := captureStdout(t, func() {
+		status = shell([]string{"-c", probe, "sh", usable, locked, missing})
+	})
+	want := []string{strconv.Itoa(os.Getuid()), strconv.Itoa(os.Getgid())}
+	// Root can use every path that exists
+	if os.Getuid() != 0 {
+		want = append(want, locked)
+	}
+	assert.Equal(t, 0, status)
+	assert.Equal(t, append(want, missing), strings.Fields(out))
+}
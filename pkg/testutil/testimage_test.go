@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffline(t *testing.T) {
+	t.Setenv(OfflineEnvVar, "")
+	assert.False(t, Offline())
+
+	t.Setenv(OfflineEnvVar, "1")
+	assert.True(t, Offline())
+
+	t.Setenv(OfflineEnvVar, "0")
+	assert.False(t, Offline())
+}
+
+func TestTestImageSourceBuildsStatically(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "main.go"), testImageSource, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "go.mod"), []byte("module reactortestimage\n\ngo 1.23\n"), 0644))
+
+	build := exec.Command("go", "build", "-o", filepath.Join(t.TempDir(), "testimage"), ".")
+	build.Dir = srcDir
+	build.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux", "GOWORK=off")
+	output, err := build.CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
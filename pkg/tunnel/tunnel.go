@@ -0,0 +1,177 @@
+// Package tunnel forwards container ports to the developer's machine when the
+// Docker daemon runs on a remote host, using reactor-managed SSH tunnels.
+package tunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/dyluth/reactor/pkg/background"
+)
+
+// stateFile records the running tunnel process in the project config dir
+const stateFile = "tunnel.json"
+
+// sshBinary is the ssh client used for tunnels; tests replace it with a stub
+var sshBinary = "ssh"
+
+// Remote describes how to reach a remote Docker host over SSH
+type Remote struct {
+	Target string // ssh destination, e.g. "user@build-box"
+	Port   string // optional ssh port
+}
+
+// state is the persisted record of a running tunnel
+type state struct {
+	PID    int    `json:"pid"`
+	Target string `json:"target"`
+	Ports  []int  `json:"ports"`
+}
+
+// DetectRemote inspects DOCKER_HOST and returns the SSH target of a remote daemon.
+// ssh:// hosts are used as-is; tcp:// hosts that are not local are reached over SSH
+// on the same host name. REACTOR_TUNNEL_SSH_HOST overrides the SSH destination.
+func DetectRemote(dockerHost string) (Remote, bool, error) {
+	if dockerHost == "" {
+		return Remote{}, false, nil
+	}
+
+	u, err := url.Parse(dockerHost)
+	if err != nil {
+		return Remote{}, false, fmt.Errorf("invalid DOCKER_HOST %q: %w", dockerHost, err)
+	}
+
+	var remote Remote
+	switch u.Scheme {
+	case "ssh":
+		remote.Target = u.Hostname()
+		if u.User != nil {
+			remote.Target = u.User.Username() + "@" + remote.Target
+		}
+		remote.Port = u.Port()
+	case "tcp", "http", "https":
+		if isLocalHost(u.Hostname()) {
+			return Remote{}, false, nil
+		}
+		remote.Target = u.Hostname()
+	default:
+		// unix:// and npipe:// sockets are always local
+		return Remote{}, false, nil
+	}
+
+	if override := os.Getenv("REACTOR_TUNNEL_SSH_HOST"); override != "" {
+		remote = Remote{Target: override}
+	}
+	return remote, true, nil
+}
+
+// isLocalHost reports whether a host name refers to this machine
+func isLocalHost(host string) bool {
+	if host == "" || host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// sshArgs builds the ssh arguments that forward each local port to the same port on the remote host
+func sshArgs(remote Remote, ports []int) []string {
+	args := []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=30",
+	}
+	if remote.Port != "" {
+		args = append(args, "-p", remote.Port)
+	}
+	for _, port := range ports {
+		p := strconv.Itoa(port)
+		args = append(args, "-L", "127.0.0.1:"+p+":localhost:"+p)
+	}
+	return append(args, remote.Target)
+}
+
+// Start launches a background SSH process forwarding the given ports and records it
+// in the project config dir. Any tunnel previously started for the project is stopped first.
+func Start(projectConfigDir string, remote Remote, ports []int) error {
+	if err := Stop(projectConfigDir); err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command(sshBinary, sshArgs(remote, ports)...)
+	// Detach from the terminal's process group so Ctrl+C in the session does not kill the tunnel
+	if err := background.Start(cmd); err != nil {
+		return fmt.Errorf("failed to start SSH tunnel to %s: %w", remote.Target, err)
+	}
+
+	data, err := json.Marshal(state{PID: cmd.Process.Pid, Target: remote.Target, Ports: ports})
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("failed to encode tunnel state: %w", err)
+	}
+	if err := os.MkdirAll(projectConfigDir, 0755); err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("failed to create project config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectConfigDir, stateFile), data, 0644); err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("failed to save tunnel state: %w", err)
+	}
+
+	// The tunnel outlives this process; reap it in the background while we are still running
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// Stop terminates the project's tunnel, if one is recorded
+func Stop(projectConfigDir string) error {
+	var s state
+	if err := readState(projectConfigDir, &s); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read tunnel state: %w", err)
+	}
+
+	// The tunnel may already have exited, and its PID may since have been reused, so
+	// only a process still forwarding the recorded ports to the target is signalled
+	background.Stop(s.PID, s.matches)
+
+	if err := os.Remove(filepath.Join(projectConfigDir, stateFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove tunnel state: %w", err)
+	}
+	return nil
+}
+
+// matches reports whether a command line is that of the tunnel s records
+func (s state) matches(args []string) bool {
+	if len(args) == 0 || args[len(args)-1] != s.Target || !background.Contains(args, "-N") {
+		return false
+	}
+	for _, port := range s.Ports {
+		p := strconv.Itoa(port)
+		if !background.Contains(args, "-L", "127.0.0.1:"+p+":localhost:"+p) {
+			return false
+		}
+	}
+	return true
+}
+
+// readState loads the recorded tunnel for a project
+func readState(projectConfigDir string, s *state) error {
+	data, err := os.ReadFile(filepath.Join(projectConfigDir, stateFile))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, s)
+}
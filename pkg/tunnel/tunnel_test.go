@@ -0,0 +1,122 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectRemote(t *testing.T) {
+	tests := []struct {
+		dockerHost string
+		expected   Remote
+		remote     bool
+	}{
+		{dockerHost: "", remote: false},
+		{dockerHost: "unix:///var/run/docker.sock", remote: false},
+		{dockerHost: "tcp://localhost:2375", remote: false},
+		{dockerHost: "tcp://127.0.0.1:2376", remote: false},
+		{dockerHost: "tcp://build-box:2376", expected: Remote{Target: "build-box"}, remote: true},
+		{dockerHost: "ssh://dev@build-box", expected: Remote{Target: "dev@build-box"}, remote: true},
+		{dockerHost: "ssh://dev@build-box:2222", expected: Remote{Target: "dev@build-box", Port: "2222"}, remote: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dockerHost, func(t *testing.T) {
+			remote, ok, err := DetectRemote(tt.dockerHost)
+			require.NoError(t, err)
+			assert.Equal(t, tt.remote, ok)
+			assert.Equal(t, tt.expected, remote)
+		})
+	}
+
+	t.Run("ssh host override", func(t *testing.T) {
+		t.Setenv("REACTOR_TUNNEL_SSH_HOST", "jump@bastion")
+		remote, ok, err := DetectRemote("tcp://build-box:2376")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, Remote{Target: "jump@bastion"}, remote)
+	})
+}
+
+func TestSSHArgs(t *testing.T) {
+	args := sshArgs(Remote{Target: "dev@build-box", Port: "2222"}, []int{8080, 3000})
+	assert.Equal(t, []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=30",
+		"-p", "2222",
+		"-L", "127.0.0.1:8080:localhost:8080",
+		"-L", "127.0.0.1:3000:localhost:3000",
+		"dev@build-box",
+	}, args)
+}
+
+func TestStartAndStop(t *testing.T) {
+	// Replace ssh with a stub that just stays alive like a tunnel would, keeping the
+	// ssh arguments in its command line
+	stub := filepath.Join(t.TempDir(), "ssh")
+	require.NoError(t, os.WriteFile(stub, []byte("#!/bin/sh\nsleep 30\n"), 0755))
+	original := sshBinary
+	sshBinary = stub
+	t.Cleanup(func() { sshBinary = original })
+
+	configDir := t.TempDir()
+	require.NoError(t, Start(configDir, Remote{Target: "build-box"}, []int{8080}))
+	assert.FileExists(t, filepath.Join(configDir, stateFile))
+
+	data, err := os.ReadFile(filepath.Join(configDir, stateFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"ports":[8080]`)
+
+	var s state
+	require.NoError(t, readState(configDir, &s))
+	process, err := os.FindProcess(s.PID)
+	require.NoError(t, err)
+	assert.NoError(t, process.Signal(syscall.Signal(0)), "tunnel process should be running")
+
+	require.NoError(t, Stop(configDir))
+	assert.NoFileExists(t, filepath.Join(configDir, stateFile))
+	assert.Eventually(t, func() bool {
+		return process.Signal(syscall.Signal(0)) != nil
+	}, 5*time.Second, 50*time.Millisecond, "tunnel process should be stopped")
+
+	// Stopping without a tunnel is a no-op
+	assert.NoError(t, Stop(configDir))
+}
+
+func TestStop_LeavesReusedPIDAlone(t *testing.T) {
+	// The recorded tunnel exited and its PID now belongs to another process
+	other := exec.Command("sleep", "30")
+	require.NoError(t, other.Start())
+	t.Cleanup(func() {
+		_ = other.Process.Kill()
+		_ = other.Wait()
+	})
+
+	configDir := t.TempDir()
+	data, err := json.Marshal(state{PID: other.Process.Pid, Target: "build-box", Ports: []int{8080}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, stateFile), data, 0644))
+
+	require.NoError(t, Stop(configDir))
+	assert.NoFileExists(t, filepath.Join(configDir, stateFile))
+	assert.NoError(t, other.Process.Signal(syscall.Signal(0)), "another process with the tunnel's PID is not stopped")
+}
+
+func TestStateMatches(t *testing.T) {
+	s := state{Target: "dev@build-box", Ports: []int{8080, 3000}}
+	args := append([]string{"ssh"}, sshArgs(Remote{Target: "dev@build-box", Port: "2222"}, []int{8080, 3000})...)
+	assert.True(t, s.matches(args))
+	assert.False(t, s.matches(append([]string{"ssh"}, sshArgs(Remote{Target: "other"}, []int{8080, 3000})...)))
+	assert.False(t, s.matches(append([]string{"ssh"}, sshArgs(Remote{Target: "dev@build-box"}, []int{8080})...)))
+	assert.False(t, s.matches([]string{"sleep", "30"}))
+}
@@ -0,0 +1,129 @@
+// Package ui styles the CLI's terminal output: status markers, colored states and the
+// per-service prefixes of workspace commands. Styling falls back to plain ASCII when
+// color is turned off with --no-color, NO_COLOR or the color preference, and when the
+// output is not a terminal, so redirected output and logs contain no escape sequences.
+package ui
+
+import (
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dyluth/reactor/pkg/settings"
+)
+
+// ANSI escape sequences
+const (
+	reset   = "\033[0m"
+	bold    = "\033[1m"
+	red     = "\033[31m"
+	green   = "\033[32m"
+	yellow  = "\033[33m"
+	blue    = "\033[34m"
+	magenta = "\033[35m"
+	cyan    = "\033[36m"
+)
+
+// servicePalette colors service prefixes; red and green are left for status markers
+var servicePalette = []string{cyan, magenta, blue, yellow, bold + cyan, bold + magenta, bold + blue}
+
+// Style formats text for one output stream
+type Style struct {
+	color bool
+}
+
+// New returns the style for out, colored when settings.ColorEnabled allows it
+func New(out io.Writer) Style {
+	return Style{color: settings.ColorEnabled(out)}
+}
+
+// NewStyle returns a style that colors text only when color is set
+func NewStyle(color bool) Style {
+	return Style{color: color}
+}
+
+// Stdout returns the style for standard output
+func Stdout() Style {
+	return New(os.Stdout)
+}
+
+// Stderr returns the style for standard error
+func Stderr() Style {
+	return New(os.Stderr)
+}
+
+// Color reports whether the style writes ANSI escape sequences
+func (s Style) Color() bool {
+	return s.color
+}
+
+func (s Style) paint(code, text string) string {
+	if !s.color || text == "" {
+		return text
+	}
+	return code + text + reset
+}
+
+// Bold returns text in bold
+func (s Style) Bold(text string) string { return s.paint(bold, text) }
+
+// Red returns text in red
+func (s Style) Red(text string) string { return s.paint(red, text) }
+
+// Green returns text in green
+func (s Style) Green(text string) string { return s.paint(green, text) }
+
+// Yellow returns text in yellow
+func (s Style) Yellow(text string) string { return s.paint(yellow, text) }
+
+// Success marks a line reporting something that worked
+func (s Style) Success() string {
+	if !s.color {
+		return "[ok]"
+	}
+	return s.Green("✓")
+}
+
+// Failure marks a line reporting an error
+func (s Style) Failure() string {
+	if !s.color {
+		return "[error]"
+	}
+	return s.Red("✗")
+}
+
+// Warning marks a line reporting something that needs attention
+func (s Style) Warning() string {
+	if !s.color {
+		return "[warning]"
+	}
+	return s.Yellow("⚠")
+}
+
+// Service returns the "[name]" prefix of a workspace service's output lines. Each
+// name keeps its color between runs, so interleaved output is easy to follow.
+func (s Style) Service(name string) string {
+	prefix := "[" + name + "]"
+	if !s.color {
+		return prefix
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return s.paint(servicePalette[h.Sum32()%uint32(len(servicePalette))], prefix)
+}
+
+// State colors a container state for status tables: green when running, yellow while
+// starting and red when stopped, unhealthy or missing. Surrounding padding is kept, so
+// columns can be padded before they are colored.
+func (s Style) State(state string) string {
+	switch strings.TrimSpace(state) {
+	case "running", "healthy":
+		return s.Green(state)
+	case "starting", "restarting", "created":
+		return s.Yellow(state)
+	case "stopped", "exited", "unhealthy", "missing", "not found", "dead":
+		return s.Red(state)
+	}
+	return state
+}
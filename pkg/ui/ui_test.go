@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dyluth/reactor/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStyle_Plain(t *testing.T) {
+	style := NewStyle(false)
+
+	assert.False(t, style.Color())
+	assert.Equal(t, "[ok]", style.Success())
+	assert.Equal(t, "[error]", style.Failure())
+	assert.Equal(t, "[warning]", style.Warning())
+	assert.Equal(t, "[api]", style.Service("api"))
+	assert.Equal(t, "running ", style.State("running "))
+	assert.Equal(t, "Error:", style.Red("Error:"))
+}
+
+func TestStyle_Color(t *testing.T) {
+	style := NewStyle(true)
+
+	assert.Equal(t, "\033[32m✓\033[0m", style.Success())
+	assert.Equal(t, "\033[31m✗\033[0m", style.Failure())
+	assert.Equal(t, "\033[33m⚠\033[0m", style.Warning())
+	assert.Equal(t, "\033[1mready\033[0m", style.Bold("ready"))
+	assert.Equal(t, "", style.Green(""), "empty text gets no escape sequences")
+}
+
+func TestStyle_Service(t *testing.T) {
+	style := NewStyle(true)
+
+	prefix := style.Service("api")
+	assert.Contains(t, prefix, "[api]")
+	assert.NotEqual(t, "[api]", prefix)
+	assert.Equal(t, prefix, style.Service("api"), "a service keeps its color")
+}
+
+func TestStyle_State(t *testing.T) {
+	style := NewStyle(true)
+
+	assert.Equal(t, "\033[32mrunning  \033[0m", style.State("running  "), "padding is kept inside the color")
+	assert.Equal(t, "\033[33mstarting\033[0m", style.State("starting"))
+	assert.Equal(t, "\033[31mnot found\033[0m", style.State("not found"))
+	assert.Equal(t, "paused", style.State("paused"))
+}
+
+func TestNew(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("REACTOR_ISOLATION_PREFIX", "")
+	t.Setenv(settings.EnvColor, "")
+	t.Setenv("NO_COLOR", "")
+
+	var buf bytes.Buffer
+	assert.False(t, New(&buf).Color(), "output that is not a terminal is plain")
+
+	path := filepath.Join(home, ".reactor", settings.FileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("color: always\n"), 0644))
+	assert.True(t, New(&buf).Color())
+}
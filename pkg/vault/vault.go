@@ -0,0 +1,288 @@
+// Package vault encrypts provider credentials at rest, either with the age CLI or with
+// a key kept in the OS keychain, so tokens agents write are not stored in plaintext.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Cipher encrypts and decrypts credential archives
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// keychainService names the keychain entries holding the per-account keys
+const keychainService = "reactor-credentials"
+
+// keychainMagic prefixes files sealed with a keychain key, identifying the format
+var keychainMagic = []byte("reactor-vault-v1\n")
+
+// commandError is a helper program that failed
+type commandError struct {
+	name     string
+	exitCode int // -1 when the program did not run to its exit
+	stderr   string
+	err      error
+}
+
+func (e *commandError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("%s: %v: %s", e.name, e.err, e.stderr)
+	}
+	return fmt.Sprintf("%s: %v", e.name, e.err)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// runCommand runs a helper program with stdin and returns its stdout, or a
+// *commandError; tests replace it
+var runCommand = func(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		exitCode := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		return nil, &commandError{name: name, exitCode: exitCode, stderr: strings.TrimSpace(stderr.String()), err: err}
+	}
+	return out, nil
+}
+
+// lookPath reports whether a helper program is installed; tests replace it
+var lookPath = exec.LookPath
+
+// NewAge returns a cipher that runs the age CLI with the identity file at identityPath,
+// generating the identity with age-keygen on first use. A passphrase-protected or
+// plugin identity (e.g. age-plugin-yubikey) can be put in its place.
+func NewAge(identityPath string) (Cipher, error) {
+	if _, err := lookPath("age"); err != nil {
+		return nil, fmt.Errorf("age not found; install it from https://age-encryption.org to encrypt credentials")
+	}
+	return &ageCipher{identity: identityPath}, nil
+}
+
+type ageCipher struct {
+	identity string
+}
+
+func (c *ageCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if err := c.ensureIdentity(); err != nil {
+		return nil, err
+	}
+	out, err := runCommand(plaintext, "age", "--encrypt", "--identity", c.identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+	return out, nil
+}
+
+func (c *ageCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	out, err := runCommand(ciphertext, "age", "--decrypt", "--identity", c.identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	return out, nil
+}
+
+func (c *ageCipher) ensureIdentity() error {
+	if _, err := os.Stat(c.identity); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.identity), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for age identity: %w", err)
+	}
+	if _, err := runCommand(nil, "age-keygen", "-o", c.identity); err != nil {
+		return fmt.Errorf("failed to generate age identity: %w", err)
+	}
+	return os.Chmod(c.identity, 0600)
+}
+
+// NewKeychain returns a cipher using AES-256-GCM with a random key stored in the OS
+// keychain under the account name: the login keychain on macOS (security) or the
+// Secret Service on Linux (secret-tool). The key is created on first use.
+func NewKeychain(account string) (Cipher, error) {
+	if _, _, err := keychainCommands(runtime.GOOS, account); err != nil {
+		return nil, err
+	}
+	return &keychainCipher{goos: runtime.GOOS, account: account}, nil
+}
+
+type keychainCipher struct {
+	goos    string
+	account string
+	key     []byte
+}
+
+func (c *keychainCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := c.aead(true)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	out := append(append([]byte{}, keychainMagic...), nonce...)
+	return aead.Seal(out, nonce, plaintext, keychainMagic), nil
+}
+
+func (c *keychainCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, keychainMagic) {
+		return nil, fmt.Errorf("failed to decrypt credentials: not a keychain-encrypted file")
+	}
+	aead, err := c.aead(false)
+	if err != nil {
+		return nil, err
+	}
+	sealed := ciphertext[len(keychainMagic):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt credentials: file is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], keychainMagic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	return plaintext, nil
+}
+
+// aead returns the cipher for the account's key, creating the key when create is set
+func (c *keychainCipher) aead(create bool) (cipher.AEAD, error) {
+	if c.key == nil {
+		key, err := c.loadKey(create)
+		if err != nil {
+			return nil, err
+		}
+		c.key = key
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *keychainCipher) loadKey(create bool) ([]byte, error) {
+	lookup, store, err := keychainCommands(c.goos, c.account)
+	if err != nil {
+		return nil, err
+	}
+
+	out, lookupErr := runCommand(nil, lookup[0], lookup[1:]...)
+	if lookupErr == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("keychain entry %s for account %s is not a reactor key", keychainService, c.account)
+		}
+		return key, nil
+	}
+	// Only a key that does not exist yet is created; any other failure, such as a locked
+	// keychain, would otherwise replace the key the stored credentials are sealed with
+	if !create || !keyNotFound(c.goos, lookupErr) {
+		return nil, fmt.Errorf("failed to read the credentials key for account %s from the keychain: %w", c.account, lookupErr)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	stdin := []byte(encoded)
+	if c.goos == "darwin" {
+		// security only takes the password as an argument
+		store = append(store, encoded)
+		stdin = nil
+	}
+	if _, err := runCommand(stdin, store[0], store[1:]...); err != nil {
+		return nil, fmt.Errorf("failed to store the credentials key in the keychain: %w", err)
+	}
+	return key, nil
+}
+
+// keyNotFound reports whether a failed keychain lookup on goos means there is no entry,
+// as opposed to the keychain being locked or unreachable
+func keyNotFound(goos string, lookupErr error) bool {
+	var cmdErr *commandError
+	if !errors.As(lookupErr, &cmdErr) {
+		return false
+	}
+	switch goos {
+	case "darwin":
+		// errSecItemNotFound
+		return cmdErr.exitCode == 44
+	case "linux":
+		// secret-tool exits 1 without a message when nothing matches
+		return cmdErr.exitCode == 1 && cmdErr.stderr == ""
+	}
+	return false
+}
+
+// keychainCommands returns the commands that look up and store an account's key on goos
+func keychainCommands(goos, account string) (lookup, store []string, err error) {
+	switch goos {
+	case "darwin":
+		return []string{"security", "find-generic-password", "-s", keychainService, "-a", account, "-w"},
+			[]string{"security", "add-generic-password", "-U", "-s", keychainService, "-a", account, "-w"}, nil
+	case "linux":
+		if _, err := lookPath("secret-tool"); err != nil {
+			return nil, nil, fmt.Errorf("secret-tool not found; install libsecret-tools to keep the credentials key in the keychain")
+		}
+		return []string{"secret-tool", "lookup", "service", keychainService, "account", account},
+			[]string{"secret-tool", "store", "--label", "reactor credentials (" + account + ")", "service", keychainService, "account", account}, nil
+	}
+	return nil, nil, fmt.Errorf("keychain credential encryption is not supported on %s", goos)
+}
+
+// ReadFile decrypts the file at path. A missing file returns an error satisfying os.IsNotExist.
+func ReadFile(path string, c Cipher) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decrypt(data)
+}
+
+// WriteFile encrypts data and replaces the file at path atomically, readable by the owner only
+func WriteFile(path string, data []byte, c Cipher) error {
+	encrypted, err := c.Encrypt(data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(encrypted); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeychain stands in for security and secret-tool, keeping entries in memory
+type fakeKeychain struct {
+	entries map[string]string
+	calls   [][]string
+	// locked makes lookups fail as they do when the keychain cannot be unlocked
+	locked bool
+}
+
+func installFakeKeychain(t *testing.T) *fakeKeychain {
+	t.Helper()
+	fake := &fakeKeychain{entries: make(map[string]string)}
+	origRun, origLook := runCommand, lookPath
+	t.Cleanup(func() { runCommand, lookPath = origRun, origLook })
+
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	runCommand = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		fake.calls = append(fake.calls, append([]string{name}, args...))
+		account := args[len(args)-1]
+		switch {
+		case name == "secret-tool" && args[0] == "lookup":
+			if fake.locked {
+				return nil, &commandError{name: name, exitCode: 1, stderr: "Cannot autolaunch D-Bus without X11 $DISPLAY", err: errors.New("exit status 1")}
+			}
+			if key, ok := fake.entries[account]; ok {
+				return []byte(key + "\n"), nil
+			}
+			return nil, &commandError{name: name, exitCode: 1, err: errors.New("exit status 1")}
+		case name == "secret-tool" && args[0] == "store":
+			fake.entries[account] = string(stdin)
+			return nil, nil
+		}
+		return nil, errors.New("unexpected command " + name)
+	}
+	return fake
+}
+
+func TestKeychainCipher_RoundTrip(t *testing.T) {
+	fake := installFakeKeychain(t)
+	c := &keychainCipher{goos: "linux", account: "work"}
+
+	sealed, err := c.Encrypt([]byte("token=secret"))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(sealed, keychainMagic))
+	assert.NotContains(t, string(sealed), "token=secret")
+	assert.Contains(t, fake.entries, "work")
+
+	// A new cipher reads the stored key back instead of creating another
+	reopened := &keychainCipher{goos: "linux", account: "work"}
+	plaintext, err := reopened.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "token=secret", string(plaintext))
+	assert.Len(t, fake.entries, 1)
+}
+
+func TestKeychainCipher_DecryptFailures(t *testing.T) {
+	installFakeKeychain(t)
+	c := &keychainCipher{goos: "linux", account: "work"}
+	sealed, err := c.Encrypt([]byte("token=secret"))
+	require.NoError(t, err)
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = c.Decrypt(tampered)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt credentials")
+
+	_, err = c.Decrypt([]byte("age-encryption.org/v1\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a keychain-encrypted file")
+
+	// Decrypting never creates a key
+	other := &keychainCipher{goos: "linux", account: "personal"}
+	_, err = other.Decrypt(sealed)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read the credentials key for account personal")
+}
+
+func TestKeychainCipher_LockedKeychain(t *testing.T) {
+	fake := installFakeKeychain(t)
+	sealed, err := (&keychainCipher{goos: "linux", account: "work"}).Encrypt([]byte("token=secret"))
+	require.NoError(t, err)
+	key := fake.entries["work"]
+
+	// A keychain that cannot be read does not get a new key, which would orphan the
+	// credentials sealed with the stored one
+	fake.locked = true
+	_, err = (&keychainCipher{goos: "linux", account: "work"}).Encrypt([]byte("token=other"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read the credentials key for account work")
+	assert.Contains(t, err.Error(), "D-Bus")
+	assert.Equal(t, key, fake.entries["work"])
+
+	fake.locked = false
+	plaintext, err := (&keychainCipher{goos: "linux", account: "work"}).Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "token=secret", string(plaintext))
+}
+
+func TestKeyNotFound(t *testing.T) {
+	assert.True(t, keyNotFound("darwin", &commandError{exitCode: 44}))
+	assert.False(t, keyNotFound("darwin", &commandError{exitCode: 36, stderr: "User interaction is not allowed."}))
+	assert.True(t, keyNotFound("linux", &commandError{exitCode: 1}))
+	assert.False(t, keyNotFound("linux", &commandError{exitCode: 1, stderr: "Cannot autolaunch D-Bus"}))
+	assert.False(t, keyNotFound("linux", errors.New("exec: secret-tool: not found")))
+}
+
+func TestKeychainCommands(t *testing.T) {
+	installFakeKeychain(t)
+
+	lookup, store, err := keychainCommands("darwin", "work")
+	require.NoError(t, err)
+	assert.Equal(t, "security find-generic-password -s reactor-credentials -a work -w", strings.Join(lookup, " "))
+	assert.Equal(t, "security add-generic-password -U -s reactor-credentials -a work -w", strings.Join(store, " "))
+
+	_, _, err = keychainCommands("windows", "work")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported on windows")
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	_, _, err = keychainCommands("linux", "work")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secret-tool not found")
+}
+
+func TestAgeCipher(t *testing.T) {
+	origRun, origLook := runCommand, lookPath
+	t.Cleanup(func() { runCommand, lookPath = origRun, origLook })
+
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	_, err := NewAge("identity")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "age not found")
+
+	var calls []string
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	runCommand = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		switch name {
+		case "age-keygen":
+			return nil, os.WriteFile(args[1], []byte("AGE-SECRET-KEY-1TEST\n"), 0644)
+		case "age":
+			// Reverse the input so the test can tell it went through the stub
+			out := make([]byte, len(stdin))
+			for i, b := range stdin {
+				out[len(stdin)-1-i] = b
+			}
+			return out, nil
+		}
+		return nil, errors.New("unexpected command " + name)
+	}
+
+	identity := filepath.Join(t.TempDir(), "work", "age-identity.txt")
+	c, err := NewAge(identity)
+	require.NoError(t, err)
+
+	sealed, err := c.Encrypt([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, "cba", string(sealed))
+	plaintext, err := c.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(plaintext))
+
+	info, err := os.Stat(identity)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, []string{
+		"age-keygen -o " + identity,
+		"age --encrypt --identity " + identity,
+		"age --decrypt --identity " + identity,
+	}, calls)
+}
+
+func TestWriteFileAndReadFile(t *testing.T) {
+	installFakeKeychain(t)
+	c := &keychainCipher{goos: "linux", account: "work"}
+	path := filepath.Join(t.TempDir(), "hash", "credentials.enc")
+
+	_, err := ReadFile(path, c)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, WriteFile(path, []byte("first"), c))
+	require.NoError(t, WriteFile(path, []byte("second"), c))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are cleaned up")
+
+	data, err := ReadFile(path, c)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+}
@@ -0,0 +1,215 @@
+package workspace
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FixWorkspaceData corrects common mistakes in a workspace file located at filePath:
+// a missing or misspelled version, service paths that are not written as clean
+// relative paths, and identical services whose names differ only by case. It returns
+// the corrected YAML and a description of each change; with no changes the data is
+// returned as it was. Comments are kept, but the file is re-indented.
+func FixWorkspaceData(data []byte, filePath string) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse workspace YAML: %w", err)
+	}
+	if err := checkNewerVersion(data); err != nil {
+		return nil, nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("workspace file must be a YAML mapping with 'version' and 'services'")
+	}
+	root := doc.Content[0]
+
+	workspaceDir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get absolute path for workspace directory: %w", err)
+	}
+
+	var fixes []string
+	fixes = append(fixes, fixVersion(root)...)
+	if services := mappingValue(root, "services"); services != nil && services.Kind == yaml.MappingNode {
+		fixes = append(fixes, fixServicePaths(services, workspaceDir)...)
+		fixes = append(fixes, fixCaseDuplicates(services)...)
+	}
+	if len(fixes) == 0 {
+		return data, nil, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to write workspace YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to write workspace YAML: %w", err)
+	}
+	return out.Bytes(), fixes, nil
+}
+
+// fixVersion adds a missing version and rewrites spellings such as "1.0" or "v1"
+// as CurrentVersion. A file that extends another inherits its version, so none is added.
+func fixVersion(root *yaml.Node) []string {
+	version := mappingValue(root, "version")
+	if version == nil {
+		if mappingValue(root, "extends") != nil {
+			return nil
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: CurrentVersion, Style: yaml.DoubleQuotedStyle}
+		// Keep a comment at the top of the file above the added line
+		if len(root.Content) > 0 {
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+		return []string{fmt.Sprintf("added version \"%s\"", CurrentVersion)}
+	}
+
+	if version.Kind != yaml.ScalarNode || version.Value == CurrentVersion {
+		return nil
+	}
+	if major, ok := majorVersion(version.Value); !ok || major != currentMajorVersion {
+		return nil
+	}
+	old := version.Value
+	version.Value, version.Tag, version.Style = CurrentVersion, "!!str", yaml.DoubleQuotedStyle
+	return []string{fmt.Sprintf("changed version '%s' to \"%s\"", old, CurrentVersion)}
+}
+
+// fixServicePaths rewrites service paths and env files as clean, forward-slash paths
+// relative to the workspace directory
+func fixServicePaths(services *yaml.Node, workspaceDir string) []string {
+	var fixes []string
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name, service := services.Content[i].Value, services.Content[i+1]
+		if service.Kind != yaml.MappingNode {
+			continue
+		}
+		for _, field := range []string{"path", "env_file"} {
+			value := mappingValue(service, field)
+			if value == nil || value.Kind != yaml.ScalarNode || value.Value == "" {
+				continue
+			}
+			normalized := normalizeServicePath(value.Value, workspaceDir)
+			if normalized == value.Value {
+				continue
+			}
+			fixes = append(fixes, fmt.Sprintf("service '%s': %s '%s' written as '%s'", name, field, value.Value, normalized))
+			value.Value = normalized
+		}
+	}
+	return fixes
+}
+
+// normalizeServicePath cleans a service path, e.g. "./api/" or "api\web" becomes "api"
+// or "api/web", and makes absolute paths inside the workspace directory relative.
+// Paths outside the workspace are only cleaned; validation reports them.
+func normalizeServicePath(p, workspaceDir string) string {
+	if filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(workspaceDir, filepath.Clean(p)); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.Clean(p)
+	}
+	return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// fixCaseDuplicates removes services whose names differ only by case from another
+// service with the same definition, keeping the lowercase name where there is one.
+// Services with different definitions are left for validation to report.
+func fixCaseDuplicates(services *yaml.Node) []string {
+	groups := make(map[string][]int)
+	var folded []string
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		key := strings.ToLower(services.Content[i].Value)
+		if _, ok := groups[key]; !ok {
+			folded = append(folded, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var fixes []string
+	remove := make(map[int]bool)
+	for _, key := range folded {
+		indexes := groups[key]
+		if len(indexes) < 2 || !sameDefinitions(services, indexes) {
+			continue
+		}
+		keep := indexes[0]
+		for _, i := range indexes {
+			if services.Content[i].Value == key {
+				keep = i
+				break
+			}
+		}
+		var removed []string
+		for _, i := range indexes {
+			if i != keep {
+				remove[i] = true
+				removed = append(removed, services.Content[i].Value)
+			}
+		}
+		sort.Strings(removed)
+		fixes = append(fixes, fmt.Sprintf("removed service %s, a duplicate of '%s'", quoteNames(removed), services.Content[keep].Value))
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+
+	content := make([]*yaml.Node, 0, len(services.Content))
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		if !remove[i] {
+			content = append(content, services.Content[i], services.Content[i+1])
+		}
+	}
+	services.Content = content
+	return fixes
+}
+
+// sameDefinitions reports whether the services at the given key indexes decode to the same Service
+func sameDefinitions(services *yaml.Node, indexes []int) bool {
+	var first Service
+	for n, i := range indexes {
+		var service Service
+		if err := services.Content[i+1].Decode(&service); err != nil {
+			return false
+		}
+		if n == 0 {
+			first = service
+			continue
+		}
+		a, errA := yaml.Marshal(first)
+		b, errB := yaml.Marshal(service)
+		if errA != nil || errB != nil || !bytes.Equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// mappingValue returns the value of key in a YAML mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
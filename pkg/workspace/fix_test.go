@@ -0,0 +1,75 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixWorkspaceData(t *testing.T) {
+	tmpDir := t.TempDir()
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "api"), 0755))
+
+	content := "# services of the shop\n" +
+		"services:\n" +
+		"  api:\n" +
+		"    path: ./api/ # REST backend\n" +
+		"  API:\n" +
+		"    path: " + filepath.Join(tmpDir, "api") + "\n" +
+		"  web:\n" +
+		"    path: 'frontend\\web'\n" +
+		"    env_file: ./web.env\n"
+
+	fixed, fixes, err := FixWorkspaceData([]byte(content), workspaceFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`added version "1"`,
+		"service 'api': path './api/' written as 'api'",
+		"service 'API': path '" + filepath.Join(tmpDir, "api") + "' written as 'api'",
+		`service 'web': path 'frontend\web' written as 'frontend/web'`,
+		"service 'web': env_file './web.env' written as 'web.env'",
+		"removed service 'API', a duplicate of 'api'",
+	}, fixes)
+	assert.Equal(t, "# services of the shop\n"+
+		"version: \"1\"\n"+
+		"services:\n"+
+		"  api:\n"+
+		"    path: api # REST backend\n"+
+		"  web:\n"+
+		"    path: 'frontend/web'\n"+
+		"    env_file: web.env\n", string(fixed))
+
+	// A fixed file needs no further fixes
+	again, fixes, err := FixWorkspaceData(fixed, workspaceFile)
+	require.NoError(t, err)
+	assert.Empty(t, fixes)
+	assert.Equal(t, fixed, again)
+}
+
+func TestFixWorkspaceData_Version(t *testing.T) {
+	fixed, fixes, err := FixWorkspaceData([]byte("version: v1.0\nservices: {}\n"), "reactor-workspace.yml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`changed version 'v1.0' to "1"`}, fixes)
+	assert.Equal(t, "version: \"1\"\nservices: {}\n", string(fixed))
+
+	// A file that extends another inherits its version
+	_, fixes, err = FixWorkspaceData([]byte("extends: base.yml\nservices: {}\n"), "reactor-workspace.yml")
+	require.NoError(t, err)
+	assert.Empty(t, fixes)
+
+	_, _, err = FixWorkspaceData([]byte("version: \"3\"\nservices: []\n"), "reactor-workspace.yml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upgrade reactor")
+}
+
+func TestFixWorkspaceData_DifferentCaseDuplicatesKept(t *testing.T) {
+	content := "version: \"1\"\nservices:\n  Api:\n    path: api\n  api:\n    path: api\n    account: work\n"
+	fixed, fixes, err := FixWorkspaceData([]byte(content), "reactor-workspace.yml")
+	require.NoError(t, err)
+	assert.Empty(t, fixes, "services with different definitions are left for the user to rename")
+	assert.Equal(t, content, string(fixed))
+}
@@ -1,8 +1,17 @@
 package workspace
 
+import "time"
+
 // Workspace defines the structure of the reactor-workspace.yml file.
 type Workspace struct {
-	Version  string             `yaml:"version"`
+	Version string `yaml:"version"`
+	Extends string `yaml:"extends,omitempty"`
+	// PortOffset is added to every host port the services publish, so several copies
+	// of the workspace can run side by side on one Docker host
+	PortOffset int `yaml:"port_offset,omitempty"`
+	// Volumes names the Docker volumes services share, e.g. an artifacts directory
+	// written by one service and read by another
+	Volumes  []string           `yaml:"volumes,omitempty"`
 	Services map[string]Service `yaml:"services"`
 }
 
@@ -10,4 +19,77 @@ type Workspace struct {
 type Service struct {
 	Path    string `yaml:"path"`
 	Account string `yaml:"account,omitempty"`
+	// EnvFile is a host dotenv file, relative to the workspace file, whose variables
+	// are passed to the service's container
+	EnvFile string `yaml:"env_file,omitempty"`
+	// RestartPolicy retries a service that fails to start during 'workspace up'
+	RestartPolicy *RestartPolicy `yaml:"restart_policy,omitempty"`
+	// OnDemand defers starting the service until a connection arrives on one of its
+	// forwarded ports; 'workspace up' listens on them in the foreground
+	OnDemand bool `yaml:"on_demand,omitempty"`
+	// DockerHost runs the service on another Docker daemon, e.g. "tcp://build-box:2376"
+	DockerHost string `yaml:"docker_host,omitempty"`
+	// Context runs the service on the daemon of a docker CLI context; it cannot be
+	// combined with DockerHost
+	Context string `yaml:"context,omitempty"`
+	// SharedPaths declares that the project and ~/.reactor exist at the same paths on
+	// the machine of a remote DockerHost or Context, e.g. on a shared home directory,
+	// so the service's bind mounts may be used there
+	SharedPaths bool `yaml:"shared_paths,omitempty"`
+	// WorkDir replaces the container's working directory, /workspace by default; it
+	// must be an absolute path in the container
+	WorkDir string `yaml:"workdir,omitempty"`
+	// Command replaces the container's command, including devcontainer.json's
+	// defaultCommand and an image command kept with overrideCommand: false. It is run
+	// with /bin/sh -c.
+	Command string `yaml:"command,omitempty"`
+	// Volumes mounts workspace volumes into the container, as "name:/target" with an
+	// optional ":ro"
+	Volumes []string `yaml:"volumes,omitempty"`
+	// MountsFrom mounts the volumes of another service at the same targets, as
+	// "service" or "service:ro"
+	MountsFrom string `yaml:"mounts_from,omitempty"`
+	// Platform runs the service's image for another platform, e.g. "linux/amd64" on an
+	// arm64 daemon, under emulation. 'workspace up' otherwise refuses images that are
+	// not published for the daemon's platform.
+	Platform string `yaml:"platform,omitempty"`
+}
+
+// RestartPolicy controls how often and how patiently a failed service start is retried
+type RestartPolicy struct {
+	// MaxAttempts is the number of retries after the first failed start
+	MaxAttempts int `yaml:"max_attempts"`
+	// Delay is the wait before the first retry (e.g. "5s"), doubled after each retry
+	Delay string `yaml:"delay,omitempty"`
+}
+
+// MaxPortOffset keeps offset host ports within the range of TCP ports
+const MaxPortOffset = 65534
+
+// DefaultRestartDelay is the wait before the first retry when restart_policy sets no delay
+const DefaultRestartDelay = 2 * time.Second
+
+// maxRestartDelay caps the doubling delay between retries
+const maxRestartDelay = time.Minute
+
+// Retries returns how many times a failed start of the service is retried
+func (s Service) Retries() int {
+	if s.RestartPolicy == nil {
+		return 0
+	}
+	return s.RestartPolicy.MaxAttempts
+}
+
+// RetryDelay returns the wait before retry n (starting at 1): the policy's delay,
+// doubled for each earlier retry and capped at a minute
+func (s Service) RetryDelay(n int) time.Duration {
+	delay := DefaultRestartDelay
+	if s.RestartPolicy != nil && s.RestartPolicy.Delay != "" {
+		// The delay is validated when the workspace file is parsed
+		delay, _ = time.ParseDuration(s.RestartPolicy.Delay)
+	}
+	for i := 1; i < n && delay < maxRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRestartDelay)
 }
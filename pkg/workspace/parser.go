@@ -3,16 +3,23 @@ package workspace
 import (
 	"crypto/sha256"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/dyluth/reactor/pkg/debug"
 	"gopkg.in/yaml.v3"
 )
 
 const (
 	workspaceFileYML  = "reactor-workspace.yml"
 	workspaceFileYAML = "reactor-workspace.yaml"
-	requiredVersion   = "1"
 )
 
 // FindWorkspaceFile looks for reactor-workspace.yml or reactor-workspace.yaml in the specified directory.
@@ -48,21 +55,36 @@ func FindWorkspaceFile(directory string) (string, bool, error) {
 }
 
 // ParseWorkspaceFile reads and parses a workspace file into a Workspace struct.
+// If the file extends another workspace file, the two are merged first.
 // It validates the version and ensures services are defined.
 func ParseWorkspaceFile(filePath string) (*Workspace, error) {
-	data, err := os.ReadFile(filePath)
+	workspace, err := loadWorkspaceFile(filePath, map[string]bool{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read workspace file: %w", err)
+		return nil, err
 	}
+	return validateWorkspace(workspace, filePath)
+}
 
-	var workspace Workspace
-	if err := yaml.Unmarshal(data, &workspace); err != nil {
-		return nil, fmt.Errorf("failed to parse workspace YAML: %w", err)
+// ParseWorkspaceData parses a workspace definition that was not read from filePath
+// itself, e.g. one piped on stdin. filePath is where the workspace is treated as
+// living: service paths and 'extends' are resolved relative to its directory.
+func ParseWorkspaceData(data []byte, filePath string) (*Workspace, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for workspace file %s: %w", filePath, err)
 	}
+	workspace, err := loadWorkspaceData(data, absPath, map[string]bool{absPath: true})
+	if err != nil {
+		return nil, err
+	}
+	return validateWorkspace(workspace, filePath)
+}
+
+// validateWorkspace checks the version and that every service path exists within the workspace directory
+func validateWorkspace(workspace *Workspace, filePath string) (*Workspace, error) {
 
-	// Validate version
-	if workspace.Version != requiredVersion {
-		return nil, fmt.Errorf("unsupported workspace version '%s', expected '%s'", workspace.Version, requiredVersion)
+	if err := checkVersion(workspace.Version); err != nil {
+		return nil, err
 	}
 
 	// Validate services map is not empty
@@ -70,6 +92,15 @@ func ParseWorkspaceFile(filePath string) (*Workspace, error) {
 		return nil, fmt.Errorf("workspace must define at least one service")
 	}
 
+	if workspace.PortOffset < 0 || workspace.PortOffset > MaxPortOffset {
+		return nil, fmt.Errorf("workspace port_offset %d must be between 0 and %d", workspace.PortOffset, MaxPortOffset)
+	}
+
+	// Service names become hostnames and CLI arguments, where case is easily lost
+	if a, b, found := caseDuplicateServices(workspace.Services); found {
+		return nil, fmt.Errorf("service names '%s' and '%s' differ only by case; rename one of them or run 'reactor workspace validate --fix' if they are the same service", a, b)
+	}
+
 	// Validate each service
 	workspaceDir := filepath.Dir(filePath)
 	for serviceName, service := range workspace.Services {
@@ -112,9 +143,240 @@ func ParseWorkspaceFile(filePath string) (*Workspace, error) {
 		} else if !info.IsDir() {
 			return nil, fmt.Errorf("service '%s' path '%s' is not a directory", serviceName, service.Path)
 		}
+
+		if service.EnvFile != "" {
+			envFile := service.EnvFile
+			if !filepath.IsAbs(envFile) {
+				envFile = filepath.Join(workspaceDir, envFile)
+			}
+			if _, err := os.Stat(envFile); err != nil {
+				if os.IsNotExist(err) {
+					return nil, fmt.Errorf("service '%s' env_file '%s' does not exist", serviceName, service.EnvFile)
+				}
+				return nil, fmt.Errorf("failed to check service '%s' env_file '%s': %w", serviceName, service.EnvFile, err)
+			}
+		}
+
+		if service.DockerHost != "" && service.Context != "" {
+			return nil, fmt.Errorf("service '%s' cannot set both docker_host and context", serviceName)
+		}
+		if service.DockerHost != "" {
+			if u, err := url.Parse(service.DockerHost); err != nil || !validDockerHostScheme(u.Scheme) {
+				return nil, fmt.Errorf("service '%s' docker_host '%s' must be a daemon address such as tcp://host:2376, ssh://user@host or unix:///path", serviceName, service.DockerHost)
+			}
+		}
+
+		if service.WorkDir != "" && !path.IsAbs(service.WorkDir) {
+			return nil, fmt.Errorf("service '%s' workdir '%s' must be an absolute path in the container", serviceName, service.WorkDir)
+		}
+
+		if service.Platform != "" && !platformPattern.MatchString(service.Platform) {
+			return nil, fmt.Errorf("service '%s' platform '%s' must be os/arch or os/arch/variant, e.g. linux/amd64", serviceName, service.Platform)
+		}
+
+		if policy := service.RestartPolicy; policy != nil {
+			if policy.MaxAttempts < 0 {
+				return nil, fmt.Errorf("service '%s' restart_policy max_attempts must not be negative", serviceName)
+			}
+			if policy.Delay != "" {
+				if delay, err := time.ParseDuration(policy.Delay); err != nil || delay < 0 {
+					return nil, fmt.Errorf("service '%s' restart_policy delay '%s' is not a valid duration (e.g. 5s)", serviceName, policy.Delay)
+				}
+			}
+		}
+	}
+
+	if err := validateVolumes(workspace); err != nil {
+		return nil, err
+	}
+
+	if debug.Enabled(debug.Workspace) {
+		names := make([]string, 0, len(workspace.Services))
+		for name := range workspace.Services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			service := workspace.Services[name]
+			debug.Logf(debug.Workspace, "service %s: path %s, account %q", name, service.Path, service.Account)
+		}
+	}
+	return workspace, nil
+}
+
+// caseDuplicateServices returns the first two service names, in sorted order, that
+// differ only by case
+func caseDuplicateServices(services map[string]Service) (string, string, bool) {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		folded := strings.ToLower(name)
+		if other, ok := seen[folded]; ok {
+			return other, name, true
+		}
+		seen[folded] = name
+	}
+	return "", "", false
+}
+
+// platformPattern matches an os/arch[/variant] platform such as linux/arm64
+var platformPattern = regexp.MustCompile(`^[a-z0-9_]+/[a-z0-9_-]+(/[a-z0-9]+)?$`)
+
+// validDockerHostScheme reports whether a docker_host scheme names a Docker daemon transport
+func validDockerHostScheme(scheme string) bool {
+	switch scheme {
+	case "unix", "tcp", "ssh", "npipe", "http", "https":
+		return true
+	}
+	return false
+}
+
+// loadWorkspaceFile reads a workspace file and resolves its 'extends' chain without validating it.
+// Service paths inherited from a base file are rebased so they stay relative to filePath.
+func loadWorkspaceFile(filePath string, seen map[string]bool) (*Workspace, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for workspace file %s: %w", filePath, err)
+	}
+	if seen[absPath] {
+		return nil, fmt.Errorf("workspace file %s extends itself (circular 'extends')", filePath)
+	}
+	seen[absPath] = true
+
+	debug.Logf(debug.Workspace, "reading %s", absPath)
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace file: %w", err)
+	}
+
+	return loadWorkspaceData(data, absPath, seen)
+}
+
+// loadWorkspaceData parses workspace YAML located at absPath and resolves its 'extends' chain
+func loadWorkspaceData(data []byte, absPath string, seen map[string]bool) (*Workspace, error) {
+	if err := checkNewerVersion(data); err != nil {
+		return nil, err
+	}
+
+	var workspace Workspace
+	if err := yaml.Unmarshal(data, &workspace); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace YAML: %w", err)
+	}
+
+	if workspace.Extends == "" {
+		return &workspace, nil
+	}
+
+	workspaceDir := filepath.Dir(absPath)
+	basePath := workspace.Extends
+	if !filepath.IsAbs(basePath) {
+		basePath = filepath.Join(workspaceDir, basePath)
+	}
+	debug.Logf(debug.Workspace, "%s extends %s", absPath, basePath)
+
+	base, err := loadWorkspaceFile(basePath, seen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extended workspace '%s': %w", workspace.Extends, err)
+	}
+
+	// Rebase the inherited service paths onto this file's directory
+	baseDir := filepath.Dir(basePath)
+	for name, service := range base.Services {
+		if service.Path != "" && !filepath.IsAbs(service.Path) && baseDir != workspaceDir {
+			rebased, err := filepath.Rel(workspaceDir, filepath.Join(baseDir, service.Path))
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve path for service '%s': %w", name, err)
+			}
+			service.Path = rebased
+		}
+		if service.EnvFile != "" && !filepath.IsAbs(service.EnvFile) && baseDir != workspaceDir {
+			rebased, err := filepath.Rel(workspaceDir, filepath.Join(baseDir, service.EnvFile))
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve env_file for service '%s': %w", name, err)
+			}
+			service.EnvFile = rebased
+		}
+		base.Services[name] = service
+	}
+
+	return mergeWorkspaces(base, &workspace), nil
+}
+
+// mergeWorkspaces layers override on top of base. Services are merged by name;
+// fields set in the override replace those of the base service.
+func mergeWorkspaces(base, override *Workspace) *Workspace {
+	merged := &Workspace{
+		Version:    base.Version,
+		Extends:    override.Extends,
+		PortOffset: base.PortOffset,
+		Services:   make(map[string]Service, len(base.Services)+len(override.Services)),
+	}
+	if override.Version != "" {
+		merged.Version = override.Version
+	}
+	if override.PortOffset != 0 {
+		merged.PortOffset = override.PortOffset
+	}
+	// An override may declare volumes of its own for the services it adds; a volume
+	// both declare is the same volume
+	merged.Volumes = append([]string(nil), base.Volumes...)
+	for _, volume := range override.Volumes {
+		if !slices.Contains(merged.Volumes, volume) {
+			merged.Volumes = append(merged.Volumes, volume)
+		}
+	}
+
+	for name, service := range base.Services {
+		merged.Services[name] = service
+	}
+	for name, service := range override.Services {
+		existing := merged.Services[name]
+		if service.Path != "" {
+			existing.Path = service.Path
+		}
+		if service.Account != "" {
+			existing.Account = service.Account
+		}
+		if service.EnvFile != "" {
+			existing.EnvFile = service.EnvFile
+		}
+		if service.RestartPolicy != nil {
+			existing.RestartPolicy = service.RestartPolicy
+		}
+		if service.OnDemand {
+			existing.OnDemand = true
+		}
+		if service.WorkDir != "" {
+			existing.WorkDir = service.WorkDir
+		}
+		if service.Command != "" {
+			existing.Command = service.Command
+		}
+		if len(service.Volumes) > 0 {
+			existing.Volumes = service.Volumes
+		}
+		if service.MountsFrom != "" {
+			existing.MountsFrom = service.MountsFrom
+		}
+		if service.Platform != "" {
+			existing.Platform = service.Platform
+		}
+		if service.SharedPaths {
+			existing.SharedPaths = true
+		}
+		// An override's endpoint replaces the base's, whichever way either names it
+		if service.DockerHost != "" || service.Context != "" {
+			existing.DockerHost = service.DockerHost
+			existing.Context = service.Context
+		}
+		merged.Services[name] = existing
 	}
 
-	return &workspace, nil
+	return merged
 }
 
 // GenerateWorkspaceHash creates a SHA256 hash of the canonical, absolute path of the workspace file.
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -280,6 +281,27 @@ services:
 		}
 	})
 
+	t.Run("MissingEnvFile", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "services", "api"), 0755))
+
+		workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+		require.NoError(t, os.WriteFile(workspaceFile, []byte(`version: "1"
+services:
+  api:
+    path: ./services/api
+    env_file: api.env`), 0644))
+
+		_, err := ParseWorkspaceFile(workspaceFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "service 'api' env_file 'api.env' does not exist")
+
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "api.env"), []byte("A=1\n"), 0644))
+		ws, err := ParseWorkspaceFile(workspaceFile)
+		require.NoError(t, err)
+		assert.Equal(t, "api.env", ws.Services["api"].EnvFile)
+	})
+
 	t.Run("InvalidYAML", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "workspace-test-*")
 		require.NoError(t, err)
@@ -304,6 +326,323 @@ services:
 	})
 }
 
+func TestParseWorkspaceFile_RestartPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "db"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+
+	write := func(policy string) {
+		content := "version: \"1\"\nservices:\n  db:\n    path: ./db\n" + policy
+		require.NoError(t, os.WriteFile(workspaceFile, []byte(content), 0644))
+	}
+
+	write("    on_demand: true\n    restart_policy:\n      max_attempts: 3\n      delay: 5s\n")
+	ws, err := ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	db := ws.Services["db"]
+	assert.True(t, db.OnDemand)
+	assert.Equal(t, 3, db.Retries())
+	assert.Equal(t, 5*time.Second, db.RetryDelay(1))
+	assert.Equal(t, 20*time.Second, db.RetryDelay(3))
+	assert.Equal(t, time.Minute, db.RetryDelay(10))
+
+	assert.Equal(t, 0, Service{}.Retries())
+	assert.Equal(t, DefaultRestartDelay, Service{}.RetryDelay(1))
+
+	write("    restart_policy:\n      max_attempts: -1\n")
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'db' restart_policy max_attempts must not be negative")
+
+	write("    restart_policy:\n      max_attempts: 2\n      delay: soon\n")
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'db' restart_policy delay 'soon' is not a valid duration")
+}
+
+func TestParseWorkspaceFile_DockerHost(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "db"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+
+	write := func(endpoint string) {
+		content := "version: \"1\"\nservices:\n  db:\n    path: ./db\n" + endpoint
+		require.NoError(t, os.WriteFile(workspaceFile, []byte(content), 0644))
+	}
+
+	write("    docker_host: tcp://build-box:2376\n")
+	ws, err := ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	assert.Equal(t, "tcp://build-box:2376", ws.Services["db"].DockerHost)
+	assert.False(t, ws.Services["db"].SharedPaths)
+
+	write("    docker_host: tcp://build-box:2376\n    shared_paths: true\n")
+	ws, err = ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	assert.True(t, ws.Services["db"].SharedPaths)
+
+	write("    context: build-box\n")
+	ws, err = ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	assert.Equal(t, "build-box", ws.Services["db"].Context)
+
+	write("    docker_host: build-box\n")
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'db' docker_host 'build-box' must be a daemon address")
+
+	write("    docker_host: tcp://build-box:2376\n    context: build-box\n")
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'db' cannot set both docker_host and context")
+}
+
+func TestParseWorkspaceFile_WorkDirAndCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "api"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+
+	write := func(overrides string) {
+		content := "version: \"1\"\nservices:\n  api:\n    path: ./api\n" + overrides
+		require.NoError(t, os.WriteFile(workspaceFile, []byte(content), 0644))
+	}
+
+	write("    workdir: /workspace/services/api\n    command: npm run dev\n")
+	ws, err := ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	assert.Equal(t, "/workspace/services/api", ws.Services["api"].WorkDir)
+	assert.Equal(t, "npm run dev", ws.Services["api"].Command)
+
+	write("    workdir: services/api\n")
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'api' workdir 'services/api' must be an absolute path in the container")
+}
+
+func TestParseWorkspaceFile_PortOffset(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "api"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+	require.NoError(t, os.WriteFile(workspaceFile, []byte("version: \"1\"\nport_offset: 100\nservices:\n  api:\n    path: ./api\n"), 0644))
+
+	ws, err := ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	assert.Equal(t, 100, ws.PortOffset)
+
+	// A developer's local override shifts the ports of their copy
+	overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+	require.NoError(t, os.WriteFile(overrideFile, []byte("extends: reactor-workspace.yml\nport_offset: 200\n"), 0644))
+	ws, err = ParseWorkspaceFile(overrideFile)
+	require.NoError(t, err)
+	assert.Equal(t, 200, ws.PortOffset)
+
+	require.NoError(t, os.WriteFile(workspaceFile, []byte("version: \"1\"\nport_offset: -1\nservices:\n  api:\n    path: ./api\n"), 0644))
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "workspace port_offset -1 must be between 0 and 65534")
+}
+
+func TestParseWorkspaceFile_Platform(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "api"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+	require.NoError(t, os.WriteFile(workspaceFile, []byte("version: \"1\"\nservices:\n  api:\n    path: ./api\n    platform: linux/amd64\n"), 0644))
+
+	ws, err := ParseWorkspaceFile(workspaceFile)
+	require.NoError(t, err)
+	assert.Equal(t, "linux/amd64", ws.Services["api"].Platform)
+
+	require.NoError(t, os.WriteFile(workspaceFile, []byte("version: \"1\"\nservices:\n  api:\n    path: ./api\n    platform: amd64\n"), 0644))
+	_, err = ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'api' platform 'amd64' must be os/arch or os/arch/variant")
+}
+
+func TestParseWorkspaceFile_Version(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "db"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+
+	tests := []struct {
+		content string
+		wantErr string
+	}{
+		{"version: \"1\"\nservices:\n  db:\n    path: ./db\n", ""},
+		{"version: 1\nservices:\n  db:\n    path: ./db\n", ""},
+		{"services:\n  db:\n    path: ./db\n", "workspace file has no version; add 'version: \"1\"'"},
+		{"version: \"1.0\"\nservices:\n  db:\n    path: ./db\n", "run 'reactor workspace validate --fix' to correct it"},
+		{"version: latest\nservices:\n  db:\n    path: ./db\n", "unsupported workspace version 'latest', expected '1'"},
+		// A newer schema is reported before its unknown layout fails to decode
+		{"version: 2\nservices:\n  - name: db\n    build: ./db\n", "newer workspace schema than this reactor understands, upgrade reactor"},
+	}
+	for _, tt := range tests {
+		require.NoError(t, os.WriteFile(workspaceFile, []byte(tt.content), 0644))
+		_, err := ParseWorkspaceFile(workspaceFile)
+		if tt.wantErr == "" {
+			assert.NoError(t, err, tt.content)
+			continue
+		}
+		if assert.Error(t, err, tt.content) {
+			assert.Contains(t, err.Error(), tt.wantErr)
+		}
+	}
+}
+
+func TestParseWorkspaceFile_CaseDuplicateServices(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "api"), 0755))
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+	content := "version: \"1\"\nservices:\n  api:\n    path: ./api\n  API:\n    path: ./api\n"
+	require.NoError(t, os.WriteFile(workspaceFile, []byte(content), 0644))
+
+	_, err := ParseWorkspaceFile(workspaceFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service names 'API' and 'api' differ only by case")
+}
+
+func TestParseWorkspaceFile_Extends(t *testing.T) {
+	newWorkspaceDir := func(t *testing.T) string {
+		tmpDir := t.TempDir()
+		for _, dir := range []string{"services/api", "services/frontend", "services/worker", "local/api"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0755))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "reactor-workspace.yml"), []byte(`version: "1"
+services:
+  api:
+    path: ./services/api
+    account: team-account
+  frontend:
+    path: ./services/frontend`), 0644))
+		return tmpDir
+	}
+
+	t.Run("MergesServicesAndSettings", func(t *testing.T) {
+		tmpDir := newWorkspaceDir(t)
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: reactor-workspace.yml
+services:
+  api:
+    account: personal-account
+  worker:
+    path: ./services/worker`), 0644))
+
+		ws, err := ParseWorkspaceFile(overrideFile)
+		require.NoError(t, err)
+
+		assert.Equal(t, "1", ws.Version)
+		assert.Len(t, ws.Services, 3)
+		assert.Equal(t, Service{Path: "./services/api", Account: "personal-account"}, ws.Services["api"])
+		assert.Equal(t, Service{Path: "./services/frontend"}, ws.Services["frontend"])
+		assert.Equal(t, Service{Path: "./services/worker"}, ws.Services["worker"])
+	})
+
+	t.Run("MergesVolumesByName", func(t *testing.T) {
+		tmpDir := newWorkspaceDir(t)
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "reactor-workspace.yml"), []byte(`version: "1"
+volumes: [artifacts, cache]
+services:
+  api:
+    path: ./services/api
+    volumes: ["artifacts:/artifacts"]`), 0644))
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: reactor-workspace.yml
+volumes: [cache, logs]
+services:
+  worker:
+    path: ./services/worker
+    volumes: ["logs:/logs"]`), 0644))
+
+		ws, err := ParseWorkspaceFile(overrideFile)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"artifacts", "cache", "logs"}, ws.Volumes)
+	})
+
+	t.Run("OverrideReplacesPath", func(t *testing.T) {
+		tmpDir := newWorkspaceDir(t)
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: ./reactor-workspace.yml
+services:
+  api:
+    path: ./local/api`), 0644))
+
+		ws, err := ParseWorkspaceFile(overrideFile)
+		require.NoError(t, err)
+		assert.Equal(t, Service{Path: "./local/api", Account: "team-account"}, ws.Services["api"])
+	})
+
+	t.Run("OverrideReplacesDockerHost", func(t *testing.T) {
+		tmpDir := newWorkspaceDir(t)
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "reactor-workspace.yml"), []byte(`version: "1"
+services:
+  api:
+    path: ./services/api
+    docker_host: tcp://build-box:2376`), 0644))
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: reactor-workspace.yml
+services:
+  api:
+    context: my-laptop`), 0644))
+
+		ws, err := ParseWorkspaceFile(overrideFile)
+		require.NoError(t, err)
+		assert.Equal(t, Service{Path: "./services/api", Context: "my-laptop"}, ws.Services["api"])
+	})
+
+	t.Run("RebasesInheritedPaths", func(t *testing.T) {
+		tmpDir := newWorkspaceDir(t)
+		sharedDir := filepath.Join(tmpDir, "shared")
+		require.NoError(t, os.MkdirAll(sharedDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(sharedDir, "team.yml"), []byte(`version: "1"
+services:
+  api:
+    path: ../services/api`), 0644))
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: shared/team.yml`), 0644))
+
+		ws, err := ParseWorkspaceFile(overrideFile)
+		require.NoError(t, err)
+		assert.Equal(t, "services/api", ws.Services["api"].Path)
+	})
+
+	t.Run("RebasesInheritedEnvFile", func(t *testing.T) {
+		tmpDir := newWorkspaceDir(t)
+		sharedDir := filepath.Join(tmpDir, "shared")
+		require.NoError(t, os.MkdirAll(sharedDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(sharedDir, "api.env"), []byte("LOG_LEVEL=debug\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(sharedDir, "team.yml"), []byte(`version: "1"
+services:
+  api:
+    path: ../services/api
+    env_file: api.env`), 0644))
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: shared/team.yml`), 0644))
+
+		ws, err := ParseWorkspaceFile(overrideFile)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("shared", "api.env"), ws.Services["api"].EnvFile)
+	})
+
+	t.Run("CircularExtends", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.yml"), []byte(`extends: b.yml`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "b.yml"), []byte(`extends: a.yml`), 0644))
+
+		_, err := ParseWorkspaceFile(filepath.Join(tmpDir, "a.yml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "circular 'extends'")
+	})
+
+	t.Run("MissingBaseFile", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		overrideFile := filepath.Join(tmpDir, "reactor-workspace.local.yml")
+		require.NoError(t, os.WriteFile(overrideFile, []byte(`extends: missing.yml`), 0644))
+
+		_, err := ParseWorkspaceFile(overrideFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load extended workspace 'missing.yml'")
+	})
+}
+
 func TestGenerateWorkspaceHash(t *testing.T) {
 	t.Run("ConsistentHashGeneration", func(t *testing.T) {
 		tmpDir, err := os.MkdirTemp("", "workspace-test-*")
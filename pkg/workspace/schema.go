@@ -0,0 +1,71 @@
+package workspace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the workspace schema version this reactor understands and writes
+const CurrentVersion = "1"
+
+// currentMajorVersion is CurrentVersion as a number, for comparing against newer files
+const currentMajorVersion = 1
+
+// checkVersion validates the schema version of a workspace file. Files of a newer
+// schema get an upgrade hint, and spellings of the current version such as "1.0" or
+// "v1" point to 'workspace validate --fix'.
+func checkVersion(version string) error {
+	if version == CurrentVersion {
+		return nil
+	}
+	if version == "" {
+		return fmt.Errorf("workspace file has no version; add 'version: \"%s\"' or run 'reactor workspace validate --fix'", CurrentVersion)
+	}
+
+	major, ok := majorVersion(version)
+	switch {
+	case !ok || major < currentMajorVersion:
+		return fmt.Errorf("unsupported workspace version '%s', expected '%s'", version, CurrentVersion)
+	case major > currentMajorVersion:
+		return newerVersionError(version)
+	}
+	return fmt.Errorf("unsupported workspace version '%s', expected '%s'; run 'reactor workspace validate --fix' to correct it", version, CurrentVersion)
+}
+
+// checkNewerVersion peeks at the version of workspace YAML before it is decoded, so a
+// file written for a newer schema is reported as such rather than as a decoding error
+func checkNewerVersion(data []byte) error {
+	var header struct {
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil // reported by the full decode
+	}
+	if major, ok := majorVersion(header.Version); ok && major > currentMajorVersion {
+		return newerVersionError(header.Version)
+	}
+	return nil
+}
+
+func newerVersionError(version string) error {
+	return fmt.Errorf("unsupported workspace version '%s', expected '%s': the file uses a newer workspace schema than this reactor understands, upgrade reactor to use it", version, CurrentVersion)
+}
+
+// majorVersion returns the major number of a version such as "1", "1.0" or "v2"
+func majorVersion(version string) (int, bool) {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	majorPart, minorPart, hasMinor := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorPart)
+	if err != nil || major < 0 {
+		return 0, false
+	}
+	if hasMinor {
+		if _, err := strconv.Atoi(minorPart); err != nil {
+			return 0, false
+		}
+	}
+	return major, true
+}
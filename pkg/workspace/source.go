@@ -0,0 +1,120 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dyluth/reactor/pkg/debug"
+)
+
+// StdinSource is the workspace file argument that reads the workspace from stdin
+const StdinSource = "-"
+
+// maxSourceSize bounds a workspace definition read from stdin or a URL
+const maxSourceSize = 1 << 20
+
+// httpClient fetches remote workspace files; a var so tests can replace it
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// IsStreamSource reports whether a workspace file argument names stdin or a URL
+// rather than a file on disk
+func IsStreamSource(source string) bool {
+	return source == StdinSource || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// SourcePath returns the path a workspace read from stdin or a URL is treated as
+// living at. It is in dir, so service paths resolve relative to it, and it is stable
+// for a given source, so the workspace instance hash is the same on every run.
+func SourcePath(source, dir string) string {
+	if source == StdinSource {
+		return filepath.Join(dir, "reactor-workspace.stdin.yml")
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, fmt.Sprintf("reactor-workspace.%x.yml", sum[:6]))
+}
+
+// ReadSource reads a workspace definition from stdin or fetches it over HTTPS. When
+// checksum is set ("sha256:<hex>" or a bare hex digest) the content must match it.
+func ReadSource(source string, stdin io.Reader, checksum string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch {
+	case source == StdinSource:
+		data, err = readLimited(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read workspace from stdin: %w", err)
+		}
+	case strings.HasPrefix(source, "https://"):
+		data, err = fetchSource(source)
+		if err != nil {
+			return nil, err
+		}
+	case strings.HasPrefix(source, "http://"):
+		return nil, fmt.Errorf("refusing to fetch workspace over plain HTTP: %s (use https://)", source)
+	default:
+		return nil, fmt.Errorf("unsupported workspace source '%s'", source)
+	}
+
+	if checksum != "" {
+		if err := VerifyChecksum(data, checksum); err != nil {
+			return nil, fmt.Errorf("workspace from %s: %w", describeSource(source), err)
+		}
+		debug.Logf(debug.Workspace, "checksum of workspace from %s verified", describeSource(source))
+	}
+	return data, nil
+}
+
+// VerifyChecksum checks data against a "sha256:<hex>" or bare hex SHA-256 digest
+func VerifyChecksum(data []byte, checksum string) error {
+	expected := strings.ToLower(strings.TrimPrefix(checksum, "sha256:"))
+	if len(expected) != sha256.Size*2 {
+		return fmt.Errorf("invalid checksum '%s': expected sha256:<64 hex characters>", checksum)
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch: expected sha256:%s, got sha256:%s", expected, actual)
+	}
+	return nil
+}
+
+func fetchSource(url string) ([]byte, error) {
+	debug.Logf(debug.Workspace, "fetching %s", url)
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace from %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch workspace from %s: %s", url, resp.Status)
+	}
+	data, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace from %s: %w", url, err)
+	}
+	return data, nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSourceSize {
+		return nil, fmt.Errorf("workspace definition exceeds %d bytes", maxSourceSize)
+	}
+	return data, nil
+}
+
+func describeSource(source string) string {
+	if source == StdinSource {
+		return "stdin"
+	}
+	return source
+}
@@ -0,0 +1,98 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sourceWorkspace = `version: "1"
+services:
+  api:
+    path: ./api
+`
+
+func TestReadSource_Stdin(t *testing.T) {
+	data, err := ReadSource(StdinSource, strings.NewReader(sourceWorkspace), "")
+	require.NoError(t, err)
+	assert.Equal(t, sourceWorkspace, string(data))
+
+	sum := sha256.Sum256([]byte(sourceWorkspace))
+	_, err = ReadSource(StdinSource, strings.NewReader(sourceWorkspace), fmt.Sprintf("sha256:%x", sum))
+	assert.NoError(t, err)
+
+	_, err = ReadSource(StdinSource, strings.NewReader(sourceWorkspace+"\n"), fmt.Sprintf("sha256:%x", sum))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "workspace from stdin: checksum mismatch")
+
+	_, err = ReadSource(StdinSource, strings.NewReader(strings.Repeat("x", maxSourceSize+1)), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds")
+}
+
+func TestReadSource_URL(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/reactor-workspace.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(sourceWorkspace))
+	}))
+	defer server.Close()
+
+	originalClient := httpClient
+	httpClient = server.Client()
+	defer func() { httpClient = originalClient }()
+
+	data, err := ReadSource(server.URL+"/reactor-workspace.yml", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, sourceWorkspace, string(data))
+
+	_, err = ReadSource(server.URL+"/missing.yml", nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404 Not Found")
+
+	_, err = ReadSource("http://example.com/reactor-workspace.yml", nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refusing to fetch workspace over plain HTTP")
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("data"))
+	assert.NoError(t, VerifyChecksum([]byte("data"), fmt.Sprintf("%x", sum)))
+	assert.NoError(t, VerifyChecksum([]byte("data"), fmt.Sprintf("sha256:%X", sum)))
+
+	err := VerifyChecksum([]byte("data"), "sha256:abc")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid checksum")
+}
+
+func TestSourcePath(t *testing.T) {
+	assert.Equal(t, "/work/reactor-workspace.stdin.yml", SourcePath(StdinSource, "/work"))
+
+	urlPath := SourcePath("https://example.com/ws.yml", "/work")
+	assert.Equal(t, "/work", filepath.Dir(urlPath))
+	assert.Equal(t, urlPath, SourcePath("https://example.com/ws.yml", "/work"), "path is stable for a source")
+	assert.NotEqual(t, urlPath, SourcePath("https://example.com/other.yml", "/work"))
+}
+
+func TestParseWorkspaceData(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api"), 0755))
+
+	ws, err := ParseWorkspaceData([]byte(sourceWorkspace), SourcePath(StdinSource, dir))
+	require.NoError(t, err)
+	assert.Equal(t, "./api", ws.Services["api"].Path)
+
+	_, err = ParseWorkspaceData([]byte("version: \"1\"\nservices:\n  web:\n    path: ./web\n"), SourcePath(StdinSource, dir))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'web' path './web' does not exist")
+}
@@ -0,0 +1,147 @@
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// VolumeMount is a workspace volume mounted into a service's container
+type VolumeMount struct {
+	Volume   string // the name in the workspace file's volumes
+	Target   string // absolute path in the container
+	ReadOnly bool
+}
+
+// volumeNamePattern restricts workspace volume names to characters valid in Docker volume names
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// VolumeName returns the Docker volume backing a workspace volume. Each workspace
+// instance gets its own volumes, so two checkouts do not share artifacts.
+func VolumeName(workspaceHash, volume string) string {
+	if len(workspaceHash) > 12 {
+		workspaceHash = workspaceHash[:12]
+	}
+	return fmt.Sprintf("reactor-ws-%s-%s", workspaceHash, volume)
+}
+
+// ServiceVolumes returns the workspace volumes mounted into a service: those of the
+// service named by its mounts_from, then its own. The workspace must be valid.
+func (w *Workspace) ServiceVolumes(name string) []VolumeMount {
+	service := w.Services[name]
+	var mounts []VolumeMount
+	if service.MountsFrom != "" {
+		from, readOnly := parseMountsFrom(service.MountsFrom)
+		for _, spec := range w.Services[from].Volumes {
+			m, _ := parseVolumeMount(spec)
+			m.ReadOnly = m.ReadOnly || readOnly
+			mounts = append(mounts, m)
+		}
+	}
+	for _, spec := range service.Volumes {
+		m, _ := parseVolumeMount(spec)
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
+
+// VolumeUsers returns the services that mount each workspace volume, sorted
+func (w *Workspace) VolumeUsers() map[string][]string {
+	users := make(map[string][]string)
+	for name := range w.Services {
+		for _, m := range w.ServiceVolumes(name) {
+			users[m.Volume] = append(users[m.Volume], name)
+		}
+	}
+	for volume, names := range users {
+		sort.Strings(names)
+		users[volume] = slices.Compact(names)
+	}
+	return users
+}
+
+// parseVolumeMount parses a service's "name:/target" volume entry, with an optional ":ro"
+func parseVolumeMount(spec string) (VolumeMount, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return VolumeMount{}, fmt.Errorf("volume '%s' must be name:/target or name:/target:ro", spec)
+	}
+	m := VolumeMount{Volume: parts[0], Target: parts[1]}
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "ro":
+			m.ReadOnly = true
+		case "rw":
+		default:
+			return VolumeMount{}, fmt.Errorf("volume '%s' has unknown mode '%s': use ro or rw", spec, parts[2])
+		}
+	}
+	if !path.IsAbs(m.Target) {
+		return VolumeMount{}, fmt.Errorf("volume '%s' target must be an absolute container path", spec)
+	}
+	m.Target = path.Clean(m.Target)
+	if m.Target == "/workspace" {
+		return VolumeMount{}, fmt.Errorf("volume '%s' cannot replace the project mount at /workspace", spec)
+	}
+	return m, nil
+}
+
+// parseMountsFrom splits a mounts_from value into the service and whether it is read-only
+func parseMountsFrom(spec string) (string, bool) {
+	from, mode, _ := strings.Cut(spec, ":")
+	return from, mode == "ro"
+}
+
+// validateVolumes checks the workspace volumes and the services' volumes and mounts_from
+func validateVolumes(w *Workspace) error {
+	declared := make(map[string]bool, len(w.Volumes))
+	for _, name := range w.Volumes {
+		if !volumeNamePattern.MatchString(name) {
+			return fmt.Errorf("workspace volume '%s' must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", name)
+		}
+		if declared[name] {
+			return fmt.Errorf("workspace volume '%s' is declared twice", name)
+		}
+		declared[name] = true
+	}
+
+	for serviceName, service := range w.Services {
+		for _, spec := range service.Volumes {
+			m, err := parseVolumeMount(spec)
+			if err != nil {
+				return fmt.Errorf("service '%s' %w", serviceName, err)
+			}
+			if !declared[m.Volume] {
+				return fmt.Errorf("service '%s' volume '%s' is not declared in the workspace volumes", serviceName, m.Volume)
+			}
+		}
+
+		if service.MountsFrom != "" {
+			from, mode, hasMode := strings.Cut(service.MountsFrom, ":")
+			if hasMode && mode != "ro" && mode != "rw" {
+				return fmt.Errorf("service '%s' mounts_from '%s' has unknown mode '%s': use ro or rw", serviceName, service.MountsFrom, mode)
+			}
+			if from == serviceName {
+				return fmt.Errorf("service '%s' cannot mount volumes from itself", serviceName)
+			}
+			if _, ok := w.Services[from]; !ok {
+				return fmt.Errorf("service '%s' mounts_from '%s' is not a service in the workspace", serviceName, from)
+			}
+		}
+	}
+
+	// Every entry parses now, so the mounts of each service can be combined
+	for serviceName := range w.Services {
+		targets := make(map[string]bool)
+		for _, m := range w.ServiceVolumes(serviceName) {
+			if targets[m.Target] {
+				return fmt.Errorf("service '%s' mounts two volumes at %s", serviceName, m.Target)
+			}
+			targets[m.Target] = true
+		}
+	}
+	return nil
+}
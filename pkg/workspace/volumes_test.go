@@ -0,0 +1,65 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkspaceFile_Volumes(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"api", "worker"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0755))
+	}
+	workspaceFile := filepath.Join(tmpDir, "reactor-workspace.yml")
+	parse := func(content string) (*Workspace, error) {
+		require.NoError(t, os.WriteFile(workspaceFile, []byte("version: \"1\"\n"+content), 0644))
+		return ParseWorkspaceFile(workspaceFile)
+	}
+
+	ws, err := parse(`volumes: [artifacts, cache]
+services:
+  api:
+    path: ./api
+    volumes: ["artifacts:/artifacts", "cache:/cache/"]
+  worker:
+    path: ./worker
+    mounts_from: api:ro
+    volumes: ["cache:/var/cache:rw"]
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []VolumeMount{
+		{Volume: "artifacts", Target: "/artifacts"},
+		{Volume: "cache", Target: "/cache"},
+	}, ws.ServiceVolumes("api"))
+	assert.Equal(t, []VolumeMount{
+		{Volume: "artifacts", Target: "/artifacts", ReadOnly: true},
+		{Volume: "cache", Target: "/cache", ReadOnly: true},
+		{Volume: "cache", Target: "/var/cache"},
+	}, ws.ServiceVolumes("worker"))
+	assert.Equal(t, map[string][]string{"artifacts": {"api", "worker"}, "cache": {"api", "worker"}}, ws.VolumeUsers())
+
+	invalid := map[string]string{
+		"volumes: [artifacts]\nservices:\n  api:\n    path: ./api\n    volumes: [\"logs:/logs\"]\n":                                                                          "service 'api' volume 'logs' is not declared in the workspace volumes",
+		"volumes: [artifacts]\nservices:\n  api:\n    path: ./api\n    volumes: [\"artifacts:artifacts\"]\n":                                                                 "service 'api' volume 'artifacts:artifacts' target must be an absolute container path",
+		"volumes: [artifacts]\nservices:\n  api:\n    path: ./api\n    volumes: [\"artifacts:/workspace\"]\n":                                                                "cannot replace the project mount at /workspace",
+		"volumes: [artifacts]\nservices:\n  api:\n    path: ./api\n    volumes: [\"artifacts:/a:rx\"]\n":                                                                     "has unknown mode 'rx'",
+		"volumes: [-bad]\nservices:\n  api:\n    path: ./api\n":                                                                                                              "workspace volume '-bad' must start with a letter or digit",
+		"volumes: [a, a]\nservices:\n  api:\n    path: ./api\n":                                                                                                              "workspace volume 'a' is declared twice",
+		"services:\n  api:\n    path: ./api\n    mounts_from: api\n":                                                                                                         "service 'api' cannot mount volumes from itself",
+		"services:\n  api:\n    path: ./api\n    mounts_from: db\n":                                                                                                          "service 'api' mounts_from 'db' is not a service in the workspace",
+		"volumes: [a, b]\nservices:\n  api:\n    path: ./api\n    volumes: [\"a:/data\"]\n  worker:\n    path: ./worker\n    mounts_from: api\n    volumes: [\"b:/data\"]\n": "service 'worker' mounts two volumes at /data",
+	}
+	for content, expected := range invalid {
+		_, err := parse(content)
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestVolumeName(t *testing.T) {
+	assert.Equal(t, "reactor-ws-0123456789ab-artifacts", VolumeName("0123456789abcdef0123", "artifacts"))
+}